@@ -0,0 +1,14 @@
+#!/bin/bash
+
+# uninstalls the agent from a container, the agent keeps running until the next version is installed
+
+echo "Uninstalling Amazon-ssm-agent from container"
+
+if rpm -q amazon-ssm-agent > /dev/null 2>&1; then
+	echo "-> Agent is installed in this container"
+	echo "Uninstalling the agent"
+	rpm --erase amazon-ssm-agent
+	sleep 1
+else
+	echo "-> Agent is not installed in this container"
+fi
@@ -0,0 +1,49 @@
+#!/bin/bash
+
+# installs the agent in a container, no service manager runs in the container so the running
+# agent is stopped after the installation and the container supervisor is expected to start it again
+
+# helper function to set error output
+function error_exit
+{
+	echo "$1" 1>&2
+	exit 1
+}
+
+# stop_agent sends SIGTERM to the agent processes of the container
+function stop_agent
+{
+	for comm in /proc/[0-9]*/comm; do
+		if [[ "$(cat "$comm" 2> /dev/null)" == "amazon-ssm-agent" ]]; then
+			pid=$(basename "$(dirname "$comm")")
+			echo "Stopping agent process $pid"
+			kill -TERM "$pid" 2> /dev/null
+		fi
+	done
+}
+
+# check parameters for registering managed instance
+DO_REGISTER=false
+if [ "$1" == "register-managed-instance" ]; then
+	if [ $# -eq 4 ]; then
+		DO_REGISTER=true
+		RMI_CODE=$2
+		RMI_ID=$3
+		RMI_REGION=$4
+	else
+		error_exit '[ERROR] Not enough parameters for RegisterManagedInstance.'
+	fi
+fi
+
+# allow ssm-agent to finish it's work
+sleep 2
+
+echo "Installing agent in container"
+dpkg -i amazon-ssm-agent.deb || error_exit '[ERROR] Failed to install the agent package.'
+
+if [ "$DO_REGISTER" = true ]; then
+	amazon-ssm-agent -register -code "$RMI_CODE" -id "$RMI_ID" -region "$RMI_REGION"
+fi
+
+echo "Restarting agent"
+stop_agent
@@ -32,6 +32,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/hibernation"
 	logger "github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
+	"github.com/aws/amazon-ssm-agent/agent/safemode"
 	"github.com/aws/amazon-ssm-agent/agent/session/utility"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
 )
@@ -43,12 +44,14 @@ const (
 	registerFlag            = "register"
 	fingerprintFlag         = "fingerprint"
 	similarityThresholdFlag = "similarityThreshold"
+	diagnoseFlag            = "diagnose"
 )
 
 var (
 	instanceIDPtr, regionPtr             *string
 	activationCode, activationID, region string
 	register, clear, force, fpFlag       bool
+	diagnose                             bool
 	similarityThreshold                  int
 	registrationFile                     = filepath.Join(appconfig.DefaultDataStorePath, "registration")
 )
@@ -59,8 +62,22 @@ func start(log logger.T, instanceIDPtr *string, regionPtr *string, shouldCheckHi
 		log.Debugf("appconfig could not be loaded - %v", err)
 		return
 	}
+	logConfigDiagnostics(log)
 	context := context.Default(log, config)
 
+	// start in safe mode when the agent keeps crashing on startup, the condition is reported as agent status
+	if safemode.RecordStart(log) {
+		log.Warnf("Agent started more than %v times within %v without running for %v, starting in safe mode: "+
+			"persisted documents are not restored and only essential plugins are loaded",
+			safemode.MaxUnstableStarts, safemode.CrashLoopWindow, safemode.StableDuration)
+		health.ReportIssue(safemode.HealthComponent, safemode.HealthStatus)
+	}
+
+	// reload the configuration on SIGHUP, or when the file changes on Windows, without restarting the agent
+	if _, watcherErr := appconfig.StartWatcher(); watcherErr != nil {
+		log.Warnf("Failed to watch the config, changes require an agent restart. %v", watcherErr)
+	}
+
 	//Reset password for default RunAs user if already exists
 	sessionUtil := &utility.SessionUtil{}
 	if err := sessionUtil.ResetPasswordIfDefaultUserExists(context); err != nil {
@@ -88,6 +105,17 @@ func start(log logger.T, instanceIDPtr *string, regionPtr *string, shouldCheckHi
 	return
 }
 
+// logConfigDiagnostics logs the settings of the configuration that differ from the defaults and the rejected settings
+func logConfigDiagnostics(log logger.T) {
+	diagnostics := appconfig.LastDiagnostics()
+	for _, setting := range diagnostics.Overridden {
+		log.Infof("Config: %v", setting)
+	}
+	for _, setting := range diagnostics.Invalid {
+		log.Warnf("Invalid config: %v", setting)
+	}
+}
+
 func startAgent(ssmAgent agent.ISSMAgent, context context.T, log logger.T, instanceIDPtr *string, regionPtr *string) (err error) {
 	cloudwatchPublisher := &cloudwatchlogspublisher.CloudWatchPublisher{}
 	coreModules := coremodules.RegisteredCoreModules(context)
@@ -100,6 +128,7 @@ func startAgent(ssmAgent agent.ISSMAgent, context context.T, log logger.T, insta
 	}
 	ssmAgent.SetCoreManager(cpm)
 
+	verifyUpdate(context)
 	ssmAgent.Start()
 	return
 }
@@ -140,4 +169,5 @@ func run(log logger.T) {
 	}
 	blockUntilSignaled(log)
 	agent.Stop()
+	safemode.RecordStable(log)
 }
@@ -19,11 +19,12 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/diagnostics"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/fingerprint"
 	logger "github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/managedInstances/registration"
@@ -55,6 +56,9 @@ func parseFlags(log logger.T) {
 	// force flag
 	flag.BoolVar(&force, "y", false, "")
 
+	// self-diagnostics
+	flag.BoolVar(&diagnose, diagnoseFlag, false, "")
+
 	flag.Parse()
 
 	if flag.NFlag() > 0 {
@@ -63,6 +67,8 @@ func parseFlags(log logger.T) {
 			exitCode = processRegistration(log)
 		} else if fpFlag {
 			exitCode = processFingerprint(log)
+		} else if diagnose {
+			exitCode = processDiagnostics(log)
 		} else {
 			flagUsage()
 		}
@@ -81,6 +87,8 @@ func flagUsage() {
 	fmt.Fprintln(os.Stderr, "\t\t-region\tSSM region       \t(REQUIRED)")
 	fmt.Fprintln(os.Stderr, "\n\t\t-clear\tClears the previously saved SSM registration")
 	fmt.Fprintln(os.Stderr, "\n\t-y\tAnswer yes for all questions")
+	fmt.Fprintln(os.Stderr, "\n\t-diagnose\tcheck the connectivity, permissions, clock, proxy, disk space and health of the agent")
+	fmt.Fprintln(os.Stderr, "\t\t\tand print the results as json")
 }
 
 // processRegistration handles flags related to the registration category
@@ -128,6 +136,19 @@ func processFingerprint(log logger.T) (exitCode int) {
 	return 0
 }
 
+// processDiagnostics runs the self-diagnostics and prints their report, it fails if a check fails
+func processDiagnostics(log logger.T) (exitCode int) {
+	report := diagnostics.Run(log)
+	if err := report.Write(os.Stdout); err != nil {
+		log.Errorf("Failed to write the diagnostics report. %v", err)
+		return 1
+	}
+	if report.Status == diagnostics.StatusFail {
+		return 1
+	}
+	return 0
+}
+
 // registerManagedInstance checks for activation credentials and performs managed instance registration when present
 func registerManagedInstance() (managedInstanceID string, err error) {
 	// try to activate the instance with the activation credentials
@@ -178,7 +199,7 @@ func registerManagedInstance() (managedInstanceID string, err error) {
 		return "", fmt.Errorf("Failed to marshal registration info. %v", err)
 	}
 
-	if err = ioutil.WriteFile(registrationFile, regData, appconfig.ReadWriteAccess); err != nil {
+	if err = fileutil.WriteFileAtomic(registrationFile, regData, appconfig.ReadWriteAccess); err != nil {
 		return "", fmt.Errorf("Failed to write registration info to file. %v", err)
 	}
 
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log/ssmlog"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
 	"github.com/aws/amazon-ssm-agent/agent/proxyconfig"
+	"github.com/aws/amazon-ssm-agent/agent/safemode"
 	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
@@ -213,5 +214,6 @@ loop:
 	}
 	s <- svc.Status{State: svc.StopPending}
 	agent.Stop()
+	safemode.RecordStable(log)
 	return false, appconfig.SuccessExitCode
 }
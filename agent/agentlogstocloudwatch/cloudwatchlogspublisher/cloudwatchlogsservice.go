@@ -41,6 +41,9 @@ const (
 	NewLineCharacter         = "\n"
 	maxNumberOfEventsPerCall = 4
 
+	// cloudWatchLogsServiceName is the name of the CloudWatch Logs service in its endpoints
+	cloudWatchLogsServiceName = "logs"
+
 	// Event size - https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/cloudwatch_limits_cwl.html
 	MessageLengthThresholdInBytes = 200 * 1000
 )
@@ -77,6 +80,12 @@ func createCloudWatchClientWithConfig(config *aws.Config) cloudwatchlogsinterfac
 		NumMaxRetries: maxRetries,
 	})
 
+	if config.Endpoint == nil && config.Region != nil {
+		if defaultEndpoint := appconfig.GetDefaultEndPoint(*config.Region, cloudWatchLogsServiceName); defaultEndpoint != "" {
+			config.Endpoint = &defaultEndpoint
+		}
+	}
+
 	appConfig, _ := appconfig.Config(false)
 	sess := session.New(config)
 	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
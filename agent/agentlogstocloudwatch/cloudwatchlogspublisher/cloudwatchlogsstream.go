@@ -0,0 +1,218 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// cloudwatchlogspublisher is responsible for pulling logs from the log queue and publishing them to cloudwatch
+
+package cloudwatchlogspublisher
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+const (
+	// Batch limits of PutLogEvents - https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+	maxEventsPerBatch   = 10000
+	maxBytesPerBatch    = 1024 * 1024
+	eventOverheadBytes  = 26
+	maxBufferedEvents   = 50000
+	maxFinalFlushFailed = 3
+)
+
+// StreamWriter uploads the lines written to it to a log stream while they are written.
+// Every line is an event with the time it was written at, the events are uploaded every flush interval.
+type StreamWriter struct {
+	service       *CloudWatchLogsService
+	log           log.T
+	logGroupName  string
+	logStreamName string
+	flushInterval time.Duration
+
+	mutex   sync.Mutex
+	partial []byte
+	events  []*cloudwatchlogs.InputLogEvent
+	dropped int
+
+	streamCreated bool
+	sequenceToken *string
+	stop          chan struct{}
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewStreamWriter creates a writer streaming to the log stream of the log group, the log stream is created on the first upload.
+// Close must be called to upload the end of the output.
+func (service *CloudWatchLogsService) NewStreamWriter(log log.T, logGroupName string, logStreamName string, flushInterval time.Duration) *StreamWriter {
+	if flushInterval <= 0 {
+		flushInterval = UploadFrequency
+	}
+	writer := &StreamWriter{
+		service:       service,
+		log:           log,
+		logGroupName:  logGroupName,
+		logStreamName: logStreamName,
+		flushInterval: flushInterval,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go writer.run()
+	return writer
+}
+
+// Write buffers the complete lines of p as events, it doesn't fail when CloudWatch is unavailable.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		index := bytes.IndexByte(w.partial, '\n')
+		if index < 0 {
+			break
+		}
+		w.addEvent(bytes.TrimSuffix(w.partial[:index], []byte("\r")))
+		w.partial = w.partial[index+1:]
+	}
+	if len(w.partial) >= MessageLengthThresholdInBytes {
+		w.addEvent(w.partial)
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+// Close uploads the buffered output and stops the uploads
+func (w *StreamWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mutex.Lock()
+		if len(w.partial) > 0 {
+			w.addEvent(w.partial)
+			w.partial = nil
+		}
+		w.mutex.Unlock()
+
+		close(w.stop)
+		<-w.done
+	})
+	return nil
+}
+
+// addEvent adds a line, split in messages CloudWatch accepts, the oldest events are dropped when CloudWatch is unavailable
+func (w *StreamWriter) addEvent(line []byte) {
+	timestamp := aws.Int64(time.Now().UnixNano() / int64(time.Millisecond))
+	for {
+		message := line
+		if len(message) > MessageLengthThresholdInBytes {
+			message = message[:MessageLengthThresholdInBytes]
+		}
+		w.events = append(w.events, &cloudwatchlogs.InputLogEvent{
+			Message:   aws.String(string(message)),
+			Timestamp: timestamp,
+		})
+		line = line[len(message):]
+		if len(line) == 0 {
+			break
+		}
+	}
+	if len(w.events) > maxBufferedEvents {
+		w.dropped += len(w.events) - maxBufferedEvents
+		w.events = w.events[len(w.events)-maxBufferedEvents:]
+	}
+}
+
+// run uploads the events every flush interval until the writer is closed
+func (w *StreamWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.stop:
+			for failed := 0; failed < maxFinalFlushFailed; {
+				if uploaded, pending := w.flush(); !uploaded {
+					failed++
+				} else if !pending {
+					break
+				}
+			}
+			w.mutex.Lock()
+			if len(w.events) > 0 {
+				w.log.Warnf("Failed to upload %v output lines to CloudWatch log stream %v", len(w.events), w.logStreamName)
+			}
+			w.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// flush uploads one batch of events, it returns whether the upload succeeded and events are still pending
+func (w *StreamWriter) flush() (uploaded bool, pending bool) {
+	w.mutex.Lock()
+	if w.dropped > 0 {
+		w.log.Warnf("Dropped %v output lines not uploaded to CloudWatch log stream %v", w.dropped, w.logStreamName)
+		w.dropped = 0
+	}
+	batch := nextBatch(w.events)
+	w.mutex.Unlock()
+
+	if len(batch) == 0 {
+		return true, false
+	}
+
+	if !w.streamCreated {
+		if err := w.service.CreateLogStream(w.log, w.logGroupName, w.logStreamName); err != nil {
+			return false, true
+		}
+		w.streamCreated = true
+		w.sequenceToken = w.service.GetSequenceTokenForStream(w.log, w.logGroupName, w.logStreamName)
+	}
+
+	nextSequenceToken, err := w.service.PutLogEvents(w.log, batch, w.logGroupName, w.logStreamName, w.sequenceToken)
+	if err != nil {
+		w.log.Debugf("Failed to upload output to CloudWatch log stream %v: %v", w.logStreamName, err)
+		w.sequenceToken = w.service.GetSequenceTokenForStream(w.log, w.logGroupName, w.logStreamName)
+		return false, true
+	}
+	w.sequenceToken = nextSequenceToken
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// the oldest events may have been dropped while uploading, remove what is left of the batch
+	last := batch[len(batch)-1]
+	for i, event := range w.events {
+		if event == last {
+			w.events = w.events[i+1:]
+			break
+		}
+	}
+	return true, len(w.events) > 0
+}
+
+// nextBatch returns the first events within the limits of a PutLogEvents call
+func nextBatch(events []*cloudwatchlogs.InputLogEvent) []*cloudwatchlogs.InputLogEvent {
+	size := 0
+	for i, event := range events {
+		size += len(*event.Message) + eventOverheadBytes
+		if i == maxEventsPerBatch || size > maxBytesPerBatch {
+			return events[:i]
+		}
+	}
+	return events
+}
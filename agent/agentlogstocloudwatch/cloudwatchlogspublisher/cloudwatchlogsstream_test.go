@@ -0,0 +1,135 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// cloudwatchlogspublisher is responsible for pulling logs from the log queue and publishing them to cloudwatch
+
+package cloudwatchlogspublisher
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cloudwatchlogspublisher_mock "github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher/mock"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// uploadRecorder records the messages of the PutLogEvents calls
+type uploadRecorder struct {
+	mutex   sync.Mutex
+	batches [][]string
+}
+
+func (r *uploadRecorder) record(input *cloudwatchlogs.PutLogEventsInput) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var messages []string
+	for _, event := range input.LogEvents {
+		messages = append(messages, *event.Message)
+	}
+	r.batches = append(r.batches, messages)
+	return true
+}
+
+func (r *uploadRecorder) messages() (messages []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, batch := range r.batches {
+		messages = append(messages, batch...)
+	}
+	return
+}
+
+func newStreamTestService(recorder *uploadRecorder, putErr error) (*CloudWatchLogsService, *cloudwatchlogspublisher_mock.CloudWatchLogsClientMock) {
+	client := cloudwatchlogspublisher_mock.NewClientMockDefault()
+	client.On("CreateLogStream", mock.Anything).Return(&cloudwatchlogs.CreateLogStreamOutput{}, nil)
+	client.On("DescribeLogStreams", mock.Anything).Return(&cloudwatchlogs.DescribeLogStreamsOutput{
+		LogStreams: []*cloudwatchlogs.LogStream{{LogStreamName: aws.String("LogStream")}},
+	}, nil)
+	client.On("PutLogEvents", mock.MatchedBy(recorder.record)).Return(&cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String("token")}, putErr)
+	service := &CloudWatchLogsService{
+		cloudWatchLogsClient: client,
+		stopPolicy:           sdkutil.NewStopPolicy("Test", 100),
+	}
+	return service, client
+}
+
+func TestStreamWriter_UploadsLinesOnClose(t *testing.T) {
+	recorder := &uploadRecorder{}
+	service, client := newStreamTestService(recorder, nil)
+
+	writer := service.NewStreamWriter(logMock, "LogGroup", "LogStream", time.Hour)
+	writer.Write([]byte("first line\r\nsecond "))
+	writer.Write([]byte("line\nincomplete"))
+	writer.Close()
+
+	assert.Equal(t, []string{"first line", "second line", "incomplete"}, recorder.messages())
+	client.AssertNumberOfCalls(t, "CreateLogStream", 1)
+}
+
+func TestStreamWriter_UploadsWhileWriting(t *testing.T) {
+	recorder := &uploadRecorder{}
+	service, _ := newStreamTestService(recorder, nil)
+
+	writer := service.NewStreamWriter(logMock, "LogGroup", "LogStream", 10*time.Millisecond)
+	defer writer.Close()
+	writer.Write([]byte("first line\nincomplete"))
+
+	for start := time.Now(); len(recorder.messages()) == 0 && time.Since(start) < 5*time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"first line"}, recorder.messages())
+}
+
+func TestStreamWriter_KeepsEventsWhenUploadFails(t *testing.T) {
+	recorder := &uploadRecorder{}
+	service, client := newStreamTestService(recorder, errors.New("unavailable"))
+
+	writer := service.NewStreamWriter(logMock, "LogGroup", "LogStream", time.Hour)
+	writer.Write([]byte("line\n"))
+	writer.Close()
+
+	client.AssertNumberOfCalls(t, "PutLogEvents", maxFinalFlushFailed)
+	assert.Len(t, writer.events, 1)
+}
+
+func TestStreamWriter_SplitsLongLines(t *testing.T) {
+	recorder := &uploadRecorder{}
+	service, _ := newStreamTestService(recorder, nil)
+
+	writer := service.NewStreamWriter(logMock, "LogGroup", "LogStream", time.Hour)
+	writer.Write([]byte(strings.Repeat("a", MessageLengthThresholdInBytes+10) + "\n"))
+	writer.Close()
+
+	messages := recorder.messages()
+	assert.Len(t, messages, 2)
+	assert.Len(t, messages[0], MessageLengthThresholdInBytes)
+	assert.Len(t, messages[1], 10)
+}
+
+func TestNextBatch(t *testing.T) {
+	var events []*cloudwatchlogs.InputLogEvent
+	for i := 0; i < 6; i++ {
+		events = append(events, &cloudwatchlogs.InputLogEvent{Message: aws.String(strings.Repeat("a", MessageLengthThresholdInBytes))})
+	}
+
+	assert.Len(t, nextBatch(events), maxBytesPerBatch/(MessageLengthThresholdInBytes+eventOverheadBytes))
+	assert.Len(t, nextBatch(events[:2]), 2)
+	assert.Empty(t, nextBatch(nil))
+}
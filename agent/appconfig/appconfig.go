@@ -36,21 +36,32 @@ func Config(reload bool) (SsmagentConfig, error) {
 	if reload || !isLoaded() {
 		var agentConfig SsmagentConfig
 		agentConfig = DefaultConfig()
+		diagnostics := newDiagnosticsBuilder()
 		path, pathErr := getAppConfigPath()
-		if pathErr != nil {
-			return agentConfig, nil
+		if pathErr == nil {
+			// Process config override
+			fmt.Printf("Applying config override from %s.\n", path)
+
+			if err := jsonutil.UnmarshalFile(path, &agentConfig); err != nil {
+				fmt.Println("Failed to unmarshal config override. Fall back to default.")
+				return agentConfig, err
+			}
+			diagnostics.checkFile(path)
 		}
-
-		// Process config override
-		fmt.Printf("Applying config override from %s.\n", path)
-
-		if err := jsonutil.UnmarshalFile(path, &agentConfig); err != nil {
-			fmt.Println("Failed to unmarshal config override. Fall back to default.")
-			return agentConfig, err
+		// Apply the instance tag and environment variable overrides on top of the file
+		if overridden := applyOverrides(&agentConfig, diagnostics); pathErr != nil && !overridden {
+			cacheDiagnostics(Diagnostics{Invalid: diagnostics.invalid})
+			return agentConfig, nil
 		}
 		agentConfig.Os.Name = runtime.GOOS
 		agentConfig.Agent.Version = version.Version
+		unvalidatedConfig := agentConfig
 		parser(&agentConfig)
+
+		defaultConfig := DefaultConfig()
+		defaultConfig.Os.Name = runtime.GOOS
+		defaultConfig.Agent.Version = version.Version
+		cacheDiagnostics(diagnostics.build(unvalidatedConfig, agentConfig, defaultConfig))
 		cache(agentConfig)
 	}
 	return getCached(), nil
@@ -105,9 +116,11 @@ func DefaultConfig() SsmagentConfig {
 	}
 	var s3 S3Cfg
 	var mds = MdsCfg{
-		CommandWorkersLimit: DefaultCommandWorkersLimit,
-		StopTimeoutMillis:   DefaultStopTimeoutMillis,
-		CommandRetryLimit:   DefaultCommandRetryLimit,
+		CommandWorkersLimit:     DefaultCommandWorkersLimit,
+		StopTimeoutMillis:       DefaultStopTimeoutMillis,
+		CommandRetryLimit:       DefaultCommandRetryLimit,
+		MaxIdlePollDelaySeconds: DefaultMaxIdlePollDelaySeconds,
+		ReplyBatchWindowMillis:  DefaultReplyBatchWindowMillis,
 	}
 	var mgs = MgsConfig{
 		SessionWorkersLimit: DefaultSessionWorkersLimit,
@@ -121,6 +134,13 @@ func DefaultConfig() SsmagentConfig {
 		AssociationLogsRetentionDurationHours: DefaultAssociationLogsRetentionDurationHours,
 		RunCommandLogsRetentionDurationHours:  DefaultRunCommandLogsRetentionDurationHours,
 		SessionLogsRetentionDurationHours:     DefaultSessionLogsRetentionDurationHours,
+		CloudWatchOutputFlushIntervalSeconds:  DefaultCloudWatchOutputFlushIntervalSeconds,
+		OutputTruncationStrategy:              OutputTruncationHead,
+		ParallelStepsLimit:                    DefaultParallelStepsLimit,
+		CustomInventoryCollectorsLocation:     DefaultCustomInventoryCollectorsFolder,
+		AssociationCatchUpPolicy:              AssociationCatchUpRunImmediately,
+		AssociationCatchUpWindowHours:         DefaultAssociationCatchUpWindowHours,
+		AssociationWorkersLimit:               DefaultAssociationWorkersLimit,
 	}
 	var agent = AgentInfo{
 		Name:                 "amazon-ssm-agent",
@@ -131,6 +151,20 @@ func DefaultConfig() SsmagentConfig {
 		Version: "1",
 	}
 	var birdwatcher BirdwatcherCfg
+	var update = UpdateCfg{
+		VerificationTimeoutMinutes: DefaultUpdateVerificationTimeoutMinutes,
+		ArtifactRetentionCount:     DefaultUpdateArtifactRetentionCount,
+	}
+
+	var download DownloadCfg
+	var endpoints EndpointCfg
+	var health = HealthCfg{
+		EndpointPort: DefaultHealthEndpointPort,
+	}
+	var localIpc LocalIpcCfg
+	var policy = PolicyCfg{
+		HookTimeoutSeconds: DefaultPolicyHookTimeoutSeconds,
+	}
 
 	var ssmagentCfg = SsmagentConfig{
 		Profile:     credsProfile,
@@ -141,6 +175,12 @@ func DefaultConfig() SsmagentConfig {
 		Os:          os,
 		S3:          s3,
 		Birdwatcher: birdwatcher,
+		Update:      update,
+		Download:    download,
+		Endpoints:   endpoints,
+		Health:      health,
+		LocalIpc:    localIpc,
+		Policy:      policy,
 	}
 
 	return ssmagentCfg
@@ -29,6 +29,18 @@ func parser(config *SsmagentConfig) {
 	config.Agent.OrchestrationRootDir = getStringValue(config.Agent.OrchestrationRootDir, defaultOrchestrationRootDirName)
 	config.Agent.Region = getStringValue(config.Agent.Region, "")
 
+	// Log config
+	config.Log.MaxFileSizeMB = getNumericValue(
+		config.Log.MaxFileSizeMB,
+		0,
+		DefaultLogMaxFileSizeMBMax,
+		0)
+	config.Log.MaxRotatedFiles = getNumericValue(
+		config.Log.MaxRotatedFiles,
+		0,
+		DefaultLogMaxRotatedFilesMax,
+		0)
+
 	// MDS config
 	config.Mds.CommandWorkersLimit = getNumericValue(
 		config.Mds.CommandWorkersLimit,
@@ -45,6 +57,16 @@ func parser(config *SsmagentConfig) {
 		DefaultStopTimeoutMillisMin,
 		DefaultStopTimeoutMillisMax,
 		DefaultStopTimeoutMillis)
+	config.Mds.MaxIdlePollDelaySeconds = getNumericValue(
+		config.Mds.MaxIdlePollDelaySeconds,
+		DefaultMaxIdlePollDelaySecondsMin,
+		DefaultMaxIdlePollDelaySecondsMax,
+		DefaultMaxIdlePollDelaySeconds)
+	config.Mds.ReplyBatchWindowMillis = getNumericValue(
+		config.Mds.ReplyBatchWindowMillis,
+		DefaultReplyBatchWindowMillisMin,
+		DefaultReplyBatchWindowMillisMax,
+		DefaultReplyBatchWindowMillis)
 	config.Mds.Endpoint = getStringValue(config.Mds.Endpoint, "")
 
 	// SSM config
@@ -67,20 +89,89 @@ func parser(config *SsmagentConfig) {
 		config.Ssm.RunCommandLogsRetentionDurationHours,
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
 		DefaultRunCommandLogsRetentionDurationHours)
+	config.Ssm.CloudWatchOutputFlushIntervalSeconds = getNumericValue(
+		config.Ssm.CloudWatchOutputFlushIntervalSeconds,
+		DefaultCloudWatchOutputFlushIntervalSecondsMin,
+		DefaultCloudWatchOutputFlushIntervalSecondsMax,
+		DefaultCloudWatchOutputFlushIntervalSeconds)
+	config.Ssm.OutputTruncationStrategy = getEnumValue(
+		config.Ssm.OutputTruncationStrategy,
+		[]string{OutputTruncationHead, OutputTruncationTail, OutputTruncationHeadAndTail},
+		OutputTruncationHead)
+	config.Ssm.ParallelStepsLimit = getNumericValue(
+		config.Ssm.ParallelStepsLimit,
+		DefaultParallelStepsLimitMin,
+		DefaultParallelStepsLimitMax,
+		DefaultParallelStepsLimit)
+	config.Ssm.CustomInventoryCollectorsLocation = getStringValue(
+		config.Ssm.CustomInventoryCollectorsLocation,
+		DefaultCustomInventoryCollectorsFolder)
+	config.Ssm.AssociationScheduleTimezone = getStringValue(config.Ssm.AssociationScheduleTimezone, "")
+	config.Ssm.AssociationJitterSeconds = getNumericValue(
+		config.Ssm.AssociationJitterSeconds,
+		0,
+		DefaultAssociationJitterSecondsMax,
+		0)
+	config.Ssm.AssociationCatchUpPolicy = getEnumValue(
+		config.Ssm.AssociationCatchUpPolicy,
+		[]string{AssociationCatchUpRunImmediately, AssociationCatchUpSkip, AssociationCatchUpRunWithinWindow},
+		AssociationCatchUpRunImmediately)
+	config.Ssm.AssociationCatchUpWindowHours = getNumericValue(
+		config.Ssm.AssociationCatchUpWindowHours,
+		DefaultAssociationCatchUpWindowHoursMin,
+		DefaultAssociationCatchUpWindowHoursMax,
+		DefaultAssociationCatchUpWindowHours)
+	config.Ssm.AssociationWorkersLimit = getNumericValue(
+		config.Ssm.AssociationWorkersLimit,
+		DefaultAssociationWorkersLimitMin,
+		DefaultAssociationWorkersLimitMax,
+		DefaultAssociationWorkersLimit)
 
-}
+	// Update config
+	config.Update.VerificationTimeoutMinutes = getNumericValue(
+		config.Update.VerificationTimeoutMinutes,
+		DefaultUpdateVerificationTimeoutMinutesMin,
+		DefaultUpdateVerificationTimeoutMinutesMax,
+		DefaultUpdateVerificationTimeoutMinutes)
+	config.Update.ManifestURL = getStringValue(config.Update.ManifestURL, "")
+	config.Update.ArtifactBaseURL = getStringValue(config.Update.ArtifactBaseURL, "")
+	config.Update.MinimumVersion = getStringValue(config.Update.MinimumVersion, "")
+	config.Update.ArtifactRetentionCount = getNumericValue(
+		config.Update.ArtifactRetentionCount,
+		DefaultUpdateArtifactRetentionCountMin,
+		DefaultUpdateArtifactRetentionCountMax,
+		DefaultUpdateArtifactRetentionCount)
+	config.Update.ArtifactMaxDiskUsageMB = getNumericValue(
+		config.Update.ArtifactMaxDiskUsageMB,
+		0,
+		DefaultUpdateArtifactMaxDiskUsageMBMax,
+		0)
 
-// TODO https://sim.amazon.com/issues/SSM-3439
-// getDefaultEndPoint returns the default endpoint for a service, it should be empty unless it's a china region
-func GetDefaultEndPoint(region string, service string) string {
-	endpoint := ""
+	// Download config
+	config.Download.MaxConcurrentDownloads = getNumericValue(
+		config.Download.MaxConcurrentDownloads,
+		0,
+		DefaultMaxConcurrentDownloadsMax,
+		0)
+	config.Download.MaxBytesPerSecond = getNumericValue(
+		config.Download.MaxBytesPerSecond,
+		0,
+		DefaultMaxBytesPerSecondMax,
+		0)
 
-	parts := strings.Split(region, "-")
-	if len(parts) > 1 && parts[0] == "cn" {
-		endpoint = service + "." + region + ".amazonaws.com.cn"
-	}
+	// Policy config
+	config.Policy.HookTimeoutSeconds = getNumericValue(
+		config.Policy.HookTimeoutSeconds,
+		DefaultPolicyHookTimeoutSecondsMin,
+		DefaultPolicyHookTimeoutSecondsMax,
+		DefaultPolicyHookTimeoutSeconds)
 
-	return endpoint
+	// Health config
+	config.Health.EndpointPort = getNumericValue(
+		config.Health.EndpointPort,
+		DefaultHealthEndpointPortMin,
+		DefaultHealthEndpointPortMax,
+		DefaultHealthEndpointPort)
 }
 
 // getStringValue returns the default value if config is empty, else the config value
@@ -91,6 +182,16 @@ func getStringValue(configValue string, defaultValue string) string {
 	return configValue
 }
 
+// getEnumValue returns the allowed value matching configValue, ignoring case, or defaultValue when none matches
+func getEnumValue(configValue string, allowedValues []string, defaultValue string) string {
+	for _, allowedValue := range allowedValues {
+		if strings.EqualFold(configValue, allowedValue) {
+			return allowedValue
+		}
+	}
+	return defaultValue
+}
+
 // getNumericValueAboveMin returns the default if config is below minimum
 func getNumericValueAboveMin(configValue int, minValue int, defaultValue int) int {
 	if configValue < minValue {
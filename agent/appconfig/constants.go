@@ -31,6 +31,14 @@ const (
 	DefaultStopTimeoutMillisMin = 10000
 	DefaultStopTimeoutMillisMax = 1000000
 
+	DefaultMaxIdlePollDelaySeconds    = 30
+	DefaultMaxIdlePollDelaySecondsMin = 0
+	DefaultMaxIdlePollDelaySecondsMax = 300
+
+	DefaultReplyBatchWindowMillis    = 1000
+	DefaultReplyBatchWindowMillisMin = 0
+	DefaultReplyBatchWindowMillisMax = 10000
+
 	// SSM defaults
 	DefaultSsmHealthFrequencyMinutes    = 5
 	DefaultSsmHealthFrequencyMinutesMin = 5
@@ -40,6 +48,33 @@ const (
 	DefaultSsmAssociationFrequencyMinutesMin = 5
 	DefaultSsmAssociationFrequencyMinutesMax = 60
 
+	// Update defaults
+	DefaultUpdateVerificationTimeoutMinutes    = 5
+	DefaultUpdateVerificationTimeoutMinutesMin = 1
+	DefaultUpdateVerificationTimeoutMinutesMax = 60
+	DefaultUpdateArtifactRetentionCount        = 3
+	DefaultUpdateArtifactRetentionCountMin     = 1
+	DefaultUpdateArtifactRetentionCountMax     = 100
+	DefaultUpdateArtifactMaxDiskUsageMBMax     = 102400
+
+	// Download limits, 0 doesn't limit the downloads
+	DefaultMaxConcurrentDownloadsMax = 100
+	DefaultMaxBytesPerSecondMax      = 1 << 30
+
+	// Local policy hook defaults
+	DefaultPolicyHookTimeoutSeconds    = 30
+	DefaultPolicyHookTimeoutSecondsMin = 1
+	DefaultPolicyHookTimeoutSecondsMax = 600
+
+	// Health endpoint defaults, the endpoint only listens on the loopback interface
+	DefaultHealthEndpointPort    = 8790
+	DefaultHealthEndpointPortMin = 1
+	DefaultHealthEndpointPortMax = 65535
+
+	//aws-ssm-agent log rotation constants, 0 keeps the settings of the seelog configurations
+	DefaultLogMaxFileSizeMBMax   = 1024
+	DefaultLogMaxRotatedFilesMax = 100
+
 	//aws-ssm-agent bookkeeping constants
 	DefaultLocationOfPending     = "pending"
 	DefaultLocationOfCurrent     = "current"
@@ -47,6 +82,7 @@ const (
 	DefaultLocationOfCorrupt     = "corrupt"
 	DefaultLocationOfState       = "state"
 	DefaultLocationOfAssociation = "association"
+	DefaultLocationOfQueued      = "queued"
 
 	//aws-ssm-agent state and orchestration logs duration for Run Command and Association
 	DefaultAssociationLogsRetentionDurationHours           = 24  // 1 day default retention
@@ -54,6 +90,38 @@ const (
 	DefaultSessionLogsRetentionDurationHours               = 336 // 14 days default retention
 	DefaultStateOrchestrationLogsRetentionDurationHoursMin = 8   // Min retention of 8hrs as some processes may not timeout before this and don't want logs to be deleted before the process completes
 
+	//aws-ssm-agent truncation strategies of the command output exceeding the size of the replies
+	OutputTruncationHead        = "Head"
+	OutputTruncationTail        = "Tail"
+	OutputTruncationHeadAndTail = "HeadAndTail"
+
+	//aws-ssm-agent interval of the uploads of the command output streamed to CloudWatch Logs
+	DefaultCloudWatchOutputFlushIntervalSeconds    = 1
+	DefaultCloudWatchOutputFlushIntervalSecondsMin = 1
+	DefaultCloudWatchOutputFlushIntervalSecondsMax = 60
+
+	//aws-ssm-agent number of steps of a parallel group of a document running at the same time
+	DefaultParallelStepsLimit    = 4
+	DefaultParallelStepsLimitMin = 1
+	DefaultParallelStepsLimitMax = 32
+
+	//aws-ssm-agent window of the random delay of the scheduled association runs, 0 doesn't delay the runs
+	DefaultAssociationJitterSecondsMax = 3600
+
+	//aws-ssm-agent catch-up policies of the scheduled association runs missed while the agent was not running
+	AssociationCatchUpRunImmediately  = "RunImmediately"
+	AssociationCatchUpSkip            = "Skip"
+	AssociationCatchUpRunWithinWindow = "RunWithinWindow"
+
+	DefaultAssociationCatchUpWindowHours    = 24
+	DefaultAssociationCatchUpWindowHoursMin = 1
+	DefaultAssociationCatchUpWindowHoursMax = 720
+
+	//aws-ssm-agent number of associations running at the same time
+	DefaultAssociationWorkersLimit    = 1
+	DefaultAssociationWorkersLimitMin = 1
+	DefaultAssociationWorkersLimitMax = 10
+
 	//aws-ssm-agent bookkeeping constants for long running plugins
 	LongRunningPluginsLocation         = "longrunningplugins"
 	LongRunningPluginsHealthCheck      = "healthcheck"
@@ -120,6 +188,9 @@ const (
 	// PluginNameAwsRunPowerShellScript is the name of the run powershell script plugin
 	PluginNameAwsRunPowerShellScript = "aws:runPowerShellScript"
 
+	// PluginNameAwsRunPythonScript is the name of the run python script plugin
+	PluginNameAwsRunPythonScript = "aws:runPythonScript"
+
 	// PluginNameAwsAgentUpdate is the name for agent update plugin
 	PluginNameAwsAgentUpdate = "aws:updateSsmAgent"
 
@@ -132,9 +203,18 @@ const (
 	// PluginRunDocument is the name of the run document plugin
 	PluginRunDocument = "aws:runDocument"
 
+	// PluginCopyFile is the name of the copy file plugin
+	PluginCopyFile = "aws:copyFile"
+
+	// PluginRunDockerContainer is the name of the run docker container plugin
+	PluginRunDockerContainer = "aws:runDockerContainer"
+
 	// PluginNameAwsSoftwareInventory is the name for inventory plugin
 	PluginNameAwsSoftwareInventory = "aws:softwareInventory"
 
+	// PluginNameAwsCollectInventory is the name for on-demand inventory collection plugin
+	PluginNameAwsCollectInventory = "aws:collectInventory"
+
 	// PluginNameDomainJoin is the name of domain join plugin
 	PluginNameDomainJoin = "aws:domainJoin"
 
@@ -170,6 +250,18 @@ const (
 	// PluginNameStandardStream is the name for session manager standard stream plugin aka shell.
 	PluginNameStandardStream = "Standard_Stream"
 
+	// PluginNamePort is the name for session manager port forwarding plugin.
+	PluginNamePort = "Port"
+
+	// PluginNameFileTransfer is the name for session manager file transfer plugin.
+	PluginNameFileTransfer = "FileTransfer"
+
+	// PluginNameInteractiveCommands is the name for session manager interactive commands plugin.
+	PluginNameInteractiveCommands = "InteractiveCommands"
+
+	// PluginNameNonInteractiveCommands is the name for session manager non-interactive commands plugin.
+	PluginNameNonInteractiveCommands = "NonInteractiveCommands"
+
 	// Session default RunAs user name
 	DefaultRunAsUserName = "ssm-user"
 )
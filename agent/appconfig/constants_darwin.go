@@ -47,6 +47,13 @@ const (
 	// are moved if the service cannot validate the document (generally impossible via cli)
 	LocalCommandRootInvalid = DefaultProgramFolder + "localcommands/invalid"
 
+	// LocalCommandRootStaging is the directory where the command documents submitted through the local IPC endpoint
+	// are written before being moved to LocalCommandRoot
+	LocalCommandRootStaging = DefaultProgramFolder + "localcommands/staging"
+
+	// LocalIpcEndpoint is the unix socket through which the tooling of the instance submits command documents
+	LocalIpcEndpoint = DefaultProgramFolder + "ipc/localcommands.sock"
+
 	// DownloadRoot specifies the directory under which files will be downloaded
 	DownloadRoot = DefaultProgramFolder + "download/"
 
@@ -76,6 +83,9 @@ const (
 	// Default Custom Inventory Inventory Folder
 	DefaultCustomInventoryFolder = DefaultDataStorePath + "inventory/custom"
 
+	// Default Custom Inventory Collectors Folder
+	DefaultCustomInventoryCollectorsFolder = DefaultDataStorePath + "inventory/collectors"
+
 	DefaultDocumentWorker = DefaultProgramFolder + "bin/ssm-document-worker"
 	DefaultSessionWorker  = DefaultProgramFolder + "bin/ssm-session-worker"
 	DefaultSessionLogger  = DefaultProgramFolder + "bin/ssm-session-logger"
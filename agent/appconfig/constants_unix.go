@@ -46,6 +46,13 @@ const (
 	// are moved if the service cannot validate the document (generally impossible via cli)
 	LocalCommandRootInvalid = "/var/lib/amazon/ssm/localcommands/invalid"
 
+	// LocalCommandRootStaging is the directory where the command documents submitted through the local IPC endpoint
+	// are written before being moved to LocalCommandRoot
+	LocalCommandRootStaging = "/var/lib/amazon/ssm/localcommands/staging"
+
+	// LocalIpcEndpoint is the unix socket through which the tooling of the instance submits command documents
+	LocalIpcEndpoint = "/var/lib/amazon/ssm/ipc/localcommands.sock"
+
 	// DownloadRoot specifies the directory under which files will be downloaded
 	DownloadRoot = "/var/log/amazon/ssm/download/"
 
@@ -75,6 +82,9 @@ const (
 	// Default Custom Inventory Inventory Folder
 	DefaultCustomInventoryFolder = DefaultDataStorePath + "inventory/custom"
 
+	// Default Custom Inventory Collectors Folder
+	DefaultCustomInventoryCollectorsFolder = DefaultDataStorePath + "inventory/collectors"
+
 	// PowerShellPluginCommandArgs is the arguments of powershell.exe to be used by the runPowerShellScript plugin
 	PowerShellPluginCommandArgs = ""
 
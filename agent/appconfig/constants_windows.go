@@ -35,6 +35,9 @@ const (
 	//Ec2configServiceFolder is the folder required by SSM agent
 	EC2ConfigServiceFolder = "Amazon\\Ec2ConfigService"
 
+	// LocalIpcEndpoint is the named pipe through which the tooling of the instance submits command documents
+	LocalIpcEndpoint = `\\.\pipe\amazon-ssm-agent-localcommands`
+
 	// ManifestCacheFolder path under local app data
 	ManifestCacheFolder = "Amazon\\SSM\\Manifests"
 
@@ -104,6 +107,10 @@ var LocalCommandRootCompleted string
 // are moved if the service cannot validate the document (generally impossible via cli)
 var LocalCommandRootInvalid string
 
+// LocalCommandRootStaging is the directory where the command documents submitted through the local IPC endpoint
+// are written before being moved to LocalCommandRoot
+var LocalCommandRootStaging string
+
 // DefaultPluginPath represents the directory for storing plugins in SSM
 var DefaultPluginPath string
 
@@ -143,6 +150,9 @@ var EnvWinDir string
 // Default Custom Inventory Data Folder
 var DefaultCustomInventoryFolder string
 
+// Default Custom Inventory Collectors Folder
+var DefaultCustomInventoryCollectorsFolder string
+
 // Plugin folder path
 var PluginFolder string
 
@@ -179,12 +189,14 @@ func init() {
 	LocalCommandRootSubmitted = filepath.Join(LocalCommandRoot, "Submitted")
 	LocalCommandRootCompleted = filepath.Join(LocalCommandRoot, "Completed")
 	LocalCommandRootInvalid = filepath.Join(LocalCommandRoot, "Invalid")
+	LocalCommandRootStaging = filepath.Join(LocalCommandRoot, "Staging")
 	DownloadRoot = filepath.Join(temp, SSMFolder, "Download")
 	UpdaterArtifactsRoot = filepath.Join(temp, SSMFolder, "Update")
 	EC2UpdateArtifactsRoot = filepath.Join(EnvWinDir, EC2ConfigServiceFolder, "Update")
 	EC2UpdaterDownloadRoot = filepath.Join(temp, EC2ConfigAppDataFolder, "Download")
 
 	DefaultCustomInventoryFolder = filepath.Join(SSMDataPath, "Inventory", "Custom")
+	DefaultCustomInventoryCollectorsFolder = filepath.Join(SSMDataPath, "Inventory", "Collectors")
 	EC2UpdateArtifactsRoot = filepath.Join(EnvWinDir, EC2ConfigServiceFolder, "Update")
 	EC2UpdaterDownloadRoot = filepath.Join(temp, EC2ConfigAppDataFolder, "Download")
 	EC2ConfigDataStorePath = filepath.Join(programData, EC2ConfigAppDataFolder, "InstanceData")
@@ -28,6 +28,13 @@ type MdsCfg struct {
 	CommandWorkersLimit int
 	StopTimeoutMillis   int64
 	CommandRetryLimit   int
+	// MaxIdlePollDelaySeconds is the maximum delay between two polls for messages while the instance receives no
+	// command, the delay grows after each poll without message and resets when a message is received.
+	// 0 polls again as soon as the previous poll returns.
+	MaxIdlePollDelaySeconds int
+	// ReplyBatchWindowMillis is the window within which the replies of a command in progress are batched, only the
+	// latest reply of the window is sent. The reply of a finished command is sent right away, 0 disables the batching.
+	ReplyBatchWindowMillis int
 }
 
 // SsmCfg represents configuration for Simple system manager (SSM)
@@ -43,6 +50,32 @@ type SsmCfg struct {
 	AssociationLogsRetentionDurationHours int
 	RunCommandLogsRetentionDurationHours  int
 	SessionLogsRetentionDurationHours     int
+	// CloudWatchOutputFlushIntervalSeconds is how often the output of the commands is uploaded to CloudWatch Logs
+	// while the commands run, when the command streams its output to CloudWatch Logs
+	CloudWatchOutputFlushIntervalSeconds int
+	// OutputTruncationStrategy is the part of the command output kept in the replies when the output exceeds their
+	// size: Head, Tail or HeadAndTail. The full output is uploaded to S3 and CloudWatch Logs when they are configured.
+	OutputTruncationStrategy string
+	// ParallelStepsLimit is the maximum number of steps of a parallel group of a document running at the same time
+	ParallelStepsLimit int
+	// CustomInventoryCollectorsLocation is the directory of the executables collecting custom inventory
+	CustomInventoryCollectorsLocation string
+	// InventoryUploadCompression enables the gzip compression of the inventory data uploaded to SSM
+	InventoryUploadCompression bool
+	// AssociationScheduleTimezone is the IANA time zone, e.g. America/New_York, of the association cron expressions
+	// which don't declare one. Cron expressions run in UTC when empty.
+	AssociationScheduleTimezone string
+	// AssociationJitterSeconds delays the scheduled association runs by up to this number of seconds, so that a fleet
+	// of instances doesn't run an association at the same time. The delay of an instance is stable across runs.
+	AssociationJitterSeconds int
+	// AssociationCatchUpPolicy decides whether a scheduled association run missed while the agent was not running
+	// runs when the agent starts: RunImmediately, Skip or RunWithinWindow, which runs it only when it was missed less
+	// than AssociationCatchUpWindowHours ago. Skipped runs wait for the next scheduled time.
+	AssociationCatchUpPolicy      string
+	AssociationCatchUpWindowHours int
+	// AssociationWorkersLimit is the maximum number of associations running at the same time, the associations of the
+	// same serialization group never run at the same time
+	AssociationWorkersLimit int
 }
 
 // AgentInfo represents metadata for amazon-ssm-agent
@@ -52,6 +85,16 @@ type AgentInfo struct {
 	Region               string
 	OrchestrationRootDir string
 	DownloadRootDir      string
+	// InstanceTagOverridesEnabled applies the settings of the instance tags prefixed with SSMAgent:, read from the
+	// instance metadata at startup
+	InstanceTagOverridesEnabled bool
+	// DocumentWorkerRequired fails the documents and sessions whose worker process cannot be started, instead of
+	// running them in the agent process, so a crashing or leaking plugin never affects the agent
+	DocumentWorkerRequired bool
+	// DocumentWorkerUser runs the document worker processes as this local user instead of the agent user, each
+	// worker owning only its channel and orchestration directories. Session workers keep running as the agent
+	// user since they start the shells as other users. Not supported on Windows.
+	DocumentWorkerUser string
 }
 
 // MgsConfig represents configuration for Message Gateway service
@@ -60,6 +103,26 @@ type MgsConfig struct {
 	Endpoint            string
 	StopTimeoutMillis   int64
 	SessionWorkersLimit int
+	DisableCompression  bool
+	// ConcurrentSessionsLimit is the maximum number of sessions running at the same time on the instance, no limit when 0
+	ConcurrentSessionsLimit int
+	// ConcurrentSessionsPerClientLimit is the maximum number of sessions of a client running at the same time, no limit when 0
+	ConcurrentSessionsPerClientLimit int
+	// PortForwardingAllowlist holds the CIDR blocks and DNS suffixes of the remote hosts port sessions may connect to
+	PortForwardingAllowlist []string
+}
+
+// LogCfg represents configurations related to the agent log files and the platform log.
+// Zero values keep the settings of the seelog configurations.
+type LogCfg struct {
+	MaxFileSizeMB   int
+	MaxRotatedFiles int
+	CompressRotated bool
+	// PlatformLogEnabled writes warnings and errors to the Windows Event Log or to systemd-journald as well
+	PlatformLogEnabled bool
+	// RedactionPatterns are regular expressions of sensitive values masked in the logs, in addition to the
+	// AWS secret keys, bearer tokens and passwords. Only the values captured by the groups of a pattern are masked.
+	RedactionPatterns []string
 }
 
 // OsInfo represents os related information
@@ -82,6 +145,81 @@ type BirdwatcherCfg struct {
 	ForceEnable bool
 }
 
+// UpdateCfg represents configuration of agent updates
+type UpdateCfg struct {
+	// MaintenanceWindows are the windows agent updates are applied in, updates are applied at any time when empty
+	MaintenanceWindows []MaintenanceWindowCfg
+	// VerificationTimeoutMinutes is how long the updated agent has to pass its checks before the update is rolled back
+	VerificationTimeoutMinutes int
+	// ManifestURL overrides the location of the update manifest, such as an internal mirror, {Region} is replaced by
+	// the region of the instance. The manifest of the region is used when empty.
+	ManifestURL string
+	// ArtifactBaseURL overrides the location the update packages are downloaded from, packages are expected at
+	// <ArtifactBaseURL>/{PackageName}/{PackageVersion}/{FileName}. The location in the manifest is used when empty.
+	ArtifactBaseURL string
+	// MinimumVersion is the oldest agent version updates may downgrade to, unless the update document allows
+	// downgrades. Downgrades are only limited by the on-disk state formats of the agent when empty.
+	MinimumVersion string
+	// ArtifactRetentionCount is how many versions of each update package are kept in the update root after
+	// successful updates, the source and target versions of the last update are always kept
+	ArtifactRetentionCount int
+	// ArtifactMaxDiskUsageMB limits the disk usage of the kept versions, older versions are removed first.
+	// Zero does not limit the disk usage.
+	ArtifactMaxDiskUsageMB int
+}
+
+// DownloadCfg represents limits shared by all artifact downloads of the agent, such as packages, content
+// and agent updates
+type DownloadCfg struct {
+	// MaxConcurrentDownloads is the maximum number of artifacts downloaded at the same time, no limit when 0
+	MaxConcurrentDownloads int
+	// MaxBytesPerSecond limits the combined bandwidth of the downloads, no limit when 0
+	MaxBytesPerSecond int
+}
+
+// HealthCfg represents configuration of the local health endpoint of the agent
+type HealthCfg struct {
+	// EndpointEnabled serves the liveness and readiness of the agent on http://localhost:<EndpointPort>
+	EndpointEnabled bool
+	// MetricsEnabled serves the internal metrics of the agent for Prometheus on http://localhost:<EndpointPort>/metrics
+	MetricsEnabled bool
+	EndpointPort   int
+}
+
+// LocalIpcCfg represents configuration of the local endpoint through which the tooling of the instance submits
+// documents and queries their status without going through the service
+type LocalIpcCfg struct {
+	// Enabled serves the local endpoint on a unix socket, or a named pipe on Windows, only root and the
+	// Administrators can connect to it
+	Enabled bool
+}
+
+// PolicyCfg represents the local policy consulted before the documents run, so the instance can refuse documents
+// independently of IAM. The documents are allowed when neither the rules nor the hook are configured.
+type PolicyCfg struct {
+	// RulesPath is a json file of rules, the first rule matching a document allows or denies it
+	RulesPath string
+	// HookPath is an executable reading the document as json on its standard input and writing its verdict as json,
+	// such as {"allow": false, "reason": "..."}, on its standard output. A failing hook denies the document.
+	HookPath           string
+	HookTimeoutSeconds int
+}
+
+// EndpointCfg represents the endpoints of the services the agent calls
+type EndpointCfg struct {
+	// Overrides maps service names, such as ssm, ec2messages, ssmmessages, s3 or logs, to the endpoints used instead
+	// of the endpoints of the region, such as VPC endpoints. {Region} is replaced by the region of the instance.
+	// The endpoints of the sections of the services take precedence.
+	Overrides map[string]string
+}
+
+// MaintenanceWindowCfg represents a recurring window starting on a schedule such as cron(0 2 ? * SUN *),
+// in the local time of the instance
+type MaintenanceWindowCfg struct {
+	Schedule        string
+	DurationMinutes int
+}
+
 // SsmagentConfig stores agent configuration values.
 type SsmagentConfig struct {
 	Profile     CredentialProfile
@@ -89,9 +227,16 @@ type SsmagentConfig struct {
 	Ssm         SsmCfg
 	Mgs         MgsConfig
 	Agent       AgentInfo
+	Log         LogCfg
 	Os          OsInfo
 	S3          S3Cfg
 	Birdwatcher BirdwatcherCfg
+	Update      UpdateCfg
+	Download    DownloadCfg
+	Endpoints   EndpointCfg
+	Health      HealthCfg
+	LocalIpc    LocalIpcCfg
+	Policy      PolicyCfg
 }
 
 // AppConstants represents some run time constant variable for various module.
@@ -0,0 +1,212 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+const (
+	// SourceFile is the source of the settings of the configuration file
+	SourceFile = "configuration file"
+	// SourceEnvironment is the source of the settings of the environment variables
+	SourceEnvironment = "environment variable"
+	// SourceInstanceTag is the source of the settings of the instance tags
+	SourceInstanceTag = "instance tag"
+)
+
+// OverriddenSetting is a setting of the configuration whose value differs from the default
+type OverriddenSetting struct {
+	Setting string
+	Value   interface{}
+	Default interface{}
+	Source  string
+}
+
+// InvalidSetting is a setting of the configuration that was rejected, the agent uses the default value instead
+type InvalidSetting struct {
+	Setting string
+	Value   interface{}
+	Default interface{}
+	Source  string
+	Reason  string
+}
+
+// Diagnostics reports the settings of the loaded configuration that differ from the defaults and the
+// settings that were rejected
+type Diagnostics struct {
+	Overridden []OverriddenSetting
+	Invalid    []InvalidSetting
+}
+
+var loadedDiagnostics Diagnostics
+var diagnosticsLock sync.RWMutex
+
+// LastDiagnostics returns the diagnostics of the last load of the configuration
+func LastDiagnostics() Diagnostics {
+	diagnosticsLock.RLock()
+	defer diagnosticsLock.RUnlock()
+	return loadedDiagnostics
+}
+
+// cacheDiagnostics caches the diagnostics of the loaded configuration
+func cacheDiagnostics(diagnostics Diagnostics) {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+	loadedDiagnostics = diagnostics
+}
+
+// String describes the overridden setting
+func (setting OverriddenSetting) String() string {
+	return fmt.Sprintf("%s is set to %v by the %s, the default is %v", setting.Setting, setting.Value, setting.Source, setting.Default)
+}
+
+// String describes the invalid setting and the value used instead
+func (setting InvalidSetting) String() string {
+	if setting.Default == nil {
+		return fmt.Sprintf("%s of the %s is ignored, %s", setting.Setting, setting.Source, setting.Reason)
+	}
+	return fmt.Sprintf("%s of the %s is %s, using the default %v instead of %v",
+		setting.Setting, setting.Source, setting.Reason, setting.Default, setting.Value)
+}
+
+// diagnosticsBuilder tracks the sources of the settings while the configuration is loaded
+type diagnosticsBuilder struct {
+	sources map[string]string
+	invalid []InvalidSetting
+}
+
+func newDiagnosticsBuilder() *diagnosticsBuilder {
+	return &diagnosticsBuilder{sources: make(map[string]string)}
+}
+
+// setSource records the source of a setting, later sources take precedence
+func (builder *diagnosticsBuilder) setSource(setting string, source string) {
+	builder.sources[setting] = source
+}
+
+// addInvalid records a setting that couldn't be applied
+func (builder *diagnosticsBuilder) addInvalid(setting string, value interface{}, source string, reason string) {
+	builder.invalid = append(builder.invalid, InvalidSetting{Setting: setting, Value: value, Source: source, Reason: reason})
+}
+
+// checkFile records the settings of the configuration file, and the settings of the file the agent doesn't know
+func (builder *diagnosticsBuilder) checkFile(path string) {
+	var content map[string]interface{}
+	if err := jsonutil.UnmarshalFile(path, &content); err != nil {
+		return
+	}
+	configType := reflect.TypeOf(SsmagentConfig{})
+	for _, sectionName := range sortedKeys(content) {
+		section, found := findField(configType, sectionName)
+		if !found {
+			builder.addInvalid(sectionName, content[sectionName], SourceFile, "an unknown section")
+			continue
+		}
+		settings, isSection := content[sectionName].(map[string]interface{})
+		if !isSection || section.Type.Kind() != reflect.Struct {
+			continue
+		}
+		for _, settingName := range sortedKeys(settings) {
+			setting, found := findField(section.Type, settingName)
+			if !found {
+				builder.addInvalid(section.Name+"."+settingName, settings[settingName], SourceFile, "an unknown setting")
+				continue
+			}
+			builder.setSource(section.Name+"."+setting.Name, SourceFile)
+		}
+	}
+}
+
+// build compares the configuration before and after the validation of its values, and with the defaults
+func (builder *diagnosticsBuilder) build(loaded SsmagentConfig, validated SsmagentConfig, defaults SsmagentConfig) (diagnostics Diagnostics) {
+	diagnostics.Invalid = append(diagnostics.Invalid, builder.invalid...)
+
+	loadedSettings := settingValues(loaded)
+	validatedSettings := settingValues(validated)
+	defaultSettings := settingValues(defaults)
+	for _, setting := range sortedKeys(validatedSettings) {
+		source := builder.sources[setting]
+		if source == "" {
+			continue
+		}
+		value := validatedSettings[setting]
+		if !sameValue(loadedSettings[setting], value) {
+			reason := "out of the accepted range"
+			if reflect.ValueOf(value).Kind() == reflect.String {
+				reason = "empty"
+			}
+			diagnostics.Invalid = append(diagnostics.Invalid, InvalidSetting{
+				Setting: setting,
+				Value:   loadedSettings[setting],
+				Default: value,
+				Source:  source,
+				Reason:  reason,
+			})
+			continue
+		}
+		if !sameValue(defaultSettings[setting], value) {
+			diagnostics.Overridden = append(diagnostics.Overridden, OverriddenSetting{
+				Setting: setting,
+				Value:   value,
+				Default: defaultSettings[setting],
+				Source:  source,
+			})
+		}
+	}
+	return
+}
+
+// settingValues returns the values of the settings of a configuration by Section.Setting path
+func settingValues(config SsmagentConfig) map[string]interface{} {
+	values := make(map[string]interface{})
+	configValue := reflect.ValueOf(config)
+	for i := 0; i < configValue.NumField(); i++ {
+		section := configValue.Field(i)
+		sectionName := configValue.Type().Field(i).Name
+		for j := 0; j < section.NumField(); j++ {
+			values[sectionName+"."+section.Type().Field(j).Name] = section.Field(j).Interface()
+		}
+	}
+	return values
+}
+
+// sameValue returns true if both values are equal, empty lists and maps are equal to missing ones
+func sameValue(a interface{}, b interface{}) bool {
+	valueA, valueB := reflect.ValueOf(a), reflect.ValueOf(b)
+	if kind := valueA.Kind(); (kind == reflect.Slice || kind == reflect.Map) && valueA.Len() == 0 && valueB.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// findField returns the field of a struct with the given name, ignoring the case like the json decoder
+func findField(structType reflect.Type, name string) (reflect.StructField, bool) {
+	return structType.FieldByNameFunc(func(field string) bool { return strings.EqualFold(field, name) })
+}
+
+func sortedKeys(values map[string]interface{}) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withConfigFile points the agent to a temporary configuration file for the duration of a test
+func withConfigFile(t *testing.T, content string) (path string, restore func()) {
+	dir, err := ioutil.TempDir("", "appconfig")
+	assert.NoError(t, err)
+	path = filepath.Join(dir, AppConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	previousPath := AppConfigPath
+	AppConfigPath = path
+	Config(true)
+	return path, func() {
+		AppConfigPath = previousPath
+		lock.Lock()
+		loadedConfig = nil
+		lock.Unlock()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDiagnosticsReportsOverriddenSettings(t *testing.T) {
+	os.Setenv("SSM_AGENT__Mgs__SessionWorkersLimit", "10")
+	defer os.Unsetenv("SSM_AGENT__Mgs__SessionWorkersLimit")
+	_, restore := withConfigFile(t, `{"Mds": {"CommandWorkersLimit": 10, "CommandRetryLimit": 15}, "mgs": {"sessionWorkersLimit": 20}}`)
+	defer restore()
+
+	diagnostics := LastDiagnostics()
+	assert.Empty(t, diagnostics.Invalid)
+	assert.Equal(t, []OverriddenSetting{
+		{Setting: "Mds.CommandWorkersLimit", Value: 10, Default: DefaultCommandWorkersLimit, Source: SourceFile},
+		{Setting: "Mgs.SessionWorkersLimit", Value: 10, Default: DefaultSessionWorkersLimit, Source: SourceEnvironment},
+	}, diagnostics.Overridden)
+}
+
+func TestDiagnosticsReportsInvalidSettings(t *testing.T) {
+	os.Setenv("SSM_AGENT__Ssm__HealthFrequencyMinutes", "often")
+	defer os.Unsetenv("SSM_AGENT__Ssm__HealthFrequencyMinutes")
+	_, restore := withConfigFile(t, `{"Mds": {"CommandRetryLimit": 1000, "Workers": 2}, "Agent": {"Name": ""}, "Unknown": {}}`)
+	defer restore()
+
+	config, _ := Config(false)
+	assert.Equal(t, DefaultCommandRetryLimit, config.Mds.CommandRetryLimit)
+	diagnostics := LastDiagnostics()
+	assert.Empty(t, diagnostics.Overridden)
+	assert.Equal(t, []InvalidSetting{
+		{Setting: "Mds.Workers", Value: 2.0, Source: SourceFile, Reason: "an unknown setting"},
+		{Setting: "Unknown", Value: map[string]interface{}{}, Source: SourceFile, Reason: "an unknown section"},
+		{Setting: "Ssm.HealthFrequencyMinutes", Value: "often", Source: SourceEnvironment, Reason: "not a valid int"},
+		{Setting: "Agent.Name", Value: "", Default: DefaultAgentName, Source: SourceFile, Reason: "empty"},
+		{Setting: "Mds.CommandRetryLimit", Value: 1000, Default: DefaultCommandRetryLimit, Source: SourceFile,
+			Reason: "out of the accepted range"},
+	}, diagnostics.Invalid)
+	assert.Equal(t, "Mds.CommandRetryLimit of the configuration file is out of the accepted range, using the default 15 instead of 1000",
+		diagnostics.Invalid[4].String())
+}
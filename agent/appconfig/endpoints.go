@@ -0,0 +1,106 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"strings"
+)
+
+const (
+	// PartitionAws is the partition of the commercial regions
+	PartitionAws = "aws"
+	// PartitionChina is the partition of the China regions
+	PartitionChina = "aws-cn"
+	// PartitionGovCloud is the partition of the AWS GovCloud (US) regions
+	PartitionGovCloud = "aws-us-gov"
+	// PartitionIso is the partition of the us-iso regions
+	PartitionIso = "aws-iso"
+	// PartitionIsoB is the partition of the us-isob regions
+	PartitionIsoB = "aws-iso-b"
+
+	// endpointRegionVariable is replaced by the region in the endpoint overrides
+	endpointRegionVariable = "{Region}"
+)
+
+// partition holds the regions with a given prefix and the domain of their endpoints
+type partition struct {
+	name         string
+	regionPrefix string
+	dnsSuffix    string
+}
+
+// partitions are the partitions other than the commercial partition
+var partitions = []partition{
+	{name: PartitionChina, regionPrefix: "cn-", dnsSuffix: "amazonaws.com.cn"},
+	{name: PartitionGovCloud, regionPrefix: "us-gov-", dnsSuffix: "amazonaws.com"},
+	{name: PartitionIso, regionPrefix: "us-iso-", dnsSuffix: "c2s.ic.gov"},
+	{name: PartitionIsoB, regionPrefix: "us-isob-", dnsSuffix: "sc2s.sgov.gov"},
+}
+
+var awsPartition = partition{name: PartitionAws, dnsSuffix: "amazonaws.com"}
+
+// getPartition returns the partition of a region, the commercial partition for unknown regions
+func getPartition(region string) partition {
+	for _, p := range partitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p
+		}
+	}
+	return awsPartition
+}
+
+// Partition returns the name of the partition of a region, such as aws-cn for cn-north-1
+func Partition(region string) string {
+	return getPartition(region).name
+}
+
+// ResolveEndpoint returns the endpoint of a service in a region. The endpoint override of the service is used if the
+// Endpoints section configures one, otherwise the endpoint is built from the domain of the partition of the region.
+func ResolveEndpoint(region string, service string) string {
+	if endpoint := endpointOverride(region, service); endpoint != "" {
+		return endpoint
+	}
+	return service + "." + region + "." + getPartition(region).dnsSuffix
+}
+
+// GetDefaultEndPoint returns the default endpoint for a service when the AWS SDK can't be relied on to resolve it,
+// which is when the Endpoints section overrides the endpoint of the service or when the region is outside of the
+// commercial and GovCloud partitions. It returns an empty string otherwise.
+func GetDefaultEndPoint(region string, service string) string {
+	if endpoint := endpointOverride(region, service); endpoint != "" {
+		return endpoint
+	}
+	if region == "" || service == "" {
+		return ""
+	}
+	if p := getPartition(region); p.dnsSuffix != awsPartition.dnsSuffix {
+		return service + "." + region + "." + p.dnsSuffix
+	}
+	return ""
+}
+
+// endpointOverride returns the endpoint override of a service, such as a VPC endpoint, or an empty string
+func endpointOverride(region string, service string) string {
+	config, err := Config(false)
+	if err != nil {
+		return ""
+	}
+	for name, endpoint := range config.Endpoints.Overrides {
+		if strings.EqualFold(name, service) && endpoint != "" {
+			return strings.Replace(endpoint, endpointRegionVariable, region, -1)
+		}
+	}
+	return ""
+}
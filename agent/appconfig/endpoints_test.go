@@ -0,0 +1,58 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		region      string
+		partition   string
+		endpoint    string
+		sdkResolved bool
+	}{
+		{"us-east-1", PartitionAws, "ssm.us-east-1.amazonaws.com", true},
+		{"cn-northwest-1", PartitionChina, "ssm.cn-northwest-1.amazonaws.com.cn", false},
+		{"us-gov-west-1", PartitionGovCloud, "ssm.us-gov-west-1.amazonaws.com", true},
+		{"us-iso-east-1", PartitionIso, "ssm.us-iso-east-1.c2s.ic.gov", false},
+		{"us-isob-east-1", PartitionIsoB, "ssm.us-isob-east-1.sc2s.sgov.gov", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.partition, Partition(test.region))
+		assert.Equal(t, test.endpoint, ResolveEndpoint(test.region, "ssm"))
+		if test.sdkResolved {
+			assert.Empty(t, GetDefaultEndPoint(test.region, "ssm"))
+		} else {
+			assert.Equal(t, test.endpoint, GetDefaultEndPoint(test.region, "ssm"))
+		}
+	}
+}
+
+func TestResolveEndpointOverrides(t *testing.T) {
+	_, restore := withConfigFile(t, `{"Endpoints": {"Overrides": {
+		"ssm": "vpce-0123.ssm.{Region}.vpce.amazonaws.com",
+		"ec2messages": ""
+	}}}`)
+	defer restore()
+
+	assert.Equal(t, "vpce-0123.ssm.us-east-1.vpce.amazonaws.com", ResolveEndpoint("us-east-1", "ssm"))
+	assert.Equal(t, "vpce-0123.ssm.us-east-1.vpce.amazonaws.com", GetDefaultEndPoint("us-east-1", "SSM"))
+	assert.Equal(t, "ec2messages.us-east-1.amazonaws.com", ResolveEndpoint("us-east-1", "ec2messages"))
+	assert.Empty(t, GetDefaultEndPoint("us-east-1", "ec2messages"))
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Settings of the configuration file can be overridden without changing the file. From the lowest to the highest
+// precedence, the agent applies:
+//  1. the defaults of the agent
+//  2. the configuration file
+//  3. the instance tags, when Agent.InstanceTagOverridesEnabled is set by the file or the environment
+//  4. the environment variables
+//
+// An environment variable such as SSM_AGENT__Mds__CommandWorkersLimit=10 or an instance tag such as
+// SSMAgent:Mds.CommandWorkersLimit=10 sets the CommandWorkersLimit setting of the Mds section. Section and setting
+// names are case insensitive. Text settings take the value as is, the other settings take a JSON value and lists
+// of text also take comma separated values.
+const (
+	// EnvOverridePrefix is the prefix of the environment variables overriding settings
+	EnvOverridePrefix = "SSM_AGENT__"
+	// envOverrideSeparator separates the section and the setting names of an environment variable
+	envOverrideSeparator = "__"
+	// TagOverridePrefix is the prefix of the instance tags overriding settings
+	TagOverridePrefix = "SSMAgent:"
+	// tagOverrideSeparator separates the section and the setting names of an instance tag
+	tagOverrideSeparator = "."
+
+	// instanceTagsMetadataPath is the metadata path listing the tags of the instance, tags in instance metadata
+	// must be allowed in the metadata options of the instance
+	instanceTagsMetadataPath = "tags/instance"
+	instanceTagsTimeout      = 2 * time.Second
+)
+
+var (
+	instanceTags     map[string]string
+	instanceTagsOnce sync.Once
+
+	// getInstanceTags is replaced in tests
+	getInstanceTags = fetchInstanceTags
+)
+
+// applyOverrides applies the instance tag and environment variable overrides to the configuration.
+// Returns true if a setting was overridden.
+func applyOverrides(config *SsmagentConfig, diagnostics *diagnosticsBuilder) (overridden bool) {
+	environment := make(map[string]string)
+	for _, variable := range os.Environ() {
+		if parts := strings.SplitN(variable, "=", 2); len(parts) == 2 {
+			environment[parts[0]] = parts[1]
+		}
+	}
+	envOverrides := parseOverrides(environment, EnvOverridePrefix, envOverrideSeparator)
+	overridden = setOverrides(config, envOverrides, SourceEnvironment, diagnostics)
+	if !config.Agent.InstanceTagOverridesEnabled {
+		return
+	}
+
+	// tags are fetched once, at startup, while reloads of the configuration pick up changes of the environment
+	instanceTagsOnce.Do(func() { instanceTags = getInstanceTags() })
+	tagOverrides := parseOverrides(instanceTags, TagOverridePrefix, tagOverrideSeparator)
+	if setOverrides(config, tagOverrides, SourceInstanceTag, diagnostics) {
+		// the environment variables take precedence over the instance tags
+		setOverrides(config, envOverrides, SourceEnvironment, diagnostics)
+		overridden = true
+	}
+	return
+}
+
+// parseOverrides returns the setting paths and values of the entries named with the given prefix,
+// such as SSM_AGENT__Mds__CommandWorkersLimit
+func parseOverrides(entries map[string]string, prefix string, separator string) map[string]string {
+	overrides := make(map[string]string)
+	for name, value := range entries {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(name, prefix), separator)
+		overrides[strings.Join(path, ".")] = value
+	}
+	return overrides
+}
+
+// setOverrides sets the settings of the configuration, settings that don't exist or values that can't be parsed are
+// reported and ignored. Returns true if a setting was overridden.
+func setOverrides(config *SsmagentConfig, overrides map[string]string, source string, diagnostics *diagnosticsBuilder) (overridden bool) {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		setting := strings.Split(path, ".")
+		if err := setSetting(reflect.ValueOf(config).Elem(), setting, overrides[path]); err != nil {
+			log.Printf("Ignoring %s override of %s. %v\n", source, path, err)
+			diagnostics.addInvalid(path, overrides[path], source, err.Error())
+			continue
+		}
+		log.Printf("Applied %s override of %s.\n", source, path)
+		diagnostics.setSource(strings.Join(setting, "."), source)
+		overridden = true
+	}
+	return
+}
+
+// setSetting sets the setting at the given path of a configuration section, the names of the path are replaced
+// by the names of the setting
+func setSetting(section reflect.Value, path []string, value string) error {
+	if len(path) == 0 || section.Kind() != reflect.Struct {
+		return fmt.Errorf("an unknown setting")
+	}
+	fieldType, found := findField(section.Type(), path[0])
+	if !found {
+		return fmt.Errorf("an unknown setting")
+	}
+	path[0] = fieldType.Name
+	field := section.FieldByIndex(fieldType.Index)
+	if len(path) > 1 {
+		return setSetting(field, path[1:], value)
+	}
+
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+		return nil
+	case field.Kind() == reflect.Struct:
+		return fmt.Errorf("a section, not a setting")
+	}
+
+	parsed := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+		if field.Type() != reflect.TypeOf([]string{}) {
+			return fmt.Errorf("not a valid %v", field.Type())
+		}
+		// lists of text also take comma separated values
+		values := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		parsed.Elem().Set(reflect.ValueOf(values))
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
+
+// fetchInstanceTags returns the tags of the instance from the instance metadata, no tags are returned when the
+// agent is not running on EC2 or when tags are not allowed in the instance metadata
+func fetchInstanceTags() (tags map[string]string) {
+	tags = make(map[string]string)
+	sess, err := session.NewSession(aws.NewConfig().
+		WithMaxRetries(1).
+		WithHTTPClient(&http.Client{Timeout: instanceTagsTimeout}))
+	if err != nil {
+		log.Printf("Failed to read the instance tags. %v\n", err)
+		return
+	}
+	client := ec2metadata.New(sess)
+	keys, err := client.GetMetadata(instanceTagsMetadataPath)
+	if err != nil {
+		log.Printf("Instance tags are not available in the instance metadata. %v\n", err)
+		return
+	}
+	for _, key := range strings.Split(keys, "\n") {
+		if !strings.HasPrefix(key, TagOverridePrefix) {
+			continue
+		}
+		value, err := client.GetMetadata(instanceTagsMetadataPath + "/" + key)
+		if err != nil {
+			log.Printf("Failed to read the instance tag %s. %v\n", key, err)
+			continue
+		}
+		tags[key] = value
+	}
+	return
+}
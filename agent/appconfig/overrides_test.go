@@ -0,0 +1,97 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withInstanceTags returns the given tags as the instance tags for the duration of a test
+func withInstanceTags(tags map[string]string) (restore func()) {
+	instanceTagsOnce = sync.Once{}
+	getInstanceTags = func() map[string]string { return tags }
+	return func() {
+		instanceTagsOnce = sync.Once{}
+		instanceTags = nil
+		getInstanceTags = fetchInstanceTags
+	}
+}
+
+func TestApplyEnvironmentOverrides(t *testing.T) {
+	os.Setenv("SSM_AGENT__Mds__CommandWorkersLimit", "10")
+	os.Setenv("SSM_AGENT__mgs__endpoint", "https://mgs.example.com")
+	os.Setenv("SSM_AGENT__Mgs__PortForwardingAllowlist", "10.0.0.0/8, .example.com")
+	os.Setenv("SSM_AGENT__Log__CompressRotated", "true")
+	defer os.Unsetenv("SSM_AGENT__Mds__CommandWorkersLimit")
+	defer os.Unsetenv("SSM_AGENT__mgs__endpoint")
+	defer os.Unsetenv("SSM_AGENT__Mgs__PortForwardingAllowlist")
+	defer os.Unsetenv("SSM_AGENT__Log__CompressRotated")
+
+	config := DefaultConfig()
+	assert.True(t, applyOverrides(&config, newDiagnosticsBuilder()))
+	assert.Equal(t, 10, config.Mds.CommandWorkersLimit)
+	assert.Equal(t, "https://mgs.example.com", config.Mgs.Endpoint)
+	assert.Equal(t, []string{"10.0.0.0/8", ".example.com"}, config.Mgs.PortForwardingAllowlist)
+	assert.True(t, config.Log.CompressRotated)
+}
+
+func TestApplyOverridesIgnoresInvalidSettings(t *testing.T) {
+	os.Setenv("SSM_AGENT__Mds__CommandWorkersLimit", "ten")
+	os.Setenv("SSM_AGENT__Mds__Unknown", "1")
+	os.Setenv("SSM_AGENT__Mds", "1")
+	defer os.Unsetenv("SSM_AGENT__Mds__CommandWorkersLimit")
+	defer os.Unsetenv("SSM_AGENT__Mds__Unknown")
+	defer os.Unsetenv("SSM_AGENT__Mds")
+
+	config := DefaultConfig()
+	assert.False(t, applyOverrides(&config, newDiagnosticsBuilder()))
+	assert.Equal(t, DefaultConfig(), config)
+}
+
+func TestApplyInstanceTagOverrides(t *testing.T) {
+	defer withInstanceTags(map[string]string{
+		"SSMAgent:Mds.CommandWorkersLimit": "10",
+		"SSMAgent:Mds.CommandRetryLimit":   "20",
+		"Name":                             "instance",
+	})()
+	os.Setenv("SSM_AGENT__Mds__CommandRetryLimit", "30")
+	defer os.Unsetenv("SSM_AGENT__Mds__CommandRetryLimit")
+
+	// instance tags are ignored unless enabled
+	config := DefaultConfig()
+	assert.True(t, applyOverrides(&config, newDiagnosticsBuilder()))
+	assert.Equal(t, DefaultCommandWorkersLimit, config.Mds.CommandWorkersLimit)
+
+	// the environment variables take precedence over the instance tags
+	config = DefaultConfig()
+	config.Agent.InstanceTagOverridesEnabled = true
+	assert.True(t, applyOverrides(&config, newDiagnosticsBuilder()))
+	assert.Equal(t, 10, config.Mds.CommandWorkersLimit)
+	assert.Equal(t, 30, config.Mds.CommandRetryLimit)
+}
+
+func TestInstanceTagOverridesEnabledByEnvironment(t *testing.T) {
+	defer withInstanceTags(map[string]string{"SSMAgent:Mds.CommandWorkersLimit": "10"})()
+	os.Setenv("SSM_AGENT__Agent__InstanceTagOverridesEnabled", "true")
+	defer os.Unsetenv("SSM_AGENT__Agent__InstanceTagOverridesEnabled")
+
+	config := DefaultConfig()
+	assert.True(t, applyOverrides(&config, newDiagnosticsBuilder()))
+	assert.Equal(t, 10, config.Mds.CommandWorkersLimit)
+}
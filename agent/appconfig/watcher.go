@@ -0,0 +1,111 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ChangeHandler is notified with the previous and the reloaded configuration when a reload changed the configuration
+type ChangeHandler func(previous SsmagentConfig, current SsmagentConfig)
+
+var (
+	subscribers     = make(map[string]ChangeHandler)
+	subscribersLock sync.Mutex
+	reloadLock      sync.Mutex
+
+	// startConfigWatcher is replaced in tests
+	startConfigWatcher = startPlatformWatcher
+)
+
+// Subscribe registers a handler notified when a reload changes the configuration. Subscribing
+// again with the same name replaces the handler. Handlers should read the new values from the
+// current configuration rather than from a context created at startup.
+func Subscribe(name string, handler ChangeHandler) {
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	subscribers[name] = handler
+}
+
+// Unsubscribe removes the handler registered with the given name.
+func Unsubscribe(name string) {
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	delete(subscribers, name)
+}
+
+// Reload reloads the configuration file and notifies the subscribers in name order if the configuration changed.
+// The current configuration is kept if the file can't be parsed, nothing is reloaded without a configuration file.
+func Reload() (changed bool, err error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	if _, err = getAppConfigPath(); err != nil {
+		return false, nil
+	}
+
+	var previous, current SsmagentConfig
+	if previous, err = Config(false); err != nil {
+		return
+	}
+	if current, err = Config(true); err != nil {
+		return
+	}
+	if reflect.DeepEqual(previous, current) {
+		return false, nil
+	}
+
+	for _, handler := range sortedSubscribers() {
+		handler(previous, current)
+	}
+	return true, nil
+}
+
+// sortedSubscribers returns the handlers of the subscribers in name order
+func sortedSubscribers() (handlers []ChangeHandler) {
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+
+	names := make([]string, 0, len(subscribers))
+	for name := range subscribers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		handlers = append(handlers, subscribers[name])
+	}
+	return
+}
+
+// StartWatcher reloads the configuration when it changes and notifies the subscribers. The agent reloads
+// on SIGHUP on Linux and macOS, and when the configuration file is written on Windows.
+// The proxy is not reloaded, it isn't part of the configuration: the agent reads it from the
+// environment, or the registry on Windows, at startup and the HTTP clients cache it.
+// The returned function stops the watcher.
+func StartWatcher() (stop func(), err error) {
+	return startConfigWatcher(reloadConfig)
+}
+
+// reloadConfig reloads the configuration, printing the outcome like the other configuration messages
+func reloadConfig() {
+	if changed, err := Reload(); err != nil {
+		log.Printf("Failed to reload the config, keeping the current config. %v\n", err)
+	} else if changed {
+		log.Printf("Reloaded config from %s.\n", AppConfigPath)
+	}
+}
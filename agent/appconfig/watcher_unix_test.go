@@ -0,0 +1,102 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build freebsd linux netbsd openbsd
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadNotifiesSubscribers(t *testing.T) {
+	path, restore := withConfigFile(t, `{"Mds": {"CommandWorkersLimit": 5}}`)
+	defer restore()
+
+	var notified []string
+	var workers []int
+	Subscribe("second", func(previous, current SsmagentConfig) { notified = append(notified, "second") })
+	Subscribe("first", func(previous, current SsmagentConfig) {
+		notified = append(notified, "first")
+		workers = []int{previous.Mds.CommandWorkersLimit, current.Mds.CommandWorkersLimit}
+	})
+	defer Unsubscribe("first")
+	defer Unsubscribe("second")
+
+	// unchanged configuration
+	changed, err := Reload()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, notified)
+
+	ioutil.WriteFile(path, []byte(`{"Mds": {"CommandWorkersLimit": 10}}`), 0600)
+	changed, err = Reload()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"first", "second"}, notified)
+	assert.Equal(t, []int{5, 10}, workers)
+	config, _ := Config(false)
+	assert.Equal(t, 10, config.Mds.CommandWorkersLimit)
+}
+
+func TestReloadKeepsConfigOnInvalidFile(t *testing.T) {
+	path, restore := withConfigFile(t, `{"Mds": {"CommandWorkersLimit": 5}}`)
+	defer restore()
+
+	notified := false
+	Subscribe("test", func(previous, current SsmagentConfig) { notified = true })
+	defer Unsubscribe("test")
+
+	ioutil.WriteFile(path, []byte(`{"Mds": `), 0600)
+	changed, err := Reload()
+	assert.Error(t, err)
+	assert.False(t, changed)
+	assert.False(t, notified)
+	config, _ := Config(false)
+	assert.Equal(t, 5, config.Mds.CommandWorkersLimit)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	path, restore := withConfigFile(t, `{"Mds": {"CommandWorkersLimit": 5}}`)
+	defer restore()
+
+	notified := false
+	Subscribe("test", func(previous, current SsmagentConfig) { notified = true })
+	Unsubscribe("test")
+
+	ioutil.WriteFile(path, []byte(`{"Mds": {"CommandWorkersLimit": 10}}`), 0600)
+	changed, _ := Reload()
+	assert.True(t, changed)
+	assert.False(t, notified)
+}
+
+func TestWatcherReloadsOnSIGHUP(t *testing.T) {
+	reloaded := make(chan bool, 1)
+	stop, err := startPlatformWatcher(func() { reloaded <- true })
+	assert.NoError(t, err)
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "configuration wasn't reloaded on SIGHUP")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+// Package appconfig manages the configuration of the agent.
+package appconfig
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startPlatformWatcher calls reload when the configuration file is written, created or renamed.
+// Windows has no SIGHUP, the parent directory is watched since the file may not exist yet.
+func startPlatformWatcher(reload func()) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = watcher.Add(filepath.Dir(AppConfigPath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	// errors of the watcher are ignored, the agent keeps running with the current configuration
+	go func() {
+		for range watcher.Errors {
+		}
+	}()
+	go func() {
+		for event := range watcher.Events {
+			if strings.EqualFold(filepath.Clean(event.Name), filepath.Clean(AppConfigPath)) &&
+				event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				reload()
+			}
+		}
+	}()
+
+	return func() { watcher.Close() }, nil
+}
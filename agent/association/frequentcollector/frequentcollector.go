@@ -38,6 +38,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registry"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/role"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsFeature"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 
@@ -323,6 +324,7 @@ func (collector *FrequentCollector) getGathererParameterMap() map[string]string
 	paramGathererMap[strings.ToLower(file.GathererName)] = "files"
 	paramGathererMap[strings.ToLower(network.GathererName)] = "networkConfig"
 	paramGathererMap[strings.ToLower(windowsUpdate.GathererName)] = "windowsUpdates"
+	paramGathererMap[strings.ToLower(windowsFeature.GathererName)] = "windowsFeatures"
 	paramGathererMap[strings.ToLower(service.GathererName)] = "services"
 	paramGathererMap[strings.ToLower(registry.GathererName)] = "windowsRegistry"
 	paramGathererMap[strings.ToLower(role.GathererName)] = "windowsRoles"
@@ -16,8 +16,10 @@ package model
 
 import (
 	"fmt"
+	"hash/fnv"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/association/scheduleexpression"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -35,12 +37,42 @@ type InstanceAssociation struct {
 	ParsedExpression  scheduleexpression.ScheduleExpression
 	Document          *string
 	Errors            []error
+	// ScheduleTimezone is the time zone of the cron expression when it doesn't declare one, UTC when empty
+	ScheduleTimezone string
+	// Jitter delays the scheduled runs of the association
+	Jitter time.Duration
+	// CatchUp decides whether a scheduled run missed while the agent was not running runs when the agent starts
+	CatchUp CatchUpPolicy
+}
+
+// CatchUpPolicy decides whether a scheduled association run missed while the agent was not running runs when the
+// agent starts
+type CatchUpPolicy struct {
+	// Policy is one of the appconfig.AssociationCatchUp policies, RunImmediately when empty
+	Policy string
+	// Window is how long after its scheduled time the RunWithinWindow policy runs a missed run
+	Window time.Duration
+	// AgentStartTime is when the agent started, the runs scheduled before were missed
+	AgentStartTime time.Time
+}
+
+// shouldRun returns true if the run missed at scheduledDate runs now
+func (policy CatchUpPolicy) shouldRun(scheduledDate time.Time, now time.Time) bool {
+	switch policy.Policy {
+	case appconfig.AssociationCatchUpSkip:
+		return false
+	case appconfig.AssociationCatchUpRunWithinWindow:
+		return now.Sub(scheduledDate) <= policy.Window
+	default:
+		return true
+	}
 }
 
 // ParseExpression parses the expression with the given association
 func (newAssoc *InstanceAssociation) ParseExpression(log log.T) error {
 
-	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpression(log, *newAssoc.Association.ScheduleExpression)
+	parsedScheduleExpression, err := scheduleexpression.CreateScheduleExpressionInTimezone(log,
+		*newAssoc.Association.ScheduleExpression, newAssoc.ScheduleTimezone)
 
 	if err != nil {
 		return fmt.Errorf("Failed to parse schedule expression %v, %v", *newAssoc.Association.ScheduleExpression, err)
@@ -50,11 +82,97 @@ func (newAssoc *InstanceAssociation) ParseExpression(log log.T) error {
 	return nil
 }
 
+// SetJitter sets the delay of the scheduled runs of the association to a random duration shorter than window, derived
+// from the instance and association ids so that it is stable across runs and differs across the instances of a fleet
+func (newAssoc *InstanceAssociation) SetJitter(instanceID string, window time.Duration) {
+	if window <= 0 {
+		newAssoc.Jitter = 0
+		return
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(instanceID + "/" + *newAssoc.Association.AssociationId))
+	newAssoc.Jitter = time.Duration(hash.Sum64()%uint64(window/time.Second)) * time.Second
+}
+
+// nextScheduledDateAfter returns the first scheduled date after fromTime, shifted by the jitter: the fromTime
+// execution was delayed by the jitter too, so that rate expressions keep their interval
+func (newAssoc *InstanceAssociation) nextScheduledDateAfter(fromTime time.Time) time.Time {
+	nextScheduledDate := newAssoc.ParsedExpression.Next(fromTime.Add(-newAssoc.Jitter))
+	if !nextScheduledDate.IsZero() {
+		nextScheduledDate = nextScheduledDate.Add(newAssoc.Jitter)
+	}
+	return nextScheduledDate
+}
+
 // IsRunOnceAssociation return true for the association that doesn't have schedule expression and will run only once
 func (assoc *InstanceAssociation) IsRunOnceAssociation() bool {
 	return assoc.Association.ScheduleExpression == nil || *assoc.Association.ScheduleExpression == ""
 }
 
+// IsReportOnly returns true if the association checks its document without applying it
+func (assoc *InstanceAssociation) IsReportOnly() bool {
+	mode, found := assoc.Association.Parameters[contracts.AssociationModeParameter]
+	return found && len(mode) == 1 && mode[0] != nil && *mode[0] == contracts.AssociationModeReportOnly
+}
+
+// SerialGroup returns the serialization group of the association, the associations of the same group run one at a time
+func (assoc *InstanceAssociation) SerialGroup() string {
+	group := assoc.Association.Parameters[contracts.AssociationSerialGroupParameter]
+	if len(group) == 1 && group[0] != nil {
+		return *group[0]
+	}
+	return ""
+}
+
+// DependsOn returns the IDs of the associations which must succeed before each run of the association
+func (assoc *InstanceAssociation) DependsOn() (associationIDs []string) {
+	for _, associationID := range assoc.Association.Parameters[contracts.AssociationDependsOnParameter] {
+		if associationID != nil && *associationID != "" && *associationID != *assoc.Association.AssociationId {
+			associationIDs = append(associationIDs, *associationID)
+		}
+	}
+	return associationIDs
+}
+
+// DependenciesState returns whether the associations the association depends on all succeeded since its last run.
+// failed is the first of them which failed since then or which is not in associations, the association waits for
+// the others until they ran.
+func (assoc *InstanceAssociation) DependenciesState(associations []*InstanceAssociation) (succeeded bool, failed string) {
+	var lastRun time.Time
+	if assoc.Association.LastExecutionDate != nil {
+		lastRun = *assoc.Association.LastExecutionDate
+	}
+
+	succeeded = true
+	for _, associationID := range assoc.DependsOn() {
+		var dependency *InstanceAssociation
+		for _, candidate := range associations {
+			if *candidate.Association.AssociationId == associationID {
+				dependency = candidate
+				break
+			}
+		}
+		if dependency == nil {
+			return false, associationID
+		}
+
+		if dependency.Association.LastExecutionDate == nil ||
+			!dependency.Association.LastExecutionDate.After(lastRun) ||
+			dependency.Association.DetailedStatus == nil {
+			succeeded = false
+			continue
+		}
+		switch *dependency.Association.DetailedStatus {
+		case contracts.AssociationStatusSuccess:
+		case contracts.AssociationStatusFailed, contracts.AssociationStatusTimedOut, string(contracts.ResultStatusSkipped):
+			return false, associationID
+		default:
+			succeeded = false
+		}
+	}
+	return succeeded, ""
+}
+
 // RunNow sets the NextScheduledDate to current time
 func (newAssoc *InstanceAssociation) RunNow() {
 	newAssoc.NextScheduledDate = aws.Time(time.Now().UTC())
@@ -99,8 +217,14 @@ func (newAssoc *InstanceAssociation) SetNextScheduledDate(log log.T) {
 	}
 
 	// Set next schedule date of association according to it's schedule
-	newAssoc.NextScheduledDate = aws.Time(
-		newAssoc.ParsedExpression.Next(newAssoc.Association.LastExecutionDate.UTC()).UTC())
+	nextScheduledDate := newAssoc.nextScheduledDateAfter(newAssoc.Association.LastExecutionDate.UTC())
+	if now := time.Now().UTC(); !nextScheduledDate.IsZero() && nextScheduledDate.Before(newAssoc.CatchUp.AgentStartTime) &&
+		!newAssoc.CatchUp.shouldRun(nextScheduledDate, now) {
+		log.Infof("Skipping the run of association %v scheduled at %v and missed while the agent was not running, catch-up policy is %v",
+			*newAssoc.Association.AssociationId, times.ToIsoDashUTC(nextScheduledDate), newAssoc.CatchUp.Policy)
+		nextScheduledDate = newAssoc.nextScheduledDateAfter(now)
+	}
+	newAssoc.NextScheduledDate = aws.Time(nextScheduledDate.UTC())
 	log.Infof("Based upon expression %v and last execution date %v, next scheduled date for association %v is %v",
 		*newAssoc.Association.ScheduleExpression, times.ToIsoDashUTC(*newAssoc.Association.LastExecutionDate),
 		*newAssoc.Association.AssociationId, times.ToIsoDashUTC(*newAssoc.NextScheduledDate))
@@ -17,8 +17,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/association/scheduleexpression"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/stretchr/testify/assert"
 )
@@ -384,3 +386,225 @@ func TestNextScheduledDateIsNilWhenAssociationExpressionTypeIsUnknown(t *testing
 	// Assert
 	assert.Nil(t, assocRawData.NextScheduledDate)
 }
+
+func TestNextScheduledDateIsDelayedByJitterForCronExpression(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+
+	testInstanceAssociation := InstanceAssociation{}
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testCronExpression := "cron(0 0 0/1 * * ? *)" // hourly cron expression
+	testInstanceAssociation.Association.ScheduleExpression = &testCronExpression
+	testInstanceAssociation.Jitter = 10 * time.Minute
+
+	// last execution was delayed by the jitter
+	lastExecutionDateTime := time.Date(2009, 11, 17, 20, 10, 3, 0, time.UTC)
+	testInstanceAssociation.Association.LastExecutionDate = &lastExecutionDateTime
+
+	// Act
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	// Assert
+	assert.Equal(t, time.Date(2009, 11, 17, 21, 10, 0, 0, time.UTC), *testInstanceAssociation.NextScheduledDate)
+}
+
+func TestNextScheduledDateKeepsIntervalWithJitterForRateExpression(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+
+	testInstanceAssociation := InstanceAssociation{}
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testRateExpression := "rate(30 minutes)"
+	testInstanceAssociation.Association.ScheduleExpression = &testRateExpression
+	testInstanceAssociation.Jitter = 10 * time.Minute
+
+	lastExecutionDateTime := time.Date(2009, 11, 17, 20, 34, 58, 0, time.UTC)
+	testInstanceAssociation.Association.LastExecutionDate = &lastExecutionDateTime
+
+	// Act
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	// Assert
+	assert.Equal(t, time.Date(2009, 11, 17, 21, 4, 58, 0, time.UTC), *testInstanceAssociation.NextScheduledDate)
+}
+
+func TestNextScheduledDateIsInScheduleTimezone(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+
+	testInstanceAssociation := InstanceAssociation{}
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testCronExpression := "cron(0 2 * * ? *)" // daily at 2am
+	testInstanceAssociation.Association.ScheduleExpression = &testCronExpression
+	testInstanceAssociation.ScheduleTimezone = "Asia/Tokyo"
+
+	lastExecutionDateTime := time.Date(2009, 11, 17, 12, 0, 0, 0, time.UTC)
+	testInstanceAssociation.Association.LastExecutionDate = &lastExecutionDateTime
+
+	// Act
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	// Assert
+	assert.Equal(t, time.Date(2009, 11, 17, 17, 0, 0, 0, time.UTC), *testInstanceAssociation.NextScheduledDate)
+}
+
+func TestSetJitterIsStableAndWithinWindow(t *testing.T) {
+	testInstanceAssociation := InstanceAssociation{}
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+
+	testInstanceAssociation.SetJitter("i-1234567890abcdef0", time.Hour)
+	jitter := testInstanceAssociation.Jitter
+	testInstanceAssociation.SetJitter("i-1234567890abcdef0", time.Hour)
+
+	assert.Equal(t, jitter, testInstanceAssociation.Jitter)
+	assert.True(t, jitter >= 0 && jitter < time.Hour)
+
+	testInstanceAssociation.SetJitter("i-1234567890abcdef0", 0)
+	assert.Equal(t, time.Duration(0), testInstanceAssociation.Jitter)
+}
+
+func newMissedHourlyAssociation(catchUp CatchUpPolicy) InstanceAssociation {
+	testInstanceAssociation := InstanceAssociation{CatchUp: catchUp}
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testCronExpression := "cron(0 0 0/1 * * ? *)" // hourly cron expression
+	testInstanceAssociation.Association.ScheduleExpression = &testCronExpression
+
+	// the run of the last hour was missed
+	lastExecutionDateTime := time.Now().UTC().Add(-2 * time.Hour)
+	testInstanceAssociation.Association.LastExecutionDate = &lastExecutionDateTime
+	return testInstanceAssociation
+}
+
+func TestNextScheduledDateRunsMissedRunImmediately(t *testing.T) {
+	logger := log.DefaultLogger()
+	testInstanceAssociation := newMissedHourlyAssociation(CatchUpPolicy{
+		Policy:         appconfig.AssociationCatchUpRunImmediately,
+		AgentStartTime: time.Now().UTC(),
+	})
+
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	assert.True(t, testInstanceAssociation.NextScheduledDate.Before(time.Now().UTC()))
+}
+
+func TestNextScheduledDateSkipsMissedRun(t *testing.T) {
+	logger := log.DefaultLogger()
+	testInstanceAssociation := newMissedHourlyAssociation(CatchUpPolicy{
+		Policy:         appconfig.AssociationCatchUpSkip,
+		AgentStartTime: time.Now().UTC(),
+	})
+
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	assert.True(t, testInstanceAssociation.NextScheduledDate.After(time.Now().UTC()))
+	assert.Equal(t, 0, testInstanceAssociation.NextScheduledDate.Minute())
+}
+
+func TestNextScheduledDateRunsRunMissedWhileAgentWasRunning(t *testing.T) {
+	logger := log.DefaultLogger()
+	testInstanceAssociation := newMissedHourlyAssociation(CatchUpPolicy{
+		Policy:         appconfig.AssociationCatchUpSkip,
+		AgentStartTime: time.Now().UTC().Add(-3 * time.Hour),
+	})
+
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	assert.True(t, testInstanceAssociation.NextScheduledDate.Before(time.Now().UTC()))
+}
+
+func TestNextScheduledDateRunsMissedRunWithinWindow(t *testing.T) {
+	logger := log.DefaultLogger()
+	testInstanceAssociation := newMissedHourlyAssociation(CatchUpPolicy{
+		Policy:         appconfig.AssociationCatchUpRunWithinWindow,
+		Window:         2 * time.Hour,
+		AgentStartTime: time.Now().UTC(),
+	})
+
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	assert.True(t, testInstanceAssociation.NextScheduledDate.Before(time.Now().UTC()))
+}
+
+func TestNextScheduledDateSkipsMissedRunOutsideWindow(t *testing.T) {
+	logger := log.DefaultLogger()
+	testInstanceAssociation := newMissedHourlyAssociation(CatchUpPolicy{
+		Policy:         appconfig.AssociationCatchUpRunWithinWindow,
+		Window:         time.Second,
+		AgentStartTime: time.Now().UTC(),
+	})
+
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	assert.True(t, testInstanceAssociation.NextScheduledDate.After(time.Now().UTC()))
+}
+
+func TestIsReportOnly(t *testing.T) {
+	for mode, expected := range map[string]bool{"ReportOnly": true, "Enforce": false, "": false} {
+		testInstanceAssociation := InstanceAssociation{Association: &ssm.InstanceAssociationSummary{}}
+		if mode != "" {
+			testInstanceAssociation.Association.Parameters = map[string][]*string{"associationMode": {aws.String(mode)}}
+		}
+		assert.Equal(t, expected, testInstanceAssociation.IsReportOnly())
+	}
+}
+
+func newDependencyAssociation(associationID string, lastExecutionDate *time.Time, status string, dependsOn ...string) *InstanceAssociation {
+	assoc := &InstanceAssociation{Association: &ssm.InstanceAssociationSummary{
+		AssociationId:     aws.String(associationID),
+		LastExecutionDate: lastExecutionDate,
+		Parameters:        map[string][]*string{"associationDependsOn": aws.StringSlice(dependsOn)},
+	}}
+	if status != "" {
+		assoc.Association.DetailedStatus = aws.String(status)
+	}
+	return assoc
+}
+
+func TestDependenciesState(t *testing.T) {
+	lastRun := time.Now().UTC().Add(-time.Hour)
+	before, after := aws.Time(lastRun.Add(-time.Minute)), aws.Time(lastRun.Add(time.Minute))
+	testCases := []struct {
+		dependency        *InstanceAssociation
+		expectedSucceeded bool
+		expectedFailed    string
+	}{
+		{newDependencyAssociation("a", after, "Success"), true, ""},
+		{newDependencyAssociation("a", before, "Success"), false, ""},
+		{newDependencyAssociation("a", after, "InProgress"), false, ""},
+		{newDependencyAssociation("a", nil, "Pending"), false, ""},
+		{newDependencyAssociation("a", after, "Failed"), false, "a"},
+		{newDependencyAssociation("other", after, "Success"), false, "a"},
+	}
+	for _, testCase := range testCases {
+		assoc := newDependencyAssociation("b", aws.Time(lastRun), "Success", "a")
+		succeeded, failed := assoc.DependenciesState([]*InstanceAssociation{assoc, testCase.dependency})
+		assert.Equal(t, testCase.expectedSucceeded, succeeded)
+		assert.Equal(t, testCase.expectedFailed, failed)
+	}
+}
+
+func TestDependenciesStateOfFirstRun(t *testing.T) {
+	assoc := newDependencyAssociation("b", nil, "Associated", "a", "b")
+	assert.Equal(t, []string{"a"}, assoc.DependsOn())
+
+	succeeded, failed := assoc.DependenciesState([]*InstanceAssociation{newDependencyAssociation("a", aws.Time(time.Now()), "Success")})
+	assert.True(t, succeeded)
+	assert.Empty(t, failed)
+}
+
+func TestSerialGroup(t *testing.T) {
+	assoc := InstanceAssociation{Association: &ssm.InstanceAssociationSummary{}}
+	assert.Empty(t, assoc.SerialGroup())
+	assoc.Association.Parameters = map[string][]*string{"associationSerialGroup": {aws.String("patching")}}
+	assert.Equal(t, "patching", assoc.SerialGroup())
+}
@@ -103,7 +103,15 @@ func InitializeDocumentState(context context.T,
 		MainSteps:     payload.DocumentContent.MainSteps,
 		Parameters:    payload.DocumentContent.Parameters,
 	}
-	return docparser.InitializeDocState(context.Log(), contracts.Association, docContent, documentInfo, parserInfo, payload.Parameters)
+	docState, err := docparser.InitializeDocState(context.Log(), contracts.Association, docContent, documentInfo, parserInfo, payload.Parameters)
+	docState.SerialKey = rawData.SerialGroup()
+	if err == nil && rawData.IsReportOnly() {
+		context.Log().Infof("Association %v runs in %v mode", documentInfo.AssociationID, contracts.AssociationModeReportOnly)
+		for i := range docState.InstancePluginsInformation {
+			docState.InstancePluginsInformation[i].Configuration.ReportOnly = true
+		}
+	}
+	return docState, err
 }
 
 // newDocumentInfo initializes new DocumentInfo object
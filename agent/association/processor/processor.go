@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager/signal"
 	assocScheduler "github.com/aws/amazon-ssm-agent/agent/association/scheduler"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
+	complianceModel "github.com/aws/amazon-ssm-agent/agent/compliance/model"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/compliance/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -47,6 +49,7 @@ const (
 	documentLevelTimeOutDurationHour        = 2
 	outputMessageTemplate            string = "%v out of %v plugin%v processed, %v success, %v failed, %v timedout, %v skipped. %v"
 	defaultRetryWaitOnBootInSeconds         = 30
+	dependencyWaitDuration                  = time.Minute
 )
 
 // Processor contains the logic for processing association
@@ -59,6 +62,10 @@ type Processor struct {
 	proc               processor.Processor
 	resChan            chan contracts.DocumentResult
 	onBoot             bool
+	// startTime is when the processor started, the associations scheduled before were missed
+	startTime time.Time
+	// workersLimit is the maximum number of associations running at the same time
+	workersLimit int
 }
 
 var lock sync.RWMutex
@@ -83,7 +90,8 @@ func NewAssociationProcessor(context context.T) *Processor {
 
 	//TODO Rename everything to service and move package to framework
 	//association has no cancel worker
-	proc := processor.NewEngineProcessor(assocContext, documentWorkersLimit, documentWorkersLimit, []contracts.DocumentType{contracts.Association})
+	workersLimit := config.Ssm.AssociationWorkersLimit
+	proc := processor.NewEngineProcessor(assocContext, workersLimit, documentWorkersLimit, []contracts.DocumentType{contracts.Association})
 	return &Processor{
 		context:            assocContext,
 		assocSvc:           assocSvc,
@@ -91,6 +99,8 @@ func NewAssociationProcessor(context context.T) *Processor {
 		agentInfo:          &agentInfo,
 		proc:               proc,
 		onBoot:             true,
+		startTime:          time.Now().UTC(),
+		workersLimit:       workersLimit,
 	}
 }
 
@@ -145,6 +155,19 @@ func (p *Processor) SetPollJob(job *scheduler.Job) {
 	p.pollJob = job
 }
 
+// applyScheduleConfig sets the time zone, the jitter and the catch-up policy of the association schedule from the
+// agent configuration
+func (p *Processor) applyScheduleConfig(assoc *model.InstanceAssociation, instanceID string) {
+	config := p.context.AppConfig()
+	assoc.ScheduleTimezone = config.Ssm.AssociationScheduleTimezone
+	assoc.SetJitter(instanceID, time.Duration(config.Ssm.AssociationJitterSeconds)*time.Second)
+	assoc.CatchUp = model.CatchUpPolicy{
+		Policy:         config.Ssm.AssociationCatchUpPolicy,
+		Window:         time.Duration(config.Ssm.AssociationCatchUpWindowHours) * time.Hour,
+		AgentStartTime: p.startTime,
+	}
+}
+
 // ProcessAssociation poll and process all the associations
 func (p *Processor) ProcessAssociation() {
 	log := p.context.Log()
@@ -221,6 +244,7 @@ func (p *Processor) ProcessAssociation() {
 			continue
 		}
 
+		p.applyScheduleConfig(assoc, instanceID)
 		if !assoc.IsRunOnceAssociation() {
 			if err = assoc.ParseExpression(log); err != nil {
 				message := fmt.Sprintf("Encountered error while parsing expression for association %v", *assoc.Association.AssociationId)
@@ -258,7 +282,7 @@ func (p *Processor) ProcessAssociation() {
 	log.Debug("ProcessAssociation completed")
 }
 
-// runScheduledAssociation runs the next scheduled association
+// runScheduledAssociation runs the scheduled associations while fewer than the workers limit associations are running
 func (p *Processor) runScheduledAssociation(log log.T) {
 	log.Debug("runScheduledAssociation starting")
 
@@ -273,57 +297,128 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 	}()
 
 	var (
-		scheduledAssociation *model.InstanceAssociation
-		err                  error
+		scheduledAssociations []*model.InstanceAssociation
+		err                   error
 	)
 
-	if scheduledAssociation, err = schedulemanager.LoadNextScheduledAssociation(log); err != nil {
+	if scheduledAssociations, err = schedulemanager.LoadScheduledAssociations(log); err != nil {
 		log.Errorf("Unable to get next scheduled association, %v, system will retry later", err)
 		return
 	}
 
-	if scheduledAssociation == nil {
+	if len(scheduledAssociations) == 0 {
 		// if no scheduled association found at given time, get the next scheduled time and wait
-		nextScheduledDate := schedulemanager.LoadNextScheduledDate(log)
-		if nextScheduledDate != nil {
-			signal.ResetWaitTimerForNextScheduledAssociation(log, *nextScheduledDate)
-		} else {
-			log.Debug("No association scheduled at this time, system will retry later")
-		}
+		p.waitForNextScheduledAssociation(log)
 		return
 	}
 
 	// stop previous wait timer if there is scheduled association
 	signal.StopWaitTimerForNextScheduledAssociation()
 
-	if schedulemanager.IsAssociationInProgress(*scheduledAssociation.Association.AssociationId) {
-		log.Debug("runScheduledAssociation is InProgress")
-		if isAssociationTimedOut(scheduledAssociation) {
-			err = fmt.Errorf("Association stuck at InProgress for longer than %v hours", documentLevelTimeOutDurationHour)
-			log.Error(err)
-			p.assocSvc.UpdateInstanceAssociationStatus(
-				log,
-				*scheduledAssociation.Association.AssociationId,
-				*scheduledAssociation.Association.Name,
-				*scheduledAssociation.Association.InstanceId,
-				contracts.AssociationStatusFailed,
-				contracts.AssociationErrorCodeStuckAtInProgressError,
-				times.ToIso8601UTC(time.Now()),
-				err.Error(),
-				service.NoOutputUrl)
-			p.complianceUploader.UpdateAssociationCompliance(
-				*scheduledAssociation.Association.AssociationId,
-				*scheduledAssociation.Association.InstanceId,
-				*scheduledAssociation.Association.Name,
-				*scheduledAssociation.Association.DocumentVersion,
-				contracts.AssociationStatusFailed,
-				time.Now().UTC())
-
+	running := schedulemanager.CountAssociationsInProgress()
+	for _, scheduledAssociation := range scheduledAssociations {
+		if schedulemanager.IsAssociationInProgress(*scheduledAssociation.Association.AssociationId) {
+			p.checkAssociationInProgress(log, scheduledAssociation)
+			continue
+		}
+		if running >= p.workersLimit {
+			log.Debugf("%v associations are running, association %v waits for a worker", running, *scheduledAssociation.Association.AssociationId)
+			break
 		}
+		if !p.checkDependencies(log, scheduledAssociation) {
+			continue
+		}
+		p.runAssociation(log, scheduledAssociation)
+		running++
+	}
 
-		return
+	// the associations which were not run are postponed, the completion of a running association signals the next run
+	if running == 0 {
+		p.waitForNextScheduledAssociation(log)
+	}
+}
+
+// waitForNextScheduledAssociation arms the timer signaling the next scheduled association
+func (p *Processor) waitForNextScheduledAssociation(log log.T) {
+	nextScheduledDate := schedulemanager.LoadNextScheduledDate(log)
+	if nextScheduledDate != nil {
+		signal.ResetWaitTimerForNextScheduledAssociation(log, *nextScheduledDate)
+	} else {
+		log.Debug("No association scheduled at this time, system will retry later")
 	}
+}
 
+// checkAssociationInProgress fails the association when it is stuck at InProgress
+func (p *Processor) checkAssociationInProgress(log log.T, scheduledAssociation *model.InstanceAssociation) {
+	log.Debug("runScheduledAssociation is InProgress")
+	if isAssociationTimedOut(scheduledAssociation) {
+		err := fmt.Errorf("Association stuck at InProgress for longer than %v hours", documentLevelTimeOutDurationHour)
+		log.Error(err)
+		p.assocSvc.UpdateInstanceAssociationStatus(
+			log,
+			*scheduledAssociation.Association.AssociationId,
+			*scheduledAssociation.Association.Name,
+			*scheduledAssociation.Association.InstanceId,
+			contracts.AssociationStatusFailed,
+			contracts.AssociationErrorCodeStuckAtInProgressError,
+			times.ToIso8601UTC(time.Now()),
+			err.Error(),
+			service.NoOutputUrl)
+		p.complianceUploader.UpdateAssociationCompliance(
+			*scheduledAssociation.Association.AssociationId,
+			*scheduledAssociation.Association.InstanceId,
+			*scheduledAssociation.Association.Name,
+			*scheduledAssociation.Association.DocumentVersion,
+			contracts.AssociationStatusFailed,
+			time.Now().UTC())
+	}
+}
+
+// checkDependencies returns true if the associations the association depends on succeeded since its last run.
+// The association is postponed while they didn't run and fails when one of them failed.
+func (p *Processor) checkDependencies(log log.T, scheduledAssociation *model.InstanceAssociation) bool {
+	dependsOn := scheduledAssociation.DependsOn()
+	if len(dependsOn) == 0 {
+		return true
+	}
+
+	associationID := *scheduledAssociation.Association.AssociationId
+	succeeded, failed := scheduledAssociation.DependenciesState(schedulemanager.Schedules())
+	if failed != "" {
+		err := fmt.Errorf("Association %v depends on association %v which failed or is not associated with the instance", associationID, failed)
+		log.Error(err)
+		p.assocSvc.UpdateInstanceAssociationStatus(
+			log,
+			associationID,
+			*scheduledAssociation.Association.Name,
+			*scheduledAssociation.Association.InstanceId,
+			contracts.AssociationStatusFailed,
+			contracts.AssociationErrorCodeDependencyError,
+			times.ToIso8601UTC(time.Now()),
+			err.Error(),
+			service.NoOutputUrl)
+		p.complianceUploader.UpdateAssociationCompliance(
+			associationID,
+			*scheduledAssociation.Association.InstanceId,
+			*scheduledAssociation.Association.Name,
+			*scheduledAssociation.Association.DocumentVersion,
+			contracts.AssociationStatusFailed,
+			time.Now().UTC())
+		schedulemanager.UpdateNextScheduledDate(log, associationID)
+		return false
+	}
+
+	if !succeeded {
+		log.Infof("Association %v waits for the associations it depends on %v", associationID, dependsOn)
+		schedulemanager.PostponeScheduledDate(log, associationID, time.Now().UTC().Add(dependencyWaitDuration))
+		return false
+	}
+	return true
+}
+
+// runAssociation submits the scheduled association to the document processor
+func (p *Processor) runAssociation(log log.T, scheduledAssociation *model.InstanceAssociation) {
+	var err error
 	log.Debugf("Update association %v to pending ", *scheduledAssociation.Association.AssociationId)
 	// Update association status to pending
 	p.assocSvc.UpdateInstanceAssociationStatus(
@@ -522,6 +617,18 @@ func (r *Processor) associationExecutionReport(
 		executionSummary,
 		outputUrl)
 
+	if len(outputs) > 0 {
+		r.complianceUploader.UpdateAssociationStepsCompliance(
+			associationID,
+			instanceID,
+			documentName,
+			documentVersion,
+			associationStatus,
+			stepComplianceItems(outputs),
+			time.Now().UTC())
+		return
+	}
+
 	r.complianceUploader.UpdateAssociationCompliance(
 		associationID,
 		instanceID,
@@ -531,6 +638,40 @@ func (r *Processor) associationExecutionReport(
 		time.Now().UTC())
 }
 
+// stepComplianceItems returns the compliance items of the steps of an association sorted by step id,
+// a step is non compliant when it did not succeed or, in report-only mode, when it would change the instance
+func stepComplianceItems(outputs map[string]*contracts.PluginResult) (steps []complianceModel.StepComplianceItem) {
+	var stepIDs []string
+	for stepID := range outputs {
+		stepIDs = append(stepIDs, stepID)
+	}
+	sort.Strings(stepIDs)
+	for _, stepID := range stepIDs {
+		output := outputs[stepID]
+		step := complianceModel.StepComplianceItem{
+			StepId:             stepID,
+			ComplianceSeverity: output.ComplianceSeverity,
+			ComplianceStatus:   complianceModel.COMPLIANT,
+		}
+		switch {
+		case output.Status == contracts.ResultStatusSkipped:
+			step.Message = "Step was skipped"
+		case output.Status != contracts.ResultStatusSuccess:
+			step.ComplianceStatus = complianceModel.NON_COMPLIANT
+			step.Message = output.Error
+			if step.Message == "" {
+				step.Message = fmt.Sprintf("Step %v", output.Status)
+			}
+		case output.Drift != nil && len(output.Drift.Changes) > 0:
+			step.ComplianceStatus = complianceModel.NON_COMPLIANT
+			step.Message = strings.Join(output.Drift.Changes, "\n")
+			step.Drift = output.Drift.Changes
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
 func (r *Processor) listenToResponses() {
 	log := r.context.Log()
 	for res := range r.resChan {
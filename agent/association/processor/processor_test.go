@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
+	complianceModel "github.com/aws/amazon-ssm-agent/agent/compliance/model"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/compliance/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -314,3 +315,52 @@ func createAssociationRawData() []*model.InstanceAssociation {
 
 	return []*model.InstanceAssociation{&assocRawData}
 }
+
+func TestStepComplianceItems(t *testing.T) {
+	steps := stepComplianceItems(map[string]*contracts.PluginResult{
+		"step3": {Status: contracts.ResultStatusSuccess, Drift: &contracts.DriftReport{Checked: true, Changes: []string{"service b would change"}}},
+		"step2": {Status: contracts.ResultStatusFailed, Error: "step2 failed", ComplianceSeverity: "HIGH"},
+		"step1": {Status: contracts.ResultStatusSuccess, Drift: &contracts.DriftReport{Checked: true}},
+		"step4": {Status: contracts.ResultStatusSkipped, Drift: &contracts.DriftReport{}},
+	})
+
+	assert.Equal(t, []complianceModel.StepComplianceItem{
+		{StepId: "step1", ComplianceStatus: complianceModel.COMPLIANT},
+		{StepId: "step2", ComplianceSeverity: "HIGH", ComplianceStatus: complianceModel.NON_COMPLIANT, Message: "step2 failed"},
+		{StepId: "step3", ComplianceStatus: complianceModel.NON_COMPLIANT, Message: "service b would change", Drift: []string{"service b would change"}},
+		{StepId: "step4", ComplianceStatus: complianceModel.COMPLIANT, Message: "Step was skipped"},
+	}, steps)
+}
+
+func TestCheckDependencies(t *testing.T) {
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	complianceUploader := complianceUploader.NewMockDefault()
+	processor.assocSvc = svcMock
+	processor.complianceUploader = complianceUploader
+	svcMock.On("UpdateInstanceAssociationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	complianceUploader.On("UpdateAssociationCompliance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	dependency := createAssociationRawData()[0]
+	dependency.Association.DetailedStatus = aws.String(contracts.AssociationStatusInProgress)
+	assoc := createAssociationRawData()[0]
+	assoc.Association.AssociationId = aws.String("Id-Dependent")
+	assoc.Association.LastExecutionDate = aws.Time(dependency.Association.LastExecutionDate.Add(-time.Hour))
+	assoc.Association.Parameters = map[string][]*string{contracts.AssociationDependsOnParameter: {aws.String("Id-Test")}}
+	logger := processor.context.Log()
+	schedulemanager.Refresh(logger, []*model.InstanceAssociation{dependency, assoc})
+
+	// the association waits while its dependency runs
+	assert.False(t, processor.checkDependencies(logger, assoc))
+	assert.True(t, assoc.NextScheduledDate.After(time.Now().UTC()))
+	assert.True(t, svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 0))
+
+	dependency.Association.DetailedStatus = aws.String(contracts.AssociationStatusSuccess)
+	assert.True(t, processor.checkDependencies(logger, assoc))
+
+	// the association fails when its dependency failed
+	dependency.Association.DetailedStatus = aws.String(contracts.AssociationStatusFailed)
+	assert.False(t, processor.checkDependencies(logger, assoc))
+	assert.True(t, svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1))
+	assert.True(t, complianceUploader.AssertNumberOfCalls(t, "UpdateAssociationCompliance", 1))
+}
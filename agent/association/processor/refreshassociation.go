@@ -123,6 +123,7 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 
 		// validate association expression, fail association if expression cannot be passed
 		// Note: we do not want to fail runcommand with out.MarkAsFailed
+		p.applyScheduleConfig(assoc, instanceID)
 		if !assoc.IsRunOnceAssociation() {
 			if err := assoc.ParseExpression(log); err != nil {
 				message := fmt.Sprintf("Encountered error while parsing expression for association %v", *assoc.Association.AssociationId)
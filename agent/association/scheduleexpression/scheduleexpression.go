@@ -29,6 +29,9 @@ import (
 const (
 	expressionTypeCron = "cron"
 	expressionTypeRate = "rate"
+
+	// timezonePrefix declares the time zone of a cron expression
+	timezonePrefix = "TZ="
 )
 
 //ScheduleExpression defines operations of a valid schedule expression which association/model makes use of
@@ -36,7 +39,14 @@ type ScheduleExpression interface {
 	Next(fromTime time.Time) time.Time
 }
 
+// CreateScheduleExpression parses a cron or rate schedule expression
 func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExpression, error) {
+	return CreateScheduleExpressionInTimezone(log, scheduleExpression, "")
+}
+
+// CreateScheduleExpressionInTimezone parses a schedule expression whose cron expression runs in the IANA time zone
+// declared by its TZ=<zone> prefix, e.g. cron(TZ=Europe/Paris 0 2 ? * SUN *), or else in timezone when not empty.
+func CreateScheduleExpressionInTimezone(log log.T, scheduleExpression string, timezone string) (ScheduleExpression, error) {
 
 	lowerCasedScheduledExpression := strings.ToLower(scheduleExpression)
 
@@ -47,9 +57,26 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 		}
 
 		cronExpression := scheduleExpression[len(expressionTypeCron)+1 : len(scheduleExpression)-1]
+		if fields := strings.Fields(cronExpression); len(fields) > 0 && strings.HasPrefix(strings.ToUpper(fields[0]), timezonePrefix) {
+			timezone = fields[0][len(timezonePrefix):]
+			cronExpression = strings.Join(fields[1:], " ")
+		}
+
+		var location *time.Location
+		if timezone != "" {
+			if location, err = time.LoadLocation(timezone); err != nil {
+				err = fmt.Errorf("Unknown time zone %v of cron expression %v", timezone, scheduleExpression)
+				log.Error(err)
+				return nil, err
+			}
+		}
+
 		parsedCronExpression, err := cronexpr.Parse(cronExpression)
 
 		if err == nil {
+			if location != nil {
+				return &cronExpressionInLocation{expression: parsedCronExpression, location: location}, nil
+			}
 			return parsedCronExpression, nil
 		} else {
 			message := fmt.Sprintf("Error %v received while parsing cron expression %v", err, scheduleExpression)
@@ -73,6 +100,21 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 	return nil, fmt.Errorf("Unknown expression type detected in expression %v", scheduleExpression)
 }
 
+// cronExpressionInLocation is a cron expression running in a time zone
+type cronExpressionInLocation struct {
+	expression *cronexpr.Expression
+	location   *time.Location
+}
+
+// Next returns the closest time instant immediately following fromTime which matches the cron expression in its
+// time zone, the returned time instant is in that time zone.
+func (expr *cronExpressionInLocation) Next(fromTime time.Time) time.Time {
+	if fromTime.IsZero() {
+		return fromTime
+	}
+	return expr.expression.Next(fromTime.In(expr.location))
+}
+
 func validateCronExpression(log log.T, scheduleExpression string) error {
 	cronRegularExpression := regexp.MustCompile("(?i)(cron\\(.*\\))")
 	result := cronRegularExpression.FindAllStringSubmatch(scheduleExpression, -1)
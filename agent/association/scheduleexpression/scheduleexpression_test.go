@@ -15,6 +15,7 @@ package scheduleexpression
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
@@ -131,3 +132,44 @@ func TestParseReturnsErrorWhenScheduleExpressionIsOfUnknownType(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Unknown expression type detected in expression at(12:00)", err.Error())
 }
+
+func TestParseReturnsCronExpressionInDeclaredTimezone(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	// Act
+	parsedExpression, err := CreateScheduleExpressionInTimezone(logger, "cron(TZ=America/New_York 0 2 * * ? *)", "Europe/Paris")
+
+	// Assert
+	assert.Nil(t, err)
+	next := parsedExpression.Next(time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2018, 7, 2, 2, 0, 0, 0, newYork).UTC(), next.UTC())
+}
+
+func TestParseReturnsCronExpressionInGivenTimezone(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	// Act
+	parsedExpression, err := CreateScheduleExpressionInTimezone(logger, "cron(0 2 * * ? *)", "Europe/Paris")
+
+	// Assert
+	assert.Nil(t, err)
+	next := parsedExpression.Next(time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2018, 7, 2, 2, 0, 0, 0, paris).UTC(), next.UTC())
+}
+
+func TestParseReturnsErrorWhenCronExpressionTimezoneIsUnknown(t *testing.T) {
+	// Assemble
+	logger := log.DefaultLogger()
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "cron(TZ=Mars/Olympus 0 2 * * ? *)")
+
+	// Assert
+	assert.Nil(t, parsedExpression)
+	assert.NotNil(t, err)
+	assert.Equal(t, "Unknown time zone Mars/Olympus of cron expression cron(TZ=Mars/Olympus 0 2 * * ? *)", err.Error())
+}
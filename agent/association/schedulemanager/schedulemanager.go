@@ -71,15 +71,12 @@ func Refresh(log log.T, assocs []*model.InstanceAssociation) {
 	log.Infof("Schedule manager refreshed with %v associations, %v new associations associated", len(associations), numberOfNewAssoc)
 }
 
-// LoadNextScheduledAssociation returns next scheduled association
-func LoadNextScheduledAssociation(log log.T) (*model.InstanceAssociation, error) {
+// LoadScheduledAssociations returns the associations whose scheduled date has passed, in the order of the schedules
+func LoadScheduledAssociations(log log.T) ([]*model.InstanceAssociation, error) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if len(associations) == 0 {
-		return nil, nil
-	}
-
+	var scheduledAssociations []*model.InstanceAssociation
 	for _, assoc := range associations {
 		currentTime := time.Now().UTC()
 		if assoc.NextScheduledDate == nil {
@@ -93,11 +90,11 @@ func LoadNextScheduledAssociation(log log.T) (*model.InstanceAssociation, error)
 				log.Infof("Next scheduled association is %v", jsonutil.Indent(assocContent))
 			}
 
-			return assoc, nil
+			scheduledAssociations = append(scheduledAssociations, assoc)
 		}
 	}
 
-	return nil, nil
+	return scheduledAssociations, nil
 }
 
 // LoadNextScheduledDate returns next scheduled date
@@ -140,6 +137,20 @@ func UpdateNextScheduledDate(log log.T, associationID string) {
 	}
 }
 
+// PostponeScheduledDate sets the next scheduled date of the given association to scheduledDate
+func PostponeScheduledDate(log log.T, associationID string, scheduledDate time.Time) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, assoc := range associations {
+		if *assoc.Association.AssociationId == associationID {
+			assoc.NextScheduledDate = aws.Time(scheduledDate)
+			log.Infof("Postponing association %v to %v", associationID, times.ToIsoDashUTC(scheduledDate))
+			break
+		}
+	}
+}
+
 // UpdateAssociationStatus sets detailed status for the given association
 func UpdateAssociationStatus(associationID string, status string) {
 	lock.Lock()
@@ -175,6 +186,20 @@ func IsAssociationInProgress(associationID string) bool {
 	return false
 }
 
+// CountAssociationsInProgress returns the number of associations with detailed status InProgress
+func CountAssociationsInProgress() (count int) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, assoc := range associations {
+		if assoc.Association.DetailedStatus != nil &&
+			*assoc.Association.DetailedStatus == contracts.AssociationStatusInProgress {
+			count++
+		}
+	}
+	return count
+}
+
 // Schedules returns all the cached schedules
 func Schedules() []*model.InstanceAssociation {
 	lock.RLock()
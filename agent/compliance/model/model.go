@@ -37,6 +37,20 @@ type AssociationComplianceItem struct {
 	Title              string
 	ComplianceSeverity string
 	ComplianceStatus   string
+	// Drift lists the changes a report-only association would apply
+	Drift []string `json:",omitempty"`
+	// Steps stores the compliance of each document step, one compliance item is reported per step when set
+	Steps []StepComplianceItem `json:",omitempty"`
+}
+
+// StepComplianceItem stores the compliance of one step of an association document
+type StepComplianceItem struct {
+	StepId             string
+	ComplianceSeverity string
+	ComplianceStatus   string
+	Message            string
+	// Drift lists the changes a report-only step would apply
+	Drift []string `json:",omitempty"`
 }
 
 // Association compliance status is Unspecified by default
@@ -47,53 +61,84 @@ var lock = sync.RWMutex{}
  * Update compliance item based on the executed instance association and update timestamp.
  */
 func UpdateAssociationComplianceItem(associationId string, documentName string, documentVersion string, associationStatus string, executionTime time.Time) {
-	if contracts.AssociationStatusTimedOut != associationStatus &&
-		contracts.AssociationStatusSuccess != associationStatus &&
-		contracts.AssociationStatusFailed != associationStatus {
+	if !isFinalAssociationStatus(associationStatus) {
 		return
 	}
 
-	lock.Lock()
-	defer lock.Unlock()
-
 	var compliantStatus = COMPLIANT
 	if contracts.AssociationStatusSuccess != associationStatus {
 		compliantStatus = NON_COMPLIANT
 	}
 
-	var statusFound = false
-	for i, status := range associationComplianceItems {
-		if status.AssociationId == associationId {
-			if status.ExecutionTime.Before(executionTime) {
-				associationComplianceItems[i] = &AssociationComplianceItem{
-					associationId,
-					executionTime,
-					documentName,
-					documentVersion,
-					ASSOCIATION_COMPLIANCE_TITLE,
-					UNSPECIFIED,
-					compliantStatus,
-				}
-			}
+	updateComplianceItem(&AssociationComplianceItem{
+		AssociationId:      associationId,
+		ExecutionTime:      executionTime,
+		DocumentName:       documentName,
+		DocumentVersion:    documentVersion,
+		Title:              ASSOCIATION_COMPLIANCE_TITLE,
+		ComplianceSeverity: UNSPECIFIED,
+		ComplianceStatus:   compliantStatus,
+	})
+}
 
-			statusFound = true
-			break
-		}
+/**
+ * Update compliance item of an association from the compliance items of its document steps,
+ * the association is compliant when it succeeded and all its steps are compliant.
+ */
+func UpdateAssociationStepsComplianceItem(associationId string, documentName string, documentVersion string, associationStatus string, steps []StepComplianceItem, executionTime time.Time) {
+	if !isFinalAssociationStatus(associationStatus) {
+		return
 	}
 
-	if !statusFound {
-		var newStatus = &AssociationComplianceItem{
-			associationId,
-			executionTime,
-			documentName,
-			documentVersion,
-			ASSOCIATION_COMPLIANCE_TITLE,
-			UNSPECIFIED,
-			compliantStatus,
+	var compliantStatus = COMPLIANT
+	if contracts.AssociationStatusSuccess != associationStatus {
+		compliantStatus = NON_COMPLIANT
+	}
+	var drift []string
+	for _, step := range steps {
+		if step.ComplianceStatus != COMPLIANT {
+			compliantStatus = NON_COMPLIANT
 		}
+		for _, change := range step.Drift {
+			drift = append(drift, step.StepId+": "+change)
+		}
+	}
+
+	updateComplianceItem(&AssociationComplianceItem{
+		AssociationId:      associationId,
+		ExecutionTime:      executionTime,
+		DocumentName:       documentName,
+		DocumentVersion:    documentVersion,
+		Title:              ASSOCIATION_COMPLIANCE_TITLE,
+		ComplianceSeverity: UNSPECIFIED,
+		ComplianceStatus:   compliantStatus,
+		Drift:              drift,
+		Steps:              steps,
+	})
+}
+
+// isFinalAssociationStatus returns true for the association statuses reported as compliance
+func isFinalAssociationStatus(associationStatus string) bool {
+	return contracts.AssociationStatusTimedOut == associationStatus ||
+		contracts.AssociationStatusSuccess == associationStatus ||
+		contracts.AssociationStatusFailed == associationStatus
+}
+
+// updateComplianceItem replaces the compliance item of the association unless it is more recent than newItem
+func updateComplianceItem(newItem *AssociationComplianceItem) {
+	lock.Lock()
+	defer lock.Unlock()
 
-		associationComplianceItems = append(associationComplianceItems, newStatus)
+	for i, item := range associationComplianceItems {
+		if item.AssociationId == newItem.AssociationId {
+			if item.ExecutionTime.Before(newItem.ExecutionTime) {
+				associationComplianceItems[i] = newItem
+			}
+			return
+		}
 	}
+
+	associationComplianceItems = append(associationComplianceItems, newItem)
 }
 
 /**
@@ -120,6 +120,30 @@ func TestUpdateAssociationComplianceItemIgnoreStaleUpdate(t *testing.T) {
 	assert.Equal(t, item1.Title, ASSOCIATION_COMPLIANCE_TITLE)
 }
 
+func TestUpdateAssociationStepsComplianceItem(t *testing.T) {
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+
+	executionTime := time.Now()
+	compliantSteps := []StepComplianceItem{{StepId: "step1", ComplianceStatus: COMPLIANT}}
+	driftedSteps := []StepComplianceItem{
+		{StepId: "step1", ComplianceStatus: COMPLIANT},
+		{StepId: "step2", ComplianceStatus: NON_COMPLIANT, Drift: []string{"service nginx would change"}},
+	}
+	UpdateAssociationStepsComplianceItem("association_1", "testDoc", "1", contracts.AssociationStatusSuccess, compliantSteps, executionTime)
+	UpdateAssociationStepsComplianceItem("association_2", "testDoc", "1", contracts.AssociationStatusSuccess, driftedSteps, executionTime)
+	UpdateAssociationStepsComplianceItem("association_3", "testDoc", "1", contracts.AssociationStatusFailed, compliantSteps, executionTime)
+	UpdateAssociationStepsComplianceItem("association_4", "testDoc", "1", contracts.AssociationStatusInProgress, compliantSteps, executionTime)
+
+	complianceItems := GetAssociationComplianceEntries()
+	assert.Equal(t, 3, len(complianceItems))
+	assert.Equal(t, COMPLIANT, complianceItems[0].ComplianceStatus)
+	assert.Empty(t, complianceItems[0].Drift)
+	assert.Equal(t, compliantSteps, complianceItems[0].Steps)
+	assert.Equal(t, NON_COMPLIANT, complianceItems[1].ComplianceStatus)
+	assert.Equal(t, []string{"step2: service nginx would change"}, complianceItems[1].Drift)
+	assert.Equal(t, NON_COMPLIANT, complianceItems[2].ComplianceStatus)
+}
+
 func TestRefreshAssociationComplianceItems(t *testing.T) {
 	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
 	association1 := &model.InstanceAssociation{
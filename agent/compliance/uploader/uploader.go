@@ -17,6 +17,7 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -39,6 +40,9 @@ const (
 	associationComplianceType     = "Association"
 	Name                          = "ComplianceUploader"
 	AssociationComplianceItemName = "AssociationComplianceItem"
+	// maxDetailLength is the length limit of the message and drift details of the compliance items
+	maxDetailLength       = 1024
+	detailTruncatedSuffix = "..."
 )
 
 var (
@@ -49,6 +53,7 @@ var (
 type T interface {
 	CreateNewServiceIfUnHealthy(log log.T)
 	UpdateAssociationCompliance(associationId string, instanceId string, documentName string, documentVersion string, associationStatus string, executionTime time.Time) error
+	UpdateAssociationStepsCompliance(associationId string, instanceId string, documentName string, documentVersion string, associationStatus string, steps []model.StepComplianceItem, executionTime time.Time) error
 }
 
 // ComplianceService wraps the Ssm Service
@@ -116,9 +121,28 @@ func (u *ComplianceUploader) UpdateAssociationCompliance(associationID string, i
 		return nil
 	}
 
+	model.UpdateAssociationComplianceItem(associationID, documentName, documentVersion, associationStatus, executionTime)
+	return u.putAssociationCompliance(instanceID, executionTime)
+}
+
+/**
+ * Update association compliance status with one compliance item per document step
+ */
+func (u *ComplianceUploader) UpdateAssociationStepsCompliance(associationID string, instanceID string, documentName string, documentVersion string, associationStatus string, steps []model.StepComplianceItem, executionTime time.Time) error {
+	if contracts.AssociationStatusTimedOut != associationStatus &&
+		contracts.AssociationStatusSuccess != associationStatus &&
+		contracts.AssociationStatusFailed != associationStatus {
+		return nil
+	}
+
+	model.UpdateAssociationStepsComplianceItem(associationID, documentName, documentVersion, associationStatus, steps, executionTime)
+	return u.putAssociationCompliance(instanceID, executionTime)
+}
+
+// putAssociationCompliance puts the compliance items of all the associations
+func (u *ComplianceUploader) putAssociationCompliance(instanceID string, executionTime time.Time) error {
 	log := u.context.Log()
 
-	model.UpdateAssociationComplianceItem(associationID, documentName, documentVersion, associationStatus, executionTime)
 	var associationComplianceEntries = model.GetAssociationComplianceEntries()
 
 	oldHash := u.optimizer.GetContentHash(AssociationComplianceItemName)
@@ -178,6 +202,10 @@ func (u *ComplianceUploader) ConvertToSsmAssociationComplianceItems(log log.T, a
 	log.Debugf("Compliance data for %v is NOT same as before - we send the whole content", AssociationComplianceItemName)
 
 	for _, item := range associationComplianceEntries {
+		if len(item.Steps) > 0 {
+			associationComplianceItems = append(associationComplianceItems, stepComplianceItems(item)...)
+			continue
+		}
 		var complianceItem = &ssm.ComplianceItemEntry{
 			Id:       aws.String(item.AssociationId),
 			Status:   aws.String(item.ComplianceStatus),
@@ -188,8 +216,49 @@ func (u *ComplianceUploader) ConvertToSsmAssociationComplianceItems(log log.T, a
 				"DocumentVersion": aws.String(item.DocumentVersion),
 			},
 		}
+		if len(item.Drift) > 0 {
+			complianceItem.Details["Drift"] = aws.String(truncateDetail(strings.Join(item.Drift, "\n")))
+		}
 		associationComplianceItems = append(associationComplianceItems, complianceItem)
 	}
 	return associationComplianceItems, newHash, nil
 
 }
+
+// stepComplianceItems converts the steps of an association compliance item into one compliance item per step
+func stepComplianceItems(item *model.AssociationComplianceItem) (complianceItems []*ssm.ComplianceItemEntry) {
+	for _, step := range item.Steps {
+		severity := step.ComplianceSeverity
+		if severity == "" {
+			severity = model.UNSPECIFIED
+		}
+		var complianceItem = &ssm.ComplianceItemEntry{
+			Id:       aws.String(item.AssociationId + "." + step.StepId),
+			Status:   aws.String(step.ComplianceStatus),
+			Severity: aws.String(severity),
+			Title:    aws.String(step.StepId),
+			Details: map[string]*string{
+				"DocumentName":    aws.String(item.DocumentName),
+				"DocumentVersion": aws.String(item.DocumentVersion),
+				"AssociationId":   aws.String(item.AssociationId),
+				"StepId":          aws.String(step.StepId),
+			},
+		}
+		if step.Message != "" {
+			complianceItem.Details["Message"] = aws.String(truncateDetail(step.Message))
+		}
+		if len(step.Drift) > 0 {
+			complianceItem.Details["Drift"] = aws.String(truncateDetail(strings.Join(step.Drift, "\n")))
+		}
+		complianceItems = append(complianceItems, complianceItem)
+	}
+	return complianceItems
+}
+
+// truncateDetail truncates a detail of a compliance item to the length limit of the details
+func truncateDetail(detail string) string {
+	if len(detail) > maxDetailLength {
+		detail = detail[:maxDetailLength-len(detailTruncatedSuffix)] + detailTruncatedSuffix
+	}
+	return detail
+}
@@ -16,6 +16,7 @@ package compliance
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -219,3 +220,38 @@ func TestChecksumSameForSameContent(t *testing.T) {
 
 	assert.Equal(t, calculateCheckSum(dataB1), calculateCheckSum(dataB2))
 }
+
+func TestDetailIsTruncated(t *testing.T) {
+	assert.Equal(t, "step1 failed", truncateDetail("step1 failed"))
+
+	detail := truncateDetail(strings.Repeat("x", 2*maxDetailLength))
+	assert.Equal(t, maxDetailLength, len(detail))
+	assert.True(t, strings.HasSuffix(detail, detailTruncatedSuffix))
+}
+
+func TestConvertStepComplianceItems(t *testing.T) {
+	c := context.NewMockDefault()
+	u := MockComplianceUploader()
+
+	item := AssociationComplianceItem()
+	item.Steps = []model.StepComplianceItem{
+		{StepId: "step1", ComplianceSeverity: "HIGH", ComplianceStatus: model.COMPLIANT},
+		{StepId: "step2", ComplianceStatus: model.NON_COMPLIANT, Message: "service b would change", Drift: []string{"service b would change"}},
+	}
+
+	complianceItems, _, err := u.ConvertToSsmAssociationComplianceItems(c.Log(), []*model.AssociationComplianceItem{item}, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(complianceItems))
+	assert.Equal(t, item.AssociationId+".step1", *complianceItems[0].Id)
+	assert.Equal(t, "HIGH", *complianceItems[0].Severity)
+	assert.Equal(t, model.COMPLIANT, *complianceItems[0].Status)
+	assert.Equal(t, "step1", *complianceItems[0].Title)
+	assert.Nil(t, complianceItems[0].Details["Message"])
+	assert.Equal(t, item.AssociationId+".step2", *complianceItems[1].Id)
+	assert.Equal(t, model.UNSPECIFIED, *complianceItems[1].Severity)
+	assert.Equal(t, model.NON_COMPLIANT, *complianceItems[1].Status)
+	assert.Equal(t, "step2", *complianceItems[1].Details["StepId"])
+	assert.Equal(t, "service b would change", *complianceItems[1].Details["Message"])
+	assert.Equal(t, "service b would change", *complianceItems[1].Details["Drift"])
+}
@@ -27,6 +27,7 @@ type T interface {
 	Log() log.T
 	AppConfig() appconfig.SsmagentConfig
 	With(context string) T
+	WithCorrelationID(correlationID string) T
 	CurrentContext() []string
 	AppConstants() *appconfig.AppConstants
 }
@@ -60,6 +61,18 @@ func (c *defaultContext) With(logContext string) T {
 	return newContext
 }
 
+// WithCorrelationID returns a context whose logger, and the loggers of the contexts derived from it,
+// tag their messages with the given correlation id.
+func (c *defaultContext) WithCorrelationID(correlationID string) T {
+	newContext := &defaultContext{
+		context:   c.context,
+		log:       c.log.WithCorrelationID(correlationID),
+		appconfig: c.appconfig,
+		appconst:  c.appconst,
+	}
+	return newContext
+}
+
 func (c *defaultContext) Log() log.T {
 	return c.log
 }
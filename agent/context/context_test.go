@@ -0,0 +1,37 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCorrelationIDPropagatesToDerivedContexts(t *testing.T) {
+	logger := log.NewCapturingLogger()
+	ctx := Default(logger, appconfig.SsmagentConfig{}).With("[EngineProcessor]").WithCorrelationID("command1")
+
+	ctx.Log().Info("starting")
+	ctx.With("[pluginName=aws:runShellScript]").Log().Info("running")
+
+	assert.Equal(t, []string{"[EngineProcessor]"}, ctx.CurrentContext())
+	entries := logger.Entries()
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "command1", entry.CorrelationID)
+	}
+}
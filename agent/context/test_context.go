@@ -41,6 +41,7 @@ func NewMockDefault() *Mock {
 	ctx.On("Log").Return(log)
 	ctx.On("AppConfig").Return(config)
 	ctx.On("With", mock.AnythingOfType("string")).Return(ctx)
+	ctx.On("WithCorrelationID", mock.AnythingOfType("string")).Return(ctx)
 	ctx.On("CurrentContext").Return([]string{})
 	ctx.On("AppConstants").Return(&appconst)
 	return ctx
@@ -58,6 +59,7 @@ func NewMockDefaultWithContext(context []string) *Mock {
 	ctx.On("Log").Return(log)
 	ctx.On("AppConfig").Return(config)
 	ctx.On("With", mock.AnythingOfType("string")).Return(ctx)
+	ctx.On("WithCorrelationID", mock.AnythingOfType("string")).Return(ctx)
 	ctx.On("CurrentContext").Return(context)
 	ctx.On("AppConstants").Return(&appconst)
 	return ctx
@@ -81,6 +83,12 @@ func (m *Mock) With(ctx string) T {
 	return args.Get(0).(T)
 }
 
+// WithCorrelationID mocks the WithCorrelationID function.
+func (m *Mock) WithCorrelationID(correlationID string) T {
+	args := m.Called(correlationID)
+	return args.Get(0).(T)
+}
+
 // CurrentContext mocks the CurrentContext function.
 func (m *Mock) CurrentContext() []string {
 	args := m.Called()
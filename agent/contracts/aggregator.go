@@ -34,14 +34,17 @@ func prepareRuntimeStatus(log log.T, pluginResult PluginResult) PluginRuntimeSta
 	}
 
 	runtimeStatus := PluginRuntimeStatus{
-		Code:           pluginResult.Code,
-		Name:           pluginResult.PluginName,
-		Status:         pluginResult.Status,
-		Output:         resultAsString,
-		StartDateTime:  times.ToIso8601UTC(pluginResult.StartDateTime),
-		EndDateTime:    times.ToIso8601UTC(pluginResult.EndDateTime),
-		StandardOutput: pluginResult.StandardOutput,
-		StandardError:  pluginResult.StandardError,
+		Code:             pluginResult.Code,
+		Name:             pluginResult.PluginName,
+		Status:           pluginResult.Status,
+		Output:           resultAsString,
+		StartDateTime:    times.ToIso8601UTC(pluginResult.StartDateTime),
+		EndDateTime:      times.ToIso8601UTC(pluginResult.EndDateTime),
+		StandardOutput:   pluginResult.StandardOutput,
+		StandardError:    pluginResult.StandardError,
+		StructuredOutput: pluginResult.StructuredOutput,
+		Attempts:         pluginResult.Attempts,
+		OutputDirectory:  pluginResult.OutputDirectory,
 	}
 
 	if pluginResult.OutputS3BucketName != "" {
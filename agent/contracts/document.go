@@ -89,6 +89,18 @@ type IOConfiguration struct {
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
 	CloudWatchConfig       CloudWatchConfiguration
+	// OutputFilter selects the part of the standard output of the step returned in the replies
+	OutputFilter *OutputFilter
+}
+
+// OutputFilter selects the meaningful part of the standard output of a step, such as
+// {"lineMatch": "^(ERROR|WARN)"} or {"jsonPath": "$.instances[*].id"}. The replies contain the filtered output
+// while the output uploaded to S3 and CloudWatch Logs is complete.
+type OutputFilter struct {
+	// LineMatch is a regular expression, only the lines of the output matching it are kept
+	LineMatch string `json:"lineMatch,omitempty" yaml:"lineMatch,omitempty"`
+	// JSONPath extracts values from the output parsed as JSON, applied after LineMatch
+	JSONPath string `json:"jsonPath,omitempty" yaml:"jsonPath,omitempty"`
 }
 
 // DocumentState represents information relevant to a command that gets executed by agent
@@ -99,6 +111,8 @@ type DocumentState struct {
 	InstancePluginsInformation []PluginState
 	CancelInformation          CancelCommandInfo
 	IOConfig                   IOConfiguration
+	// SerialKey is the serialization key of the document, the documents with the same key run one at a time
+	SerialKey string `json:",omitempty"`
 }
 
 // IsRebootRequired returns if reboot is needed
@@ -128,10 +128,28 @@ const (
 	AssociationErrorCodeSubmitAssociationError = "SubmitAssocError"
 	// AssociationErrorCodeStuckAtInProgressError represents association stuck in InProgress Error
 	AssociationErrorCodeStuckAtInProgressError = "StuckAtInProgress"
+	// AssociationErrorCodeDependencyError represents the failure of an association the association depends on
+	AssociationErrorCodeDependencyError = "DependencyError"
 	// AssociationErrorCodeNoError represents no error
 	AssociationErrorCodeNoError = ""
 )
 
+const (
+	// AssociationModeParameter is the association parameter selecting the mode of the association, the document
+	// declares it to let the associations choose their mode
+	AssociationModeParameter = "associationMode"
+	// AssociationModeEnforce applies the document, this is the default
+	AssociationModeEnforce = "Enforce"
+	// AssociationModeReportOnly checks the document without applying it and reports the drift as compliance
+	AssociationModeReportOnly = "ReportOnly"
+	// AssociationSerialGroupParameter is the association parameter naming its serialization group, the associations
+	// of the same group run one at a time
+	AssociationSerialGroupParameter = "associationSerialGroup"
+	// AssociationDependsOnParameter is the association parameter listing the IDs of the associations which must
+	// succeed before the association runs, once per run of the association
+	AssociationDependsOnParameter = "associationDependsOn"
+)
+
 const (
 	// DocumentPendingMessages represents the summary message for pending association
 	AssociationPendingMessage string = "Association is pending"
@@ -181,8 +199,23 @@ type InstancePluginConfig struct {
 	Settings      interface{}         `json:"settings" yaml:"settings"`
 	Timeout       int                 `json:"timeoutSeconds" yaml:"timeoutSeconds"`
 	Preconditions map[string][]string `json:"precondition" yaml:"precondition"`
+	RetryPolicy   *RetryPolicy        `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+	// ParallelGroup names the group of consecutive steps running at the same time, the step runs alone when empty
+	ParallelGroup string `json:"parallelGroup,omitempty" yaml:"parallelGroup,omitempty"`
+	// OutputFilter selects the part of the standard output of the step returned in the replies
+	OutputFilter *OutputFilter `json:"outputFilter,omitempty" yaml:"outputFilter,omitempty"`
+	// ComplianceSeverity is the severity of the compliance item the step reports when it runs in an association
+	ComplianceSeverity string `json:"complianceSeverity,omitempty" yaml:"complianceSeverity,omitempty"`
 }
 
+const (
+	// OnFailureContinue runs the following steps of the document when the step fails, this is the default
+	OnFailureContinue = "continue"
+	// OnFailureExit skips the following steps of the document when the step fails,
+	// the other steps of its parallel group still running are canceled
+	OnFailureExit = "exit"
+)
+
 // DocumentContent object which represents ssm document content.
 type DocumentContent struct {
 	SchemaVersion string                   `json:"schemaVersion" yaml:"schemaVersion"`
@@ -190,15 +223,30 @@ type DocumentContent struct {
 	RuntimeConfig map[string]*PluginConfig `json:"runtimeConfig" yaml:"runtimeConfig"`
 	MainSteps     []*InstancePluginConfig  `json:"mainSteps" yaml:"mainSteps"`
 	Parameters    map[string]*Parameter    `json:"parameters" yaml:"parameters"`
+
+	// SecureStringAccess controls whether SecureString parameters are decrypted, see parameterstore.ResolveOptionsForAccess
+	SecureStringAccess string `json:"secureStringAccess,omitempty" yaml:"secureStringAccess,omitempty"`
 }
 
 // SessionInputs stores session configuration
 type SessionInputs struct {
-	S3BucketName                string `json:"s3BucketName" yaml:"s3BucketName"`
-	S3KeyPrefix                 string `json:"s3KeyPrefix" yaml:"s3KeyPrefix"`
-	S3EncryptionEnabled         bool   `json:"s3EncryptionEnabled" yaml:"s3EncryptionEnabled"`
-	CloudWatchLogGroupName      string `json:"cloudWatchLogGroupName" yaml:"cloudWatchLogGroupName"`
-	CloudWatchEncryptionEnabled bool   `json:"cloudWatchEncryptionEnabled" yaml:"cloudWatchEncryptionEnabled"`
+	S3BucketName                string   `json:"s3BucketName" yaml:"s3BucketName"`
+	S3KeyPrefix                 string   `json:"s3KeyPrefix" yaml:"s3KeyPrefix"`
+	S3EncryptionEnabled         bool     `json:"s3EncryptionEnabled" yaml:"s3EncryptionEnabled"`
+	CloudWatchLogGroupName      string   `json:"cloudWatchLogGroupName" yaml:"cloudWatchLogGroupName"`
+	CloudWatchEncryptionEnabled bool     `json:"cloudWatchEncryptionEnabled" yaml:"cloudWatchEncryptionEnabled"`
+	RunAsEnabled                bool     `json:"runAsEnabled" yaml:"runAsEnabled"`
+	RunAsDefaultUser            string   `json:"runAsDefaultUser" yaml:"runAsDefaultUser"`
+	RunAsCreateUser             bool     `json:"runAsCreateUser" yaml:"runAsCreateUser"`
+	RunAsGroups                 []string `json:"runAsGroups" yaml:"runAsGroups"`
+	RecordingEnabled            bool     `json:"recordingEnabled" yaml:"recordingEnabled"`
+	FileTransferDirectory       string   `json:"fileTransferDirectory" yaml:"fileTransferDirectory"`
+	IdleSessionTimeout          string   `json:"idleSessionTimeout" yaml:"idleSessionTimeout"`
+	MaxSessionDuration          string   `json:"maxSessionDuration" yaml:"maxSessionDuration"`
+	CommandAllowList            []string `json:"commandAllowList" yaml:"commandAllowList"`
+	CommandDenyList             []string `json:"commandDenyList" yaml:"commandDenyList"`
+	KmsKeyId                    string   `json:"kmsKeyId" yaml:"kmsKeyId"`
+	MetricsLoggingEnabled       bool     `json:"metricsLoggingEnabled" yaml:"metricsLoggingEnabled"`
 }
 
 // SessionDocumentContent object which represents ssm session content.
@@ -208,6 +256,7 @@ type SessionDocumentContent struct {
 	SessionType   string                `json:"sessionType" yaml:"sessionType"`
 	Inputs        SessionInputs         `json:"inputs" yaml:"inputs"`
 	Parameters    map[string]*Parameter `json:"parameters" yaml:"parameters"`
+	Properties    interface{}           `json:"properties" yaml:"properties"`
 }
 
 // AdditionalInfo section in agent response
@@ -239,6 +288,9 @@ type PluginRuntimeStatus struct {
 	OutputS3KeyPrefix  string       `json:"outputS3KeyPrefix"`
 	StandardOutput     string       `json:"standardOutput"`
 	StandardError      string       `json:"standardError"`
+	StructuredOutput   interface{}  `json:"structuredOutput,omitempty"`
+	Attempts           int          `json:"attempts,omitempty"`
+	OutputDirectory    string       `json:"outputDirectory,omitempty"`
 }
 
 // AgentConfiguration is a struct that stores information about the agent and instance
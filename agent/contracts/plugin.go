@@ -40,6 +40,18 @@ type PluginResult struct {
 	Error              string       `json:"error"`
 	StandardOutput     string       `json:"standardOutput"`
 	StandardError      string       `json:"standardError"`
+	StructuredOutput   interface{}  `json:"structuredOutput,omitempty"`
+	Attempts           int          `json:"attempts,omitempty"`
+	Drift              *DriftReport `json:"drift,omitempty"`
+	ComplianceSeverity string       `json:"complianceSeverity,omitempty"`
+	OutputDirectory    string       `json:"outputDirectory,omitempty"`
+}
+
+// DriftReport represents the changes a step of a report-only association would apply.
+type DriftReport struct {
+	// Checked is false when the plugin of the step does not support the report-only mode
+	Checked bool     `json:"checked"`
+	Changes []string `json:"changes,omitempty"`
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -80,9 +92,31 @@ type Configuration struct {
 	DefaultWorkingDirectory     string
 	Preconditions               map[string][]string
 	IsPreconditionEnabled       bool
+	RetryPolicy                 *RetryPolicy
+	ParallelGroup               string
+	OnFailure                   string
+	OutputFilter                *OutputFilter
 	CurrentAssociations         []string
 	SessionId                   string
 	ClientId                    string
+	RunAsEnabled                bool
+	RunAsUser                   string
+	RunAsCreateUser             bool
+	RunAsGroups                 []string
+	RecordingEnabled            bool
+	FileTransferDirectory       string
+	IdleSessionTimeout          string
+	MaxSessionDuration          string
+	CommandAllowList            []string
+	CommandDenyList             []string
+	KmsKeyId                    string
+	MetricsLoggingEnabled       bool
+	// ReportOnly checks the plugin without applying it, see AssociationModeReportOnly
+	ReportOnly bool
+	// ComplianceSeverity is the severity of the compliance item of the step
+	ComplianceSeverity string
+	// DocumentParameters are the parameters of the document running the plugin, SSM parameters are not resolved
+	DocumentParameters map[string]interface{}
 }
 
 // Plugin wraps the plugin configuration and plugin result.
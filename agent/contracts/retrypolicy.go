@@ -0,0 +1,93 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package contracts provides model definitions for document state
+package contracts
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// MaxRetryAttempts is the maximum number of attempts of a step with a retry policy
+	MaxRetryAttempts = 10
+
+	// DefaultMaxRetryBackoffSeconds caps the delay between attempts when the retry policy does not
+	DefaultMaxRetryBackoffSeconds = 300
+
+	// maxRetryBackoffSeconds is the maximum delay between attempts a retry policy can ask for
+	maxRetryBackoffSeconds = 3600
+)
+
+// RetryPolicy describes how a step which fails is run again, such as
+// {"maxAttempts": 3, "backoffSeconds": 10, "retryableExitCodes": [75]}.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the step runs, including the first attempt
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts"`
+	// BackoffSeconds is the delay before the first retry, it doubles with each following retry
+	BackoffSeconds int `json:"backoffSeconds" yaml:"backoffSeconds"`
+	// MaxBackoffSeconds caps the delay between attempts, DefaultMaxRetryBackoffSeconds when not set
+	MaxBackoffSeconds int `json:"maxBackoffSeconds" yaml:"maxBackoffSeconds"`
+	// RetryableExitCodes restricts the retries to the attempts exiting with these codes, any failure is retried when empty
+	RetryableExitCodes []int `json:"retryableExitCodes" yaml:"retryableExitCodes"`
+}
+
+// Validate checks the values of the retry policy are in range
+func (policy RetryPolicy) Validate() error {
+	if policy.MaxAttempts < 1 || policy.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("maxAttempts %v is not between 1 and %v", policy.MaxAttempts, MaxRetryAttempts)
+	}
+	if policy.BackoffSeconds < 0 || policy.BackoffSeconds > maxRetryBackoffSeconds {
+		return fmt.Errorf("backoffSeconds %v is not between 0 and %v", policy.BackoffSeconds, maxRetryBackoffSeconds)
+	}
+	if policy.MaxBackoffSeconds < 0 || policy.MaxBackoffSeconds > maxRetryBackoffSeconds {
+		return fmt.Errorf("maxBackoffSeconds %v is not between 0 and %v", policy.MaxBackoffSeconds, maxRetryBackoffSeconds)
+	}
+	return nil
+}
+
+// ShouldRetry returns true if the step is run again after the given attempt ended with status and exitCode
+func (policy RetryPolicy) ShouldRetry(attempt int, status ResultStatus, exitCode int) bool {
+	if attempt >= policy.MaxAttempts {
+		return false
+	}
+	if status != ResultStatusFailed && status != ResultStatusTimedOut {
+		return false
+	}
+	if len(policy.RetryableExitCodes) == 0 {
+		return true
+	}
+	for _, code := range policy.RetryableExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry, the first retry being the second attempt of the step
+func (policy RetryPolicy) Backoff(retry int) time.Duration {
+	maxBackoff := policy.MaxBackoffSeconds
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxRetryBackoffSeconds
+	}
+	backoff := policy.BackoffSeconds
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return time.Duration(backoff) * time.Second
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contracts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyValidate(t *testing.T) {
+	assert.NoError(t, RetryPolicy{MaxAttempts: 1}.Validate())
+	assert.NoError(t, RetryPolicy{MaxAttempts: MaxRetryAttempts, BackoffSeconds: 5, MaxBackoffSeconds: 60}.Validate())
+
+	for _, policy := range []RetryPolicy{
+		{},
+		{MaxAttempts: MaxRetryAttempts + 1},
+		{MaxAttempts: 3, BackoffSeconds: -1},
+		{MaxAttempts: 3, MaxBackoffSeconds: 7200},
+	} {
+		assert.Error(t, policy.Validate(), "%+v", policy)
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+	assert.True(t, policy.ShouldRetry(1, ResultStatusFailed, 1))
+	assert.True(t, policy.ShouldRetry(2, ResultStatusTimedOut, 0))
+	assert.False(t, policy.ShouldRetry(3, ResultStatusFailed, 1))
+	assert.False(t, policy.ShouldRetry(1, ResultStatusSuccess, 0))
+	assert.False(t, policy.ShouldRetry(1, ResultStatusCancelled, 1))
+	assert.False(t, policy.ShouldRetry(1, ResultStatusSuccessAndReboot, 3010))
+
+	policy.RetryableExitCodes = []int{75, 111}
+	assert.True(t, policy.ShouldRetry(1, ResultStatusFailed, 111))
+	assert.False(t, policy.ShouldRetry(1, ResultStatusFailed, 1))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BackoffSeconds: 10, MaxBackoffSeconds: 30}
+	assert.Equal(t, 10*time.Second, policy.Backoff(1))
+	assert.Equal(t, 20*time.Second, policy.Backoff(2))
+	assert.Equal(t, 30*time.Second, policy.Backoff(3))
+	assert.Equal(t, 30*time.Second, policy.Backoff(100))
+
+	policy = RetryPolicy{MaxAttempts: 10, BackoffSeconds: 200}
+	assert.Equal(t, time.Duration(DefaultMaxRetryBackoffSeconds)*time.Second, policy.Backoff(2))
+	assert.Equal(t, time.Duration(0), RetryPolicy{MaxAttempts: 2}.Backoff(1))
+}
@@ -0,0 +1,373 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package diagnostics checks the environment the agent needs to run, such as the connectivity to the
+// endpoints of the services, the permissions of the instance and the clock, and reports the results.
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/health"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssmmds"
+)
+
+const (
+	// requestTimeout bounds each request of the checks
+	requestTimeout = 10 * time.Second
+	// maxClockSkew is the clock skew AWS accepts for signed requests
+	maxClockSkew = 5 * time.Minute
+	// warnClockSkew is the clock skew reported before requests start failing
+	warnClockSkew = time.Minute
+	// minAvailableDiskBytes is the space the agent needs for documents, logs and updates
+	minAvailableDiskBytes = 100 * 1024 * 1024
+	// warnAvailableDiskBytes is the space reported before the agent starts failing
+	warnAvailableDiskBytes = 500 * 1024 * 1024
+)
+
+// endpointServices are the services the agent connects to
+var endpointServices = []string{"ssm", "ec2messages", "ssmmessages", "s3"}
+
+// proxyVariables are the environment variables configuring the proxy of the agent
+var proxyVariables = []string{"https_proxy", "HTTPS_PROXY", "http_proxy", "HTTP_PROXY", "no_proxy", "NO_PROXY"}
+
+// checks run in order, the clock check uses the responses of the connectivity check
+var checks = []check{
+	{name: "Configuration", run: checkConfiguration},
+	{name: "Proxy", run: checkProxy},
+	{name: "Connectivity", run: checkConnectivity},
+	{name: "ClockSkew", run: checkClockSkew},
+	{name: "Credentials", run: checkCredentials},
+	{name: "Permissions", run: checkPermissions},
+	{name: "DiskSpace", run: checkDiskSpace},
+	{name: "ServiceHealth", run: checkServiceHealth},
+}
+
+// runner holds the state shared by the checks
+type runner struct {
+	log        log.T
+	instanceID string
+	region     string
+	config     appconfig.SsmagentConfig
+	configErr  error
+	httpClient *http.Client
+	// serverTime is the time of the last response of an endpoint, used to measure the clock skew
+	serverTime time.Time
+	localTime  time.Time
+	session    *session.Session
+	diskSpace  func(path string) (fileutil.DiskSpaceInfo, error)
+}
+
+func newRunner(log log.T) *runner {
+	config, err := appconfig.Config(false)
+	return &runner{
+		log:       log,
+		config:    config,
+		configErr: err,
+		httpClient: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSHandshakeTimeout: requestTimeout},
+		},
+		diskSpace: fileutil.GetDiskSpaceInfoOfPath,
+	}
+}
+
+// checkConfiguration reports the config file errors and the settings that are overridden or ignored
+func checkConfiguration(r *runner) CheckResult {
+	if r.configErr != nil {
+		return result(StatusFail, fmt.Sprintf("The config can't be loaded, the defaults are used. %v", r.configErr), nil)
+	}
+	diagnostics := appconfig.LastDiagnostics()
+	details := make(map[string]string)
+	for _, setting := range diagnostics.Overridden {
+		details[setting.Setting] = setting.String()
+	}
+	for _, setting := range diagnostics.Invalid {
+		details[setting.Setting] = setting.String()
+	}
+	if len(diagnostics.Invalid) > 0 {
+		return result(StatusWarn, fmt.Sprintf("%v settings are invalid and ignored", len(diagnostics.Invalid)), details)
+	}
+	return result(StatusPass, fmt.Sprintf("The config is valid, %v settings are overridden", len(diagnostics.Overridden)), details)
+}
+
+// checkProxy reports the proxy configuration and the proxy used for the endpoints
+func checkProxy(r *runner) CheckResult {
+	details := make(map[string]string)
+	status := StatusPass
+	for _, variable := range proxyVariables {
+		value := os.Getenv(variable)
+		if value == "" {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(variable), "proxy") && !strings.HasPrefix(strings.ToLower(variable), "no_") {
+			proxyURL, err := url.Parse(value)
+			if err != nil {
+				details[variable] = "invalid: " + err.Error()
+				status = StatusWarn
+				continue
+			}
+			value = redactURL(proxyURL)
+		}
+		details[variable] = value
+	}
+	if len(details) == 0 {
+		return result(StatusPass, "No proxy is configured", nil)
+	}
+	if request, err := http.NewRequest("GET", endpointURL(r, "ssm"), nil); err == nil {
+		if proxyURL, err := http.ProxyFromEnvironment(request); err == nil && proxyURL != nil {
+			details["ssmProxy"] = redactURL(proxyURL)
+		} else {
+			details["ssmProxy"] = "direct"
+		}
+	}
+	if status == StatusWarn {
+		return result(status, "The proxy configuration is invalid", details)
+	}
+	return result(status, "A proxy is configured", details)
+}
+
+// redactURL returns the url without its password
+func redactURL(u *url.URL) string {
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			redacted := *u
+			redacted.User = url.UserPassword(u.User.Username(), "REDACTED")
+			return redacted.String()
+		}
+	}
+	return u.String()
+}
+
+// checkConnectivity sends a request to the endpoint of each service the agent connects to,
+// any http response means the endpoint is reachable
+func checkConnectivity(r *runner) CheckResult {
+	if r.region == "" {
+		return result(StatusFail, "The region of the instance is unknown", nil)
+	}
+	details := make(map[string]string)
+	var unreachable []string
+	for _, service := range endpointServices {
+		endpoint := endpointURL(r, service)
+		response, err := r.httpClient.Get(endpoint)
+		if err != nil {
+			details[service] = fmt.Sprintf("%v unreachable: %v", endpoint, err)
+			unreachable = append(unreachable, service)
+			continue
+		}
+		response.Body.Close()
+		details[service] = fmt.Sprintf("%v reachable", endpoint)
+		if serverTime, err := http.ParseTime(response.Header.Get("Date")); err == nil {
+			r.serverTime = serverTime
+			r.localTime = time.Now()
+		}
+	}
+	if len(unreachable) > 0 {
+		return result(StatusFail, "Endpoints are unreachable: "+strings.Join(unreachable, ", "), details)
+	}
+	return result(StatusPass, "All endpoints are reachable", details)
+}
+
+// endpointURL returns the url of the endpoint of a service, honoring the endpoints of the config
+func endpointURL(r *runner, service string) string {
+	endpoint := ""
+	switch service {
+	case "ssm":
+		endpoint = r.config.Ssm.Endpoint
+	case "ec2messages":
+		endpoint = r.config.Mds.Endpoint
+	case "ssmmessages":
+		endpoint = r.config.Mgs.Endpoint
+	case "s3":
+		endpoint = r.config.S3.Endpoint
+	}
+	if endpoint == "" {
+		endpoint = appconfig.ResolveEndpoint(r.region, service)
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint
+}
+
+// checkClockSkew compares the clock of the instance with the time of the responses of the endpoints
+func checkClockSkew(r *runner) CheckResult {
+	if r.serverTime.IsZero() {
+		return result(StatusSkip, "No endpoint returned its time", nil)
+	}
+	skew := r.localTime.Sub(r.serverTime)
+	details := map[string]string{
+		"instanceTime": r.localTime.UTC().Format(time.RFC3339),
+		"serverTime":   r.serverTime.UTC().Format(time.RFC3339),
+		"skewSeconds":  fmt.Sprint(int64(skew / time.Second)),
+	}
+	if skew < 0 {
+		skew = -skew
+	}
+	switch {
+	case skew > maxClockSkew:
+		return result(StatusFail, fmt.Sprintf("The clock is off by %v, AWS rejects the requests of the agent", skew), details)
+	case skew > warnClockSkew:
+		return result(StatusWarn, fmt.Sprintf("The clock is off by %v", skew), details)
+	}
+	return result(StatusPass, "The clock is in sync", details)
+}
+
+// checkCredentials retrieves the credentials the agent uses
+func checkCredentials(r *runner) CheckResult {
+	awsConfig := sdkutil.AwsConfig()
+	awsConfig.MaxRetries = aws.Int(0)
+	awsConfig.HTTPClient = r.httpClient
+	if r.region != "" {
+		awsConfig.Region = aws.String(r.region)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return result(StatusFail, fmt.Sprintf("Failed to create an AWS session. %v", err), nil)
+	}
+	credentials, err := sess.Config.Credentials.Get()
+	if err != nil {
+		return result(StatusFail, fmt.Sprintf("No credentials are available. %v", err), nil)
+	}
+	r.session = sess
+	return result(StatusPass, "Credentials are available", map[string]string{"provider": credentials.ProviderName})
+}
+
+// checkPermissions calls read-only APIs the agent needs to find whether the credentials are allowed to call them
+func checkPermissions(r *runner) CheckResult {
+	if r.session == nil {
+		return result(StatusSkip, "No credentials are available", nil)
+	}
+	if r.instanceID == "" {
+		return result(StatusSkip, "The instance id is unknown", nil)
+	}
+
+	ssmConfig := aws.NewConfig()
+	if endpoint := r.config.Ssm.Endpoint; endpoint != "" {
+		ssmConfig.Endpoint = aws.String(endpoint)
+	} else if endpoint := appconfig.GetDefaultEndPoint(r.region, "ssm"); endpoint != "" {
+		ssmConfig.Endpoint = aws.String(endpoint)
+	}
+	mdsConfig := aws.NewConfig()
+	if endpoint := r.config.Mds.Endpoint; endpoint != "" {
+		mdsConfig.Endpoint = aws.String(endpoint)
+	} else if endpoint := appconfig.GetDefaultEndPoint(r.region, "ec2messages"); endpoint != "" {
+		mdsConfig.Endpoint = aws.String(endpoint)
+	}
+
+	calls := map[string]error{}
+	_, calls["ssm:ListInstanceAssociations"] = ssm.New(r.session, ssmConfig).ListInstanceAssociations(
+		&ssm.ListInstanceAssociationsInput{InstanceId: aws.String(r.instanceID), MaxResults: aws.Int64(1)})
+	_, calls["ec2messages:GetEndpoint"] = ssmmds.New(r.session, mdsConfig).GetEndpoint(
+		&ssmmds.GetEndpointInput{Destination: aws.String(r.instanceID)})
+	return permissionsResult(calls)
+}
+
+// permissionsResult fails when an api call is denied and warns when an api call fails otherwise
+func permissionsResult(calls map[string]error) CheckResult {
+	details := make(map[string]string)
+	var denied, failed []string
+	for action, err := range calls {
+		if err == nil {
+			details[action] = "allowed"
+			continue
+		}
+		details[action] = err.Error()
+		if awsErr, ok := err.(awserr.Error); ok && isAccessDenied(awsErr.Code()) {
+			denied = append(denied, action)
+		} else {
+			failed = append(failed, action)
+		}
+	}
+	sort.Strings(denied)
+	sort.Strings(failed)
+	switch {
+	case len(denied) > 0:
+		return result(StatusFail, "The credentials are not allowed to call "+strings.Join(denied, ", "), details)
+	case len(failed) > 0:
+		return result(StatusWarn, "The permissions can't be verified for "+strings.Join(failed, ", "), details)
+	}
+	return result(StatusPass, "The credentials are allowed to call the agent APIs", details)
+}
+
+// isAccessDenied returns true if the error code means the credentials are not allowed to call an api
+func isAccessDenied(code string) bool {
+	switch code {
+	case "AccessDeniedException", "AccessDenied", "UnauthorizedOperation", "UnrecognizedClientException",
+		"InvalidSignatureException", "ExpiredTokenException":
+		return true
+	}
+	return false
+}
+
+// checkDiskSpace reports the space available on the disk of the data of the agent
+func checkDiskSpace(r *runner) CheckResult {
+	diskSpace, err := r.diskSpace(appconfig.DefaultDataStorePath)
+	if err != nil {
+		return result(StatusWarn, fmt.Sprintf("Failed to read the available disk space. %v", err), nil)
+	}
+	details := map[string]string{
+		"path":           appconfig.DefaultDataStorePath,
+		"availableBytes": fmt.Sprint(diskSpace.AvailBytes),
+		"totalBytes":     fmt.Sprint(diskSpace.TotalBytes),
+	}
+	message := fmt.Sprintf("%v MB are available", diskSpace.AvailBytes/(1024*1024))
+	switch {
+	case diskSpace.AvailBytes < minAvailableDiskBytes:
+		return result(StatusFail, message, details)
+	case diskSpace.AvailBytes < warnAvailableDiskBytes:
+		return result(StatusWarn, message, details)
+	}
+	return result(StatusPass, message, details)
+}
+
+// checkServiceHealth reads the readiness of the running agent on its health endpoint
+func checkServiceHealth(r *runner) CheckResult {
+	if !r.config.Health.EndpointEnabled {
+		return result(StatusSkip, "The health endpoint of the agent is not enabled, set Health.EndpointEnabled", nil)
+	}
+	endpoint := fmt.Sprintf("http://127.0.0.1:%v%v", r.config.Health.EndpointPort, health.ReadinessPath)
+	response, err := (&http.Client{Timeout: requestTimeout}).Get(endpoint)
+	if err != nil {
+		return result(StatusFail, fmt.Sprintf("The agent is not running. %v", err), nil)
+	}
+	defer response.Body.Close()
+
+	var report health.ReadinessReport
+	if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+		return result(StatusWarn, fmt.Sprintf("Failed to read the readiness of the agent. %v", err), nil)
+	}
+	details := map[string]string{"status": report.Status, "version": report.Version}
+	if report.LastError != nil {
+		details["lastError"] = fmt.Sprintf("%v: %v", report.LastError.Component, report.LastError.Error)
+	}
+	if !report.Ready {
+		return result(StatusFail, "The agent is not ready: "+strings.Join(report.NotReady, ", "), details)
+	}
+	return result(StatusPass, "The agent is ready", details)
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package diagnostics checks the environment the agent needs to run, such as the connectivity to the
+// endpoints of the services, the permissions of the instance and the clock, and reports the results.
+package diagnostics
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// StatusPass means the check found no issue
+	StatusPass Status = "Pass"
+	// StatusWarn means the check found an issue the agent can run with
+	StatusWarn Status = "Warn"
+	// StatusFail means the check found an issue that prevents the agent from running
+	StatusFail Status = "Fail"
+	// StatusSkip means the check doesn't apply to the instance
+	StatusSkip Status = "Skip"
+)
+
+// severities orders the statuses to compute the status of a report
+var severities = map[Status]int{StatusSkip: 0, StatusPass: 1, StatusWarn: 2, StatusFail: 3}
+
+// CheckResult is the outcome of a check
+type CheckResult struct {
+	Name       string            `json:"name"`
+	Status     Status            `json:"status"`
+	Message    string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+}
+
+// Report is the outcome of the checks, its status is the status of the most severe check
+type Report struct {
+	AgentVersion string        `json:"agentVersion"`
+	InstanceID   string        `json:"instanceId,omitempty"`
+	Region       string        `json:"region,omitempty"`
+	Platform     string        `json:"platform"`
+	Time         time.Time     `json:"time"`
+	Status       Status        `json:"status"`
+	Checks       []CheckResult `json:"checks"`
+}
+
+// check is a named diagnostic, the checks run in order and may use what the previous checks found
+type check struct {
+	name string
+	run  func(r *runner) CheckResult
+}
+
+// Run runs the checks and returns their report.
+func Run(log log.T) Report {
+	r := newRunner(log)
+	if instanceID, err := platform.InstanceID(); err == nil {
+		r.instanceID = instanceID
+	}
+	if region, err := platform.Region(); err == nil {
+		r.region = region
+	}
+	return r.run(checks)
+}
+
+// run runs the given checks and returns their report
+func (r *runner) run(checks []check) Report {
+	report := Report{
+		AgentVersion: version.Version,
+		InstanceID:   r.instanceID,
+		Region:       r.region,
+		Platform:     runtime.GOOS,
+		Time:         time.Now().UTC(),
+		Status:       StatusSkip,
+	}
+	for _, c := range checks {
+		r.log.Debugf("Running diagnostic check %v", c.name)
+		start := time.Now()
+		result := c.run(r)
+		result.Name = c.name
+		result.DurationMs = int64(time.Since(start) / time.Millisecond)
+		if severities[result.Status] > severities[report.Status] {
+			report.Status = result.Status
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// Write writes the report as indented json.
+func (report Report) Write(writer io.Writer) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(content, '\n'))
+	return err
+}
+
+// result creates the result of a check
+func result(status Status, message string, details map[string]string) CheckResult {
+	return CheckResult{Status: status, Message: message, Details: details}
+}
@@ -19,10 +19,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/outputfilter"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/parameters"
 	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessiontype"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/aws/aws-sdk-go/service/ssm"
 
 	"fmt"
 	"path/filepath"
@@ -101,11 +104,19 @@ func (docContent *DocContent) ParseDocument(log log.T,
 	if err = validateSchema(docContent.SchemaVersion); err != nil {
 		return
 	}
-	if err = getValidatedParameters(log, params, docContent); err != nil {
+	var validParameters map[string]interface{}
+	if validParameters, err = getValidatedParameters(log, params, docContent); err != nil {
 		return
 	}
 
-	return parseDocumentContent(*docContent, parserInfo)
+	if pluginsInfo, err = parseDocumentContent(*docContent, parserInfo); err != nil {
+		return
+	}
+	// the parameters are kept for the plugins running child documents with the parameters of their document
+	for i := range pluginsInfo {
+		pluginsInfo[i].Configuration.DocumentParameters = validParameters
+	}
+	return
 }
 
 // GetSchemaVersion is a method used to get document schema version
@@ -128,7 +139,11 @@ func (sessionDocContent *SessionDocContent) ParseDocument(log log.T,
 	parserInfo DocumentParserInfo,
 	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
-	return parsePluginStateForStartSession(parserInfo, docInfo.DocumentID, docInfo.ClientId)
+	if err = getValidatedSessionParameters(log, params, sessionDocContent); err != nil {
+		return
+	}
+
+	return parsePluginStateForStartSession(parserInfo, sessionDocContent.SessionType, sessionDocContent.Inputs, sessionDocContent.Properties, docInfo.DocumentID, docInfo.ClientId)
 }
 
 // ParseParameters is a method to parse the ssm parameters into a string map interface
@@ -226,9 +241,23 @@ func parsePluginStateForV20Schema(
 	// set precondition flag based on document schema version
 	isPreconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
 
+	if err = validateStepFlow(docContent.MainSteps); err != nil {
+		return pluginsInfo, err
+	}
+
 	// getPluginConfigurations converts from PluginConfig (structure from the MDS message) to plugin.Configuration (structure expected by the plugin)
 	for _, instancePluginConfig := range docContent.MainSteps {
 		pluginName := instancePluginConfig.Action
+		if instancePluginConfig.RetryPolicy != nil {
+			if err = instancePluginConfig.RetryPolicy.Validate(); err != nil {
+				return pluginsInfo, fmt.Errorf("invalid retryPolicy of step %v: %v", instancePluginConfig.Name, err)
+			}
+		}
+		if instancePluginConfig.OutputFilter != nil {
+			if _, err = outputfilter.New(*instancePluginConfig.OutputFilter); err != nil {
+				return pluginsInfo, fmt.Errorf("invalid outputFilter of step %v: %v", instancePluginConfig.Name, err)
+			}
+		}
 		config := contracts.Configuration{
 			Settings:                instancePluginConfig.Settings,
 			Properties:              instancePluginConfig.Inputs,
@@ -241,6 +270,11 @@ func parsePluginStateForV20Schema(
 			PluginID:                instancePluginConfig.Name,
 			Preconditions:           instancePluginConfig.Preconditions,
 			IsPreconditionEnabled:   isPreconditionEnabled,
+			RetryPolicy:             instancePluginConfig.RetryPolicy,
+			ParallelGroup:           instancePluginConfig.ParallelGroup,
+			OnFailure:               instancePluginConfig.OnFailure,
+			OutputFilter:            instancePluginConfig.OutputFilter,
+			ComplianceSeverity:      instancePluginConfig.ComplianceSeverity,
 			DefaultWorkingDirectory: defaultWorkingDir,
 		}
 
@@ -253,15 +287,59 @@ func parsePluginStateForV20Schema(
 	return
 }
 
+// validateStepFlow checks the onFailure and complianceSeverity of the steps and that the steps of each parallel group
+// are consecutive
+func validateStepFlow(mainSteps []*contracts.InstancePluginConfig) error {
+	groups := make(map[string]struct{})
+	previousGroup := ""
+	for _, step := range mainSteps {
+		switch step.OnFailure {
+		case "", contracts.OnFailureContinue, contracts.OnFailureExit:
+		default:
+			return fmt.Errorf("invalid onFailure %v of step %v, it must be %v or %v",
+				step.OnFailure, step.Name, contracts.OnFailureContinue, contracts.OnFailureExit)
+		}
+
+		switch step.ComplianceSeverity {
+		case "", ssm.ComplianceSeverityCritical, ssm.ComplianceSeverityHigh, ssm.ComplianceSeverityMedium,
+			ssm.ComplianceSeverityLow, ssm.ComplianceSeverityInformational, ssm.ComplianceSeverityUnspecified:
+		default:
+			return fmt.Errorf("invalid complianceSeverity %v of step %v", step.ComplianceSeverity, step.Name)
+		}
+
+		if step.ParallelGroup != "" && step.ParallelGroup != previousGroup {
+			if _, found := groups[step.ParallelGroup]; found {
+				return fmt.Errorf("the steps of parallelGroup %v are not consecutive", step.ParallelGroup)
+			}
+			groups[step.ParallelGroup] = struct{}{}
+		}
+		previousGroup = step.ParallelGroup
+	}
+	return nil
+}
+
 // parsePluginStateForStartSession initializes instancePluginsInfo for the docState. Used by startSession.
 func parsePluginStateForStartSession(
 	parserInfo DocumentParserInfo,
+	sessionType string,
+	inputs contracts.SessionInputs,
+	properties interface{},
 	sessionId string,
 	clientId string) (pluginsInfo []contracts.PluginState, err error) {
 
 	// getPluginConfigurations converts from PluginConfig (structure from the MGS message) to plugin.Configuration (structure expected by the plugin)
+	// sessions run the shell plugin unless the document asks for another built in or registered session type
 	pluginName := appconfig.PluginNameStandardStream
+	if sessiontype.IsKnown(sessionType) {
+		pluginName = sessionType
+	}
+	if definition, ok := sessiontype.Registered(sessionType); ok {
+		if err = definition.ValidateProperties(properties); err != nil {
+			return
+		}
+	}
 	config := contracts.Configuration{
+		Properties:                  properties,
 		MessageId:                   parserInfo.MessageId,
 		BookKeepingFileName:         parserInfo.DocumentId,
 		PluginName:                  pluginName,
@@ -275,6 +353,18 @@ func parsePluginStateForStartSession(
 		ClientId:                    clientId,
 		CloudWatchLogGroup:          parserInfo.CloudWatchConfig.LogGroupName,
 		CloudWatchEncryptionEnabled: parserInfo.CloudWatchConfig.LogGroupEncryptionEnabled,
+		RunAsEnabled:                inputs.RunAsEnabled,
+		RunAsUser:                   inputs.RunAsDefaultUser,
+		RunAsCreateUser:             inputs.RunAsCreateUser,
+		RunAsGroups:                 inputs.RunAsGroups,
+		RecordingEnabled:            inputs.RecordingEnabled,
+		FileTransferDirectory:       inputs.FileTransferDirectory,
+		IdleSessionTimeout:          inputs.IdleSessionTimeout,
+		MaxSessionDuration:          inputs.MaxSessionDuration,
+		CommandAllowList:            inputs.CommandAllowList,
+		CommandDenyList:             inputs.CommandDenyList,
+		KmsKeyId:                    inputs.KmsKeyId,
+		MetricsLoggingEnabled:       inputs.MetricsLoggingEnabled,
 	}
 
 	var plugin contracts.PluginState
@@ -299,7 +389,9 @@ func validateSchema(documentSchemaVersion string) error {
 }
 
 // getValidatedParameters validates the parameters and modifies the document content by replacing all ssm parameters with their actual values.
-func getValidatedParameters(log log.T, params map[string]interface{}, docContent *DocContent) error {
+// SecureString parameters, if allowed by the document, are replaced with redaction markers instead.
+// It returns the valid parameters, with the default values of the missing ones.
+func getValidatedParameters(log log.T, params map[string]interface{}, docContent *DocContent) (map[string]interface{}, error) {
 
 	//ValidateParameterNames
 	validParameters := parameters.ValidParameters(log, params)
@@ -311,20 +403,47 @@ func getValidatedParameters(log log.T, params map[string]interface{}, docContent
 		}
 	}
 
+	resolveOptions, err := parameterstore.ResolveOptionsForAccess(docContent.SecureStringAccess)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Info("Validating SSM parameters")
 	// Validates SSM parameters
-	if err := parameterstore.ValidateSSMParameters(log, docContent.Parameters, validParameters); err != nil {
+	if err := parameterstore.ValidateSSMParametersWithOptions(log, docContent.Parameters, validParameters, resolveOptions); err != nil {
+		return nil, err
+	}
+
+	if err = replaceValidatedPluginParameters(docContent, validParameters, resolveOptions, log); err != nil {
+		return nil, err
+	}
+	return validParameters, nil
+}
+
+// getValidatedSessionParameters validates the parameters and replaces them with their values within the session document properties.
+func getValidatedSessionParameters(log log.T, params map[string]interface{}, sessionDocContent *SessionDocContent) error {
+	validParameters := parameters.ValidParameters(log, params)
+
+	// add default values for missing parameters
+	for k, v := range sessionDocContent.Parameters {
+		if _, ok := validParameters[k]; !ok {
+			validParameters[k] = v.DefaultVal
+		}
+	}
+
+	if err := parameterstore.ValidateSSMParameters(log, sessionDocContent.Parameters, validParameters); err != nil {
 		return err
 	}
 
-	err := replaceValidatedPluginParameters(docContent, validParameters, log)
-	return err
+	sessionDocContent.Properties = parameters.ReplaceParameters(sessionDocContent.Properties, validParameters, log)
+	return nil
 }
 
 // replaceValidatedPluginParameters replaces parameters with their values, within the plugin Properties.
 func replaceValidatedPluginParameters(
 	docContent *DocContent,
 	params map[string]interface{},
+	resolveOptions parameterstore.ResolveOptions,
 	logger log.T) error {
 	var err error
 
@@ -340,12 +459,12 @@ func replaceValidatedPluginParameters(
 
 			logger.Debug("Resolving SSM parameters")
 			// Resolves SSM parameters
-			if updatedRuntimeConfig[pluginName].Settings, err = parameterstore.Resolve(logger, updatedRuntimeConfig[pluginName].Settings); err != nil {
+			if updatedRuntimeConfig[pluginName].Settings, err = parameterstore.ResolveWithOptions(logger, updatedRuntimeConfig[pluginName].Settings, resolveOptions); err != nil {
 				return err
 			}
 
 			// Resolves SSM parameters
-			if updatedRuntimeConfig[pluginName].Properties, err = parameterstore.Resolve(logger, updatedRuntimeConfig[pluginName].Properties); err != nil {
+			if updatedRuntimeConfig[pluginName].Properties, err = parameterstore.ResolveWithOptions(logger, updatedRuntimeConfig[pluginName].Properties, resolveOptions); err != nil {
 				return err
 			}
 		}
@@ -363,12 +482,12 @@ func replaceValidatedPluginParameters(
 
 			logger.Debug("Resolving SSM parameters")
 			// Resolves SSM parameters
-			if updatedMainSteps[index].Settings, err = parameterstore.Resolve(logger, updatedMainSteps[index].Settings); err != nil {
+			if updatedMainSteps[index].Settings, err = parameterstore.ResolveWithOptions(logger, updatedMainSteps[index].Settings, resolveOptions); err != nil {
 				return err
 			}
 
 			// Resolves SSM parameters
-			if updatedMainSteps[index].Inputs, err = parameterstore.Resolve(logger, updatedMainSteps[index].Inputs); err != nil {
+			if updatedMainSteps[index].Inputs, err = parameterstore.ResolveWithOptions(logger, updatedMainSteps[index].Inputs, resolveOptions); err != nil {
 				return err
 			}
 		}
@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessiontype"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,6 +45,8 @@ const (
 const parameterdocument = `{"schemaVersion":"1.2","description":"","parameters":{"commands":{"type":"StringList"}},"runtimeConfig":{"aws:runPowerShellScript":{"properties":[{"id":"0.aws:runPowerShellScript","runCommand":"{{ commands }}"}]}}}`
 const invaliddocument = `{"schemaVersion":"1.2","description":"PowerShell.","FOO":"bar"}`
 const testparameters = `{"commands":["date"]}`
+const retrypolicydocument = `{"schemaVersion":"2.2","description":"","mainSteps":[{"action":"aws:runShellScript","name":"install","inputs":{"runCommand":["make install"]},"retryPolicy":{"maxAttempts":3,"backoffSeconds":10,"retryableExitCodes":[75]}}]}`
+const outputfilterdocument = `{"schemaVersion":"2.2","description":"","mainSteps":[{"action":"aws:runShellScript","name":"describe","inputs":{"runCommand":["aws ec2 describe-instances"]},"outputFilter":{"jsonPath":"$.Reservations[*].Instances[*].InstanceId"}}]}`
 
 var sampleMessageFiles = []string{
 	"testdata/sampleMessageVersion2_0.json",
@@ -200,6 +203,196 @@ func TestInitializeDocStateForStartSessionDocument_Valid(t *testing.T) {
 	assert.Equal(t, fileutil.BuildPath(testOrchDir, appconfig.PluginNameStandardStream), pluginInfo[0].Configuration.OrchestrationDirectory)
 }
 
+func TestInitializeDocStateForStartSessionDocument_Port(t *testing.T) {
+	mockLog := log.NewMockLog()
+
+	testParserInfo := DocumentParserInfo{
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+		OrchestrationDir: testOrchDir,
+	}
+	sessionDocContent := &SessionDocContent{
+		SchemaVersion: "1.0",
+		SessionType:   appconfig.PluginNamePort,
+	}
+
+	docState, err := InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		nil)
+
+	assert.Nil(t, err)
+
+	pluginInfo := docState.InstancePluginsInformation
+	assert.Equal(t, 1, len(pluginInfo))
+	assert.Equal(t, appconfig.PluginNamePort, pluginInfo[0].Name)
+	assert.Equal(t, appconfig.PluginNamePort, pluginInfo[0].Configuration.PluginName)
+	assert.Equal(t, fileutil.BuildPath(testOrchDir, appconfig.PluginNamePort), pluginInfo[0].Configuration.OrchestrationDirectory)
+}
+
+func TestInitializeDocStateForStartSessionDocument_FileTransfer(t *testing.T) {
+	mockLog := log.NewMockLog()
+
+	testParserInfo := DocumentParserInfo{
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+		OrchestrationDir: testOrchDir,
+	}
+	sessionDocContent := &SessionDocContent{
+		SchemaVersion: "1.0",
+		SessionType:   appconfig.PluginNameFileTransfer,
+		Inputs: contracts.SessionInputs{
+			FileTransferDirectory: "/var/transfer",
+		},
+	}
+
+	docState, err := InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		nil)
+
+	assert.Nil(t, err)
+
+	pluginInfo := docState.InstancePluginsInformation
+	assert.Equal(t, 1, len(pluginInfo))
+	assert.Equal(t, appconfig.PluginNameFileTransfer, pluginInfo[0].Name)
+	assert.Equal(t, "/var/transfer", pluginInfo[0].Configuration.FileTransferDirectory)
+	assert.Equal(t, fileutil.BuildPath(testOrchDir, appconfig.PluginNameFileTransfer), pluginInfo[0].Configuration.OrchestrationDirectory)
+}
+
+func TestInitializeDocStateForStartSessionDocument_InteractiveCommands(t *testing.T) {
+	mockLog := log.NewMockLog()
+
+	testParserInfo := DocumentParserInfo{
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+		OrchestrationDir: testOrchDir,
+	}
+	sessionDocContent := &SessionDocContent{
+		SchemaVersion: "1.0",
+		SessionType:   appconfig.PluginNameInteractiveCommands,
+		Inputs: contracts.SessionInputs{
+			CommandAllowList: []string{`tail -f /var/log/\w+`},
+			CommandDenyList:  []string{`.*\.\..*`},
+		},
+		Parameters: map[string]*contracts.Parameter{
+			"logFile":   {ParamType: contracts.ParamTypeString},
+			"logFolder": {ParamType: contracts.ParamTypeString, DefaultVal: "/var/log"},
+		},
+		Properties: map[string]interface{}{
+			"commands": "tail -f {{ logFolder }}/{{ logFile }}",
+		},
+	}
+
+	docState, err := InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		map[string]interface{}{"logFile": "messages"})
+
+	assert.Nil(t, err)
+
+	pluginInfo := docState.InstancePluginsInformation
+	assert.Equal(t, 1, len(pluginInfo))
+	assert.Equal(t, appconfig.PluginNameInteractiveCommands, pluginInfo[0].Name)
+	assert.Equal(t, map[string]interface{}{"commands": "tail -f /var/log/messages"}, pluginInfo[0].Configuration.Properties)
+	assert.Equal(t, []string{`tail -f /var/log/\w+`}, pluginInfo[0].Configuration.CommandAllowList)
+	assert.Equal(t, []string{`.*\.\..*`}, pluginInfo[0].Configuration.CommandDenyList)
+}
+
+func TestInitializeDocStateForStartSessionDocument_RegisteredSessionType(t *testing.T) {
+	mockLog := log.NewMockLog()
+	sessionType := "Custom_DocParserTest"
+	assert.Nil(t, sessiontype.Register(sessiontype.Definition{Name: sessionType, RequiredProperties: []string{"target"}}))
+
+	testParserInfo := DocumentParserInfo{
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+		OrchestrationDir: testOrchDir,
+	}
+	sessionDocContent := &SessionDocContent{
+		SchemaVersion: "1.0",
+		SessionType:   sessionType,
+		Properties:    map[string]interface{}{"target": "db"},
+	}
+
+	docState, err := InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		nil)
+
+	assert.Nil(t, err)
+	pluginInfo := docState.InstancePluginsInformation
+	assert.Equal(t, 1, len(pluginInfo))
+	assert.Equal(t, sessionType, pluginInfo[0].Name)
+	assert.Equal(t, map[string]interface{}{"target": "db"}, pluginInfo[0].Configuration.Properties)
+
+	sessionDocContent = &SessionDocContent{
+		SchemaVersion: "1.0",
+		SessionType:   sessionType,
+	}
+	_, err = InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		nil)
+
+	assert.NotNil(t, err)
+}
+
+func TestInitializeDocStateForStartSessionDocument_RunAs(t *testing.T) {
+	mockLog := log.NewMockLog()
+
+	testParserInfo := DocumentParserInfo{
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+		OrchestrationDir: testOrchDir,
+	}
+	sessionDocContent := &SessionDocContent{
+		SchemaVersion: "1.0",
+		Inputs: contracts.SessionInputs{
+			RunAsEnabled:          true,
+			RunAsDefaultUser:      "developer",
+			RunAsCreateUser:       true,
+			RunAsGroups:           []string{"docker", "wheel"},
+			RecordingEnabled:      true,
+			IdleSessionTimeout:    "10",
+			MaxSessionDuration:    "120",
+			KmsKeyId:              "kms-key-id",
+			MetricsLoggingEnabled: true,
+		},
+	}
+
+	docState, err := InitializeDocState(mockLog,
+		contracts.StartSession,
+		sessionDocContent,
+		contracts.DocumentInfo{DocumentID: testSessionId, ClientId: testClientId},
+		testParserInfo,
+		nil)
+
+	assert.Nil(t, err)
+
+	pluginInfo := docState.InstancePluginsInformation
+	assert.Equal(t, 1, len(pluginInfo))
+	assert.True(t, pluginInfo[0].Configuration.RunAsEnabled)
+	assert.Equal(t, "developer", pluginInfo[0].Configuration.RunAsUser)
+	assert.True(t, pluginInfo[0].Configuration.RunAsCreateUser)
+	assert.Equal(t, []string{"docker", "wheel"}, pluginInfo[0].Configuration.RunAsGroups)
+	assert.True(t, pluginInfo[0].Configuration.RecordingEnabled)
+	assert.Equal(t, "10", pluginInfo[0].Configuration.IdleSessionTimeout)
+	assert.Equal(t, "120", pluginInfo[0].Configuration.MaxSessionDuration)
+	assert.Equal(t, "kms-key-id", pluginInfo[0].Configuration.KmsKeyId)
+	assert.True(t, pluginInfo[0].Configuration.MetricsLoggingEnabled)
+}
+
 func TestParseDocument_EmptyDocContent(t *testing.T) {
 	mockLog := log.NewMockLog()
 	testParserInfo := DocumentParserInfo{
@@ -265,6 +458,75 @@ func TestParseDocument_InvalidSchema(t *testing.T) {
 	assert.Contains(t, err.Error(), "Document with schema version 9999.0 is not supported by this version of ssm agent")
 }
 
+func TestParseDocument_RetryPolicy(t *testing.T) {
+	mockLog := log.NewMockLog()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir:  testOrchDir,
+		MessageId:         testMessageID,
+		DocumentId:        testDocumentID,
+		DefaultWorkingDir: testWorkingDir,
+	}
+
+	var testDocContent DocContent
+	err := json.Unmarshal([]byte(retrypolicydocument), &testDocContent)
+	assert.NoError(t, err)
+	pluginsInfo, err := testDocContent.ParseDocument(mockLog, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &contracts.RetryPolicy{MaxAttempts: 3, BackoffSeconds: 10, RetryableExitCodes: []int{75}}, pluginsInfo[0].Configuration.RetryPolicy)
+
+	testDocContent.MainSteps[0].RetryPolicy.MaxAttempts = contracts.MaxRetryAttempts + 1
+	_, err = testDocContent.ParseDocument(mockLog, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid retryPolicy of step install")
+}
+
+func TestParseDocument_OutputFilter(t *testing.T) {
+	mockLog := log.NewMockLog()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir:  testOrchDir,
+		MessageId:         testMessageID,
+		DocumentId:        testDocumentID,
+		DefaultWorkingDir: testWorkingDir,
+	}
+
+	var testDocContent DocContent
+	err := json.Unmarshal([]byte(outputfilterdocument), &testDocContent)
+	assert.NoError(t, err)
+	pluginsInfo, err := testDocContent.ParseDocument(mockLog, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &contracts.OutputFilter{JSONPath: "$.Reservations[*].Instances[*].InstanceId"}, pluginsInfo[0].Configuration.OutputFilter)
+
+	testDocContent.MainSteps[0].OutputFilter.LineMatch = "[i-"
+	_, err = testDocContent.ParseDocument(mockLog, contracts.DocumentInfo{}, testParserInfo, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid outputFilter of step describe")
+}
+
+func TestValidateStepFlow(t *testing.T) {
+	steps := []*contracts.InstancePluginConfig{
+		{Name: "download"},
+		{Name: "web", ParallelGroup: "configure", OnFailure: contracts.OnFailureExit},
+		{Name: "database", ParallelGroup: "configure", OnFailure: contracts.OnFailureContinue},
+		{Name: "restart"},
+	}
+	assert.NoError(t, validateStepFlow(steps))
+
+	steps[3].ParallelGroup = "configure"
+	steps[2].ParallelGroup = ""
+	err := validateStepFlow(steps)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "parallelGroup configure are not consecutive")
+
+	assert.NoError(t, validateStepFlow([]*contracts.InstancePluginConfig{{Name: "install", ComplianceSeverity: "HIGH"}}))
+	err = validateStepFlow([]*contracts.InstancePluginConfig{{Name: "install", ComplianceSeverity: "Severe"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid complianceSeverity Severe of step install")
+
+	err = validateStepFlow([]*contracts.InstancePluginConfig{{Name: "install", OnFailure: "Abort"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid onFailure Abort of step install")
+}
+
 func TestParseDocument_ValidParameters(t *testing.T) {
 	mockLog := log.NewMockLog()
 
@@ -296,6 +558,7 @@ func TestParseDocument_ValidParameters(t *testing.T) {
 
 	pluginInfoTest := pluginsInfo[0]
 	assert.Equal(t, "", pluginInfoTest.Result.Error)
+	assert.Equal(t, []interface{}{"date"}, pluginInfoTest.Configuration.DocumentParameters["commands"])
 	assert.Equal(t, filepath.Join(testOrchDir, "awsrunPowerShellScript"), pluginInfoTest.Configuration.OrchestrationDirectory)
 	assert.Equal(t, testS3Bucket, pluginInfoTest.Configuration.OutputS3BucketName)
 	assert.Equal(t, filepath.Join(testS3Prefix, "awsrunPowerShellScript"), pluginInfoTest.Configuration.OutputS3KeyPrefix)
@@ -22,6 +22,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -44,6 +45,17 @@ type T interface {
 	Execute(log.T, string, string, string, task.CancelFlag, int, string, []string) (io.Reader, io.Reader, int, []error)
 	NewExecute(log.T, string, io.Writer, io.Writer, task.CancelFlag, int, string, []string) (int, error)
 	StartExe(log.T, string, io.Writer, io.Writer, task.CancelFlag, string, []string) (*os.Process, int, error)
+	ExecuteWithEnvironment(log.T, string, io.Writer, io.Writer, task.CancelFlag, int, string, []string, Environment) (int, error)
+}
+
+// Environment represents the environment variables of an executed process
+type Environment struct {
+	// Variables are set for the process, they take precedence over the inherited variables
+	Variables map[string]string
+	// Clean starts the process with the variables the platform needs to run a shell instead of the environment of the agent
+	Clean bool
+	// Limits caps the resources of the process and of the processes it starts
+	Limits ResourceLimits
 }
 
 // ShellCommandExecuter is specially added for testing purposes
@@ -173,6 +185,23 @@ func (ShellCommandExecuter) NewExecute(
 	return
 }
 
+// ExecuteWithEnvironment executes a list of shell commands in the given working directory and environment and provides
+// the stdout and stderr writers.
+func (ShellCommandExecuter) ExecuteWithEnvironment(
+	log log.T,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	cancelFlag task.CancelFlag,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	environment Environment,
+) (exitCode int, err error) {
+	exitCode, err = ExecuteCommandWithEnvironment(log, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, environment)
+	return
+}
+
 // StartExe starts a list of shell commands in the given working directory.
 // Returns process started, an exit code (0 if successfully launch, 1 if error launching process), and a set of errors.
 // The errors need not be fatal - the output streams may still have data
@@ -254,6 +283,21 @@ func ExecuteCommand(log log.T,
 	commandName string,
 	commandArguments []string,
 ) (exitCode int, err error) {
+	return ExecuteCommandWithEnvironment(log, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, Environment{})
+}
+
+// ExecuteCommandWithEnvironment executes the given commands using the given working directory and environment.
+// Standard output and standard error are sent to the given writers.
+func ExecuteCommandWithEnvironment(log log.T,
+	cancelFlag task.CancelFlag,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	environment Environment,
+) (exitCode int, err error) {
 
 	stdoutInterruptable, stopStdout := newWriter(stdoutWriter)
 	stderrInterruptable, stopStderr := newWriter(stderrWriter)
@@ -283,7 +327,21 @@ func ExecuteCommand(log log.T,
 	prepareProcess(command)
 
 	// configure environment variables
-	prepareEnvironment(command)
+	prepareEnvironment(command, environment)
+
+	// the cgroup or job object of the limits is created before the process starts
+	var limiter *resourceLimiter
+	if environment.Limits.IsSet() {
+		var limitErr error
+		limiter, limitErr = newResourceLimiter(log, command, environment.Limits)
+		defer limiter.release()
+		if limitErr != nil {
+			log.Error("error occurred limiting the resources of the command", limitErr)
+			exitCode = 1
+			err = fmt.Errorf("failed to apply the resource limits: %v", limitErr)
+			return
+		}
+	}
 
 	log.Debug()
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
@@ -294,6 +352,17 @@ func ExecuteCommand(log log.T,
 		return
 	}
 
+	if limiter != nil {
+		if limitErr := limiter.apply(command.Process); limitErr != nil {
+			log.Error("error occurred limiting the resources of the command", limitErr)
+			killProcess(command.Process, &timeoutSignal{})
+			command.Wait()
+			exitCode = 1
+			err = fmt.Errorf("failed to apply the resource limits: %v", limitErr)
+			return
+		}
+	}
+
 	signal := timeoutSignal{}
 
 	cancelled := make(chan bool, 1)
@@ -400,7 +469,7 @@ func StartCommand(log log.T,
 	prepareProcess(command)
 
 	// configure environment variables
-	prepareEnvironment(command)
+	prepareEnvironment(command, Environment{})
 
 	log.Debug()
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
@@ -445,21 +514,68 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 	}
 }
 
-// prepareEnvironment adds ssm agent standard environment variables to the command
-func prepareEnvironment(command *exec.Cmd) {
+// prepareEnvironment adds ssm agent standard environment variables and the variables of the environment to the command
+func prepareEnvironment(command *exec.Cmd, environment Environment) {
 	env := os.Environ()
+	if environment.Clean {
+		env = cleanEnvironment(env)
+	}
 	if instance, err := instance.InstanceID(); err == nil {
 		env = append(env, fmtEnvVariable(envVarInstanceID, instance))
 	}
 	if region, err := instance.Region(); err == nil {
 		env = append(env, fmtEnvVariable(envVarRegionName, region))
 	}
+	names := make([]string, 0, len(environment.Variables))
+	for name := range environment.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = setEnvVariable(env, name, environment.Variables[name])
+	}
 	command.Env = env
 
 	// Running powershell on linux erquired the HOME env variable to be set and to remove the TERM env variable
 	validateEnvironmentVariables(command)
 }
 
+// cleanEnvironment keeps the variables the platform needs to run a shell
+func cleanEnvironment(env []string) (clean []string) {
+	for _, variable := range env {
+		name := strings.SplitN(variable, "=", 2)[0]
+		for _, kept := range cleanEnvironmentVariables {
+			if envVariableNamesEqual(name, kept) {
+				clean = append(clean, variable)
+				break
+			}
+		}
+	}
+	return
+}
+
+// setEnvVariable sets a variable, replacing the variables with the same name
+func setEnvVariable(env []string, name string, val string) []string {
+	result := make([]string, 0, len(env)+1)
+	for _, variable := range env {
+		if !envVariableNamesEqual(strings.SplitN(variable, "=", 2)[0], name) {
+			result = append(result, variable)
+		}
+	}
+	return append(result, fmtEnvVariable(name, val))
+}
+
+// ValidateEnvVariableName returns an error when the name can't be the name of an environment variable
+func ValidateEnvVariableName(name string) error {
+	if name == "" {
+		return fmt.Errorf("environment variable name is empty")
+	}
+	if strings.ContainsAny(name, "=\x00") {
+		return fmt.Errorf("environment variable name %v contains = or a null character", name)
+	}
+	return nil
+}
+
 // fmtEnvVariable creates the string to append to the current set of environment variables.
 func fmtEnvVariable(name string, val string) string {
 	return fmt.Sprintf("%s=%s", name, val)
@@ -17,6 +17,7 @@ package executers
 import (
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"testing"
@@ -72,7 +73,7 @@ func TestEnvironmentVariables_All(t *testing.T) {
 	defer func() { instance = instanceTemp }()
 
 	command := getTestCommand(t)
-	prepareEnvironment(command)
+	prepareEnvironment(command, Environment{})
 
 	assert.Equal(t, getEnvVariableValue(command.Env, envVarInstanceID), testInstanceID)
 	assert.Equal(t, getEnvVariableValue(command.Env, envVarRegionName), testRegionName)
@@ -84,12 +85,58 @@ func TestEnvironmentVariables_None(t *testing.T) {
 	defer func() { instance = instanceTemp }()
 
 	command := getTestCommand(t)
-	prepareEnvironment(command)
+	prepareEnvironment(command, Environment{})
 
 	assert.Empty(t, getEnvVariableValue(command.Env, envVarInstanceID))
 	assert.Empty(t, getEnvVariableValue(command.Env, envVarRegionName))
 }
 
+func TestEnvironmentVariables_Variables(t *testing.T) {
+	instanceTemp := instance
+	instance = &instanceInfoStub{instanceID: testInstanceID, regionName: testRegionName}
+	defer func() { instance = instanceTemp }()
+	os.Setenv("SSM_TEST_INHERITED", "inherited")
+	defer os.Unsetenv("SSM_TEST_INHERITED")
+
+	command := getTestCommand(t)
+	prepareEnvironment(command, Environment{Variables: map[string]string{"SSM_TEST_INHERITED": "overridden", "SSM_TEST_VAR": "a=b"}})
+
+	assert.Equal(t, "overridden", getEnvVariableValue(command.Env, "SSM_TEST_INHERITED"))
+	assert.Equal(t, "a=b", getEnvVariableValue(command.Env, "SSM_TEST_VAR"))
+	assert.Equal(t, testInstanceID, getEnvVariableValue(command.Env, envVarInstanceID))
+	count := 0
+	for _, variable := range command.Env {
+		if strings.HasPrefix(variable, "SSM_TEST_INHERITED=") {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestEnvironmentVariables_Clean(t *testing.T) {
+	instanceTemp := instance
+	instance = &instanceInfoStub{instanceID: testInstanceID, regionName: testRegionName}
+	defer func() { instance = instanceTemp }()
+	os.Setenv("SSM_TEST_INHERITED", "inherited")
+	defer os.Unsetenv("SSM_TEST_INHERITED")
+
+	command := getTestCommand(t)
+	prepareEnvironment(command, Environment{Clean: true, Variables: map[string]string{"SSM_TEST_VAR": "value"}})
+
+	assert.Empty(t, getEnvVariableValue(command.Env, "SSM_TEST_INHERITED"))
+	assert.Equal(t, os.Getenv("PATH"), getEnvVariableValue(command.Env, "PATH"))
+	assert.Equal(t, "value", getEnvVariableValue(command.Env, "SSM_TEST_VAR"))
+	assert.Equal(t, testInstanceID, getEnvVariableValue(command.Env, envVarInstanceID))
+	assert.Equal(t, testRegionName, getEnvVariableValue(command.Env, envVarRegionName))
+}
+
+func TestValidateEnvVariableName(t *testing.T) {
+	assert.NoError(t, ValidateEnvVariableName("MY_VAR"))
+	assert.Error(t, ValidateEnvVariableName(""))
+	assert.Error(t, ValidateEnvVariableName("MY=VAR"))
+	assert.Error(t, ValidateEnvVariableName("MY\x00VAR"))
+}
+
 func TestQuoteShString(t *testing.T) {
 	var result string
 
@@ -24,6 +24,14 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 )
 
+// cleanEnvironmentVariables are the variables kept in a clean environment
+var cleanEnvironmentVariables = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_ALL", "TMPDIR"}
+
+// envVariableNamesEqual compares the names of environment variables, which are case sensitive
+func envVariableNamesEqual(name1 string, name2 string) bool {
+	return name1 == name2
+}
+
 func prepareProcess(command *exec.Cmd) {
 	// make the process the leader of its process group
 	// (otherwise we cannot kill it properly)
@@ -18,12 +18,22 @@ package executers
 import (
 	"os"
 	"os/exec"
+	"strings"
 )
 
 const (
 	CWConfigIndex = 2
 )
 
+// cleanEnvironmentVariables are the variables kept in a clean environment
+var cleanEnvironmentVariables = []string{"SystemRoot", "SystemDrive", "windir", "ComSpec", "PATH", "PATHEXT", "TEMP", "TMP",
+	"PSModulePath", "ProgramFiles", "ProgramFiles(x86)", "ProgramData", "USERPROFILE", "COMPUTERNAME"}
+
+// envVariableNamesEqual compares the names of environment variables, which are case insensitive
+func envVariableNamesEqual(name1 string, name2 string) bool {
+	return strings.EqualFold(name1, name2)
+}
+
 func prepareProcess(command *exec.Cmd) {
 	// nothing to do on windows
 }
@@ -0,0 +1,113 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResourceLimits caps the resources of an executed process and of the processes it starts, zero values are not limited
+type ResourceLimits struct {
+	// CPUPercent caps the CPU time as a percentage of one core, 200 allows two cores
+	CPUPercent int `json:"cpuPercent"`
+	// MemoryMB caps the memory of the process tree in megabytes
+	MemoryMB int `json:"memoryMB"`
+	// MaxProcesses caps the number of processes of the process tree
+	MaxProcesses int `json:"maxProcesses"`
+	// MaxOpenFiles caps the open files of the process, as ulimit -n
+	MaxOpenFiles int `json:"maxOpenFiles"`
+}
+
+// IsSet returns whether any resource is limited
+func (limits ResourceLimits) IsSet() bool {
+	return limits != ResourceLimits{}
+}
+
+// Validate ensures the limits are positive and supported on the platform
+func (limits ResourceLimits) Validate() error {
+	for _, limit := range limits.fields() {
+		if *limit.value < 0 {
+			return fmt.Errorf("%v must be a positive number", limit.name)
+		}
+	}
+	return validatePlatformResourceLimits(limits)
+}
+
+// UnmarshalJSON accepts numbers and the strings document parameter substitution produces, such as "512"
+func (limits *ResourceLimits) UnmarshalJSON(data []byte) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	var parsed ResourceLimits
+	fields := parsed.fields()
+	for key, value := range values {
+		var field *int
+		for _, limit := range fields {
+			// field names are case insensitive as for the other plugin inputs
+			if strings.EqualFold(key, limit.name) {
+				field = limit.value
+			}
+		}
+		if field == nil {
+			return fmt.Errorf("unknown resource limit %v", key)
+		}
+		number, err := parseLimit(value)
+		if err != nil {
+			return fmt.Errorf("%v %v", key, err)
+		}
+		*field = number
+	}
+	*limits = parsed
+	return nil
+}
+
+// namedLimit is a field of the resource limits with its input name
+type namedLimit struct {
+	name  string
+	value *int
+}
+
+func (limits *ResourceLimits) fields() []namedLimit {
+	return []namedLimit{
+		{"cpuPercent", &limits.CPUPercent},
+		{"memoryMB", &limits.MemoryMB},
+		{"maxProcesses", &limits.MaxProcesses},
+		{"maxOpenFiles", &limits.MaxOpenFiles},
+	}
+}
+
+// parseLimit returns the whole number of the json value
+func parseLimit(value interface{}) (int, error) {
+	switch typed := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if typed == float64(int(typed)) {
+			return int(typed), nil
+		}
+	case string:
+		if strings.TrimSpace(typed) == "" {
+			return 0, nil
+		}
+		if number, err := strconv.Atoi(strings.TrimSpace(typed)); err == nil {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("value %v is not a whole number", value)
+}
@@ -0,0 +1,272 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package executers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// cgroupParent is the cgroup holding the cgroups of the executed processes
+	cgroupParent = "amazon-ssm-agent"
+
+	// cpuPeriodMicroseconds is the period the cpu quota applies to
+	cpuPeriodMicroseconds = 100000
+
+	// cgroupRemoveTimeout is how long removing a cgroup waits for its killed processes to exit
+	cgroupRemoveTimeout   = time.Second
+	cgroupRemoveRetryWait = 100 * time.Millisecond
+)
+
+// cgroupRoot is where the cgroup hierarchies are mounted
+var cgroupRoot = "/sys/fs/cgroup"
+
+// validatePlatformResourceLimits accepts every limit, they are enforced with cgroups and rlimits
+func validatePlatformResourceLimits(limits ResourceLimits) error {
+	return nil
+}
+
+// resourceLimiter enforces the limits of a process with a cgroup, the processes it starts inherit the cgroup and
+// the open files limit
+type resourceLimiter struct {
+	log     log.T
+	limits  ResourceLimits
+	cgroups []string
+	// cgroupDir is the cgroup v2 the process starts in, closed once the process started
+	cgroupDir *os.File
+}
+
+// cgroupCounter tells apart the cgroups created within the same nanosecond
+var cgroupCounter uint64
+
+// newResourceLimiter creates the cgroup of the command before it starts. With cgroup v2 the process is started in
+// its cgroup, so that no process it starts escapes the limits.
+func newResourceLimiter(log log.T, command *exec.Cmd, limits ResourceLimits) (limiter *resourceLimiter, err error) {
+	limiter = &resourceLimiter{log: log, limits: limits}
+	if limits.CPUPercent == 0 && limits.MemoryMB == 0 && limits.MaxProcesses == 0 {
+		return limiter, nil
+	}
+
+	// cgroups left by processes which kept running are never reused
+	name := fmt.Sprintf("command-%v-%v", time.Now().UnixNano(), atomic.AddUint64(&cgroupCounter, 1))
+	if !isCgroupV2() {
+		if limiter.cgroups, err = createCgroupV1(name, limits); err != nil {
+			return limiter, fmt.Errorf("failed to create the cgroup of the process: %v", err)
+		}
+		return limiter, nil
+	}
+	if limiter.cgroups, err = createCgroupV2(name, limits); err != nil {
+		return limiter, fmt.Errorf("failed to create the cgroup of the process: %v", err)
+	}
+	if limiter.cgroupDir, err = os.Open(limiter.cgroups[0]); err != nil {
+		return limiter, fmt.Errorf("failed to open the cgroup of the process: %v", err)
+	}
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.UseCgroupFD = true
+	command.SysProcAttr.CgroupFD = int(limiter.cgroupDir.Fd())
+	return limiter, nil
+}
+
+// apply limits the started process. Cgroup v1 can't start a process in a cgroup, the process is moved to its cgroup
+// once started, as its open files limit is set: the processes it started in between escape these limits.
+func (limiter *resourceLimiter) apply(process *os.Process) error {
+	if limiter.limits.MaxOpenFiles > 0 {
+		if err := setOpenFilesLimit(process.Pid, limiter.limits.MaxOpenFiles); err != nil {
+			return fmt.Errorf("failed to limit the open files: %v", err)
+		}
+	}
+	if limiter.cgroupDir != nil {
+		limiter.cgroupDir.Close()
+		limiter.cgroupDir = nil
+	} else {
+		for _, cgroup := range limiter.cgroups {
+			if err := writeCgroupFile(cgroup, "cgroup.procs", strconv.Itoa(process.Pid)); err != nil {
+				return fmt.Errorf("failed to move the process to its cgroup: %v", err)
+			}
+		}
+	}
+	if len(limiter.cgroups) > 0 {
+		limiter.log.Debugf("Process %v limited by the cgroups %v", process.Pid, limiter.cgroups)
+	}
+	return nil
+}
+
+// release removes the cgroups once the process exited
+func (limiter *resourceLimiter) release() {
+	if limiter.cgroupDir != nil {
+		limiter.cgroupDir.Close()
+	}
+	removeCgroups(limiter.log, limiter.cgroups)
+}
+
+// isCgroupV2 returns whether the unified cgroup hierarchy is mounted
+func isCgroupV2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// createCgroupV2 creates the cgroup of the process in the unified hierarchy
+func createCgroupV2(name string, limits ResourceLimits) ([]string, error) {
+	parent := filepath.Join(cgroupRoot, cgroupParent)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+	// the parent only holds cgroups, so its children can use the controllers
+	if err := writeCgroupFile(parent, "cgroup.subtree_control", "+cpu +memory +pids"); err != nil {
+		return nil, err
+	}
+
+	cgroup := filepath.Join(parent, name)
+	if err := os.Mkdir(cgroup, 0755); err != nil {
+		return nil, err
+	}
+	cgroups := []string{cgroup}
+
+	if limits.CPUPercent > 0 {
+		quota := fmt.Sprintf("%v %v", cpuQuota(limits.CPUPercent), cpuPeriodMicroseconds)
+		if err := writeCgroupFile(cgroup, "cpu.max", quota); err != nil {
+			return cgroups, err
+		}
+	}
+	if limits.MemoryMB > 0 {
+		if err := writeCgroupFile(cgroup, "memory.max", memoryBytes(limits.MemoryMB)); err != nil {
+			return cgroups, err
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		if err := writeCgroupFile(cgroup, "pids.max", strconv.Itoa(limits.MaxProcesses)); err != nil {
+			return cgroups, err
+		}
+	}
+	return cgroups, nil
+}
+
+// createCgroupV1 creates the cgroup of the process in the hierarchy of each limited controller
+func createCgroupV1(name string, limits ResourceLimits) (cgroups []string, err error) {
+	type controllerLimit struct {
+		controller string
+		files      map[string]string
+	}
+	var controllerLimits []controllerLimit
+	if limits.CPUPercent > 0 {
+		controllerLimits = append(controllerLimits, controllerLimit{"cpu", map[string]string{
+			"cpu.cfs_period_us": strconv.Itoa(cpuPeriodMicroseconds),
+			"cpu.cfs_quota_us":  strconv.Itoa(cpuQuota(limits.CPUPercent)),
+		}})
+	}
+	if limits.MemoryMB > 0 {
+		controllerLimits = append(controllerLimits, controllerLimit{"memory", map[string]string{
+			"memory.limit_in_bytes": memoryBytes(limits.MemoryMB),
+		}})
+	}
+	if limits.MaxProcesses > 0 {
+		controllerLimits = append(controllerLimits, controllerLimit{"pids", map[string]string{
+			"pids.max": strconv.Itoa(limits.MaxProcesses),
+		}})
+	}
+
+	for _, limit := range controllerLimits {
+		cgroup := filepath.Join(cgroupRoot, limit.controller, cgroupParent, name)
+		if err = os.MkdirAll(cgroup, 0755); err != nil {
+			return
+		}
+		cgroups = append(cgroups, cgroup)
+		// the period is set before the quota, the kernel validates the quota against it
+		for _, file := range []string{"cpu.cfs_period_us", "cpu.cfs_quota_us", "memory.limit_in_bytes", "pids.max"} {
+			if value, ok := limit.files[file]; ok {
+				if err = writeCgroupFile(cgroup, file, value); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// removeCgroups removes the cgroups once the processes they hold exited, processes left running keep their cgroup
+func removeCgroups(log log.T, cgroups []string) {
+	for _, cgroup := range cgroups {
+		if oomKills := readOomKills(cgroup); oomKills > 0 {
+			log.Warnf("%v processes were killed for exceeding the memory limit", oomKills)
+		}
+		deadline := time.Now().Add(cgroupRemoveTimeout)
+		for {
+			err := os.Remove(cgroup)
+			if err == nil || os.IsNotExist(err) {
+				break
+			}
+			if time.Now().After(deadline) {
+				log.Warnf("Cgroup %v was not removed, its processes are still running: %v", cgroup, err)
+				break
+			}
+			time.Sleep(cgroupRemoveRetryWait)
+		}
+	}
+}
+
+// readOomKills returns the processes of the cgroup killed for exceeding the memory limit
+func readOomKills(cgroup string) int {
+	events, err := ioutil.ReadFile(filepath.Join(cgroup, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(events), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, _ := strconv.Atoi(fields[1])
+			return count
+		}
+	}
+	return 0
+}
+
+// setOpenFilesLimit sets the soft and hard open files limit of the process
+func setOpenFilesLimit(pid int, maxOpenFiles int) error {
+	limit := syscall.Rlimit{Cur: uint64(maxOpenFiles), Max: uint64(maxOpenFiles)}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), syscall.RLIMIT_NOFILE,
+		uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func writeCgroupFile(cgroup string, file string, value string) error {
+	return ioutil.WriteFile(filepath.Join(cgroup, file), []byte(value), 0644)
+}
+
+// cpuQuota returns the cpu time of each period, in microseconds, of the percentage of one core
+func cpuQuota(cpuPercent int) int {
+	return cpuPercent * cpuPeriodMicroseconds / 100
+}
+
+func memoryBytes(memoryMB int) string {
+	return strconv.FormatInt(int64(memoryMB)*1024*1024, 10)
+}
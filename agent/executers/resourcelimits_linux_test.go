@@ -0,0 +1,117 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package executers
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// withCgroupRoot runs the test against a temporary folder standing for the cgroup mount
+func withCgroupRoot(t *testing.T, test func(root string)) {
+	root, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(root)
+	defer func(original string) { cgroupRoot = original }(cgroupRoot)
+	cgroupRoot = root
+	test(root)
+}
+
+func readCgroupFile(path ...string) string {
+	content, _ := ioutil.ReadFile(filepath.Join(path...))
+	return string(content)
+}
+
+func TestCreateCgroupV2(t *testing.T) {
+	withCgroupRoot(t, func(root string) {
+		ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids"), 0644)
+		assert.True(t, isCgroupV2())
+
+		cgroups, err := createCgroupV2("command-1", ResourceLimits{CPUPercent: 50, MemoryMB: 2, MaxProcesses: 10})
+
+		assert.NoError(t, err)
+		cgroup := filepath.Join(root, cgroupParent, "command-1")
+		assert.Equal(t, []string{cgroup}, cgroups)
+		assert.Equal(t, "+cpu +memory +pids", readCgroupFile(root, cgroupParent, "cgroup.subtree_control"))
+		assert.Equal(t, "50000 100000", readCgroupFile(cgroup, "cpu.max"))
+		assert.Equal(t, "2097152", readCgroupFile(cgroup, "memory.max"))
+		assert.Equal(t, "10", readCgroupFile(cgroup, "pids.max"))
+	})
+}
+
+func TestCreateCgroupV1(t *testing.T) {
+	withCgroupRoot(t, func(root string) {
+		assert.False(t, isCgroupV2())
+
+		cgroups, err := createCgroupV1("command-1", ResourceLimits{CPUPercent: 150, MemoryMB: 1})
+
+		assert.NoError(t, err)
+		cpu := filepath.Join(root, "cpu", cgroupParent, "command-1")
+		memory := filepath.Join(root, "memory", cgroupParent, "command-1")
+		assert.Equal(t, []string{cpu, memory}, cgroups)
+		assert.Equal(t, "150000", readCgroupFile(cpu, "cpu.cfs_quota_us"))
+		assert.Equal(t, "1048576", readCgroupFile(memory, "memory.limit_in_bytes"))
+	})
+}
+
+func TestReadOomKills(t *testing.T) {
+	cgroup, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(cgroup)
+	ioutil.WriteFile(filepath.Join(cgroup, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 2\n"), 0644)
+
+	assert.Equal(t, 2, readOomKills(cgroup))
+}
+
+func TestResourceLimiterStartsProcessInCgroupV2(t *testing.T) {
+	withCgroupRoot(t, func(root string) {
+		ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids"), 0644)
+		command := exec.Command("true")
+		prepareProcess(command)
+
+		limiter, err := newResourceLimiter(log.NewMockLog(), command, ResourceLimits{MaxProcesses: 10})
+
+		assert.NoError(t, err)
+		assert.Len(t, limiter.cgroups, 1)
+		assert.Equal(t, "10", readCgroupFile(limiter.cgroups[0], "pids.max"))
+		// the process starts in its cgroup, in its own process group as before
+		assert.True(t, command.SysProcAttr.Setpgid)
+		assert.True(t, command.SysProcAttr.UseCgroupFD)
+		assert.Equal(t, int(limiter.cgroupDir.Fd()), command.SysProcAttr.CgroupFD)
+
+		// the process is already in its cgroup once started, it is not moved
+		assert.NoError(t, limiter.apply(&os.Process{Pid: os.Getpid()}))
+		assert.Nil(t, limiter.cgroupDir)
+		assert.Equal(t, "", readCgroupFile(limiter.cgroups[0], "cgroup.procs"))
+	})
+}
+
+func TestResourceLimiterWithoutCgroup(t *testing.T) {
+	withCgroupRoot(t, func(root string) {
+		command := exec.Command("true")
+
+		limiter, err := newResourceLimiter(log.NewMockLog(), command, ResourceLimits{MaxOpenFiles: 64})
+
+		assert.NoError(t, err)
+		assert.Empty(t, limiter.cgroups)
+		assert.Nil(t, command.SysProcAttr)
+		limiter.release()
+	})
+}
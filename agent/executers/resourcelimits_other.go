@@ -0,0 +1,48 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd netbsd openbsd
+
+package executers
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+var errResourceLimitsNotSupported = errors.New("resource limits are not supported on this platform")
+
+// validatePlatformResourceLimits rejects the limits, which are not enforced on this platform
+func validatePlatformResourceLimits(limits ResourceLimits) error {
+	if limits.IsSet() {
+		return errResourceLimitsNotSupported
+	}
+	return nil
+}
+
+// resourceLimiter is never created, the process would otherwise run without its limits
+type resourceLimiter struct{}
+
+// newResourceLimiter fails, the limits are not enforced on this platform
+func newResourceLimiter(log log.T, command *exec.Cmd, limits ResourceLimits) (*resourceLimiter, error) {
+	return &resourceLimiter{}, errResourceLimitsNotSupported
+}
+
+func (limiter *resourceLimiter) apply(process *os.Process) error {
+	return errResourceLimitsNotSupported
+}
+
+func (limiter *resourceLimiter) release() {}
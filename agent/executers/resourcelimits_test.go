@@ -0,0 +1,44 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceLimits_UnmarshalJSON(t *testing.T) {
+	var limits ResourceLimits
+	err := json.Unmarshal([]byte(`{"cpuPercent": 50, "MEMORYMB": "512", "maxProcesses": " 64 ", "maxOpenFiles": ""}`), &limits)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ResourceLimits{CPUPercent: 50, MemoryMB: 512, MaxProcesses: 64}, limits)
+	assert.True(t, limits.IsSet())
+	assert.False(t, ResourceLimits{}.IsSet())
+}
+
+func TestResourceLimits_UnmarshalJSONInvalid(t *testing.T) {
+	for _, data := range []string{`{"memory": 512}`, `{"memoryMB": 1.5}`, `{"memoryMB": "half"}`, `{"memoryMB": true}`, `[]`} {
+		var limits ResourceLimits
+		assert.Error(t, json.Unmarshal([]byte(data), &limits), data)
+	}
+}
+
+func TestResourceLimits_Validate(t *testing.T) {
+	assert.NoError(t, ResourceLimits{}.Validate())
+	assert.Error(t, ResourceLimits{CPUPercent: -1}.Validate())
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package executers
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/jobobject"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxCpuRate is the cpu rate of all the processors, job objects express cpu rates in 1/100 of a percent
+const maxCpuRate = 10000
+
+// validatePlatformResourceLimits rejects the limits job objects do not enforce
+func validatePlatformResourceLimits(limits ResourceLimits) error {
+	if limits.MaxOpenFiles > 0 {
+		return errors.New("maxOpenFiles is not supported on Windows")
+	}
+	return nil
+}
+
+// resourceLimiter enforces the limits of a process with a job object, the processes it starts belong to the job
+// object as well
+type resourceLimiter struct {
+	log log.T
+	job syscall.Handle
+}
+
+// newResourceLimiter creates the job object of the command before it starts
+func newResourceLimiter(log log.T, command *exec.Cmd, limits ResourceLimits) (*resourceLimiter, error) {
+	job, err := jobobject.CreateLimitedJobObject(jobobject.JobLimits{
+		CpuRate:            cpuRate(limits.CPUPercent, runtime.NumCPU()),
+		JobMemoryLimit:     uintptr(limits.MemoryMB) * 1024 * 1024,
+		ActiveProcessLimit: uint32(limits.MaxProcesses),
+	})
+	if err != nil {
+		return &resourceLimiter{log: log}, err
+	}
+	return &resourceLimiter{log: log, job: job}, nil
+}
+
+// apply assigns the started process to the job object, the processes it started before escape the limits
+func (limiter *resourceLimiter) apply(process *os.Process) error {
+	if err := jobobject.AssignProcessToJob(limiter.job, uint32(process.Pid)); err != nil {
+		return err
+	}
+	limiter.log.Debugf("Process %v limited by a job object", process.Pid)
+	return nil
+}
+
+// release closes the job object once the process exited
+func (limiter *resourceLimiter) release() {
+	if limiter.job != 0 {
+		syscall.CloseHandle(limiter.job)
+	}
+}
+
+// cpuRate converts the percentage of one core to the share of all the processors
+func cpuRate(cpuPercent int, processors int) uint32 {
+	if cpuPercent == 0 {
+		return 0
+	}
+	rate := cpuPercent * 100 / processors
+	if rate < 1 {
+		return 1
+	}
+	if rate > maxCpuRate {
+		return maxCpuRate
+	}
+	return uint32(rate)
+}
@@ -0,0 +1,413 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fileutil contains utilities for working with the file system.
+package fileutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// ArchiveFormat is the format of an archive, detected from the leading bytes of the file
+type ArchiveFormat string
+
+const (
+	// ArchiveUnknown is a file which isn't a supported archive
+	ArchiveUnknown ArchiveFormat = ""
+	// ArchiveZip is a zip archive
+	ArchiveZip ArchiveFormat = "zip"
+	// ArchiveTarGz is a gzip compressed tarball
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	// ArchiveTarZst is a zstd compressed tarball
+	ArchiveTarZst ArchiveFormat = "tar.zst"
+	// Archive7z is a 7-Zip archive
+	Archive7z ArchiveFormat = "7z"
+)
+
+// magic numbers of the supported archive formats
+var archiveSignatures = []struct {
+	format    ArchiveFormat
+	signature []byte
+}{
+	{ArchiveZip, []byte("PK\x03\x04")},
+	{ArchiveTarGz, []byte{0x1f, 0x8b}},
+	{ArchiveTarZst, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{Archive7z, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}},
+}
+
+// MaxExtractedBytes limits the total size of the files extracted from an archive, so a small
+// archive can't fill the disk of the instance
+var MaxExtractedBytes int64 = 10 << 30
+
+var (
+	// zstd and 7z archives are extracted by the zstd and 7-Zip command line tools
+	zstdCommand      = "zstd"
+	sevenZipCommands = []string{"7z", "7za"}
+
+	// newZstdReader and runSevenZip are replaced in tests
+	newZstdReader = zstdCommandReader
+	runSevenZip   = sevenZipCommand
+)
+
+// DetectArchiveFormat returns the format of the archive at src, ArchiveUnknown if it isn't a supported archive
+func DetectArchiveFormat(src string) (format ArchiveFormat, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return
+	}
+	for _, archive := range archiveSignatures {
+		if bytes.HasPrefix(header[:n], archive.signature) {
+			return archive.format, nil
+		}
+	}
+	return ArchiveUnknown, nil
+}
+
+// ExtractArchive extracts the zip, tar.gz, zstd compressed tarball or 7z archive at src into dest.
+// Archives with entries placed outside of dest or expanding beyond MaxExtractedBytes are rejected.
+func ExtractArchive(src, dest string) error {
+	format, err := DetectArchiveFormat(src)
+	if err != nil {
+		return fmt.Errorf("failed to read archive %v, %v", src, err)
+	}
+	if err = ValidateUNCPath(dest); err != nil {
+		return err
+	}
+
+	switch format {
+	case ArchiveZip:
+		return extractZip(src, dest)
+	case ArchiveTarGz:
+		return extractTarGz(src, dest)
+	case ArchiveTarZst:
+		return extractTarZst(src, dest)
+	case Archive7z:
+		return extract7z(src, dest)
+	default:
+		return fmt.Errorf("%v isn't a zip, tar.gz, tar.zst or 7z archive", src)
+	}
+}
+
+// extractZip checks the size of the zip archive before unzipping it, the zip reader fails on entries
+// larger than their declared size
+func extractZip(src, dest string) error {
+	r, err := zip.OpenReader(LongPath(src))
+	if err != nil {
+		return err
+	}
+	var size uint64
+	for _, f := range r.File {
+		size += f.UncompressedSize64
+	}
+	r.Close()
+
+	if size > uint64(MaxExtractedBytes) {
+		return fmt.Errorf("%v expands to %v bytes, more than the limit of %v bytes", src, size, MaxExtractedBytes)
+	}
+	return Unzip(src, dest)
+}
+
+// extractTarGz extracts a gzip compressed tarball
+func extractTarGz(src, dest string) error {
+	file, err := os.Open(LongPath(src))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	return extractTar(gr, dest)
+}
+
+// extractTarZst extracts a zstd compressed tarball
+func extractTarZst(src, dest string) (err error) {
+	reader, err := newZstdReader(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := reader.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to decompress %v, %v", src, closeErr)
+		}
+	}()
+
+	if err = extractTar(reader, dest); err != nil {
+		return err
+	}
+	// the tarball ends before the padding of the decompressed stream, the rest is read so zstd exits cleanly
+	_, err = io.Copy(ioutil.Discard, reader)
+	return err
+}
+
+// extractTar extracts the directories, files and links of a tarball into dest
+func extractTar(reader io.Reader, dest string) error {
+	if err := os.MkdirAll(LongPath(dest), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+
+	remaining := MaxExtractedBytes
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		itemPath := filepath.Join(dest, hdr.Name)
+		if !isUnderDir(itemPath, dest) {
+			return fmt.Errorf("%v attempts to place files outside %v subtree", hdr.Name, dest)
+		}
+		mode := hdr.FileInfo().Mode()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(LongPath(itemPath), mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if hdr.Size > remaining {
+				return fmt.Errorf("archive expands to more than the limit of %v bytes", MaxExtractedBytes)
+			}
+			remaining -= hdr.Size
+			if err = extractTarFile(tr, LongPath(itemPath), mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = checkSymlinkTarget(itemPath, hdr.Linkname, dest); err != nil {
+				return fmt.Errorf("link %v points outside %v subtree, %v", hdr.Name, dest, err)
+			}
+			os.Remove(itemPath)
+			if err = os.Symlink(hdr.Linkname, itemPath); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			target := filepath.Join(dest, hdr.Linkname)
+			if !isUnderDir(target, dest) {
+				return fmt.Errorf("link %v points outside %v subtree", hdr.Name, dest)
+			}
+			os.Remove(itemPath)
+			if err = os.Link(target, itemPath); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkSymlinkTarget checks that the symlink at linkPath resolves under dest. The directory of the link is
+// resolved first since it may go through the links extracted before. A ".." following another element of
+// the target is rejected, it would depend on whether that element is a link, which a later entry may create.
+func checkSymlinkTarget(linkPath, linkname, dest string) error {
+	afterName := false
+	for _, element := range strings.Split(filepath.ToSlash(linkname), "/") {
+		if element == ".." && afterName {
+			return fmt.Errorf("%v has a parent reference after a path element", linkname)
+		}
+		afterName = afterName || (element != "" && element != "." && element != "..")
+	}
+	if filepath.IsAbs(linkname) {
+		if !isUnderDir(linkname, dest) {
+			return fmt.Errorf("%v is not under %v", linkname, dest)
+		}
+		return nil
+	}
+
+	linkDir := filepath.Dir(linkPath)
+	if err := os.MkdirAll(LongPath(linkDir), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+	realDir, err := filepath.EvalSymlinks(linkDir)
+	if err != nil {
+		return err
+	}
+	realDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	if target := filepath.Join(realDir, linkname); !isUnderDir(target, realDest) {
+		return fmt.Errorf("%v resolves to %v", linkname, target)
+	}
+	return nil
+}
+
+// extractTarFile writes the content of the current tarball entry to path
+func extractTarFile(tr *tar.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+	fw, err := os.OpenFile(path, appconfig.FileFlagsCreateOrTruncate, mode)
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	_, err = io.Copy(fw, tr)
+	return err
+}
+
+// extract7z validates the entries listed by 7-Zip before extracting the archive
+func extract7z(src, dest string) error {
+	listing, err := runSevenZip("l", "-slt", "--", src)
+	if err != nil {
+		return fmt.Errorf("failed to list %v, %v", src, err)
+	}
+
+	var size int64
+	for _, entry := range parseSevenZipListing(listing) {
+		if filepath.IsAbs(entry.path) || !isUnderDir(filepath.Join(dest, entry.path), dest) {
+			return fmt.Errorf("%v attempts to place files outside %v subtree", entry.path, dest)
+		}
+		size += entry.size
+	}
+	if size > MaxExtractedBytes {
+		return fmt.Errorf("%v expands to %v bytes, more than the limit of %v bytes", src, size, MaxExtractedBytes)
+	}
+
+	if err = os.MkdirAll(LongPath(dest), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+	if _, err = runSevenZip("x", "-y", "-o"+dest, "--", src); err != nil {
+		return fmt.Errorf("failed to extract %v, %v", src, err)
+	}
+	return nil
+}
+
+// sevenZipEntry is an entry of a 7z archive
+type sevenZipEntry struct {
+	path string
+	size int64
+}
+
+// parseSevenZipListing parses the technical listing (7z l -slt) of an archive. The entries follow a
+// dashed line, the properties of each entry are "Name = Value" lines separated by blank lines.
+func parseSevenZipListing(listing string) (entries []sevenZipEntry) {
+	scanner := bufio.NewScanner(strings.NewReader(listing))
+	inEntries := false
+	var entry *sevenZipEntry
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !inEntries {
+			inEntries = strings.HasPrefix(line, "----------")
+			continue
+		}
+
+		if line == "" {
+			if entry != nil {
+				entries = append(entries, *entry)
+				entry = nil
+			}
+			continue
+		}
+		parts := strings.SplitN(line, " = ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "Path":
+			entry = &sevenZipEntry{path: parts[1]}
+		case "Size":
+			if entry != nil {
+				entry.size, _ = strconv.ParseInt(parts[1], 10, 64)
+			}
+		}
+	}
+	if entry != nil {
+		entries = append(entries, *entry)
+	}
+	return
+}
+
+// sevenZipCommand runs the first 7-Zip command line tool found and returns its output
+func sevenZipCommand(args ...string) (string, error) {
+	commands := sevenZipCommands
+	if programFiles := os.Getenv("ProgramFiles"); programFiles != "" {
+		// 7-Zip doesn't add itself to the PATH on Windows
+		commands = append(commands, filepath.Join(programFiles, "7-Zip", "7z.exe"))
+	}
+	for _, command := range commands {
+		path, err := exec.LookPath(command)
+		if err != nil {
+			continue
+		}
+		output, err := exec.Command(path, args...).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("%v: %v", err, strings.TrimSpace(string(output)))
+		}
+		return string(output), nil
+	}
+	return "", fmt.Errorf("7-Zip is required to extract 7z archives, none of %v was found", commands)
+}
+
+// zstdCommandReader returns the tarball decompressed by the zstd command line tool, closing the
+// reader waits for the tool to exit
+func zstdCommandReader(src string) (io.ReadCloser, error) {
+	path, err := exec.LookPath(zstdCommand)
+	if err != nil {
+		return nil, fmt.Errorf("zstd is required to extract zstd compressed archives, %v", err)
+	}
+
+	cmd := exec.Command(path, "-d", "-c", "-q", "--", LongPath(src))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd, stderr: &stderr}, nil
+}
+
+// commandReader reads the output of a command
+type commandReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+// Close stops reading the output and waits for the command to exit
+func (r *commandReader) Close() error {
+	r.ReadCloser.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("%v: %v", err, strings.TrimSpace(r.stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fileutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tarEntry is an entry of a test tarball
+type tarEntry struct {
+	name     string
+	content  string
+	typeflag byte
+	linkname string
+}
+
+func createTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: typeflag, Linkname: entry.linkname}
+		if typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		if typeflag == tar.TypeReg {
+			tw.Write([]byte(entry.content))
+		}
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func createTarGz(t *testing.T, path string, entries []tarEntry) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(createTar(t, entries))
+	gw.Close()
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+}
+
+func createZip(t *testing.T, path string, files map[string]string) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+
+	testCases := map[string]struct {
+		content []byte
+		format  ArchiveFormat
+	}{
+		"package.zip":     {[]byte("PK\x03\x04rest"), ArchiveZip},
+		"package.tar.gz":  {[]byte{0x1f, 0x8b, 0x08, 0x00}, ArchiveTarGz},
+		"package.tar.zst": {[]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, ArchiveTarZst},
+		"package.7z":      {[]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0x00, 0x04}, Archive7z},
+		"script.sh":       {[]byte("echo hello"), ArchiveUnknown},
+		"empty":           {[]byte{}, ArchiveUnknown},
+	}
+	for name, testCase := range testCases {
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, testCase.content, 0600)
+		format, err := DetectArchiveFormat(path)
+		assert.NoError(t, err, name)
+		assert.Equal(t, testCase.format, format, name)
+	}
+
+	_, err := DetectArchiveFormat(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestExtractArchiveTarGz(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "package.tar.gz")
+	dest := filepath.Join(dir, "package")
+	createTarGz(t, src, []tarEntry{
+		{name: "bin/", typeflag: tar.TypeDir},
+		{name: "bin/install.sh", content: "install"},
+		{name: "bin/setup.sh", typeflag: tar.TypeSymlink, linkname: "install.sh"},
+	})
+
+	assert.NoError(t, ExtractArchive(src, dest))
+	content, _ := ioutil.ReadFile(filepath.Join(dest, "bin", "setup.sh"))
+	assert.Equal(t, "install", string(content))
+}
+
+func TestExtractArchiveRejectsEntriesOutsideDestination(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "package.tar.gz")
+	dest := filepath.Join(dir, "package")
+
+	createTarGz(t, src, []tarEntry{{name: "../evil.sh", content: "evil"}})
+	assert.Error(t, ExtractArchive(src, dest))
+	assert.False(t, Exists(filepath.Join(dir, "evil.sh")))
+
+	createTarGz(t, src, []tarEntry{{name: "passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}})
+	assert.Error(t, ExtractArchive(src, dest))
+
+	createTarGz(t, src, []tarEntry{{name: "passwd", typeflag: tar.TypeLink, linkname: "../../etc/passwd"}})
+	assert.Error(t, ExtractArchive(src, dest))
+}
+
+func TestExtractArchiveRejectsLinksThroughLinks(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "package.tar.gz")
+	dest := filepath.Join(dir, "package")
+
+	createTarGz(t, src, []tarEntry{
+		{name: "a/b/up2", typeflag: tar.TypeSymlink, linkname: "../.."},
+		{name: "esc", typeflag: tar.TypeSymlink, linkname: "a/b/up2/.."},
+		{name: "esc/pwned.txt", content: "pwned"},
+	})
+	assert.Error(t, ExtractArchive(src, dest))
+	assert.False(t, Exists(filepath.Join(dir, "pwned.txt")))
+
+	// the directory of the link is a link to the destination itself
+	os.RemoveAll(dest)
+	createTarGz(t, src, []tarEntry{
+		{name: "a", typeflag: tar.TypeSymlink, linkname: "."},
+		{name: "a/esc", typeflag: tar.TypeSymlink, linkname: "../"},
+		{name: "a/esc/pwned.txt", content: "pwned"},
+	})
+	assert.Error(t, ExtractArchive(src, dest))
+	assert.False(t, Exists(filepath.Join(dir, "pwned.txt")))
+}
+
+func TestExtractArchiveSizeLimit(t *testing.T) {
+	defer func(max int64) { MaxExtractedBytes = max }(MaxExtractedBytes)
+	MaxExtractedBytes = 10
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "package.tar.gz")
+	createTarGz(t, src, []tarEntry{{name: "a", content: "123456"}, {name: "b", content: "123456"}})
+	assert.Error(t, ExtractArchive(src, filepath.Join(dir, "tar")))
+
+	src = filepath.Join(dir, "package.zip")
+	createZip(t, src, map[string]string{"a": "123456", "b": "123456"})
+	assert.Error(t, ExtractArchive(src, filepath.Join(dir, "zip")))
+
+	createZip(t, src, map[string]string{"a": "123456"})
+	assert.NoError(t, ExtractArchive(src, filepath.Join(dir, "zip")))
+}
+
+func TestExtractArchiveTarZst(t *testing.T) {
+	defer func() { newZstdReader = zstdCommandReader }()
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "package.tar.zst")
+	ioutil.WriteFile(src, []byte{0x28, 0xb5, 0x2f, 0xfd}, 0600)
+	tarball := createTar(t, []tarEntry{{name: "install.sh", content: "install"}})
+	newZstdReader = func(path string) (io.ReadCloser, error) {
+		assert.Equal(t, src, path)
+		return ioutil.NopCloser(bytes.NewReader(tarball)), nil
+	}
+
+	assert.NoError(t, ExtractArchive(src, dir))
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "install.sh"))
+	assert.Equal(t, "install", string(content))
+}
+
+const sevenZipListing = `
+7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21
+
+Listing archive: package.7z
+
+--
+Path = package.7z
+Type = 7z
+Physical Size = 180
+
+----------
+Path = bin
+Size = 0
+Attributes = D_ drwxr-xr-x
+
+Path = %v
+Size = %v
+Packed Size = 12
+Attributes = A_ -rw-r--r--
+`
+
+func TestParseSevenZipListing(t *testing.T) {
+	listing := strings.Replace(strings.Replace(sevenZipListing, "%v", "bin/install.sh", 1), "%v", "7", 1)
+	assert.Equal(t, []sevenZipEntry{{path: "bin", size: 0}, {path: "bin/install.sh", size: 7}}, parseSevenZipListing(listing))
+}
+
+func TestExtractArchive7z(t *testing.T) {
+	defer func(max int64) {
+		runSevenZip = sevenZipCommand
+		MaxExtractedBytes = max
+	}(MaxExtractedBytes)
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "package.7z")
+	dest := filepath.Join(dir, "package")
+	ioutil.WriteFile(src, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, 0600)
+
+	var listing string
+	var extractArgs []string
+	runSevenZip = func(args ...string) (string, error) {
+		if args[0] == "l" {
+			return listing, nil
+		}
+		extractArgs = args
+		return "", nil
+	}
+
+	listing = strings.Replace(strings.Replace(sevenZipListing, "%v", "bin/install.sh", 1), "%v", "7", 1)
+	assert.NoError(t, ExtractArchive(src, dest))
+	assert.Equal(t, []string{"x", "-y", "-o" + dest, "--", src}, extractArgs)
+
+	extractArgs = nil
+	listing = strings.Replace(strings.Replace(sevenZipListing, "%v", "../evil.sh", 1), "%v", "7", 1)
+	assert.Error(t, ExtractArchive(src, dest))
+	assert.Nil(t, extractArgs)
+
+	MaxExtractedBytes = 5
+	listing = strings.Replace(strings.Replace(sevenZipListing, "%v", "bin/install.sh", 1), "%v", "7", 1)
+	assert.Error(t, ExtractArchive(src, dest))
+	assert.Nil(t, extractArgs)
+}
+
+func TestExtractArchiveUnknownFormat(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "script.sh")
+	ioutil.WriteFile(src, []byte("echo hello"), 0600)
+
+	assert.Error(t, ExtractArchive(src, dir))
+}
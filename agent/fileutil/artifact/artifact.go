@@ -51,10 +51,17 @@ type DownloadInput struct {
 	SourceURL            string
 	DestinationDirectory string
 	SourceChecksums      map[string]string
+
+	// S3RequesterPays acknowledges that the requester is charged for downloads from requester-pays buckets
+	S3RequesterPays bool
+	// S3KMSKeyID, if set, rejects S3 objects that are not encrypted with this KMS key (SSE-KMS)
+	S3KMSKeyID string
+	// CABundlePath is a PEM file with certificate authorities trusted for https downloads, in addition to the system ones
+	CABundlePath string
 }
 
 // httpDownload attempts to download a file via http/s call
-func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOutput, err error) {
+func httpDownload(log log.T, fileURL string, destFile string, transport http.RoundTripper) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as http/https download %v", destFile)
 	eTagFile := destFile + ".etag"
 	var check http.Client
@@ -70,6 +77,7 @@ func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOu
 	}
 
 	check = http.Client{
+		Transport: transport,
 		CheckRedirect: func(r *http.Request, via []*http.Request) error {
 			r.URL.Opaque = r.URL.Path
 			return nil
@@ -107,7 +115,7 @@ func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOu
 			return
 		}
 	}
-	_, err = FileCopy(log, destFile, resp.Body)
+	_, err = FileCopy(log, destFile, getDownloadManager().throttle(resp.Body))
 	if err == nil {
 		output.LocalFilePath = destFile
 		output.IsUpdated = true
@@ -144,7 +152,6 @@ func awsConfig(log log.T, amazonS3URL s3util.AmazonS3URL) (config *aws.Config, e
 
 // CanGetS3Object returns true if it is possible to fetch an object because it exists, is not deleted, and read permissions exist for this request
 func CanGetS3Object(log log.T, amazonS3URL s3util.AmazonS3URL) bool {
-	config, _ := awsConfig(log, amazonS3URL)
 	bucketName := amazonS3URL.Bucket
 	objectKey := amazonS3URL.Key
 
@@ -153,11 +160,7 @@ func CanGetS3Object(log log.T, amazonS3URL s3util.AmazonS3URL) bool {
 		Key:    aws.String(objectKey),
 	}
 
-	appConfig, _ := appconfig.Config(false)
-	sess := session.New(config)
-	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
-
-	s3client := s3.New(sess)
+	s3client := newS3Client(log, amazonS3URL)
 	var res *s3.HeadObjectOutput
 	var err error
 	if res, err = s3client.HeadObject(params); err != nil {
@@ -171,7 +174,6 @@ func CanGetS3Object(log log.T, amazonS3URL s3util.AmazonS3URL) bool {
 // ListS3Folders returns the folders under a given S3 URL where folders are keys whose prefix is the URL key
 // and contain a / after the prefix.  The folder name is the part between the prefix and the /.
 func ListS3Folders(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []string, err error) {
-	config, _ := awsConfig(log, amazonS3URL)
 	prefix := amazonS3URL.Key
 	if !strings.HasSuffix(prefix, "/") {
 		prefix = prefix + "/"
@@ -181,11 +183,7 @@ func ListS3Folders(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []str
 		Prefix:    &prefix,
 		Delimiter: aws.String("/"),
 	}
-	appConfig, _ := appconfig.Config(false)
-	sess := session.New(config)
-	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
-
-	s3client := s3.New(sess)
+	s3client := newS3Client(log, amazonS3URL)
 	req, resp := s3client.ListObjectsRequest(params)
 	err = req.Send()
 	log.Debugf("ListS3Folders Bucket: %v, Prefix: %v, RequestID: %v", params.Bucket, params.Prefix, req.RequestID)
@@ -205,7 +203,6 @@ func ListS3Folders(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []str
 // ListS3Directory returns all the objects (files and folders) under a given S3 URL where folders are keys whose prefix
 // is the URL key and contain a / after the prefix.
 func ListS3Directory(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []string, err error) {
-	config, _ := awsConfig(log, amazonS3URL)
 	var params *s3.ListObjectsInput
 	prefix := amazonS3URL.Key
 	if prefix != "" {
@@ -224,11 +221,7 @@ func ListS3Directory(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []s
 	}
 	log.Debugf("ListS3Object Bucket: %v, Prefix: %v", params.Bucket, params.Prefix)
 
-	appConfig, _ := appconfig.Config(false)
-	sess := session.New(config)
-	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
-
-	s3client := s3.New(sess)
+	s3client := newS3Client(log, amazonS3URL)
 	obj, err := s3client.ListObjects(params)
 	if err != nil {
 		log.Errorf("ListS3Directory error %v", err.Error())
@@ -243,16 +236,51 @@ func ListS3Directory(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []s
 	return
 }
 
-// s3Download attempts to download a file via the aws sdk.
-func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (output DownloadOutput, err error) {
-	log.Debugf("attempting to download as s3 download %v", destFile)
-	eTagFile := destFile + ".etag"
-
+// newS3Client creates an S3 client for the region of the S3 URL.
+// Requests are signed with SigV4, which is required to get SSE-KMS encrypted objects.
+func newS3Client(log log.T, amazonS3URL s3util.AmazonS3URL) *s3.S3 {
 	config, _ := awsConfig(log, amazonS3URL)
+	appConfig, _ := appconfig.Config(false)
+	sess := session.New(config)
+	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+
+	return s3.New(sess)
+}
+
+// newGetObjectInput creates the GetObject request parameters for the download input
+func newGetObjectInput(input DownloadInput, amazonS3URL s3util.AmazonS3URL) *s3.GetObjectInput {
 	params := &s3.GetObjectInput{
 		Bucket: aws.String(amazonS3URL.Bucket),
 		Key:    aws.String(amazonS3URL.Key),
 	}
+	if input.S3RequesterPays {
+		params.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	return params
+}
+
+// verifyKMSEncryption checks that the object is encrypted with the KMS key expected by the download input
+func verifyKMSEncryption(input DownloadInput, resp *s3.GetObjectOutput) error {
+	if input.S3KMSKeyID == "" {
+		return nil
+	}
+	if aws.StringValue(resp.ServerSideEncryption) != s3.ServerSideEncryptionAwsKms {
+		return fmt.Errorf("s3 object is not encrypted with KMS, expected key %v", input.S3KMSKeyID)
+	}
+	keyID := aws.StringValue(resp.SSEKMSKeyId)
+	// the key can be given as key id, alias or ARN while S3 returns the key ARN
+	if keyID != input.S3KMSKeyID && !strings.HasSuffix(keyID, "/"+input.S3KMSKeyID) {
+		return fmt.Errorf("s3 object is encrypted with KMS key %v, expected key %v", keyID, input.S3KMSKeyID)
+	}
+	return nil
+}
+
+// s3Download attempts to download a file via the aws sdk.
+func s3Download(log log.T, input DownloadInput, amazonS3URL s3util.AmazonS3URL, destFile string) (output DownloadOutput, err error) {
+	log.Debugf("attempting to download as s3 download %v", destFile)
+	eTagFile := destFile + ".etag"
+
+	params := newGetObjectInput(input, amazonS3URL)
 
 	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
 		var existingETag string
@@ -263,12 +291,8 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 		}
 		params.IfNoneMatch = aws.String(existingETag)
 	}
-	appConfig, _ := appconfig.Config(false)
-	sess := session.New(config)
-	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
-
-	s3client := s3.New(sess)
 
+	s3client := newS3Client(log, amazonS3URL)
 	req, resp := s3client.GetObjectRequest(params)
 	err = req.Send()
 	if err != nil {
@@ -284,6 +308,11 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 		output.LocalFilePath = destFile
 		return output, nil
 	}
+	defer resp.Body.Close()
+
+	if err = verifyKMSEncryption(input, resp); err != nil {
+		return
+	}
 
 	if *resp.ETag != "" {
 		log.Debug("files etag is ", *resp.ETag)
@@ -294,8 +323,7 @@ func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (out
 		}
 	}
 
-	defer resp.Body.Close()
-	_, err = FileCopy(log, destFile, resp.Body)
+	_, err = FileCopy(log, destFile, getDownloadManager().throttle(resp.Body))
 	if err == nil {
 		output.LocalFilePath = destFile
 		output.IsUpdated = true
@@ -365,22 +393,12 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 		urlHash := sha1.Sum([]byte(fileURL.String()))
 		output.LocalFilePath = filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
 
-		amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
-		if amazonS3URL.IsBucketAndKeyPresent() {
-			// source is s3
-			var tempOutput DownloadOutput
-			tempOutput, err = s3Download(log, amazonS3URL, output.LocalFilePath)
-			// if s3 download fails, attempt http/https download as fallback
-			if err != nil {
-				tempOutput, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
-			}
-			output = tempOutput
-		} else {
-			// simple http/https download
-			output, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
-		}
+		// wait for a download slot, the agent limits the downloads running at the same time
+		release := getDownloadManager().acquire()
+		defer release()
 
-		if err != nil {
+		// try the sources handling the url in order, e.g. s3 urls fall back to http/https download
+		if output, err = downloadFromSources(log, input, fileURL, output.LocalFilePath); err != nil {
 			return
 		}
 
@@ -38,14 +38,14 @@ var (
 	mockLog              = log.NewMockLog()
 
 	downloadTests = []DownloadTest{
-		// {DownloadInput{SourceUrl, DestinationDirectory, SourceHashValue, SourceHashType},
+		// {DownloadInput{SourceURL, DestinationDirectory, SourceChecksums},
 		// DownloadOutput{LocalFilePath, IsUpdated, IsHashMatched}},
 		{
 			// validate sha256
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -56,9 +56,9 @@ var (
 		{
 			// validate incorrect sha256 fails
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha256": "111111111",
 				}},
 			DownloadOutput{
@@ -69,9 +69,9 @@ var (
 		{
 			// validate md5
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5": "e84913ff3a8eef39238b32170e657ba8",
 				}},
 			DownloadOutput{
@@ -83,9 +83,9 @@ var (
 		{
 			// validate incorrect md5 fails
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5": "222222222",
 				}},
 			DownloadOutput{
@@ -96,9 +96,9 @@ var (
 		{
 			// ensure default is sha256
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -109,9 +109,9 @@ var (
 		{
 			// relative url is not supported
 			DownloadInput{
-				"IamRelativeFilePath",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "IamRelativeFilePath",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -122,9 +122,9 @@ var (
 		{
 			// relative url is not supported
 			DownloadInput{
-				"IamRelativeFilePath/IdontExist",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "IamRelativeFilePath/IdontExist",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				}},
 			DownloadOutput{
@@ -135,9 +135,9 @@ var (
 		{
 			// s3 download error
 			DownloadInput{
-				"https://s3.amazonaws.com/ssmnotsuchbucket/ssmnosuchfile.txt",
-				downloadFolder,
-				map[string]string{
+				SourceURL:            "https://s3.amazonaws.com/ssmnotsuchbucket/ssmnosuchfile.txt",
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"": "",
 				}},
 			DownloadOutput{
@@ -148,9 +148,9 @@ var (
 		{
 			// ensure empty map is valid
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{},
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums:      map[string]string{},
 			},
 			DownloadOutput{
 				localPathExist,
@@ -160,9 +160,9 @@ var (
 		{
 			// ensure empty value is valid; this is important for the agent updater itself
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{"sha256": ""},
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums:      map[string]string{"sha256": ""},
 			},
 			DownloadOutput{
 				localPathExist,
@@ -172,9 +172,9 @@ var (
 		{
 			// first checksum fails, the second one succeeds
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"md5":    "111111111",
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
@@ -187,9 +187,9 @@ var (
 		{
 			// none of the provided algorithms are supported
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"sha512": "111111111",
 					"sha1":   "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
@@ -202,9 +202,9 @@ var (
 		{
 			// one supported algorithm and one not supported
 			DownloadInput{
-				localPathExist,
-				downloadFolder,
-				map[string]string{
+				SourceURL:            localPathExist,
+				DestinationDirectory: downloadFolder,
+				SourceChecksums: map[string]string{
 					"foo":    "123456789",
 					"sha256": "090c1965e46155b2b23ba9093ed7c67243957a397e3ad5531a693d57958a760a",
 				},
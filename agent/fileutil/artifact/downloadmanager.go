@@ -0,0 +1,122 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+var (
+	manager     *downloadManager
+	managerOnce sync.Once
+
+	// timeNow and sleep are replaced in tests
+	timeNow = time.Now
+	sleep   = time.Sleep
+)
+
+// downloadManager limits the number of concurrent downloads and their combined bandwidth, it is shared
+// by all the downloads of the agent process
+type downloadManager struct {
+	// slots holds a token per running download, nil doesn't limit the number of downloads
+	slots chan struct{}
+	// limiter throttles the bytes read by the downloads, nil doesn't limit the bandwidth
+	limiter *bandwidthLimiter
+}
+
+// getDownloadManager returns the download manager configured with the download limits of the agent config
+func getDownloadManager() *downloadManager {
+	managerOnce.Do(func() {
+		config, err := appconfig.Config(false)
+		if err != nil {
+			config = appconfig.DefaultConfig()
+		}
+		manager = newDownloadManager(config.Download.MaxConcurrentDownloads, config.Download.MaxBytesPerSecond)
+	})
+	return manager
+}
+
+// newDownloadManager creates a download manager, a limit of 0 isn't enforced
+func newDownloadManager(maxConcurrentDownloads int, maxBytesPerSecond int) *downloadManager {
+	m := &downloadManager{}
+	if maxConcurrentDownloads > 0 {
+		m.slots = make(chan struct{}, maxConcurrentDownloads)
+	}
+	if maxBytesPerSecond > 0 {
+		m.limiter = &bandwidthLimiter{bytesPerSecond: maxBytesPerSecond}
+	}
+	return m
+}
+
+// acquire blocks until the download may start and returns the func releasing its slot
+func (m *downloadManager) acquire() (release func()) {
+	if m.slots == nil {
+		return func() {}
+	}
+	m.slots <- struct{}{}
+	return func() { <-m.slots }
+}
+
+// throttle wraps the reader of a download so the downloads don't exceed the bandwidth limit
+func (m *downloadManager) throttle(reader io.Reader) io.Reader {
+	if m.limiter == nil {
+		return reader
+	}
+	return &throttledReader{reader: reader, limiter: m.limiter}
+}
+
+// bandwidthLimiter spaces out reads so that the bytes read over time don't exceed bytesPerSecond
+type bandwidthLimiter struct {
+	bytesPerSecond int
+
+	mu sync.Mutex
+	// next is when the bytes reserved so far have been read at the limited rate
+	next time.Time
+}
+
+// wait reserves the time of reading n bytes at the limited rate and blocks until the reservation ends
+func (l *bandwidthLimiter) wait(n int) {
+	l.mu.Lock()
+	now := timeNow()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(n) * time.Second / time.Duration(l.bytesPerSecond))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+
+	sleep(delay)
+}
+
+// throttledReader reads from reader at the rate of limiter
+type throttledReader struct {
+	reader  io.Reader
+	limiter *bandwidthLimiter
+}
+
+// Read reads at most a second worth of bytes and waits for the limiter
+func (r *throttledReader) Read(p []byte) (n int, err error) {
+	if len(p) > r.limiter.bytesPerSecond {
+		p = p[:r.limiter.bytesPerSecond]
+	}
+	n, err = r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadManagerWithoutLimits(t *testing.T) {
+	m := newDownloadManager(0, 0)
+	reader := bytes.NewReader([]byte("content"))
+
+	release := m.acquire()
+	release()
+	assert.Equal(t, reader, m.throttle(reader))
+}
+
+func TestDownloadManagerLimitsConcurrentDownloads(t *testing.T) {
+	m := newDownloadManager(2, 0)
+	first := m.acquire()
+	m.acquire()
+
+	acquired := make(chan bool)
+	go func() {
+		m.acquire()
+		acquired <- true
+	}()
+
+	select {
+	case <-acquired:
+		assert.Fail(t, "third download started while two downloads were running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		assert.Fail(t, "download didn't start after a slot was released")
+	}
+}
+
+func TestDownloadManagerLimitsBandwidth(t *testing.T) {
+	defer func() {
+		timeNow = time.Now
+		sleep = time.Sleep
+	}()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	timeNow = func() time.Time { return now }
+	sleep = func(d time.Duration) { slept += d }
+
+	m := newDownloadManager(0, 100)
+	content, err := ioutil.ReadAll(m.throttle(bytes.NewReader(make([]byte, 250))))
+
+	assert.NoError(t, err)
+	assert.Len(t, content, 250)
+	// reads of 100, 100 and 50 bytes end 1s, 2s and 2.5s after the download started
+	assert.Equal(t, 5500*time.Millisecond, slept)
+}
+
+func TestBandwidthLimiterIsSharedByDownloads(t *testing.T) {
+	defer func() {
+		timeNow = time.Now
+		sleep = time.Sleep
+	}()
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var delays []time.Duration
+	timeNow = func() time.Time { return now }
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	m := newDownloadManager(0, 1000)
+	m.limiter.wait(500)
+	m.limiter.wait(500)
+	// once the reserved time has passed the next read isn't delayed by earlier reads
+	now = now.Add(2 * time.Second)
+	m.limiter.wait(1000)
+
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, time.Second}, delays)
+}
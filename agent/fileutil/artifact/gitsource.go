@@ -0,0 +1,88 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package artifact contains utilities for working downloading files.
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// gitSchemePrefix marks urls to clone with git, e.g. git+https://github.com/owner/repo.git#v1.0
+	gitSchemePrefix = "git+"
+)
+
+// Assign method to global variables to allow unittest to override
+var runGitCommand = execGitCommand
+
+// gitSource downloads git repositories with a shallow clone.
+// The url fragment selects the branch or tag to check out, the default branch is used without it.
+type gitSource struct{}
+
+// Name returns the name of the source
+func (gitSource) Name() string {
+	return "git"
+}
+
+// CanDownload returns true for urls with a git+ scheme
+func (gitSource) CanDownload(log log.T, sourceURL *url.URL) bool {
+	return strings.HasPrefix(strings.ToLower(sourceURL.Scheme), gitSchemePrefix)
+}
+
+// Download clones the repository into the destFile directory
+func (gitSource) Download(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (output DownloadOutput, err error) {
+	if len(input.SourceChecksums) > 0 {
+		return output, errors.New("checksums are not supported for git repositories")
+	}
+
+	repositoryURL := *sourceURL
+	repositoryURL.Scheme = strings.TrimPrefix(strings.ToLower(sourceURL.Scheme), gitSchemePrefix)
+	repositoryURL.Fragment = ""
+
+	// clone again instead of updating an existing clone which might have been modified
+	if err = fileutil.DeleteDirectory(destFile); err != nil {
+		return output, fmt.Errorf("failed to remove previous clone %v, %v", destFile, err)
+	}
+
+	args := []string{"clone", "--depth", "1"}
+	if sourceURL.Fragment != "" {
+		args = append(args, "--branch", sourceURL.Fragment)
+	}
+	args = append(args, "--", repositoryURL.String(), destFile)
+
+	log.Debugf("attempting to download as git clone %v", destFile)
+	if err = runGitCommand(log, args...); err != nil {
+		fileutil.DeleteDirectory(destFile)
+		return output, err
+	}
+
+	output.LocalFilePath = destFile
+	output.IsUpdated = true
+	return output, nil
+}
+
+// execGitCommand runs git with the given arguments
+func execGitCommand(log log.T, args ...string) error {
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git %v failed, %v: %v", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
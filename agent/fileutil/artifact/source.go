@@ -0,0 +1,127 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package artifact contains utilities for working downloading files.
+package artifact
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/s3util"
+)
+
+// DownloadSource downloads artifacts from one kind of location
+type DownloadSource interface {
+	// Name identifies the source in logs
+	Name() string
+	// CanDownload returns true if the source handles the url
+	CanDownload(log log.T, sourceURL *url.URL) bool
+	// Download downloads the url to destFile
+	Download(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (DownloadOutput, error)
+}
+
+// downloadSources are tried in order, the first source that handles the url and succeeds is used
+var downloadSources = []DownloadSource{
+	gitSource{},
+	s3Source{},
+	httpSource{},
+}
+
+// downloadFromSources downloads the url with the first source that handles it successfully
+func downloadFromSources(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (output DownloadOutput, err error) {
+	err = fmt.Errorf("no download source supports url %v", input.SourceURL)
+	for _, source := range downloadSources {
+		if !source.CanDownload(log, sourceURL) {
+			continue
+		}
+		if output, err = source.Download(log, input, sourceURL, destFile); err == nil {
+			return output, nil
+		}
+		log.Debugf("%v download of %v failed, %v", source.Name(), input.SourceURL, err)
+	}
+	return output, err
+}
+
+// s3Source downloads objects with the S3 API
+type s3Source struct{}
+
+// Name returns the name of the source
+func (s3Source) Name() string {
+	return "s3"
+}
+
+// CanDownload returns true for urls referencing an S3 bucket and key
+func (s3Source) CanDownload(log log.T, sourceURL *url.URL) bool {
+	return s3util.ParseAmazonS3URL(log, sourceURL).IsBucketAndKeyPresent()
+}
+
+// Download downloads the S3 object
+func (s3Source) Download(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (DownloadOutput, error) {
+	return s3Download(log, input, s3util.ParseAmazonS3URL(log, sourceURL), destFile)
+}
+
+// httpSource downloads files with http/https requests
+type httpSource struct{}
+
+// Name returns the name of the source
+func (httpSource) Name() string {
+	return "http"
+}
+
+// CanDownload returns true for http and https urls
+func (httpSource) CanDownload(log log.T, sourceURL *url.URL) bool {
+	scheme := strings.ToLower(sourceURL.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// Download downloads the file, trusting the certificate authorities of the CA bundle if one is given
+func (httpSource) Download(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (DownloadOutput, error) {
+	transport, err := newHTTPTransport(input.CABundlePath)
+	if err != nil {
+		return DownloadOutput{}, err
+	}
+	return httpDownload(log, input.SourceURL, destFile, transport)
+}
+
+// newHTTPTransport returns a transport trusting the certificates of the CA bundle in addition to the system ones,
+// or nil to use the default transport if no bundle is given
+func newHTTPTransport(caBundlePath string) (http.RoundTripper, error) {
+	if caBundlePath == "" {
+		return nil, nil
+	}
+
+	caBundle, err := ioutil.ReadFile(caBundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %v, %v", caBundlePath, err)
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %v", caBundlePath)
+	}
+
+	return &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: rootCAs},
+	}, nil
+}
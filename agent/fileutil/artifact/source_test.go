@@ -0,0 +1,185 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/s3util"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+var logger = log.NewMockLog()
+
+// fakeSource is a DownloadSource recording its calls
+type fakeSource struct {
+	name   string
+	handle bool
+	err    error
+	calls  *[]string
+}
+
+func (f fakeSource) Name() string { return f.name }
+
+func (f fakeSource) CanDownload(log log.T, sourceURL *url.URL) bool { return f.handle }
+
+func (f fakeSource) Download(log log.T, input DownloadInput, sourceURL *url.URL, destFile string) (DownloadOutput, error) {
+	*f.calls = append(*f.calls, f.name)
+	return DownloadOutput{LocalFilePath: destFile, IsUpdated: true}, f.err
+}
+
+func TestDownloadFromSourcesFallsBack(t *testing.T) {
+	defer func(sources []DownloadSource) { downloadSources = sources }(downloadSources)
+	var calls []string
+	downloadSources = []DownloadSource{
+		fakeSource{name: "unsupported", handle: false, calls: &calls},
+		fakeSource{name: "failing", handle: true, err: errors.New("failed"), calls: &calls},
+		fakeSource{name: "working", handle: true, calls: &calls},
+	}
+
+	sourceURL, _ := url.Parse("https://example.com/file")
+	output, err := downloadFromSources(logger, DownloadInput{SourceURL: sourceURL.String()}, sourceURL, "dest")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dest", output.LocalFilePath)
+	assert.Equal(t, []string{"failing", "working"}, calls)
+}
+
+func TestDownloadFromSourcesWithoutSource(t *testing.T) {
+	defer func(sources []DownloadSource) { downloadSources = sources }(downloadSources)
+	downloadSources = []DownloadSource{}
+
+	sourceURL, _ := url.Parse("ftp://example.com/file")
+	_, err := downloadFromSources(logger, DownloadInput{SourceURL: sourceURL.String()}, sourceURL, "dest")
+
+	assert.Error(t, err)
+}
+
+func TestSourcesCanDownload(t *testing.T) {
+	s3URL, _ := url.Parse("https://s3.amazonaws.com/bucket/key")
+	httpsURL, _ := url.Parse("https://example.com/file")
+	gitURL, _ := url.Parse("git+https://github.com/aws/amazon-ssm-agent.git#mainline")
+
+	assert.True(t, s3Source{}.CanDownload(logger, s3URL))
+	assert.False(t, s3Source{}.CanDownload(logger, gitURL))
+	assert.True(t, httpSource{}.CanDownload(logger, httpsURL))
+	assert.False(t, httpSource{}.CanDownload(logger, gitURL))
+	assert.True(t, gitSource{}.CanDownload(logger, gitURL))
+	assert.False(t, gitSource{}.CanDownload(logger, httpsURL))
+}
+
+func TestHTTPSourceWithCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "content")
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	caBundlePath := filepath.Join(dir, "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	ioutil.WriteFile(caBundlePath, caBundle, 0600)
+	sourceURL, _ := url.Parse(server.URL)
+	destFile := filepath.Join(dir, "file")
+
+	// the test server certificate isn't trusted without the bundle
+	_, err := httpSource{}.Download(logger, DownloadInput{SourceURL: server.URL}, sourceURL, destFile)
+	assert.Error(t, err)
+
+	output, err := httpSource{}.Download(logger, DownloadInput{SourceURL: server.URL, CABundlePath: caBundlePath}, sourceURL, destFile)
+	assert.NoError(t, err)
+	assert.True(t, output.IsUpdated)
+	content, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, "content", string(content))
+}
+
+func TestNewHTTPTransportWithInvalidCABundle(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "artifact")
+	defer os.RemoveAll(dir)
+	caBundlePath := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(caBundlePath, []byte("not a certificate"), 0600)
+
+	_, err := newHTTPTransport(caBundlePath)
+	assert.Error(t, err)
+
+	_, err = newHTTPTransport(filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestNewGetObjectInputRequesterPays(t *testing.T) {
+	sourceURL, _ := url.Parse("https://s3.amazonaws.com/bucket/key")
+	amazonS3URL := s3util.ParseAmazonS3URL(logger, sourceURL)
+
+	params := newGetObjectInput(DownloadInput{}, amazonS3URL)
+	assert.Nil(t, params.RequestPayer)
+
+	params = newGetObjectInput(DownloadInput{S3RequesterPays: true}, amazonS3URL)
+	assert.Equal(t, s3.RequestPayerRequester, aws.StringValue(params.RequestPayer))
+}
+
+func TestVerifyKMSEncryption(t *testing.T) {
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/1234abcd"
+	encrypted := &s3.GetObjectOutput{
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAwsKms),
+		SSEKMSKeyId:          aws.String(keyARN),
+	}
+
+	assert.NoError(t, verifyKMSEncryption(DownloadInput{}, &s3.GetObjectOutput{}))
+	assert.NoError(t, verifyKMSEncryption(DownloadInput{S3KMSKeyID: keyARN}, encrypted))
+	assert.NoError(t, verifyKMSEncryption(DownloadInput{S3KMSKeyID: "1234abcd"}, encrypted))
+	assert.Error(t, verifyKMSEncryption(DownloadInput{S3KMSKeyID: "otherkey"}, encrypted))
+	assert.Error(t, verifyKMSEncryption(DownloadInput{S3KMSKeyID: "1234abcd"}, &s3.GetObjectOutput{}))
+}
+
+func TestGitSourceDownload(t *testing.T) {
+	defer func() { runGitCommand = execGitCommand }()
+	var gitArgs []string
+	runGitCommand = func(log log.T, args ...string) error {
+		gitArgs = args
+		return nil
+	}
+
+	sourceURL, _ := url.Parse("git+https://github.com/aws/amazon-ssm-agent.git#v2.3.0")
+	output, err := gitSource{}.Download(logger, DownloadInput{}, sourceURL, "dest")
+
+	assert.NoError(t, err)
+	assert.Equal(t, DownloadOutput{LocalFilePath: "dest", IsUpdated: true}, output)
+	assert.Equal(t, []string{"clone", "--depth", "1", "--branch", "v2.3.0", "--", "https://github.com/aws/amazon-ssm-agent.git", "dest"}, gitArgs)
+}
+
+func TestGitSourceDownloadFailures(t *testing.T) {
+	defer func() { runGitCommand = execGitCommand }()
+	runGitCommand = func(log log.T, args ...string) error {
+		return errors.New("git clone failed")
+	}
+	sourceURL, _ := url.Parse("git+ssh://git@github.com/aws/amazon-ssm-agent.git")
+
+	_, err := gitSource{}.Download(logger, DownloadInput{}, sourceURL, "dest")
+	assert.Error(t, err)
+
+	_, err = gitSource{}.Download(logger, DownloadInput{SourceChecksums: map[string]string{"sha256": "abc"}}, sourceURL, "dest")
+	assert.Error(t, err)
+}
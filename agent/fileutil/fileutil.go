@@ -124,7 +124,7 @@ func BuildPath(root string, elements ...string) string {
 	for _, element := range elements {
 		fullPath = filepath.Join(fullPath, removeInvalidColon(element))
 	}
-	return fullPath
+	return LongPath(fullPath)
 }
 
 // BuildS3Path joins the root directory path with valid components.
@@ -144,10 +144,35 @@ func removeInvalidColon(pluginName string) string {
 	return pluginName
 }
 
+// ValidateUNCPath returns an error if path is a UNC path, such as \\server\share\folder, missing its server
+// or share name. Other paths are valid.
+func ValidateUNCPath(path string) error {
+	uncPath := path
+	if strings.HasPrefix(uncPath, `\\?\UNC\`) {
+		uncPath = `\\` + uncPath[len(`\\?\UNC\`):]
+	} else if !strings.HasPrefix(uncPath, `\\`) || strings.HasPrefix(uncPath, `\\?\`) || strings.HasPrefix(uncPath, `\\.\`) {
+		return nil
+	}
+
+	components := strings.FieldsFunc(uncPath[2:], func(r rune) bool { return r == '\\' || r == '/' })
+	if len(components) < 2 {
+		return fmt.Errorf("invalid UNC path %v, the server and share names are required", path)
+	}
+	for _, name := range components[:2] {
+		if strings.ContainsAny(name, `<>:"|?*`) {
+			return fmt.Errorf("invalid UNC path %v, %v contains invalid characters", path, name)
+		}
+	}
+	return nil
+}
+
 // MakeDirs create the directories along the path if missing.
 func MakeDirs(destinationDir string) (err error) {
+	if err = ValidateUNCPath(destinationDir); err != nil {
+		return
+	}
 	// create directory
-	err = fs.MkdirAll(destinationDir, appconfig.ReadWriteAccess)
+	err = fs.MkdirAll(LongPath(destinationDir), appconfig.ReadWriteAccess)
 	if err != nil {
 		err = fmt.Errorf("failed to create directory %v. %v", destinationDir, err)
 	}
@@ -156,8 +181,11 @@ func MakeDirs(destinationDir string) (err error) {
 
 // MakeDirsWithExecuteAccess create the directories along the path if missing.
 func MakeDirsWithExecuteAccess(destinationDir string) (err error) {
+	if err = ValidateUNCPath(destinationDir); err != nil {
+		return
+	}
 	// create directory
-	if err = fs.MkdirAll(destinationDir, appconfig.ReadWriteExecuteAccess); err != nil {
+	if err = fs.MkdirAll(LongPath(destinationDir), appconfig.ReadWriteExecuteAccess); err != nil {
 		err = fmt.Errorf("failed to create directory %v. %v", destinationDir, err)
 	}
 	return
@@ -236,6 +264,56 @@ func WriteIntoFileWithPermissionsExtended(absolutePath, content string, perm os.
 	return
 }
 
+// WriteFileAtomic writes data to a temporary file next to filename, flushes it to disk and renames it
+// over filename, so a crash or power loss leaves either the previous or the new content behind.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(filename, data, perm, nil)
+}
+
+// writeFileAtomic implements WriteFileAtomic, calling prepare on the temporary file before it is renamed
+func writeFileAtomic(filename string, data []byte, perm os.FileMode, prepare func(tempPath string) error) (err error) {
+	dir, name := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	tempFile, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return fmt.Errorf("couldn't create temporary file for %v - %v", filename, err)
+	}
+	tempPath := tempFile.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+
+	if _, err = tempFile.Write(data); err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write into file - %v", err)
+	}
+
+	if err = os.Chmod(tempPath, perm); err != nil {
+		return
+	}
+	if prepare != nil {
+		if err = prepare(tempPath); err != nil {
+			return
+		}
+	}
+
+	if err = os.Rename(tempPath, filename); err != nil {
+		return fmt.Errorf("couldn't replace %v - %v", filename, err)
+	}
+	syncDirectory(dir)
+	return nil
+}
+
 // GetFileModificationTime returns the modification time of the file
 func GetFileModificationTime(srcPath string) (modificationTime time.Time, err error) {
 
@@ -313,7 +391,11 @@ func isUnderDir(childPath, parentDirPath string) bool {
 // Unzip unzips the installation package (using platform agnostic zip functionality)
 // For platform specific implementation that uses tar.gz on Linux, use Uncompress
 func Unzip(src, dest string) error {
-	r, err := zip.OpenReader(src)
+	if err := ValidateUNCPath(dest); err != nil {
+		return err
+	}
+
+	r, err := zip.OpenReader(LongPath(src))
 	if err != nil {
 		return err
 	}
@@ -323,7 +405,7 @@ func Unzip(src, dest string) error {
 		}
 	}()
 
-	os.MkdirAll(dest, appconfig.ReadWriteExecuteAccess)
+	os.MkdirAll(LongPath(dest), appconfig.ReadWriteExecuteAccess)
 	// Closure to address file descriptors issue with all the deferred .Close() methods
 	extractAndWriteFile := func(f *zip.File) error {
 		rc, err := f.Open()
@@ -341,6 +423,8 @@ func Unzip(src, dest string) error {
 		if !isUnderDir(path, dest) {
 			return fmt.Errorf("%v attepts to place files outside %v subtree", f.Name, dest)
 		}
+		// entries of deep archives may exceed the path length limit of Windows
+		path = LongPath(path)
 		if f.FileInfo().IsDir() {
 			os.MkdirAll(path, f.Mode())
 		} else {
@@ -14,7 +14,9 @@
 package fileutil
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -161,6 +163,42 @@ func TestMakeDirs(t *testing.T) {
 	fs = osFS{}
 }
 
+func TestValidateUNCPath(t *testing.T) {
+	validPaths := []string{
+		`C:\ProgramData\Amazon\SSM`,
+		`/var/lib/amazon/ssm`,
+		`\\server\share`,
+		`\\server\share\orchestration\folder`,
+		`\\?\UNC\server\share\folder`,
+		`\\?\C:\ProgramData\Amazon\SSM`,
+		`\\.\pipe\name`,
+	}
+	for _, path := range validPaths {
+		assert.NoError(t, ValidateUNCPath(path), path)
+	}
+
+	invalidPaths := []string{
+		`\\`,
+		`\\server`,
+		`\\server\`,
+		`\\?\UNC\server`,
+		`\\server:1\share`,
+		`\\server\share*\folder`,
+	}
+	for _, path := range invalidPaths {
+		assert.Error(t, ValidateUNCPath(path), path)
+	}
+}
+
+func TestMakeDirsInvalidUNCPath(t *testing.T) {
+	fs = osFSStub{}
+	err := MakeDirs(`\\server`)
+	assert.Error(t, err, "expected invalid UNC path error")
+
+	// reset
+	fs = osFS{}
+}
+
 func TestDeleteFile(t *testing.T) {
 	file := "samplefile"
 
@@ -261,6 +299,52 @@ func (a ioUtilStub) ReadFile(filename string) ([]byte, error) {
 	return a.b, a.err
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileutil")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+
+	// new file
+	err = WriteFileAtomic(file, []byte(`{"version":1}`), 0600)
+	assert.NoError(t, err)
+	content, _ := ioutil.ReadFile(file)
+	assert.Equal(t, `{"version":1}`, string(content))
+
+	// existing file is replaced
+	err = WriteFileAtomic(file, []byte(`{"version":2}`), 0644)
+	assert.NoError(t, err)
+	content, _ = ioutil.ReadFile(file)
+	assert.Equal(t, `{"version":2}`, string(content))
+
+	// no temporary files are left behind
+	files, _ := GetFileNames(dir)
+	assert.Equal(t, []string{"state.json"}, files)
+}
+
+func TestWriteFileAtomicKeepsPreviousContentOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileutil")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+	assert.NoError(t, WriteFileAtomic(file, []byte("previous"), 0600))
+
+	err = writeFileAtomic(file, []byte("next"), 0600, func(tempPath string) error {
+		return errors.New("failed to harden")
+	})
+	assert.Error(t, err)
+
+	content, _ := ioutil.ReadFile(file)
+	assert.Equal(t, "previous", string(content))
+	files, _ := GetFileNames(dir)
+	assert.Equal(t, []string{"state.json"}, files)
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	err := WriteFileAtomic(filepath.Join("testdata", "missing", "state.json"), []byte("content"), 0600)
+	assert.Error(t, err)
+}
+
 func TestAppendToFile(t *testing.T) {
 	// Valid file
 	var file = "testdata/file.txt"
@@ -28,6 +28,11 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
+// LongPath returns path unchanged, paths aren't limited to MAX_PATH outside of Windows.
+func LongPath(path string) string {
+	return path
+}
+
 // Uncompress untar the installation package
 func Uncompress(log log.T, src, dest string) error {
 	file, err := os.Open(src)
@@ -83,7 +88,6 @@ func Uncompress(log log.T, src, dest string) error {
 
 // GetDiskSpaceInfo returns DiskSpaceInfo with available, free, and total bytes from system disk space
 func GetDiskSpaceInfo() (diskSpaceInfo DiskSpaceInfo, err error) {
-	var stat syscall.Statfs_t
 	var wd string
 
 	// get a rooted path name
@@ -91,8 +95,17 @@ func GetDiskSpaceInfo() (diskSpaceInfo DiskSpaceInfo, err error) {
 		return
 	}
 
+	return GetDiskSpaceInfoOfPath(wd)
+}
+
+// GetDiskSpaceInfoOfPath returns DiskSpaceInfo with available, free, and total bytes of the disk path is on
+func GetDiskSpaceInfoOfPath(path string) (diskSpaceInfo DiskSpaceInfo, err error) {
+	var stat syscall.Statfs_t
+
 	// get filesystem statistics
-	syscall.Statfs(wd, &stat)
+	if err = syscall.Statfs(path, &stat); err != nil {
+		return
+	}
 
 	// get block size
 	bSize := uint64(stat.Bsize)
@@ -111,3 +124,12 @@ func GetDiskSpaceInfo() (diskSpaceInfo DiskSpaceInfo, err error) {
 func HardenDataFolder() error {
 	return nil // do nothing
 }
+
+// syncDirectory flushes the directory entry of a renamed file to disk. Not every file system supports
+// syncing a directory, so this is best effort.
+func syncDirectory(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -18,6 +18,8 @@ package fileutil
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -25,6 +27,34 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
+const (
+	// maxDirPathLength is the longest directory path the Windows APIs accept without the long path prefix,
+	// MAX_PATH minus the room for an 8.3 file name
+	maxDirPathLength = 248
+
+	longPathPrefix    = `\\?\`
+	longUNCPathPrefix = `\\?\UNC\`
+	devicePathPrefix  = `\\.\`
+)
+
+// LongPath returns the \\?\ prefixed form of an absolute path too long for the Windows APIs, so deep
+// orchestration directories and folders on network shares can be created. Other paths are returned unchanged.
+func LongPath(path string) string {
+	if len(path) < maxDirPathLength ||
+		strings.HasPrefix(path, longPathPrefix) ||
+		strings.HasPrefix(path, devicePathPrefix) ||
+		!filepath.IsAbs(path) {
+		return path
+	}
+
+	// the prefix turns off the path normalization, separators and relative elements are resolved first
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		return longUNCPathPrefix + path[2:]
+	}
+	return longPathPrefix + path
+}
+
 // Uncompress unzips the installation package
 func Uncompress(log log.T, src, dest string) error {
 	return Unzip(src, dest)
@@ -33,19 +63,25 @@ func Uncompress(log log.T, src, dest string) error {
 // GetDiskSpaceInfo returns available, free, and total bytes respectively from system disk space
 func GetDiskSpaceInfo() (diskSpaceInfo DiskSpaceInfo, err error) {
 	var wd string
-	var availBytes, totalBytes, freeBytes int64
 
 	// Get a rooted path name
 	if wd, err = os.Getwd(); err != nil {
 		return
 	}
 
+	return GetDiskSpaceInfoOfPath(wd)
+}
+
+// GetDiskSpaceInfoOfPath returns available, free, and total bytes respectively of the disk path is on
+func GetDiskSpaceInfoOfPath(path string) (diskSpaceInfo DiskSpaceInfo, err error) {
+	var availBytes, totalBytes, freeBytes int64
+
 	// Load kernel32.dll and find GetDiskFreeSpaceEX function
 	getDiskFreeSpace := syscall.MustLoadDLL("kernel32.dll").MustFindProc("GetDiskFreeSpaceExW")
 
 	// Get the available bytes (for arguments, GetDiskFreeSpace function takes dir name, avail, total, and free respectively)
 	_, _, err = getDiskFreeSpace.Call(
-		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(wd))),
+		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(path))),
 		uintptr(unsafe.Pointer(&availBytes)),
 		uintptr(unsafe.Pointer(&totalBytes)),
 		uintptr(unsafe.Pointer(&freeBytes)))
@@ -62,3 +98,7 @@ func GetDiskSpaceInfo() (diskSpaceInfo DiskSpaceInfo, err error) {
 func HardenDataFolder() error {
 	return Harden(appconfig.SSMDataPath)
 }
+
+// syncDirectory is a no-op on Windows, where directories can't be opened for syncing and
+// the rename is already persisted by the file system journal.
+func syncDirectory(dir string) {}
@@ -0,0 +1,48 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package fileutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPath(t *testing.T) {
+	deepFolder := strings.Repeat(`folder\`, 40)
+
+	// short and relative paths are unchanged
+	assert.Equal(t, `C:\ProgramData\Amazon\SSM`, LongPath(`C:\ProgramData\Amazon\SSM`))
+	assert.Equal(t, deepFolder, LongPath(deepFolder))
+
+	// long paths are prefixed
+	assert.Equal(t, `\\?\C:\`+strings.TrimSuffix(deepFolder, `\`), LongPath(`C:\`+deepFolder))
+	assert.Equal(t, `\\?\UNC\server\share\`+strings.TrimSuffix(deepFolder, `\`), LongPath(`\\server\share\`+deepFolder))
+
+	// separators and relative elements are resolved before prefixing
+	assert.Equal(t, `\\?\C:\`+strings.TrimSuffix(deepFolder, `\`), LongPath(`C:/other/../`+strings.Replace(deepFolder, `\`, `/`, -1)))
+
+	// prefixed paths are unchanged
+	prefixed := `\\?\C:\` + deepFolder
+	assert.Equal(t, prefixed, LongPath(prefixed))
+}
+
+func TestBuildPathLongPath(t *testing.T) {
+	root := `C:\ProgramData\Amazon\SSM\InstanceData\i-1234567890abcdef0\document\orchestration`
+	element := strings.Repeat("a", 200)
+	assert.Equal(t, `\\?\`+root+`\`+element, BuildPath(root, element))
+}
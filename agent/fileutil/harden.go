@@ -1,8 +1,6 @@
 package fileutil
 
 import (
-	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 )
@@ -11,32 +9,11 @@ const (
 	RWPermission = 0600
 )
 
-// HardenedWriteFile calls ioutil.WriteFile and guarantees a hardened permission
-// control. If the file already exists, it hardens the permissions before
-// writing data to it.
+// HardenedWriteFile atomically replaces the content of filename and guarantees a hardened
+// permission control. The data is written to a hardened temporary file which is then renamed
+// over filename, so an interrupted write never leaves a partially written file behind.
 func HardenedWriteFile(filename string, data []byte) (err error) {
-
-	if _, err = os.Stat(filename); err != nil {
-		if os.IsNotExist(err) {
-			f, err := os.Create(filename)
-			if err != nil {
-				return fmt.Errorf("Failed to create the file, %s", err)
-			}
-			defer f.Close()
-		} else {
-			return
-		}
-	}
-
-	if err = Harden(filename); err != nil {
-		return
-	}
-
-	if err = ioutil.WriteFile(filename, data, RWPermission); err != nil {
-		return
-	}
-
-	return
+	return writeFileAtomic(filename, data, RWPermission, Harden)
 }
 
 // RecursivelyHarden the files and directory under the specified path.
@@ -18,6 +18,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/health"
+	"github.com/aws/amazon-ssm-agent/agent/localipc"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
 	"github.com/aws/amazon-ssm-agent/agent/runcommand"
 	"github.com/aws/amazon-ssm-agent/agent/session"
@@ -54,6 +55,11 @@ func loadCoreModules(context context.T) {
 		context.Log().Errorf("Failed to start offline command document processor")
 	}
 
+	// the local endpoint submits documents to the offline command processor
+	if context.AppConfig().LocalIpc.Enabled {
+		registeredCoreModules = append(registeredCoreModules, localipc.NewLocalIpc(context))
+	}
+
 	registeredCoreModules = append(registeredCoreModules, startup.NewProcessor(context))
 
 	// registering the long running plugin manager as a core module
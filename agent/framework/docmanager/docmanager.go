@@ -97,7 +97,7 @@ func (d *DocumentFileMgr) PersistDocumentState(log log.T, fileName, instanceID,
 			log.Debugf("overwriting contents of %v", absoluteFileName)
 		}
 		log.Tracef("persisting interim state %v in file %v", jsonutil.Indent(content), absoluteFileName)
-		if s, err := fileutil.WriteIntoFileWithPermissions(absoluteFileName, jsonutil.Indent(content), os.FileMode(int(appconfig.ReadWriteAccess))); s && err == nil {
+		if err := fileutil.WriteFileAtomic(absoluteFileName, []byte(jsonutil.Indent(content)), os.FileMode(int(appconfig.ReadWriteAccess))); err == nil {
 			log.Debugf("successfully persisted interim state in %v", locationFolder)
 		} else {
 			log.Debugf("persisting interim state in %v failed with error %v", locationFolder, err)
@@ -18,12 +18,16 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/outputfilter"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
@@ -34,6 +38,8 @@ const (
 	truncateOut = "\n---Output truncated---"
 	// truncateError represents the string appended when error is truncated
 	truncateError = "\n---Error truncated----"
+	// outputErrorTitle separates the output from the error
+	outputErrorTitle = "\n----------ERROR-------\n"
 )
 
 // PluginConfig is used for initializing plugins with default values
@@ -86,12 +92,14 @@ type IOHandler interface {
 	GetStdoutWriter() multiwriter.DocumentIOMultiWriter
 	GetStderrWriter() multiwriter.DocumentIOMultiWriter
 	GetIOConfig() contracts.IOConfiguration
+	GetStructuredOutput() interface{}
 
 	SetStatus(contracts.ResultStatus)
 	SetExitCode(int)
 	SetOutput(interface{})
 	SetStdout(string)
 	SetStderr(string)
+	SetStructuredOutput(interface{})
 }
 
 // DefaultIOHandler is used for writing output by the plugins
@@ -104,6 +112,8 @@ type DefaultIOHandler struct {
 	ioConfig contracts.IOConfiguration
 	//refreshassociation and invoker write a different output rather than merging stdout and stderr
 	output interface{}
+	//structured outputs written by step scripts, one per merged output
+	structuredOutputs []interface{}
 
 	// List of Writers attached to the IOHandler instance
 	StdoutWriter multiwriter.DocumentIOMultiWriter
@@ -133,7 +143,11 @@ func (out *DefaultIOHandler) Init(log log.T, filePath ...string) {
 
 	stdOutLogStreamName := ""
 	stdErrLogStreamName := ""
+	cloudWatchFlushInterval := time.Duration(appconfig.DefaultCloudWatchOutputFlushIntervalSeconds) * time.Second
 	if out.ioConfig.CloudWatchConfig.LogGroupName != "" {
+		if config, err := appconfig.Config(false); err == nil {
+			cloudWatchFlushInterval = time.Duration(config.Ssm.CloudWatchOutputFlushIntervalSeconds) * time.Second
+		}
 		cwl := cloudwatchlogspublisher.NewCloudWatchLogsService()
 		if !cwl.IsLogGroupPresent(log, out.ioConfig.CloudWatchConfig.LogGroupName) {
 			if err := cwl.CreateLogGroup(log, out.ioConfig.CloudWatchConfig.LogGroupName); err != nil {
@@ -148,20 +162,28 @@ func (out *DefaultIOHandler) Init(log log.T, filePath ...string) {
 
 	// Initialize file output module
 	stdoutFile := iomodule.File{
-		FileName:               pluginConfig.StdoutFileName,
-		OrchestrationDirectory: fullPath,
-		OutputS3BucketName:     out.ioConfig.OutputS3BucketName,
-		OutputS3KeyPrefix:      s3KeyPrefix,
-		LogGroupName:           out.ioConfig.CloudWatchConfig.LogGroupName,
-		LogStreamName:          stdOutLogStreamName,
+		FileName:                pluginConfig.StdoutFileName,
+		OrchestrationDirectory:  fullPath,
+		OutputS3BucketName:      out.ioConfig.OutputS3BucketName,
+		OutputS3KeyPrefix:       s3KeyPrefix,
+		LogGroupName:            out.ioConfig.CloudWatchConfig.LogGroupName,
+		LogStreamName:           stdOutLogStreamName,
+		CloudWatchFlushInterval: cloudWatchFlushInterval,
 	}
 
-	// Initialize console output module
+	// Initialize console output module, the output filter only applies to the output returned in the replies
 	stdoutConsole := iomodule.CommandOutput{
 		OutputString:           &out.stdout,
 		FileName:               pluginConfig.StdoutConsoleFileName,
 		OrchestrationDirectory: fullPath,
 	}
+	if out.ioConfig.OutputFilter != nil {
+		if transformer, err := outputfilter.New(*out.ioConfig.OutputFilter); err != nil {
+			log.Errorf("Ignoring the invalid output filter: %v", err)
+		} else {
+			stdoutConsole.Transformer = transformer
+		}
+	}
 
 	log.Debug("Initializing the Stdout Multi-writer with file and console listeners")
 	// Get a multi-writer for standard output
@@ -170,12 +192,13 @@ func (out *DefaultIOHandler) Init(log log.T, filePath ...string) {
 
 	// Initialize file error module
 	stderrFile := iomodule.File{
-		FileName:               pluginConfig.StderrFileName,
-		OrchestrationDirectory: fullPath,
-		OutputS3BucketName:     out.ioConfig.OutputS3BucketName,
-		OutputS3KeyPrefix:      s3KeyPrefix,
-		LogGroupName:           out.ioConfig.CloudWatchConfig.LogGroupName,
-		LogStreamName:          stdErrLogStreamName,
+		FileName:                pluginConfig.StderrFileName,
+		OrchestrationDirectory:  fullPath,
+		OutputS3BucketName:      out.ioConfig.OutputS3BucketName,
+		OutputS3KeyPrefix:       s3KeyPrefix,
+		LogGroupName:            out.ioConfig.CloudWatchConfig.LogGroupName,
+		LogStreamName:           stdErrLogStreamName,
+		CloudWatchFlushInterval: cloudWatchFlushInterval,
 	}
 
 	// Initialize console error module
@@ -225,9 +248,33 @@ func (out *DefaultIOHandler) Close(log log.T) {
 	}
 }
 
-// String returns the output by concatenating stdout and stderr
+// String returns the output by concatenating stdout and stderr.
+// When the output is truncated, it ends with the locations of the full output.
 func (out DefaultIOHandler) String() (response string) {
-	return TruncateOutput(out.stdout, out.stderr, MaximumPluginOutputSize)
+	response = TruncateOutput(out.stdout, out.stderr, MaximumPluginOutputSize)
+	if fitsOutput(out.stdout, out.stderr, MaximumPluginOutputSize) {
+		return response
+	}
+	if locations := out.fullOutputLocations(); locations != "" {
+		response = TruncateOutput(out.stdout, out.stderr, MaximumPluginOutputSize-len(locations)) + locations
+	}
+	return response
+}
+
+// fullOutputLocations describes where the full output is uploaded, if it is
+func (out DefaultIOHandler) fullOutputLocations() string {
+	var locations []string
+	if out.ioConfig.OutputS3BucketName != "" {
+		locations = append(locations, fmt.Sprintf("s3://%s/%s", out.ioConfig.OutputS3BucketName, out.ioConfig.OutputS3KeyPrefix))
+	}
+	if out.ioConfig.CloudWatchConfig.LogGroupName != "" {
+		locations = append(locations, fmt.Sprintf("CloudWatch Logs log group %s, log streams %s/*",
+			out.ioConfig.CloudWatchConfig.LogGroupName, out.ioConfig.CloudWatchConfig.LogStreamPrefix))
+	}
+	if len(locations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n---Full output in %s---", strings.Join(locations, " and "))
 }
 
 // GetOutput returns the output to be appended to the response
@@ -264,6 +311,19 @@ func (out DefaultIOHandler) GetIOConfig() contracts.IOConfiguration {
 	return out.ioConfig
 }
 
+// GetStructuredOutput returns the structured output of the step, or a list of them when
+// several outputs were merged
+func (out DefaultIOHandler) GetStructuredOutput() interface{} {
+	switch len(out.structuredOutputs) {
+	case 0:
+		return nil
+	case 1:
+		return out.structuredOutputs[0]
+	default:
+		return out.structuredOutputs
+	}
+}
+
 // GetStdoutWriter returns the stdout writer
 func (out DefaultIOHandler) GetStdoutWriter() multiwriter.DocumentIOMultiWriter {
 	return out.StdoutWriter
@@ -299,6 +359,15 @@ func (out *DefaultIOHandler) SetOutput(output interface{}) {
 	out.output = output
 }
 
+// SetStructuredOutput sets the structured output
+func (out *DefaultIOHandler) SetStructuredOutput(output interface{}) {
+	if output == nil {
+		out.structuredOutputs = nil
+		return
+	}
+	out.structuredOutputs = []interface{}{output}
+}
+
 // Merge plugin output objects
 func (out *DefaultIOHandler) Merge(log log.T, mergeOutput *DefaultIOHandler) {
 
@@ -318,6 +387,8 @@ func (out *DefaultIOHandler) Merge(log log.T, mergeOutput *DefaultIOHandler) {
 	stderrBuffer.WriteString(mergeOutput.GetStderr())
 	out.stderr = stderrBuffer.String()
 
+	out.structuredOutputs = append(out.structuredOutputs, mergeOutput.structuredOutputs...)
+
 	if out.ExitCode == 0 {
 		out.ExitCode = mergeOutput.GetExitCode()
 	}
@@ -416,8 +487,22 @@ func (out *DefaultIOHandler) AppendErrorf(format string, params ...interface{})
 	}
 }
 
-// TruncateOutput truncates the output
+// outputTruncationStrategy returns the configured strategy to truncate the output, variable to allow unit tests to override it
+var outputTruncationStrategy = func() string {
+	if config, err := appconfig.Config(false); err == nil {
+		return config.Ssm.OutputTruncationStrategy
+	}
+	return appconfig.OutputTruncationHead
+}
+
+// OutputTruncationStrategy returns the configured strategy to truncate the output exceeding the size of the replies
+func OutputTruncationStrategy() string {
+	return outputTruncationStrategy()
+}
+
+// TruncateOutput truncates the output with the configured truncation strategy
 func TruncateOutput(stdout string, stderr string, capacity int) (response string) {
+	strategy := outputTruncationStrategy()
 	outputSize := len(stdout)
 	errorSize := len(stderr)
 
@@ -425,7 +510,7 @@ func TruncateOutput(stdout string, stderr string, capacity int) (response string
 	errorTitle := ""
 	lenErrorTitle := 0
 	if errorSize > 0 {
-		errorTitle = "\n----------ERROR-------\n"
+		errorTitle = outputErrorTitle
 		lenErrorTitle = len(errorTitle)
 	}
 
@@ -440,16 +525,58 @@ func TruncateOutput(stdout string, stderr string, capacity int) (response string
 	// truncate out and error when both exceed the size
 	if outputSize > availableSpace/2 && errorSize > availableSpace/2 {
 		truncateSize := availableSpace - len(truncateError) - len(truncateOut)
-		return fmt.Sprint(stdout[:truncateSize/2], truncateOut, errorTitle, stderr[:truncateSize/2], truncateError)
+		return fmt.Sprint(
+			Truncate(stdout, truncateSize/2+len(truncateOut), truncateOut, strategy),
+			errorTitle,
+			Truncate(stderr, truncateSize/2+len(truncateError), truncateError, strategy))
 	}
 
 	// truncate error when output is short
 	if outputSize < availableSpace/2 {
-		truncateSize := availableSpace - len(truncateError)
-		return fmt.Sprint(stdout, errorTitle, stderr[:truncateSize-outputSize], truncateError)
+		return fmt.Sprint(stdout, errorTitle, Truncate(stderr, availableSpace-outputSize, truncateError, strategy))
 	}
 
 	// truncate output when error is short
-	truncateSize := availableSpace - len(truncateOut)
-	return fmt.Sprint(stdout[:truncateSize-errorSize], truncateOut, errorTitle, stderr)
+	return fmt.Sprint(Truncate(stdout, availableSpace-errorSize, truncateOut, strategy), errorTitle, stderr)
+}
+
+// fitsOutput returns whether TruncateOutput returns the output and the error without truncating them
+func fitsOutput(stdout string, stderr string, capacity int) bool {
+	if len(stderr) > 0 {
+		capacity -= len(outputErrorTitle)
+	}
+	return len(stdout)+len(stderr) < capacity
+}
+
+// Truncate shortens text to maxLength, marker included, keeping the parts of the text given by the strategy:
+// the head, the tail or both. The marker is placed where the text is cut.
+func Truncate(text string, maxLength int, marker string, strategy string) string {
+	if len(text) <= maxLength {
+		return text
+	}
+
+	// markers starting with a new line are followed by a new line when the output continues after them
+	cut := marker
+	if strings.HasPrefix(marker, "\n") {
+		switch {
+		case strings.EqualFold(strategy, appconfig.OutputTruncationTail):
+			cut = marker[1:] + "\n"
+		case strings.EqualFold(strategy, appconfig.OutputTruncationHeadAndTail):
+			cut = marker + "\n"
+		}
+	}
+	if maxLength <= len(cut) {
+		return cut[:maxLength]
+	}
+
+	keep := maxLength - len(cut)
+	switch {
+	case strings.EqualFold(strategy, appconfig.OutputTruncationTail):
+		return cut + text[len(text)-keep:]
+	case strings.EqualFold(strategy, appconfig.OutputTruncationHeadAndTail):
+		head := keep / 2
+		return text[:head] + cut + text[len(text)-(keep-head):]
+	default:
+		return text[:keep] + cut
+	}
 }
@@ -15,11 +15,13 @@ package iohandler
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	iomodulemock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
@@ -68,6 +70,47 @@ func TestTruncateOutput(t *testing.T) {
 	}
 }
 
+func TestTruncate(t *testing.T) {
+	text := "0123456789abcdefghij"
+
+	assert.Equal(t, text, Truncate(text, len(text), "\n--", appconfig.OutputTruncationTail))
+	assert.Equal(t, "0123456\n--", Truncate(text, 10, "\n--", appconfig.OutputTruncationHead))
+	assert.Equal(t, "--\nghij", Truncate(text, 7, "\n--", appconfig.OutputTruncationTail))
+	assert.Equal(t, "012\n--\nhij", Truncate(text, 10, "\n--", appconfig.OutputTruncationHeadAndTail))
+	assert.Equal(t, "012[..]ghij", Truncate(text, 11, "[..]", "headandtail"))
+	assert.Equal(t, "--", Truncate(text, 2, "\n--", appconfig.OutputTruncationTail))
+}
+
+func TestTruncateOutput_TailStrategy(t *testing.T) {
+	strategyTemp := outputTruncationStrategy
+	outputTruncationStrategy = func() string { return appconfig.OutputTruncationTail }
+	defer func() { outputTruncationStrategy = strategyTemp }()
+
+	actual := TruncateOutput(longMessage, "", sampleSize)
+
+	assert.Len(t, actual, sampleSize)
+	assert.Equal(t, "---Output truncated---\n", actual[:len(truncateOut)])
+	assert.True(t, strings.HasSuffix(longMessage, actual[len(truncateOut):]))
+}
+
+func TestString_ReferencesFullOutput(t *testing.T) {
+	output := DefaultIOHandler{
+		stdout: strings.Repeat("a", MaximumPluginOutputSize),
+		ioConfig: contracts.IOConfiguration{
+			OutputS3BucketName: "bucket",
+			OutputS3KeyPrefix:  "prefix",
+		},
+	}
+
+	actual := output.String()
+
+	assert.True(t, len(actual) <= MaximumPluginOutputSize)
+	assert.True(t, strings.HasSuffix(actual, "\n---Output truncated---\n---Full output in s3://bucket/prefix---"), actual[len(actual)-100:])
+
+	output.stdout = "short output"
+	assert.Equal(t, "short output", output.String())
+}
+
 var logger = log.NewMockLog()
 
 func TestRegisterOutputSource(t *testing.T) {
@@ -174,3 +217,19 @@ func TestAppendFormat(t *testing.T) {
 	assert.Contains(t, output.GetStdout(), testStringFormatted)
 	assert.Contains(t, output.GetStderr(), testStringFormatted)
 }
+
+func TestStructuredOutput(t *testing.T) {
+	output := DefaultIOHandler{}
+	assert.Nil(t, output.GetStructuredOutput())
+
+	output.SetStructuredOutput(map[string]interface{}{"id": "i-1"})
+	assert.Equal(t, map[string]interface{}{"id": "i-1"}, output.GetStructuredOutput())
+
+	merged := DefaultIOHandler{}
+	merged.SetStructuredOutput("second")
+	output.Merge(logger, &merged)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "i-1"}, "second"}, output.GetStructuredOutput())
+
+	output.SetStructuredOutput(nil)
+	assert.Nil(t, output.GetStructuredOutput())
+}
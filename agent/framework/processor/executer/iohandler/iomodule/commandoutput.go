@@ -23,7 +23,9 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/outputfilter"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
 )
 
 // CommandOutput handles writing output to a string.
@@ -31,6 +33,8 @@ type CommandOutput struct {
 	OutputString           *string
 	FileName               string
 	OrchestrationDirectory string
+	// Transformer, if set, selects the part of the output set in OutputString
+	Transformer outputfilter.Transformer
 }
 
 func (c CommandOutput) Read(log log.T, reader *io.PipeReader) {
@@ -50,14 +54,18 @@ func (c CommandOutput) Read(log log.T, reader *io.PipeReader) {
 
 	defer fileWriter.Close()
 
-	// Read byte by byte and write to file
+	// Read byte by byte and write to file, redacting SecureString parameter values
+	redactingWriter := parameterstore.NewRedactingWriter(fileWriter)
 	scanner := bufio.NewScanner(reader)
 	scanner.Split(bufio.ScanBytes)
 	for scanner.Scan() {
-		if _, err = fileWriter.Write([]byte(scanner.Text())); err != nil {
+		if _, err = redactingWriter.Write([]byte(scanner.Text())); err != nil {
 			log.Errorf("Failed to write the message to stdoutConsoleFile: %v", err)
 		}
 	}
+	if err = redactingWriter.Close(); err != nil {
+		log.Errorf("Failed to write the message to stdoutConsoleFile: %v", err)
+	}
 
 	// Check if scanner exited because of an error
 	if err := scanner.Err(); err != nil {
@@ -75,6 +83,14 @@ func (c CommandOutput) Read(log log.T, reader *io.PipeReader) {
 		*c.OutputString, err = fileutil.ReadAllText(filePath)
 		if err != nil {
 			log.Errorf("Error reading %v at path %v", c.FileName, filePath)
+			return
+		}
+		if c.Transformer != nil {
+			if output, err := c.Transformer.Transform(*c.OutputString); err != nil {
+				log.Warnf("Failed to filter %v, returning the full output: %v", c.FileName, err)
+			} else {
+				*c.OutputString = output
+			}
 		}
 	}
 }
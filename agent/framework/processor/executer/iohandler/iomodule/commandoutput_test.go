@@ -9,6 +9,12 @@ import (
 
 	"strconv"
 
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/outputfilter"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -65,3 +71,45 @@ func testFileCommandOutput(pipeTestCase string, i int) string {
 	return stdout
 
 }
+
+// TestCommandOutputTransformer tests the output is filtered while the console file keeps the full output
+func TestCommandOutputTransformer(t *testing.T) {
+	testCases := []struct {
+		filter   contracts.OutputFilter
+		expected string
+	}{
+		{contracts.OutputFilter{LineMatch: "^result"}, "result: 42"},
+		// output which is not JSON is returned unfiltered
+		{contracts.OutputFilter{JSONPath: "$.id"}, "downloading\nresult: 42\n"},
+	}
+	for _, testCase := range testCases {
+		dir, err := ioutil.TempDir("", "commandoutput")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		transformer, err := outputfilter.New(testCase.filter)
+		assert.NoError(t, err)
+		var stdout string
+		stdoutConsole := CommandOutput{
+			OutputString:           &stdout,
+			FileName:               "stdoutConsole",
+			OrchestrationDirectory: dir,
+			Transformer:            transformer,
+		}
+
+		r, w := io.Pipe()
+		done := make(chan bool)
+		go func() {
+			stdoutConsole.Read(logger, r)
+			close(done)
+		}()
+		w.Write([]byte("downloading\nresult: 42\n"))
+		w.Close()
+		<-done
+
+		assert.Equal(t, testCase.expected, stdout)
+		content, err := ioutil.ReadFile(filepath.Join(dir, "stdoutConsole"))
+		assert.NoError(t, err)
+		assert.Equal(t, "downloading\nresult: 42\n", string(content))
+	}
+}
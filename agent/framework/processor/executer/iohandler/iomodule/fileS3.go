@@ -24,13 +24,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
 )
 
-const (
-	maxCloudWatchUploadRetry = 5
-)
-
 // File handles writing to an output file and upload to s3 and cloudWatch
 type File struct {
 	FileName               string
@@ -39,6 +36,8 @@ type File struct {
 	OutputS3KeyPrefix      string
 	LogGroupName           string
 	LogStreamName          string
+	// CloudWatchFlushInterval is how often the output is uploaded to CloudWatchLogs while it is written
+	CloudWatchFlushInterval time.Duration
 }
 
 // Read reads from the stream and writes to the output file, s3 and CloudWatchLogs.
@@ -63,21 +62,28 @@ func (file File) Read(log log.T, reader *io.PipeReader) {
 
 	defer fileWriter.Close()
 
-	cwl := cloudwatchlogspublisher.NewCloudWatchLogsService()
+	// Stream the output to CloudWatchLogs while it is written
+	var writer io.Writer = fileWriter
 	if file.LogGroupName != "" {
 		log.Debugf("Received CloudWatch Configs: LogGroupName: %s\n, LogStreamName: %s\n", file.LogGroupName, file.LogStreamName)
-		//Start CWL logging on different go routine
-		go cwl.StreamData(log, file.LogGroupName, file.LogStreamName, filePath, false, false)
+		cwlWriter := cloudwatchlogspublisher.NewCloudWatchLogsService().NewStreamWriter(log, file.LogGroupName, file.LogStreamName, file.CloudWatchFlushInterval)
+		writer = io.MultiWriter(fileWriter, cwlWriter)
+		// Block until the end of the output is uploaded to CloudWatchLogs
+		defer cwlWriter.Close()
 	}
 
-	// Read byte by byte and write to file
+	// Read byte by byte and write to file, redacting SecureString parameter values
+	redactingWriter := parameterstore.NewRedactingWriter(writer)
 	scanner := bufio.NewScanner(reader)
 	scanner.Split(bufio.ScanBytes)
 	for scanner.Scan() {
-		if _, err = fileWriter.Write([]byte(scanner.Text())); err != nil {
+		if _, err = redactingWriter.Write([]byte(scanner.Text())); err != nil {
 			log.Errorf("Failed to write the message to stdout: %v", err)
 		}
 	}
+	if err = redactingWriter.Close(); err != nil {
+		log.Errorf("Failed to write the message to stdout: %v", err)
+	}
 
 	// Check if scanner exited because of an error
 	if err := scanner.Err(); err != nil {
@@ -97,15 +103,4 @@ func (file File) Read(log log.T, reader *io.PipeReader) {
 			log.Errorf("Failed to upload the output to s3: %v", err)
 		}
 	}
-
-	//Block main thread until CloudWatchLogs uploading is complete or until maxCloudWatchUploadRetry is reached
-	//TODO Add unit test to test maxRetry logic
-	if file.LogGroupName != "" {
-		cwl.IsFileComplete = true
-		retry := 0
-		for !cwl.IsUploadComplete && retry < maxCloudWatchUploadRetry {
-			retry++
-			time.Sleep(cloudwatchlogspublisher.UploadFrequency)
-		}
-	}
 }
@@ -140,6 +140,12 @@ func (m *MockIOHandler) GetIOConfig() contracts.IOConfiguration {
 	return args.Get(0).(contracts.IOConfiguration)
 }
 
+// GetStructuredOutput is a mocked method that just returns what mock tells it to.
+func (m *MockIOHandler) GetStructuredOutput() interface{} {
+	args := m.Called()
+	return args.Get(0)
+}
+
 // SetStatus is a mocked method that acknowledges that the function has been called.
 func (m *MockIOHandler) SetStatus(status contracts.ResultStatus) {
 	m.Called(status)
@@ -164,3 +170,8 @@ func (m *MockIOHandler) SetStdout(stdout string) {
 func (m *MockIOHandler) SetStderr(stderr string) {
 	m.Called(stderr)
 }
+
+// SetStructuredOutput is a mocked method that acknowledges that the function has been called.
+func (m *MockIOHandler) SetStructuredOutput(out interface{}) {
+	m.Called(out)
+}
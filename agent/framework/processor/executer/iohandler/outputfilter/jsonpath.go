@@ -0,0 +1,170 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package outputfilter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// wildcard is the selector of all the members of an object or elements of an array
+const wildcard = "*"
+
+// jsonPathSelector selects a member of an object by key, an element of an array by index, or all of them
+type jsonPathSelector struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// jsonPath extracts values from a JSON document, supporting the member (.name, ['name']),
+// index ([0], [-1]) and wildcard (.*, [*]) selectors
+type jsonPath []jsonPathSelector
+
+// parseJSONPath parses a path such as $.items[*].name
+func parseJSONPath(path string) (jsonPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("the path must start with $")
+	}
+	var selectors jsonPath
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			name := rest[1:end]
+			if name == "" {
+				return nil, fmt.Errorf("empty member name")
+			}
+			selectors = append(selectors, jsonPathSelector{key: name, isKey: true})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ]")
+			}
+			selector, err := parseBracketSelector(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, selector)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+	return selectors, nil
+}
+
+// parseBracketSelector parses the content of a bracket selector: *, an index or a quoted member name
+func parseBracketSelector(content string) (jsonPathSelector, error) {
+	content = strings.TrimSpace(content)
+	if content == wildcard {
+		return jsonPathSelector{key: wildcard, isKey: true}, nil
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return jsonPathSelector{key: content[1 : len(content)-1], isKey: true}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathSelector{}, fmt.Errorf("invalid selector [%v]", content)
+	}
+	return jsonPathSelector{index: index}, nil
+}
+
+// Transform parses output as JSON and returns the selected values, one per line.
+// Strings are returned as they are, the other values as JSON.
+func (path jsonPath) Transform(output string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return "", fmt.Errorf("output is not JSON: %v", err)
+	}
+
+	values := []interface{}{document}
+	for _, selector := range path {
+		var selected []interface{}
+		for _, value := range values {
+			selected = append(selected, selector.selectFrom(value)...)
+		}
+		values = selected
+	}
+
+	lines := make([]string, 0, len(values))
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			lines = append(lines, text)
+			continue
+		}
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		lines = append(lines, strings.TrimSuffix(buffer.String(), "\n"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// selectFrom returns the values the selector selects from value, none if value has no such member or element
+func (selector jsonPathSelector) selectFrom(value interface{}) []interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if !selector.isKey {
+			return nil
+		}
+		if selector.key != wildcard {
+			if member, found := typed[selector.key]; found {
+				return []interface{}{member}
+			}
+			return nil
+		}
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		members := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			members = append(members, typed[key])
+		}
+		return members
+	case []interface{}:
+		if selector.isKey {
+			if selector.key == wildcard {
+				return typed
+			}
+			return nil
+		}
+		index := selector.index
+		if index < 0 {
+			index += len(typed)
+		}
+		if index < 0 || index >= len(typed) {
+			return nil
+		}
+		return []interface{}{typed[index]}
+	default:
+		return nil
+	}
+}
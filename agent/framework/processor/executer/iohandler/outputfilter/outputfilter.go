@@ -0,0 +1,78 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package outputfilter implements the transformers selecting the part of the output of a step returned in the replies.
+package outputfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// Transformer transforms the output of a step
+type Transformer interface {
+	Transform(output string) (string, error)
+}
+
+// chain applies transformers one after the other
+type chain []Transformer
+
+// New returns the transformer applying the given filter, it fails if the filter is invalid.
+func New(filter contracts.OutputFilter) (Transformer, error) {
+	var transformers chain
+	if filter.LineMatch != "" {
+		pattern, err := regexp.Compile(filter.LineMatch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lineMatch %v: %v", filter.LineMatch, err)
+		}
+		transformers = append(transformers, lineMatch{pattern: pattern})
+	}
+	if filter.JSONPath != "" {
+		path, err := parseJSONPath(filter.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonPath %v: %v", filter.JSONPath, err)
+		}
+		transformers = append(transformers, path)
+	}
+	return transformers, nil
+}
+
+// Transform applies the transformers of the chain in order
+func (c chain) Transform(output string) (string, error) {
+	var err error
+	for _, transformer := range c {
+		if output, err = transformer.Transform(output); err != nil {
+			return "", err
+		}
+	}
+	return output, nil
+}
+
+// lineMatch keeps the lines of the output matching a regular expression, like grep
+type lineMatch struct {
+	pattern *regexp.Regexp
+}
+
+// Transform returns the lines of output matching the pattern
+func (l lineMatch) Transform(output string) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if l.pattern.MatchString(strings.TrimSuffix(line, "\r")) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package outputfilter
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonOutput = `{"items": [{"name": "a", "size": 1}, {"name": "b", "size": 2.5, "tags": {"x": true}}], "status": "ok"}`
+
+func TestTransform(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filter   contracts.OutputFilter
+		output   string
+		expected string
+	}{
+		{"NoFilter", contracts.OutputFilter{}, "line1\nline2", "line1\nline2"},
+		{"LineMatch", contracts.OutputFilter{LineMatch: "^ERROR"}, "INFO start\nERROR one\nINFO end\nERROR two\n", "ERROR one\nERROR two"},
+		{"LineMatchWindowsNewlines", contracts.OutputFilter{LineMatch: "done$"}, "step done\r\nother\r\n", "step done\r"},
+		{"LineMatchNothing", contracts.OutputFilter{LineMatch: "missing"}, "line1\nline2", ""},
+		{"JSONPathRoot", contracts.OutputFilter{JSONPath: "$"}, ` {"a": 1} `, `{"a":1}`},
+		{"JSONPathString", contracts.OutputFilter{JSONPath: "$.status"}, jsonOutput, "ok"},
+		{"JSONPathIndex", contracts.OutputFilter{JSONPath: "$.items[1].size"}, jsonOutput, "2.5"},
+		{"JSONPathNegativeIndex", contracts.OutputFilter{JSONPath: "$['items'][-1].name"}, jsonOutput, "b"},
+		{"JSONPathWildcard", contracts.OutputFilter{JSONPath: "$.items[*].name"}, jsonOutput, "a\nb"},
+		{"JSONPathObject", contracts.OutputFilter{JSONPath: "$.items[1].tags"}, jsonOutput, `{"x":true}`},
+		{"JSONPathObjectWildcard", contracts.OutputFilter{JSONPath: "$.items[0].*"}, jsonOutput, "a\n1"},
+		{"JSONPathMissing", contracts.OutputFilter{JSONPath: "$.items[5].name"}, jsonOutput, ""},
+		{"LineMatchThenJSONPath", contracts.OutputFilter{LineMatch: "^{", JSONPath: "$.id"}, "Downloading...\n{\"id\": \"i-123\"}\nDone", "i-123"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			transformer, err := New(testCase.filter)
+			assert.NoError(t, err)
+			output, err := transformer.Transform(testCase.output)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, output)
+		})
+	}
+}
+
+func TestTransformNotJSON(t *testing.T) {
+	transformer, err := New(contracts.OutputFilter{JSONPath: "$.status"})
+	assert.NoError(t, err)
+	_, err = transformer.Transform("not json")
+	assert.Error(t, err)
+}
+
+func TestNewInvalidFilter(t *testing.T) {
+	for _, filter := range []contracts.OutputFilter{
+		{LineMatch: "[a-"},
+		{JSONPath: "items"},
+		{JSONPath: "$..items"},
+		{JSONPath: "$.items[0"},
+		{JSONPath: "$.items[x]"},
+		{JSONPath: "$items"},
+	} {
+		_, err := New(filter)
+		assert.Error(t, err, "filter %+v", filter)
+	}
+}
@@ -32,6 +32,15 @@ type Channel interface {
 	Destroy()
 }
 
+//FileChannelPath returns the directory of the file channel of the given name
+func FileChannelPath(filename string) (string, error) {
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(appconfig.DefaultDataStorePath, instanceID, defaultFileChannelPath, filename), nil
+}
+
 //find the folder named as "documentID" under the default root dir
 //if not found, create a new filechannel under the default root dir
 //return the channel and the found flag
@@ -55,7 +55,7 @@ func NewFileWatcherChannel(logger log.T, mode Mode, name string) (*fileWatcherCh
 
 	tmpPath := path.Join(name, "tmp")
 	curTime := time.Now()
-	//TODO if client is RunAs, server needs to grant client user R/W access respectively
+	//when the worker runs as another user, the master hands the directory over to that user after creating it
 	if err := createIfNotExist(name); err != nil {
 		logger.Errorf("failed to create directory: %v", err)
 		os.RemoveAll(name)
@@ -8,12 +8,14 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/basicexecuter"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/channel"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -27,6 +29,13 @@ const (
 	defaultOrphanProcessTimeout = 172800 * time.Second
 )
 
+//workerExitsCounter counts the exits of the worker processes, a failed exit is usually a crash of a plugin
+var workerExitsCounter = metrics.NewCounter("ssm_agent_worker_exits_total",
+	"Exits of the document and session worker processes, by worker and result.", "worker", "result")
+
+//orphanProcessPollInterval is how often a reattached orphan process is checked for liveness, overridden in tests
+var orphanProcessPollInterval = 10 * time.Second
+
 type OutOfProcExecuter struct {
 	basicexecuter.BasicExecuter
 	docState   *contracts.DocumentState
@@ -52,6 +61,14 @@ var processCreator = func(name string, argv []string) (proc.OSProcess, error) {
 	return proc.StartProcess(name, argv)
 }
 
+var userProcessCreator = func(name string, argv []string, runAsUser string, workDirs []string) (proc.OSProcess, error) {
+	return proc.StartProcessAs(name, argv, runAsUser, workDirs)
+}
+
+var channelPathFinder = func(documentID string) (string, error) {
+	return channel.FileChannelPath(documentID)
+}
+
 func NewOutOfProcExecuter(ctx context.T) *OutOfProcExecuter {
 	return &OutOfProcExecuter{
 		BasicExecuter: *basicexecuter.NewBasicExecuter(ctx),
@@ -75,9 +92,14 @@ func (e *OutOfProcExecuter) Run(
 	//stopTimer signals messaging routine to stop, it's buffered because it needs to exit if messaging is already stopped and not receiving anymore
 	stopTimer := make(chan bool, 1)
 	//start prepare messaging
-	//if anything fails during the prep stage, use in-proc Runner
+	//if anything fails during the prep stage, use in-proc Runner, unless worker processes are required
+	//or the document would run as the worker user
 	ipc, err := e.initialize(stopTimer)
 	if err != nil {
+		if e.ctx.AppConfig().Agent.DocumentWorkerRequired || e.workerUser() != "" {
+			log.Errorf("failed to prepare outofproc executer, failing the document since worker processes are required")
+			return e.failDocument(docStore, fmt.Sprintf("failed to start the worker process: %v", err))
+		}
 		log.Errorf("failed to prepare outofproc executer, falling back to InProc Executer")
 		return e.BasicExecuter.Run(cancelFlag, docStore)
 	} else {
@@ -140,6 +162,16 @@ func (e *OutOfProcExecuter) generateUnexpectedFailResult(errMsg string) contract
 	return docResult
 }
 
+//failDocument fails the document without running it and returns the closed result channel
+func (e *OutOfProcExecuter) failDocument(docStore executer.DocumentStore, errMsg string) chan contracts.DocumentResult {
+	resChan := make(chan contracts.DocumentResult, 1)
+	e.docState.DocumentInformation.DocumentStatus = contracts.ResultStatusFailed
+	resChan <- e.generateUnexpectedFailResult(errMsg)
+	docStore.Save(*e.docState)
+	close(resChan)
+	return resChan
+}
+
 //prepare the channel for messaging as well as launching the document worker process, if the channel already exists, re-open it.
 //launch timeout timer based off the discovered process status
 func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc channel.Channel, err error) {
@@ -154,16 +186,15 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc channel.Channel
 	}
 	if found {
 		log.Info("discovered old channel object, trying to find detached process...")
-		var stopTime time.Duration
 		procInfo := e.docState.DocumentInformation.ProcInfo
 		if processFinder(log, procInfo) {
-			log.Infof("found orphan process: %v, start time: %v", procInfo.Pid, procInfo.StartTime)
-			stopTime = defaultOrphanProcessTimeout
+			//the worker survived the previous agent, e.g. through an update, reattach to it through the channel
+			log.Infof("found orphan process: %v, start time: %v, reattaching", procInfo.Pid, procInfo.StartTime)
+			go e.watchOrphanProcess(stopTimer, procInfo)
 		} else {
 			log.Infof("process: %v not found, treat as exited", procInfo.Pid)
-			stopTime = defaultZombieProcessTimeout
+			go timeout(stopTimer, defaultZombieProcessTimeout, e.cancelFlag)
 		}
-		go timeout(stopTimer, stopTime, e.cancelFlag)
 	} else {
 		log.Debug("channel not found, starting a new process...")
 		var workerName string
@@ -173,7 +204,7 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc channel.Channel
 			workerName = appconfig.DefaultDocumentWorker
 		}
 		var process proc.OSProcess
-		if process, err = processCreator(workerName, proc.FormArgv(documentID)); err != nil {
+		if process, err = e.startWorker(workerName, documentID); err != nil {
 			log.Errorf("start process: %v error: %v", workerName, err)
 			//make sure close the channel
 			ipc.Destroy()
@@ -193,6 +224,36 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc channel.Channel
 	return
 }
 
+//startWorker launches the worker process of the document, as the worker user when one is configured. That worker gets
+//the instance id on the command line, since the registration of managed instances is readable by root only.
+func (e *OutOfProcExecuter) startWorker(workerName string, documentID string) (proc.OSProcess, error) {
+	workerUser := e.workerUser()
+	if workerUser == "" {
+		return processCreator(workerName, proc.FormArgv(documentID))
+	}
+	channelDir, err := channelPathFinder(documentID)
+	if err != nil {
+		return nil, err
+	}
+	workDirs := []string{channelDir}
+	if orchestrationDir := e.docState.IOConfig.OrchestrationDirectory; orchestrationDir != "" {
+		if err = fileutil.MakeDirsWithExecuteAccess(orchestrationDir); err != nil {
+			return nil, err
+		}
+		workDirs = append(workDirs, orchestrationDir)
+	}
+	argv := proc.FormArgvWithInstanceID(documentID, e.docState.DocumentInformation.InstanceID)
+	return userProcessCreator(workerName, argv, workerUser, workDirs)
+}
+
+//workerUser returns the user the worker of the document runs as, empty for the agent user
+func (e *OutOfProcExecuter) workerUser() string {
+	if e.docState.DocumentType == contracts.StartSession {
+		return ""
+	}
+	return e.ctx.AppConfig().Agent.DocumentWorkerUser
+}
+
 func (e *OutOfProcExecuter) WaitForProcess(stopTimer chan bool, process proc.OSProcess) {
 	log := e.ctx.Log()
 	//TODO revisit this feature, it has done sides of killing the document worker too fast -- the worker might busy doing s3 upload
@@ -209,13 +270,53 @@ func (e *OutOfProcExecuter) WaitForProcess(stopTimer chan bool, process proc.OSP
 	//}()
 	if err := process.Wait(); err != nil {
 		log.Errorf("process: %v exited unsuccessfully, error message: %v", process.Pid(), err)
+		workerExitsCounter.Inc(e.workerName(), "failure")
 	} else {
 		log.Debugf("process: %v exited successfully, trying to stop messaging worker", process.Pid())
+		workerExitsCounter.Inc(e.workerName(), "success")
 	}
 	//waitReturned = true
 	timeout(stopTimer, defaultZombieProcessTimeout, e.cancelFlag)
 }
 
+//watchOrphanProcess stops the messaging once the reattached orphan process has exited, giving it time to deliver its
+//last messages, since an orphan cannot be waited for like a child process. The messaging is stopped anyway after the
+//command maximum timeout.
+func (e *OutOfProcExecuter) watchOrphanProcess(stopTimer chan bool, procInfo contracts.OSProcInfo) {
+	log := e.ctx.Log()
+	stopChan := make(chan bool)
+	go func() {
+		e.cancelFlag.Wait()
+		close(stopChan)
+	}()
+	maxTimer := time.After(defaultOrphanProcessTimeout)
+	ticker := time.NewTicker(orphanProcessPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-maxTimer:
+			stopTimer <- true
+			return
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if !processFinder(log, procInfo) {
+				log.Infof("orphan process: %v exited, trying to stop messaging worker", procInfo.Pid)
+				timeout(stopTimer, defaultZombieProcessTimeout, e.cancelFlag)
+				return
+			}
+		}
+	}
+}
+
+//workerName returns the name of the worker of the document, as labeled in the metrics
+func (e *OutOfProcExecuter) workerName() string {
+	if e.docState.DocumentType == contracts.StartSession {
+		return "ssm-session-worker"
+	}
+	return "ssm-document-worker"
+}
+
 func timeout(stopTimer chan bool, duration time.Duration, cancelFlag task.CancelFlag) {
 	stopChan := make(chan bool)
 	//TODO refactor cancelFlag.Wait() to return channel instead of blocking call
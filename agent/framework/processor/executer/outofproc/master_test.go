@@ -1,6 +1,7 @@
 package outofproc
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type TestCase struct {
@@ -326,6 +328,37 @@ func TestInitializeConnectOldOrphan(t *testing.T) {
 	channelMock.AssertExpectations(t)
 }
 
+func TestInitializeReattachedOrphanExited(t *testing.T) {
+	testCase := CreateTestCase()
+	testCase.docState.DocumentType = contracts.StartSession
+	testCase.docState.DocumentInformation.ProcInfo = contracts.OSProcInfo{Pid: testPid, StartTime: testStartDateTime}
+	channelMock := new(channelmock.MockedChannel)
+	channelCreator = func(log log.T, mode channel.Mode, documentID string) (channel.Channel, error, bool) {
+		return channelMock, nil, true
+	}
+	//the orphan is found when reattaching, then exits
+	var finderCalls int32
+	processFinder = func(log log.T, procinfo contracts.OSProcInfo) bool {
+		assert.Equal(t, testPid, procinfo.Pid)
+		return atomic.AddInt32(&finderCalls, 1) < 3
+	}
+	defer func(interval time.Duration) { orphanProcessPollInterval = interval }(orphanProcessPollInterval)
+	orphanProcessPollInterval = 10 * time.Millisecond
+	cancel := task.NewChanneledCancelFlag()
+	exe := &OutOfProcExecuter{
+		ctx:        testCase.context,
+		docState:   &testCase.docState,
+		cancelFlag: cancel,
+	}
+	stopTimer := make(chan bool)
+	_, err := exe.initialize(stopTimer)
+	assert.NoError(t, err)
+	//messaging is stopped once the orphan has exited
+	<-stopTimer
+	assert.Equal(t, int32(3), atomic.LoadInt32(&finderCalls))
+	cancel.Set(task.Completed)
+}
+
 //TODO add Run() unittest
 
 //this is needed, since after marshal-unmarshalling thru the data channel, the pointer value changed
@@ -335,3 +368,93 @@ func assertValueEqual(t *testing.T, a map[string]*contracts.PluginResult, b map[
 		assert.Equal(t, *val, *b[key])
 	}
 }
+
+func TestRunFailsWhenDocumentWorkerRequired(t *testing.T) {
+	testCase := CreateTestCase()
+	config := appconfig.SsmagentConfig{}
+	config.Agent.DocumentWorkerRequired = true
+	contextMock := new(context.Mock)
+	contextMock.On("Log").Return(logger)
+	contextMock.On("AppConfig").Return(config)
+	contextMock.On("With", mock.AnythingOfType("string")).Return(contextMock)
+	channelMock := new(channelmock.MockedChannel)
+	channelMock.On("Destroy").Return(nil)
+	channelCreator = func(log log.T, mode channel.Mode, documentID string) (channel.Channel, error, bool) {
+		return channelMock, nil, false
+	}
+	processCreator = func(name string, argv []string) (proc.OSProcess, error) {
+		return nil, errors.New("failed to create process")
+	}
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+
+	exe := NewOutOfProcExecuter(contextMock)
+	resChan := exe.Run(task.NewChanneledCancelFlag(), testCase.docStore)
+	var results []contracts.DocumentResult
+	for res := range resChan {
+		results = append(results, res)
+	}
+	//the document fails instead of running in the agent process
+	assert.Len(t, results, 1)
+	assert.Equal(t, contracts.ResultStatusFailed, results[0].Status)
+	assert.Equal(t, "failed to start the worker process: failed to create process", results[0].PluginResults["plugin1"].Output)
+	assert.Equal(t, contracts.ResultStatusFailed, exe.docState.DocumentInformation.DocumentStatus)
+	testCase.docStore.AssertExpectations(t)
+	channelMock.AssertExpectations(t)
+}
+
+func TestRunStartsDocumentWorkerAsWorkerUser(t *testing.T) {
+	testCase := CreateTestCase()
+	testCase.docState.IOConfig.OrchestrationDirectory = ""
+	config := appconfig.SsmagentConfig{}
+	config.Agent.DocumentWorkerUser = "ssm-worker"
+	contextMock := new(context.Mock)
+	contextMock.On("Log").Return(logger)
+	contextMock.On("AppConfig").Return(config)
+	contextMock.On("With", mock.AnythingOfType("string")).Return(contextMock)
+	channelMock := new(channelmock.MockedChannel)
+	channelMock.On("Destroy").Return(nil)
+	channelCreator = func(log log.T, mode channel.Mode, documentID string) (channel.Channel, error, bool) {
+		return channelMock, nil, false
+	}
+	channelPathFinder = func(documentID string) (string, error) {
+		return "/channels/" + documentID, nil
+	}
+	processCreator = func(name string, argv []string) (proc.OSProcess, error) {
+		assert.Fail(t, "the worker must not run as the agent user")
+		return nil, errors.New("unexpected process")
+	}
+	var startedUser string
+	var startedArgv, startedWorkDirs []string
+	userProcessCreator = func(name string, argv []string, runAsUser string, workDirs []string) (proc.OSProcess, error) {
+		startedArgv, startedUser, startedWorkDirs = argv, runAsUser, workDirs
+		return nil, errors.New("failed to switch user")
+	}
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+
+	exe := NewOutOfProcExecuter(contextMock)
+	resChan := exe.Run(task.NewChanneledCancelFlag(), testCase.docStore)
+	var results []contracts.DocumentResult
+	for res := range resChan {
+		results = append(results, res)
+	}
+	assert.Equal(t, "ssm-worker", startedUser)
+	assert.Equal(t, []string{testDocumentID, testInstanceID}, startedArgv)
+	assert.Equal(t, []string{"/channels/" + testDocumentID}, startedWorkDirs)
+	//the document fails rather than running as root in the agent process
+	assert.Len(t, results, 1)
+	assert.Equal(t, contracts.ResultStatusFailed, results[0].Status)
+	assert.Equal(t, "failed to start the worker process: failed to switch user", results[0].PluginResults["plugin1"].Output)
+}
+
+func TestSessionWorkerIgnoresWorkerUser(t *testing.T) {
+	testCase := CreateTestCase()
+	testCase.docState.DocumentType = contracts.StartSession
+	config := appconfig.SsmagentConfig{}
+	config.Agent.DocumentWorkerUser = "ssm-worker"
+	contextMock := new(context.Mock)
+	contextMock.On("AppConfig").Return(config)
+	exe := &OutOfProcExecuter{ctx: contextMock, docState: &testCase.docState}
+	assert.Equal(t, "", exe.workerUser())
+}
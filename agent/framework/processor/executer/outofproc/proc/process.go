@@ -76,6 +76,19 @@ func StartProcess(name string, argv []string) (OSProcess, error) {
 	return &p, err
 }
 
+//start a child process as the given local user, the user is given ownership of the work directories of the process
+func StartProcessAs(name string, argv []string, runAsUser string, workDirs []string) (OSProcess, error) {
+	cmd := exec.Command(name, argv...)
+	prepareProcess(cmd)
+	if err := setProcessUser(cmd, runAsUser, workDirs); err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &WorkerProcess{cmd, time.Now().UTC()}, nil
+}
+
 //os.FindProcess() doesn't work on Linux: https://groups.google.com/forum/#!topic/golang-nuts/hqrp0UHBK9k
 //what we can only do is check whether it exists
 func IsProcessExists(log log.T, pid int, createTime time.Time) bool {
@@ -110,3 +123,8 @@ func ParseArgv(argv []string) (string, string, error) {
 func FormArgv(channelName string) []string {
 	return []string{channelName}
 }
+
+//FormArgvWithInstanceID passes the instance id along, for workers that can't look it up themselves
+func FormArgvWithInstanceID(channelName string, instanceID string) []string {
+	return []string{channelName, instanceID}
+}
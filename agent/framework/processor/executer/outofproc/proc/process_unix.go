@@ -17,7 +17,11 @@
 package proc
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -32,10 +36,81 @@ var ps = func() ([]byte, error) {
 }
 
 func prepareProcess(command *exec.Cmd) {
-	// set pgid to new pid, so that the process can survive when upstart/systemd kill the original process group
+	// set pgid to new pid, so that the process can survive when upstart/systemd kill the original process group,
+	// e.g. when the agent is stopped to be updated
 	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
+//setProcessUser starts the process as the given user, without the supplementary groups of the agent, in its first
+//work directory. The user owns the work directories, and the directories above them are made searchable by others,
+//not readable, so that the process can reach them.
+func setProcessUser(command *exec.Cmd, runAsUser string, workDirs []string) error {
+	credential, err := lookupCredential(runAsUser)
+	if err != nil {
+		return err
+	}
+	for _, dir := range workDirs {
+		if err = grantWorkDir(dir, credential); err != nil {
+			return fmt.Errorf("failed to hand %s over to %s: %v", dir, runAsUser, err)
+		}
+	}
+	command.SysProcAttr.Credential = credential
+	if len(workDirs) > 0 {
+		command.Dir = workDirs[0]
+	}
+	return nil
+}
+
+//lookupCredential returns the uid and gid of the given local user, root is refused
+func lookupCredential(runAsUser string) (*syscall.Credential, error) {
+	u, err := user.Lookup(runAsUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %v", runAsUser, err)
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid %s for %s: %v", u.Uid, runAsUser, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid %s for %s: %v", u.Gid, runAsUser, err)
+	}
+	if uid == 0 || gid == 0 {
+		return nil, fmt.Errorf("%s is not allowed as worker user", runAsUser)
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}}, nil
+}
+
+//grantWorkDir gives the user ownership of the directory and its content, and lets others search the directories above.
+//The agent creates some directories without the search permission, which only root can do without.
+func grantWorkDir(dir string, credential *syscall.Credential) error {
+	for parent := filepath.Dir(dir); ; parent = filepath.Dir(parent) {
+		info, err := os.Stat(parent)
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm()&0001 == 0 {
+			if err = os.Chmod(parent, info.Mode().Perm()|0001); err != nil {
+				return err
+			}
+		}
+		if parent == filepath.Dir(parent) {
+			break
+		}
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Mode().Perm()&0700 != 0700 {
+			if err = os.Chmod(path, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+		}
+		return os.Lchown(path, int(credential.Uid), int(credential.Gid))
+	})
+}
+
 //given the pid and the unix process startTime format string, return whether the process is still alive
 func find_process(pid int, startTime time.Time) (bool, error) {
 	output, err := ps()
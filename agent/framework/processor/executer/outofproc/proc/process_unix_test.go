@@ -17,6 +17,10 @@
 package proc
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 
 	"time"
@@ -73,3 +77,35 @@ func TestCompareTime(t *testing.T) {
 	testTime := time.Date(2017, 8, 4, 11, 39, 23, 10000, time.UTC)
 	assert.True(t, compareTimes(testTime, testInput))
 }
+
+func TestLookupCredentialRefusesRoot(t *testing.T) {
+	_, err := lookupCredential("root")
+	assert.Error(t, err)
+}
+
+func TestGrantWorkDir(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner requires root")
+	}
+	root, err := ioutil.TempDir("", "workdir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	parent := filepath.Join(root, "parent")
+	workDir := filepath.Join(parent, "worker")
+	assert.NoError(t, os.MkdirAll(filepath.Join(workDir, "output"), 0600))
+	assert.NoError(t, os.Chmod(parent, 0700))
+
+	credential := &syscall.Credential{Uid: 65534, Gid: 65534}
+	assert.NoError(t, grantWorkDir(workDir, credential))
+
+	info, err := os.Stat(parent)
+	assert.NoError(t, err)
+	//the parent can be searched, not read
+	assert.Equal(t, os.FileMode(0701), info.Mode().Perm())
+	for _, dir := range []string{workDir, filepath.Join(workDir, "output")} {
+		info, err = os.Stat(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(65534), info.Sys().(*syscall.Stat_t).Uid)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm()&0700)
+	}
+}
@@ -30,7 +30,14 @@ var (
 )
 
 func prepareProcess(command *exec.Cmd) {
-	// nothing to do on windows
+	// start the process in its own process group, so that it is detached from the console events of the agent
+	// and survives when the agent is stopped, e.g. to be updated
+	command.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+//setProcessUser fails, Windows would need the password of the user to start the process with its token
+func setProcessUser(command *exec.Cmd, runAsUser string, workDirs []string) error {
+	return errors.New("running worker processes as another user is not supported on Windows")
 }
 
 //given the pid and the high order filetime, look up the process
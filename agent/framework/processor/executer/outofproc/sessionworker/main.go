@@ -66,7 +66,8 @@ func initialize(args []string) (context.T, string, error) {
 	}
 
 	//use argsVal1 as context name which is either channelName or dataChannelId
-	return context.Default(logger, config).With(defaultSessionWorkerContextName).With("[" + channelName + "]"),
+	//the channel is named after the session, which correlates the worker logs with the agent logs of the session
+	return context.Default(logger, config).WithCorrelationID(channelName).With(defaultSessionWorkerContextName).With("[" + channelName + "]"),
 		channelName,
 		err
 }
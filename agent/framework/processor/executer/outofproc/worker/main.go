@@ -50,7 +50,8 @@ func initialize(args []string) (context.T, string, error) {
 		logger.Errorf("failed to parse argv: %v", err)
 	}
 	//use process as context name
-	return context.Default(logger, config).With(defaultWorkerContextName).With("[" + channelName + "]"), channelName, err
+	//the channel is named after the document, which correlates the worker logs with the agent logs of the document
+	return context.Default(logger, config).WithCorrelationID(channelName).With(defaultWorkerContextName).With("[" + channelName + "]"), channelName, err
 }
 
 func main() {
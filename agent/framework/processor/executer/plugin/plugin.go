@@ -16,6 +16,7 @@
 package plugin
 
 import (
+	"fmt"
 	"runtime/debug"
 	"sync"
 
@@ -23,17 +24,25 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurecontainers"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configuredaemon"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/copyfile"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/dockercontainer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/lrpminvoker"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/refreshassociation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/rundockercontainer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/rundocument"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/runscript"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/updatessmagent"
+	"github.com/aws/amazon-ssm-agent/agent/safemode"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/filetransfer"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/interactivecommands"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/port"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
 	"github.com/aws/amazon-ssm-agent/agent/session/plugins/shell"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessiontype"
 )
 
 // allPlugins is the list of all known plugins.
@@ -42,10 +51,12 @@ import (
 var allPlugins = map[string]struct{}{
 	appconfig.PluginNameAwsAgentUpdate:         {},
 	appconfig.PluginNameAwsApplications:        {},
+	appconfig.PluginNameAwsCollectInventory:    {},
 	appconfig.PluginNameAwsConfigureDaemon:     {},
 	appconfig.PluginNameAwsConfigurePackage:    {},
 	appconfig.PluginNameAwsPowerShellModule:    {},
 	appconfig.PluginNameAwsRunPowerShellScript: {},
+	appconfig.PluginNameAwsRunPythonScript:     {},
 	appconfig.PluginNameAwsRunShellScript:      {},
 	appconfig.PluginNameAwsSoftwareInventory:   {},
 	appconfig.PluginNameCloudWatch:             {},
@@ -56,6 +67,8 @@ var allPlugins = map[string]struct{}{
 	appconfig.PluginNameRefreshAssociation:     {},
 	appconfig.PluginDownloadContent:            {},
 	appconfig.PluginRunDocument:                {},
+	appconfig.PluginCopyFile:                   {},
+	appconfig.PluginRunDockerContainer:         {},
 }
 
 var once sync.Once
@@ -63,6 +76,11 @@ var once sync.Once
 // registeredPlugins stores the registered plugins.
 var registeredPlugins *runpluginutil.PluginRegistry
 
+var customSessionPluginsLock sync.Mutex
+
+// customSessionPlugins stores the session plugins registered by custom builds.
+var customSessionPlugins = runpluginutil.PluginRegistry{}
+
 type CloudWatchFactory struct {
 }
 
@@ -77,6 +95,13 @@ func (f InventoryGathererFactory) Create(context context.T) (runpluginutil.T, er
 	return inventory.NewPlugin(context)
 }
 
+type CollectInventoryFactory struct {
+}
+
+func (f CollectInventoryFactory) Create(context context.T) (runpluginutil.T, error) {
+	return inventory.NewCollectPlugin(context)
+}
+
 type RunPowerShellFactory struct {
 }
 
@@ -84,6 +109,13 @@ func (f RunPowerShellFactory) Create(context context.T) (runpluginutil.T, error)
 	return runscript.NewRunPowerShellPlugin()
 }
 
+type RunPythonFactory struct {
+}
+
+func (f RunPythonFactory) Create(context context.T) (runpluginutil.T, error) {
+	return runscript.NewRunPythonPlugin()
+}
+
 type UpdateAgentFactory struct {
 }
 
@@ -133,6 +165,27 @@ func (r RunDocumentFactory) Create(context context.T) (runpluginutil.T, error) {
 	return rundocument.NewPlugin()
 }
 
+type CopyFileFactory struct {
+}
+
+func (f CopyFileFactory) Create(context context.T) (runpluginutil.T, error) {
+	return copyfile.NewPlugin()
+}
+
+type ConfigureDaemonFactory struct {
+}
+
+func (f ConfigureDaemonFactory) Create(context context.T) (runpluginutil.T, error) {
+	return configuredaemon.NewPlugin()
+}
+
+type RunDockerContainerFactory struct {
+}
+
+func (f RunDockerContainerFactory) Create(context context.T) (runpluginutil.T, error) {
+	return rundockercontainer.NewPlugin()
+}
+
 type SessionPluginFactory struct {
 	newPluginFunc sessionplugin.NewPluginFunc
 }
@@ -141,6 +194,24 @@ func (f SessionPluginFactory) Create(context context.T) (runpluginutil.T, error)
 	return sessionplugin.NewPlugin(f.newPluginFunc)
 }
 
+// RegisterSessionPlugin registers a custom session type, served by the plugin newPluginFunc creates,
+// for session documents of the given sessionType which set every required property.
+// Custom builds register their session types from an init function of a package linked into both the agent,
+// which parses the session documents, and the session worker, which runs the session plugins.
+func RegisterSessionPlugin(sessionType string, newPluginFunc sessionplugin.NewPluginFunc, requiredProperties ...string) error {
+	if newPluginFunc == nil {
+		return fmt.Errorf("session type %s has no plugin", sessionType)
+	}
+
+	customSessionPluginsLock.Lock()
+	defer customSessionPluginsLock.Unlock()
+	if err := sessiontype.Register(sessiontype.Definition{Name: sessionType, RequiredProperties: requiredProperties}); err != nil {
+		return err
+	}
+	customSessionPlugins[sessionType] = SessionPluginFactory{newPluginFunc}
+	return nil
+}
+
 // RegisteredWorkerPlugins returns all registered core modules.
 func RegisteredWorkerPlugins(context context.T) runpluginutil.PluginRegistry {
 
@@ -184,6 +255,16 @@ func loadWorkers(context context.T) {
 		context.Log().Infof("Successfully loaded platform dependent plugin %v", key)
 	}
 
+	// only the plugins needed to fix or update the agent are loaded while the agent is crash looping
+	if safemode.Enabled() {
+		for key := range plugins {
+			if !safemode.EssentialPlugins[key] {
+				delete(plugins, key)
+				context.Log().Warnf("Agent is in safe mode, plugin %v is not loaded", key)
+			}
+		}
+	}
+
 	registeredPlugins = &plugins
 }
 
@@ -194,6 +275,24 @@ func loadSessionPlugins() {
 	shellPluginName := appconfig.PluginNameStandardStream
 	sessionPlugins[shellPluginName] = SessionPluginFactory{shell.NewPlugin}
 
+	portPluginName := appconfig.PluginNamePort
+	sessionPlugins[portPluginName] = SessionPluginFactory{port.NewPlugin}
+
+	fileTransferPluginName := appconfig.PluginNameFileTransfer
+	sessionPlugins[fileTransferPluginName] = SessionPluginFactory{filetransfer.NewPlugin}
+
+	interactiveCommandsPluginName := appconfig.PluginNameInteractiveCommands
+	sessionPlugins[interactiveCommandsPluginName] = SessionPluginFactory{interactivecommands.NewPlugin}
+
+	nonInteractiveCommandsPluginName := appconfig.PluginNameNonInteractiveCommands
+	sessionPlugins[nonInteractiveCommandsPluginName] = SessionPluginFactory{interactivecommands.NewNonInteractivePlugin}
+
+	customSessionPluginsLock.Lock()
+	for sessionType, factory := range customSessionPlugins {
+		sessionPlugins[sessionType] = factory
+	}
+	customSessionPluginsLock.Unlock()
+
 	registeredPlugins = &sessionPlugins
 }
 
@@ -204,9 +303,15 @@ func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegis
 	inventoryPluginName := inventory.Name()
 	workerPlugins[inventoryPluginName] = InventoryGathererFactory{}
 
+	// registering aws:collectInventory plugin
+	workerPlugins[inventory.CollectPluginName()] = CollectInventoryFactory{}
+
 	// registering aws:runPowerShellScript plugin
 	workerPlugins[appconfig.PluginNameAwsRunPowerShellScript] = RunPowerShellFactory{}
 
+	// registering aws:runPythonScript plugin
+	workerPlugins[appconfig.PluginNameAwsRunPythonScript] = RunPythonFactory{}
+
 	// registering aws:updateSsmAgent plugin
 	updateAgentPluginName := updatessmagent.Name()
 	workerPlugins[updateAgentPluginName] = UpdateAgentFactory{}
@@ -236,5 +341,17 @@ func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegis
 	runDocumentPluginName := rundocument.Name()
 	workerPlugins[runDocumentPluginName] = RunDocumentFactory{}
 
+	//registering aws:copyFile
+	copyFilePluginName := copyfile.Name()
+	workerPlugins[copyFilePluginName] = CopyFileFactory{}
+
+	//registering aws:configureDaemon
+	configureDaemonPluginName := configuredaemon.Name()
+	workerPlugins[configureDaemonPluginName] = ConfigureDaemonFactory{}
+
+	//registering aws:runDockerContainer
+	runDockerContainerPluginName := rundockercontainer.Name()
+	workerPlugins[runDockerContainerPluginName] = RunDockerContainerFactory{}
+
 	return workerPlugins
 }
@@ -75,14 +75,5 @@ func loadPlatformDependentPlugins(context context.T) runpluginutil.PluginRegistr
 	updateEC2AgentPluginName := updateec2config.Name()
 	workerPlugins[updateEC2AgentPluginName] = UpdateEc2ConfigFactory{}
 
-	//// registering aws:configureDaemon
-	//configureDaemonPluginName := configuredaemon.Name()
-	//configureDaemonPlugin, err := configuredaemon.NewPlugin(pluginutil.DefaultPluginConfig())
-	//if err != nil {
-	//	log.Errorf("failed to create plugin %s %v", configureDaemonPluginName, err)
-	//} else {
-	//	workerPlugins[configureDaemonPluginName] = configureDaemonPlugin
-	//}
-
 	return workerPlugins
 }
@@ -33,3 +33,8 @@ func (m *MockedProcessor) Cancel(docState contracts.DocumentState) {
 	m.Called(docState)
 	return
 }
+
+func (m *MockedProcessor) SetCommandWorkersLimit(limit int) {
+	m.Called(limit)
+	return
+}
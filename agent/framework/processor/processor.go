@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,21 +31,47 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/policy"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
+	"github.com/aws/amazon-ssm-agent/agent/safemode"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 )
 
 type ExecuterCreator func(ctx context.T) executer.Executer
 
+// workerProcessFinder returns true if the worker process of a document is still running, overridden in tests
+var workerProcessFinder = func(log log.T, procInfo contracts.OSProcInfo) bool {
+	// pid 0 is reserved for kernel on both linux and windows, it means the worker was never started
+	return procInfo.Pid != 0 && proc.IsProcessExists(log, procInfo.Pid, procInfo.StartTime)
+}
+
+// evaluatePolicy consults the local policy before a document runs, overridden in tests
+var evaluatePolicy = policy.Evaluate
+
 const (
 
 	// hardstopTimeout is the time before the processor will be shutdown during a hardstop
 	hardStopTimeout = time.Second * 4
 )
 
+var (
+	documentsCounter = metrics.NewCounter("ssm_agent_documents_total",
+		"Documents executed by the agent, by document type and final status.", "type", "status")
+	pluginFailuresCounter = metrics.NewCounter("ssm_agent_plugin_failures_total",
+		"Plugins that failed or timed out, by plugin name.", "plugin")
+	updateAttemptsCounter = metrics.NewCounter("ssm_agent_update_attempts_total",
+		"Agent update attempts, by final status.", "status")
+	queuedDocumentsGauge = metrics.NewGauge("ssm_agent_queued_documents",
+		"Documents waiting for a worker, by pool.", "pool")
+)
+
 type Processor interface {
 	//Start activate the Processor and pick up the left over document in the last run, it returns a channel to caller to gather DocumentResult
 	Start() (chan contracts.DocumentResult, error)
@@ -56,6 +83,8 @@ type Processor interface {
 	Submit(docState contracts.DocumentState)
 	//cancel process the cancel document, with no return value since the command is already tracked in a different thread
 	Cancel(docState contracts.DocumentState)
+	//SetCommandWorkersLimit resizes the pool of the workers processing the documents, running documents are not interrupted
+	SetCommandWorkersLimit(limit int)
 	//TODO do we need to implement CancelAll?
 	//CancelAll()
 }
@@ -69,6 +98,8 @@ type EngineProcessor struct {
 	supportedDocTypes []contracts.DocumentType
 	resChan           chan contracts.DocumentResult
 	documentMgr       docmanager.DocumentMgr
+	jobQueueStore     task.JobQueueStore
+	auditLogger       audit.Logger
 }
 
 //TODO worker pool should be triggered in the Start() function
@@ -79,13 +110,28 @@ func NewEngineProcessor(ctx context.T, commandWorkerLimit int, cancelWorkerLimit
 	// so we can define the number of workers per each
 	cancelWaitDuration := 10000 * time.Millisecond
 	clock := times.DefaultClock
-	sendCommandTaskPool := task.NewPool(log, commandWorkerLimit, cancelWaitDuration, clock)
+	// persist the queue of the send command pool so that documents waiting for a worker are
+	// re-submitted in their original order on the next start
+	var jobQueueStore task.JobQueueStore
+	var sendCommandTaskPool task.Pool
+	if instanceID, err := platform.InstanceID(); err == nil {
+		jobQueueStore = task.NewFileJobQueueStore(jobQueueDir(instanceID, supportedDocs))
+		sendCommandTaskPool = task.NewPoolWithJobQueueStore(log, commandWorkerLimit, cancelWaitDuration, clock, jobQueueStore)
+	} else {
+		log.Warnf("no instanceID provided, queued documents will not be persisted, %v", err)
+		sendCommandTaskPool = task.NewPool(log, commandWorkerLimit, cancelWaitDuration, clock)
+	}
 	cancelCommandTaskPool := task.NewPool(log, cancelWorkerLimit, cancelWaitDuration, clock)
 	resChan := make(chan contracts.DocumentResult)
 	executerCreator := func(ctx context.T) executer.Executer {
 		return outofproc.NewOutOfProcExecuter(ctx)
 	}
 	documentMgr := docmanager.NewDocumentFileMgr(appconfig.DefaultDataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState)
+	if len(supportedDocs) > 0 {
+		// the processors are told apart by the first document type they support
+		queuedDocumentsGauge.SetFunc(queuedJobs(sendCommandTaskPool), string(supportedDocs[0]))
+		queuedDocumentsGauge.SetFunc(queuedJobs(cancelCommandTaskPool), string(supportedDocs[0])+"Cancel")
+	}
 	return &EngineProcessor{
 		context:           ctx.With("[EngineProcessor]"),
 		executerCreator:   executerCreator,
@@ -94,7 +140,20 @@ func NewEngineProcessor(ctx context.T, commandWorkerLimit int, cancelWorkerLimit
 		supportedDocTypes: supportedDocs,
 		resChan:           resChan,
 		documentMgr:       documentMgr,
+		jobQueueStore:     jobQueueStore,
+		auditLogger:       audit.Default(),
+	}
+}
+
+// jobQueueDir returns the directory of the persisted queue of a processor. Each processor has its own queue since
+// sortByQueueOrder discards the queued jobs the processor has no pending document for.
+func jobQueueDir(instanceID string, supportedDocs []contracts.DocumentType) string {
+	dir := docmanager.DocumentStateDir(instanceID, appconfig.DefaultLocationOfQueued)
+	if len(supportedDocs) > 0 {
+		// the processors are told apart by the first document type they support
+		dir = filepath.Join(dir, string(supportedDocs[0]))
 	}
+	return dir
 }
 
 func (p *EngineProcessor) Start() (resChan chan contracts.DocumentResult, err error) {
@@ -124,6 +183,13 @@ func (p *EngineProcessor) InitialProcessing() (err error) {
 	}
 
 	log.Info("Initial processing")
+	if safemode.Enabled() {
+		// the persisted documents may be what crashes the agent, they are set aside instead of being processed
+		log.Warn("Agent is in safe mode, moving the persisted documents to the corrupt folder instead of processing them")
+		p.quarantineDocuments(instanceID, appconfig.DefaultLocationOfCurrent)
+		p.quarantineDocuments(instanceID, appconfig.DefaultLocationOfPending)
+		return
+	}
 	//prioritize the ongoing document first
 	p.processInProgressDocuments(instanceID)
 	//deal with the pending jobs that haven't picked up by worker yet
@@ -147,40 +213,58 @@ func (p *EngineProcessor) Submit(docState contracts.DocumentState) {
 	return
 }
 
-func (p *EngineProcessor) submit(docState *contracts.DocumentState) error {
-	log := p.context.Log()
-	//TODO this is a hack, in future jobID should be managed by Processing engine itself, instead of inferring from job's internal field
-	var jobID string
+//TODO this is a hack, in future jobID should be managed by Processing engine itself, instead of inferring from job's internal field
+func getJobID(docState *contracts.DocumentState) string {
 	if docState.IsAssociation() {
-		jobID = docState.DocumentInformation.AssociationID
-	} else {
-		jobID = docState.DocumentInformation.MessageID
+		return docState.DocumentInformation.AssociationID
+	}
+	return docState.DocumentInformation.MessageID
+}
+
+// getSubmitter returns the component that submitted the given document, based on its type
+func getSubmitter(docState *contracts.DocumentState) task.Submitter {
+	switch docState.DocumentType {
+	case contracts.SendCommand, contracts.CancelCommand:
+		return task.SubmitterMDS
+	case contracts.SendCommandOffline, contracts.CancelCommandOffline:
+		return task.SubmitterOffline
+	case contracts.Association:
+		return task.SubmitterAssociation
+	case contracts.StartSession, contracts.TerminateSession:
+		return task.SubmitterMGS
+	default:
+		return task.SubmitterUnknown
 	}
-	return p.sendCommandPool.Submit(log, jobID, func(cancelFlag task.CancelFlag) {
+}
+
+// submit adds the document to the send command pool, the documents with a serialization key wait for the
+// documents submitted before with the same key
+func (p *EngineProcessor) submit(docState *contracts.DocumentState) error {
+	log := p.context.Log()
+	jobID := getJobID(docState)
+	job := func(cancelFlag task.CancelFlag) {
 		processCommand(
 			p.context,
 			p.executerCreator,
 			cancelFlag,
 			p.resChan,
 			docState,
-			p.documentMgr)
-	})
-
+			p.documentMgr,
+			p.auditLogger)
+	}
+	if docState.SerialKey != "" {
+		return p.sendCommandPool.SubmitSerialized(log, getSubmitter(docState), jobID, docState.SerialKey, job)
+	}
+	return p.sendCommandPool.SubmitFrom(log, getSubmitter(docState), jobID, job)
 }
 
 func (p *EngineProcessor) Cancel(docState contracts.DocumentState) {
 	log := p.context.Log()
-	//TODO this is a hack, in future jobID should be managed by Processing engine itself, instead of inferring from job's internal field
-	var jobID string
-	if docState.IsAssociation() {
-		jobID = docState.DocumentInformation.AssociationID
-	} else {
-		jobID = docState.DocumentInformation.MessageID
-	}
+	jobID := getJobID(&docState)
 	//queue up the pending document
 	p.documentMgr.PersistDocumentState(log, docState.DocumentInformation.DocumentID, docState.DocumentInformation.InstanceID, appconfig.DefaultLocationOfPending, docState)
 	err := p.cancelCommandPool.Submit(log, jobID, func(cancelFlag task.CancelFlag) {
-		processCancelCommand(p.context, p.sendCommandPool, &docState, p.documentMgr)
+		processCancelCommand(p.context, p.sendCommandPool, &docState, p.documentMgr, p.auditLogger)
 	})
 	if err != nil {
 		log.Error("CancelCommand failed", err)
@@ -188,6 +272,11 @@ func (p *EngineProcessor) Cancel(docState contracts.DocumentState) {
 	}
 }
 
+//SetCommandWorkersLimit resizes the send command pool, the documents being processed run to completion
+func (p *EngineProcessor) SetCommandWorkersLimit(limit int) {
+	p.sendCommandPool.Resize(limit)
+}
+
 //Stop set the cancel flags of all the running jobs, which are to be captured by the command worker and shutdown gracefully
 func (p *EngineProcessor) Stop(stopType contracts.StopType) {
 	var waitTimeout time.Duration
@@ -204,14 +293,14 @@ func (p *EngineProcessor) Stop(stopType contracts.StopType) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		p.sendCommandPool.ShutdownAndWait(waitTimeout)
+		p.sendCommandPool.ShutdownAndWait(waitTimeout).Log(p.context.Log(), "SendCommand pool")
 	}()
 
 	// shutdown the cancel command pool in a separate go routine
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		p.cancelCommandPool.ShutdownAndWait(waitTimeout)
+		p.cancelCommandPool.ShutdownAndWait(waitTimeout).Log(p.context.Log(), "CancelCommand pool")
 	}()
 
 	// wait for everything to shutdown
@@ -241,17 +330,59 @@ func (p *EngineProcessor) processPendingDocuments(instanceID string) {
 	}
 
 	//iterate through all pending messages
+	docStates := []contracts.DocumentState{}
 	for _, f := range files {
 		log.Infof("Found pending document - %v", f.Name())
 		//inspect document state
 		docState := p.documentMgr.GetDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfPending)
+		docStates = append(docStates, docState)
+	}
 
+	for _, docState := range p.sortByQueueOrder(docStates) {
 		if p.isSupportedDocumentType(docState.DocumentType) {
 			log.Infof("Processing pending document %v", docState.DocumentInformation.DocumentID)
 			p.Submit(docState)
 		}
+	}
+}
+
+// sortByQueueOrder orders the pending documents by the position of their job in the persisted queue,
+// documents that were not queued come last. Queued jobs without a pending document are discarded.
+func (p *EngineProcessor) sortByQueueOrder(docStates []contracts.DocumentState) []contracts.DocumentState {
+	if p.jobQueueStore == nil {
+		return docStates
+	}
+	log := p.context.Log()
+	queuedJobs, err := p.jobQueueStore.QueuedJobs()
+	if err != nil {
+		log.Errorf("failed to read queued jobs, %v", err)
+		return docStates
+	}
+
+	pendingJobs := make(map[string]bool, len(docStates))
+	for i := range docStates {
+		pendingJobs[getJobID(&docStates[i])] = true
+	}
+	positions := make(map[string]int, len(queuedJobs))
+	for i, jobID := range queuedJobs {
+		if !pendingJobs[jobID] {
+			log.Debugf("discarding queued job %v with no pending document", jobID)
+			p.jobQueueStore.Dequeue(jobID)
+			continue
+		}
+		positions[jobID] = i
+	}
 
+	position := func(docState *contracts.DocumentState) int {
+		if i, found := positions[getJobID(docState)]; found {
+			return i
+		}
+		return len(queuedJobs)
 	}
+	sort.SliceStable(docStates, func(i, j int) bool {
+		return position(&docStates[i]) < position(&docStates[j])
+	})
+	return docStates
 }
 
 // ProcessInProgressDocuments processes InProgress documents that have already dequeued and entered job pool
@@ -281,16 +412,22 @@ func (p *EngineProcessor) processInProgressDocuments(instanceID string) {
 		//inspect document state
 		docState := p.documentMgr.GetDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent)
 
-		retryLimit := config.Mds.CommandRetryLimit
-		if docState.DocumentInformation.RunCount >= retryLimit {
-			p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
-			continue
-		}
+		if docState.DocumentType == contracts.StartSession && workerProcessFinder(log, docState.DocumentInformation.ProcInfo) {
+			// the session worker survived the previous agent, e.g. through an update: the session is handed over to
+			// this agent by reattaching to the worker, which is not a retry of the session
+			log.Infof("Session %v is still running in worker process %v, reattaching", docState.DocumentInformation.DocumentID, docState.DocumentInformation.ProcInfo.Pid)
+		} else {
+			retryLimit := config.Mds.CommandRetryLimit
+			if docState.DocumentInformation.RunCount >= retryLimit {
+				p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, appconfig.DefaultLocationOfCurrent, appconfig.DefaultLocationOfCorrupt)
+				continue
+			}
 
-		// increment the command run count
-		docState.DocumentInformation.RunCount++
+			// increment the command run count
+			docState.DocumentInformation.RunCount++
 
-		p.documentMgr.PersistDocumentState(log, docState.DocumentInformation.DocumentID, instanceID, appconfig.DefaultLocationOfCurrent, docState)
+			p.documentMgr.PersistDocumentState(log, docState.DocumentInformation.DocumentID, instanceID, appconfig.DefaultLocationOfCurrent, docState)
+		}
 
 		if p.isSupportedDocumentType(docState.DocumentType) {
 			log.Infof("Processing in-progress document %v", docState.DocumentInformation.DocumentID)
@@ -303,6 +440,26 @@ func (p *EngineProcessor) processInProgressDocuments(instanceID string) {
 	}
 }
 
+// quarantineDocuments moves the documents of the supported types persisted in a location to the corrupt folder
+func (p *EngineProcessor) quarantineDocuments(instanceID string, location string) {
+	log := p.context.Log()
+	files, err := ioutil.ReadDir(docmanager.DocumentStateDir(instanceID, location))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		docState := p.documentMgr.GetDocumentState(log, f.Name(), instanceID, location)
+		if !p.isSupportedDocumentType(docState.DocumentType) {
+			continue
+		}
+		log.Warnf("Moving document %v to the corrupt folder", f.Name())
+		p.documentMgr.MoveDocumentState(log, f.Name(), instanceID, location, appconfig.DefaultLocationOfCorrupt)
+		if p.jobQueueStore != nil {
+			p.jobQueueStore.Dequeue(getJobID(&docState))
+		}
+	}
+}
+
 func (p *EngineProcessor) isSupportedDocumentType(documentType contracts.DocumentType) bool {
 	for _, d := range p.supportedDocTypes {
 		if documentType == d {
@@ -312,8 +469,47 @@ func (p *EngineProcessor) isSupportedDocumentType(documentType contracts.Documen
 	return false
 }
 
-func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
+// queuedJobs returns a function counting the jobs of a pool that haven't started yet
+func queuedJobs(pool task.Pool) func() float64 {
+	return func() float64 {
+		queued := 0
+		for _, job := range pool.ListJobs() {
+			if job.StartedAt.IsZero() {
+				queued++
+			}
+		}
+		return float64(queued)
+	}
+}
+
+// recordDocumentMetrics counts a finished document along with its failed plugins and agent updates
+func recordDocumentMetrics(docState *contracts.DocumentState, final *contracts.DocumentResult) {
+	documentsCounter.Inc(string(docState.DocumentType), string(final.Status))
+	for _, result := range final.PluginResults {
+		if result == nil {
+			continue
+		}
+		if result.Status == contracts.ResultStatusFailed || result.Status == contracts.ResultStatusTimedOut {
+			pluginFailuresCounter.Inc(result.PluginName)
+		}
+		if result.PluginName == appconfig.PluginNameAwsAgentUpdate {
+			updateAttemptsCounter.Inc(string(result.Status))
+		}
+	}
+}
+
+func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr, auditLogger audit.Logger) {
+	// tag all the logs of the execution, including the ones of the executer and the plugins
+	context = context.WithCorrelationID(docState.DocumentInformation.DocumentID)
 	log := context.Log()
+	// the local policy is only consulted before the first run, the resumed documents were allowed already
+	if config := context.AppConfig().Policy; policy.Enabled(config) && docState.DocumentInformation.RunCount == 0 {
+		if verdict := evaluatePolicy(log, config, policy.NewInput(docState)); !verdict.Allow {
+			denyDocument(context, resChan, docState, docMgr, auditLogger, verdict.Reason)
+			return
+		}
+	}
+	logAudit(log, auditLogger, newAuditEntry(audit.DocumentStarted, docState))
 	//persist the current running document
 	docMgr.MoveDocumentState(log,
 		docState.DocumentInformation.DocumentID,
@@ -345,6 +541,16 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 		final = &res
 	}
 	//TODO add shutdown as API call, move cancelFlag out of task pool; cancelFlag to contracts, nobody else above runplugins needs to create cancelFlag.
+	if final != nil && final.LastPlugin == "" {
+		entry := newAuditEntry(audit.DocumentFinished, docState)
+		entry.Status = string(final.Status)
+		entry.ExitCodes = make(map[string]int)
+		for pluginID, result := range final.PluginResults {
+			entry.ExitCodes[pluginID] = result.Code
+		}
+		logAudit(log, auditLogger, entry)
+		recordDocumentMetrics(docState, final)
+	}
 	// Shutdown/reboot detection
 	if final == nil || final.LastPlugin != "" {
 		log.Infof("document %v still in progress, shutting down...", messageID)
@@ -365,10 +571,53 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 
 }
 
-//TODO CancelCommand is currently treated as a special type of Command by the Processor, but in general Cancel operation should be seen as a probe to existing commands
-func processCancelCommand(context context.T, sendCommandPool task.Pool, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
+// denyDocument fails the document denied by the local policy without running it
+func denyDocument(context context.T, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr, auditLogger audit.Logger, reason string) {
+	log := context.Log()
+	docInfo := docState.DocumentInformation
+	log.Warnf("document %v was denied by the local policy: %v", docInfo.DocumentID, reason)
+	entry := newAuditEntry(audit.DocumentDenied, docState)
+	entry.Reason = reason
+	logAudit(log, auditLogger, entry)
+
+	now := time.Now()
+	result := contracts.DocumentResult{
+		DocumentName:    docInfo.DocumentName,
+		DocumentVersion: docInfo.DocumentVersion,
+		MessageID:       docInfo.MessageID,
+		AssociationID:   docInfo.AssociationID,
+		PluginResults:   make(map[string]*contracts.PluginResult),
+		Status:          contracts.ResultStatusFailed,
+		NPlugins:        len(docState.InstancePluginsInformation),
+	}
+	for _, plugin := range docState.InstancePluginsInformation {
+		result.PluginResults[plugin.Id] = &contracts.PluginResult{
+			PluginID:      plugin.Id,
+			PluginName:    plugin.Name,
+			Status:        contracts.ResultStatusFailed,
+			Code:          1,
+			Output:        "Denied by the local policy: " + reason,
+			StartDateTime: now,
+			EndDateTime:   now,
+		}
+	}
+	recordDocumentMetrics(docState, &result)
+	resChan <- result
+
+	docMgr.RemoveDocumentState(log,
+		docInfo.DocumentID,
+		docInfo.InstanceID,
+		appconfig.DefaultLocationOfPending)
+}
 
+//TODO CancelCommand is currently treated as a special type of Command by the Processor, but in general Cancel operation should be seen as a probe to existing commands
+func processCancelCommand(context context.T, sendCommandPool task.Pool, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr, auditLogger audit.Logger) {
+	// tag the logs of the cancellation with the id of the canceled document
+	context = context.WithCorrelationID(docState.CancelInformation.CancelCommandID)
 	log := context.Log()
+	entry := newAuditEntry(audit.DocumentCancelRequested, docState)
+	entry.CommandID = docState.CancelInformation.CancelCommandID
+	logAudit(log, auditLogger, entry)
 	//persist the final status of cancel-message in current folder
 	docMgr.MoveDocumentState(log,
 		docState.DocumentInformation.DocumentID,
@@ -395,6 +644,33 @@ func processCancelCommand(context context.T, sendCommandPool task.Pool, docState
 
 }
 
+// newAuditEntry creates an audit entry of the given event for the document.
+func newAuditEntry(event audit.EventType, docState *contracts.DocumentState) audit.Entry {
+	docInfo := docState.DocumentInformation
+	entry := audit.Entry{
+		Event:         event,
+		DocumentType:  string(docState.DocumentType),
+		DocumentName:  docInfo.DocumentName,
+		CommandID:     docInfo.CommandID,
+		AssociationID: docInfo.AssociationID,
+		Requester:     docInfo.ClientId,
+	}
+	if docState.DocumentType == contracts.StartSession {
+		entry.SessionID = docInfo.DocumentID
+	}
+	return entry
+}
+
+// logAudit records the entry in the audit log, failures are only reported in the diagnostic log.
+func logAudit(log log.T, auditLogger audit.Logger, entry audit.Entry) {
+	if auditLogger == nil {
+		return
+	}
+	if err := auditLogger.Log(entry); err != nil {
+		log.Warnf("failed to write audit log entry %v: %v", entry.Event, err)
+	}
+}
+
 //TODO remove this once CloudWatch plugin is reworked
 //temporary solution on plugins with shared responsibility with agent
 func handleCloudwatchPlugin(context context.T, pluginResults map[string]*contracts.PluginResult, documentID string) {
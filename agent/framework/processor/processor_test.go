@@ -15,6 +15,8 @@
 package processor
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"fmt"
@@ -25,6 +27,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
+	"github.com/aws/amazon-ssm-agent/agent/policy"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -38,7 +42,7 @@ func TestEngineProcessor_Submit(t *testing.T) {
 	creator := func(ctx context.T) executer.Executer {
 		return executerMock
 	}
-	sendCommandPoolMock.On("Submit", ctx.Log(), "messageID", mock.Anything).Return(nil)
+	sendCommandPoolMock.On("SubmitFrom", ctx.Log(), task.SubmitterMDS, "messageID", mock.Anything).Return(nil)
 	docMock := new(DocumentMgrMock)
 	processor := EngineProcessor{
 		executerCreator: creator,
@@ -46,13 +50,30 @@ func TestEngineProcessor_Submit(t *testing.T) {
 		context:         ctx,
 		documentMgr:     docMock,
 	}
-	docState := contracts.DocumentState{}
+	docState := contracts.DocumentState{DocumentType: contracts.SendCommand}
 	docState.DocumentInformation.MessageID = "messageID"
 	docMock.On("PersistDocumentState", mock.Anything, mock.Anything, mock.Anything, appconfig.DefaultLocationOfPending, docState)
 	processor.Submit(docState)
 	sendCommandPoolMock.AssertExpectations(t)
 }
 
+func TestEngineProcessor_SubmitSerialized(t *testing.T) {
+	sendCommandPoolMock := new(task.MockedPool)
+	ctx := context.NewMockDefault()
+	sendCommandPoolMock.On("SubmitSerialized", ctx.Log(), task.SubmitterAssociation, "associationID", "group", mock.Anything).Return(nil)
+	docMock := new(DocumentMgrMock)
+	processor := EngineProcessor{
+		sendCommandPool: sendCommandPoolMock,
+		context:         ctx,
+		documentMgr:     docMock,
+	}
+	docState := contracts.DocumentState{DocumentType: contracts.Association, SerialKey: "group"}
+	docState.DocumentInformation.AssociationID = "associationID"
+	docMock.On("PersistDocumentState", mock.Anything, mock.Anything, mock.Anything, appconfig.DefaultLocationOfPending, docState)
+	processor.Submit(docState)
+	sendCommandPoolMock.AssertExpectations(t)
+}
+
 func TestEngineProcessor_Cancel(t *testing.T) {
 	cancelCommandPoolMock := new(task.MockedPool)
 	ctx := context.NewMockDefault()
@@ -87,13 +108,54 @@ func TestEngineProcessor_Stop(t *testing.T) {
 		context:           ctx,
 		resChan:           resChan,
 	}
-	sendCommandPoolMock.On("ShutdownAndWait", mock.AnythingOfType("time.Duration")).Return(true)
-	cancelCommandPoolMock.On("ShutdownAndWait", mock.AnythingOfType("time.Duration")).Return(true)
+	sendCommandPoolMock.On("ShutdownAndWait", mock.AnythingOfType("time.Duration")).Return(task.ShutdownReport{Finished: true})
+	cancelCommandPoolMock.On("ShutdownAndWait", mock.AnythingOfType("time.Duration")).Return(task.ShutdownReport{Finished: true})
 	processor.Stop(contracts.StopTypeSoftStop)
 	sendCommandPoolMock.AssertExpectations(t)
 	cancelCommandPoolMock.AssertExpectations(t)
 }
 
+func TestEngineProcessor_SortByQueueOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queued")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := task.NewFileJobQueueStore(dir)
+	store.Enqueue("second")
+	store.Enqueue("stale")
+	store.Enqueue("first")
+	store.Dequeue("first")
+	store.Enqueue("first")
+
+	processor := EngineProcessor{
+		context:       context.NewMockDefault(),
+		jobQueueStore: store,
+	}
+	docStates := make([]contracts.DocumentState, 3)
+	docStates[0].DocumentInformation.MessageID = "unqueued"
+	docStates[1].DocumentInformation.MessageID = "first"
+	docStates[2].DocumentInformation.MessageID = "second"
+
+	sorted := processor.sortByQueueOrder(docStates)
+	assert.Equal(t, "second", sorted[0].DocumentInformation.MessageID)
+	assert.Equal(t, "first", sorted[1].DocumentInformation.MessageID)
+	assert.Equal(t, "unqueued", sorted[2].DocumentInformation.MessageID)
+
+	// queued jobs without pending documents are discarded
+	queued, err := store.QueuedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second", "first"}, queued)
+}
+
+func TestJobQueueDirPerProcessor(t *testing.T) {
+	commandDir := jobQueueDir("i-1234", []contracts.DocumentType{contracts.SendCommand, contracts.CancelCommand})
+	associationDir := jobQueueDir("i-1234", []contracts.DocumentType{contracts.Association})
+	sessionDir := jobQueueDir("i-1234", []contracts.DocumentType{contracts.StartSession, contracts.TerminateSession})
+
+	assert.NotEqual(t, commandDir, associationDir)
+	assert.NotEqual(t, commandDir, sessionDir)
+	assert.NotEqual(t, associationDir, sessionDir)
+}
+
 //TODO add shutdown and reboot test once we encapsulate docmanager
 func TestProcessCommand(t *testing.T) {
 	ctx := context.NewMockDefault()
@@ -132,12 +194,19 @@ func TestProcessCommand(t *testing.T) {
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", mock.Anything, "documentID", "instanceID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
 	docMock.On("RemoveDocumentState", mock.Anything, "documentID", "instanceID", appconfig.DefaultLocationOfCurrent)
-	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock)
+	auditMock := audit.NewMockedLogger()
+	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock, auditMock)
 	executerMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
 	close(resChan)
 	//assert channel is not closed, each instance of Processor keeps a distinct copy of channel
 	assert.NotNil(t, resChan)
+	//assert the start and the completion of the document are audited
+	assert.Len(t, auditMock.Calls, 2)
+	assert.Equal(t, audit.DocumentStarted, auditMock.Calls[0].Arguments.Get(0).(audit.Entry).Event)
+	finished := auditMock.Calls[1].Arguments.Get(0).(audit.Entry)
+	assert.Equal(t, audit.DocumentFinished, finished.Event)
+	assert.Equal(t, string(contracts.ResultStatusSuccess), finished.Status)
 
 }
 
@@ -165,16 +234,64 @@ func TestProcessCommand_Shutdown(t *testing.T) {
 	}()
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", mock.Anything, "documentID", "instanceID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
-	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock)
+	auditMock := audit.NewMockedLogger()
+	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock, auditMock)
 	executerMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
 	close(resChan)
 	//assert channel is not closed, each instance of Processor keeps a distinct copy of channel
 	assert.NotNil(t, resChan)
+	//assert the document is not audited as finished
+	assert.Len(t, auditMock.Calls, 1)
 	//TODO assert document file is not moved
 
 }
 
+func TestProcessCommand_DeniedByPolicy(t *testing.T) {
+	config := appconfig.SsmagentConfig{}
+	config.Policy.RulesPath = "rules.json"
+	ctx := new(context.Mock)
+	ctx.On("Log").Return(log.NewMockLog())
+	ctx.On("AppConfig").Return(config)
+	ctx.On("WithCorrelationID", mock.AnythingOfType("string")).Return(ctx)
+	docState := contracts.DocumentState{
+		InstancePluginsInformation: []contracts.PluginState{{Id: "step1", Name: "aws:runShellScript"}},
+	}
+	docState.DocumentInformation.MessageID = "messageID"
+	docState.DocumentInformation.InstanceID = "instanceID"
+	docState.DocumentInformation.DocumentID = "documentID"
+	docState.DocumentInformation.DocumentName = "documentName"
+	evaluatePolicy = func(log log.T, config appconfig.PolicyCfg, input policy.Input) policy.Verdict {
+		assert.Equal(t, "rules.json", config.RulesPath)
+		assert.Equal(t, "documentName", input.DocumentName)
+		return policy.Verdict{Reason: "change freeze"}
+	}
+	defer func() { evaluatePolicy = policy.Evaluate }()
+
+	//the executer is never created
+	creator := func(ctx context.T) executer.Executer {
+		assert.Fail(t, "the denied document must not run")
+		return nil
+	}
+	resChan := make(chan contracts.DocumentResult, 1)
+	docMock := new(DocumentMgrMock)
+	docMock.On("RemoveDocumentState", mock.Anything, "documentID", "instanceID", appconfig.DefaultLocationOfPending)
+	auditMock := audit.NewMockedLogger()
+	processCommand(ctx, creator, task.NewChanneledCancelFlag(), resChan, &docState, docMock, auditMock)
+	docMock.AssertExpectations(t)
+
+	result := <-resChan
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, "", result.LastPlugin)
+	assert.Equal(t, "messageID", result.MessageID)
+	assert.Equal(t, "Denied by the local policy: change freeze", result.PluginResults["step1"].Output)
+	//assert the denial is audited with its reason
+	assert.Len(t, auditMock.Calls, 1)
+	denied := auditMock.Calls[0].Arguments.Get(0).(audit.Entry)
+	assert.Equal(t, audit.DocumentDenied, denied.Event)
+	assert.Equal(t, "change freeze", denied.Reason)
+}
+
 func TestProcessCancelCommand_Success(t *testing.T) {
 	ctx := context.NewMockDefault()
 	sendCommandPoolMock := new(task.MockedPool)
@@ -184,11 +301,24 @@ func TestProcessCancelCommand_Success(t *testing.T) {
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", mock.Anything, "", "", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
 	docMock.On("RemoveDocumentState", mock.Anything, "", "", appconfig.DefaultLocationOfCurrent, mock.Anything)
-	processCancelCommand(ctx, sendCommandPoolMock, &docState, docMock)
+	auditMock := audit.NewMockedLogger()
+	processCancelCommand(ctx, sendCommandPoolMock, &docState, docMock, auditMock)
 	sendCommandPoolMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
 	assert.Equal(t, docState.DocumentInformation.DocumentStatus, contracts.ResultStatusSuccess)
+	auditMock.AssertCalled(t, "Log", mock.MatchedBy(func(entry audit.Entry) bool {
+		return entry.Event == audit.DocumentCancelRequested
+	}))
+
+}
 
+func TestLogAudit_FailureIsLogged(t *testing.T) {
+	logger := log.NewCapturingLogger()
+	auditMock := new(audit.MockedLogger)
+	auditMock.On("Log", mock.Anything).Return(fmt.Errorf("disk full"))
+	logAudit(logger, auditMock, audit.Entry{Event: audit.DocumentStarted})
+	auditMock.AssertExpectations(t)
+	assert.True(t, logger.ContainsWarning("DocumentStarted: disk full"))
 }
 
 type DocumentMgrMock struct {
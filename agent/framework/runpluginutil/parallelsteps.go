@@ -0,0 +1,155 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/times"
+)
+
+const (
+	// parallelStepsCancelWaitDuration is how long the pool of a parallel group waits for its workers to exit
+	parallelStepsCancelWaitDuration = 10 * time.Second
+
+	// cancelPollInterval is how often the cancel flag of the command is checked while a parallel group runs
+	cancelPollInterval = 100 * time.Millisecond
+)
+
+// runParallelSteps runs the steps of a parallel group at the same time on a pool of workers and returns once
+// all of them completed, it returns true if one of the steps requested a reboot. A step failing with onFailure
+// exit cancels the other steps of the group still running, the steps that have not started are skipped.
+func runParallelSteps(
+	context context.T,
+	steps []contracts.PluginState,
+	ioConfig contracts.IOConfiguration,
+	logStreamPrefix string,
+	registry PluginRegistry,
+	resChan chan contracts.PluginResult,
+	cancelFlag task.CancelFlag,
+	pluginOutputs map[string]*contracts.PluginResult) (reboot bool) {
+
+	log := context.Log()
+	group := steps[0].Configuration.ParallelGroup
+	limit := parallelStepsLimit(log, len(steps))
+	log.Infof("Running %v steps of parallel group %v, %v at a time", len(steps), group, limit)
+
+	pool := task.NewPool(log, limit, parallelStepsCancelWaitDuration, times.DefaultClock)
+	defer pool.ShutdownAndWait(parallelStepsCancelWaitDuration)
+
+	// each step has its own cancel flag so that a failing step can cancel the others
+	stepFlags := make(map[string]task.CancelFlag, len(steps))
+	for _, step := range steps {
+		stepFlags[step.Id] = task.NewChanneledCancelFlag()
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go propagateCancelFlag(cancelFlag, stepFlags, done)
+
+	var (
+		wg         sync.WaitGroup
+		mutex      sync.Mutex
+		exitStepID string
+	)
+	for _, step := range steps {
+		step := step
+		pluginOutput := pluginOutputs[step.Id]
+		job := func(task.CancelFlag) {
+			defer wg.Done()
+			mutex.Lock()
+			failedStepID := exitStepID
+			mutex.Unlock()
+			if failedStepID != "" {
+				skipStepAfterExit(context, step.Id, failedStepID, pluginOutput, resChan)
+				return
+			}
+
+			stepReboot := runStep(context, step, ioConfig, logStreamPrefix, registry, resChan, stepFlags[step.Id], pluginOutput)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			reboot = reboot || stepReboot
+			if exitStepID == "" && isExitOnFailure(step.Configuration, pluginOutput.Status) {
+				log.Infof("Step %v of parallel group %v failed, canceling the other steps of the group", step.Id, group)
+				exitStepID = step.Id
+				for stepID, stepFlag := range stepFlags {
+					if stepID != step.Id {
+						stepFlag.Set(task.Canceled)
+					}
+				}
+			}
+		}
+
+		wg.Add(1)
+		if err := pool.Submit(log, step.Id, job); err != nil {
+			wg.Done()
+			err = fmt.Errorf("failed to schedule step %v of parallel group %v: %v", step.Id, group, err)
+			log.Error(err)
+			pluginOutput.Status = contracts.ResultStatusFailed
+			pluginOutput.Error = err.Error()
+			sendPluginResult(context, pluginOutput, resChan)
+		}
+	}
+	wg.Wait()
+	return
+}
+
+// parallelStepsLimit returns the number of steps of a parallel group running at the same time
+func parallelStepsLimit(log log.T, steps int) int {
+	limit := appconfig.DefaultParallelStepsLimit
+	if config, err := appconfig.Config(false); err == nil {
+		limit = config.Ssm.ParallelStepsLimit
+	} else {
+		log.Warnf("Failed to load the agent configuration, running %v steps at a time: %v", limit, err)
+	}
+	if steps < limit {
+		return steps
+	}
+	return limit
+}
+
+// propagateCancelFlag sets the cancel flags of the steps of a parallel group when the command is canceled or
+// the agent shuts down, until done is closed.
+func propagateCancelFlag(cancelFlag task.CancelFlag, stepFlags map[string]task.CancelFlag, done chan struct{}) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		var state task.State
+		switch {
+		case cancelFlag.ShutDown():
+			state = task.ShutDown
+		case cancelFlag.Canceled():
+			state = task.Canceled
+		default:
+			continue
+		}
+		for _, stepFlag := range stepFlags {
+			stepFlag.Set(state)
+		}
+		return
+	}
+}
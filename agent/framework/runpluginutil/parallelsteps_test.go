@@ -0,0 +1,178 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newParallelTestContext returns a context which the steps running at the same time can share,
+// unlike the context mock
+func newParallelTestContext() context.T {
+	logger := log.NewMockLog()
+	logger.On("WithContext", mock.Anything).Return(logger)
+	return context.Default(logger, appconfig.DefaultConfig())
+}
+
+// newStepState returns the state of a step of a parallel group executed by a plugin running execute
+func newStepState(dir string, name string, group string, onFailure string, registry PluginRegistry, execute func(cancelFlag task.CancelFlag, output iohandler.IOHandler)) contracts.PluginState {
+	plugin := new(PluginMock)
+	plugin.On("Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		execute(args.Get(2).(task.CancelFlag), args.Get(3).(iohandler.IOHandler))
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(plugin, nil)
+	registry[name] = pluginFactory
+
+	return contracts.PluginState{
+		Name: name,
+		Id:   name,
+		Configuration: contracts.Configuration{
+			PluginID:               name,
+			PluginName:             name,
+			OrchestrationDirectory: filepath.Join(dir, name),
+			ParallelGroup:          group,
+			OnFailure:              onFailure,
+		},
+	}
+}
+
+// TestRunPluginsParallelGroup tests the steps of a parallel group run at the same time.
+func TestRunPluginsParallelGroup(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	dir, err := ioutil.TempDir("", "parallelsteps")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// each step of the group waits for the other one to start
+	var started sync.WaitGroup
+	started.Add(2)
+	waitForGroup := func(cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+		started.Done()
+		waited := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+			output.MarkAsSucceeded()
+		case <-time.After(5 * time.Second):
+			output.MarkAsFailed(nil)
+		}
+	}
+
+	var order []string
+	var orderMutex sync.Mutex
+	record := func(name string) func(task.CancelFlag, iohandler.IOHandler) {
+		return func(cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+			orderMutex.Lock()
+			order = append(order, name)
+			orderMutex.Unlock()
+			output.MarkAsSucceeded()
+		}
+	}
+
+	registry := PluginRegistry{}
+	steps := []contracts.PluginState{
+		newStepState(dir, "first", "", "", registry, record("first")),
+		newStepState(dir, "web", "configure", "", registry, waitForGroup),
+		newStepState(dir, "database", "configure", "", registry, waitForGroup),
+		newStepState(dir, "last", "", "", registry, record("last")),
+	}
+
+	ch := make(chan contracts.PluginResult, len(steps))
+	outputs := RunPlugins(newParallelTestContext(), steps, contracts.IOConfiguration{OrchestrationDirectory: dir}, registry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	for _, step := range steps {
+		assert.Equal(t, contracts.ResultStatusSuccess, outputs[step.Id].Status, step.Id)
+	}
+	assert.Equal(t, []string{"first", "last"}, order)
+	assert.Len(t, ch, len(steps))
+}
+
+// TestRunPluginsParallelGroupExitOnFailure tests a step failing with onFailure exit cancels the other steps of
+// its group and skips the following steps.
+func TestRunPluginsParallelGroupExitOnFailure(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	dir, err := ioutil.TempDir("", "parallelsteps")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fail := func(cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+		output.MarkAsFailed(nil)
+	}
+	waitForCancel := func(cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+		canceled := make(chan struct{})
+		go func() {
+			cancelFlag.Wait()
+			close(canceled)
+		}()
+		select {
+		case <-canceled:
+			output.MarkAsCancelled()
+		case <-time.After(5 * time.Second):
+			output.MarkAsSucceeded()
+		}
+	}
+	succeed := func(cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+		output.MarkAsSucceeded()
+	}
+
+	registry := PluginRegistry{}
+	steps := []contracts.PluginState{
+		newStepState(dir, "web", "configure", contracts.OnFailureExit, registry, fail),
+		newStepState(dir, "database", "configure", "", registry, waitForCancel),
+		newStepState(dir, "last", "", "", registry, succeed),
+	}
+
+	ch := make(chan contracts.PluginResult, len(steps))
+	outputs := RunPlugins(newParallelTestContext(), steps, contracts.IOConfiguration{OrchestrationDirectory: dir}, registry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["web"].Status)
+	assert.Equal(t, contracts.ResultStatusCancelled, outputs["database"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["last"].Status)
+	assert.Contains(t, outputs["last"].Output, "step web failed")
+	assert.Len(t, ch, len(steps))
+}
+
+func TestNextStepGroup(t *testing.T) {
+	steps := []contracts.PluginState{
+		{Id: "a"},
+		{Id: "b", Configuration: contracts.Configuration{ParallelGroup: "g"}},
+		{Id: "c", Configuration: contracts.Configuration{ParallelGroup: "g"}},
+		{Id: "d", Configuration: contracts.Configuration{ParallelGroup: "h"}},
+	}
+	assert.Equal(t, steps[0:1], nextStepGroup(steps, 0))
+	assert.Equal(t, steps[1:3], nextStepGroup(steps, 1))
+	assert.Equal(t, steps[3:4], nextStepGroup(steps, 3))
+}
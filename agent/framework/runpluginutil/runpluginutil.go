@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -42,6 +43,12 @@ type T interface {
 	Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler)
 }
 
+// Checker is implemented by the plugins supporting the report-only mode of associations,
+// Check returns the changes Execute would apply without applying them.
+type Checker interface {
+	Check(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) (changes []string)
+}
+
 type PluginFactory interface {
 	Create(context context.T) (T, error)
 }
@@ -57,10 +64,12 @@ var SSMPluginRegistry PluginRegistry
 var allPlugins = map[string]struct{}{
 	appconfig.PluginNameAwsAgentUpdate:         {},
 	appconfig.PluginNameAwsApplications:        {},
+	appconfig.PluginNameAwsCollectInventory:    {},
 	appconfig.PluginNameAwsConfigureDaemon:     {},
 	appconfig.PluginNameAwsConfigurePackage:    {},
 	appconfig.PluginNameAwsPowerShellModule:    {},
 	appconfig.PluginNameAwsRunPowerShellScript: {},
+	appconfig.PluginNameAwsRunPythonScript:     {},
 	appconfig.PluginNameAwsRunShellScript:      {},
 	appconfig.PluginNameAwsSoftwareInventory:   {},
 	appconfig.PluginNameCloudWatch:             {},
@@ -71,11 +80,8 @@ var allPlugins = map[string]struct{}{
 	appconfig.PluginNameRefreshAssociation:     {},
 	appconfig.PluginDownloadContent:            {},
 	appconfig.PluginRunDocument:                {},
-}
-
-// allSessionPlugins is the list of all known session plugins.
-var allSessionPlugins = map[string]struct{}{
-	appconfig.PluginNameStandardStream: {},
+	appconfig.PluginCopyFile:                   {},
+	appconfig.PluginRunDockerContainer:         {},
 }
 
 // Assign method to global variables to allow unittest to override
@@ -99,125 +105,299 @@ func RunPlugins(
 	//Contains the logStreamPrefix without the pluginID
 	logStreamPrefix := ioConfig.CloudWatchConfig.LogStreamPrefix
 
-	for _, pluginState := range plugins {
-		pluginID := pluginState.Id     // the identifier of the plugin
-		pluginName := pluginState.Name // the name of the plugin
-		pluginOutput := pluginState.Result
-		pluginOutput.PluginID = pluginID
-		pluginOutput.PluginName = pluginName
-		pluginOutputs[pluginID] = &pluginOutput
-		switch pluginOutput.Status {
-		//TODO properly initialize the plugin status
-		case "":
-			context.Log().Debugf("plugin - %v has empty state, initialize as NotStarted",
-				pluginName)
-			pluginOutput.StartDateTime = time.Now()
-			pluginOutput.Status = contracts.ResultStatusNotStarted
-
-		case contracts.ResultStatusNotStarted, contracts.ResultStatusInProgress:
-			context.Log().Debugf("plugin - %v status %v",
-				pluginName,
-				pluginOutput.Status)
-			pluginOutput.StartDateTime = time.Now()
+	// exitStepID is the step which failed with onFailure exit, the steps following it are skipped
+	var exitStepID string
+	for index := 0; index < len(plugins); {
+		steps := nextStepGroup(plugins, index)
+		index += len(steps)
 
-		case contracts.ResultStatusSuccessAndReboot:
-			context.Log().Debugf("plugin - %v just experienced reboot, reset to InProgress...",
-				pluginName)
-			pluginOutput.Status = contracts.ResultStatusInProgress
+		var pendingSteps []contracts.PluginState
+		for _, pluginState := range steps {
+			if initializePluginOutput(context, pluginState, pluginOutputs) {
+				pendingSteps = append(pendingSteps, pluginState)
+			}
+		}
 
-		default:
-			context.Log().Debugf("plugin - %v already executed, skipping...",
-				pluginName)
+		if exitStepID != "" {
+			for _, pluginState := range pendingSteps {
+				skipStepAfterExit(context, pluginState.Id, exitStepID, pluginOutputs[pluginState.Id], resChan)
+			}
 			continue
 		}
 
-		context.Log().Debugf("Executing plugin - %v", pluginName)
-
-		// populate plugin start time and status
-		configuration := pluginState.Configuration
-
-		if ioConfig.OutputS3BucketName != "" {
-			pluginOutputs[pluginID].OutputS3BucketName = ioConfig.OutputS3BucketName
-			if ioConfig.OutputS3KeyPrefix != "" {
-				pluginOutputs[pluginID].OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, pluginName)
+		var reboot bool
+		if len(pendingSteps) > 1 {
+			reboot = runParallelSteps(context, pendingSteps, ioConfig, logStreamPrefix, registry, resChan, cancelFlag, pluginOutputs)
+		} else if len(pendingSteps) == 1 {
+			pluginState := pendingSteps[0]
+			reboot = runStep(context, pluginState, ioConfig, logStreamPrefix, registry, resChan, cancelFlag, pluginOutputs[pluginState.Id])
+		}
 
+		for _, pluginState := range pendingSteps {
+			if exitStepID == "" && isExitOnFailure(pluginState.Configuration, pluginOutputs[pluginState.Id].Status) {
+				context.Log().Infof("Step %v failed with onFailure %v, skipping the following steps", pluginState.Id, contracts.OnFailureExit)
+				exitStepID = pluginState.Id
 			}
 		}
-		//Append pluginID to logStreamPrefix. Replace ':' or '*' with '-' since LogStreamNames cannot have those characters
-		if ioConfig.CloudWatchConfig.LogGroupName != "" {
-			ioConfig.CloudWatchConfig.LogStreamPrefix = fmt.Sprintf("%s/%s", logStreamPrefix, pluginID)
-			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, ":", "-", -1)
-			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, "*", "-", -1)
+
+		//TODO handle cancelFlag here
+		if reboot {
+			// do not execute the the next plugin
+			break
+		}
+	}
+
+	return
+}
+
+// nextStepGroup returns the steps starting at index which run together: the consecutive steps of a parallel
+// group, or the step alone when it is not part of a group.
+func nextStepGroup(plugins []contracts.PluginState, index int) []contracts.PluginState {
+	group := plugins[index].Configuration.ParallelGroup
+	end := index + 1
+	if group != "" {
+		for end < len(plugins) && plugins[end].Configuration.ParallelGroup == group {
+			end++
 		}
+	}
+	return plugins[index:end]
+}
 
-		var (
-			r                  contracts.PluginResult
-			pluginFactory      PluginFactory
-			pluginHandlerFound bool
-			isKnown            bool
-			isSupported        bool
-		)
-
-		pluginFactory, pluginHandlerFound = registry[pluginName]
-		isKnown, isSupported, _ = isSupportedPlugin(context.Log(), pluginName)
-		operation, logMessage := getStepExecutionOperation(
-			context.Log(),
+// initializePluginOutput adds the output of the plugin to pluginOutputs from its state,
+// it returns false if the plugin already executed.
+func initializePluginOutput(context context.T, pluginState contracts.PluginState, pluginOutputs map[string]*contracts.PluginResult) bool {
+	pluginID := pluginState.Id     // the identifier of the plugin
+	pluginName := pluginState.Name // the name of the plugin
+	pluginOutput := pluginState.Result
+	pluginOutput.PluginID = pluginID
+	pluginOutput.PluginName = pluginName
+	pluginOutput.ComplianceSeverity = pluginState.Configuration.ComplianceSeverity
+	pluginOutputs[pluginID] = &pluginOutput
+	switch pluginOutput.Status {
+	//TODO properly initialize the plugin status
+	case "":
+		context.Log().Debugf("plugin - %v has empty state, initialize as NotStarted",
+			pluginName)
+		pluginOutput.StartDateTime = time.Now()
+		pluginOutput.Status = contracts.ResultStatusNotStarted
+
+	case contracts.ResultStatusNotStarted, contracts.ResultStatusInProgress:
+		context.Log().Debugf("plugin - %v status %v",
 			pluginName,
-			pluginID,
-			isKnown,
-			isSupported,
-			pluginHandlerFound,
-			configuration.IsPreconditionEnabled,
-			configuration.Preconditions)
-
-		switch operation {
-		case executeStep:
-			context.Log().Infof("Running plugin %s", pluginName)
-			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
-			pluginOutputs[pluginID].Code = r.Code
-			pluginOutputs[pluginID].Status = r.Status
-			pluginOutputs[pluginID].Error = r.Error
-			pluginOutputs[pluginID].Output = r.Output
-			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
-			pluginOutputs[pluginID].StandardError = r.StandardError
-
-		case skipStep:
-			context.Log().Info(logMessage)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusSkipped
-			pluginOutputs[pluginID].Code = 0
-			pluginOutputs[pluginID].Output = logMessage
-		case failStep:
-			err := fmt.Errorf(logMessage)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
-			pluginOutputs[pluginID].Error = err.Error()
-			context.Log().Error(err)
-		default:
-			err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
-			pluginOutputs[pluginID].Error = err.Error()
-			context.Log().Error(err)
+			pluginOutput.Status)
+		pluginOutput.StartDateTime = time.Now()
+
+	case contracts.ResultStatusSuccessAndReboot:
+		context.Log().Debugf("plugin - %v just experienced reboot, reset to InProgress...",
+			pluginName)
+		pluginOutput.Status = contracts.ResultStatusInProgress
+
+	default:
+		context.Log().Debugf("plugin - %v already executed, skipping...",
+			pluginName)
+		return false
+	}
+	return true
+}
+
+// runStep executes one plugin and sends its result, it returns true if the plugin requested a reboot.
+func runStep(
+	context context.T,
+	pluginState contracts.PluginState,
+	ioConfig contracts.IOConfiguration,
+	logStreamPrefix string,
+	registry PluginRegistry,
+	resChan chan contracts.PluginResult,
+	cancelFlag task.CancelFlag,
+	pluginOutput *contracts.PluginResult) (reboot bool) {
+
+	pluginID := pluginState.Id
+	pluginName := pluginState.Name
+	context.Log().Debugf("Executing plugin - %v", pluginName)
+
+	// populate plugin start time and status
+	configuration := pluginState.Configuration
+
+	if ioConfig.OutputS3BucketName != "" {
+		pluginOutput.OutputS3BucketName = ioConfig.OutputS3BucketName
+		if ioConfig.OutputS3KeyPrefix != "" {
+			pluginOutput.OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, pluginName)
+
+		}
+	}
+	//Append pluginID to logStreamPrefix. Replace ':' or '*' with '-' since LogStreamNames cannot have those characters
+	if ioConfig.CloudWatchConfig.LogGroupName != "" {
+		ioConfig.CloudWatchConfig.LogStreamPrefix = fmt.Sprintf("%s/%s", logStreamPrefix, pluginID)
+		ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, ":", "-", -1)
+		ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, "*", "-", -1)
+	}
+	ioConfig.OutputFilter = configuration.OutputFilter
+
+	var (
+		r                  contracts.PluginResult
+		pluginFactory      PluginFactory
+		pluginHandlerFound bool
+		isKnown            bool
+		isSupported        bool
+	)
+
+	pluginFactory, pluginHandlerFound = registry[pluginName]
+	isKnown, isSupported, _ = isSupportedPlugin(context.Log(), pluginName)
+	operation, logMessage := getStepExecutionOperation(
+		context.Log(),
+		pluginName,
+		pluginID,
+		isKnown,
+		isSupported,
+		pluginHandlerFound,
+		configuration.IsPreconditionEnabled,
+		configuration.Preconditions)
+
+	switch operation {
+	case executeStep:
+		context.Log().Infof("Running plugin %s", pluginName)
+		r = runPluginWithRetries(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
+		pluginOutput.Code = r.Code
+		pluginOutput.Status = r.Status
+		pluginOutput.Error = r.Error
+		pluginOutput.Output = r.Output
+		pluginOutput.StandardOutput = r.StandardOutput
+		pluginOutput.StandardError = r.StandardError
+		pluginOutput.StructuredOutput = r.StructuredOutput
+		pluginOutput.Drift = r.Drift
+		pluginOutput.Attempts = r.Attempts
+		pluginOutput.OutputDirectory = r.OutputDirectory
+		if r.Attempts > 1 && pluginOutput.OutputS3KeyPrefix != "" {
+			pluginOutput.OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, attemptDirectory(r.Attempts), pluginName)
 		}
 
-		// set end time.
-		pluginOutputs[pluginID].EndDateTime = time.Now()
-		context.Log().Infof("Sending plugin %v completion message", pluginID)
+	case skipStep:
+		context.Log().Info(logMessage)
+		pluginOutput.Status = contracts.ResultStatusSkipped
+		pluginOutput.Code = 0
+		pluginOutput.Output = logMessage
+	case failStep:
+		err := fmt.Errorf(logMessage)
+		pluginOutput.Status = contracts.ResultStatusFailed
+		pluginOutput.Error = err.Error()
+		context.Log().Error(err)
+	default:
+		err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
+		pluginOutput.Status = contracts.ResultStatusFailed
+		pluginOutput.Error = err.Error()
+		context.Log().Error(err)
+	}
 
-		// truncate the result and send it back to buffer channel.
-		result := *pluginOutputs[pluginID]
-		pluginConfig := iohandler.DefaultOutputConfig()
-		result.StandardOutput = pluginutil.StringPrefix(result.StandardOutput, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
-		result.StandardError = pluginutil.StringPrefix(result.StandardError, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
-		// send to buffer channel, guaranteed to not block since buffer size is plugin number
-		resChan <- result
+	sendPluginResult(context, pluginOutput, resChan)
 
-		//TODO handle cancelFlag here
-		if pluginHandlerFound && r.Status == contracts.ResultStatusSuccessAndReboot {
-			// do not execute the the next plugin
-			break
+	return pluginHandlerFound && r.Status == contracts.ResultStatusSuccessAndReboot
+}
+
+// skipStepAfterExit marks the plugin as skipped because exitStepID failed with onFailure exit and sends its result.
+func skipStepAfterExit(context context.T, pluginID string, exitStepID string, pluginOutput *contracts.PluginResult, resChan chan contracts.PluginResult) {
+	logMessage := fmt.Sprintf("Step %v is skipped because step %v failed with onFailure %v", pluginID, exitStepID, contracts.OnFailureExit)
+	context.Log().Info(logMessage)
+	pluginOutput.Status = contracts.ResultStatusSkipped
+	pluginOutput.Code = 0
+	pluginOutput.Output = logMessage
+	sendPluginResult(context, pluginOutput, resChan)
+}
+
+// sendPluginResult sets the end time of the plugin and sends its truncated result.
+func sendPluginResult(context context.T, pluginOutput *contracts.PluginResult, resChan chan contracts.PluginResult) {
+	// set end time.
+	pluginOutput.EndDateTime = time.Now()
+	context.Log().Infof("Sending plugin %v completion message", pluginOutput.PluginID)
+
+	// truncate the result and send it back to buffer channel.
+	result := *pluginOutput
+	pluginConfig := iohandler.DefaultOutputConfig()
+	truncationStrategy := iohandler.OutputTruncationStrategy()
+	result.StandardOutput = iohandler.Truncate(result.StandardOutput, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix, truncationStrategy)
+	result.StandardError = iohandler.Truncate(result.StandardError, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix, truncationStrategy)
+	// send to buffer channel, guaranteed to not block since buffer size is plugin number
+	resChan <- result
+}
+
+// isExitOnFailure returns true if the step failed and its following steps must be skipped
+func isExitOnFailure(configuration contracts.Configuration, status contracts.ResultStatus) bool {
+	return configuration.OnFailure == contracts.OnFailureExit &&
+		(status == contracts.ResultStatusFailed || status == contracts.ResultStatusTimedOut)
+}
+
+// runPluginWithRetries runs the plugin, then runs it again while the retry policy of the step allows it.
+// The output of each retry goes to its own attempt subdirectory so that the output of every attempt is kept.
+func runPluginWithRetries(
+	context context.T,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+
+	policy := config.RetryPolicy
+	if policy == nil {
+		return runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	}
+
+	log := context.Log()
+	var startDateTime time.Time
+	for attempt := 1; ; attempt++ {
+		attemptConfig, attemptIOConfig := config, ioConfig
+		if attempt > 1 {
+			directory := attemptDirectory(attempt)
+			attemptConfig.OrchestrationDirectory = fileutil.BuildPath(config.OrchestrationDirectory, directory)
+			attemptIOConfig.OrchestrationDirectory = fileutil.BuildPath(ioConfig.OrchestrationDirectory, directory)
+			if ioConfig.OutputS3KeyPrefix != "" {
+				attemptIOConfig.OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, directory)
+			}
+			if ioConfig.CloudWatchConfig.LogStreamPrefix != "" {
+				attemptIOConfig.CloudWatchConfig.LogStreamPrefix = fmt.Sprintf("%s/%s", ioConfig.CloudWatchConfig.LogStreamPrefix, directory)
+			}
+		}
+
+		res = runPlugin(context, factory, pluginName, attemptConfig, cancelFlag, attemptIOConfig)
+		res.Attempts = attempt
+		if attempt == 1 {
+			startDateTime = res.StartDateTime
+		} else {
+			res.StartDateTime = startDateTime
+		}
+
+		if !policy.ShouldRetry(attempt, res.Status, res.Code) || cancelFlag.Canceled() || cancelFlag.ShutDown() {
+			return
+		}
+		backoff := policy.Backoff(attempt)
+		log.Infof("Attempt %v of %v of step %v ended with status %v and exit code %v, retrying in %v",
+			attempt, policy.MaxAttempts, config.PluginID, res.Status, res.Code, backoff)
+		if !waitForRetry(cancelFlag, backoff) {
+			log.Infof("Step %v was stopped while waiting to be retried", config.PluginID)
+			return
 		}
 	}
+}
 
-	return
+// attemptDirectory is the orchestration subdirectory and S3 key prefix of the output of a retry
+func attemptDirectory(attempt int) string {
+	return fmt.Sprintf("attempt-%d", attempt)
+}
+
+// waitForRetry waits for the backoff of a retry, it returns false if the command is canceled or the agent
+// shuts down in the meantime.
+// Assigned to a variable to allow unittest to override.
+var waitForRetry = func(cancelFlag task.CancelFlag, backoff time.Duration) bool {
+	deadline := time.Now().Add(backoff)
+	for !cancelFlag.Canceled() && !cancelFlag.ShutDown() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return true
+		}
+		if remaining > time.Second {
+			remaining = time.Second
+		}
+		time.Sleep(remaining)
+	}
+	return false
 }
 
 func runPlugin(
@@ -254,9 +434,33 @@ func runPlugin(
 		return
 	}
 
+	// SecureString values are only decrypted in memory, the document state keeps the redaction markers
+	if config.Settings, err = parameterstore.RestoreSecureStrings(log, config.BookKeepingFileName, config.Settings); err == nil {
+		config.Properties, err = parameterstore.RestoreSecureStrings(log, config.BookKeepingFileName, config.Properties)
+	}
+	defer parameterstore.ForgetSecureValues(config.BookKeepingFileName)
+	if err != nil {
+		res.Status = contracts.ResultStatusFailed
+		res.Code = 1
+		res.Error = fmt.Errorf("failed to resolve SecureString parameters: %v", err).Error()
+		log.Error(res.Error)
+		return
+	}
+
 	res.StartDateTime = time.Now()
 	defer func() { res.EndDateTime = time.Now() }()
 
+	if config.ReportOnly {
+		if _, ok := plugin.(Checker); !ok {
+			res.Status = contracts.ResultStatusSkipped
+			res.Output = fmt.Sprintf("Step %v is skipped because plugin %v does not support the report-only mode", config.PluginID, pluginName)
+			res.Drift = &contracts.DriftReport{}
+			log.Info(res.Output)
+			return
+		}
+		res.Drift = &contracts.DriftReport{Checked: true}
+	}
+
 	output := iohandler.NewDefaultIOHandler(log, ioConfig)
 	//check if properties is a list. If true, then unroll
 	switch config.Properties.(type) {
@@ -266,46 +470,79 @@ func runPlugin(
 		if properties = pluginutil.LoadParametersAsList(log, config.Properties, &res); res.Code != 0 {
 			return
 		}
+		// each property writes its output in its own folder of the plugin folder
+		res.OutputDirectory = fileutil.BuildPath(ioConfig.OrchestrationDirectory, pluginName)
 		for _, prop := range properties {
 			config.Properties = prop
 			propOutput := iohandler.NewDefaultIOHandler(log, ioConfig)
-			executePlugin(context, plugin, pluginName, config, cancelFlag, propOutput)
+			changes := executePlugin(context, plugin, pluginName, config, cancelFlag, propOutput)
 			output.Merge(log, propOutput)
+			addDrift(res.Drift, changes)
 		}
 
 	default:
-		executePlugin(context, plugin, pluginName, config, cancelFlag, output)
+		if propID, err := propertyID(pluginName, config); err == nil {
+			res.OutputDirectory = fileutil.BuildPath(ioConfig.OrchestrationDirectory, pluginName, propID)
+		}
+		changes := executePlugin(context, plugin, pluginName, config, cancelFlag, output)
+		addDrift(res.Drift, changes)
 	}
 
 	res.Code = output.GetExitCode()
 	res.Status = output.GetStatus()
 	res.Output = output.GetOutput()
-	res.StandardOutput = output.GetStdout()
-	res.StandardError = output.GetStderr()
+	if outputString, ok := res.Output.(string); ok {
+		res.Output = parameterstore.Redact(outputString)
+	}
+	res.StandardOutput = parameterstore.Redact(output.GetStdout())
+	res.StandardError = parameterstore.Redact(output.GetStderr())
+	res.StructuredOutput = redactStructuredOutput(output.GetStructuredOutput())
 
 	return
 }
 
+// addDrift adds the changes a report-only plugin would apply to its drift report
+func addDrift(drift *contracts.DriftReport, changes []string) {
+	if drift == nil {
+		return
+	}
+	for _, change := range changes {
+		drift.Changes = append(drift.Changes, parameterstore.Redact(change))
+	}
+}
+
+// redactStructuredOutput redacts secure parameter values from the strings of a parsed JSON payload
+func redactStructuredOutput(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return parameterstore.Redact(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactStructuredOutput(item)
+		}
+		return redacted
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = redactStructuredOutput(item)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// executePlugin runs the plugin, or checks it in report-only mode and returns the changes it would apply
 func executePlugin(context context.T,
 	plugin T,
 	pluginName string,
 	config contracts.Configuration,
 	cancelFlag task.CancelFlag,
-	output iohandler.IOHandler) {
+	output iohandler.IOHandler) (changes []string) {
 	log := context.Log()
 	// Get the property ID if it exists.
-	var propID string
-	var err error
-	if config.PluginName == config.PluginID {
-		if pluginName == appconfig.PluginNameCloudWatch {
-			propID = appconfig.PluginNameCloudWatch
-		} else {
-			propID, err = GetPropertyName(config.Properties) //V10 Schema
-		}
-	} else {
-		propID = config.PluginID //V20 Schema
-	}
-
+	propID, err := propertyID(pluginName, config)
 	if err != nil {
 		errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
 		output.MarkAsFailed(errorString)
@@ -314,8 +551,24 @@ func executePlugin(context context.T,
 		defer output.Close(log)
 		output.Init(log, pluginName, propID)
 
-		plugin.Execute(context, config, cancelFlag, output)
+		if checker, ok := plugin.(Checker); ok && config.ReportOnly {
+			changes = checker.Check(context, config, cancelFlag, output)
+		} else {
+			plugin.Execute(context, config, cancelFlag, output)
+		}
+	}
+	return
+}
+
+// propertyID returns the ID of the properties of a step, the output of the step is written under this ID
+func propertyID(pluginName string, config contracts.Configuration) (propID string, err error) {
+	if config.PluginName == config.PluginID {
+		if pluginName == appconfig.PluginNameCloudWatch {
+			return appconfig.PluginNameCloudWatch, nil
+		}
+		return GetPropertyName(config.Properties) //V10 Schema
 	}
+	return config.PluginID, nil //V20 Schema
 }
 
 func GetPropertyName(rawPluginInput interface{}) (propertyName string, err error) {
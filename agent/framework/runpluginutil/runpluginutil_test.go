@@ -16,11 +16,16 @@ package runpluginutil
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
@@ -88,11 +93,12 @@ func TestRunPluginsWithNewDocument(t *testing.T) {
 			Configuration: config,
 		}
 		pluginResults[name] = &contracts.PluginResult{
-			PluginID:      name,
-			PluginName:    name,
-			StartDateTime: defaultTime,
-			EndDateTime:   defaultTime,
-			Output:        "",
+			PluginID:        name,
+			PluginName:      name,
+			StartDateTime:   defaultTime,
+			EndDateTime:     defaultTime,
+			Output:          "",
+			OutputDirectory: name,
 		}
 
 		pluginInstances[name].On("Execute", ctx, pluginConfigs[name].Configuration, cancelFlag, mock.Anything).Return()
@@ -259,11 +265,12 @@ func TestRunPluginsWithCancelFlagShutdown(t *testing.T) {
 		}
 
 		pluginResults[name] = &contracts.PluginResult{
-			Output:        "",
-			PluginName:    name,
-			PluginID:      name,
-			StartDateTime: defaultTime,
-			EndDateTime:   defaultTime,
+			Output:          "",
+			PluginName:      name,
+			PluginID:        name,
+			StartDateTime:   defaultTime,
+			EndDateTime:     defaultTime,
+			OutputDirectory: name,
 		}
 		if name == testPlugin1 {
 			plugins[name].On("Execute", ctx, pluginState.Configuration, cancelFlag, mock.Anything).Run(func(args mock.Arguments) {
@@ -359,6 +366,7 @@ func TestRunPluginsWithInProgressDocuments(t *testing.T) {
 		mockPlugin.AssertExpectations(t)
 	}
 	pluginResults[testPlugin2].Status = ""
+	pluginResults[testPlugin2].OutputDirectory = testPlugin2
 	assert.Equal(t, pluginResults[testPlugin1], outputs[testPlugin1])
 	assert.Equal(t, pluginResults[testPlugin2], outputs[testPlugin2])
 }
@@ -429,11 +437,12 @@ func TestRunPluginsWithDuplicatePluginType(t *testing.T) {
 		}
 
 		pluginResults[name] = &contracts.PluginResult{
-			Output:        "",
-			PluginID:      name,
-			PluginName:    pluginType,
-			StartDateTime: defaultTime,
-			EndDateTime:   defaultTime,
+			Output:          "",
+			PluginID:        name,
+			PluginName:      pluginType,
+			StartDateTime:   defaultTime,
+			EndDateTime:     defaultTime,
+			OutputDirectory: "awsrunShellScript",
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -521,11 +530,12 @@ func TestRunPluginsWithCompatiblePrecondition(t *testing.T) {
 			Configuration: config,
 		}
 		pluginResults[name] = &contracts.PluginResult{
-			Output:        "",
-			PluginName:    name,
-			PluginID:      name,
-			StartDateTime: defaultTime,
-			EndDateTime:   defaultTime,
+			Output:          "",
+			PluginName:      name,
+			PluginID:        name,
+			StartDateTime:   defaultTime,
+			EndDateTime:     defaultTime,
+			OutputDirectory: name,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -611,11 +621,12 @@ func TestRunPluginsWithCompatiblePreconditionWithValueFirst(t *testing.T) {
 			Configuration: config,
 		}
 		pluginResults[name] = &contracts.PluginResult{
-			Output:        "",
-			PluginID:      name,
-			PluginName:    name,
-			StartDateTime: defaultTime,
-			EndDateTime:   defaultTime,
+			Output:          "",
+			PluginID:        name,
+			PluginName:      name,
+			StartDateTime:   defaultTime,
+			EndDateTime:     defaultTime,
+			OutputDirectory: name,
 		}
 
 		pluginFactory := new(PluginFactoryMock)
@@ -1381,11 +1392,12 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 			}
 		} else {
 			pluginResults[name] = &contracts.PluginResult{
-				Output:        "",
-				PluginID:      name,
-				PluginName:    name,
-				StartDateTime: defaultTime,
-				EndDateTime:   defaultTime,
+				Output:          "",
+				PluginID:        name,
+				PluginName:      name,
+				StartDateTime:   defaultTime,
+				EndDateTime:     defaultTime,
+				OutputDirectory: name,
 			}
 			pluginInstances[name].On("Execute", ctx, pluginConfigs[name].Configuration, cancelFlag, mock.Anything).Return(*pluginResults[name])
 		}
@@ -1503,3 +1515,149 @@ func TestRunPluginSuccessWithNonTruncatedResult(t *testing.T) {
 		assert.Equal(t, pluginResults[pluginID].StandardOutput, output.StandardOutput)
 	}
 }
+
+// TestRunPluginsWithRetryPolicy tests a step is run again in its own attempt directory while its exit code is retryable.
+func TestRunPluginsWithRetryPolicy(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	origWaitForRetry := waitForRetry
+	defer func() { waitForRetry = origWaitForRetry }()
+	var backoffs []time.Duration
+	waitForRetry = func(cancelFlag task.CancelFlag, backoff time.Duration) bool {
+		backoffs = append(backoffs, backoff)
+		return true
+	}
+
+	dir, err := ioutil.TempDir("", "runpluginutil")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		exitCodes        []int
+		expectedStatus   contracts.ResultStatus
+		expectedAttempts int
+	}{
+		{[]int{75, 75, 0}, contracts.ResultStatusSuccess, 3},
+		{[]int{75, 75, 75}, contracts.ResultStatusFailed, 3},
+		{[]int{1}, contracts.ResultStatusFailed, 1},
+	}
+	for _, testCase := range testCases {
+		backoffs = []time.Duration{}
+		var orchestrationDirectories []string
+		exitCodes := testCase.exitCodes
+		plugin := new(PluginMock)
+		plugin.On("Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			orchestrationDirectories = append(orchestrationDirectories, args.Get(1).(contracts.Configuration).OrchestrationDirectory)
+			output := args.Get(3).(iohandler.IOHandler)
+			output.SetExitCode(exitCodes[0])
+			if exitCodes[0] == 0 {
+				output.MarkAsSucceeded()
+			} else {
+				output.MarkAsFailed(nil)
+			}
+			exitCodes = exitCodes[1:]
+		}).Return()
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(plugin, nil)
+
+		stepDirectory := filepath.Join(dir, testPlugin1)
+		pluginState := contracts.PluginState{
+			Name: testPlugin1,
+			Id:   testPlugin1,
+			Configuration: contracts.Configuration{
+				PluginID:               testPlugin1,
+				PluginName:             testPlugin1,
+				OrchestrationDirectory: stepDirectory,
+				RetryPolicy:            &contracts.RetryPolicy{MaxAttempts: 3, BackoffSeconds: 5, RetryableExitCodes: []int{75}},
+			},
+		}
+		ioConfig := contracts.IOConfiguration{OrchestrationDirectory: dir, OutputS3BucketName: "bucket", OutputS3KeyPrefix: "prefix"}
+
+		ch := make(chan contracts.PluginResult, 1)
+		outputs := RunPlugins(context.NewMockDefault(), []contracts.PluginState{pluginState}, ioConfig, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+		close(ch)
+
+		assert.Equal(t, testCase.expectedStatus, outputs[testPlugin1].Status)
+		assert.Equal(t, testCase.expectedAttempts, outputs[testPlugin1].Attempts)
+		expectedDirectories := []string{stepDirectory, filepath.Join(stepDirectory, "attempt-2"), filepath.Join(stepDirectory, "attempt-3")}
+		assert.Equal(t, expectedDirectories[:testCase.expectedAttempts], orchestrationDirectories)
+		expectedBackoffs := []time.Duration{5 * time.Second, 10 * time.Second}
+		assert.Equal(t, expectedBackoffs[:testCase.expectedAttempts-1], backoffs)
+		if testCase.expectedAttempts > 1 {
+			assert.Equal(t, fmt.Sprintf("prefix/attempt-%v/%v", testCase.expectedAttempts, testPlugin1), outputs[testPlugin1].OutputS3KeyPrefix)
+		}
+	}
+}
+
+// TestRunPluginsReportOnly tests the plugins are checked in report-only mode and the plugins which can't be checked are skipped.
+func TestRunPluginsReportOnly(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	checker := new(CheckerPluginMock)
+	checker.On("Check", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(3).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return([]string{"service nginx would be enabled"})
+	plugin := new(PluginMock)
+	registry := PluginRegistry{}
+	for name, instance := range map[string]T{testPlugin1: checker, testPlugin2: plugin} {
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(instance, nil)
+		registry[name] = pluginFactory
+	}
+
+	var pluginStates []contracts.PluginState
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		pluginStates = append(pluginStates, contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: contracts.Configuration{PluginID: name, PluginName: name, ReportOnly: true, ComplianceSeverity: "HIGH"},
+		})
+	}
+
+	ch := make(chan contracts.PluginResult, len(pluginStates))
+	outputs := RunPlugins(context.NewMockDefault(), pluginStates, contracts.IOConfiguration{}, registry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	checker.AssertNumberOfCalls(t, "Execute", 0)
+	plugin.AssertNumberOfCalls(t, "Execute", 0)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	assert.Equal(t, &contracts.DriftReport{Checked: true, Changes: []string{"service nginx would be enabled"}}, outputs[testPlugin1].Drift)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin2].Status)
+	assert.Equal(t, &contracts.DriftReport{}, outputs[testPlugin2].Drift)
+	assert.Equal(t, "HIGH", outputs[testPlugin2].ComplianceSeverity)
+}
+
+func TestPropertyID(t *testing.T) {
+	// V10 schema steps write their output under the ID of their properties
+	propID, err := propertyID("aws:runScript", contracts.Configuration{
+		PluginName: "aws:runScript",
+		PluginID:   "aws:runScript",
+		Properties: map[string]interface{}{"id": "0.aws:runScript"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.aws:runScript", propID)
+
+	// V20 schema steps write their output under the step name
+	propID, err = propertyID("aws:runShellScript", contracts.Configuration{PluginName: "aws:runShellScript", PluginID: "step1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "step1", propID)
+
+	propID, err = propertyID(appconfig.PluginNameCloudWatch, contracts.Configuration{
+		PluginName: appconfig.PluginNameCloudWatch,
+		PluginID:   appconfig.PluginNameCloudWatch,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, appconfig.PluginNameCloudWatch, propID)
+}
+
+func TestWaitForRetryCanceled(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	assert.True(t, waitForRetry(cancelFlag, 10*time.Millisecond))
+	cancelFlag.Set(task.Canceled)
+	assert.False(t, waitForRetry(cancelFlag, time.Hour))
+
+	cancelFlag = task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.ShutDown)
+	assert.False(t, waitForRetry(cancelFlag, time.Hour))
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessiontype"
 )
 
 // IsPluginSupportedForCurrentPlatform always returns true for plugins that exist for linux because currently there
@@ -29,8 +30,8 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 	platformName, _ := platform.PlatformName(log)
 	platformVersion, _ := platform.PlatformVersion(log)
 
-	if _, known := allSessionPlugins[pluginName]; known == true {
-		return known, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
+	if sessiontype.IsKnown(pluginName) {
+		return true, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
 	}
 	_, known := allPlugins[pluginName]
 	return known, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
@@ -27,6 +27,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/session/sessiontype"
 )
 
 // IsPluginSupportedForCurrentPlatform returns true if current platform supports the plugin with given name.
@@ -34,8 +35,8 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 	platformName, _ := platform.PlatformName(log)
 	platformVersion, _ := platform.PlatformVersion(log)
 
-	if _, known := allSessionPlugins[pluginName]; known == true {
-		return known, isSupportedSessionPlugin(log, pluginName), fmt.Sprintf("%s v%s", platformName, platformVersion)
+	if sessiontype.IsKnown(pluginName) {
+		return true, isSupportedSessionPlugin(log, pluginName), fmt.Sprintf("%s v%s", platformName, platformVersion)
 	}
 
 	_, known := allPlugins[pluginName]
@@ -34,6 +34,16 @@ func (m *PluginMock) Execute(context context.T, config contracts.Configuration,
 	return
 }
 
+// CheckerPluginMock stands for a mocked plugin supporting the report-only mode.
+type CheckerPluginMock struct {
+	PluginMock
+}
+
+func (m *CheckerPluginMock) Check(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) []string {
+	args := m.Called(context, config, cancelFlag, output)
+	return args.Get(0).([]string)
+}
+
 type PluginFactoryMock struct {
 	mock.Mock
 }
@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package health contains routines that periodically reports health information of the agent
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const (
+	// LivenessPath is the path of the liveness of the agent on the health endpoint
+	LivenessPath = "/health/live"
+	// ReadinessPath is the path of the readiness of the agent on the health endpoint
+	ReadinessPath = "/health/ready"
+	// MetricsPath is the path of the metrics of the agent on the health endpoint
+	MetricsPath = "/metrics"
+
+	// endpointHost restricts the health endpoint to the instance
+	endpointHost = "127.0.0.1"
+)
+
+// LivenessReport is served on LivenessPath while the agent runs
+type LivenessReport struct {
+	Live          bool       `json:"live"`
+	Version       string     `json:"version"`
+	UptimeSeconds int64      `json:"uptimeSeconds"`
+	LastError     *LastError `json:"lastError,omitempty"`
+}
+
+// ReadinessReport is served on ReadinessPath, with status 200 when the agent is ready and 503 otherwise
+type ReadinessReport struct {
+	Ready      bool                       `json:"ready"`
+	Version    string                     `json:"version"`
+	Status     string                     `json:"status"`
+	NotReady   []string                   `json:"notReady,omitempty"`
+	Components map[string]ComponentStatus `json:"components"`
+	LastError  *LastError                 `json:"lastError,omitempty"`
+}
+
+// endpoint serves the health of the agent over http on the loopback interface
+type endpoint struct {
+	log       log.T
+	startTime time.Time
+	server    *http.Server
+	address   string
+}
+
+// startEndpoint listens on the configured port of the loopback interface and serves the health
+// and the metrics of the agent, depending on which of them are enabled.
+func startEndpoint(log log.T, config appconfig.HealthCfg) (*endpoint, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(endpointHost, fmt.Sprint(config.EndpointPort)))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &endpoint{log: log, startTime: time.Now(), address: listener.Addr().String()}
+	mux := http.NewServeMux()
+	if config.EndpointEnabled {
+		mux.HandleFunc(LivenessPath, e.serveLiveness)
+		mux.HandleFunc(ReadinessPath, e.serveReadiness)
+	}
+	if config.MetricsEnabled {
+		mux.Handle(MetricsPath, metrics.PrometheusHandler(metrics.Default))
+	}
+	e.server = &http.Server{Handler: mux}
+	go func() {
+		if err := e.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Health endpoint stopped: %v", err)
+		}
+	}()
+	log.Infof("Serving agent health on http://%s", e.address)
+	return e, nil
+}
+
+// stop closes the listener and the connections of the endpoint
+func (e *endpoint) stop() {
+	if err := e.server.Close(); err != nil {
+		e.log.Warnf("Failed to stop the health endpoint: %v", err)
+	}
+}
+
+func (e *endpoint) serveLiveness(writer http.ResponseWriter, request *http.Request) {
+	e.writeReport(writer, http.StatusOK, LivenessReport{
+		Live:          true,
+		Version:       version.Version,
+		UptimeSeconds: int64(time.Since(e.startTime) / time.Second),
+		LastError:     GetLastError(),
+	})
+}
+
+func (e *endpoint) serveReadiness(writer http.ResponseWriter, request *http.Request) {
+	ready, statuses := Readiness()
+	statusCode := http.StatusOK
+	if !ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+	e.writeReport(writer, statusCode, ReadinessReport{
+		Ready:      ready,
+		Version:    version.Version,
+		Status:     agentStatus(),
+		NotReady:   notReadyComponents(statuses),
+		Components: statuses,
+		LastError:  GetLastError(),
+	})
+}
+
+// writeReport writes a report as json
+func (e *endpoint) writeReport(writer http.ResponseWriter, statusCode int, report interface{}) {
+	content, err := json.Marshal(report)
+	if err != nil {
+		e.log.Errorf("Failed to serialize the health report: %v", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	writer.Write(content)
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package health contains routines that periodically reports health information of the agent
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/stretchr/testify/assert"
+)
+
+// resetComponents clears the readiness reported by the components
+func resetComponents() {
+	componentsLock.Lock()
+	defer componentsLock.Unlock()
+	components = make(map[string]ComponentStatus)
+	lastError = nil
+}
+
+func getReport(t *testing.T, e *endpoint, path string, report interface{}) int {
+	response, err := http.Get("http://" + e.address + path)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(report))
+	return response.StatusCode
+}
+
+func TestHealthEndpointReadiness(t *testing.T) {
+	resetComponents()
+	defer resetComponents()
+	e, err := startEndpoint(log.NewMockLog(), appconfig.HealthCfg{EndpointEnabled: true})
+	assert.NoError(t, err)
+	defer e.stop()
+	assert.True(t, strings.HasPrefix(e.address, endpointHost+":"))
+
+	// required components haven't reported yet
+	var report ReadinessReport
+	assert.Equal(t, http.StatusServiceUnavailable, getReport(t, e, ReadinessPath, &report))
+	assert.False(t, report.Ready)
+	assert.Equal(t, []string{ComponentCredentials, ComponentMds}, report.NotReady)
+
+	SetReady(ComponentCredentials)
+	SetReady(ComponentMds)
+	report = ReadinessReport{}
+	assert.Equal(t, http.StatusOK, getReport(t, e, ReadinessPath, &report))
+	assert.True(t, report.Ready)
+	assert.Equal(t, version.Version, report.Version)
+	assert.Nil(t, report.LastError)
+
+	// optional components count once they report
+	SetNotReady(ComponentMgs, errors.New("connection refused"))
+	report = ReadinessReport{}
+	assert.Equal(t, http.StatusServiceUnavailable, getReport(t, e, ReadinessPath, &report))
+	assert.Equal(t, []string{ComponentMgs}, report.NotReady)
+	assert.Equal(t, "connection refused", report.Components[ComponentMgs].Error)
+	assert.Equal(t, ComponentMgs, report.LastError.Component)
+}
+
+func TestHealthEndpointLiveness(t *testing.T) {
+	resetComponents()
+	defer resetComponents()
+	e, err := startEndpoint(log.NewMockLog(), appconfig.HealthCfg{EndpointEnabled: true})
+	assert.NoError(t, err)
+	defer e.stop()
+
+	SetNotReady(ComponentMds, errors.New("throttled"))
+	var report LivenessReport
+	assert.Equal(t, http.StatusOK, getReport(t, e, LivenessPath, &report))
+	assert.True(t, report.Live)
+	assert.Equal(t, version.Version, report.Version)
+	assert.Equal(t, "throttled", report.LastError.Error)
+}
+
+func TestHealthEndpointMetrics(t *testing.T) {
+	e, err := startEndpoint(log.NewMockLog(), appconfig.HealthCfg{MetricsEnabled: true})
+	assert.NoError(t, err)
+	defer e.stop()
+
+	metrics.NewCounter("ssm_agent_test_total", "Test counter.").Inc()
+	response, err := http.Get("http://" + e.address + MetricsPath)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, metrics.PrometheusContentType, response.Header.Get("Content-Type"))
+	content, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "ssm_agent_test_total 1\n")
+
+	// the health paths are only served when the health endpoint is enabled
+	response, err = http.Get("http://" + e.address + ReadinessPath)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
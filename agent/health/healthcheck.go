@@ -16,12 +16,17 @@ package health
 
 import (
 	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/carlescere/scheduler"
 )
@@ -39,16 +44,28 @@ type HealthCheck struct {
 	healthCheckStopPolicy *sdkutil.StopPolicy
 	healthJob             *scheduler.Job
 	service               ssm.Service
+	serviceLock           sync.RWMutex
+	endpoint              *endpoint
 }
 
 const (
 	name = "HealthCheck"
 	// AgentName is the name of the current agent.
 	AgentName = "amazon-ssm-agent"
+	// activeStatus is the agent status reported while no component reports an issue.
+	activeStatus = "Active"
 )
 
 var healthModule *HealthCheck
 
+var recordAgentCheck = updateutil.RecordAgentCheck
+
+var newSsmService = ssm.NewService
+
+// issues are the agent statuses reported by components of the agent, indexed by component.
+var issues = make(map[string]string)
+var issuesLock sync.RWMutex
+
 // AgentState enumerates active and passive agentMode
 type AgentState int32
 
@@ -94,12 +111,55 @@ func (h *HealthCheck) updateHealth() {
 	var err error
 	//TODO when will status become inactive?
 	// If both ssm config and command is inactive => agent is inactive.
-	if _, err = h.service.UpdateInstanceInformation(log, version.Version, "Active", AgentName); err != nil {
+	if _, err = h.ssmService().UpdateInstanceInformation(log, version.Version, agentStatus(), AgentName); err != nil {
 		sdkutil.HandleAwsError(log, err, h.healthCheckStopPolicy)
+		SetNotReady(ComponentCredentials, err)
+		return
 	}
+	SetReady(ComponentCredentials)
+	recordAgentCheck(log, updateutil.CheckRegistered)
 	return
 }
 
+// ReportIssue reports that a component of the agent doesn't work, status is reported as agent status until the issue is cleared.
+// The health of the agent is updated right away when the issue is new.
+func ReportIssue(component string, status string) {
+	issuesLock.Lock()
+	previous, found := issues[component]
+	issues[component] = status
+	issuesLock.Unlock()
+	if (!found || previous != status) && healthModule != nil {
+		go healthModule.updateHealth()
+	}
+}
+
+// ClearIssue reports that a component of the agent works again.
+// The health of the agent is updated right away when the component had an issue.
+func ClearIssue(component string) {
+	issuesLock.Lock()
+	_, found := issues[component]
+	delete(issues, component)
+	issuesLock.Unlock()
+	if found && healthModule != nil {
+		go healthModule.updateHealth()
+	}
+}
+
+// agentStatus returns the status of the agent, the status of the first component with an issue when any.
+func agentStatus() string {
+	issuesLock.RLock()
+	defer issuesLock.RUnlock()
+	if len(issues) == 0 {
+		return activeStatus
+	}
+	components := make([]string, 0, len(issues))
+	for component := range issues {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	return issues[components[0]]
+}
+
 // scheduleInMinutes Run Schedule In Minutes
 func (h *HealthCheck) scheduleInMinutes() int {
 	updateHealthFrequencyMins := 5
@@ -132,6 +192,17 @@ func (h *HealthCheck) ModuleExecute(context context.T) (err error) {
 
 	randomSeconds := rand.Intn(scheduleInMinutes * 60)
 
+	// Serve the health and the metrics of the agent locally when enabled
+	if config := h.context.AppConfig(); config.Health.EndpointEnabled || config.Health.MetricsEnabled {
+		if h.endpoint, err = startEndpoint(h.context.Log(), config.Health); err != nil {
+			h.context.Log().Errorf("unable to start the health endpoint. %v", err)
+			err = nil
+		}
+	}
+
+	// Reconnect to SSM when a reload changes its endpoint
+	appconfig.Subscribe(name, h.onConfigChange)
+
 	// First call updateHealth once
 	go h.updateHealth()
 
@@ -149,23 +220,51 @@ func (h *HealthCheck) ModuleExecute(context context.T) (err error) {
 
 // ModuleRequestStop handles the termination of the health check module job
 func (h *HealthCheck) ModuleRequestStop(stopType contracts.StopType) (err error) {
+	appconfig.Unsubscribe(name)
 	if h.healthJob != nil {
 		h.context.Log().Info("stopping update instance health job.")
 		h.healthJob.Quit <- true
 	}
+	if h.endpoint != nil {
+		h.endpoint.stop()
+		h.endpoint = nil
+	}
 	return nil
 }
 
+// onConfigChange replaces the SSM service when a reload of the agent configuration changed the endpoint of SSM
+func (h *HealthCheck) onConfigChange(previous appconfig.SsmagentConfig, current appconfig.SsmagentConfig) {
+	if previous.Agent.Region == current.Agent.Region &&
+		previous.Ssm.Endpoint == current.Ssm.Endpoint &&
+		reflect.DeepEqual(previous.Endpoints, current.Endpoints) {
+		return
+	}
+	h.context.Log().Info("SSM settings changed, reconnecting to SSM")
+	service := newSsmService()
+	h.serviceLock.Lock()
+	defer h.serviceLock.Unlock()
+	h.service = service
+}
+
+// ssmService returns the service the health is reported to
+func (h *HealthCheck) ssmService() ssm.Service {
+	h.serviceLock.RLock()
+	defer h.serviceLock.RUnlock()
+	return h.service
+}
+
 //ping sends an empty ping to the health service to identify if the service exists
 func (h *HealthCheck) ping() (err error) {
-	_, err = h.service.UpdateEmptyInstanceInformation(h.context.Log(), version.Version, AgentName)
+	_, err = h.ssmService().UpdateEmptyInstanceInformation(h.context.Log(), version.Version, AgentName)
 	return err
 }
 
 // GetAgentState returns the state of the agent. It is the caller's responsibility to log the error
 func (h *HealthCheck) GetAgentState() (a AgentState, err error) {
 	if err = h.ping(); err != nil {
+		SetNotReady(ComponentCredentials, err)
 		return Passive, err
 	}
+	SetReady(ComponentCredentials)
 	return Active, err
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssm"
 	ssmMock "github.com/aws/amazon-ssm-agent/agent/ssm/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/carlescere/scheduler"
@@ -120,6 +121,25 @@ func (suite *HealthCheckTestSuite) TestModuleRequestStopWithoutHealthJob() {
 	}(wg)
 }
 
+// Testing the agent status reported while components report issues
+func (suite *HealthCheckTestSuite) TestUpdateHealthWithIssues() {
+	defer ClearIssue("B")
+	defer ClearIssue("A")
+	ReportIssue("B", "StatusB")
+	ReportIssue("A", "StatusA")
+	suite.serviceMock.On("UpdateInstanceInformation", mock.Anything, version.Version, "StatusA", AgentName).Return(nil, nil).Once()
+	suite.serviceMock.On("UpdateInstanceInformation", mock.Anything, version.Version, "StatusB", AgentName).Return(nil, nil).Once()
+	suite.serviceMock.On("UpdateInstanceInformation", mock.Anything, version.Version, "Active", AgentName).Return(nil, nil).Once()
+
+	suite.healthCheck.(*HealthCheck).updateHealth()
+	ClearIssue("A")
+	suite.healthCheck.(*HealthCheck).updateHealth()
+	ClearIssue("B")
+	suite.healthCheck.(*HealthCheck).updateHealth()
+
+	suite.serviceMock.AssertExpectations(suite.T())
+}
+
 // Testing the GetAgentState method which should return Active status
 func (suite *HealthCheckTestSuite) TestGetAgentStateActive() {
 	// UpdateEmptyInstanceInformation will return active in the h.ping() function.
@@ -140,6 +160,26 @@ func (suite *HealthCheckTestSuite) TestGetAgentStatePassive() {
 	assert.NotNil(suite.T(), err, "GetAgentStatePassive should return error message UpdatesWithError")
 }
 
+// Testing that a reload of the configuration reconnects to SSM only when its endpoint changed
+func (suite *HealthCheckTestSuite) TestOnConfigChange() {
+	reloadedService := new(ssmMock.Service)
+	defer func(original func() ssm.Service) { newSsmService = original }(newSsmService)
+	newSsmService = func() ssm.Service {
+		return reloadedService
+	}
+	healthCheck := suite.healthCheck.(*HealthCheck)
+	previous := appconfig.DefaultConfig()
+	current := appconfig.DefaultConfig()
+
+	current.Ssm.HealthFrequencyMinutes = previous.Ssm.HealthFrequencyMinutes + 1
+	healthCheck.onConfigChange(previous, current)
+	assert.Equal(suite.T(), suite.serviceMock, healthCheck.ssmService())
+
+	current.Endpoints.Overrides = map[string]string{"ssm": "vpce-1.ssm.{region}.vpce.amazonaws.com"}
+	healthCheck.onConfigChange(previous, current)
+	assert.Equal(suite.T(), reloadedService, healthCheck.ssmService())
+}
+
 //Execute the test suite
 func TestHealthCheckTestSuite(t *testing.T) {
 	suite.Run(t, new(HealthCheckTestSuite))
@@ -0,0 +1,112 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package health contains routines that periodically reports health information of the agent
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ComponentCredentials is ready once SSM accepts the credentials of the agent
+	ComponentCredentials = "Credentials"
+	// ComponentMds is ready while the agent polls MDS for commands
+	ComponentMds = "MessageDeliveryService"
+	// ComponentMgs is ready while the control channel of the agent is connected to MGS
+	ComponentMgs = "MessageGatewayService"
+)
+
+// requiredComponents must be ready for the agent to be ready, the other components only count once they report
+var requiredComponents = []string{ComponentCredentials, ComponentMds}
+
+// ComponentStatus is the readiness of a component of the agent
+type ComponentStatus struct {
+	Ready   bool      `json:"ready"`
+	Error   string    `json:"error,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// LastError is the last error a component of the agent reported
+type LastError struct {
+	Component string    `json:"component"`
+	Error     string    `json:"error"`
+	Time      time.Time `json:"time"`
+}
+
+var components = make(map[string]ComponentStatus)
+var lastError *LastError
+var componentsLock sync.RWMutex
+
+// SetReady reports that a component of the agent works.
+func SetReady(component string) {
+	componentsLock.Lock()
+	defer componentsLock.Unlock()
+	components[component] = ComponentStatus{Ready: true, Updated: time.Now().UTC()}
+}
+
+// SetNotReady reports that a component of the agent doesn't work, the error is kept as the last error of the agent.
+func SetNotReady(component string, err error) {
+	componentsLock.Lock()
+	defer componentsLock.Unlock()
+	status := ComponentStatus{Ready: false, Updated: time.Now().UTC()}
+	if err != nil {
+		status.Error = err.Error()
+		lastError = &LastError{Component: component, Error: status.Error, Time: status.Updated}
+	}
+	components[component] = status
+}
+
+// Readiness returns true if the required components and every component that reported are ready,
+// along with the status of the components.
+func Readiness() (ready bool, statuses map[string]ComponentStatus) {
+	componentsLock.RLock()
+	defer componentsLock.RUnlock()
+
+	ready = true
+	statuses = make(map[string]ComponentStatus)
+	for _, component := range requiredComponents {
+		statuses[component] = ComponentStatus{}
+	}
+	for component, status := range components {
+		statuses[component] = status
+	}
+	for _, status := range statuses {
+		ready = ready && status.Ready
+	}
+	return
+}
+
+// GetLastError returns the last error reported by a component, nil if none did.
+func GetLastError() *LastError {
+	componentsLock.RLock()
+	defer componentsLock.RUnlock()
+	if lastError == nil {
+		return nil
+	}
+	last := *lastError
+	return &last
+}
+
+// notReadyComponents returns the names of the components that are not ready, sorted.
+func notReadyComponents(statuses map[string]ComponentStatus) (names []string) {
+	for component, status := range statuses {
+		if !status.Ready {
+			names = append(names, component)
+		}
+	}
+	sort.Strings(names)
+	return
+}
@@ -13,7 +13,8 @@
 
 // Package hibernation is responsible for the agent in hibernate mode.
 // It depends on health pings in an exponential backoff to check if the agent needs
-// to move to active mode.
+// to move to active mode. Events that may give credentials to the agent, such as the
+// attachment of an instance role, wake it up earlier.
 package hibernation
 
 import (
@@ -41,6 +42,11 @@ type Hibernate struct {
 	scheduleBackOff     func(m *Hibernate)
 	schedulePing        func(m *Hibernate)
 
+	wakeSources          []wakeSource
+	checkHealth          func(m *Hibernate)
+	wakeRetryInterval    time.Duration
+	maxWakeRetryInterval time.Duration
+
 	seelogger seelog.LoggerInterface
 	isLogged  bool
 }
@@ -54,6 +60,14 @@ const (
 	maxBackOffInterval = 60 * 60 //Minute conversion
 	multiplier         = 2
 	initialPingRate    = 5 * 60 //Seconds
+
+	// wakeRetryInterval is the first interval of the health pings following a wake event,
+	// credentials may take a few seconds to be accepted after an IAM fix
+	wakeRetryInterval = 10 * time.Second
+	// maxWakeRetryInterval ends the health pings following a wake event
+	maxWakeRetryInterval = 160 * time.Second
+	// minWakeInterval ignores the wake events following a health ping closely
+	minWakeInterval = 5 * time.Second
 )
 
 // NewHibernateMode creates an object of type NewHibernateMode
@@ -71,6 +85,11 @@ func NewHibernateMode(healthModule health.IHealthCheck, context context.T) *Hibe
 		maxInterval:         maxBackOffInterval,
 		scheduleBackOff:     scheduleBackOffStrategy,
 		schedulePing:        scheduleEmptyHealthPing,
+
+		wakeSources:          defaultWakeSources,
+		checkHealth:          (*Hibernate).healthCheck,
+		wakeRetryInterval:    wakeRetryInterval,
+		maxWakeRetryInterval: maxWakeRetryInterval,
 	}
 }
 
@@ -78,9 +97,18 @@ func NewHibernateMode(healthModule health.IHealthCheck, context context.T) *Hibe
 func (m *Hibernate) ExecuteHibernation() health.AgentState {
 	next := time.Duration(initialPingRate) * time.Second
 	m.seelogger.Info("Agent is in hibernate mode. Reducing logging. Logging will be reduced to one log per backoff period")
-	// Wait backoff time and then schedule health pings
-	<-time.After(next)
-	m.scheduleBackOff(m)
+	stopWake := make(chan struct{})
+	defer close(stopWake)
+	go m.watchWakeEvents(stopWake)
+
+	// Wait backoff time and then schedule health pings, unless a wake event activated the agent meanwhile
+	go func() {
+		select {
+		case <-time.After(next):
+			m.scheduleBackOff(m)
+		case <-stopWake:
+		}
+	}()
 
 loop:
 	// using an infinite loop to block the agent from starting
@@ -101,6 +129,39 @@ loop:
 	}
 }
 
+// watchWakeEvents pings the health of the agent when a wake source reports an event, then again with an
+// exponential backoff until maxWakeRetryInterval, as long as the agent hibernates.
+func (m *Hibernate) watchWakeEvents(stop <-chan struct{}) {
+	wake := make(chan string)
+	for _, source := range m.wakeSources {
+		go source(stop, wake)
+	}
+
+	var retry <-chan time.Time
+	var retryInterval time.Duration
+	var lastPing time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case reason := <-wake:
+			if time.Since(lastPing) < minWakeInterval {
+				continue
+			}
+			m.seelogger.Infof("Waking up from hibernation, %v. Checking agent health.", reason)
+			retryInterval = m.wakeRetryInterval
+		case <-retry:
+			retryInterval = multiplier * retryInterval
+		}
+		lastPing = time.Now()
+		m.checkHealth(m)
+		retry = nil
+		if retryInterval <= m.maxWakeRetryInterval {
+			retry = time.After(retryInterval)
+		}
+	}
+}
+
 func (m *Hibernate) healthCheck() {
 	status, err := m.healthModule.GetAgentState()
 	if err != nil && !m.isLogged {
@@ -17,6 +17,10 @@
 package hibernation
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -32,6 +36,7 @@ func TestHibernation_ExecuteHibernation_AgentTurnsActive(t *testing.T) {
 
 	hibernate := NewHibernateMode(healthMock, ctx)
 	hibernate.scheduleBackOff = fakeScheduler
+	hibernate.wakeSources = nil
 	for i := 0; i < 4; i++ {
 		modeChan <- health.Passive
 	}
@@ -67,6 +72,83 @@ func TestHibernation_scheduleBackOffStrategy(t *testing.T) {
 	assert.Equal(t, 4, hibernate.currentPingInterval) // maxInterval is 4
 }
 
+func TestHibernation_WakeEventPingsHealthWithBackoff(t *testing.T) {
+	ctx := context.NewMockDefault()
+	hibernate := NewHibernateMode(nil, ctx)
+	hibernate.wakeRetryInterval = 10 * time.Millisecond
+	hibernate.maxWakeRetryInterval = 40 * time.Millisecond
+	var pings int32
+	hibernate.checkHealth = func(*Hibernate) { atomic.AddInt32(&pings, 1) }
+	hibernate.wakeSources = []wakeSource{func(stop <-chan struct{}, wake chan<- string) {
+		sendWake(stop, wake, "instance role attached")
+		// wake events right after a ping are ignored
+		sendWake(stop, wake, "instance role attached")
+	}}
+
+	stop := make(chan struct{})
+	go hibernate.watchWakeEvents(stop)
+	time.Sleep(500 * time.Millisecond)
+	close(stop)
+
+	// one ping for the wake event, then pings after 10ms, 20ms and 40ms
+	assert.Equal(t, int32(4), atomic.LoadInt32(&pings))
+}
+
+func TestPollForChange(t *testing.T) {
+	states := make(chan string, 3)
+	states <- ""
+	states <- "role"
+	states <- "role"
+	read := func() string {
+		select {
+		case state := <-states:
+			return state
+		default:
+			return "role"
+		}
+	}
+	changed := func(previous, current string) string {
+		if current != previous {
+			return current + " attached"
+		}
+		return ""
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	wake := make(chan string)
+	go pollForChange(stop, wake, time.Millisecond, read, changed)
+	assert.Equal(t, "role attached", <-wake)
+	select {
+	case reason := <-wake:
+		assert.Fail(t, "unexpected wake", reason)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchFilesWakesOnCredentialsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hibernation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	credentials := filepath.Join(dir, "credentials")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	wake := make(chan string)
+	go watchFiles(stop, wake, []string{credentials})
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0600))
+	assert.NoError(t, ioutil.WriteFile(credentials, []byte("[default]"), 0600))
+	select {
+	case reason := <-wake:
+		assert.Contains(t, reason, credentials)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no wake for the credentials file")
+	}
+}
+
 func fakeScheduler(*Hibernate) {
 	//Do nothing
 }
@@ -0,0 +1,176 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package hibernation is responsible for the agent in hibernate mode.
+package hibernation
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/managedInstances/sharedCredentials"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// roleCheckInterval is how often the instance metadata is checked for an attached role
+	roleCheckInterval = 30 * time.Second
+	// networkCheckInterval is how often the addresses of the network interfaces are checked
+	networkCheckInterval = 10 * time.Second
+	// metadataTimeout bounds the requests to the instance metadata, which fail fast outside of EC2
+	metadataTimeout = 2 * time.Second
+	// roleCredentialsMetadataPath lists the role attached to the instance
+	roleCredentialsMetadataPath = "iam/security-credentials/"
+)
+
+// wakeSource watches for an event that may give credentials to the agent and sends the reason on wake until stop is closed
+type wakeSource func(stop <-chan struct{}, wake chan<- string)
+
+// defaultWakeSources are the events waking the agent up from hibernation
+var defaultWakeSources = []wakeSource{watchInstanceRole, watchCredentialsFiles, watchNetwork}
+
+// watchInstanceRole wakes when an IAM role is attached to the instance or replaced.
+func watchInstanceRole(stop <-chan struct{}, wake chan<- string) {
+	sess, err := session.NewSession(aws.NewConfig().
+		WithMaxRetries(0).
+		WithHTTPClient(&http.Client{Timeout: metadataTimeout}))
+	if err != nil {
+		return
+	}
+	client := ec2metadata.New(sess)
+	getRole := func() string {
+		role, _ := client.GetMetadata(roleCredentialsMetadataPath)
+		return strings.TrimSpace(role)
+	}
+	pollForChange(stop, wake, roleCheckInterval, getRole, func(previous, current string) string {
+		if current != "" && current != previous {
+			return "instance role " + current + " attached"
+		}
+		return ""
+	})
+}
+
+// watchNetwork wakes when a network interface gets a new address, such as when the network comes up.
+func watchNetwork(stop <-chan struct{}, wake chan<- string) {
+	getAddresses := func() string {
+		addresses, err := net.InterfaceAddrs()
+		if err != nil {
+			return ""
+		}
+		var names []string
+		for _, address := range addresses {
+			if ip, ok := address.(*net.IPNet); ok && !ip.IP.IsLoopback() && !ip.IP.IsLinkLocalUnicast() {
+				names = append(names, ip.IP.String())
+			}
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	pollForChange(stop, wake, networkCheckInterval, getAddresses, func(previous, current string) string {
+		known := make(map[string]bool)
+		for _, address := range strings.Split(previous, ",") {
+			known[address] = true
+		}
+		for _, address := range strings.Split(current, ",") {
+			if address != "" && !known[address] {
+				return "network address " + address + " is up"
+			}
+		}
+		return ""
+	})
+}
+
+// pollForChange reads a state every interval and sends the reason returned by changed on wake, if any.
+func pollForChange(stop <-chan struct{}, wake chan<- string, interval time.Duration, read func() string, changed func(previous, current string) string) {
+	previous := read()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			current := read()
+			if reason := changed(previous, current); reason != "" {
+				sendWake(stop, wake, reason)
+			}
+			previous = current
+		}
+	}
+}
+
+// watchCredentialsFiles wakes when the shared credentials file or the credentials profile of the agent is written.
+func watchCredentialsFiles(stop <-chan struct{}, wake chan<- string) {
+	var files []string
+	if file, err := sharedCredentials.Filename(); err == nil {
+		files = append(files, file)
+	}
+	if config, err := appconfig.Config(false); err == nil && config.Profile.Path != "" {
+		files = append(files, config.Profile.Path)
+	}
+	watchFiles(stop, wake, files)
+}
+
+// watchFiles sends a wake reason when one of the files is created or written.
+// The directories of the files are watched since the files may not exist yet.
+func watchFiles(stop <-chan struct{}, wake chan<- string, files []string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	defer watcher.Close()
+
+	watched := make(map[string]bool)
+	for _, file := range files {
+		file = filepath.Clean(file)
+		if watcher.Add(filepath.Dir(file)) == nil {
+			watched[file] = true
+		}
+	}
+	if len(watched) == 0 {
+		return
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if watched[filepath.Clean(event.Name)] && event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+				sendWake(stop, wake, "credentials file "+event.Name+" changed")
+			}
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// sendWake sends a wake reason unless the sources are stopped
+func sendWake(stop <-chan struct{}, wake chan<- string, reason string) {
+	select {
+	case wake <- reason:
+	case <-stop:
+	}
+}
@@ -15,6 +15,7 @@
 
 // Package JobObject allows creation of job object for SSM agent process.
 // This is to to control the lifetime of daemon processes launched via the RunDaemon plugin.
+// Limited job objects cap the resources of the commands the executers run.
 package jobobject
 
 import (
@@ -25,11 +26,16 @@ import (
 )
 
 const (
-	JobObjectExtendedLimitInformation = 9
-	childprocessNotInheritHandle      = false
-	processSetQuotaAccess             = 0x100
-	processTerminateAccess            = 0x1
-	jobObjectLimitkillonClose         = 0x2000
+	JobObjectExtendedLimitInformation  = 9
+	JobObjectCpuRateControlInformation = 15
+	childprocessNotInheritHandle       = false
+	processSetQuotaAccess              = 0x100
+	processTerminateAccess             = 0x1
+	jobObjectLimitActiveProcess        = 0x8
+	jobObjectLimitJobMemory            = 0x200
+	jobObjectLimitkillonClose          = 0x2000
+	jobObjectCpuRateControlEnable      = 0x1
+	jobObjectCpuRateControlHardCap     = 0x4
 )
 
 type (
@@ -76,6 +82,19 @@ type JobObjectExtendedLimit struct {
 	PeakJobMemoryUsed     uintptr
 }
 
+type JobObjectCpuRateControl struct {
+	ControlFlags uint32
+	CpuRate      uint32
+}
+
+// JobLimits are the limits of the processes of a job object, zero values are not limited
+type JobLimits struct {
+	// CpuRate is the cpu time of the processes in 1/100 of a percent of all the processors
+	CpuRate            uint32
+	JobMemoryLimit     uintptr
+	ActiveProcessLimit uint32
+}
+
 // Function setInformationJobObject allows setting of specific properties on Job Objects.
 func setInformationJobObject(job syscall.Handle, infoclass uint32, info uintptr, infolen uint32) (err error) {
 	r1, _, e1 := SetInformationJobObject.Call(
@@ -112,6 +131,11 @@ func createJobObject(jobAttrs *syscall.SecurityAttributes, name *uint16) (handle
 
 // Function AttachProcessToJobObject attached child processes to the SSM agent job object.
 func AttachProcessToJobObject(Pid uint32) (err error) {
+	return AssignProcessToJob(SSMjobObject, Pid)
+}
+
+// Function AssignProcessToJob assigns the process to the job object, the processes it starts belong to the job as well.
+func AssignProcessToJob(job syscall.Handle, Pid uint32) (err error) {
 	handle, err := syscall.OpenProcess(processSetQuotaAccess|processTerminateAccess, childprocessNotInheritHandle, Pid)
 	if err != nil {
 		return err
@@ -119,7 +143,7 @@ func AttachProcessToJobObject(Pid uint32) (err error) {
 	defer syscall.CloseHandle(handle)
 
 	r1, _, e1 := AssignProcessToJobObject.Call(
-		uintptr(SSMjobObject),
+		uintptr(job),
 		uintptr(handle))
 
 	if r1 == 0 {
@@ -132,6 +156,39 @@ func AttachProcessToJobObject(Pid uint32) (err error) {
 	return err
 }
 
+// Function CreateLimitedJobObject creates a job object enforcing the limits on the processes assigned to it.
+// Closing the job object does not stop its processes.
+func CreateLimitedJobObject(limits JobLimits) (job syscall.Handle, err error) {
+	if job, err = createJobObject(nil, nil); err != nil {
+		return
+	}
+
+	var jobinfo JobObjectExtendedLimit
+	if limits.JobMemoryLimit > 0 {
+		jobinfo.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+		jobinfo.JobMemoryLimit = limits.JobMemoryLimit
+	}
+	if limits.ActiveProcessLimit > 0 {
+		jobinfo.BasicLimitInformation.LimitFlags |= jobObjectLimitActiveProcess
+		jobinfo.BasicLimitInformation.ActiveProcessLimit = limits.ActiveProcessLimit
+	}
+	if jobinfo.BasicLimitInformation.LimitFlags != 0 {
+		err = setInformationJobObject(job, JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&jobinfo)), uint32(unsafe.Sizeof(jobinfo)))
+	}
+	if err == nil && limits.CpuRate > 0 {
+		cpuinfo := JobObjectCpuRateControl{
+			ControlFlags: jobObjectCpuRateControlEnable | jobObjectCpuRateControlHardCap,
+			CpuRate:      limits.CpuRate,
+		}
+		err = setInformationJobObject(job, JobObjectCpuRateControlInformation, uintptr(unsafe.Pointer(&cpuinfo)), uint32(unsafe.Sizeof(cpuinfo)))
+	}
+	if err != nil {
+		syscall.CloseHandle(job)
+		return 0, err
+	}
+	return
+}
+
 // Set up a job object for the SSM agent process on Windows. This is to control the lifetime of daemon processes
 // launched via the ConfigureDaemon/RunDaemon plugin.
 // The init function is automatically invoked prior to main function being invoked.
@@ -0,0 +1,140 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localipc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	mdsService "github.com/aws/amazon-ssm-agent/agent/runcommand/mds"
+	"github.com/twinj/uuid"
+)
+
+// validName matches the names of the documents and the submission IDs, which are used as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,199}$`)
+
+// commandDirs are the directories of the offline command processor
+type commandDirs struct {
+	root      string
+	staging   string
+	submitted string
+	invalid   string
+}
+
+func defaultCommandDirs() commandDirs {
+	return commandDirs{
+		root:      appconfig.LocalCommandRoot,
+		staging:   appconfig.LocalCommandRootStaging,
+		submitted: appconfig.LocalCommandRootSubmitted,
+		invalid:   appconfig.LocalCommandRootInvalid,
+	}
+}
+
+// submit validates a document and hands it to the offline command processor, the returned submission ID is
+// the name of the document followed by a unique suffix
+func (d commandDirs) submit(documentName string, document json.RawMessage) (submissionID string, err error) {
+	if !validName.MatchString(documentName) {
+		return "", fmt.Errorf("invalid document name %q", documentName)
+	}
+	var content contracts.DocumentContent
+	if err = json.Unmarshal(document, &content); err != nil {
+		return "", fmt.Errorf("invalid document: %v", err)
+	}
+	if content.SchemaVersion == "" {
+		return "", errors.New("invalid document: schemaVersion is missing")
+	}
+	if len(content.MainSteps) == 0 && len(content.RuntimeConfig) == 0 {
+		return "", errors.New("invalid document: the document has no step")
+	}
+
+	uuid.SwitchFormat(uuid.CleanHyphen)
+	submissionID = fmt.Sprintf("%v-%v", documentName, uuid.NewV4().String())
+	if err = fileutil.MakeDirs(d.staging); err != nil {
+		return "", err
+	}
+
+	// write the document outside of the command root first so the processor never picks it up partially written
+	stagingPath := filepath.Join(d.staging, submissionID)
+	if err = fileutil.WriteAllText(stagingPath, string(document)); err != nil {
+		return "", err
+	}
+	if err = os.Rename(stagingPath, filepath.Join(d.root, submissionID)); err != nil {
+		os.Remove(stagingPath)
+		return "", err
+	}
+	return submissionID, nil
+}
+
+// status returns the status of a submitted document, with the result of its command once it started
+func (d commandDirs) status(submissionID string) (response Response, err error) {
+	if !validName.MatchString(submissionID) {
+		return response, fmt.Errorf("invalid submission ID %q", submissionID)
+	}
+	response.SubmissionID = submissionID
+
+	if fileutil.Exists(filepath.Join(d.root, submissionID)) {
+		response.Status = StatusPending
+		return response, nil
+	}
+	if commandID, found := findCommandID(d.invalid, submissionID); found {
+		response.CommandID = commandID
+		response.Status = StatusInvalid
+		return response, nil
+	}
+	commandID, found := findCommandID(d.submitted, submissionID)
+	if !found {
+		return response, fmt.Errorf("submission %v not found", submissionID)
+	}
+	response.CommandID = commandID
+
+	// the result file is written on the first reply of the command
+	resultPath := filepath.Join(d.submitted, submissionID+"."+commandID+mdsService.CommandResultFileSuffix)
+	if !fileutil.Exists(resultPath) {
+		response.Status = string(contracts.ResultStatusInProgress)
+		return response, nil
+	}
+	var result messageContracts.OfflineCommandResult
+	if err = jsonutil.UnmarshalFile(resultPath, &result); err != nil {
+		return response, err
+	}
+	response.Status = string(result.DocumentStatus)
+	response.Result = &result
+	return response, nil
+}
+
+// findCommandID looks for a document the offline command processor moved to dir,
+// such documents are renamed <submission ID>.<command ID>
+func findCommandID(dir string, submissionID string) (commandID string, found bool) {
+	paths, err := filepath.Glob(filepath.Join(dir, submissionID+".*"))
+	if err != nil {
+		return "", false
+	}
+	for _, path := range paths {
+		// command IDs have no dot, which skips the result files
+		if suffix := strings.TrimPrefix(filepath.Base(path), submissionID+"."); !strings.Contains(suffix, ".") {
+			return suffix, true
+		}
+	}
+	return "", false
+}
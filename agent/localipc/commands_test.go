@@ -0,0 +1,114 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localipc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDocument = `{"schemaVersion": "2.2", "mainSteps": [{"action": "aws:runShellScript", "name": "run", "inputs": {"runCommand": ["echo hello"]}}]}`
+
+// newTestCommandDirs creates the directories of the offline command processor in a temporary directory
+func newTestCommandDirs(t *testing.T) (dirs commandDirs, cleanup func()) {
+	root, err := ioutil.TempDir("", "localipc")
+	assert.NoError(t, err)
+	dirs = commandDirs{
+		root:      root,
+		staging:   filepath.Join(root, "staging"),
+		submitted: filepath.Join(root, "submitted"),
+		invalid:   filepath.Join(root, "invalid"),
+	}
+	assert.NoError(t, fileutil.MakeDirs(dirs.submitted))
+	assert.NoError(t, fileutil.MakeDirs(dirs.invalid))
+	return dirs, func() { os.RemoveAll(root) }
+}
+
+func TestSubmit(t *testing.T) {
+	dirs, cleanup := newTestCommandDirs(t)
+	defer cleanup()
+
+	submissionID, err := dirs.submit("bootstrap", json.RawMessage(testDocument))
+	assert.NoError(t, err)
+	assert.Regexp(t, "^bootstrap-[0-9a-f-]{36}$", submissionID)
+
+	content, err := ioutil.ReadFile(filepath.Join(dirs.root, submissionID))
+	assert.NoError(t, err)
+	assert.Equal(t, testDocument, string(content))
+	names, err := fileutil.GetFileNames(dirs.staging)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	response, err := dirs.status(submissionID)
+	assert.NoError(t, err)
+	assert.Equal(t, Response{SubmissionID: submissionID, Status: StatusPending}, response)
+}
+
+func TestSubmitInvalid(t *testing.T) {
+	dirs, cleanup := newTestCommandDirs(t)
+	defer cleanup()
+
+	for name, document := range map[string]string{
+		"../bootstrap": testDocument,
+		"":             testDocument,
+		"bootstrap":    "not json",
+		"nosteps":      `{"schemaVersion": "2.2"}`,
+		"noschema":     `{"mainSteps": [{"action": "aws:runShellScript", "name": "run"}]}`,
+	} {
+		_, err := dirs.submit(name, json.RawMessage(document))
+		assert.Error(t, err, name)
+	}
+	names, err := fileutil.GetFileNames(dirs.root)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestStatus(t *testing.T) {
+	dirs, cleanup := newTestCommandDirs(t)
+	defer cleanup()
+
+	// picked up by the processor, no reply yet
+	submissionID := "bootstrap-1"
+	assert.NoError(t, fileutil.WriteAllText(filepath.Join(dirs.submitted, submissionID+".command1"), testDocument))
+	response, err := dirs.status(submissionID)
+	assert.NoError(t, err)
+	assert.Equal(t, Response{SubmissionID: submissionID, CommandID: "command1", Status: string(contracts.ResultStatusInProgress)}, response)
+
+	// replied
+	result := `{"commandId": "command1", "documentName": "bootstrap-1", "documentStatus": "Success", "steps": []}`
+	assert.NoError(t, fileutil.WriteAllText(filepath.Join(dirs.submitted, submissionID+".command1.result.json"), result))
+	response, err = dirs.status(submissionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "command1", response.CommandID)
+	assert.Equal(t, string(contracts.ResultStatusSuccess), response.Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, response.Result.DocumentStatus)
+
+	// failed to parse
+	assert.NoError(t, fileutil.WriteAllText(filepath.Join(dirs.invalid, "bootstrap-2.command2"), "not json"))
+	response, err = dirs.status("bootstrap-2")
+	assert.NoError(t, err)
+	assert.Equal(t, Response{SubmissionID: "bootstrap-2", CommandID: "command2", Status: StatusInvalid}, response)
+
+	_, err = dirs.status("bootstrap-3")
+	assert.Error(t, err)
+	_, err = dirs.status("*")
+	assert.Error(t, err)
+}
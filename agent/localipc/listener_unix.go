@@ -0,0 +1,59 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package localipc
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// listen serves the endpoint on a unix socket only its owner can connect to, in a directory only its owner can list
+func listen(endpoint string) (net.Listener, error) {
+	dir := filepath.Dir(endpoint)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// the directory may already exist with other permissions
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, err
+	}
+	// remove the socket left behind by a previous run of the agent
+	if err := os.Remove(endpoint); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(endpoint, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// dial connects to the endpoint
+func dial(endpoint string) (io.ReadWriteCloser, error) {
+	return net.Dial("unix", endpoint)
+}
+
+// isAuthorizedUser returns true for root and the user running the agent
+func isAuthorizedUser(uid uint32) bool {
+	return uid == 0 || int(uid) == os.Getuid()
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package localipc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalIpcOverUnixSocket(t *testing.T) {
+	dirs, cleanup := newTestCommandDirs(t)
+	defer cleanup()
+	endpoint := filepath.Join(dirs.root, "ipc", "localcommands.sock")
+	localIpc := &LocalIpc{context: context.NewMockDefault(), endpoint: endpoint, dirs: dirs}
+	assert.NoError(t, localIpc.ModuleExecute(localIpc.context))
+
+	info, err := os.Stat(endpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	response, err := Call(endpoint, Request{Action: ActionSubmit, DocumentName: "bootstrap", Document: json.RawMessage(testDocument)})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, response.Status)
+
+	response, err = Call(endpoint, Request{Action: ActionStatus, SubmissionID: response.SubmissionID})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, response.Status)
+
+	_, err = Call(endpoint, Request{Action: ActionStatus, SubmissionID: "unknown"})
+	assert.EqualError(t, err, "submission unknown not found")
+	_, err = Call(endpoint, Request{Action: "delete"})
+	assert.EqualError(t, err, "unknown action delete")
+
+	assert.NoError(t, localIpc.ModuleRequestStop(contracts.StopTypeSoftStop))
+	_, err = Call(endpoint, Request{Action: ActionStatus, SubmissionID: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestIsAuthorizedUser(t *testing.T) {
+	assert.True(t, isAuthorizedUser(0))
+	assert.True(t, isAuthorizedUser(uint32(os.Getuid())))
+	if os.Getuid() != 12345 {
+		assert.False(t, isAuthorizedUser(12345))
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package localipc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	pipeAccessDuplex          = 0x3
+	fileFlagFirstPipeInstance = 0x80000
+	pipeRejectRemoteClients   = 0x8
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 64 * 1024
+	sddlRevision1             = 1
+	seGroupEnabled            = 0x4
+
+	errorPipeBusy      = syscall.Errno(231)
+	errorPipeConnected = syscall.Errno(535)
+
+	// pipeSecurityDescriptor only grants access to LocalSystem and the Administrators
+	pipeSecurityDescriptor = "D:P(A;;GA;;;SY)(A;;GA;;;BA)"
+	localSystemSid         = "S-1-5-18"
+	administratorsSid      = "S-1-5-32-544"
+
+	// dialRetries is how many times a client tries again while all the instances of the pipe are busy
+	dialRetries     = 10
+	dialRetryPeriod = 100 * time.Millisecond
+)
+
+var (
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+	procCreateNamedPipeW                                     = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe                                     = modkernel32.NewProc("ConnectNamedPipe")
+	procDisconnectNamedPipe                                  = modkernel32.NewProc("DisconnectNamedPipe")
+	procGetNamedPipeClientProcessId                          = modkernel32.NewProc("GetNamedPipeClientProcessId")
+	procConvertStringSecurityDescriptorToSecurityDescriptorW = modadvapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+
+	errListenerClosed = errors.New("listener closed")
+)
+
+// pipeListener accepts the connections to a named pipe, each connection gets its own instance of the pipe
+type pipeListener struct {
+	path      string
+	lock      sync.Mutex
+	next      windows.Handle
+	accepting bool
+	closed    bool
+}
+
+// listen serves the endpoint on a named pipe only LocalSystem and the Administrators can open
+func listen(endpoint string) (net.Listener, error) {
+	// creating the first instance fails when another process already owns the pipe
+	handle, err := createPipe(endpoint, true)
+	if err != nil {
+		return nil, err
+	}
+	return &pipeListener{path: endpoint, next: handle}, nil
+}
+
+// Accept waits for a client to open the next instance of the pipe
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return nil, errListenerClosed
+	}
+	handle := l.next
+	l.next = windows.InvalidHandle
+	if handle == windows.InvalidHandle {
+		var err error
+		if handle, err = createPipe(l.path, false); err != nil {
+			l.lock.Unlock()
+			return nil, err
+		}
+	}
+	l.accepting = true
+	l.lock.Unlock()
+
+	r1, _, e1 := procConnectNamedPipe.Call(uintptr(handle), 0)
+
+	l.lock.Lock()
+	l.accepting = false
+	closed := l.closed
+	l.lock.Unlock()
+
+	if closed {
+		windows.CloseHandle(handle)
+		return nil, errListenerClosed
+	}
+	// the client may open the pipe before ConnectNamedPipe is called
+	if r1 == 0 && e1 != errorPipeConnected {
+		windows.CloseHandle(handle)
+		return nil, e1
+	}
+	return &pipeConn{handle: handle, path: l.path, server: true}, nil
+}
+
+// Close stops accepting connections, the accepted connections stay open
+func (l *pipeListener) Close() error {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return nil
+	}
+	l.closed = true
+	handle := l.next
+	l.next = windows.InvalidHandle
+	accepting := l.accepting
+	l.lock.Unlock()
+
+	if handle != windows.InvalidHandle {
+		windows.CloseHandle(handle)
+	}
+	// open the instance waiting in Accept so it returns
+	if accepting {
+		if client, err := dial(l.path); err == nil {
+			client.Close()
+		}
+	}
+	return nil
+}
+
+// Addr returns the path of the pipe
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// pipeAddr is the path of a named pipe
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn is a connection over an instance of a named pipe opened for synchronous I/O
+type pipeConn struct {
+	handle windows.Handle
+	path   string
+	server bool
+
+	lock      sync.Mutex
+	deadline  *time.Timer
+	expired   bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// errPipeTimeout is returned by the I/O interrupted by the deadline of the connection
+var errPipeTimeout net.Error = pipeTimeoutError{}
+
+type pipeTimeoutError struct{}
+
+func (pipeTimeoutError) Error() string   { return "i/o timeout" }
+func (pipeTimeoutError) Timeout() bool   { return true }
+func (pipeTimeoutError) Temporary() bool { return true }
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	var n uint32
+	if err := windows.ReadFile(c.handle, b, &n, nil); err != nil {
+		if c.isExpired() {
+			return int(n), errPipeTimeout
+		}
+		if err == windows.ERROR_BROKEN_PIPE {
+			return int(n), io.EOF
+		}
+		return int(n), err
+	}
+	return int(n), nil
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	var n uint32
+	err := windows.WriteFile(c.handle, b, &n, nil)
+	if err != nil && c.isExpired() {
+		return int(n), errPipeTimeout
+	}
+	return int(n), err
+}
+
+// Close waits for the client to read what was written before disconnecting it, on the server side
+func (c *pipeConn) Close() error {
+	c.lock.Lock()
+	if c.deadline != nil {
+		c.deadline.Stop()
+		c.deadline = nil
+	}
+	c.lock.Unlock()
+	return c.closeHandle(true)
+}
+
+// closeHandle disconnects the client and closes the handle once, flushing the written data unless the
+// connection expired since the client may never read it
+func (c *pipeConn) closeHandle(flush bool) error {
+	c.closeOnce.Do(func() {
+		if c.server {
+			if flush {
+				windows.FlushFileBuffers(c.handle)
+			}
+			procDisconnectNamedPipe.Call(uintptr(c.handle))
+		}
+		c.closeErr = windows.CloseHandle(c.handle)
+	})
+	return c.closeErr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return pipeAddr(c.path) }
+func (c *pipeConn) RemoteAddr() net.Addr { return pipeAddr(c.path) }
+
+// SetDeadline closes the connection when the deadline expires, synchronous I/O can't be cancelled otherwise,
+// so the connection is unusable after its deadline. A zero value clears the deadline.
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.expired {
+		return errPipeTimeout
+	}
+	if c.deadline != nil {
+		c.deadline.Stop()
+		c.deadline = nil
+	}
+	if !t.IsZero() {
+		c.deadline = time.AfterFunc(time.Until(t), c.expire)
+	}
+	return nil
+}
+
+// SetReadDeadline and SetWriteDeadline share the deadline of the connection
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return c.SetDeadline(t) }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+// expire interrupts the pending I/O by closing the connection when its deadline expires
+func (c *pipeConn) expire() {
+	c.lock.Lock()
+	c.expired = true
+	c.deadline = nil
+	c.lock.Unlock()
+	c.closeHandle(false)
+}
+
+func (c *pipeConn) isExpired() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.expired
+}
+
+// createPipe creates an instance of the named pipe restricted to LocalSystem and the Administrators
+func createPipe(path string, first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	sddl, err := windows.UTF16PtrFromString(pipeSecurityDescriptor)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	var descriptor uintptr
+	if r1, _, e1 := procConvertStringSecurityDescriptorToSecurityDescriptorW.Call(
+		uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&descriptor)), 0); r1 == 0 {
+		return windows.InvalidHandle, e1
+	}
+	defer windows.LocalFree(windows.Handle(descriptor))
+
+	attributes := windows.SecurityAttributes{SecurityDescriptor: descriptor}
+	attributes.Length = uint32(unsafe.Sizeof(attributes))
+	openMode := uint32(pipeAccessDuplex)
+	if first {
+		openMode |= fileFlagFirstPipeInstance
+	}
+	r1, _, e1 := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(name)),
+		uintptr(openMode),
+		pipeRejectRemoteClients,
+		pipeUnlimitedInstances,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		uintptr(unsafe.Pointer(&attributes)))
+	if windows.Handle(r1) == windows.InvalidHandle {
+		return windows.InvalidHandle, e1
+	}
+	return windows.Handle(r1), nil
+}
+
+// dial opens an instance of the pipe, waiting a little while all the instances are busy
+func dial(endpoint string) (io.ReadWriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	for retry := 0; ; retry++ {
+		handle, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+		if err == nil {
+			return &pipeConn{handle: handle, path: endpoint}, nil
+		}
+		if err != errorPipeBusy || retry >= dialRetries {
+			return nil, err
+		}
+		time.Sleep(dialRetryPeriod)
+	}
+}
+
+// authorizePeer checks that the process connected to the pipe runs as LocalSystem or as an elevated administrator
+func authorizePeer(conn net.Conn) error {
+	pipe, ok := conn.(*pipeConn)
+	if !ok {
+		return fmt.Errorf("unexpected connection type %T", conn)
+	}
+	var pid uint32
+	if r1, _, e1 := procGetNamedPipeClientProcessId.Call(uintptr(pipe.handle), uintptr(unsafe.Pointer(&pid))); r1 == 0 {
+		return e1
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_INFORMATION, false, pid)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(process)
+	var token windows.Token
+	if err = windows.OpenProcessToken(process, windows.TOKEN_QUERY, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+
+	authorized, err := isAuthorizedToken(token)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return fmt.Errorf("process %v is not run by LocalSystem or an administrator", pid)
+	}
+	return nil
+}
+
+// isAuthorizedToken returns true for the tokens of LocalSystem and the tokens with the Administrators group enabled,
+// which excludes the administrators running without elevation
+func isAuthorizedToken(token windows.Token) (bool, error) {
+	localSystem, err := windows.StringToSid(localSystemSid)
+	if err != nil {
+		return false, err
+	}
+	administrators, err := windows.StringToSid(administratorsSid)
+	if err != nil {
+		return false, err
+	}
+
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return false, err
+	}
+	if windows.EqualSid(user.User.Sid, localSystem) {
+		return true, nil
+	}
+
+	groups, err := token.GetTokenGroups()
+	if err != nil {
+		return false, err
+	}
+	if groups.GroupCount == 0 {
+		return false, nil
+	}
+	for _, group := range (*[1 << 16]windows.SIDAndAttributes)(unsafe.Pointer(&groups.Groups[0]))[:groups.GroupCount:groups.GroupCount] {
+		if group.Attributes&seGroupEnabled != 0 && windows.EqualSid(group.Sid, administrators) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
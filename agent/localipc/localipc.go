@@ -0,0 +1,212 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localipc serves the local endpoint through which the tooling of the instance submits command documents
+// and queries their status without going through the service, such as for bootstrap and break-glass scenarios.
+package localipc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+)
+
+const (
+	name = "LocalIpc"
+
+	// maxRequestSize bounds the size of a request, documents included
+	maxRequestSize = 1024 * 1024
+
+	// requestTimeout is how long a client has to send its request, where deadlines are supported
+	requestTimeout = 30 * time.Second
+)
+
+// Actions of the requests
+const (
+	// ActionSubmit submits a command document to the offline command processor
+	ActionSubmit = "submit"
+	// ActionStatus returns the status of a submitted command document
+	ActionStatus = "status"
+)
+
+// Statuses of the submissions besides the statuses of the documents
+const (
+	// StatusPending is the status of a submitted document not picked up by the offline command processor yet
+	StatusPending = "Pending"
+	// StatusInvalid is the status of a submitted document the offline command processor failed to parse
+	StatusInvalid = "Invalid"
+)
+
+// Request is a request sent to the local endpoint, a connection carries a single request
+type Request struct {
+	Action string `json:"action"`
+	// DocumentName names the submitted document, it only contains letters, digits, '-', '_' and '.'
+	DocumentName string          `json:"documentName,omitempty"`
+	Document     json.RawMessage `json:"document,omitempty"`
+	SubmissionID string          `json:"submissionId,omitempty"`
+}
+
+// Response is the response of the local endpoint to a request
+type Response struct {
+	SubmissionID string                                 `json:"submissionId,omitempty"`
+	CommandID    string                                 `json:"commandId,omitempty"`
+	Status       string                                 `json:"status,omitempty"`
+	Result       *messageContracts.OfflineCommandResult `json:"result,omitempty"`
+	Error        string                                 `json:"error,omitempty"`
+}
+
+// LocalIpc is the core module serving the local endpoint
+type LocalIpc struct {
+	context  context.T
+	endpoint string
+	dirs     commandDirs
+	lock     sync.Mutex
+	listener net.Listener
+}
+
+// NewLocalIpc creates the core module serving the local endpoint
+func NewLocalIpc(context context.T) *LocalIpc {
+	return &LocalIpc{
+		context:  context.With("[" + name + "]"),
+		endpoint: appconfig.LocalIpcEndpoint,
+		dirs:     defaultCommandDirs(),
+	}
+}
+
+// ICoreModule implementation
+
+// ModuleName returns the module name
+func (l *LocalIpc) ModuleName() string {
+	return name
+}
+
+// ModuleExecute starts serving the local endpoint
+func (l *LocalIpc) ModuleExecute(context context.T) (err error) {
+	log := l.context.Log()
+	listener, err := listen(l.endpoint)
+	if err != nil {
+		log.Errorf("unable to serve the local endpoint %v. %v", l.endpoint, err)
+		return nil
+	}
+
+	l.lock.Lock()
+	l.listener = listener
+	l.lock.Unlock()
+
+	go l.serve(listener)
+	log.Infof("Accepting command documents on %v", l.endpoint)
+	return nil
+}
+
+// ModuleRequestStop stops serving the local endpoint, the running commands are not affected
+func (l *LocalIpc) ModuleRequestStop(stopType contracts.StopType) (err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.listener != nil {
+		err = l.listener.Close()
+		l.listener = nil
+	}
+	return err
+}
+
+// serve accepts the connections to the local endpoint until the listener is closed
+func (l *LocalIpc) serve(listener net.Listener) {
+	log := l.context.Log()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			l.lock.Lock()
+			stopped := l.listener != listener
+			l.lock.Unlock()
+			if !stopped {
+				log.Errorf("Local endpoint stopped: %v", err)
+			}
+			return
+		}
+		go l.handleConnection(conn)
+	}
+}
+
+// handleConnection authorizes the peer of a connection, then reads its request and writes the response
+func (l *LocalIpc) handleConnection(conn net.Conn) {
+	log := l.context.Log()
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var response Response
+	if err := authorizePeer(conn); err != nil {
+		log.Warnf("Rejected connection to the local endpoint: %v", err)
+		response.Error = "access denied"
+	} else {
+		var request Request
+		if err = json.NewDecoder(io.LimitReader(conn, maxRequestSize)).Decode(&request); err != nil {
+			response.Error = "invalid request: " + err.Error()
+		} else {
+			response = l.handleRequest(request)
+		}
+	}
+
+	if err := json.NewEncoder(conn).Encode(response); err != nil {
+		log.Warnf("Failed to write the response of the local endpoint: %v", err)
+	}
+}
+
+// handleRequest runs the action of a request
+func (l *LocalIpc) handleRequest(request Request) (response Response) {
+	log := l.context.Log()
+	var err error
+	switch request.Action {
+	case ActionSubmit:
+		if response.SubmissionID, err = l.dirs.submit(request.DocumentName, request.Document); err == nil {
+			log.Infof("Document %v was submitted through the local endpoint", response.SubmissionID)
+			response.Status = StatusPending
+		}
+	case ActionStatus:
+		response, err = l.dirs.status(request.SubmissionID)
+	default:
+		err = errors.New("unknown action " + request.Action)
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	return response
+}
+
+// Call sends a request to the local endpoint of the agent and returns its response,
+// the error of the response is returned as an error
+func Call(endpoint string, request Request) (response Response, err error) {
+	conn, err := dial(endpoint)
+	if err != nil {
+		return response, err
+	}
+	defer conn.Close()
+
+	if err = json.NewEncoder(conn).Encode(request); err != nil {
+		return response, err
+	}
+	if err = json.NewDecoder(conn).Decode(&response); err != nil {
+		return response, err
+	}
+	if response.Error != "" {
+		err = errors.New(response.Error)
+	}
+	return response, err
+}
@@ -0,0 +1,237 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package audit implements the append-only audit log of the documents run by the agent.
+// Each entry holds the hash of the previous entry so that tampering with the log can be detected.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// AuditFile is the name of the audit log file in the log directory.
+const AuditFile = "audit.log"
+
+// EventType is the type of an audited event.
+type EventType string
+
+const (
+	// DocumentStarted is logged when the agent starts running a document.
+	DocumentStarted EventType = "DocumentStarted"
+
+	// DocumentFinished is logged when a document reaches a terminal status.
+	DocumentFinished EventType = "DocumentFinished"
+
+	// DocumentDenied is logged when the local policy denies a document, with the reason of the denial.
+	DocumentDenied EventType = "DocumentDenied"
+
+	// DocumentCancelRequested is logged when the agent receives a request to cancel a document.
+	DocumentCancelRequested EventType = "DocumentCancelRequested"
+
+	// SessionShellStarted is logged when a session shell starts, with the OS user it runs as.
+	SessionShellStarted EventType = "SessionShellStarted"
+
+	// SessionCommandRejected is logged when the command of an interactive commands session is rejected by the command filters.
+	SessionCommandRejected EventType = "SessionCommandRejected"
+)
+
+// Entry is an audit log entry.
+type Entry struct {
+	Time          time.Time      `json:"time"`
+	Event         EventType      `json:"event"`
+	DocumentType  string         `json:"documentType,omitempty"`
+	DocumentName  string         `json:"documentName,omitempty"`
+	CommandID     string         `json:"commandId,omitempty"`
+	AssociationID string         `json:"associationId,omitempty"`
+	SessionID     string         `json:"sessionId,omitempty"`
+	Requester     string         `json:"requester,omitempty"`
+	RunAsUser     string         `json:"runAsUser,omitempty"`
+	Command       string         `json:"command,omitempty"`
+	Status        string         `json:"status,omitempty"`
+	ExitCodes     map[string]int `json:"exitCodes,omitempty"`
+	Reason        string         `json:"reason,omitempty"`
+	// PreviousHash is the hash of the previous entry of the log, empty for the first entry.
+	PreviousHash string `json:"previousHash"`
+	// Hash is the hash of this entry, computed with an empty Hash.
+	Hash string `json:"hash"`
+}
+
+// Logger records audit entries.
+type Logger interface {
+	// Log appends the given entry to the audit log.
+	Log(entry Entry) error
+}
+
+// FileLogger is a Logger that appends the entries to a file, one JSON object per line.
+// The agent and the session workers append to the same file, so the file is locked while an entry is chained
+// to the last entry of the file and appended.
+type FileLogger struct {
+	path string
+	m    sync.Mutex
+}
+
+var defaultLogger *FileLogger
+var defaultLock sync.Mutex
+
+// Default returns the audit logger writing to the audit file of the agent log directory.
+func Default() Logger {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultLogger == nil {
+		defaultLogger = NewFileLogger(filepath.Join(log.DefaultLogDir, AuditFile))
+	}
+	return defaultLogger
+}
+
+// NewFileLogger creates an audit logger that appends the entries to the given file.
+func NewFileLogger(path string) *FileLogger {
+	return &FileLogger{path: path}
+}
+
+// Log chains the entry to the last entry of the file and appends it to the file.
+func (l *FileLogger) Log(entry Entry) (err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if err = lockFile(file); err != nil {
+		return fmt.Errorf("failed to lock %v: %v", l.path, err)
+	}
+	defer unlockFile(file)
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	// another process may have appended entries since the last call
+	if entry.PreviousHash, err = lastHash(file); err != nil {
+		return fmt.Errorf("failed to read the last entry of %v: %v", l.path, err)
+	}
+	if entry.Hash, err = hash(entry); err != nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, err = file.Write(append(line, '\n'))
+	return
+}
+
+// Verify checks the hash chain of the given audit log file.
+// Returns an error describing the first entry that has been tampered with, if any.
+func Verify(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	previousHash := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("entry %v is not valid: %v", lineNumber, err)
+		}
+		if entry.PreviousHash != previousHash {
+			return fmt.Errorf("entry %v does not follow the previous entry", lineNumber)
+		}
+		expected, err := hash(entry)
+		if err != nil {
+			return err
+		}
+		if entry.Hash != expected {
+			return fmt.Errorf("entry %v has been modified", lineNumber)
+		}
+		previousHash = entry.Hash
+	}
+	return scanner.Err()
+}
+
+// hash returns the hash of the given entry, computed with an empty Hash.
+func hash(entry Entry) (string, error) {
+	entry.Hash = ""
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastHashReadSize is the size of the blocks read from the end of the audit log to find its last entry
+const lastHashReadSize = 4096
+
+// lastHash returns the hash of the last entry of the given audit log file, empty if there is none.
+// The file is read backwards from its end until the last entry is complete.
+func lastHash(file *os.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+
+	var tail []byte
+	for offset := size; offset > 0; {
+		readSize := int64(lastHashReadSize)
+		if readSize > offset {
+			readSize = offset
+		}
+		offset -= readSize
+		block := make([]byte, readSize)
+		if _, err = file.ReadAt(block, offset); err != nil && err != io.EOF {
+			return "", err
+		}
+		tail = append(block, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if index := bytes.LastIndexByte(trimmed, '\n'); index >= 0 {
+			return entryHash(trimmed[index+1:])
+		}
+		if offset == 0 {
+			return entryHash(trimmed)
+		}
+	}
+	return "", nil
+}
+
+// entryHash returns the hash of the given line of the audit log, empty for an empty line
+func entryHash(line []byte) (string, error) {
+	if len(line) == 0 {
+		return "", nil
+	}
+	var entry Entry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return "", fmt.Errorf("last entry is not valid: %v", err)
+	}
+	return entry.Hash, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogFile(t *testing.T) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.Nil(t, err)
+	return filepath.Join(dir, AuditFile), func() { os.RemoveAll(dir) }
+}
+
+func readEntries(t *testing.T, path string) (entries []Entry) {
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry Entry
+		assert.Nil(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return
+}
+
+func TestFileLoggerChainsEntries(t *testing.T) {
+	path, cleanup := newTestLogFile(t)
+	defer cleanup()
+
+	logger := NewFileLogger(path)
+	assert.Nil(t, logger.Log(Entry{Event: DocumentStarted, CommandID: "command1"}))
+	assert.Nil(t, logger.Log(Entry{Event: DocumentFinished, CommandID: "command1", Status: "Success", ExitCodes: map[string]int{"aws:runShellScript": 0}}))
+
+	entries := readEntries(t, path)
+	assert.Len(t, entries, 2)
+	assert.Empty(t, entries[0].PreviousHash)
+	assert.NotEmpty(t, entries[0].Hash)
+	assert.False(t, entries[0].Time.IsZero())
+	assert.Equal(t, entries[0].Hash, entries[1].PreviousHash)
+	assert.Equal(t, 0, entries[1].ExitCodes["aws:runShellScript"])
+	assert.Nil(t, Verify(path))
+}
+
+func TestFileLoggerResumesChain(t *testing.T) {
+	path, cleanup := newTestLogFile(t)
+	defer cleanup()
+
+	assert.Nil(t, NewFileLogger(path).Log(Entry{Event: DocumentStarted, CommandID: "command1"}))
+	// a new logger, as after an agent restart, continues the chain of the existing file
+	assert.Nil(t, NewFileLogger(path).Log(Entry{Event: DocumentFinished, CommandID: "command1"}))
+
+	entries := readEntries(t, path)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, entries[0].Hash, entries[1].PreviousHash)
+	assert.Nil(t, Verify(path))
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	path, cleanup := newTestLogFile(t)
+	defer cleanup()
+
+	logger := NewFileLogger(path)
+	for _, commandID := range []string{"command1", "command2", "command3"} {
+		assert.Nil(t, logger.Log(Entry{Event: DocumentStarted, CommandID: commandID}))
+	}
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+
+	// modified entry
+	modified := strings.Replace(lines[1], "command2", "command4", 1)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(strings.Join([]string{lines[0], modified, lines[2]}, "\n")+"\n"), 0600))
+	err = Verify(path)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "entry 2 has been modified")
+
+	// removed entry
+	assert.Nil(t, ioutil.WriteFile(path, []byte(strings.Join([]string{lines[0], lines[2]}, "\n")+"\n"), 0600))
+	err = Verify(path)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "entry 2 does not follow the previous entry")
+}
+
+func TestFileLoggersShareFile(t *testing.T) {
+	path, cleanup := newTestLogFile(t)
+	defer cleanup()
+
+	// the agent and a session worker write to the same file, each with its own logger
+	agentLogger := NewFileLogger(path)
+	workerLogger := NewFileLogger(path)
+	assert.Nil(t, agentLogger.Log(Entry{Event: DocumentStarted, SessionID: "session1"}))
+	assert.Nil(t, workerLogger.Log(Entry{Event: SessionShellStarted, SessionID: "session1", RunAsUser: "ssm-user"}))
+	assert.Nil(t, agentLogger.Log(Entry{Event: DocumentFinished, SessionID: "session1"}))
+	assert.Nil(t, Verify(path))
+
+	done := make(chan bool)
+	for _, logger := range []*FileLogger{agentLogger, workerLogger} {
+		go func(logger *FileLogger) {
+			for i := 0; i < 50; i++ {
+				assert.Nil(t, logger.Log(Entry{Event: DocumentStarted, Command: strings.Repeat("x", 5000)}))
+			}
+			done <- true
+		}(logger)
+	}
+	<-done
+	<-done
+
+	assert.Len(t, readEntries(t, path), 103)
+	assert.Nil(t, Verify(path))
+}
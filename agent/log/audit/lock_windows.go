@@ -0,0 +1,56 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package audit
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	lockfileExclusiveLock = 0x2
+	// the lock covers the whole file, whatever its size
+	lockRangeLow  = 0xffffffff
+	lockRangeHigh = 0xffffffff
+)
+
+var (
+	modkernel32      = windows.NewLazySystemDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+// lockFile takes an exclusive lock on the file, waiting for the other processes to release it
+func lockFile(file *os.File) error {
+	var overlapped windows.Overlapped
+	if r1, _, e1 := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock, 0, lockRangeLow, lockRangeHigh,
+		uintptr(unsafe.Pointer(&overlapped))); r1 == 0 {
+		return e1
+	}
+	return nil
+}
+
+// unlockFile releases the lock on the file
+func unlockFile(file *os.File) error {
+	var overlapped windows.Overlapped
+	if r1, _, e1 := procUnlockFileEx.Call(file.Fd(), 0, lockRangeLow, lockRangeHigh,
+		uintptr(unsafe.Pointer(&overlapped))); r1 == 0 {
+		return e1
+	}
+	return nil
+}
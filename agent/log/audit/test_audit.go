@@ -0,0 +1,36 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import "github.com/stretchr/testify/mock"
+
+// Note: This code is used in the test files. However, this code is not in a _test.go file
+// because then we would have to copy it in every test package that needs the mock.
+
+// MockedLogger stands for a mocked audit logger.
+type MockedLogger struct {
+	mock.Mock
+}
+
+// NewMockedLogger returns a mocked audit logger that accepts any entry.
+func NewMockedLogger() *MockedLogger {
+	logger := new(MockedLogger)
+	logger.On("Log", mock.Anything).Return(nil)
+	return logger
+}
+
+// Log mocks the method with the same name.
+func (m *MockedLogger) Log(entry Entry) error {
+	return m.Called(entry).Error(0)
+}
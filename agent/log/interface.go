@@ -75,4 +75,6 @@ type BasicT interface {
 type T interface {
 	BasicT
 	WithContext(context ...string) (contextLogger T)
+	WithFields(fields map[string]interface{}) (fieldsLogger T)
+	WithCorrelationID(correlationID string) (correlatedLogger T)
 }
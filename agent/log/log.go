@@ -15,7 +15,9 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/cihub/seelog"
@@ -26,6 +28,20 @@ const (
 	ErrorFile = "errors.log"
 )
 
+// Names of the fields commonly attached to structured log entries.
+const (
+	FieldCommandID    = "commandId"
+	FieldDocumentName = "documentName"
+	FieldPluginID     = "pluginId"
+	FieldSessionID    = "sessionId"
+
+	// FieldCorrelationID is the field that holds the id of the execution a structured log entry belongs to.
+	FieldCorrelationID = "correlationId"
+
+	// FieldMessage is the field that holds the message of a structured log entry.
+	FieldMessage = "message"
+)
+
 var loadedLogger T
 var PkgMutex = new(sync.Mutex)
 
@@ -48,6 +64,8 @@ func DefaultLogger() T {
 // ContextFormatFilter is a filter that can add a context to the parameters of a log message.
 type ContextFormatFilter struct {
 	Context []string
+	// CorrelationID is the id of the execution the messages belong to, added after the context if set
+	CorrelationID string
 }
 
 // Filter adds the context at the beginning of the parameter slice.
@@ -60,6 +78,9 @@ func (f ContextFormatFilter) Filter(params ...interface{}) (newParams []interfac
 	for i, param := range params {
 		newParams[ctxLen+i] = param
 	}
+	if f.CorrelationID != "" {
+		newParams = append(newParams[:ctxLen], append([]interface{}{correlationTag(f.CorrelationID) + " "}, newParams[ctxLen:]...)...)
+	}
 	return newParams
 }
 
@@ -69,11 +90,69 @@ func (f ContextFormatFilter) Filterf(format string, params ...interface{}) (newF
 	for _, param := range f.Context {
 		newFormat += param + " "
 	}
+	if f.CorrelationID != "" {
+		newFormat += strings.Replace(correlationTag(f.CorrelationID), "%", "%%", -1) + " "
+	}
 	newFormat += format
 	newParams = params
 	return
 }
 
+// correlationTag returns the tag identifying the execution a log message belongs to.
+func correlationTag(correlationID string) string {
+	return "[" + FieldCorrelationID + "=" + correlationID + "]"
+}
+
+// FieldsFormatFilter is a filter that turns a log message into a JSON object holding the given fields.
+type FieldsFormatFilter struct {
+	Context []string
+	Fields  map[string]interface{}
+	// CorrelationID is the id of the execution the entries belong to, added as a field if set
+	CorrelationID string
+}
+
+// Filter formats the parameters into the message of a JSON log entry.
+func (f FieldsFormatFilter) Filter(params ...interface{}) (newParams []interface{}) {
+	return []interface{}{f.entry(fmt.Sprint(params...))}
+}
+
+// Filterf formats the format string and parameters into the message of a JSON log entry.
+func (f FieldsFormatFilter) Filterf(format string, params ...interface{}) (newFormat string, newParams []interface{}) {
+	return "%s", []interface{}{f.entry(fmt.Sprintf(format, params...))}
+}
+
+// entry returns the JSON log entry for the given message, prefixed with the context.
+func (f FieldsFormatFilter) entry(message string) string {
+	if len(f.Context) > 0 {
+		message = strings.Join(f.Context, " ") + " " + message
+	}
+	overrides := map[string]interface{}{FieldMessage: message}
+	if f.CorrelationID != "" {
+		overrides[FieldCorrelationID] = f.CorrelationID
+	}
+	entry := mergeFields(f.Fields, overrides)
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		// fall back to the plain message rather than losing the log entry
+		return message
+	}
+	return string(bytes)
+}
+
+// mergeFields returns a new map with the fields of both maps; fields of the second map win.
+func mergeFields(fields map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(fields)+len(overrides))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// GetLogConfigBytes returns the seelog configurations of the agent, with the rotation settings
+// and the platform log applied.
 func GetLogConfigBytes() []byte {
-	return getLogConfigBytes()
+	return applyPlatformSink(applyRotationConfig(getLogConfigBytes(), getRotationConfig()), isPlatformSinkEnabled())
 }
@@ -26,6 +26,9 @@ const (
 	// See Seelog documentation to customize the logger
 	DefaultSeelogConfigFilePath = "/etc/amazon/ssm/seelog.xml"
 
+	// DefaultLogLevelFilePath specifies the control file that overrides the log level at runtime
+	DefaultLogLevelFilePath = "/etc/amazon/ssm/loglevel"
+
 	DefaultLogDir = "/var/log/amazon/ssm"
 )
 
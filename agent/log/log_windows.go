@@ -29,6 +29,9 @@ var DefaultLogDir = filepath.Join(appconfig.SSMDataPath, "Logs")
 // See Seelog documentation to customize the logger
 var DefaultSeelogConfigFilePath = filepath.Join(appconfig.DefaultProgramFolder, appconfig.SeelogConfigFileName)
 
+// DefaultLogLevelFilePath specifies the control file that overrides the log level at runtime
+var DefaultLogLevelFilePath = filepath.Join(appconfig.DefaultProgramFolder, "loglevel")
+
 // getLogConfigBytes reads and returns the seelog configs from the config file path if present
 // otherwise returns the seelog default configurations
 // Windows uses default log configuration if there is no seelog.xml override provided.
@@ -0,0 +1,120 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+const (
+	// PlatformSinkSource is the name under which the agent writes to the platform log.
+	PlatformSinkSource = "amazon-ssm-agent"
+
+	// platformReceiverName is the name of the seelog custom receiver writing to the platform log
+	platformReceiverName = "ssmplatformlog"
+
+	// platformFormatID is the id of the seelog format used by the platform log receiver
+	platformFormatID = "fmtplatform"
+)
+
+// platformSink writes log messages to the native log of the platform.
+type platformSink interface {
+	write(level seelog.LogLevel, message string, context seelog.LogContextInterface) error
+	close() error
+}
+
+var platformSinkEnabled bool
+var platformSinkLock sync.RWMutex
+
+// outputsEndTag and formatsEndTag match the end of the outputs and formats of the seelog configurations
+var outputsEndTag = regexp.MustCompile(`</outputs\s*>`)
+var formatsEndTag = regexp.MustCompile(`</formats\s*>`)
+var seelogEndTag = regexp.MustCompile(`</seelog\s*>`)
+
+func init() {
+	seelog.RegisterReceiver(platformReceiverName, &platformReceiver{})
+}
+
+// SetPlatformSinkEnabled sets whether warnings and errors are also written to the native log of the platform,
+// the Windows Event Log on Windows and systemd-journald on Linux.
+func SetPlatformSinkEnabled(enabled bool) {
+	platformSinkLock.Lock()
+	defer platformSinkLock.Unlock()
+	platformSinkEnabled = enabled
+}
+
+// isPlatformSinkEnabled returns whether warnings and errors are also written to the native log of the platform.
+func isPlatformSinkEnabled() bool {
+	platformSinkLock.RLock()
+	defer platformSinkLock.RUnlock()
+	return platformSinkEnabled
+}
+
+// applyPlatformSink adds the platform log receiver to the outputs of the seelog configurations.
+func applyPlatformSink(seelogConfig []byte, enabled bool) []byte {
+	if !enabled || !platformSinkSupported || !outputsEndTag.Match(seelogConfig) {
+		return seelogConfig
+	}
+	receiver := fmt.Sprintf(`<filter levels="warn,error,critical"><custom name="%v" formatid="%v"/></filter>`, platformReceiverName, platformFormatID)
+	format := fmt.Sprintf(`<format id="%v" format="%%Msg"/>`, platformFormatID)
+
+	config := outputsEndTag.ReplaceAllFunc(seelogConfig, func(tag []byte) []byte {
+		return append([]byte(receiver), tag...)
+	})
+	if formatsEndTag.Match(config) {
+		return formatsEndTag.ReplaceAllFunc(config, func(tag []byte) []byte {
+			return append([]byte(format), tag...)
+		})
+	}
+	return seelogEndTag.ReplaceAllFunc(config, func(tag []byte) []byte {
+		return append([]byte("<formats>"+format+"</formats>"), tag...)
+	})
+}
+
+// platformReceiver is the seelog custom receiver writing to the native log of the platform.
+type platformReceiver struct {
+	sink platformSink
+}
+
+// AfterParse opens the platform log.
+// A platform log that cannot be opened is reported but does not prevent the agent from logging to its files.
+func (r *platformReceiver) AfterParse(initArgs seelog.CustomReceiverInitArgs) (err error) {
+	if r.sink, err = openPlatformSink(PlatformSinkSource); err != nil {
+		fmt.Println("Error opening the platform log, agent logs are only written to the log files:", err)
+	}
+	return nil
+}
+
+// ReceiveMessage writes the message to the platform log.
+func (r *platformReceiver) ReceiveMessage(message string, level seelog.LogLevel, context seelog.LogContextInterface) error {
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.write(level, message, context)
+}
+
+// Flush does nothing, messages are written to the platform log as they are received.
+func (r *platformReceiver) Flush() {}
+
+// Close closes the platform log.
+func (r *platformReceiver) Close() error {
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.close()
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cihub/seelog"
+)
+
+// platformSinkSupported indicates whether the platform has a native log the agent can write to.
+const platformSinkSupported = true
+
+// journalSocket is the socket of the native protocol of systemd-journald
+const journalSocket = "/run/systemd/journal/socket"
+
+// syslog priorities used by systemd-journald
+const (
+	journalPriorityCritical = 2
+	journalPriorityError    = 3
+	journalPriorityWarning  = 4
+	journalPriorityInfo     = 6
+)
+
+// journalSink writes log messages to systemd-journald.
+type journalSink struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+// openPlatformSink opens the connection to systemd-journald.
+func openPlatformSink(source string) (platformSink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journalSink{conn: conn, identifier: source}, nil
+}
+
+// write sends the message and its structured fields to systemd-journald.
+func (s *journalSink) write(level seelog.LogLevel, message string, context seelog.LogContextInterface) error {
+	_, err := s.conn.Write(journalEntry(s.identifier, level, message, context))
+	return err
+}
+
+// close closes the connection to systemd-journald.
+func (s *journalSink) close() error {
+	return s.conn.Close()
+}
+
+// journalEntry serializes the message in the native protocol of systemd-journald.
+// The fields of a structured log message are added as SSM_<FIELD> journal fields.
+func journalEntry(identifier string, level seelog.LogLevel, message string, context seelog.LogContextInterface) []byte {
+	var entry bytes.Buffer
+	writeJournalField(&entry, "SYSLOG_IDENTIFIER", identifier)
+	writeJournalField(&entry, "PRIORITY", strconv.Itoa(journalPriority(level)))
+	if context != nil && context.IsValid() {
+		writeJournalField(&entry, "CODE_FILE", context.FileName())
+		writeJournalField(&entry, "CODE_LINE", strconv.Itoa(context.Line()))
+		writeJournalField(&entry, "CODE_FUNC", context.Func())
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(message), &fields); err == nil {
+		for name, value := range fields {
+			if name == FieldMessage {
+				continue
+			}
+			writeJournalField(&entry, "SSM_"+journalFieldName(name), fmt.Sprint(value))
+		}
+		if msg, ok := fields[FieldMessage].(string); ok {
+			message = msg
+		}
+	}
+	writeJournalField(&entry, "MESSAGE", message)
+	return entry.Bytes()
+}
+
+// writeJournalField writes a field in the native protocol of systemd-journald.
+// Values holding a newline are written with their length as they cannot be delimited by a newline.
+func writeJournalField(entry *bytes.Buffer, name string, value string) {
+	entry.WriteString(name)
+	if strings.Contains(value, "\n") {
+		entry.WriteByte('\n')
+		binary.Write(entry, binary.LittleEndian, uint64(len(value)))
+	} else {
+		entry.WriteByte('=')
+	}
+	entry.WriteString(value)
+	entry.WriteByte('\n')
+}
+
+// journalFieldName turns a field name into a valid journal field name,
+// made of upper case letters, digits and underscores.
+func journalFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// journalPriority returns the syslog priority of a seelog level.
+func journalPriority(level seelog.LogLevel) int {
+	switch level {
+	case seelog.CriticalLvl:
+		return journalPriorityCritical
+	case seelog.ErrorLvl:
+		return journalPriorityError
+	case seelog.WarnLvl:
+		return journalPriorityWarning
+	default:
+		return journalPriorityInfo
+	}
+}
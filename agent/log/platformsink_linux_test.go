@@ -0,0 +1,38 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package log
+
+import (
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry(t *testing.T) {
+	entry := journalEntry("amazon-ssm-agent", seelog.WarnLvl, "plugin timed out", nil)
+	assert.Equal(t, "SYSLOG_IDENTIFIER=amazon-ssm-agent\nPRIORITY=4\nMESSAGE=plugin timed out\n", string(entry))
+}
+
+func TestJournalEntryWithFields(t *testing.T) {
+	entry := journalEntry("amazon-ssm-agent", seelog.ErrorLvl, `{"commandId":"command1","message":"plugin failed"}`, nil)
+	assert.Equal(t, "SYSLOG_IDENTIFIER=amazon-ssm-agent\nPRIORITY=3\nSSM_COMMANDID=command1\nMESSAGE=plugin failed\n", string(entry))
+}
+
+func TestJournalEntryMultiline(t *testing.T) {
+	entry := journalEntry("amazon-ssm-agent", seelog.CriticalLvl, "line1\nline2", nil)
+	assert.Equal(t, "SYSLOG_IDENTIFIER=amazon-ssm-agent\nPRIORITY=2\nMESSAGE\n\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\n", string(entry))
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build !linux,!windows
+
+package log
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// platformSinkSupported indicates whether the platform has a native log the agent can write to.
+const platformSinkSupported = false
+
+// openPlatformSink fails, the platform has no native log supported by the agent.
+func openPlatformSink(source string) (platformSink, error) {
+	return nil, fmt.Errorf("no platform log is supported on %v", runtime.GOOS)
+}
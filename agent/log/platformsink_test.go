@@ -0,0 +1,48 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPlatformSink(t *testing.T) {
+	config := `<seelog type="sync">
+    <outputs>
+        <console/>
+    </outputs>
+</seelog>`
+
+	// disabled keeps the seelog settings
+	assert.Equal(t, config, string(applyPlatformSink([]byte(config), false)))
+
+	if !platformSinkSupported {
+		assert.Equal(t, config, string(applyPlatformSink([]byte(config), true)))
+		return
+	}
+	assert.Equal(t, `<seelog type="sync">
+    <outputs>
+        <console/>
+    <filter levels="warn,error,critical"><custom name="ssmplatformlog" formatid="fmtplatform"/></filter></outputs>
+<formats><format id="fmtplatform" format="%Msg"/></formats></seelog>`, string(applyPlatformSink([]byte(config), true)))
+}
+
+func TestApplyPlatformSinkToDefaultConfig(t *testing.T) {
+	config := applyPlatformSink(DefaultConfig(), true)
+	_, err := seelog.LoggerFromConfigAsBytes(config)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package log
+
+import (
+	"github.com/cihub/seelog"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// platformSinkSupported indicates whether the platform has a native log the agent can write to.
+const platformSinkSupported = true
+
+// Event IDs of the agent events in the Windows Event Log.
+const (
+	EventIDWarning  = 100
+	EventIDError    = 200
+	EventIDCritical = 300
+)
+
+// eventLogSink writes log messages to the Windows Event Log.
+type eventLogSink struct {
+	eventLog *eventlog.Log
+}
+
+// openPlatformSink opens the Windows Event Log, registering the agent as an event source if needed.
+func openPlatformSink(source string) (platformSink, error) {
+	// registration fails when the source already exists, which is fine
+	eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+	eventLog, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogSink{eventLog: eventLog}, nil
+}
+
+// write reports the message as an event of the Windows Event Log.
+func (s *eventLogSink) write(level seelog.LogLevel, message string, context seelog.LogContextInterface) error {
+	switch level {
+	case seelog.CriticalLvl:
+		return s.eventLog.Error(EventIDCritical, message)
+	case seelog.ErrorLvl:
+		return s.eventLog.Error(EventIDError, message)
+	default:
+		return s.eventLog.Warning(EventIDWarning, message)
+	}
+}
+
+// close closes the Windows Event Log.
+func (s *eventLogSink) close() error {
+	return s.eventLog.Close()
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// RedactedMask replaces the sensitive values in log messages.
+const RedactedMask = "[REDACTED]"
+
+// defaultRedactionPatterns match AWS secret keys, bearer tokens and password pairs.
+// Only the values captured by the groups of a pattern are masked.
+var defaultRedactionPatterns = []string{
+	`(?i)(?:aws_secret_access_key|secret_?access_?key|secret_?key)["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`,
+	`(?i)bearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	`(?i)(?:password|passwd|pwd)["']?\s*[:=]\s*("[^"]*"|'[^']*'|[^\s,;&]+)`,
+}
+
+var compiledDefaultRedactionPatterns = mustCompilePatterns(defaultRedactionPatterns)
+var redactionPatterns = compiledDefaultRedactionPatterns
+var redactionLock sync.RWMutex
+
+// SetRedactionPatterns sets the patterns of sensitive values that are masked in all log messages in addition
+// to the default ones, replacing the patterns set before. If a pattern has capture groups only the captured
+// values are masked, otherwise the whole match is. The patterns are left unchanged if one of them is invalid.
+func SetRedactionPatterns(patterns []string) error {
+	compiled := append([]*regexp.Regexp{}, compiledDefaultRedactionPatterns...)
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %v: %v", pattern, err)
+		}
+		compiled = append(compiled, expression)
+	}
+
+	redactionLock.Lock()
+	defer redactionLock.Unlock()
+	redactionPatterns = compiled
+	return nil
+}
+
+// Redact masks the sensitive values of the given message.
+func Redact(message string) string {
+	redactionLock.RLock()
+	defer redactionLock.RUnlock()
+	for _, pattern := range redactionPatterns {
+		message = redactPattern(pattern, message)
+	}
+	return message
+}
+
+// redactPattern masks the values of the message that match the given pattern.
+func redactPattern(pattern *regexp.Regexp, message string) string {
+	matches := pattern.FindAllStringSubmatchIndex(message, -1)
+	if matches == nil {
+		return message
+	}
+
+	redacted := ""
+	last := 0
+	for _, match := range matches {
+		// mask the capture groups, or the whole match if there is none
+		groups := match[2:]
+		if len(groups) == 0 {
+			groups = match[:2]
+		}
+		for i := 0; i < len(groups); i += 2 {
+			start, end := groups[i], groups[i+1]
+			if start < last {
+				// group did not participate in the match or overlaps a masked value
+				continue
+			}
+			redacted += message[last:start] + RedactedMask
+			last = end
+		}
+	}
+	return redacted + message[last:]
+}
+
+// mustCompilePatterns compiles the given patterns, panicking on invalid ones.
+func mustCompilePatterns(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = regexp.MustCompile(pattern)
+	}
+	return compiled
+}
+
+// redactedMessage is a log message that is formatted and redacted only if it is written.
+type redactedMessage struct {
+	format    string
+	params    []interface{}
+	formatted bool
+}
+
+// redactf returns a message formatted according to the format specifier, with sensitive values masked.
+func redactf(format string, params ...interface{}) redactedMessage {
+	return redactedMessage{format: format, params: params, formatted: true}
+}
+
+// redact returns a message formatted using the default formats, with sensitive values masked.
+func redact(v ...interface{}) redactedMessage {
+	return redactedMessage{params: v}
+}
+
+// String formats the message and masks its sensitive values.
+func (m redactedMessage) String() string {
+	if m.formatted {
+		return Redact(fmt.Sprintf(m.format, m.params...))
+	}
+	return Redact(fmt.Sprint(m.params...))
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// archiveDirName is the directory, next to the log files, that holds the compressed rotated log files.
+const archiveDirName = "archive"
+
+// RotationConfig holds the rotation settings enforced on the rolling log files.
+// Zero values keep the settings of the seelog configurations.
+type RotationConfig struct {
+	MaxFileSizeBytes int64
+	MaxRolls         int
+	Compress         bool
+}
+
+var rotationConfig RotationConfig
+var rotationLock sync.RWMutex
+
+// rollingFileTag matches the rolling file outputs of the seelog configurations
+var rollingFileTag = regexp.MustCompile(`<rollingfile\b[^>]*>`)
+
+// fileNameAttribute matches the file name of a rolling file output
+var fileNameAttribute = regexp.MustCompile(`\sfilename\s*=\s*"([^"]*)"`)
+
+// SetRotationConfig sets the rotation settings applied to the seelog configurations of the agent.
+func SetRotationConfig(config RotationConfig) {
+	rotationLock.Lock()
+	defer rotationLock.Unlock()
+	rotationConfig = config
+}
+
+// getRotationConfig returns the rotation settings applied to the seelog configurations of the agent.
+func getRotationConfig() RotationConfig {
+	rotationLock.RLock()
+	defer rotationLock.RUnlock()
+	return rotationConfig
+}
+
+// applyRotationConfig enforces the given rotation settings on all the rolling file outputs of the seelog configurations.
+func applyRotationConfig(seelogConfig []byte, config RotationConfig) []byte {
+	return rollingFileTag.ReplaceAllFunc(seelogConfig, func(tag []byte) []byte {
+		rollingFile := string(tag)
+		if config.MaxFileSizeBytes > 0 {
+			rollingFile = setAttribute(rollingFile, "maxsize", fmt.Sprint(config.MaxFileSizeBytes))
+		}
+		if config.MaxRolls > 0 {
+			rollingFile = setAttribute(rollingFile, "maxrolls", fmt.Sprint(config.MaxRolls))
+		}
+		if config.Compress {
+			rollingFile = setAttribute(rollingFile, "archivetype", "gzip")
+			rollingFile = setAttribute(rollingFile, "archiveexploded", "true")
+			if match := fileNameAttribute.FindStringSubmatch(rollingFile); match != nil {
+				rollingFile = setAttribute(rollingFile, "archivepath", filepath.Join(filepath.Dir(match[1]), archiveDirName))
+			}
+		}
+		return []byte(rollingFile)
+	})
+}
+
+// setAttribute sets the value of the given attribute of an xml tag, adding the attribute if needed.
+func setAttribute(tag string, name string, value string) string {
+	attribute := regexp.MustCompile(`\s` + name + `\s*=\s*"[^"]*"`)
+	if attribute.MatchString(tag) {
+		return attribute.ReplaceAllLiteralString(tag, ` `+name+`="`+value+`"`)
+	}
+	end := len(tag) - len(">")
+	if tag[end-1] == '/' {
+		end--
+	}
+	return tag[:end] + ` ` + name + `="` + value + `"` + tag[end:]
+}
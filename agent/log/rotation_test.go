@@ -0,0 +1,59 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRotationConfig(t *testing.T) {
+	config := `<seelog type="sync">
+    <outputs>
+        <rollingfile type="size" filename="/var/log/amazon/ssm/amazon-ssm-agent.log" maxsize="30000000" maxrolls="5"/>
+        <filter levels="error,critical">
+            <rollingfile type="size" filename="/var/log/amazon/ssm/errors.log" maxsize="10000000"/>
+        </filter>
+    </outputs>
+</seelog>`
+
+	// zero values keep the seelog settings
+	assert.Equal(t, config, string(applyRotationConfig([]byte(config), RotationConfig{})))
+
+	assert.Equal(t, `<seelog type="sync">
+    <outputs>
+        <rollingfile type="size" filename="/var/log/amazon/ssm/amazon-ssm-agent.log" maxsize="1048576" maxrolls="5"/>
+        <filter levels="error,critical">
+            <rollingfile type="size" filename="/var/log/amazon/ssm/errors.log" maxsize="1048576"/>
+        </filter>
+    </outputs>
+</seelog>`, string(applyRotationConfig([]byte(config), RotationConfig{MaxFileSizeBytes: 1048576})))
+
+	assert.Equal(t, `<seelog type="sync">
+    <outputs>
+        <rollingfile type="size" filename="/var/log/amazon/ssm/amazon-ssm-agent.log" maxsize="30000000" maxrolls="10" archivetype="gzip" archiveexploded="true" archivepath="/var/log/amazon/ssm/archive"/>
+        <filter levels="error,critical">
+            <rollingfile type="size" filename="/var/log/amazon/ssm/errors.log" maxsize="10000000" maxrolls="10" archivetype="gzip" archiveexploded="true" archivepath="/var/log/amazon/ssm/archive"/>
+        </filter>
+    </outputs>
+</seelog>`, string(applyRotationConfig([]byte(config), RotationConfig{MaxRolls: 10, Compress: true})))
+}
+
+func TestApplyRotationConfigToDefaultConfig(t *testing.T) {
+	config := applyRotationConfig(DefaultConfig(), RotationConfig{MaxFileSizeBytes: 1048576, MaxRolls: 10, Compress: true})
+	_, err := seelog.LoggerFromConfigAsBytes(config)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmlog is used to initialize ssm functional logger
+package ssmlog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/cihub/seelog"
+)
+
+// levelOverride is the log level set at runtime, empty if the level of the configurations file applies
+var levelOverride string
+var levelLock sync.RWMutex
+
+// seelogRootTag matches the root element of the seelog configurations
+var seelogRootTag = regexp.MustCompile(`<seelog\b[^>]*>`)
+
+// levelAttributes matches the attributes of the root element that constrain the log levels
+var levelAttributes = regexp.MustCompile(`\s+(minlevel|maxlevel|levels)\s*=\s*"[^"]*"`)
+
+// SetLogLevel changes the minimum level of the logger without restarting the agent.
+// The level overrides the level of the configurations file until ResetLogLevel is called.
+func SetLogLevel(level string) error {
+	seelogLevel, found := seelog.LogLevelFromString(strings.ToLower(strings.TrimSpace(level)))
+	if !found {
+		return fmt.Errorf("invalid log level %v", level)
+	}
+	setLevelOverride(seelogLevel.String())
+	if isLoaded() {
+		replaceLogger()
+	}
+	return nil
+}
+
+// ResetLogLevel restores the log level defined in the configurations file.
+func ResetLogLevel() {
+	setLevelOverride("")
+	if isLoaded() {
+		replaceLogger()
+	}
+}
+
+// setLevelOverride sets the log level applied on top of the configurations file
+func setLevelOverride(level string) {
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	levelOverride = level
+}
+
+// getLogConfigBytes returns the current configurations with the log level override applied, if any
+func getLogConfigBytes() []byte {
+	levelLock.RLock()
+	level := levelOverride
+	levelLock.RUnlock()
+	return overrideLogLevel(log.GetLogConfigBytes(), level)
+}
+
+// overrideLogLevel sets the minimum level of the given seelog configurations,
+// dropping any level constraint of the root element
+func overrideLogLevel(seelogConfig []byte, level string) []byte {
+	if level == "" {
+		return seelogConfig
+	}
+	return seelogRootTag.ReplaceAllFunc(seelogConfig, func(tag []byte) []byte {
+		tag = levelAttributes.ReplaceAll(tag, nil)
+		prefix := len("<seelog")
+		return []byte(string(tag[:prefix]) + ` minlevel="` + level + `"` + string(tag[prefix:]))
+	})
+}
+
+// loadLogLevelFile applies the log level of the control file, if any.
+// The level of the configurations file applies when the control file is missing or empty.
+func loadLogLevelFile(logLevelFilePath string) error {
+	content, err := ioutil.ReadFile(logLevelFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	level := strings.TrimSpace(string(content))
+	if level == "" {
+		setLevelOverride("")
+		return nil
+	}
+	seelogLevel, found := seelog.LogLevelFromString(strings.ToLower(level))
+	if !found {
+		return fmt.Errorf("invalid log level %v in %v", level, logLevelFilePath)
+	}
+	setLevelOverride(seelogLevel.String())
+	return nil
+}
+
+// reloadLogLevel applies the log level of the control file and replaces the logger accordingly
+func reloadLogLevel() {
+	if err := loadLogLevelFile(log.DefaultLogLevelFilePath); err != nil {
+		getCached().Errorf("Failed to load the log level, keeping the current level: %v", err)
+		return
+	}
+	replaceLogger()
+}
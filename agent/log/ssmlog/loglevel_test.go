@@ -0,0 +1,72 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ssmlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideLogLevel(t *testing.T) {
+	config := `<seelog type="sync" minlevel="info" maxlevel="error">
+    <outputs><console/></outputs>
+</seelog>`
+
+	assert.Equal(t, config, string(overrideLogLevel([]byte(config), "")))
+
+	overridden := overrideLogLevel([]byte(config), "debug")
+	assert.Equal(t, `<seelog minlevel="debug" type="sync">
+    <outputs><console/></outputs>
+</seelog>`, string(overridden))
+
+	// the overridden default configurations are valid
+	logger, err := seelog.LoggerFromConfigAsBytes(overrideLogLevel(log.DefaultConfig(), "debug"))
+	assert.Nil(t, err)
+	logger.Close()
+}
+
+func TestSetLogLevelInvalid(t *testing.T) {
+	assert.NotNil(t, SetLogLevel("verbose"))
+}
+
+func TestLoadLogLevelFile(t *testing.T) {
+	defer setLevelOverride("")
+	dir, err := ioutil.TempDir("", "loglevel")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	levelFile := filepath.Join(dir, "loglevel")
+
+	// missing file
+	assert.Nil(t, loadLogLevelFile(levelFile))
+	assert.Equal(t, "", levelOverride)
+
+	assert.Nil(t, ioutil.WriteFile(levelFile, []byte(" Trace\n"), 0600))
+	assert.Nil(t, loadLogLevelFile(levelFile))
+	assert.Equal(t, "trace", levelOverride)
+
+	// an invalid level keeps the current level
+	assert.Nil(t, ioutil.WriteFile(levelFile, []byte("verbose"), 0600))
+	assert.NotNil(t, loadLogLevelFile(levelFile))
+	assert.Equal(t, "trace", levelOverride)
+
+	assert.Nil(t, ioutil.WriteFile(levelFile, []byte(""), 0600))
+	assert.Nil(t, loadLogLevelFile(levelFile))
+	assert.Equal(t, "", levelOverride)
+}
@@ -16,9 +16,10 @@ package ssmlog
 
 import (
 	"fmt"
-
+	"reflect"
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/cihub/seelog"
 )
@@ -43,19 +44,59 @@ func SSMLogger(useWatcher bool) log.T {
 
 // initLogger initializes a new logger based on current configurations and starts file watcher on the configurations file
 func initLogger(useWatcher bool) (logger log.T) {
+	var levelErr error
+	if useWatcher {
+		// Apply the log level of the control file, if any
+		levelErr = loadLogLevelFile(log.DefaultLogLevelFilePath)
+	}
+	// Apply the log settings of the agent configurations
+	loadAgentLogConfig()
 	// Read the current configurations or get the default configurations
-	logConfigBytes := log.GetLogConfigBytes()
+	logConfigBytes := getLogConfigBytes()
 	// Initialize the base seelog logger
 	baseLogger, _ := initBaseLoggerFromBytes(logConfigBytes)
 	// Create the wrapper logger
 	logger = withContext(baseLogger)
 	if useWatcher {
-		// Start the config file watcher
-		startWatcher(logger)
+		if levelErr != nil {
+			logger.Errorf("Failed to load the log level: %v", levelErr)
+		}
+		// Start the config file and log level file watchers
+		startWatcher(logger, log.DefaultSeelogConfigFilePath, replaceLogger)
+		startWatcher(logger, log.DefaultLogLevelFilePath, reloadLogLevel)
+		// Apply the log settings of the agent configurations when they are reloaded
+		appconfig.Subscribe("Logger", reloadAgentLogConfig)
 	}
 	return
 }
 
+// reloadAgentLogConfig replaces the logger when a reload of the agent configurations changed the log settings
+func reloadAgentLogConfig(previous appconfig.SsmagentConfig, current appconfig.SsmagentConfig) {
+	if reflect.DeepEqual(previous.Log, current.Log) {
+		return
+	}
+	loadAgentLogConfig()
+	replaceLogger()
+}
+
+// loadAgentLogConfig applies the log settings of the agent configurations to the logger configurations
+func loadAgentLogConfig() {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		fmt.Println("Error loading the log settings, keeping the seelog settings:", err)
+		return
+	}
+	log.SetRotationConfig(log.RotationConfig{
+		MaxFileSizeBytes: int64(config.Log.MaxFileSizeMB) * 1024 * 1024,
+		MaxRolls:         config.Log.MaxRotatedFiles,
+		Compress:         config.Log.CompressRotated,
+	})
+	log.SetPlatformSinkEnabled(config.Log.PlatformLogEnabled)
+	if err = log.SetRedactionPatterns(config.Log.RedactionPatterns); err != nil {
+		fmt.Println("Error loading the redaction patterns of the logs:", err)
+	}
+}
+
 // withContext creates a wrapper logger on the base logger passed with context is passed
 func withContext(logger seelog.LoggerInterface, context ...string) (contextLogger log.T) {
 	loggerInstance.BaseLoggerInstance = logger
@@ -113,16 +154,16 @@ func getCached() log.T {
 	return *loadedLogger
 }
 
-// startWatcher starts the file watcher on the seelog configurations file path
-func startWatcher(logger log.T) {
+// startWatcher starts the file watcher on the given logger configurations file path
+func startWatcher(logger log.T, configFilePath string, onChange func()) {
 	defer func() {
 		// In case the creation of watcher panics, let the current logger continue
 		if msg := recover(); msg != nil {
-			logger.Errorf("Seelog File Watcher Initilization Failed. Any updates on config file will be ignored unless agent is restarted: %v", msg)
+			logger.Errorf("Seelog File Watcher Initilization Failed. Any updates on %v will be ignored unless agent is restarted: %v", configFilePath, msg)
 		}
 	}()
 	fileWatcher := &FileWatcher{}
-	fileWatcher.Init(logger, log.DefaultSeelogConfigFilePath, replaceLogger)
+	fileWatcher.Init(logger, configFilePath, onChange)
 	// Start the file watcher
 	fileWatcher.Start()
 }
@@ -135,7 +176,7 @@ func replaceLogger() {
 	logger := getCached()
 
 	//Create new logger
-	logConfigBytes := log.GetLogConfigBytes()
+	logConfigBytes := getLogConfigBytes()
 	baseLogger, err := initBaseLoggerFromBytes(logConfigBytes)
 
 	// If err in creating logger, do not replace logger
@@ -0,0 +1,251 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+// Note: This code is used in the test files. However, this code is not in a _test.go file
+// because then we would have to copy it in every test package that needs it.
+
+// CapturedEntry is a log entry recorded by a CapturingLogger.
+type CapturedEntry struct {
+	Level         seelog.LogLevel
+	Message       string
+	Context       []string
+	Fields        map[string]interface{}
+	CorrelationID string
+}
+
+// String returns the entry as it would be written by the agent logger, prefixed with its context
+// and its correlation id.
+func (e CapturedEntry) String() string {
+	prefix := e.Context
+	if e.CorrelationID != "" {
+		prefix = append(append([]string(nil), prefix...), correlationTag(e.CorrelationID))
+	}
+	if len(prefix) == 0 {
+		return e.Message
+	}
+	return strings.Join(prefix, " ") + " " + e.Message
+}
+
+// capture holds the entries shared by a CapturingLogger and the loggers derived from it.
+type capture struct {
+	entries []CapturedEntry
+	m       sync.Mutex
+}
+
+// CapturingLogger is a logger that records its entries in memory so that tests can assert on them.
+// Loggers derived with WithContext, WithFields and WithCorrelationID record into the same entries.
+type CapturingLogger struct {
+	capture       *capture
+	context       []string
+	fields        map[string]interface{}
+	correlationID string
+}
+
+// NewCapturingLogger returns a logger recording its entries in memory.
+func NewCapturingLogger() *CapturingLogger {
+	return &CapturingLogger{capture: &capture{}}
+}
+
+// Entries returns a copy of the recorded entries.
+func (l *CapturingLogger) Entries() []CapturedEntry {
+	l.capture.m.Lock()
+	defer l.capture.m.Unlock()
+	return append([]CapturedEntry(nil), l.capture.entries...)
+}
+
+// EntriesByLevel returns the recorded entries of the given level.
+func (l *CapturingLogger) EntriesByLevel(level seelog.LogLevel) (entries []CapturedEntry) {
+	for _, entry := range l.Entries() {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// CountByLevel returns the number of recorded entries of the given level.
+func (l *CapturingLogger) CountByLevel(level seelog.LogLevel) int {
+	return len(l.EntriesByLevel(level))
+}
+
+// Contains returns true if a recorded entry of any level contains the given text.
+func (l *CapturingLogger) Contains(text string) bool {
+	for _, entry := range l.Entries() {
+		if strings.Contains(entry.String(), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsError returns true if a recorded error entry contains the given text.
+func (l *CapturingLogger) ContainsError(text string) bool {
+	return l.containsAtLevel(seelog.ErrorLvl, text)
+}
+
+// ContainsWarning returns true if a recorded warning entry contains the given text.
+func (l *CapturingLogger) ContainsWarning(text string) bool {
+	return l.containsAtLevel(seelog.WarnLvl, text)
+}
+
+// Reset discards the recorded entries.
+func (l *CapturingLogger) Reset() {
+	l.capture.m.Lock()
+	defer l.capture.m.Unlock()
+	l.capture.entries = nil
+}
+
+// containsAtLevel returns true if a recorded entry of the given level contains the given text.
+func (l *CapturingLogger) containsAtLevel(level seelog.LogLevel, text string) bool {
+	for _, entry := range l.EntriesByLevel(level) {
+		if strings.Contains(entry.String(), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// record records an entry with the context and fields of the logger.
+func (l *CapturingLogger) record(level seelog.LogLevel, message string) {
+	l.capture.m.Lock()
+	defer l.capture.m.Unlock()
+	l.capture.entries = append(l.capture.entries, CapturedEntry{
+		Level:         level,
+		Message:       message,
+		Context:       l.context,
+		Fields:        l.fields,
+		CorrelationID: l.correlationID,
+	})
+}
+
+// formatMessage and printMessage take the parameters as a slice, so vet doesn't check the calls to the
+// logging functions of T as printf wrappers.
+func formatMessage(format string, params []interface{}) string {
+	return fmt.Sprintf(format, params...)
+}
+
+func printMessage(params []interface{}) string {
+	return fmt.Sprint(params...)
+}
+
+// WithContext returns a logger recording into the same entries with the given context.
+func (l *CapturingLogger) WithContext(context ...string) (contextLogger T) {
+	return &CapturingLogger{
+		capture:       l.capture,
+		context:       append(append([]string(nil), l.context...), context...),
+		fields:        l.fields,
+		correlationID: l.correlationID,
+	}
+}
+
+// WithFields returns a logger recording into the same entries with the given fields added.
+func (l *CapturingLogger) WithFields(fields map[string]interface{}) (fieldsLogger T) {
+	return &CapturingLogger{
+		capture:       l.capture,
+		context:       l.context,
+		fields:        mergeFields(l.fields, fields),
+		correlationID: l.correlationID,
+	}
+}
+
+// WithCorrelationID returns a logger recording into the same entries with the given correlation id.
+func (l *CapturingLogger) WithCorrelationID(correlationID string) (correlatedLogger T) {
+	return &CapturingLogger{
+		capture:       l.capture,
+		context:       l.context,
+		fields:        l.fields,
+		correlationID: correlationID,
+	}
+}
+
+// Tracef records a trace entry.
+func (l *CapturingLogger) Tracef(format string, params ...interface{}) {
+	l.record(seelog.TraceLvl, formatMessage(format, params))
+}
+
+// Debugf records a debug entry.
+func (l *CapturingLogger) Debugf(format string, params ...interface{}) {
+	l.record(seelog.DebugLvl, formatMessage(format, params))
+}
+
+// Infof records an info entry.
+func (l *CapturingLogger) Infof(format string, params ...interface{}) {
+	l.record(seelog.InfoLvl, formatMessage(format, params))
+}
+
+// Warnf records a warning entry.
+func (l *CapturingLogger) Warnf(format string, params ...interface{}) error {
+	l.record(seelog.WarnLvl, formatMessage(format, params))
+	return nil
+}
+
+// Errorf records an error entry.
+func (l *CapturingLogger) Errorf(format string, params ...interface{}) error {
+	l.record(seelog.ErrorLvl, formatMessage(format, params))
+	return nil
+}
+
+// Criticalf records a critical entry.
+func (l *CapturingLogger) Criticalf(format string, params ...interface{}) error {
+	l.record(seelog.CriticalLvl, formatMessage(format, params))
+	return nil
+}
+
+// Trace records a trace entry.
+func (l *CapturingLogger) Trace(v ...interface{}) {
+	l.record(seelog.TraceLvl, printMessage(v))
+}
+
+// Debug records a debug entry.
+func (l *CapturingLogger) Debug(v ...interface{}) {
+	l.record(seelog.DebugLvl, printMessage(v))
+}
+
+// Info records an info entry.
+func (l *CapturingLogger) Info(v ...interface{}) {
+	l.record(seelog.InfoLvl, printMessage(v))
+}
+
+// Warn records a warning entry.
+func (l *CapturingLogger) Warn(v ...interface{}) error {
+	l.record(seelog.WarnLvl, printMessage(v))
+	return nil
+}
+
+// Error records an error entry.
+func (l *CapturingLogger) Error(v ...interface{}) error {
+	l.record(seelog.ErrorLvl, printMessage(v))
+	return nil
+}
+
+// Critical records a critical entry.
+func (l *CapturingLogger) Critical(v ...interface{}) error {
+	l.record(seelog.CriticalLvl, printMessage(v))
+	return nil
+}
+
+// Flush does nothing, entries are recorded as they are logged.
+func (l *CapturingLogger) Flush() {}
+
+// Close does nothing, entries remain available after the logger is closed.
+func (l *CapturingLogger) Close() {}
@@ -0,0 +1,52 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapturingLogger(t *testing.T) {
+	logger := NewCapturingLogger()
+	logger.Infof("starting %v", "document1")
+	logger.Warn("retrying ", 1)
+	logger.Errorf("plugin %v failed: %v", "aws:runShellScript", "exit status 1")
+
+	assert.Equal(t, 1, logger.CountByLevel(seelog.InfoLvl))
+	assert.Equal(t, 1, logger.CountByLevel(seelog.WarnLvl))
+	assert.Equal(t, 1, logger.CountByLevel(seelog.ErrorLvl))
+	assert.Equal(t, 0, logger.CountByLevel(seelog.DebugLvl))
+	assert.True(t, logger.Contains("starting document1"))
+	assert.True(t, logger.ContainsWarning("retrying 1"))
+	assert.True(t, logger.ContainsError("aws:runShellScript failed"))
+	assert.False(t, logger.ContainsError("starting"))
+
+	logger.Reset()
+	assert.Empty(t, logger.Entries())
+}
+
+func TestCapturingLoggerDerivedLoggers(t *testing.T) {
+	logger := NewCapturingLogger()
+	logger.WithContext("[EngineProcessor]").WithFields(map[string]interface{}{FieldCommandID: "command1"}).Error("document failed")
+
+	entries := logger.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, []string{"[EngineProcessor]"}, entries[0].Context)
+	assert.Equal(t, "command1", entries[0].Fields[FieldCommandID])
+	assert.Equal(t, "[EngineProcessor] document failed", entries[0].String())
+	assert.True(t, logger.ContainsError("[EngineProcessor] document failed"))
+}
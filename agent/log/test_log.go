@@ -39,6 +39,7 @@ func NewMockLog() *Mock {
 	log.On("Warnf", mock.Anything, mock.Anything).Return(nil)
 	log.On("Tracef", mock.Anything, mock.Anything).Return()
 	log.On("Infof", mock.Anything, mock.Anything).Return()
+	log.On("WithCorrelationID", mock.Anything).Return(log)
 	return log
 }
 
@@ -55,6 +56,7 @@ func NewMockLogWithContext(ctx string) *Mock {
 	log.On("Errorf", mock.Anything, mock.Anything).Return(nil)
 	log.On("Tracef", mock.Anything, mock.Anything).Return()
 	log.On("Infof", mock.Anything, mock.Anything).Return()
+	log.On("WithCorrelationID", mock.Anything).Return(log)
 	return log
 }
 
@@ -65,6 +67,22 @@ func (_m *Mock) WithContext(context ...string) (contextLogger T) {
 	return ret.Get(0).(T)
 }
 
+// WithFields mocks the WithFields function.
+func (_m *Mock) WithFields(fields map[string]interface{}) (fieldsLogger T) {
+	fmt.Print(_m.context)
+	fmt.Printf("WithFields: %v", fields)
+	ret := _m.Called(fields)
+	return ret.Get(0).(T)
+}
+
+// WithCorrelationID mocks the WithCorrelationID function.
+func (_m *Mock) WithCorrelationID(correlationID string) (correlatedLogger T) {
+	fmt.Print(_m.context)
+	fmt.Printf("WithCorrelationID: %v", correlationID)
+	ret := _m.Called(correlationID)
+	return ret.Get(0).(T)
+}
+
 // Tracef mocks the Tracef function.
 func (_m *Mock) Tracef(format string, params ...interface{}) {
 	fmt.Print(_m.context)
@@ -23,6 +23,7 @@ type DelegateLogger struct {
 }
 
 // Wrapper is a logger that can modify the format of a log message before delegating to another logger.
+// Sensitive values of the messages are masked, see Redact.
 type Wrapper struct {
 	Format   FormatFilter
 	M        *sync.Mutex
@@ -41,11 +42,57 @@ type FormatFilter interface {
 
 // WithContext creates a wrapper logger with context
 func (w *Wrapper) WithContext(context ...string) (contextLogger T) {
-	formatFilter := &ContextFormatFilter{Context: context}
+	if format, ok := w.Format.(*FieldsFormatFilter); ok {
+		// keep writing structured entries with the same fields
+		formatFilter := &FieldsFormatFilter{Context: context, Fields: format.Fields, CorrelationID: format.CorrelationID}
+		return &Wrapper{Format: formatFilter, M: w.M, Delegate: w.Delegate}
+	}
+	formatFilter := &ContextFormatFilter{Context: context, CorrelationID: w.correlationID()}
 	contextLogger = &Wrapper{Format: formatFilter, M: w.M, Delegate: w.Delegate}
 	return contextLogger
 }
 
+// WithFields creates a wrapper logger that writes JSON log entries holding the given fields
+func (w *Wrapper) WithFields(fields map[string]interface{}) (fieldsLogger T) {
+	formatFilter := &FieldsFormatFilter{Fields: fields, CorrelationID: w.correlationID()}
+	switch format := w.Format.(type) {
+	case *ContextFormatFilter:
+		formatFilter.Context = format.Context
+	case *FieldsFormatFilter:
+		formatFilter.Context = format.Context
+		formatFilter.Fields = mergeFields(format.Fields, fields)
+	}
+	fieldsLogger = &Wrapper{Format: formatFilter, M: w.M, Delegate: w.Delegate}
+	return fieldsLogger
+}
+
+// WithCorrelationID creates a wrapper logger that tags its messages with the given correlation id.
+// Loggers derived from it with WithContext and WithFields keep the correlation id.
+func (w *Wrapper) WithCorrelationID(correlationID string) (correlatedLogger T) {
+	var formatFilter FormatFilter
+	switch format := w.Format.(type) {
+	case *FieldsFormatFilter:
+		formatFilter = &FieldsFormatFilter{Context: format.Context, Fields: format.Fields, CorrelationID: correlationID}
+	case *ContextFormatFilter:
+		formatFilter = &ContextFormatFilter{Context: format.Context, CorrelationID: correlationID}
+	default:
+		formatFilter = &ContextFormatFilter{CorrelationID: correlationID}
+	}
+	correlatedLogger = &Wrapper{Format: formatFilter, M: w.M, Delegate: w.Delegate}
+	return correlatedLogger
+}
+
+// correlationID returns the correlation id the logger tags its messages with, if any.
+func (w *Wrapper) correlationID() string {
+	switch format := w.Format.(type) {
+	case *ContextFormatFilter:
+		return format.CorrelationID
+	case *FieldsFormatFilter:
+		return format.CorrelationID
+	}
+	return ""
+}
+
 // Tracef formats message according to format specifier
 // and writes to log with level = Trace.
 func (w *Wrapper) Tracef(format string, params ...interface{}) {
@@ -53,7 +100,7 @@ func (w *Wrapper) Tracef(format string, params ...interface{}) {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Tracef(format, params...)
+	w.Delegate.BaseLoggerInstance.Tracef("%s", redactf(format, params...))
 }
 
 // Debugf formats message according to format specifier
@@ -63,7 +110,7 @@ func (w *Wrapper) Debugf(format string, params ...interface{}) {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Debugf(format, params...)
+	w.Delegate.BaseLoggerInstance.Debugf("%s", redactf(format, params...))
 }
 
 // Infof formats message according to format specifier
@@ -73,7 +120,7 @@ func (w *Wrapper) Infof(format string, params ...interface{}) {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Infof(format, params...)
+	w.Delegate.BaseLoggerInstance.Infof("%s", redactf(format, params...))
 }
 
 // Warnf formats message according to format specifier
@@ -83,7 +130,7 @@ func (w *Wrapper) Warnf(format string, params ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Warnf(format, params...)
+	return w.Delegate.BaseLoggerInstance.Warnf("%s", redactf(format, params...))
 }
 
 // Errorf formats message according to format specifier
@@ -93,7 +140,7 @@ func (w *Wrapper) Errorf(format string, params ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Errorf(format, params...)
+	return w.Delegate.BaseLoggerInstance.Errorf("%s", redactf(format, params...))
 }
 
 // Criticalf formats message according to format specifier
@@ -103,7 +150,7 @@ func (w *Wrapper) Criticalf(format string, params ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Criticalf(format, params...)
+	return w.Delegate.BaseLoggerInstance.Criticalf("%s", redactf(format, params...))
 }
 
 // Trace formats message using the default formats for its operands
@@ -112,7 +159,7 @@ func (w *Wrapper) Trace(v ...interface{}) {
 	v = w.Format.Filter(v...)
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Trace(v...)
+	w.Delegate.BaseLoggerInstance.Trace(redact(v...))
 }
 
 // Debug formats message using the default formats for its operands
@@ -122,7 +169,7 @@ func (w *Wrapper) Debug(v ...interface{}) {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Debug(v...)
+	w.Delegate.BaseLoggerInstance.Debug(redact(v...))
 }
 
 // Info formats message using the default formats for its operands
@@ -132,7 +179,7 @@ func (w *Wrapper) Info(v ...interface{}) {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	w.Delegate.BaseLoggerInstance.Info(v...)
+	w.Delegate.BaseLoggerInstance.Info(redact(v...))
 }
 
 // Warn formats message using the default formats for its operands
@@ -142,7 +189,7 @@ func (w *Wrapper) Warn(v ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Warn(v...)
+	return w.Delegate.BaseLoggerInstance.Warn(redact(v...))
 }
 
 // Error formats message using the default formats for its operands
@@ -152,7 +199,7 @@ func (w *Wrapper) Error(v ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Error(v...)
+	return w.Delegate.BaseLoggerInstance.Error(redact(v...))
 }
 
 // Critical formats message using the default formats for its operands
@@ -162,7 +209,7 @@ func (w *Wrapper) Critical(v ...interface{}) error {
 
 	w.M.Lock()
 	defer w.M.Unlock()
-	return w.Delegate.BaseLoggerInstance.Critical(v...)
+	return w.Delegate.BaseLoggerInstance.Critical(redact(v...))
 }
 
 // Flush flushes all the messages in the logger.
@@ -0,0 +1,82 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWrapper(t *testing.T, out *bytes.Buffer) *Wrapper {
+	seelogger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(out, seelog.TraceLvl, "%Msg%n")
+	assert.Nil(t, err)
+	return &Wrapper{Format: &ContextFormatFilter{Context: []string{}}, M: new(sync.Mutex), Delegate: &DelegateLogger{BaseLoggerInstance: seelogger}}
+}
+
+func TestWithFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := newTestWrapper(t, &out).WithContext("[ctx]").WithFields(map[string]interface{}{
+		FieldCommandID: "command",
+		FieldPluginID:  "plugin",
+	})
+
+	logger.Infof("plugin %v started", "aws:runShellScript")
+	logger.Info("done ", 1)
+	logger.Flush()
+	assert.Equal(t, `{"commandId":"command","message":"[ctx] plugin aws:runShellScript started","pluginId":"plugin"}
+{"commandId":"command","message":"[ctx] done 1","pluginId":"plugin"}
+`, out.String())
+}
+
+func TestWithFieldsMergesFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := newTestWrapper(t, &out).
+		WithFields(map[string]interface{}{FieldCommandID: "command", FieldDocumentName: "document"}).
+		WithFields(map[string]interface{}{FieldDocumentName: "other"}).
+		WithContext("[ctx]")
+
+	logger.Debugf("message")
+	logger.Flush()
+	assert.Equal(t, `{"commandId":"command","documentName":"other","message":"[ctx] message"}
+`, out.String())
+}
+
+func TestWrapperRedactsMessages(t *testing.T) {
+	var out bytes.Buffer
+	logger := newTestWrapper(t, &out).WithContext("[ctx]")
+
+	logger.Infof("connecting with password=%v", "hunter2")
+	logger.Error("header Authorization: Bearer abc.def-ghi")
+	logger.Flush()
+	assert.Equal(t, "[ctx] connecting with password=[REDACTED]\n[ctx] header Authorization: Bearer [REDACTED]\n", out.String())
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	var out bytes.Buffer
+	logger := newTestWrapper(t, &out).WithContext("[ctx]").WithCorrelationID("command")
+
+	logger.Infof("plugin %v started", "aws:runShellScript")
+	// derived loggers keep the correlation id
+	logger.WithContext("[ctx]", "[plugin]").Info("done ", 1)
+	logger.WithFields(map[string]interface{}{FieldPluginID: "plugin"}).Warnf("retrying")
+	logger.Flush()
+	assert.Equal(t, `[ctx] [correlationId=command] plugin aws:runShellScript started
+[ctx] [plugin] [correlationId=command] done 1
+{"correlationId":"command","message":"[ctx] retrying","pluginId":"plugin"}
+`, out.String())
+}
@@ -34,6 +34,7 @@ import (
 	managerContracts "github.com/aws/amazon-ssm-agent/agent/longrunning/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/plugin/cloudwatch"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/safemode"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 	"github.com/carlescere/scheduler"
@@ -169,6 +170,10 @@ func (m *Manager) ModuleName() string {
 func (m *Manager) ModuleExecute(context context.T) (err error) {
 
 	log := m.context.Log()
+	if safemode.Enabled() {
+		log.Warnf("Agent is in safe mode, long running plugins are not started")
+		return
+	}
 	log.Infof("starting long running plugin manager")
 	//read from data store to determine if there were any previously long running plugins which need to be started again
 	var dataStoreMap map[string]managerContracts.PluginInfo
@@ -264,14 +269,14 @@ func (m *Manager) ModuleRequestStop(stopType contracts.StopType) (err error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		m.startPlugin.ShutdownAndWait(waitTimeout)
+		m.startPlugin.ShutdownAndWait(waitTimeout).Log(m.context.Log(), "StartPlugin pool")
 	}()
 
 	// shutdown the cancel command pool in a separate go routine
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		m.stopPlugin.ShutdownAndWait(waitTimeout)
+		m.stopPlugin.ShutdownAndWait(waitTimeout).Log(m.context.Log(), "StopPlugin pool")
 	}()
 
 	if len(m.runningPlugins) > 0 {
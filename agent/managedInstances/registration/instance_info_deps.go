@@ -15,6 +15,7 @@
 package registration
 
 import (
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/vault/fsvault"
 )
@@ -28,9 +29,9 @@ type fileT interface {
 	WriteAllText(filePath string, text string) (err error)
 }
 
-// WriteAllText writes all text content to the specified file
+// WriteAllText atomically replaces the content of the specified file
 func (fileUtility) WriteAllText(filePath string, text string) (err error) {
-	return fileutil.WriteAllText(filePath, text)
+	return fileutil.WriteFileAtomic(filePath, []byte(text), appconfig.ReadWriteAccess)
 }
 
 // dependency for vault
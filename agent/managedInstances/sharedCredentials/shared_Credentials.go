@@ -47,6 +47,11 @@ func filename() (string, error) {
 	return filepath.Join(homeDir, ".aws", "credentials"), nil
 }
 
+// Filename returns the path of the AWS shared credentials file.
+func Filename() (string, error) {
+	return filename()
+}
+
 func createFile(filePath string) error {
 	dir, _ := filepath.Split(filePath)
 
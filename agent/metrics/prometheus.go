@@ -0,0 +1,88 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics holds the internal metrics of the agent and exports them to sinks.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// PrometheusSink writes the metrics in the Prometheus text exposition format
+type PrometheusSink struct {
+	writer io.Writer
+}
+
+// NewPrometheusSink creates a sink writing the metrics to writer
+func NewPrometheusSink(writer io.Writer) *PrometheusSink {
+	return &PrometheusSink{writer: writer}
+}
+
+// Export writes the metrics, metrics without series are skipped
+func (s *PrometheusSink) Export(families []Family) error {
+	writer := bufio.NewWriter(s.writer)
+	for _, family := range families {
+		if len(family.Series) == 0 {
+			continue
+		}
+		if family.Help != "" {
+			fmt.Fprintf(writer, "# HELP %s %s\n", family.Name, helpEscaper.Replace(family.Help))
+		}
+		fmt.Fprintf(writer, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, series := range family.Series {
+			writer.WriteString(family.Name)
+			if len(series.Labels) > 0 {
+				labels := make([]string, len(series.Labels))
+				for i, label := range series.Labels {
+					labels[i] = fmt.Sprintf(`%s="%s"`, label.Name, labelValueEscaper.Replace(label.Value))
+				}
+				fmt.Fprintf(writer, "{%s}", strings.Join(labels, ","))
+			}
+			fmt.Fprintf(writer, " %s\n", formatValue(series.Value))
+		}
+	}
+	return writer.Flush()
+}
+
+// formatValue formats a value the way Prometheus parses it
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// PrometheusHandler serves the metrics of a registry to Prometheus scrapers
+func PrometheusHandler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", PrometheusContentType)
+		registry.Collect(NewPrometheusSink(writer))
+	})
+}
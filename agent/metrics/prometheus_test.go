@@ -0,0 +1,52 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics holds the internal metrics of the agent and exports them to sinks.
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("ssm_agent_plugin_failures_total", "Failed plugins.\nBy name.", "plugin").Inc(`aws:run"Script\`)
+	registry.Gauge("ssm_agent_queued_documents", "").Set(math.Inf(1))
+	registry.Gauge("ssm_agent_empty", "No series.")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, registry.Collect(NewPrometheusSink(&buffer)))
+	assert.Equal(t, `# HELP ssm_agent_plugin_failures_total Failed plugins.\nBy name.
+# TYPE ssm_agent_plugin_failures_total counter
+ssm_agent_plugin_failures_total{plugin="aws:run\"Script\\"} 1
+# TYPE ssm_agent_queued_documents gauge
+ssm_agent_queued_documents +Inf
+`, buffer.String())
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("ssm_agent_documents_total", "Documents.").Add(2.5)
+
+	recorder := httptest.NewRecorder()
+	PrometheusHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, PrometheusContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP ssm_agent_documents_total Documents.\n# TYPE ssm_agent_documents_total counter\nssm_agent_documents_total 2.5\n", recorder.Body.String())
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics holds the internal metrics of the agent and exports them to sinks.
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Type is the type of a metric
+type Type string
+
+const (
+	// TypeCounter is a value that only increases, such as the number of executed documents
+	TypeCounter Type = "counter"
+	// TypeGauge is a value that goes up and down, such as the number of queued documents
+	TypeGauge Type = "gauge"
+
+	// labelSeparator joins the label values of a series in the keys of the series
+	labelSeparator = "\xff"
+)
+
+// Label is a name and value pair identifying a series of a metric
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Series is the value of a metric for a set of label values
+type Series struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a snapshot of a metric and its series
+type Family struct {
+	Name   string
+	Help   string
+	Type   Type
+	Series []Series
+}
+
+// Sink exports the metrics of a registry, such as the Prometheus exporter of the health endpoint
+type Sink interface {
+	// Export is called with a snapshot of the metrics each time the registry is collected
+	Export(families []Family) error
+}
+
+// Registry holds the metrics of the agent
+type Registry struct {
+	mut     sync.Mutex
+	metrics map[string]*metric
+}
+
+// metric holds the series of a metric by label values, sampled series are read when the registry is collected
+type metric struct {
+	name       string
+	help       string
+	metricType Type
+	labelNames []string
+	values     map[string]float64
+	samplers   map[string]func() float64
+}
+
+// Counter is a metric that only increases
+type Counter struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Gauge is a metric that goes up and down
+type Gauge struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Default is the registry of the metrics of the agent
+var Default = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// NewCounter returns the counter of the default registry with the given name, registering it if needed
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	return Default.Counter(name, help, labelNames...)
+}
+
+// NewGauge returns the gauge of the default registry with the given name, registering it if needed
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return Default.Gauge(name, help, labelNames...)
+}
+
+// Counter returns the counter with the given name, registering it if needed
+func (r *Registry) Counter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{registry: r, metric: r.register(name, help, TypeCounter, labelNames)}
+}
+
+// Gauge returns the gauge with the given name, registering it if needed
+func (r *Registry) Gauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{registry: r, metric: r.register(name, help, TypeGauge, labelNames)}
+}
+
+func (r *Registry) register(name string, help string, metricType Type, labelNames []string) *metric {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if m, found := r.metrics[name]; found {
+		return m
+	}
+	m := &metric{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+		samplers:   make(map[string]func() float64),
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// Inc increments the series of the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the series of the given label values, negative deltas are ignored
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.registry.update(c.metric, labelValues, func(value float64) float64 { return value + delta })
+}
+
+// Set sets the series of the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.registry.update(g.metric, labelValues, func(float64) float64 { return value })
+}
+
+// Add adds delta to the series of the given label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.registry.update(g.metric, labelValues, func(value float64) float64 { return value + delta })
+}
+
+// SetFunc samples the series of the given label values with sample each time the registry is collected,
+// a nil function removes the series
+func (g *Gauge) SetFunc(sample func() float64, labelValues ...string) {
+	g.registry.mut.Lock()
+	defer g.registry.mut.Unlock()
+	key := seriesKey(g.metric, labelValues)
+	if sample == nil {
+		delete(g.metric.samplers, key)
+		return
+	}
+	g.metric.samplers[key] = sample
+}
+
+func (r *Registry) update(m *metric, labelValues []string, update func(float64) float64) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	key := seriesKey(m, labelValues)
+	m.values[key] = update(m.values[key])
+}
+
+// seriesKey returns the key of the series of the given label values, missing values are empty
+func seriesKey(m *metric, labelValues []string) string {
+	values := make([]string, len(m.labelNames))
+	copy(values, labelValues)
+	return strings.Join(values, labelSeparator)
+}
+
+// Snapshot returns the metrics of the registry sorted by name, with their series sorted by label values
+func (r *Registry) Snapshot() (families []Family) {
+	r.mut.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	values := make([]map[string]float64, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metricValues := make(map[string]float64, len(m.values)+len(m.samplers))
+		for key, value := range m.values {
+			metricValues[key] = value
+		}
+		metrics = append(metrics, m)
+		values = append(values, metricValues)
+	}
+	samplers := make([]map[string]func() float64, len(metrics))
+	for i, m := range metrics {
+		samplers[i] = make(map[string]func() float64, len(m.samplers))
+		for key, sample := range m.samplers {
+			samplers[i][key] = sample
+		}
+	}
+	r.mut.Unlock()
+
+	for i, m := range metrics {
+		// samplers are called without the lock, they may read state guarded by other locks
+		for key, sample := range samplers[i] {
+			values[i][key] = sample()
+		}
+		family := Family{Name: m.name, Help: m.help, Type: m.metricType}
+		keys := make([]string, 0, len(values[i]))
+		for key := range values[i] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series := Series{Value: values[i][key]}
+			for j, value := range strings.Split(key, labelSeparator) {
+				if j < len(m.labelNames) {
+					series.Labels = append(series.Labels, Label{Name: m.labelNames[j], Value: value})
+				}
+			}
+			family.Series = append(family.Series, series)
+		}
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return
+}
+
+// Collect exports a snapshot of the metrics of the registry to the sink
+func (r *Registry) Collect(sink Sink) error {
+	return sink.Export(r.Snapshot())
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics holds the internal metrics of the agent and exports them to sinks.
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("documents_total", "Documents.", "type", "status")
+	counter.Inc("SendCommand", "Success")
+	counter.Add(2, "SendCommand", "Success")
+	counter.Add(-1, "SendCommand", "Success")
+	counter.Inc("SendCommand", "Failed")
+
+	// registering the same name returns the same metric
+	registry.Counter("documents_total", "Documents.", "type", "status").Inc("StartSession", "Success")
+
+	assert.Equal(t, []Family{{
+		Name: "documents_total",
+		Help: "Documents.",
+		Type: TypeCounter,
+		Series: []Series{
+			{Labels: []Label{{"type", "SendCommand"}, {"status", "Failed"}}, Value: 1},
+			{Labels: []Label{{"type", "SendCommand"}, {"status", "Success"}}, Value: 3},
+			{Labels: []Label{{"type", "StartSession"}, {"status", "Success"}}, Value: 1},
+		},
+	}}, registry.Snapshot())
+}
+
+func TestGauge(t *testing.T) {
+	registry := NewRegistry()
+	gauge := registry.Gauge("queued", "Queued.", "pool")
+	gauge.Set(5, "a")
+	gauge.Add(-2, "a")
+	queued := 7
+	gauge.SetFunc(func() float64 { return float64(queued) }, "b")
+
+	families := registry.Snapshot()
+	assert.Equal(t, []Series{
+		{Labels: []Label{{"pool", "a"}}, Value: 3},
+		{Labels: []Label{{"pool", "b"}}, Value: 7},
+	}, families[0].Series)
+
+	// sampled series are read on each snapshot, until removed
+	queued = 8
+	assert.Equal(t, float64(8), registry.Snapshot()[0].Series[1].Value)
+	gauge.SetFunc(nil, "b")
+	assert.Len(t, registry.Snapshot()[0].Series, 1)
+}
+
+func TestSnapshotSortsMetrics(t *testing.T) {
+	registry := NewRegistry()
+	registry.Gauge("b", "")
+	registry.Counter("a", "").Inc()
+
+	families := registry.Snapshot()
+	assert.Equal(t, "a", families[0].Name)
+	assert.Equal(t, []Series{{Value: 1}}, families[0].Series)
+	assert.Equal(t, "b", families[1].Name)
+	assert.Empty(t, families[1].Series)
+}
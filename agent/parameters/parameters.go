@@ -15,10 +15,12 @@
 package parameters
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
@@ -34,8 +36,13 @@ const paramNameRegex = "^[a-zA-Z0-9]+$"
 // Strings like "a {{ parameter1 }} within a string" are replaced with strings where the parameters
 // are replaced by a marshaled version of their values. In this case, the resulting object is always a string.
 //
-// Note: this only works on composite types []interface{} and map[string]interface{} which are what json.Unmarshal
-// produces by default. If your object contains []string, for example, the object will be returned as is.
+// Strings holding a JSON object or array, such as an inline CloudWatch configuration, are decoded and the
+// parameters are replaced within the decoded values before encoding them again. This keeps the JSON valid
+// when values contain quotes and preserves the type of values given as a single "{{ parameter }}" token.
+//
+// Note: this works on composite types []interface{} and map[string]interface{} which are what json.Unmarshal
+// produces by default, as well as []string and map[string]string whose values always stay strings.
+// Other types are returned as is.
 //
 // Returns a new object with replaced parameters.
 func ReplaceParameters(input interface{}, parameters map[string]interface{}, logger log.T) interface{} {
@@ -48,6 +55,11 @@ func ReplaceParameters(input interface{}, parameters map[string]interface{}, log
 			}
 		}
 
+		// look for parameters within an embedded JSON document
+		if output, ok := replaceParametersInJSON(input, parameters, logger); ok {
+			return output
+		}
+
 		// look for multiple parameter strings
 		for parameterName, parameterValue := range parameters {
 			var parameterValueString string
@@ -67,6 +79,21 @@ func ReplaceParameters(input interface{}, parameters map[string]interface{}, log
 		}
 		return out
 
+	case []string:
+		// for string slices, replace parameters on each element keeping the elements as strings
+		out := make([]string, len(input))
+		for i, v := range input {
+			out[i] = replaceParametersInString(v, parameters, logger)
+		}
+		return out
+
+	case map[string]string:
+		out := make(map[string]string)
+		for k, v := range input {
+			out[k] = replaceParametersInString(v, parameters, logger)
+		}
+		return out
+
 	case []map[string]interface{}:
 		// this case is not caught by the one above because map cannot be converted to interface{}
 		out := make([]map[string]interface{}, len(input))
@@ -101,6 +128,41 @@ func ReplaceParameters(input interface{}, parameters map[string]interface{}, log
 
 var singleParamRegex = regexp.MustCompile(paramNameRegex)
 
+// replaceParametersInString replaces parameters in input and converts the result to a string
+func replaceParametersInString(input string, parameters map[string]interface{}, logger log.T) string {
+	output, err := convertToString(ReplaceParameters(input, parameters, logger))
+	if err != nil {
+		logger.Error(err)
+		return input
+	}
+	return output
+}
+
+// replaceParametersInJSON replaces parameters within input if it is a JSON object or array referencing parameters.
+// Returns false if input is not such a JSON document.
+func replaceParametersInJSON(input string, parameters map[string]interface{}, logger log.T) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+	if !strings.Contains(trimmed, "{{") ||
+		!(strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") ||
+			strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")) {
+		return input, false
+	}
+
+	var document interface{}
+	if err := json.Unmarshal([]byte(trimmed), &document); err != nil {
+		return input, false
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(ReplaceParameters(document, parameters, logger)); err != nil {
+		logger.Errorf("failed to encode JSON after replacing parameters: %v", err)
+		return input, false
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), true
+}
+
 // isSingleParameterString returns true if the given string has the form "{{ paramName }}" with
 // some spaces but nothing else.
 func isSingleParameterString(input string, paramName string) bool {
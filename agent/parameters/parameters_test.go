@@ -223,3 +223,52 @@ func TestConvertToString(t *testing.T) {
 		assert.Equal(t, tst.Output, actual)
 	}
 }
+
+func TestReplaceParametersInTypedCollections(t *testing.T) {
+	params := map[string]interface{}{
+		"name":  "world",
+		"count": 3,
+	}
+
+	assert.Equal(t, []string{"hello world", "3"}, ReplaceParameters([]string{"hello {{ name }}", "{{ count }}"}, params, logger))
+	assert.Equal(t, map[string]string{"greeting": "hello world"}, ReplaceParameters(map[string]string{"greeting": "hello {{name}}"}, params, logger))
+}
+
+func TestReplaceParametersDeeplyNested(t *testing.T) {
+	params := map[string]interface{}{"name": "world"}
+	input := map[string]interface{}{
+		"a": []interface{}{
+			map[string]interface{}{
+				"b": []interface{}{[]interface{}{"{{ name }}"}},
+			},
+		},
+	}
+	output := map[string]interface{}{
+		"a": []interface{}{
+			map[string]interface{}{
+				"b": []interface{}{[]interface{}{"world"}},
+			},
+		},
+	}
+
+	assert.Equal(t, output, ReplaceParameters(input, params, logger))
+}
+
+func TestReplaceParametersInJSONString(t *testing.T) {
+	params := map[string]interface{}{
+		"logGroup": `my "quoted" group`,
+		"interval": 60,
+	}
+
+	input := `{"logs": {"log_group_name": "{{ logGroup }}", "interval": "{{ interval }}", "name": "<{{ interval }}s>"}}`
+	output := ReplaceParameters(input, params, logger)
+
+	assert.Equal(t, `{"logs":{"interval":60,"log_group_name":"my \"quoted\" group","name":"<60s>"}}`, output)
+}
+
+func TestReplaceParametersInInvalidJSONString(t *testing.T) {
+	params := map[string]interface{}{"interval": 60}
+
+	// parameters outside of JSON strings make the document invalid JSON, they are replaced as text
+	assert.Equal(t, `{"interval": 60}`, ReplaceParameters(`{"interval": {{ interval }}}`, params, logger))
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// parameterCacheTTL is how long resolved parameters are reused before calling GetParameters again
+	parameterCacheTTL = 30 * time.Second
+
+	// maxThrottlingRetries is the number of times a throttled GetParameters call is retried
+	maxThrottlingRetries = 3
+
+	// throttlingRetryBaseDelay is the delay before the first retry, doubled for each following retry
+	throttlingRetryBaseDelay = 500 * time.Millisecond
+)
+
+// Assign functions to global variables to allow unittest to override
+var getParametersPage = callGetParametersPage
+var timeNow = time.Now
+var sleep = time.Sleep
+
+type cachedParameter struct {
+	parameter Parameter
+	expiry    time.Time
+}
+
+// parameterCache holds recently resolved parameters indexed by the name used in the request.
+// SecureString parameters are never cached.
+var parameterCache = struct {
+	sync.Mutex
+	entries map[string]cachedParameter
+}{entries: make(map[string]cachedParameter)}
+
+// getCachedParameter returns the cached parameter for paramName if it hasn't expired
+func getCachedParameter(paramName string) (Parameter, bool) {
+	parameterCache.Lock()
+	defer parameterCache.Unlock()
+
+	entry, found := parameterCache.entries[paramName]
+	if !found {
+		return Parameter{}, false
+	}
+	if timeNow().After(entry.expiry) {
+		delete(parameterCache.entries, paramName)
+		return Parameter{}, false
+	}
+	return entry.parameter, true
+}
+
+// cacheParameters caches the parameters returned for the requested names
+func cacheParameters(paramNames []string, parameters []Parameter) {
+	parameterCache.Lock()
+	defer parameterCache.Unlock()
+
+	expiry := timeNow().Add(parameterCacheTTL)
+	for _, paramName := range paramNames {
+		name, version := splitParameterVersion(paramName)
+		for _, paramObj := range parameters {
+			if paramObj.Type == ParamTypeSecureString || paramObj.Name != name {
+				continue
+			}
+			if version == 0 || paramObj.Version == version {
+				parameterCache.entries[paramName] = cachedParameter{parameter: paramObj, expiry: expiry}
+			}
+		}
+	}
+}
+
+// clearParameterCache drops all cached parameters
+func clearParameterCache() {
+	parameterCache.Lock()
+	defer parameterCache.Unlock()
+	parameterCache.entries = make(map[string]cachedParameter)
+}
+
+// splitParameterVersion splits a parameter reference of the format name:version, version is 0 if not present
+func splitParameterVersion(paramName string) (string, int64) {
+	index := strings.LastIndex(paramName, ":")
+	if index < 0 {
+		return paramName, 0
+	}
+	version, err := strconv.ParseInt(paramName[index+1:], 10, 64)
+	if err != nil {
+		return paramName, 0
+	}
+	return paramName[:index], version
+}
+
+// getParametersWithRetry calls GetParameters and retries with exponential backoff while the call is throttled
+func getParametersWithRetry(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+	delay := throttlingRetryBaseDelay
+	for retry := 0; ; retry++ {
+		response, err := getParametersPage(log, paramNames, withDecryption)
+		if err == nil || !isThrottlingError(err) || retry == maxThrottlingRetries {
+			return response, err
+		}
+		log.Debugf("GetParameters call was throttled, retrying in %v", delay)
+		sleep(delay)
+		delay = delay * 2
+	}
+}
+
+// isThrottlingError returns true if err was caused by the service throttling the request
+func isThrottlingError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "ThrottlingException") || strings.Contains(message, "Rate exceeded")
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubGetParametersPage records the requested pages and returns a String parameter for each name
+func stubGetParametersPage(pages *[][]string, parameterType string) {
+	getParametersPage = func(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+		*pages = append(*pages, paramNames)
+		response := GetParametersResponse{}
+		for _, paramName := range paramNames {
+			response.Parameters = append(response.Parameters, Parameter{Name: paramName, Type: parameterType, Value: "value", Version: 1})
+		}
+		return &response, nil
+	}
+}
+
+func TestGetParametersInBatchesChunksRequests(t *testing.T) {
+	clearParameterCache()
+	defer clearParameterCache()
+	var pages [][]string
+	stubGetParametersPage(&pages, ParamTypeString)
+
+	paramNames := []string{}
+	for i := 0; i < 25; i++ {
+		paramNames = append(paramNames, fmt.Sprintf("param%d", i))
+	}
+	result, err := getParametersInBatches(logger, paramNames, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 25, len(result.Parameters))
+	assert.Equal(t, 3, len(pages))
+	assert.Equal(t, 10, len(pages[0]))
+	assert.Equal(t, 10, len(pages[1]))
+	assert.Equal(t, 5, len(pages[2]))
+}
+
+func TestGetParametersInBatchesUsesCache(t *testing.T) {
+	clearParameterCache()
+	defer clearParameterCache()
+	defer func() { timeNow = time.Now }()
+	var pages [][]string
+	stubGetParametersPage(&pages, ParamTypeString)
+	start := time.Now()
+	timeNow = func() time.Time { return start }
+
+	getParametersInBatches(logger, []string{"param1", "param2"}, false)
+	result, err := getParametersInBatches(logger, []string{"param1", "param3"}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result.Parameters))
+	assert.Equal(t, [][]string{{"param1", "param2"}, {"param3"}}, pages)
+
+	// expired entries are requested again
+	timeNow = func() time.Time { return start.Add(parameterCacheTTL + time.Second) }
+	getParametersInBatches(logger, []string{"param1"}, false)
+	assert.Equal(t, []string{"param1"}, pages[2])
+}
+
+func TestGetParametersInBatchesDoesNotCacheSecureString(t *testing.T) {
+	clearParameterCache()
+	defer clearParameterCache()
+	var pages [][]string
+	stubGetParametersPage(&pages, ParamTypeSecureString)
+
+	getParametersInBatches(logger, []string{"secret"}, true)
+	getParametersInBatches(logger, []string{"secret"}, true)
+
+	assert.Equal(t, 2, len(pages))
+}
+
+func TestCacheParametersWithVersion(t *testing.T) {
+	clearParameterCache()
+	defer clearParameterCache()
+
+	cacheParameters([]string{"param:2", "param:3"}, []Parameter{{Name: "param", Type: ParamTypeString, Value: "v2", Version: 2}})
+
+	paramObj, found := getCachedParameter("param:2")
+	assert.True(t, found)
+	assert.Equal(t, "v2", paramObj.Value)
+	_, found = getCachedParameter("param:3")
+	assert.False(t, found)
+}
+
+func TestGetParametersWithRetryOnThrottling(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	calls := 0
+	getParametersPage = func(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+		calls++
+		if calls < 3 {
+			return nil, fmt.Errorf("ThrottlingException: Rate exceeded")
+		}
+		return &GetParametersResponse{}, nil
+	}
+
+	_, err := getParametersWithRetry(logger, []string{"param"}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{throttlingRetryBaseDelay, 2 * throttlingRetryBaseDelay}, delays)
+}
+
+func TestGetParametersWithRetryGivesUp(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+	sleep = func(d time.Duration) {}
+
+	calls := 0
+	getParametersPage = func(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+		calls++
+		return nil, fmt.Errorf("ThrottlingException: Rate exceeded")
+	}
+	_, err := getParametersWithRetry(logger, []string{"param"}, false)
+	assert.Error(t, err)
+	assert.Equal(t, maxThrottlingRetries+1, calls)
+
+	// other errors are not retried
+	calls = 0
+	getParametersPage = func(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+		calls++
+		return nil, fmt.Errorf("AccessDeniedException")
+	}
+	_, err = getParametersWithRetry(logger, []string{"param"}, false)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -49,9 +49,28 @@ const (
 )
 
 var callParameterService = callGetParameters
+var callDecryptedParameterService = callGetDecryptedParameters
 
-// Resolve resolves ssm parameters of the format {{ssm:*}}
+// Resolve resolves ssm parameters of the format {{ssm:*}} and parameter hierarchies of the format {{ssm-path:*}}
 func Resolve(log log.T, input interface{}) (interface{}, error) {
+	return resolve(log, input, ResolveOptions{}, false)
+}
+
+// ResolveWithOptions resolves ssm parameters of the format {{ssm:*}} according to the given options.
+// SecureString parameters are never expanded, they are replaced with redaction markers which are
+// only restored in memory right before a plugin runs (see RestoreSecureStrings).
+func ResolveWithOptions(log log.T, input interface{}, options ResolveOptions) (interface{}, error) {
+	return resolve(log, input, options, true)
+}
+
+// resolve resolves ssm parameters, replacing SecureString values with redaction markers if redactSecureStrings is set
+func resolve(log log.T, input interface{}, options ResolveOptions, redactSecureStrings bool) (interface{}, error) {
+	// Resolve {{ssm-path:*}} references first, the parameters under the path are not referenced individually
+	input, err := resolveSSMPathReferences(log, input, options, redactSecureStrings)
+	if err != nil {
+		return input, err
+	}
+
 	validSSMParam, err := getValidSSMParamRegexCompiler(log, defaultParamName)
 	if err != nil {
 		return input, err
@@ -66,11 +85,15 @@ func Resolve(log log.T, input interface{}) (interface{}, error) {
 	}
 
 	// Get ssm parameter values
-	resolvedSSMParamMap, err := getSSMParameterValues(log, ssmParams)
+	resolvedSSMParamMap, err := getSSMParameterValues(log, ssmParams, options)
 	if err != nil {
 		return input, err
 	}
 
+	if redactSecureStrings {
+		resolvedSSMParamMap = redactSecureStringValues(resolvedSSMParamMap)
+	}
+
 	// Replace ssm parameter names with their values
 	input, err = replaceSSMParameters(log, input, resolvedSSMParamMap)
 	if err != nil {
@@ -86,15 +109,24 @@ func ValidateSSMParameters(
 	log log.T,
 	documentParameters map[string]*contracts.Parameter,
 	parameters map[string]interface{}) error {
+	return ValidateSSMParametersWithOptions(log, documentParameters, parameters, ResolveOptions{})
+}
+
+// ValidateSSMParametersWithOptions validates SSM parameters according to the given options
+func ValidateSSMParametersWithOptions(
+	log log.T,
+	documentParameters map[string]*contracts.Parameter,
+	parameters map[string]interface{},
+	options ResolveOptions) error {
 
 	/*
 		This function validates the following things before the document is sent for execution
 
-		1. Document doesn't contain SecureString SSM Parameters
+		1. Document doesn't contain SecureString SSM Parameters, unless the options allow them
 		2. SSM parameter values match the allowed pattern in the document
 	*/
 
-	resolvedParameters, err := Resolve(log, parameters)
+	resolvedParameters, err := resolve(log, parameters, options, false)
 	if err != nil {
 		return err
 	}
@@ -137,6 +169,14 @@ func ValidateSSMParameters(
 					}
 				}
 
+			case map[string]interface{}:
+				// values of {{ssm-path:*}} references
+				for _, v := range input {
+					if !validParamValue.MatchString(v.(string)) {
+						return errorString
+					}
+				}
+
 			default:
 				return fmt.Errorf("Unable to determine parameter value type for %v", paramName)
 			}
@@ -165,7 +205,7 @@ func getValidSSMParamRegexCompiler(log log.T, paramName string) (*regexp.Regexp,
 }
 
 // getSSMParameterValues takes a list of strings and resolves them by calling the GetParameters API
-func getSSMParameterValues(log log.T, ssmParams []string) (map[string]Parameter, error) {
+func getSSMParameterValues(log log.T, ssmParams []string, options ResolveOptions) (map[string]Parameter, error) {
 	var result *GetParametersResponse
 	var err error
 
@@ -188,11 +228,25 @@ func getSSMParameterValues(log log.T, ssmParams []string) (map[string]Parameter,
 		}
 	}
 
-	if result, err = callParameterService(log, paramNames); err != nil {
+	if options.AllowSecureString {
+		result, err = callDecryptedParameterService(log, paramNames)
+	} else {
+		result, err = callParameterService(log, paramNames)
+	}
+	if err != nil {
+		if options.TypedDecryptionErrors {
+			return nil, newDecryptionError(paramNames, err)
+		}
 		return nil, err
 	}
 
 	if len(paramNames) != len(result.Parameters) {
+		if options.TypedDecryptionErrors {
+			return nil, &DecryptionError{
+				Reason:     DecryptionFailureParameterNotFound,
+				Parameters: result.InvalidParameters,
+			}
+		}
 		errorString := fmt.Errorf("Input contains invalid parameters %v", result.InvalidParameters)
 		log.Debug(errorString)
 		return nil, errorString
@@ -256,7 +310,7 @@ func getSSMParameterValues(log log.T, ssmParams []string) (map[string]Parameter,
 		}
 	}
 
-	if len(secureStringParams) > 0 {
+	if len(secureStringParams) > 0 && !options.AllowSecureString {
 		return nil, fmt.Errorf("Parameters %v of type %v are not supported", secureStringParams, ParamTypeSecureString)
 	}
 
@@ -265,27 +319,39 @@ func getSSMParameterValues(log log.T, ssmParams []string) (map[string]Parameter,
 
 // callGetParameters makes a GetParameters API call to the service
 func callGetParameters(log log.T, paramNames []string) (*GetParametersResponse, error) {
+	return getParametersInBatches(log, paramNames, false)
+}
+
+// callGetDecryptedParameters makes a GetParameters API call to the service with decryption enabled
+func callGetDecryptedParameters(log log.T, paramNames []string) (*GetParametersResponse, error) {
+	return getParametersInBatches(log, paramNames, true)
+}
+
+// getParametersInBatches calls GetParameters with at most MaxParametersPerCall names at a time and merges the responses.
+// Parameters found in the cache are not requested again.
+func getParametersInBatches(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
 	finalResult := GetParametersResponse{}
 
-	ssmSvc := ssm.NewService()
+	uncachedParamNames := []string{}
+	for _, paramName := range paramNames {
+		if paramObj, found := getCachedParameter(paramName); found {
+			finalResult.Parameters = append(finalResult.Parameters, paramObj)
+		} else {
+			uncachedParamNames = append(uncachedParamNames, paramName)
+		}
+	}
 
-	for i := 0; i < len(paramNames); i = i + MaxParametersPerCall {
+	for i := 0; i < len(uncachedParamNames); i = i + MaxParametersPerCall {
 		limit := i + MaxParametersPerCall
-		if limit > len(paramNames) {
-			limit = len(paramNames)
+		if limit > len(uncachedParamNames) {
+			limit = len(uncachedParamNames)
 		}
 
-		result, err := ssmSvc.GetParameters(log, paramNames[i:limit])
+		response, err := getParametersWithRetry(log, uncachedParamNames[i:limit], withDecryption)
 		if err != nil {
 			return nil, err
 		}
-
-		var response GetParametersResponse
-		err = jsonutil.Remarshal(result, &response)
-		if err != nil {
-			log.Debug(err)
-			return nil, fmt.Errorf("%v", ErrorMsg)
-		}
+		cacheParameters(uncachedParamNames[i:limit], response.Parameters)
 
 		finalResult.Parameters = append(finalResult.Parameters, response.Parameters...)
 		finalResult.InvalidParameters = append(finalResult.InvalidParameters, response.InvalidParameters...)
@@ -293,3 +359,25 @@ func callGetParameters(log log.T, paramNames []string) (*GetParametersResponse,
 
 	return &finalResult, nil
 }
+
+// callGetParametersPage makes a single GetParameters API call for at most MaxParametersPerCall names
+func callGetParametersPage(log log.T, paramNames []string, withDecryption bool) (*GetParametersResponse, error) {
+	ssmSvc := ssm.NewService()
+	getParameters := ssmSvc.GetParameters
+	if withDecryption {
+		getParameters = ssmSvc.GetDecryptedParameters
+	}
+
+	result, err := getParameters(log, paramNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetParametersResponse
+	err = jsonutil.Remarshal(result, &response)
+	if err != nil {
+		log.Debug(err)
+		return nil, fmt.Errorf("%v", ErrorMsg)
+	}
+	return &response, nil
+}
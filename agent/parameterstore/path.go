@@ -0,0 +1,211 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssm"
+)
+
+const (
+	// ssmPathRegex matches references of the format {{ssm-path:/path}}, {{ssm-path:/path/*}} or {{ssm-path:/path/**}}
+	ssmPathRegex = "\\{\\{ *ssm-path:(/[/\\w.-]*?)(\\*\\*?)? *\\}\\}"
+
+	// recursivePathWildcard requests all the parameters in the hierarchy below the path
+	recursivePathWildcard = "**"
+)
+
+var callParametersByPathService = callGetParametersByPath
+
+// ssmPathReference is a parsed {{ssm-path:*}} reference
+type ssmPathReference struct {
+	Path      string
+	Recursive bool
+}
+
+// resolveSSMPathReferences resolves {{ssm-path:*}} references to a StringMap of the parameters under the path,
+// indexed by their name relative to the path. A string holding only the reference is replaced by the map,
+// otherwise the reference is replaced by the map marshaled to JSON.
+func resolveSSMPathReferences(log log.T, input interface{}, options ResolveOptions, redactSecureStrings bool) (interface{}, error) {
+	validSSMPath, err := regexp.Compile(ssmPathRegex)
+	if err != nil {
+		log.Debug(err)
+		return input, fmt.Errorf("%v", ErrorMsg)
+	}
+
+	references := extractSSMParameters(log, input, validSSMPath)
+	if len(references) == 0 {
+		return input, nil
+	}
+
+	resolvedPaths := map[string]map[string]interface{}{}
+	for _, reference := range references {
+		if _, seen := resolvedPaths[reference]; seen {
+			continue
+		}
+		if resolvedPaths[reference], err = getSSMPathValues(log, parseSSMPathReference(validSSMPath, reference), options, redactSecureStrings); err != nil {
+			return input, err
+		}
+	}
+
+	return replaceSSMPathReferences(log, input, validSSMPath, resolvedPaths)
+}
+
+// parseSSMPathReference returns the path and recursion of a {{ssm-path:*}} reference
+func parseSSMPathReference(validSSMPath *regexp.Regexp, reference string) ssmPathReference {
+	match := validSSMPath.FindStringSubmatch(reference)
+	path := match[1]
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return ssmPathReference{
+		Path:      path,
+		Recursive: match[2] == recursivePathWildcard,
+	}
+}
+
+// getSSMPathValues gets the parameters under the path as a StringMap
+func getSSMPathValues(log log.T, reference ssmPathReference, options ResolveOptions, redactSecureStrings bool) (map[string]interface{}, error) {
+	parameters, err := callParametersByPathService(log, reference.Path, reference.Recursive, options.AllowSecureString)
+	if err != nil {
+		if options.TypedDecryptionErrors {
+			return nil, newDecryptionError([]string{reference.Path}, err)
+		}
+		return nil, err
+	}
+
+	secureStringParams := []string{}
+	values := map[string]interface{}{}
+	prefix := strings.TrimSuffix(reference.Path, "/") + "/"
+	for _, paramObj := range parameters {
+		value := paramObj.Value
+		if paramObj.Type == ParamTypeSecureString {
+			secureStringParams = append(secureStringParams, paramObj.Name)
+			if redactSecureStrings {
+				value = fmt.Sprintf(secureStringMarkerFormat, paramObj.Name)
+			}
+		}
+		values[strings.TrimPrefix(paramObj.Name, prefix)] = value
+	}
+
+	if len(secureStringParams) > 0 && !options.AllowSecureString {
+		return nil, fmt.Errorf("Parameters %v of type %v are not supported", secureStringParams, ParamTypeSecureString)
+	}
+
+	return values, nil
+}
+
+// replaceSSMPathReferences replaces {{ssm-path:*}} references with the resolved StringMaps
+func replaceSSMPathReferences(log log.T, input interface{}, validSSMPath *regexp.Regexp, resolvedPaths map[string]map[string]interface{}) (interface{}, error) {
+	switch input := input.(type) {
+	case string:
+		if values, found := resolvedPaths[strings.TrimSpace(input)]; found {
+			return values, nil
+		}
+
+		var err error
+		output := validSSMPath.ReplaceAllStringFunc(input, func(reference string) string {
+			content, marshalErr := jsonutil.Marshal(resolvedPaths[reference])
+			if marshalErr != nil {
+				log.Debug(marshalErr)
+				err = fmt.Errorf("%v", ErrorMsg)
+				return reference
+			}
+			return content
+		})
+		return output, err
+
+	case []interface{}:
+		out := make([]interface{}, len(input))
+		for i, v := range input {
+			var err error
+			if out[i], err = replaceSSMPathReferences(log, v, validSSMPath, resolvedPaths); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(input))
+		for i, v := range input {
+			temp, err := replaceSSMPathReferences(log, v, validSSMPath, resolvedPaths)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = temp.(map[string]interface{})
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, v := range input {
+			var err error
+			if out[k], err = replaceSSMPathReferences(log, v, validSSMPath, resolvedPaths); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{})
+		for k, v := range input {
+			key, ok := k.(string)
+			if !ok {
+				return nil, errors.New("Unrecognized parameter type")
+			}
+			var err error
+			if out[key], err = replaceSSMPathReferences(log, v, validSSMPath, resolvedPaths); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	default:
+		// any other type, return as is
+		return input, nil
+	}
+}
+
+// callGetParametersByPath makes GetParametersByPath API calls until all the pages under path are returned
+func callGetParametersByPath(log log.T, path string, recursive bool, withDecryption bool) ([]Parameter, error) {
+	ssmSvc := ssm.NewService()
+
+	parameters := []Parameter{}
+	var nextToken *string
+	for {
+		result, err := ssmSvc.GetParametersByPath(log, path, recursive, withDecryption, nextToken)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []Parameter
+		if err = jsonutil.Remarshal(result.Parameters, &page); err != nil {
+			log.Debug(err)
+			return nil, fmt.Errorf("%v", ErrorMsg)
+		}
+		parameters = append(parameters, page...)
+
+		if result.NextToken == nil || *result.NextToken == "" {
+			return parameters, nil
+		}
+		nextToken = result.NextToken
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var pathParameters = []Parameter{
+	{Name: "/my/app/host", Type: ParamTypeString, Value: "db.local", Version: 1},
+	{Name: "/my/app/port", Type: ParamTypeString, Value: "5432", Version: 3},
+}
+
+func stubParametersByPathService(t *testing.T, expectedPath string, expectedRecursive bool, parameters []Parameter) {
+	callParametersByPathService = func(log log.T, path string, recursive bool, withDecryption bool) ([]Parameter, error) {
+		assert.Equal(t, expectedPath, path)
+		assert.Equal(t, expectedRecursive, recursive)
+		return parameters, nil
+	}
+}
+
+func TestParseSSMPathReference(t *testing.T) {
+	validSSMPath := regexp.MustCompile(ssmPathRegex)
+
+	assert.Equal(t, ssmPathReference{Path: "/my/app"}, parseSSMPathReference(validSSMPath, "{{ssm-path:/my/app}}"))
+	assert.Equal(t, ssmPathReference{Path: "/my/app"}, parseSSMPathReference(validSSMPath, "{{ ssm-path:/my/app/* }}"))
+	assert.Equal(t, ssmPathReference{Path: "/my/app", Recursive: true}, parseSSMPathReference(validSSMPath, "{{ssm-path:/my/app/**}}"))
+	assert.Equal(t, ssmPathReference{Path: "/", Recursive: true}, parseSSMPathReference(validSSMPath, "{{ssm-path:/**}}"))
+	assert.False(t, validSSMPath.MatchString("{{ssm-path:my/app}}"))
+}
+
+func TestResolveSSMPathAsStringMap(t *testing.T) {
+	stubParametersByPathService(t, "/my/app", false, pathParameters)
+
+	input := map[string]interface{}{"settings": "{{ssm-path:/my/app/*}}"}
+	result, err := Resolve(logger, input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"settings": map[string]interface{}{"host": "db.local", "port": "5432"},
+	}, result)
+}
+
+func TestResolveSSMPathRecursiveWithinString(t *testing.T) {
+	stubParametersByPathService(t, "/my", true, []Parameter{
+		{Name: "/my/app/host", Type: ParamTypeString, Value: "db.local", Version: 1},
+	})
+
+	result, err := Resolve(logger, []interface{}{"config={{ssm-path:/my/**}}"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{`config={"app/host":"db.local"}`}, result)
+}
+
+func TestResolveSSMPathRejectsSecureString(t *testing.T) {
+	stubParametersByPathService(t, "/my/app", false, []Parameter{
+		{Name: "/my/app/password", Type: ParamTypeSecureString, Value: "encrypted", Version: 1},
+	})
+
+	_, err := Resolve(logger, "{{ssm-path:/my/app}}")
+
+	assert.Error(t, err)
+}
+
+func TestResolveSSMPathRedactsSecureString(t *testing.T) {
+	stubParametersByPathService(t, "/my/app", false, []Parameter{
+		{Name: "/my/app/password", Type: ParamTypeSecureString, Value: "s3cr3t", Version: 1},
+	})
+
+	result, err := ResolveWithOptions(logger, "{{ssm-path:/my/app}}", ResolveOptions{AllowSecureString: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "{{ssm-secure:/my/app/password}}"}, result)
+}
+
+func TestValidateSSMParametersWithSSMPath(t *testing.T) {
+	stubParametersByPathService(t, "/my/app", false, pathParameters)
+	documentParameters := map[string]*contracts.Parameter{
+		"settings": {AllowedPattern: "^[a-z.]+$"},
+	}
+	parameters := map[string]interface{}{"settings": "{{ssm-path:/my/app}}"}
+
+	// the port doesn't match the allowed pattern
+	err := ValidateSSMParameters(logger, documentParameters, parameters)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,272 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// SecureStringAccessDeny rejects SecureString parameters, this is the default for documents
+	SecureStringAccessDeny = "deny"
+
+	// SecureStringAccessDecrypt allows SecureString parameters to be decrypted
+	SecureStringAccessDecrypt = "decrypt"
+
+	// SecureStringAccessDecryptStrict allows SecureString parameters to be decrypted and reports
+	// decryption failures as DecryptionError
+	SecureStringAccessDecryptStrict = "decryptStrict"
+
+	// secureStringMarkerFormat is the redaction marker replacing SecureString values
+	secureStringMarkerFormat = "{{ssm-secure:%v}}"
+
+	// secureStringMarkerRegex matches redaction markers
+	secureStringMarkerRegex = "\\{\\{ *ssm-secure:[/\\w.:-]+ *\\}\\}"
+)
+
+// DecryptionFailureReason identifies why a SecureString parameter couldn't be resolved
+type DecryptionFailureReason string
+
+const (
+	// DecryptionFailureKMSAccessDenied means the instance is not allowed to use the KMS key of the parameter
+	DecryptionFailureKMSAccessDenied DecryptionFailureReason = "KMSAccessDenied"
+
+	// DecryptionFailureParameterNotFound means the parameter (or the requested version) doesn't exist
+	DecryptionFailureParameterNotFound DecryptionFailureReason = "ParameterNotFound"
+)
+
+// ResolveOptions controls how ssm parameters are resolved
+type ResolveOptions struct {
+	// AllowSecureString allows SecureString parameters, they are fetched with decryption
+	AllowSecureString bool
+
+	// TypedDecryptionErrors reports KMS access denial and missing parameters as *DecryptionError
+	TypedDecryptionErrors bool
+}
+
+// DecryptionError is returned when parameters can't be resolved and TypedDecryptionErrors is set
+type DecryptionError struct {
+	Reason     DecryptionFailureReason
+	Parameters []string
+	Err        error
+}
+
+// Error returns the error message
+func (e *DecryptionError) Error() string {
+	switch e.Reason {
+	case DecryptionFailureKMSAccessDenied:
+		return fmt.Sprintf("Access to the KMS key was denied while decrypting parameters %v: %v", e.Parameters, e.Err)
+	case DecryptionFailureParameterNotFound:
+		return fmt.Sprintf("Input contains invalid parameters %v", e.Parameters)
+	default:
+		return fmt.Sprintf("Failed to resolve parameters %v: %v", e.Parameters, e.Err)
+	}
+}
+
+// IsKMSAccessDenied returns true if err is a DecryptionError caused by KMS access denial
+func IsKMSAccessDenied(err error) bool {
+	decryptionErr, ok := err.(*DecryptionError)
+	return ok && decryptionErr.Reason == DecryptionFailureKMSAccessDenied
+}
+
+// IsParameterNotFound returns true if err is a DecryptionError caused by a missing parameter
+func IsParameterNotFound(err error) bool {
+	decryptionErr, ok := err.(*DecryptionError)
+	return ok && decryptionErr.Reason == DecryptionFailureParameterNotFound
+}
+
+// ResolveOptionsForAccess returns the resolve options for the document level secureStringAccess flag
+func ResolveOptionsForAccess(access string) (ResolveOptions, error) {
+	switch access {
+	case "", SecureStringAccessDeny:
+		return ResolveOptions{}, nil
+	case SecureStringAccessDecrypt:
+		return ResolveOptions{AllowSecureString: true}, nil
+	case SecureStringAccessDecryptStrict:
+		return ResolveOptions{AllowSecureString: true, TypedDecryptionErrors: true}, nil
+	default:
+		return ResolveOptions{}, fmt.Errorf("Invalid secureStringAccess %v, expected one of %v, %v or %v",
+			access, SecureStringAccessDeny, SecureStringAccessDecrypt, SecureStringAccessDecryptStrict)
+	}
+}
+
+// newDecryptionError wraps service errors caused by KMS access denial, other errors are returned as is
+func newDecryptionError(paramNames []string, err error) error {
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "accessdenied") && strings.Contains(message, "kms") {
+		return &DecryptionError{
+			Reason:     DecryptionFailureKMSAccessDenied,
+			Parameters: paramNames,
+			Err:        err,
+		}
+	}
+	return err
+}
+
+// secureValues holds the decrypted SecureString values of the running documents, indexed by scope and value.
+// It is used to redact the values from anything written to disk.
+var secureValues = struct {
+	sync.RWMutex
+	scopes map[string]map[string]string
+}{scopes: make(map[string]map[string]string)}
+
+// RestoreSecureStrings replaces the redaction markers in input with the decrypted parameter values.
+// The values are remembered under scope so that Redact removes them from plugin output until
+// ForgetSecureValues is called.
+func RestoreSecureStrings(log log.T, scope string, input interface{}) (interface{}, error) {
+	validMarker, err := regexp.Compile(secureStringMarkerRegex)
+	if err != nil {
+		log.Debug(err)
+		return input, fmt.Errorf("%v", ErrorMsg)
+	}
+
+	markers := extractSSMParameters(log, input, validMarker)
+	if len(markers) == 0 {
+		return input, nil
+	}
+
+	// Markers keep the original parameter reference, resolve them as regular {{ssm:*}} references
+	references := make([]string, len(markers))
+	for i, marker := range markers {
+		references[i] = fmt.Sprintf("{{ssm:%v}}", secureStringReference(marker))
+	}
+
+	resolvedSSMParamMap, err := getSSMParameterValues(log, references, ResolveOptions{AllowSecureString: true, TypedDecryptionErrors: true})
+	if err != nil {
+		return input, err
+	}
+
+	resolvedMarkerMap := map[string]Parameter{}
+	for _, marker := range markers {
+		reference := secureStringReference(marker)
+		paramObj := resolvedSSMParamMap[fmt.Sprintf("{{ssm:%v}}", reference)]
+		resolvedMarkerMap[marker] = paramObj
+		rememberSecureValue(scope, paramObj.Value, reference)
+	}
+
+	return replaceSSMParameters(log, input, resolvedMarkerMap)
+}
+
+// ForgetSecureValues drops the SecureString values remembered for scope
+func ForgetSecureValues(scope string) {
+	secureValues.Lock()
+	defer secureValues.Unlock()
+	delete(secureValues.scopes, scope)
+}
+
+// Redact replaces the known SecureString values in text with their redaction markers
+func Redact(text string) string {
+	secureValues.RLock()
+	defer secureValues.RUnlock()
+
+	values := []string{}
+	references := map[string]string{}
+	for _, scopeValues := range secureValues.scopes {
+		for value, reference := range scopeValues {
+			values = append(values, value)
+			references[value] = reference
+		}
+	}
+
+	// Replace longer values first so that a value containing another one is fully redacted
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		text = strings.Replace(text, value, fmt.Sprintf(secureStringMarkerFormat, references[value]), -1)
+	}
+	return text
+}
+
+// NewRedactingWriter returns a writer which redacts SecureString values before writing to w.
+// Output is buffered line by line while values are known, Close flushes the remaining output.
+func NewRedactingWriter(w io.Writer) io.WriteCloser {
+	return &redactingWriter{writer: w}
+}
+
+type redactingWriter struct {
+	writer io.Writer
+	buffer []byte
+}
+
+// Write redacts and writes every complete line of p
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if len(r.buffer) == 0 && !hasSecureValues() {
+		return r.writer.Write(p)
+	}
+
+	r.buffer = append(r.buffer, p...)
+	if index := strings.LastIndex(string(r.buffer), "\n"); index >= 0 {
+		line := Redact(string(r.buffer[:index+1]))
+		r.buffer = r.buffer[index+1:]
+		if _, err := r.writer.Write([]byte(line)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close redacts and writes the buffered output
+func (r *redactingWriter) Close() error {
+	if len(r.buffer) == 0 {
+		return nil
+	}
+	_, err := r.writer.Write([]byte(Redact(string(r.buffer))))
+	r.buffer = nil
+	return err
+}
+
+// redactSecureStringValues returns a copy of ssmParameters where SecureString values are replaced with redaction markers
+func redactSecureStringValues(ssmParameters map[string]Parameter) map[string]Parameter {
+	redacted := make(map[string]Parameter, len(ssmParameters))
+	for reference, paramObj := range ssmParameters {
+		if paramObj.Type == ParamTypeSecureString {
+			paramObj.Value = fmt.Sprintf(secureStringMarkerFormat, secureStringReference(reference))
+		}
+		redacted[reference] = paramObj
+	}
+	return redacted
+}
+
+// secureStringReference returns the parameter name, and version if any, of a {{ssm:*}} reference or redaction marker
+func secureStringReference(reference string) string {
+	reference = strings.Trim(reference, "{} ")
+	return reference[strings.Index(reference, ":")+1:]
+}
+
+// rememberSecureValue records a decrypted value for redaction
+func rememberSecureValue(scope, value, reference string) {
+	if value == "" {
+		return
+	}
+	secureValues.Lock()
+	defer secureValues.Unlock()
+	if _, ok := secureValues.scopes[scope]; !ok {
+		secureValues.scopes[scope] = make(map[string]string)
+	}
+	secureValues.scopes[scope][value] = reference
+}
+
+// hasSecureValues returns true if any SecureString value is remembered
+func hasSecureValues() bool {
+	secureValues.RLock()
+	defer secureValues.RUnlock()
+	return len(secureValues.scopes) > 0
+}
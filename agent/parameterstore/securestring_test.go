@@ -0,0 +1,164 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parameterstore contains modules to resolve ssm parameters present in the document.
+package parameterstore
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var secureParameter = Parameter{
+	Name:    "db/password",
+	Type:    ParamTypeSecureString,
+	Value:   "s3cr3t",
+	Version: 2,
+}
+
+func stubDecryptedParameterService(parameters []Parameter, invalidParameters []string, err error) {
+	callDecryptedParameterService = func(log log.T, paramNames []string) (*GetParametersResponse, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &GetParametersResponse{Parameters: parameters, InvalidParameters: invalidParameters}, nil
+	}
+}
+
+func TestResolveOptionsForAccess(t *testing.T) {
+	options, err := ResolveOptionsForAccess("")
+	assert.NoError(t, err)
+	assert.Equal(t, ResolveOptions{}, options)
+
+	options, err = ResolveOptionsForAccess(SecureStringAccessDecrypt)
+	assert.NoError(t, err)
+	assert.Equal(t, ResolveOptions{AllowSecureString: true}, options)
+
+	options, err = ResolveOptionsForAccess(SecureStringAccessDecryptStrict)
+	assert.NoError(t, err)
+	assert.Equal(t, ResolveOptions{AllowSecureString: true, TypedDecryptionErrors: true}, options)
+
+	_, err = ResolveOptionsForAccess("always")
+	assert.Error(t, err)
+}
+
+func TestResolveRejectsSecureStringByDefault(t *testing.T) {
+	callParameterService = func(log log.T, paramNames []string) (*GetParametersResponse, error) {
+		return &GetParametersResponse{Parameters: []Parameter{secureParameter}}, nil
+	}
+
+	_, err := ResolveWithOptions(logger, "password={{ssm:db/password}}", ResolveOptions{})
+
+	assert.Error(t, err)
+}
+
+func TestResolveWithOptionsRedactsSecureString(t *testing.T) {
+	stubDecryptedParameterService([]Parameter{secureParameter}, []string{}, nil)
+
+	result, err := ResolveWithOptions(logger, "password={{ssm:db/password}}", ResolveOptions{AllowSecureString: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "password={{ssm-secure:db/password}}", result)
+}
+
+func TestValidateSSMParametersWithOptionsUsesDecryptedValues(t *testing.T) {
+	stubDecryptedParameterService([]Parameter{secureParameter}, []string{}, nil)
+	documentParameters := map[string]*contracts.Parameter{
+		"password": {AllowedPattern: "^[a-z0-9]+$"},
+	}
+	parameters := map[string]interface{}{"password": "{{ssm:db/password}}"}
+
+	err := ValidateSSMParametersWithOptions(logger, documentParameters, parameters, ResolveOptions{AllowSecureString: true})
+
+	assert.NoError(t, err)
+}
+
+func TestResolveWithOptionsTypedErrors(t *testing.T) {
+	strict := ResolveOptions{AllowSecureString: true, TypedDecryptionErrors: true}
+
+	stubDecryptedParameterService(nil, nil, fmt.Errorf("Encountered error while calling GetParameters API. Error: AccessDeniedException: not authorized to perform kms:Decrypt"))
+	_, err := ResolveWithOptions(logger, "{{ssm:db/password}}", strict)
+	assert.True(t, IsKMSAccessDenied(err))
+	assert.False(t, IsParameterNotFound(err))
+
+	stubDecryptedParameterService([]Parameter{}, []string{"db/password"}, nil)
+	_, err = ResolveWithOptions(logger, "{{ssm:db/password}}", strict)
+	assert.True(t, IsParameterNotFound(err))
+	assert.Equal(t, []string{"db/password"}, err.(*DecryptionError).Parameters)
+
+	// other service errors are returned as is
+	stubDecryptedParameterService(nil, nil, fmt.Errorf("ThrottlingException"))
+	_, err = ResolveWithOptions(logger, "{{ssm:db/password}}", strict)
+	assert.Error(t, err)
+	assert.False(t, IsKMSAccessDenied(err))
+
+	// without the strict mode errors are untyped
+	stubDecryptedParameterService([]Parameter{}, []string{"db/password"}, nil)
+	_, err = ResolveWithOptions(logger, "{{ssm:db/password}}", ResolveOptions{AllowSecureString: true})
+	assert.Error(t, err)
+	assert.False(t, IsParameterNotFound(err))
+}
+
+func TestRestoreSecureStringsAndRedact(t *testing.T) {
+	stubDecryptedParameterService([]Parameter{secureParameter}, []string{}, nil)
+	defer ForgetSecureValues("command-id")
+
+	input := map[string]interface{}{"commands": []interface{}{"login -p {{ssm-secure:db/password}}"}}
+	result, err := RestoreSecureStrings(logger, "command-id", input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"commands": []string{"login -p s3cr3t"}}, result)
+	assert.Equal(t, "output {{ssm-secure:db/password}}", Redact("output s3cr3t"))
+
+	ForgetSecureValues("command-id")
+	assert.Equal(t, "output s3cr3t", Redact("output s3cr3t"))
+}
+
+func TestRestoreSecureStringsWithoutMarkers(t *testing.T) {
+	stubDecryptedParameterService(nil, nil, fmt.Errorf("unexpected call"))
+
+	result, err := RestoreSecureStrings(logger, "command-id", "echo hello")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "echo hello", result)
+}
+
+func TestRedactingWriter(t *testing.T) {
+	rememberSecureValue("command-id", "s3cr3t", "db/password")
+	defer ForgetSecureValues("command-id")
+
+	var out bytes.Buffer
+	writer := NewRedactingWriter(&out)
+	for _, b := range []byte("line s3cr3t\ntrailing s3c") {
+		writer.Write([]byte{b})
+	}
+	assert.Equal(t, "line {{ssm-secure:db/password}}\n", out.String())
+
+	writer.Write([]byte("r3t"))
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "line {{ssm-secure:db/password}}\ntrailing {{ssm-secure:db/password}}", out.String())
+}
+
+func TestRedactingWriterWithoutSecureValues(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewRedactingWriter(&out)
+
+	writer.Write([]byte("partial"))
+
+	assert.Equal(t, "partial", out.String())
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -60,6 +61,14 @@ type ApplicationPluginInput struct {
 	Source         string
 	SourceHash     string
 	SourceHashType string
+	// InstallerType is Msi, Msix or Exe, it is detected from the extension of the source when not set
+	InstallerType string
+	// SilentSwitches names the template of switches running Exe installers silently, such as Nsis or InnoSetup
+	SilentSwitches string
+	// ProductCode verifies the result of the action, it is the product code or uninstall key name of
+	// Msi and Exe installers and the package name of Msix packages
+	ProductCode    string
+	TimeoutSeconds interface{}
 }
 
 // NewPlugin returns a new instance of the plugin.
@@ -118,47 +127,117 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput Application
 		return
 	}
 
-	// Get application mode
-	mode, err := getMsiApplicationMode(log, pluginInput)
+	installerType, err := getInstallerType(pluginInput)
 	if err != nil {
 		output.MarkAsFailed(err)
 		return
 	}
-	log.Debugf("mode is %v", mode)
+	if err = validateInstallerInput(installerType, pluginInput); err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	log.Debugf("installer type is %v", installerType)
 
 	var localFilePath string
-	// Download file from source if available
-	downloadOutput, err := pluginutil.DownloadFileFromSource(log, pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType)
-	if err != nil || downloadOutput.IsHashMatched == false || downloadOutput.LocalFilePath == "" {
-		errorString := fmt.Errorf("failed to download file reliably %v", pluginInput.Source)
-		output.MarkAsFailed(errorString)
+	if requiresSource(installerType, pluginInput) {
+		// Download file from source if available
+		downloadOutput, err := pluginutil.DownloadFileFromSource(log, pluginInput.Source, pluginInput.SourceHash, pluginInput.SourceHashType)
+		if err != nil || downloadOutput.IsHashMatched == false || downloadOutput.LocalFilePath == "" {
+			errorString := fmt.Errorf("failed to download file reliably %v", pluginInput.Source)
+			output.MarkAsFailed(errorString)
+			return
+		}
+		localFilePath = downloadOutput.LocalFilePath
+		log.Debugf("local path to file is %v", localFilePath)
+	}
+
+	// Construct Command Name and Arguments
+	var commandName string
+	var commandArguments []string
+	switch installerType {
+	case InstallerTypeMsix:
+		commandName, commandArguments = getMsixCommand(log, pluginInput, localFilePath)
+	case InstallerTypeExe:
+		commandName, commandArguments, err = getExeCommand(log, pluginInput, localFilePath)
+	default:
+		commandName, commandArguments, err = getMsiExecCommand(log, pluginInput, localFilePath)
+	}
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+
+	// Execute Command
+	executionTimeout := getExecutionTimeout(log, pluginInput)
+	exitCode, err := p.CommandExecuter.NewExecute(log, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments)
+
+	// Set output status
+	output.SetExitCode(exitCode)
+	if installerType == InstallerTypeMsi {
+		setMsiExecStatus(log, pluginInput, cancelFlag, output)
+	} else {
+		setInstallerStatus(log, pluginInput, cancelFlag, output)
+	}
+	if output.GetStatus() == contracts.ResultStatusTimedOut {
+		output.AppendErrorf("%v did not complete within %v seconds", pluginInput.Source, executionTimeout)
+	}
+
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to run commands: %v", err))
 		return
 	}
-	localFilePath = downloadOutput.LocalFilePath
-	log.Debugf("local path to file is %v", localFilePath)
+
+	// the product is only expected in its final state once the instance restarted
+	if output.GetStatus() == contracts.ResultStatusSuccess && pluginInput.ProductCode != "" {
+		if err = p.verifyAction(log, installerType, pluginInput, cancelFlag); err != nil {
+			output.MarkAsFailed(err)
+		}
+	}
+}
+
+// getMsiExecCommand returns the msiexec command running the action on the msi package
+func getMsiExecCommand(log log.T, pluginInput ApplicationPluginInput, localFilePath string) (string, []string, error) {
+	// Get application mode
+	mode, err := getMsiApplicationMode(log, pluginInput)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Debugf("mode is %v", mode)
 
 	// Create msi related log file
 	localSourceLogFilePath := localFilePath + ".msiexec.log.txt"
 	log.Debugf("log path is %v", localSourceLogFilePath)
 
-	// Construct Command Name and Arguments
-	commandName := msiExecCommand
 	commandArguments := []string{mode, localFilePath, "/quiet", "/norestart", "/log", localSourceLogFilePath}
 	if pluginInput.Parameters != "" {
 		log.Debugf("Got Parameters \"%v\"", pluginInput.Parameters)
 		params := processParams(log, pluginInput.Parameters)
 		commandArguments = append(commandArguments, params...)
 	}
+	return msiExecCommand, commandArguments, nil
+}
 
-	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(log, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments)
+// getExecutionTimeout returns the timeout of the installer, the default timeout applies when it is not set
+func getExecutionTimeout(log log.T, pluginInput ApplicationPluginInput) int {
+	if pluginInput.TimeoutSeconds == nil {
+		return defaultApplicationExecutionTimeoutInSeconds
+	}
+	return pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
+}
 
-	// Set output status
-	output.SetExitCode(exitCode)
-	setMsiExecStatus(log, pluginInput, cancelFlag, output)
+// requiresSource returns whether the action runs the downloaded installer, Msix and Exe uninstalls
+// only need the product code
+func requiresSource(installerType string, pluginInput ApplicationPluginInput) bool {
+	return installerType == InstallerTypeMsi || pluginInput.Action != UNINSTALL
+}
 
-	if err != nil {
-		output.MarkAsFailed(fmt.Errorf("failed to run commands: %v", err))
-		return
+// isSourceExtension returns whether the path of the source url has one of the extensions
+func isSourceExtension(source string, extensions ...string) bool {
+	sourcePath := strings.ToLower(strings.SplitN(source, "?", 2)[0])
+	for _, extension := range extensions {
+		if strings.HasSuffix(sourcePath, extension) {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,119 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package application implements the application plugin.
+//
+// +build windows
+
+package application
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows/registry"
+)
+
+// logFilePlaceholder is replaced by the path of the installer log file in the silent switches
+const logFilePlaceholder = "{logFile}"
+
+// silentSwitchTemplates are the switches running the installers of common frameworks without user interaction
+var silentSwitchTemplates = map[string][]string{
+	"nsis":          {"/S"},
+	"innosetup":     {"/VERYSILENT", "/SUPPRESSMSGBOXES", "/NORESTART", "/LOG=" + logFilePlaceholder},
+	"installshield": {"/s", "/v/qn"},
+	"wixburn":       {"/quiet", "/norestart", "/log", logFilePlaceholder},
+}
+
+// getExeCommand returns the command running the installer, or the uninstaller registered for the product
+func getExeCommand(log log.T, pluginInput ApplicationPluginInput, localFilePath string) (string, []string, error) {
+	switches, err := getSilentSwitches(pluginInput.SilentSwitches, localFilePath+".install.log.txt")
+	if err != nil {
+		return "", nil, err
+	}
+
+	var commandName string
+	var commandArguments []string
+	if pluginInput.Action == UNINSTALL {
+		uninstallCommand, quiet, err := getUninstallCommand(log, pluginInput.ProductCode)
+		if err != nil {
+			return "", nil, err
+		}
+		if uninstallCommand == nil {
+			// as for msi products, uninstalling a product which is not installed succeeds
+			log.Infof("%v is not installed", pluginInput.ProductCode)
+			return "cmd.exe", []string{"/c", "exit", "0"}, nil
+		}
+		commandName, commandArguments = uninstallCommand[0], uninstallCommand[1:]
+		if !quiet {
+			commandArguments = append(commandArguments, switches...)
+		}
+	} else {
+		commandName, commandArguments = localFilePath, switches
+	}
+
+	if pluginInput.Parameters != "" {
+		log.Debugf("Got Parameters \"%v\"", pluginInput.Parameters)
+		commandArguments = append(commandArguments, processParams(log, pluginInput.Parameters)...)
+	}
+	return commandName, commandArguments, nil
+}
+
+// getSilentSwitches returns the switches of the template with the log file of the installer
+func getSilentSwitches(templateName string, logFilePath string) ([]string, error) {
+	if templateName == "" {
+		return []string{}, nil
+	}
+	template, ok := silentSwitchTemplates[strings.ToLower(templateName)]
+	if !ok {
+		return nil, fmt.Errorf("SilentSwitches is set to unsupported value: %v", templateName)
+	}
+	switches := make([]string, len(template))
+	for i, value := range template {
+		switches[i] = strings.Replace(value, logFilePlaceholder, logFilePath, -1)
+	}
+	return switches, nil
+}
+
+// getUninstallCommand returns the uninstall command registered for the product and whether it already runs silently,
+// the command is nil when the product is not installed
+func getUninstallCommand(log log.T, productCode string) ([]string, bool, error) {
+	key, err := openUninstallKey(productCode)
+	if err != nil {
+		if err == registry.ErrNotExist {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read the uninstall key of %v: %v", productCode, err)
+	}
+	defer key.Close()
+
+	quiet := true
+	commandLine, _, err := key.GetStringValue("QuietUninstallString")
+	if err != nil {
+		quiet = false
+		if commandLine, _, err = key.GetStringValue("UninstallString"); err != nil {
+			return nil, false, fmt.Errorf("no uninstall command is registered for %v", productCode)
+		}
+	}
+	return splitCommandLine(log, commandLine), quiet, nil
+}
+
+// splitCommandLine splits the command line into the unquoted executable and its arguments
+func splitCommandLine(log log.T, commandLine string) []string {
+	params := processParams(log, strings.TrimSpace(commandLine))
+	if len(params) > 0 {
+		params[0] = strings.Trim(params[0], `"`)
+	}
+	return params
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package application implements the application plugin.
+//
+// +build windows
+
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	InstallerTypeMsi = "Msi"
+
+	InstallerTypeMsix = "Msix"
+
+	InstallerTypeExe = "Exe"
+)
+
+const (
+	// verificationTimeoutInSeconds is the timeout of the command verifying Msix packages
+	verificationTimeoutInSeconds = 60
+
+	// uninstallKeyPath is the registry key listing the installed Msi and Exe products
+	uninstallKeyPath = `SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\`
+)
+
+// getInstallerType returns the installer type of the plugin input, detecting it from the source when it is not set
+func getInstallerType(pluginInput ApplicationPluginInput) (string, error) {
+	switch strings.ToLower(pluginInput.InstallerType) {
+	case "":
+		if isSourceExtension(pluginInput.Source, ".msix", ".msixbundle", ".appx", ".appxbundle") {
+			return InstallerTypeMsix, nil
+		}
+		if isSourceExtension(pluginInput.Source, ".exe") {
+			return InstallerTypeExe, nil
+		}
+		// msi remains the default for sources without a known extension
+		return InstallerTypeMsi, nil
+	case strings.ToLower(InstallerTypeMsi):
+		return InstallerTypeMsi, nil
+	case strings.ToLower(InstallerTypeMsix):
+		return InstallerTypeMsix, nil
+	case strings.ToLower(InstallerTypeExe):
+		return InstallerTypeExe, nil
+	default:
+		return "", fmt.Errorf("InstallerType is set to unsupported value: %v", pluginInput.InstallerType)
+	}
+}
+
+// validateInstallerInput ensures the action and product code are supported by the installer type
+func validateInstallerInput(installerType string, pluginInput ApplicationPluginInput) error {
+	if installerType != InstallerTypeMsi {
+		if pluginInput.Action != INSTALL && pluginInput.Action != UNINSTALL {
+			return fmt.Errorf("Action %v is not supported by %v installers", pluginInput.Action, installerType)
+		}
+		if pluginInput.Action == UNINSTALL && pluginInput.ProductCode == "" {
+			return fmt.Errorf("ProductCode must be specified to uninstall %v installers", installerType)
+		}
+	}
+	if installerType != InstallerTypeExe && pluginInput.SilentSwitches != "" {
+		return fmt.Errorf("SilentSwitches is only supported by %v installers", InstallerTypeExe)
+	}
+	if pluginInput.ProductCode != "" {
+		if installerType == InstallerTypeMsix && !msixPackageName.MatchString(pluginInput.ProductCode) {
+			return fmt.Errorf("ProductCode %v is not a valid package name", pluginInput.ProductCode)
+		}
+		if strings.ContainsAny(pluginInput.ProductCode, `\/`) {
+			return fmt.Errorf("ProductCode %v is not a valid product code", pluginInput.ProductCode)
+		}
+	}
+	return nil
+}
+
+// setInstallerStatus sets the status of Msix and Exe installers based on the exit code
+func setInstallerStatus(log log.T, pluginInput ApplicationPluginInput, cancelFlag task.CancelFlag, out iohandler.IOHandler) {
+	out.AppendInfo(pluginInput.Source)
+
+	switch out.GetExitCode() {
+	case appconfig.SuccessExitCode:
+		out.SetStatus(contracts.ResultStatusSuccess)
+	case ErrorSuccessRebootInitiated, appconfig.RebootExitCode:
+		out.SetStatus(contracts.ResultStatusSuccessAndReboot)
+	case appconfig.CommandStoppedPreemptivelyExitCode:
+		out.SetStatus(getStoppedStatus(cancelFlag))
+	default:
+		out.SetStatus(contracts.ResultStatusFailed)
+		out.AppendErrorf("Action:{%v}; Status:{%v}; ErrorCode:{%v}; Source:{%v};", pluginInput.Action, out.GetStatus(), out.GetExitCode(), pluginInput.Source)
+	}
+	log.Debugf("resultCode: %v", out.GetExitCode())
+}
+
+// getStoppedStatus returns the status of an installer stopped before it completed
+func getStoppedStatus(cancelFlag task.CancelFlag) contracts.ResultStatus {
+	if cancelFlag.ShutDown() {
+		return contracts.ResultStatusFailed
+	}
+	if cancelFlag.Canceled() {
+		return contracts.ResultStatusCancelled
+	}
+	return contracts.ResultStatusTimedOut
+}
+
+// verifyAction ensures the product is installed after an install and removed after an uninstall
+func (p *Plugin) verifyAction(log log.T, installerType string, pluginInput ApplicationPluginInput, cancelFlag task.CancelFlag) error {
+	var installed bool
+	var err error
+	if installerType == InstallerTypeMsix {
+		installed, err = p.isMsixPackageInstalled(log, pluginInput.ProductCode, cancelFlag)
+	} else {
+		installed, err = isProductInstalled(pluginInput.ProductCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify %v: %v", pluginInput.ProductCode, err)
+	}
+
+	log.Debugf("%v installed: %v", pluginInput.ProductCode, installed)
+	if pluginInput.Action == UNINSTALL && installed {
+		return fmt.Errorf("%v is still installed after the uninstall", pluginInput.ProductCode)
+	}
+	if pluginInput.Action != UNINSTALL && !installed {
+		return fmt.Errorf("%v is not installed after the %v", pluginInput.ProductCode, strings.ToLower(pluginInput.Action))
+	}
+	return nil
+}
+
+// isMsixPackageInstalled returns whether a package with the name is installed for any user
+func (p *Plugin) isMsixPackageInstalled(log log.T, packageName string, cancelFlag task.CancelFlag) (bool, error) {
+	var stdout, stderr bytes.Buffer
+	script := fmt.Sprintf("if (Get-AppxPackage -AllUsers -Name %v) { exit 0 } else { exit 1 }", quotePowerShellString(packageName))
+	exitCode, err := p.CommandExecuter.NewExecute(log, "", &stdout, &stderr, cancelFlag, verificationTimeoutInSeconds,
+		appconfig.PowerShellPluginCommandName, powerShellArguments(script))
+	if err != nil {
+		return false, fmt.Errorf("%v %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return exitCode == appconfig.SuccessExitCode, nil
+}
+
+// isProductInstalled returns whether the product has an uninstall key in the 64-bit or 32-bit registry
+func isProductInstalled(productCode string) (bool, error) {
+	key, err := openUninstallKey(productCode)
+	if err == registry.ErrNotExist {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	key.Close()
+	return true, nil
+}
+
+// openUninstallKey opens the uninstall key of the product, 32-bit installers register it in the 32-bit registry view
+func openUninstallKey(productCode string) (key registry.Key, err error) {
+	for _, view := range []uint32{registry.WOW64_64KEY, registry.WOW64_32KEY} {
+		if key, err = registry.OpenKey(registry.LOCAL_MACHINE, uninstallKeyPath+productCode, registry.QUERY_VALUE|view); err != registry.ErrNotExist {
+			return
+		}
+	}
+	return
+}
@@ -75,13 +75,7 @@ func setMsiExecStatus(log log.T, pluginInput ApplicationPluginInput, cancelFlag
 	case appconfig.RebootExitCode:
 		out.SetStatus(contracts.ResultStatusSuccessAndReboot)
 	case appconfig.CommandStoppedPreemptivelyExitCode:
-		if cancelFlag.ShutDown() {
-			out.SetStatus(contracts.ResultStatusFailed)
-		}
-		if cancelFlag.Canceled() {
-			out.SetStatus(contracts.ResultStatusCancelled)
-		}
-		out.SetStatus(contracts.ResultStatusTimedOut)
+		out.SetStatus(getStoppedStatus(cancelFlag))
 	default:
 		isUnKnownError = true
 	}
@@ -0,0 +1,57 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package application implements the application plugin.
+//
+// +build windows
+
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// msixPackageName matches the names of Msix packages
+var msixPackageName = regexp.MustCompile(`^[a-zA-Z0-9.\-]+$`)
+
+// getMsixCommand returns the PowerShell command adding the package or removing the installed package
+func getMsixCommand(log log.T, pluginInput ApplicationPluginInput, localFilePath string) (string, []string) {
+	var script string
+	if pluginInput.Action == UNINSTALL {
+		// removing a package which is not installed succeeds, as for msi products
+		script = fmt.Sprintf("Get-AppxPackage -AllUsers -Name %v | Remove-AppxPackage -AllUsers", quotePowerShellString(pluginInput.ProductCode))
+	} else {
+		script = fmt.Sprintf("Add-AppxPackage -Path %v -ForceApplicationShutdown", quotePowerShellString(localFilePath))
+		if pluginInput.Parameters != "" {
+			log.Debugf("Got Parameters \"%v\"", pluginInput.Parameters)
+			script += " " + pluginInput.Parameters
+		}
+	}
+	log.Debugf("msix command is %v", script)
+	return appconfig.PowerShellPluginCommandName, powerShellArguments(script)
+}
+
+// powerShellArguments returns the arguments running the script, errors stop the script with a non zero exit code
+func powerShellArguments(script string) []string {
+	return []string{"-NoProfile", "-NonInteractive", "-Command", "$ErrorActionPreference = 'Stop'; " + script}
+}
+
+// quotePowerShellString returns the value as a PowerShell literal string
+func quotePowerShellString(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
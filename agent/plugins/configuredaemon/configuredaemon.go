@@ -38,11 +38,8 @@ type Plugin struct {
 
 // NewPlugin returns lrpminvoker
 func NewPlugin() (*Plugin, error) {
-	var plugin Plugin
-	var err error
-	//getting the reference of LRPM - long running plugin manager - which manages all long running plugins
-	plugin.lrpm, err = manager.GetInstance()
-	return &plugin, err
+	// the long running plugin manager is only needed by the ssm daemon actions, it is looked up when they run
+	return &Plugin{}, nil
 }
 
 func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
@@ -52,12 +49,31 @@ func (p *Plugin) Execute(context context.T, config contracts.Configuration, canc
 		output.MarkAsShutdown()
 	} else if cancelFlag.Canceled() {
 		output.MarkAsCancelled()
+	} else if isServiceAction(config.Properties) {
+		configureService(log, services, config.Properties, output)
 	} else {
 		runConfigureDaemon(p, context, config.Properties, config.OrchestrationDirectory, config.DefaultWorkingDirectory, cancelFlag, output)
 	}
 	return
 }
 
+// Check reports the changes Execute would apply to the services of the operating system without applying them,
+// the ssm daemon actions can't be checked.
+func (p *Plugin) Check(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) (changes []string) {
+	log := context.Log()
+	log.Infof("%v checking configuration %v", Name(), config)
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else if isServiceAction(config.Properties) {
+		changes = checkService(log, checkServices, config.Properties, output)
+	} else {
+		output.MarkAsFailed(fmt.Errorf("the ssm daemon actions of %v don't support the report-only mode", Name()))
+	}
+	return
+}
+
 func runConfigureDaemon(
 	p *Plugin,
 	context context.T,
@@ -85,6 +101,15 @@ func runConfigureDaemon(
 		return
 	}
 
+	if p.lrpm == nil {
+		//getting the reference of LRPM - long running plugin manager - which manages all long running plugins
+		if p.lrpm, err = manager.GetInstance(); err != nil {
+			output.AppendErrorf("\nUnable to manage ssm daemon %v: %v", input.Name, err.Error())
+			output.SetStatus(contracts.ResultStatusFailed)
+			return
+		}
+	}
+
 	daemonFilePath := filepath.Join(appconfig.DaemonRoot, fmt.Sprintf("%v.json", input.Name))
 	// make sure directory for ssm daemons exists
 	if err := fileutil.MakeDirs(appconfig.DaemonRoot); err != nil {
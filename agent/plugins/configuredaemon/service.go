@@ -0,0 +1,164 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// Actions managing the services of the operating system
+	ActionInstall = "Install"
+	ActionEnable  = "Enable"
+	ActionDisable = "Disable"
+	ActionRestart = "Restart"
+)
+
+// validServiceName matches the names of systemd units and Windows services which are not mistaken for options
+var validServiceName = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.@\-]*$`)
+
+// ServicePluginInput represents an action on a service of the operating system.
+type ServicePluginInput struct {
+	contracts.PluginInput
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// UnitFileContent is the systemd unit of the service, Linux only
+	UnitFileContent string `json:"unitFileContent"`
+	// BinaryPath and Arguments are the command the service runs, units are generated from them on Linux
+	BinaryPath  string   `json:"binaryPath"`
+	Arguments   []string `json:"arguments"`
+	DisplayName string   `json:"displayName"`
+}
+
+// serviceManager applies the actions to the services of the operating system,
+// the actions return whether they changed the service
+type serviceManager interface {
+	Install(log log.T, input *ServicePluginInput) (changed bool, err error)
+	Enable(log log.T, name string) (changed bool, err error)
+	Disable(log log.T, name string) (changed bool, err error)
+	Restart(log log.T, name string) error
+}
+
+// isServiceAction returns whether the plugin input manages a service of the operating system rather than an ssm daemon
+func isServiceAction(rawPluginInput interface{}) bool {
+	var input ServicePluginInput
+	if err := jsonutil.Remarshal(rawPluginInput, &input); err != nil {
+		return false
+	}
+	switch input.Action {
+	case ActionInstall, ActionEnable, ActionDisable, ActionRestart:
+		return true
+	}
+	return false
+}
+
+// configureService applies the action to the service and reports whether the service drifted from the expected state
+func configureService(log log.T, services serviceManager, rawPluginInput interface{}, output iohandler.IOHandler) {
+	input, changed, ok := runServiceAction(log, services, rawPluginInput, output)
+	if !ok {
+		return
+	}
+
+	if changed {
+		output.AppendInfof("Service %v: %v applied, the service changed", input.Name, input.Action)
+	} else {
+		output.AppendInfof("Service %v: %v not needed, the service is up to date", input.Name, input.Action)
+	}
+	output.MarkAsSucceeded()
+}
+
+// checkService returns the change the action would apply to the service, services must not apply the actions
+func checkService(log log.T, services serviceManager, rawPluginInput interface{}, output iohandler.IOHandler) (changes []string) {
+	input, changed, ok := runServiceAction(log, services, rawPluginInput, output)
+	if !ok {
+		return nil
+	}
+
+	if changed {
+		change := fmt.Sprintf("Service %v: %v would change the service", input.Name, input.Action)
+		output.AppendInfo(change)
+		changes = append(changes, change)
+	} else {
+		output.AppendInfof("Service %v: %v not needed, the service is up to date", input.Name, input.Action)
+	}
+	output.MarkAsSucceeded()
+	return changes
+}
+
+// runServiceAction validates the plugin input and runs its action, it marks the output as failed and returns false
+// when the action could not run
+func runServiceAction(log log.T, services serviceManager, rawPluginInput interface{}, output iohandler.IOHandler) (input ServicePluginInput, changed bool, ok bool) {
+	if err := jsonutil.Remarshal(rawPluginInput, &input); err != nil {
+		output.MarkAsFailed(fmt.Errorf("invalid format in plugin properties %v; \nerror %v", rawPluginInput, err))
+		return
+	}
+	if err := validateServiceInput(&input); err != nil {
+		output.MarkAsFailed(fmt.Errorf("configureDaemon input invalid: %v", err))
+		return
+	}
+
+	var err error
+	switch input.Action {
+	case ActionInstall:
+		changed, err = services.Install(log, &input)
+	case ActionEnable:
+		changed, err = services.Enable(log, input.Name)
+	case ActionDisable:
+		changed, err = services.Disable(log, input.Name)
+	case ActionRestart:
+		changed, err = true, services.Restart(log, input.Name)
+	}
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to %v service %v: %v", strings.ToLower(input.Action), input.Name, err))
+		return input, false, false
+	}
+	return input, changed, true
+}
+
+// validateServiceInput ensures the plugin input matches the defined schema
+func validateServiceInput(input *ServicePluginInput) error {
+	if input.Name == "" {
+		return errors.New("service name is missing")
+	}
+	if !validServiceName.MatchString(input.Name) {
+		return fmt.Errorf("invalid service name %v", input.Name)
+	}
+	if input.Action != ActionInstall {
+		return nil
+	}
+	if input.UnitFileContent != "" && input.BinaryPath != "" {
+		return errors.New("only one of UnitFileContent and BinaryPath can be specified")
+	}
+	if input.UnitFileContent == "" && input.BinaryPath == "" {
+		return errors.New("UnitFileContent or BinaryPath must be specified to install a service")
+	}
+	if input.BinaryPath != "" && !filepath.IsAbs(input.BinaryPath) {
+		return fmt.Errorf("BinaryPath %v must be an absolute path", input.BinaryPath)
+	}
+	if input.UnitFileContent != "" && !unitFilesSupported {
+		return errors.New("UnitFileContent is not supported on this platform")
+	}
+	return nil
+}
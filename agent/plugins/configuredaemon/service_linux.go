@@ -0,0 +1,176 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build linux
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// unitFilesSupported is whether services can be installed from the content of a systemd unit
+const unitFilesSupported = true
+
+// systemdUnitDir is the folder of the units installed by the administrator
+const systemdUnitDir = "/etc/systemd/system"
+
+// unitTemplate is the unit of the services installed from a binary path
+const unitTemplate = `[Unit]
+Description=%v
+
+[Service]
+ExecStart=%v
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`
+
+var services serviceManager = systemdManager{unitDir: systemdUnitDir, systemctl: runSystemctl}
+
+// checkServices reports the changes of the actions without applying them
+var checkServices serviceManager = systemdManager{unitDir: systemdUnitDir, systemctl: runSystemctl, dryRun: true}
+
+// systemdManager manages the units of systemd
+type systemdManager struct {
+	unitDir string
+	// systemctl runs systemctl with the arguments and returns its output
+	systemctl func(args ...string) (string, error)
+	// dryRun reports whether the actions would change the units without changing them
+	dryRun bool
+}
+
+// Install writes the unit of the service and reloads systemd when the unit changed
+func (m systemdManager) Install(log log.T, input *ServicePluginInput) (bool, error) {
+	content := input.UnitFileContent
+	if content == "" {
+		content = generateUnit(input)
+	}
+
+	unitPath := filepath.Join(m.unitDir, unitName(input.Name))
+	existing, err := ioutil.ReadFile(unitPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err == nil && bytes.Equal(existing, []byte(content)) {
+		log.Debugf("Unit %v is unchanged", unitPath)
+		return false, nil
+	}
+	if m.dryRun {
+		return true, nil
+	}
+
+	if err = fileutil.WriteFileAtomic(unitPath, []byte(content), 0644); err != nil {
+		return false, err
+	}
+	if _, err = m.systemctl("daemon-reload"); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Enable enables the unit unless it is already enabled
+func (m systemdManager) Enable(log log.T, name string) (bool, error) {
+	state, err := m.enabledState(name)
+	if err != nil {
+		return false, err
+	}
+	if state == "enabled" {
+		return false, nil
+	}
+	if m.dryRun {
+		return true, nil
+	}
+	_, err = m.systemctl("enable", unitName(name))
+	return true, err
+}
+
+// Disable disables the unit unless it is already disabled
+func (m systemdManager) Disable(log log.T, name string) (bool, error) {
+	state, err := m.enabledState(name)
+	if err != nil {
+		return false, err
+	}
+	if state != "enabled" {
+		return false, nil
+	}
+	if m.dryRun {
+		return true, nil
+	}
+	_, err = m.systemctl("disable", unitName(name))
+	return true, err
+}
+
+// Restart restarts the unit, starting it when it is stopped
+func (m systemdManager) Restart(log log.T, name string) error {
+	if m.dryRun {
+		return nil
+	}
+	_, err := m.systemctl("restart", unitName(name))
+	return err
+}
+
+// enabledState returns the state systemctl is-enabled reports, which exits with an error for disabled units
+func (m systemdManager) enabledState(name string) (string, error) {
+	output, err := m.systemctl("is-enabled", unitName(name))
+	state := strings.TrimSpace(output)
+	if state == "" && err != nil {
+		return "", err
+	}
+	return state, nil
+}
+
+// unitName returns the name of the unit file of the service
+func unitName(name string) string {
+	if strings.HasSuffix(name, ".service") {
+		return name
+	}
+	return name + ".service"
+}
+
+// generateUnit returns a unit running the binary of the service
+func generateUnit(input *ServicePluginInput) string {
+	description := input.DisplayName
+	if description == "" {
+		description = input.Name
+	}
+	command := []string{strconv.Quote(input.BinaryPath)}
+	for _, argument := range input.Arguments {
+		command = append(command, strconv.Quote(argument))
+	}
+	// % starts the specifiers of systemd
+	execStart := strings.Replace(strings.Join(command, " "), "%", "%%", -1)
+	return fmt.Sprintf(unitTemplate, description, execStart)
+}
+
+// runSystemctl runs systemctl, the output is part of the returned error
+func runSystemctl(args ...string) (string, error) {
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("systemctl %v failed: %v %v", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
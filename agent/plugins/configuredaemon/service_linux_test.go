@@ -0,0 +1,94 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build linux
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSystemdManager returns a manager of the units in a temporary folder, systemctl calls are recorded
+func newSystemdManager(enabledState string) (systemdManager, *[]string) {
+	unitDir, _ := ioutil.TempDir("", "systemd")
+	calls := []string{}
+	return systemdManager{
+		unitDir: unitDir,
+		systemctl: func(args ...string) (string, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[0] == "is-enabled" {
+				return enabledState + "\n", nil
+			}
+			return "", nil
+		},
+	}, &calls
+}
+
+func TestSystemdInstall_OnlyWhenUnitChanged(t *testing.T) {
+	manager, calls := newSystemdManager("")
+	defer os.RemoveAll(manager.unitDir)
+	input := &ServicePluginInput{Name: "app", BinaryPath: "/opt/app/bin/app", Arguments: []string{"--port", "80%"}}
+
+	changed, err := manager.Install(logger, input)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	content, _ := ioutil.ReadFile(filepath.Join(manager.unitDir, "app.service"))
+	assert.Contains(t, string(content), "ExecStart=\"/opt/app/bin/app\" \"--port\" \"80%%\"\n")
+
+	changed, err = manager.Install(logger, input)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, []string{"daemon-reload"}, *calls)
+}
+
+func TestSystemdEnable_DetectsDrift(t *testing.T) {
+	manager, calls := newSystemdManager("enabled")
+	defer os.RemoveAll(manager.unitDir)
+
+	changed, err := manager.Enable(logger, "app")
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = manager.Disable(logger, "app.service")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"is-enabled app.service", "is-enabled app.service", "disable app.service"}, *calls)
+}
+
+func TestSystemdDryRun_ChangesNothing(t *testing.T) {
+	manager, calls := newSystemdManager("disabled")
+	defer os.RemoveAll(manager.unitDir)
+	manager.dryRun = true
+
+	changed, err := manager.Install(logger, &ServicePluginInput{Name: "app", BinaryPath: "/opt/app/bin/app"})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	_, err = os.Stat(filepath.Join(manager.unitDir, "app.service"))
+	assert.True(t, os.IsNotExist(err))
+
+	changed, err = manager.Enable(logger, "app")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	assert.NoError(t, manager.Restart(logger, "app"))
+	assert.Equal(t, []string{"is-enabled app.service"}, *calls)
+}
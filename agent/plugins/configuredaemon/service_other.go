@@ -0,0 +1,53 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build darwin freebsd netbsd openbsd
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"errors"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// unitFilesSupported is whether services can be installed from the content of a systemd unit
+const unitFilesSupported = false
+
+var errServicesNotSupported = errors.New("services are not supported on this platform")
+
+var services serviceManager = unsupportedServiceManager{}
+
+// checkServices reports the changes of the actions without applying them
+var checkServices serviceManager = unsupportedServiceManager{}
+
+// unsupportedServiceManager fails the actions on platforms without a supported service manager
+type unsupportedServiceManager struct{}
+
+func (unsupportedServiceManager) Install(log log.T, input *ServicePluginInput) (bool, error) {
+	return false, errServicesNotSupported
+}
+
+func (unsupportedServiceManager) Enable(log log.T, name string) (bool, error) {
+	return false, errServicesNotSupported
+}
+
+func (unsupportedServiceManager) Disable(log log.T, name string) (bool, error) {
+	return false, errServicesNotSupported
+}
+
+func (unsupportedServiceManager) Restart(log log.T, name string) error {
+	return errServicesNotSupported
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"errors"
+	"testing"
+
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logger = log.NewMockLog()
+
+// serviceManagerStub records the actions and returns the configured result
+type serviceManagerStub struct {
+	changed bool
+	err     error
+	actions []string
+}
+
+func (s *serviceManagerStub) Install(log log.T, input *ServicePluginInput) (bool, error) {
+	s.actions = append(s.actions, ActionInstall+" "+input.Name)
+	return s.changed, s.err
+}
+
+func (s *serviceManagerStub) Enable(log log.T, name string) (bool, error) {
+	s.actions = append(s.actions, ActionEnable+" "+name)
+	return s.changed, s.err
+}
+
+func (s *serviceManagerStub) Disable(log log.T, name string) (bool, error) {
+	s.actions = append(s.actions, ActionDisable+" "+name)
+	return s.changed, s.err
+}
+
+func (s *serviceManagerStub) Restart(log log.T, name string) error {
+	s.actions = append(s.actions, ActionRestart+" "+name)
+	return s.err
+}
+
+func TestIsServiceAction(t *testing.T) {
+	assert.True(t, isServiceAction(map[string]interface{}{"name": "nginx", "action": "Enable"}))
+	assert.False(t, isServiceAction(map[string]interface{}{"name": "daemon", "action": "Start"}))
+	assert.False(t, isServiceAction("invalid"))
+}
+
+func TestConfigureService_ReportsDrift(t *testing.T) {
+	for _, changed := range []bool{true, false} {
+		services := &serviceManagerStub{changed: changed}
+		output := new(iohandlermocks.MockIOHandler)
+		var message string
+		output.On("AppendInfof", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			message = args.String(0)
+		}).Return()
+		output.On("MarkAsSucceeded").Return()
+
+		configureService(logger, services, map[string]interface{}{"name": "nginx", "action": "Enable"}, output)
+
+		output.AssertExpectations(t)
+		assert.Equal(t, []string{"Enable nginx"}, services.actions)
+		if changed {
+			assert.Contains(t, message, "the service changed")
+		} else {
+			assert.Contains(t, message, "up to date")
+		}
+	}
+}
+
+func TestConfigureService_Failure(t *testing.T) {
+	services := &serviceManagerStub{err: errors.New("unit not found")}
+	output := new(iohandlermocks.MockIOHandler)
+	output.On("MarkAsFailed", mock.Anything).Return()
+
+	configureService(logger, services, map[string]interface{}{"name": "nginx", "action": "Restart"}, output)
+
+	output.AssertExpectations(t)
+	assert.Equal(t, []string{"Restart nginx"}, services.actions)
+}
+
+func TestCheckService_ReportsChanges(t *testing.T) {
+	for _, changed := range []bool{true, false} {
+		services := &serviceManagerStub{changed: changed}
+		output := new(iohandlermocks.MockIOHandler)
+		output.On("AppendInfo", mock.Anything).Return()
+		output.On("AppendInfof", mock.Anything, mock.Anything).Return()
+		output.On("MarkAsSucceeded").Return()
+
+		changes := checkService(logger, services, map[string]interface{}{"name": "nginx", "action": "Disable"}, output)
+
+		assert.Equal(t, []string{"Disable nginx"}, services.actions)
+		if changed {
+			assert.Equal(t, []string{"Service nginx: Disable would change the service"}, changes)
+		} else {
+			assert.Empty(t, changes)
+		}
+	}
+}
+
+func TestCheckService_InvalidInput(t *testing.T) {
+	services := &serviceManagerStub{changed: true}
+	output := new(iohandlermocks.MockIOHandler)
+	output.On("MarkAsFailed", mock.Anything).Return()
+
+	changes := checkService(logger, services, map[string]interface{}{"action": "Enable"}, output)
+
+	output.AssertExpectations(t)
+	assert.Empty(t, changes)
+	assert.Empty(t, services.actions)
+}
+
+func TestValidateServiceInput(t *testing.T) {
+	assert.NoError(t, validateServiceInput(&ServicePluginInput{Name: "getty@tty1.service", Action: ActionRestart}))
+	assert.Error(t, validateServiceInput(&ServicePluginInput{Action: ActionRestart}))
+	assert.Error(t, validateServiceInput(&ServicePluginInput{Name: "--all", Action: ActionEnable}))
+	assert.Error(t, validateServiceInput(&ServicePluginInput{Name: "app", Action: ActionInstall}))
+	assert.Error(t, validateServiceInput(&ServicePluginInput{Name: "app", Action: ActionInstall, BinaryPath: "app"}))
+	assert.Error(t, validateServiceInput(&ServicePluginInput{Name: "app", Action: ActionInstall, BinaryPath: "/app", UnitFileContent: "[Unit]"}))
+}
@@ -0,0 +1,187 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build windows
+
+// Package configuredaemon implements the ConfigureDaemon plugin.
+package configuredaemon
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// unitFilesSupported is whether services can be installed from the content of a systemd unit
+const unitFilesSupported = false
+
+const (
+	// errorServiceDoesNotExist is ERROR_SERVICE_DOES_NOT_EXIST
+	errorServiceDoesNotExist = syscall.Errno(1060)
+
+	stopTimeout      = 60 * time.Second
+	stopPollInterval = 500 * time.Millisecond
+)
+
+var services serviceManager = windowsServiceManager{}
+
+// checkServices reports the changes of the actions without applying them
+var checkServices serviceManager = windowsServiceManager{dryRun: true}
+
+// windowsServiceManager manages the services of the Windows service control manager
+type windowsServiceManager struct {
+	// dryRun reports whether the actions would change the services without changing them
+	dryRun bool
+}
+
+// Install creates the service, or updates its command and display name when they changed
+func (m windowsServiceManager) Install(log log.T, input *ServicePluginInput) (bool, error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return false, err
+	}
+	defer manager.Disconnect()
+
+	displayName := input.DisplayName
+	if displayName == "" {
+		displayName = input.Name
+	}
+
+	service, err := manager.OpenService(input.Name)
+	if err == errorServiceDoesNotExist && m.dryRun {
+		return true, nil
+	}
+	if err == errorServiceDoesNotExist {
+		log.Infof("Creating service %v", input.Name)
+		if service, err = manager.CreateService(input.Name, input.BinaryPath, mgr.Config{DisplayName: displayName}, input.Arguments...); err != nil {
+			return false, err
+		}
+		service.Close()
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer service.Close()
+
+	config, err := service.Config()
+	if err != nil {
+		return false, err
+	}
+	binaryPathName := commandLine(input)
+	if strings.EqualFold(config.BinaryPathName, binaryPathName) && config.DisplayName == displayName {
+		return false, nil
+	}
+	if m.dryRun {
+		return true, nil
+	}
+	config.BinaryPathName = binaryPathName
+	config.DisplayName = displayName
+	return true, service.UpdateConfig(config)
+}
+
+// Enable sets the service to start automatically unless it already does
+func (m windowsServiceManager) Enable(log log.T, name string) (bool, error) {
+	return setStartType(name, mgr.StartAutomatic, m.dryRun)
+}
+
+// Disable sets the service to be disabled unless it already is, the running service is not stopped
+func (m windowsServiceManager) Disable(log log.T, name string) (bool, error) {
+	return setStartType(name, mgr.StartDisabled, m.dryRun)
+}
+
+// Restart stops the service when it runs and starts it
+func (m windowsServiceManager) Restart(log log.T, name string) error {
+	if m.dryRun {
+		return nil
+	}
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	status, err := service.Query()
+	if err != nil {
+		return err
+	}
+	if status.State != svc.Stopped {
+		log.Infof("Stopping service %v", name)
+		if status.State != svc.StopPending {
+			if status, err = service.Control(svc.Stop); err != nil {
+				return err
+			}
+		}
+		deadline := time.Now().Add(stopTimeout)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service did not stop within %v", stopTimeout)
+			}
+			time.Sleep(stopPollInterval)
+			if status, err = service.Query(); err != nil {
+				return err
+			}
+		}
+	}
+	log.Infof("Starting service %v", name)
+	return service.Start()
+}
+
+// setStartType sets the start type of the service unless dryRun and returns whether it changed
+func setStartType(name string, startType uint32, dryRun bool) (bool, error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return false, err
+	}
+	defer manager.Disconnect()
+
+	service, err := manager.OpenService(name)
+	if err != nil {
+		return false, err
+	}
+	defer service.Close()
+
+	config, err := service.Config()
+	if err != nil {
+		return false, err
+	}
+	if config.StartType == startType {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	config.StartType = startType
+	return true, service.UpdateConfig(config)
+}
+
+// commandLine returns the command line of the service as the service control manager stores it
+func commandLine(input *ServicePluginInput) string {
+	command := syscall.EscapeArg(input.BinaryPath)
+	for _, argument := range input.Arguments {
+		command += " " + syscall.EscapeArg(argument)
+	}
+	return command
+}
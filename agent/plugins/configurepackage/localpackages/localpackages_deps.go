@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 )
 
@@ -60,5 +61,5 @@ func (fileSysDepImp) ReadFile(filename string) ([]byte, error) {
 }
 
 func (fileSysDepImp) WriteFile(filename string, content string) error {
-	return fileutil.WriteAllText(filename, content)
+	return fileutil.WriteFileAtomic(filename, []byte(content), appconfig.ReadWriteAccess)
 }
@@ -0,0 +1,256 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package copyfile implements the aws:copyFile plugin
+package copyfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	downloadsDir = "downloads" //Directory under the orchestration directory where aws:downloadContent saves relative destinations
+
+	defaultFileMode = 0644
+)
+
+// templateParameterName matches the names accepted for document parameters
+var templateParameterName = regexp.MustCompile("^[a-zA-Z0-9]+$")
+
+// Plugin is the type for the aws:copyFile plugin.
+type Plugin struct{}
+
+// CopyFilePluginInput represents one file of the aws:copyFile plugin
+type CopyFilePluginInput struct {
+	contracts.PluginInput
+	// DestinationPath is the absolute path of the file written
+	DestinationPath string `json:"destinationPath"`
+	// Content is the inline content of the file
+	Content string `json:"content"`
+	// SourcePath is the file copied instead of the inline content, relative paths are in the folder
+	// aws:downloadContent downloads relative destinations to
+	SourcePath string `json:"sourcePath"`
+	// TemplateParameters replace the {{ name }} placeholders of the content, usually set from the document parameters
+	TemplateParameters map[string]string `json:"templateParameters"`
+	// Owner and Group are the names or ids of the user and group owning the file, they are kept when empty
+	Owner string `json:"owner"`
+	Group string `json:"group"`
+	// Mode is the octal permissions of the file, such as 0644
+	Mode string `json:"mode"`
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin() (*Plugin, error) {
+	return &Plugin{}, nil
+}
+
+// Name returns the plugin name
+func Name() string {
+	return appconfig.PluginCopyFile
+}
+
+// Execute writes the file described by the plugin properties, unless it already has the expected content.
+func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	log.Infof("%v started", Name())
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else if input, err := parseAndValidateInput(config.Properties); err != nil {
+		output.MarkAsFailed(err)
+	} else {
+		p.copyFile(log, input, config, output)
+	}
+}
+
+// copyFile renders the content of the file, writes it when it changed and applies the ownership and permissions
+func (p *Plugin) copyFile(log log.T, input *CopyFilePluginInput, config contracts.Configuration, output iohandler.IOHandler) {
+	content, err := readContent(input, config)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	content = render(log, content, input.TemplateParameters)
+
+	destination := input.DestinationPath
+	existing, err := os.Stat(destination)
+	if err != nil && !os.IsNotExist(err) {
+		output.MarkAsFailed(fmt.Errorf("failed to read %v, %v", destination, err))
+		return
+	}
+	if existing != nil && existing.IsDir() {
+		output.MarkAsFailed(fmt.Errorf("%v is a directory", destination))
+		return
+	}
+
+	mode := os.FileMode(defaultFileMode)
+	if input.Mode != "" {
+		// the mode is validated with the input
+		parsed, _ := strconv.ParseUint(input.Mode, 8, 32)
+		mode = os.FileMode(parsed)
+	} else if existing != nil {
+		mode = existing.Mode().Perm()
+	}
+
+	unchanged := false
+	if existing != nil {
+		if unchanged, err = hasContent(destination, content); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to read %v, %v", destination, err))
+			return
+		}
+	}
+
+	if unchanged {
+		log.Infof("%v is unchanged, skipping the copy", destination)
+		if existing.Mode().Perm() != mode {
+			if err = os.Chmod(destination, mode); err != nil {
+				output.MarkAsFailed(fmt.Errorf("failed to set the mode of %v, %v", destination, err))
+				return
+			}
+		}
+	} else {
+		if err = fileutil.MakeDirs(filepath.Dir(destination)); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to create the folder of %v, %v", destination, err))
+			return
+		}
+		if err = fileutil.WriteFileAtomic(destination, content, mode); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	}
+
+	if err = setOwnership(destination, input.Owner, input.Group); err != nil {
+		output.MarkAsFailed(fmt.Errorf("failed to set the owner of %v, %v", destination, err))
+		return
+	}
+
+	if unchanged {
+		output.AppendInfof("%v is up to date", destination)
+	} else {
+		output.AppendInfof("Content copied to %v", destination)
+	}
+	output.MarkAsSucceeded()
+}
+
+// readContent returns the inline content or the content of the source file
+func readContent(input *CopyFilePluginInput, config contracts.Configuration) ([]byte, error) {
+	if input.SourcePath == "" {
+		return []byte(input.Content), nil
+	}
+
+	sourcePath := input.SourcePath
+	if !filepath.IsAbs(sourcePath) {
+		orchestrationDir := strings.TrimSuffix(config.OrchestrationDirectory, config.PluginID)
+		sourcePath = filepath.Join(orchestrationDir, downloadsDir, sourcePath)
+	}
+	content, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the source file %v, %v", sourcePath, err)
+	}
+	return content, nil
+}
+
+// render replaces the {{ name }} placeholders of the content by the template parameters
+func render(log log.T, content []byte, templateParameters map[string]string) []byte {
+	if len(templateParameters) == 0 {
+		return content
+	}
+
+	names := make([]string, 0, len(templateParameters))
+	for name := range templateParameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	text := string(content)
+	for _, name := range names {
+		// values are literal, file content such as scripts often contains $
+		placeholder := regexp.MustCompile(fmt.Sprintf(`{{\s*%v\s*}}`, name))
+		text = placeholder.ReplaceAllLiteralString(text, templateParameters[name])
+	}
+	log.Debugf("Rendered %v template parameters", len(names))
+	return []byte(text)
+}
+
+// hasContent returns whether the file has the content, comparing their hashes
+func hasContent(path string, content []byte) (bool, error) {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	existingHash := sha256.Sum256(existing)
+	contentHash := sha256.Sum256(content)
+	return bytes.Equal(existingHash[:], contentHash[:]), nil
+}
+
+// parseAndValidateInput parses the plugin properties and validates them
+func parseAndValidateInput(rawPluginInput interface{}) (*CopyFilePluginInput, error) {
+	var input CopyFilePluginInput
+	if err := jsonutil.Remarshal(rawPluginInput, &input); err != nil {
+		return nil, fmt.Errorf("invalid format in plugin properties %v; \nerror %v", rawPluginInput, err)
+	}
+
+	if err := validateInput(&input); err != nil {
+		return nil, fmt.Errorf("invalid input: %v", err)
+	}
+	return &input, nil
+}
+
+// validateInput ensures the plugin input matches the defined schema
+func validateInput(input *CopyFilePluginInput) error {
+	if input.DestinationPath == "" {
+		return errors.New("DestinationPath must be specified")
+	}
+	if !filepath.IsAbs(input.DestinationPath) {
+		return fmt.Errorf("DestinationPath %v must be an absolute path", input.DestinationPath)
+	}
+	if input.Content != "" && input.SourcePath != "" {
+		return errors.New("only one of Content and SourcePath can be specified")
+	}
+	if (input.Owner != "" || input.Group != "") && !ownershipSupported {
+		return errors.New("Owner and Group are not supported on this platform")
+	}
+	if input.Mode != "" {
+		if mode, err := strconv.ParseUint(input.Mode, 8, 32); err != nil || mode > 0777 {
+			return fmt.Errorf("Mode %v must be octal permissions such as 0644", input.Mode)
+		}
+	}
+	for name := range input.TemplateParameters {
+		if !templateParameterName.MatchString(name) {
+			return fmt.Errorf("invalid template parameter name %v", name)
+		}
+	}
+	return nil
+}
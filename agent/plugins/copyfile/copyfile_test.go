@@ -0,0 +1,132 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package copyfile implements the aws:copyFile plugin
+package copyfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logger = log.NewMockLog()
+
+// executeCopyFile runs the plugin with the properties and returns the message appended to the output
+func executeCopyFile(t *testing.T, properties map[string]interface{}, config contracts.Configuration) (message string) {
+	cancelFlag := task.NewMockDefault()
+	cancelFlag.On("ShutDown").Return(false)
+	cancelFlag.On("Canceled").Return(false)
+	output := new(iohandlermocks.MockIOHandler)
+	output.On("AppendInfof", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		message = args.String(0)
+	}).Return()
+	output.On("MarkAsSucceeded").Return()
+	config.Properties = properties
+
+	p, _ := NewPlugin()
+	p.Execute(context.NewMockDefault(), config, cancelFlag, output)
+
+	output.AssertExpectations(t)
+	return
+}
+
+func TestCopyFile_InlineContentWithTemplate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "copyfile")
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "conf", "app.conf")
+
+	message := executeCopyFile(t, map[string]interface{}{
+		"destinationPath":    destination,
+		"content":            "port={{ port }}\nhome=$HOME\n",
+		"templateParameters": map[string]interface{}{"port": "8080"},
+		"mode":               "0640",
+	}, contracts.Configuration{})
+
+	content, err := ioutil.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "port=8080\nhome=$HOME\n", string(content))
+	assert.Equal(t, "Content copied to %v", message)
+	if ownershipSupported {
+		info, _ := os.Stat(destination)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	}
+}
+
+func TestCopyFile_SkipsUnchangedFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "copyfile")
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "app.conf")
+	ioutil.WriteFile(destination, []byte("content"), 0600)
+	modified := time.Now().Add(-time.Hour)
+	os.Chtimes(destination, modified, modified)
+
+	message := executeCopyFile(t, map[string]interface{}{
+		"destinationPath": destination,
+		"content":         "content",
+	}, contracts.Configuration{})
+
+	info, _ := os.Stat(destination)
+	assert.Equal(t, "%v is up to date", message)
+	assert.True(t, info.ModTime().Equal(modified))
+}
+
+func TestCopyFile_DownloadedSource(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "copyfile")
+	defer os.RemoveAll(dir)
+	downloads := filepath.Join(dir, "commandID", downloadsDir)
+	os.MkdirAll(downloads, 0700)
+	ioutil.WriteFile(filepath.Join(downloads, "app.conf"), []byte("downloaded"), 0600)
+	destination := filepath.Join(dir, "app.conf")
+
+	executeCopyFile(t, map[string]interface{}{
+		"destinationPath": destination,
+		"sourcePath":      "app.conf",
+	}, contracts.Configuration{
+		OrchestrationDirectory: filepath.Join(dir, "commandID", "copyStep"),
+		PluginID:               "copyStep",
+	})
+
+	content, err := ioutil.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "downloaded", string(content))
+}
+
+func TestValidateInput(t *testing.T) {
+	absolutePath, _ := filepath.Abs("app.conf")
+
+	assert.NoError(t, validateInput(&CopyFilePluginInput{DestinationPath: absolutePath, Mode: "755"}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{DestinationPath: "app.conf"}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{DestinationPath: absolutePath, Content: "a", SourcePath: "b"}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{DestinationPath: absolutePath, Mode: "0999"}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{DestinationPath: absolutePath, Mode: "4755"}))
+	assert.Error(t, validateInput(&CopyFilePluginInput{DestinationPath: absolutePath, TemplateParameters: map[string]string{"a b": ""}}))
+}
+
+func TestRender(t *testing.T) {
+	rendered := render(logger, []byte("{{name}} and {{ name }} cost ${{price}}, {{ other }}"), map[string]string{"name": "$1", "price": "5"})
+
+	assert.Equal(t, "$1 and $1 cost $5, {{ other }}", string(rendered))
+}
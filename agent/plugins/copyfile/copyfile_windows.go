@@ -0,0 +1,26 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+// Package copyfile implements the aws:copyFile plugin
+package copyfile
+
+// ownershipSupported is false, files are owned through their ACL on Windows
+const ownershipSupported = false
+
+// setOwnership does nothing, Owner and Group are rejected with the input
+func setOwnership(path string, owner string, group string) error {
+	return nil
+}
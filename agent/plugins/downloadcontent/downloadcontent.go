@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitrepositoryresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/gitresource/privategithub"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
@@ -41,6 +42,7 @@ import (
 
 const (
 	GitHub      = "GitHub"      //Github represents the source type "GitHub" from where the resource can be downloaded
+	Git         = "Git"         //Git represents the source type "Git", a git repository cloned over https or ssh
 	S3          = "S3"          //S3 represents the source type "S3" from where the resource is being downloaded
 	SSMDocument = "SSMDocument" //SSMDocument represents the source type as SSM Document
 
@@ -71,6 +73,8 @@ type DownloadContentPlugin struct {
 	SourceType      string `json:"sourceType"`
 	SourceInfo      string `json:"sourceInfo"`
 	DestinationPath string `json:"destinationPath"`
+	// ExtractArchive extracts the downloaded zip, tar.gz, tar.zst and 7z archives into their folder
+	ExtractArchive bool `json:"extractArchive"`
 	// TODO: 08/25/2017 meloniam@ Change the type of SourceInfo and documentParameters to map[string]interface{}
 	// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 }
@@ -83,6 +87,8 @@ func newRemoteResource(log log.T, SourceType string, SourceInfo string) (resourc
 		// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 		token := privategithub.NewTokenInfoImpl()
 		return gitresource.NewGitResource(log, SourceInfo, token)
+	case Git:
+		return gitrepositoryresource.NewGitRepositoryResource(SourceInfo)
 	case S3:
 		return s3resource.NewS3Resource(log, SourceInfo)
 	case SSMDocument:
@@ -154,6 +160,14 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 		return
 	}
 
+	if input.ExtractArchive {
+		log.Debug("Extracting downloaded archives")
+		if result.Files, err = extractArchives(log, result.Files); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	}
+
 	if err := setPermissions(log, result); err != nil {
 		output.MarkAsFailed(fmt.Errorf("Failed to set right permissions to the content. Error - %v", err))
 		return
@@ -164,6 +178,57 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 	return
 }
 
+// extractArchives replaces the downloaded archives by the files extracted from them into their folder,
+// files which aren't archives are kept
+func extractArchives(log log.T, files []string) (extracted []string, err error) {
+	added := make(map[string]bool)
+	add := func(path string) {
+		if !added[path] {
+			added[path] = true
+			extracted = append(extracted, path)
+		}
+	}
+
+	for _, path := range files {
+		var format fileutil.ArchiveFormat
+		if format, err = fileutil.DetectArchiveFormat(path); err != nil {
+			return nil, fmt.Errorf("failed to read %v, %v", path, err)
+		}
+		if format == fileutil.ArchiveUnknown {
+			add(path)
+			continue
+		}
+
+		dir := filepath.Dir(path)
+		log.Infof("Extracting %v archive %v to %v", format, path, dir)
+		if err = fileutil.ExtractArchive(path, dir); err != nil {
+			return nil, fmt.Errorf("failed to extract %v, %v", path, err)
+		}
+		if err = fileutil.DeleteFile(path); err != nil {
+			return nil, fmt.Errorf("failed to delete archive %v, %v", path, err)
+		}
+
+		err = filepath.Walk(dir, func(p string, info os.FileInfo, walkErr error) error {
+			if walkErr == nil && info.Mode().IsRegular() {
+				add(p)
+			}
+			return walkErr
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the folders walked may have listed archives extracted and deleted afterwards
+	files, extracted = extracted, nil
+	for _, path := range files {
+		if fileutil.Exists(path) {
+			extracted = append(extracted, path)
+		}
+	}
+	return
+}
+
 func setPermissions(log log.T, result *remoteresource.DownloadResult) error {
 	for _, path := range result.Files {
 		log.Infof("Setting permission for file %v", path)
@@ -205,7 +270,7 @@ func validateInput(input *DownloadContentPlugin) (valid bool, err error) {
 		return false, errors.New("SourceType must be specified")
 	}
 	//ensure all entries are valid
-	if input.SourceType != GitHub && input.SourceType != S3 && input.SourceType != SSMDocument {
+	if input.SourceType != GitHub && input.SourceType != Git && input.SourceType != S3 && input.SourceType != SSMDocument {
 		return false, errors.New("Unsupported source type")
 	}
 	// ensure non-empty source info
@@ -16,6 +16,11 @@
 package downloadcontent
 
 import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"time"
@@ -75,6 +80,19 @@ func TestNewRemoteResource_S3(t *testing.T) {
 
 }
 
+func TestNewRemoteResource_Git(t *testing.T) {
+
+	locationInfo := `{
+		"repository" : "https://github.com/aws/amazon-ssm-agent.git",
+		"tag" : "2.3.0.0"
+		}`
+	remoteresource, err := newRemoteResource(logger, "Git", locationInfo)
+
+	assert.NotNil(t, remoteresource)
+	assert.NoError(t, err)
+
+}
+
 func TestNewRemoteResource_SSMDocument(t *testing.T) {
 
 	locationInfo := `{
@@ -351,6 +369,28 @@ func TestPlugin_ExecuteSSMDocError(t *testing.T) {
 	mockIOHandler.AssertExpectations(t)
 }
 
+func TestExtractArchives(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "downloadcontent")
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("scripts/install.sh")
+	w.Write([]byte("install"))
+	zw.Close()
+	archive := filepath.Join(dir, "package.zip")
+	ioutil.WriteFile(archive, buf.Bytes(), 0600)
+	script := filepath.Join(dir, "run.sh")
+	ioutil.WriteFile(script, []byte("run"), 0600)
+
+	files, err := extractArchives(logger, []string{archive, script})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "run.sh"), filepath.Join(dir, "scripts", "install.sh")}, files)
+	_, err = os.Stat(archive)
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestValidateInput_UnsupportedLocationType(t *testing.T) {
 
 	input := DownloadContentPlugin{}
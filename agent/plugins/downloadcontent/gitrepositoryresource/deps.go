@@ -0,0 +1,61 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package gitrepositoryresource
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssmparameterresolver"
+)
+
+// dependencies on the git command line and on Parameter Store to get the credentials
+type gitdeps interface {
+	ResolveSecureParameter(log log.T, parameterReference string) (string, error)
+	Git(log log.T, dir string, env []string, args ...string) error
+}
+
+type gitDepImpl struct{}
+
+// ResolveSecureParameter returns the value of the ssm-secure: reference, the value is never logged
+func (g gitDepImpl) ResolveSecureParameter(log log.T, parameterReference string) (string, error) {
+	paramService := ssmparameterresolver.NewService()
+	values, err := ssmparameterresolver.ResolveParameterReferenceList(&paramService, log, []string{parameterReference},
+		ssmparameterresolver.ResolveOptions{IgnoreSecureParameters: false})
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[parameterReference]
+	if !ok {
+		return "", fmt.Errorf("parameter %v could not be resolved", parameterReference)
+	}
+	return value.Value, nil
+}
+
+// Git runs git with the arguments in dir, the output of git is part of the returned error
+func (g gitDepImpl) Git(log log.T, dir string, env []string, args ...string) error {
+	log.Debugf("Running git %v", strings.Join(args, " "))
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	// never prompt for credentials, the agent has no terminal
+	command.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	if output, err := command.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %v failed: %v\n%v", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
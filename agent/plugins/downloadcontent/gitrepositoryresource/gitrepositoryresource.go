@@ -0,0 +1,351 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package gitrepositoryresource implements the methods to clone resources from git repositories
+package gitrepositoryresource
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+)
+
+const (
+	ssmSecurePrefix      = "ssm-secure:"
+	secretsManagerPrefix = "secretsmanager:"
+
+	// secretsManagerReferencePath is the Parameter Store path referencing Secrets Manager secrets
+	secretsManagerReferencePath = "/aws/reference/secretsmanager/"
+)
+
+// Credentials are references to Parameter Store parameters, {{ ssm-secure:name }}, or to Secrets Manager secrets,
+// {{ secretsmanager:secret-id }}, so that they are never part of the documents
+var credentialReferencePattern = regexp.MustCompile(`^{{\s*(` + ssmSecurePrefix + `|` + secretsManagerPrefix + `)([\w-/+=.@]+)\s*}}$`)
+
+// scpLikeURLPattern matches the user@host:path repositories of the ssh transport
+var scpLikeURLPattern = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^/]`)
+
+var commitIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// GitRepositoryResource is a struct for the remote resource of type Git
+type GitRepositoryResource struct {
+	Info    GitRepositoryInfo
+	gitdeps gitdeps
+}
+
+// GitRepositoryInfo represents the sourceInfo type sent by runcommand
+type GitRepositoryInfo struct {
+	// Repository is the https or ssh url of the repository
+	Repository string `json:"repository"`
+	// Branch, Tag or CommitID pin the version cloned, the default branch is cloned when none is given
+	Branch   string `json:"branch"`
+	Tag      string `json:"tag"`
+	CommitID string `json:"commitID"`
+	// Shallow clones the pinned version only, without the history
+	Shallow bool `json:"shallow"`
+	// Submodules clones the submodules of the repository recursively
+	Submodules bool `json:"submodules"`
+	// Username and Password authenticate https repositories, Password is a credential reference
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// PrivateSSHKey is a credential reference to the deploy key authenticating ssh repositories
+	PrivateSSHKey string `json:"privateSSHKey"`
+	// KnownHosts are the host keys trusted for ssh repositories, the host keys known to the instance are used otherwise
+	KnownHosts string `json:"knownHosts"`
+}
+
+// NewGitRepositoryResource is a constructor of type GitRepositoryResource
+func NewGitRepositoryResource(info string) (*GitRepositoryResource, error) {
+	gitInfo, err := parseSourceInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitRepositoryResource{
+		Info:    gitInfo,
+		gitdeps: gitDepImpl{},
+	}, nil
+}
+
+// parseSourceInfo unmarshals the information in sourceInfo of type GitRepositoryInfo and returns it
+func parseSourceInfo(sourceInfo string) (gitInfo GitRepositoryInfo, err error) {
+	if err = jsonutil.Unmarshal(sourceInfo, &gitInfo); err != nil {
+		return gitInfo, fmt.Errorf("SourceInfo could not be unmarshalled for SourceType Git. Please check JSON format of SourceInfo - %v", err)
+	}
+	return gitInfo, nil
+}
+
+// ValidateLocationInfo ensures that the required parameters of SourceInfo are specified
+func (git *GitRepositoryResource) ValidateLocationInfo() (valid bool, err error) {
+	info := git.Info
+	if info.Repository == "" {
+		return false, errors.New("Repository for Git SourceType must be specified")
+	}
+	isSSH := isSSHRepository(info.Repository)
+	if !isSSH && !strings.HasPrefix(info.Repository, "https://") {
+		return false, errors.New("Repository for Git SourceType must be an https or ssh url")
+	}
+
+	pinned := 0
+	for _, version := range []string{info.Branch, info.Tag, info.CommitID} {
+		if version != "" {
+			pinned++
+		}
+		if strings.HasPrefix(version, "-") {
+			return false, fmt.Errorf("invalid version %v", version)
+		}
+	}
+	if pinned > 1 {
+		return false, errors.New("only one of Branch, Tag and CommitID can be specified")
+	}
+	if info.CommitID != "" && !commitIDPattern.MatchString(info.CommitID) {
+		return false, fmt.Errorf("CommitID %v must be a commit hash", info.CommitID)
+	}
+
+	if isSSH && (info.Username != "" || info.Password != "") {
+		return false, errors.New("Username and Password can only be specified for https repositories")
+	}
+	if !isSSH && (info.PrivateSSHKey != "" || info.KnownHosts != "") {
+		return false, errors.New("PrivateSSHKey and KnownHosts can only be specified for ssh repositories")
+	}
+	for _, credential := range []string{info.Password, info.PrivateSSHKey} {
+		if credential != "" && !credentialReferencePattern.MatchString(credential) {
+			return false, errors.New("Credentials must be specified as '{{ ssm-secure:parameter-name }}' or '{{ secretsmanager:secret-id }}'")
+		}
+	}
+	return true, nil
+}
+
+// DownloadRemoteResource clones the repository into destinationPath, which must be empty
+func (git *GitRepositoryResource) DownloadRemoteResource(log log.T, filesys filemanager.FileSystem, destinationPath string) (err error, result *remoteresource.DownloadResult) {
+	if destinationPath == "" {
+		destinationPath = appconfig.DownloadRoot
+	}
+	destinationPath = filepath.Clean(destinationPath)
+	existed := fileutil.Exists(destinationPath)
+	if empty, err := isEmptyOrMissing(destinationPath); err != nil {
+		return err, nil
+	} else if !empty {
+		return fmt.Errorf("destination %v must be an empty directory to clone %v", destinationPath, git.Info.Repository), nil
+	}
+
+	// credentials are written to a folder only the agent can read, and removed once cloned
+	credentialsDir, err := ioutil.TempDir("", "gitcredentials")
+	if err != nil {
+		return err, nil
+	}
+	defer os.RemoveAll(credentialsDir)
+
+	options, env, err := git.credentials(log, credentialsDir)
+	if err != nil {
+		return err, nil
+	}
+
+	if err = fileutil.MakeDirs(filepath.Dir(destinationPath)); err != nil {
+		return err, nil
+	}
+	log.Infof("Cloning %v to %v", git.Info.Repository, destinationPath)
+	if err = git.clone(log, destinationPath, options, env); err != nil {
+		if !existed {
+			os.RemoveAll(destinationPath)
+		}
+		return err, nil
+	}
+
+	result = &remoteresource.DownloadResult{}
+	if result.Files, err = listFiles(destinationPath); err != nil {
+		return err, nil
+	}
+	return nil, result
+}
+
+// clone runs the git commands checking out the pinned version of the repository and its submodules
+func (git *GitRepositoryResource) clone(log log.T, destinationPath string, options []string, env []string) error {
+	info := git.Info
+	run := func(dir string, args ...string) error {
+		return git.gitdeps.Git(log, dir, env, append(append([]string{}, options...), args...)...)
+	}
+	parentDir := filepath.Dir(destinationPath)
+
+	if info.CommitID == "" {
+		args := []string{"clone"}
+		if info.Shallow {
+			args = append(args, "--depth", "1")
+			if info.Submodules {
+				args = append(args, "--shallow-submodules")
+			}
+		}
+		if info.Submodules {
+			args = append(args, "--recurse-submodules")
+		}
+		if version := info.Branch + info.Tag; version != "" {
+			args = append(args, "--branch", version)
+		}
+		return run(parentDir, append(args, "--", info.Repository, destinationPath)...)
+	}
+
+	// commits are fetched by hash, which clone doesn't support
+	if info.Shallow {
+		if err := run(parentDir, "init", destinationPath); err != nil {
+			return err
+		}
+		if err := run(destinationPath, "remote", "add", "origin", info.Repository); err != nil {
+			return err
+		}
+		if err := run(destinationPath, "fetch", "--depth", "1", "origin", info.CommitID); err != nil {
+			return err
+		}
+		if err := run(destinationPath, "checkout", "--detach", "FETCH_HEAD"); err != nil {
+			return err
+		}
+	} else {
+		if err := run(parentDir, "clone", "--no-checkout", "--", info.Repository, destinationPath); err != nil {
+			return err
+		}
+		if err := run(destinationPath, "checkout", "--detach", info.CommitID); err != nil {
+			return err
+		}
+	}
+	if info.Submodules {
+		args := []string{"submodule", "update", "--init", "--recursive"}
+		if info.Shallow {
+			args = append(args, "--depth", "1")
+		}
+		return run(destinationPath, args...)
+	}
+	return nil
+}
+
+// credentials returns the git options and environment authenticating the clone, the credentials are written to dir
+func (git *GitRepositoryResource) credentials(log log.T, dir string) (options []string, env []string, err error) {
+	info := git.Info
+
+	if info.Password != "" {
+		var password string
+		if password, err = git.resolveCredential(log, info.Password); err != nil {
+			return
+		}
+		var repositoryURL *url.URL
+		if repositoryURL, err = url.Parse(info.Repository); err != nil {
+			return
+		}
+		storeURL := url.URL{Scheme: repositoryURL.Scheme, Host: repositoryURL.Host, User: url.UserPassword(info.Username, password)}
+		storePath := filepath.Join(dir, "credentials")
+		if err = ioutil.WriteFile(storePath, []byte(storeURL.String()+"\n"), appconfig.ReadWriteAccess); err != nil {
+			return
+		}
+		// the store helper keeps the credentials out of the command lines, the empty helper disables the configured ones
+		options = append(options,
+			"-c", "credential.helper=",
+			"-c", "credential.helper=store --file="+quoteForShell(storePath))
+	}
+
+	if isSSHRepository(info.Repository) {
+		sshCommand := "ssh -o BatchMode=yes"
+		if info.PrivateSSHKey != "" {
+			var key string
+			if key, err = git.resolveCredential(log, info.PrivateSSHKey); err != nil {
+				return
+			}
+			keyPath := filepath.Join(dir, "id")
+			if err = ioutil.WriteFile(keyPath, []byte(strings.TrimSpace(key)+"\n"), appconfig.ReadWriteAccess); err != nil {
+				return
+			}
+			sshCommand += " -o IdentitiesOnly=yes -i " + quoteForShell(keyPath)
+		}
+		if info.KnownHosts != "" {
+			knownHostsPath := filepath.Join(dir, "known_hosts")
+			if err = ioutil.WriteFile(knownHostsPath, []byte(info.KnownHosts+"\n"), appconfig.ReadWriteAccess); err != nil {
+				return
+			}
+			sshCommand += " -o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + quoteForShell(knownHostsPath)
+		}
+		env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+	}
+	return
+}
+
+// resolveCredential returns the value of the Parameter Store parameter or Secrets Manager secret referenced
+func (git *GitRepositoryResource) resolveCredential(log log.T, reference string) (string, error) {
+	match := credentialReferencePattern.FindStringSubmatch(reference)
+	if match == nil {
+		return "", errors.New("Credentials must be specified as '{{ ssm-secure:parameter-name }}' or '{{ secretsmanager:secret-id }}'")
+	}
+	parameterReference := ssmSecurePrefix + match[2]
+	if match[1] == secretsManagerPrefix {
+		parameterReference = ssmSecurePrefix + secretsManagerReferencePath + match[2]
+	}
+
+	value, err := git.gitdeps.ResolveSecureParameter(log, parameterReference)
+	if err != nil {
+		return "", fmt.Errorf("Could not resolve the credential %v. Error - %v", reference, err)
+	}
+	return value, nil
+}
+
+// isSSHRepository returns whether the repository is cloned with the ssh transport
+func isSSHRepository(repository string) bool {
+	return strings.HasPrefix(repository, "ssh://") || scpLikeURLPattern.MatchString(repository)
+}
+
+// quoteForShell quotes a path for the shell git runs its helpers and ssh command with
+func quoteForShell(path string) string {
+	return "'" + strings.Replace(filepath.ToSlash(path), "'", `'\''`, -1) + "'"
+}
+
+// isEmptyOrMissing returns whether the path doesn't exist or is an empty directory
+func isEmptyOrMissing(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+	return fileutil.IsDirEmpty(path)
+}
+
+// listFiles returns the files cloned, without the git metadata
+func listFiles(dir string) (files []string, err error) {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return
+}
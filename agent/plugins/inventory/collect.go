@@ -0,0 +1,164 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	errorMsgForNoGathererEnabled    = "no inventory type is enabled in the input of %v plugin"
+	msgForUploadedInventoryType     = "%v: %v entries, uploaded to SSM"
+	msgForUnchangedInventoryType    = "%v: %v entries, unchanged since the last upload to SSM"
+	msgWhenNoInventoryTypeCollected = "No inventory data was collected"
+)
+
+// CollectionReport reports an inventory type collected on demand
+type CollectionReport struct {
+	TypeName   string `json:"typeName"`
+	EntryCount int    `json:"entryCount"`
+	Uploaded   bool   `json:"uploaded"`
+}
+
+// CollectPlugin runs an immediate inventory collection of the gatherers enabled in its input and uploads the inventory
+// types which changed since the last upload. Unlike aws:softwareInventory, it is invoked by commands.
+type CollectPlugin struct {
+	*Plugin
+}
+
+// CollectPluginName returns the name of the on-demand inventory collection plugin
+func CollectPluginName() string {
+	return appconfig.PluginNameAwsCollectInventory
+}
+
+// NewCollectPlugin creates a new on-demand inventory collection plugin.
+func NewCollectPlugin(context context.T) (*CollectPlugin, error) {
+	p, err := NewPlugin(context)
+	return &CollectPlugin{Plugin: p}, err
+}
+
+// Execute runs the on-demand inventory collection.
+func (p *CollectPlugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	var inventoryInput PluginInput
+
+	if err := jsonutil.Remarshal(config.Properties, &inventoryInput); err != nil {
+		errorMsg := fmt.Sprintf(errorMsgForInvalidInventoryInput, CollectPluginName())
+		log.Error(errorMsg)
+		output.SetExitCode(1)
+		output.SetStatus(contracts.ResultStatusFailed)
+		output.AppendError(errorMsg)
+		return
+	}
+
+	p.CollectInventoryOnDemand(context, inventoryInput, output)
+
+	if output.GetExitCode() != 0 {
+		output.SetStatus(contracts.ResultStatusFailed)
+	} else {
+		output.SetStatus(contracts.ResultStatusSuccess)
+	}
+}
+
+// CollectInventoryOnDemand runs the gatherers enabled in the input, uploads the inventory types which changed since
+// the last upload and reports every collected inventory type.
+func (p *Plugin) CollectInventoryOnDemand(context context.T, inventoryInput PluginInput, output iohandler.IOHandler) {
+	log := p.context.Log()
+
+	configuredGatherers, err := p.ValidateInventoryInput(context, inventoryInput)
+	if err == nil && len(configuredGatherers) == 0 {
+		err = fmt.Errorf(errorMsgForNoGathererEnabled, CollectPluginName())
+	}
+	if err != nil {
+		log.Info(err.Error())
+		output.SetExitCode(1)
+		output.AppendError(err.Error())
+		return
+	}
+
+	var items []model.Item
+	if items, err = p.RunGatherers(configuredGatherers); err != nil {
+		log.Info(err.Error())
+		output.SetExitCode(1)
+		output.AppendError(err.Error())
+		return
+	}
+
+	var dirtyItems []*ssm.InventoryItem
+	if dirtyItems, err = p.uploader.GetDirtySsmInventoryItems(context, items); err != nil {
+		log.Infof("Encountered error in collecting dirty Inventory items - %v. Skipping upload to SSM", err.Error())
+		output.SetExitCode(1)
+		output.AppendError(err.Error())
+		return
+	}
+
+	if len(dirtyItems) > 0 {
+		if err = p.uploader.SendDataToSSM(context, dirtyItems); err != nil {
+			propagateSSMError(output, err, log)
+			return
+		}
+	}
+
+	uploaded := make(map[string]bool)
+	for _, dirtyItem := range dirtyItems {
+		uploaded[*dirtyItem.TypeName] = true
+	}
+	reports := make([]CollectionReport, 0, len(items))
+	for _, item := range items {
+		reports = append(reports, CollectionReport{
+			TypeName:   item.Name,
+			EntryCount: entryCount(item.Content),
+			Uploaded:   uploaded[item.Name],
+		})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].TypeName < reports[j].TypeName })
+
+	if len(reports) == 0 {
+		output.AppendInfo(msgWhenNoInventoryTypeCollected)
+	}
+	for _, report := range reports {
+		if report.Uploaded {
+			output.AppendInfof(msgForUploadedInventoryType, report.TypeName, report.EntryCount)
+		} else {
+			output.AppendInfof(msgForUnchangedInventoryType, report.TypeName, report.EntryCount)
+		}
+	}
+	output.SetStructuredOutput(reports)
+	output.SetExitCode(0)
+	log.Infof("%v collected %v inventory types on demand, %v uploaded", CollectPluginName(), len(reports), len(dirtyItems))
+}
+
+// entryCount returns the number of entries of the content of an inventory type
+func entryCount(content interface{}) int {
+	value := reflect.ValueOf(content)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		return value.Len()
+	case reflect.Invalid:
+		return 0
+	default:
+		return 1
+	}
+}
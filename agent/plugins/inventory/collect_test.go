@@ -0,0 +1,153 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockUploader stands for a mocked inventory uploader.
+type mockUploader struct {
+	mock.Mock
+}
+
+func (m *mockUploader) SendDataToSSM(context context.T, items []*ssm.InventoryItem) (err error) {
+	args := m.Called(context, items)
+	return args.Error(0)
+}
+
+func (m *mockUploader) ConvertToSsmInventoryItems(context context.T, items []model.Item) (optimizedInventoryItems, nonOptimizedInventoryItems []*ssm.InventoryItem, err error) {
+	args := m.Called(context, items)
+	return args.Get(0).([]*ssm.InventoryItem), args.Get(1).([]*ssm.InventoryItem), args.Error(2)
+}
+
+func (m *mockUploader) GetDirtySsmInventoryItems(context context.T, items []model.Item) (dirtyInventoryItems []*ssm.InventoryItem, err error) {
+	args := m.Called(context, items)
+	return args.Get(0).([]*ssm.InventoryItem), args.Error(1)
+}
+
+// mockCollectPlugin returns a collect plugin with the application and network gatherers returning items
+func mockCollectPlugin() (*Plugin, *mockUploader) {
+	names := []string{application.GathererName, network.GathererName}
+	p, _ := MockInventoryPlugin(names, names)
+	uploader := &mockUploader{}
+	p.uploader = uploader
+
+	applicationItem := model.Item{
+		Name:    application.GathererName,
+		Content: []model.ApplicationData{{Name: "foo"}, {Name: "bar"}},
+	}
+	networkItem := model.Item{
+		Name:    network.GathererName,
+		Content: []model.NetworkData{{Name: "eth0"}},
+	}
+	config := model.Config{Collection: model.Enabled}
+
+	applicationGatherer := p.supportedGatherers[application.GathererName].(*gatherers.Mock)
+	applicationGatherer.On("Name").Return(application.GathererName)
+	applicationGatherer.On("Run", p.context, config).Return([]model.Item{applicationItem}, nil)
+	networkGatherer := p.supportedGatherers[network.GathererName].(*gatherers.Mock)
+	networkGatherer.On("Name").Return(network.GathererName)
+	networkGatherer.On("Run", p.context, config).Return([]model.Item{networkItem}, nil)
+
+	return p, uploader
+}
+
+func TestCollectInventoryOnDemand(t *testing.T) {
+	p, uploader := mockCollectPlugin()
+	dirtyItems := []*ssm.InventoryItem{{TypeName: aws.String(network.GathererName)}}
+	uploader.On("GetDirtySsmInventoryItems", p.context, mock.Anything).Return(dirtyItems, nil)
+	uploader.On("SendDataToSSM", p.context, dirtyItems).Return(nil)
+	output := &iohandler.DefaultIOHandler{}
+
+	p.CollectInventoryOnDemand(p.context, PluginInput{Applications: model.Enabled, NetworkConfig: model.Enabled}, output)
+
+	uploader.AssertExpectations(t)
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.Equal(t, []CollectionReport{
+		{TypeName: application.GathererName, EntryCount: 2, Uploaded: false},
+		{TypeName: network.GathererName, EntryCount: 1, Uploaded: true},
+	}, output.GetStructuredOutput())
+	assert.Contains(t, output.GetStdout(), "AWS:Network: 1 entries, uploaded to SSM")
+	assert.Contains(t, output.GetStdout(), "AWS:Application: 2 entries, unchanged since the last upload to SSM")
+}
+
+func TestCollectInventoryOnDemandOnlyRunsSelectedGatherers(t *testing.T) {
+	p, uploader := mockCollectPlugin()
+	uploader.On("GetDirtySsmInventoryItems", p.context, mock.Anything).Return([]*ssm.InventoryItem{}, nil)
+	output := &iohandler.DefaultIOHandler{}
+
+	p.CollectInventoryOnDemand(p.context, PluginInput{NetworkConfig: model.Enabled}, output)
+
+	uploader.AssertNotCalled(t, "SendDataToSSM", mock.Anything, mock.Anything)
+	p.supportedGatherers[application.GathererName].(*gatherers.Mock).AssertNotCalled(t, "Run", mock.Anything, mock.Anything)
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.Equal(t, []CollectionReport{{TypeName: network.GathererName, EntryCount: 1, Uploaded: false}}, output.GetStructuredOutput())
+}
+
+func TestCollectInventoryOnDemandWithoutGatherer(t *testing.T) {
+	p, uploader := mockCollectPlugin()
+	output := &iohandler.DefaultIOHandler{}
+
+	p.CollectInventoryOnDemand(p.context, PluginInput{}, output)
+
+	uploader.AssertNotCalled(t, "GetDirtySsmInventoryItems", mock.Anything, mock.Anything)
+	assert.Equal(t, 1, output.GetExitCode())
+	assert.Contains(t, output.GetStderr(), "no inventory type is enabled")
+}
+
+func TestCollectInventoryOnDemandUploadFailure(t *testing.T) {
+	p, uploader := mockCollectPlugin()
+	dirtyItems := []*ssm.InventoryItem{{TypeName: aws.String(network.GathererName)}}
+	uploader.On("GetDirtySsmInventoryItems", p.context, mock.Anything).Return(dirtyItems, nil)
+	uploader.On("SendDataToSSM", p.context, dirtyItems).Return(errors.New("upload failed"))
+	output := &iohandler.DefaultIOHandler{}
+
+	p.CollectInventoryOnDemand(p.context, PluginInput{NetworkConfig: model.Enabled}, output)
+
+	assert.Equal(t, 1, output.GetExitCode())
+	assert.Nil(t, output.GetStructuredOutput())
+}
+
+func TestCollectPluginExecute(t *testing.T) {
+	p, uploader := mockCollectPlugin()
+	uploader.On("GetDirtySsmInventoryItems", p.context, mock.Anything).Return([]*ssm.InventoryItem{}, nil)
+	collectPlugin := &CollectPlugin{Plugin: p}
+	config := contracts.Configuration{Properties: map[string]interface{}{"networkConfig": model.Enabled}}
+	output := &iohandler.DefaultIOHandler{}
+
+	collectPlugin.Execute(p.context, config, nil, output)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, []CollectionReport{{TypeName: network.GathererName, EntryCount: 1, Uploaded: false}}, output.GetStructuredOutput())
+}
+
+func TestEntryCount(t *testing.T) {
+	assert.Equal(t, 2, entryCount([]model.ApplicationData{{}, {}}))
+	assert.Equal(t, 1, entryCount(model.InstanceInformation{}))
+	assert.Equal(t, 0, entryCount(nil))
+}
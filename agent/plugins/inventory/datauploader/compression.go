@@ -0,0 +1,67 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package datauploader contains routines upload inventory data to SSM - Inventory service
+package datauploader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// putInventoryOperation is the name of the operation whose payload is compressed
+	putInventoryOperation = "PutInventory"
+	// compressionThresholdBytes is the payload size below which compressing is not worth it
+	compressionThresholdBytes = 1024
+	// gzipContentEncoding is the content encoding of the compressed payloads
+	gzipContentEncoding = "gzip"
+)
+
+// compressPutInventoryHandler compresses the payload of PutInventory requests with gzip
+var compressPutInventoryHandler = request.NamedHandler{
+	Name: "inventory.CompressPutInventoryHandler",
+	Fn:   compressPutInventory,
+}
+
+// compressPutInventory replaces the payload of a PutInventory request by its gzip compression,
+// the payload is sent as it is when it is small or when it cannot be compressed
+func compressPutInventory(r *request.Request) {
+	if r.Error != nil || r.Operation == nil || r.Operation.Name != putInventoryOperation || r.Body == nil {
+		return
+	}
+	if _, err := r.Body.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(payload) < compressionThresholdBytes {
+		r.SetBufferBody(payload)
+		return
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err = writer.Write(payload); err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		r.SetBufferBody(payload)
+		return
+	}
+	r.SetBufferBody(compressed.Bytes())
+	r.HTTPRequest.Header.Set("Content-Encoding", gzipContentEncoding)
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package datauploader contains routines upload inventory data to SSM - Inventory service
+package datauploader
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCompressingSSMClient() *ssm.SSM {
+	sess := session.New(&aws.Config{Region: aws.String("us-east-1"), Credentials: credentials.AnonymousCredentials})
+	client := ssm.New(sess)
+	client.Handlers.Build.PushBackNamed(compressPutInventoryHandler)
+	return client
+}
+
+func readBody(t *testing.T, body interface {
+	Read([]byte) (int, error)
+}) string {
+	content, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func newPutInventoryInput(value string) *ssm.PutInventoryInput {
+	return &ssm.PutInventoryInput{
+		InstanceId: aws.String("i-12345678"),
+		Items: []*ssm.InventoryItem{{
+			TypeName:      aws.String("Custom:Test"),
+			SchemaVersion: aws.String("1.0"),
+			CaptureTime:   aws.String("2018-10-16T08:12:46Z"),
+			Content:       []map[string]*string{{"Name": aws.String(value)}},
+		}},
+	}
+}
+
+func TestCompressPutInventory(t *testing.T) {
+	req, _ := newCompressingSSMClient().PutInventoryRequest(newPutInventoryInput(strings.Repeat("a", compressionThresholdBytes)))
+	assert.NoError(t, req.Build())
+	assert.Equal(t, gzipContentEncoding, req.HTTPRequest.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(req.Body)
+	assert.NoError(t, err)
+	payload := readBody(t, reader)
+	assert.Contains(t, payload, `"InstanceId":"i-12345678"`)
+	assert.Contains(t, payload, strings.Repeat("a", compressionThresholdBytes))
+}
+
+func TestCompressPutInventorySmallPayload(t *testing.T) {
+	req, _ := newCompressingSSMClient().PutInventoryRequest(newPutInventoryInput("a"))
+	assert.NoError(t, req.Build())
+	assert.Empty(t, req.HTTPRequest.Header.Get("Content-Encoding"))
+	assert.Contains(t, readBody(t, req.Body), `"InstanceId":"i-12345678"`)
+}
+
+func TestCompressOtherOperation(t *testing.T) {
+	req, _ := newCompressingSSMClient().DescribeInstanceInformationRequest(&ssm.DescribeInstanceInformationInput{
+		NextToken: aws.String(strings.Repeat("a", compressionThresholdBytes)),
+	})
+	assert.NoError(t, req.Build())
+	assert.Empty(t, req.HTTPRequest.Header.Get("Content-Encoding"))
+}
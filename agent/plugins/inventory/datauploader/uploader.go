@@ -91,7 +91,12 @@ func NewInventoryUploader(context context.T) (*InventoryUploader, error) {
 	sess := session.New(cfg)
 	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appCfg.Agent.Name, appCfg.Agent.Version))
 
-	uploader.ssm = ssm.New(sess)
+	ssmClient := ssm.New(sess)
+	if appCfg.Ssm.InventoryUploadCompression {
+		// the protocol handlers build the payload, it is compressed after them
+		ssmClient.Handlers.Build.PushBackNamed(compressPutInventoryHandler)
+	}
+	uploader.ssm = ssmClient
 
 	if uploader.optimizer, err = NewOptimizerImpl(context); err != nil {
 		log.Errorf("Unable to load optimizer for inventory uploader because - %v", err.Error())
@@ -173,8 +178,8 @@ func calculateCheckSum(data []byte) (checkSum string) {
 }
 
 // ConvertToSsmInventoryItems converts given array of inventory.Item into an array of *ssm.InventoryItem. It returns 2 such arrays - one is optimized array
-// which contains only contentHash for those inventory types where the dataset hasn't changed from previous collection. The other array is non-optimized array
-// which contains both contentHash & content. This is done to avoid iterating over the inventory data twice. It throws error when it encounters error during
+// which skips the inventory types where the dataset hasn't changed from previous upload. The other array is non-optimized array
+// which contains all inventory types with both contentHash & content. This is done to avoid iterating over the inventory data twice. It throws error when it encounters error during
 // conversion process.
 func (u *InventoryUploader) ConvertToSsmInventoryItems(context context.T, items []model.Item) (optimizedInventoryItems, nonOptimizedInventoryItems []*ssm.InventoryItem, err error) {
 
@@ -189,7 +194,7 @@ func (u *InventoryUploader) ConvertToSsmInventoryItems(context context.T, items
 	for _, item := range items {
 
 		var dataB []byte
-		var optimizedItem, nonOptimizedItem *ssm.InventoryItem
+		var nonOptimizedItem *ssm.InventoryItem
 
 		newHash := ""
 		oldHash := ""
@@ -224,20 +229,7 @@ func (u *InventoryUploader) ConvertToSsmInventoryItems(context context.T, items
 		log.Debugf("old hash - %v, new hash - %v for the inventory type - %v", oldHash, newHash, itemName)
 
 		if newHash == oldHash {
-			log.Debugf("Inventory data for %v is same as before - we can just send content hash", itemName)
-
-			//set the inventory item accordingly
-			optimizedItem = &ssm.InventoryItem{
-				CaptureTime:   &item.CaptureTime,
-				TypeName:      &itemName,
-				SchemaVersion: &item.SchemaVersion,
-				ContentHash:   &oldHash,
-			}
-
-			log.Debugf("Optimized item - %v", optimizedItem)
-
-			optimizedInventoryItems = append(optimizedInventoryItems, optimizedItem)
-
+			log.Debugf("Inventory data for %v is same as before - skipping it in the optimized items", itemName)
 		} else {
 			log.Debugf("New inventory data for %v has been detected - can't optimize here", itemName)
 			log.Debugf("Adding item - %v to the optimizedItems (since its new data)", nonOptimizedItem)
@@ -105,6 +105,30 @@ func TestConvertToSsmInventoryItems(t *testing.T) {
 	assert.NotNil(t, err, "Error should be thrown for unsupported Item.Content")
 }
 
+func TestConvertToSsmInventoryItemsSkipsUnchangedTypes(t *testing.T) {
+	c := context.NewMockDefault()
+	items := append(ApplicationInventoryItem(), model.Item{
+		Name:          "ChangedInventoryItem",
+		Content:       FakeStructForTesting(),
+		SchemaVersion: "1.0",
+		CaptureTime:   "time",
+	})
+	dataB, _ := json.Marshal(items[0].Content)
+
+	var u InventoryUploader
+	optimizer := NewMockDefault()
+	optimizer.On("GetContentHash", "RandomInventoryItem").Return(calculateCheckSum(dataB))
+	optimizer.On("GetContentHash", "ChangedInventoryItem").Return("OldHash")
+	u.optimizer = optimizer
+
+	optimizedItems, nonOptimizedItems, err := u.ConvertToSsmInventoryItems(c, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(nonOptimizedItems), "all inventory types should be in the non-optimized items")
+	assert.Equal(t, 1, len(optimizedItems), "unchanged inventory types should be skipped")
+	assert.Equal(t, "ChangedInventoryItem", *optimizedItems[0].TypeName)
+	assert.NotNil(t, optimizedItems[0].Content)
+}
+
 func TestConvertExcludedAndEmptyToSsmInventoryItems(t *testing.T) {
 
 	var items []model.Item
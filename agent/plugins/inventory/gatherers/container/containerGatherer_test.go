@@ -0,0 +1,73 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContainers = []model.ContainerData{
+	{
+		ContainerId: "3f4e5d",
+		Name:        "web",
+		Image:       "nginx:1.15",
+		State:       "running",
+		Runtime:     DockerRuntime,
+	},
+}
+
+var testImages = []model.ContainerImageData{
+	{
+		ImageId:    "sha256:be1f31be9a87",
+		Repository: "nginx",
+		Tag:        "1.15",
+		Size:       "109MB",
+		Runtime:    DockerRuntime,
+	},
+}
+
+func testCollectContainerData(context context.T, config model.Config) ([]model.ContainerData, []model.ContainerImageData, error) {
+	return testContainers, testImages, nil
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = testCollectContainerData
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfContainerGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testContainers, items[0].Content)
+	assert.Equal(t, ImageTypeName, items[1].Name)
+	assert.Equal(t, SchemaVersionOfContainerGatherer, items[1].SchemaVersion)
+	assert.Equal(t, testImages, items[1].Content)
+}
+
+func TestGathererError(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T, config model.Config) ([]model.ContainerData, []model.ContainerImageData, error) {
+		return nil, nil, errors.New("failed")
+	}
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Nil(t, items)
+}
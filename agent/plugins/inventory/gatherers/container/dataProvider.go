@@ -0,0 +1,304 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// DockerRuntime represents the containers and images managed by Docker
+	DockerRuntime = "docker"
+	// ContainerdRuntime represents the containers and images managed by containerd, listed with crictl
+	ContainerdRuntime = "containerd"
+
+	dockerCmd  = "docker"
+	crictlCmd  = "crictl"
+	noneValue  = "<none>"
+	criPrefix  = "CONTAINER_"
+	dockerTime = "2006-01-02 15:04:05 -0700 MST"
+)
+
+// dockerContainer is a container listed by docker ps
+type dockerContainer struct {
+	ID        string
+	Names     string
+	Image     string
+	Command   string
+	CreatedAt string
+	Ports     string
+	State     string
+	Status    string
+}
+
+// dockerImage is an image listed by docker images
+type dockerImage struct {
+	ID         string
+	Repository string
+	Tag        string
+	Digest     string
+	CreatedAt  string
+	Size       string
+}
+
+// criContainers are the containers listed by crictl ps
+type criContainers struct {
+	Containers []struct {
+		ID       string `json:"id"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Image struct {
+			Image string `json:"image"`
+		} `json:"image"`
+		ImageRef  string `json:"imageRef"`
+		State     string `json:"state"`
+		CreatedAt string `json:"createdAt"`
+	} `json:"containers"`
+}
+
+// criImages are the images listed by crictl images
+type criImages struct {
+	Images []struct {
+		ID          string   `json:"id"`
+		RepoTags    []string `json:"repoTags"`
+		RepoDigests []string `json:"repoDigests"`
+		Size        string   `json:"size"`
+	} `json:"images"`
+}
+
+// containerRuntime lists the running containers and the images of a container runtime
+type containerRuntime struct {
+	name    string
+	command string
+	collect func(log log.T) ([]model.ContainerData, []model.ContainerImageData, error)
+}
+
+var runtimes = []containerRuntime{
+	{name: DockerRuntime, command: dockerCmd, collect: collectDockerData},
+	{name: ContainerdRuntime, command: crictlCmd, collect: collectContainerdData},
+}
+
+// decoupling exec.Command and exec.LookPath for easy testability
+var cmdExecutor = executeCommand
+var lookPath = exec.LookPath
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	output, err := exec.Command(command, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%v: %v", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return output, err
+}
+
+// collectContainerData collects the running containers and the images of the container runtimes installed on the instance.
+// A runtime which fails to list its containers is skipped so that the other inventory types are still reported.
+func collectContainerData(context context.T, config model.Config) (containers []model.ContainerData, images []model.ContainerImageData, err error) {
+	log := context.Log()
+	containers = []model.ContainerData{}
+	images = []model.ContainerImageData{}
+	knownContainers := make(map[string]bool)
+	knownImages := make(map[string]bool)
+
+	for _, runtime := range runtimes {
+		if _, lookPathErr := lookPath(runtime.command); lookPathErr != nil {
+			log.Debugf("Container runtime %v is not installed: %v", runtime.name, lookPathErr)
+			continue
+		}
+		runtimeContainers, runtimeImages, collectErr := runtime.collect(log)
+		if collectErr != nil {
+			log.Errorf("Failed to collect the containers of %v: %v", runtime.name, collectErr)
+			continue
+		}
+		// a runtime can manage the containers of another one, e.g. crictl listing the containers of dockershim
+		for _, container := range runtimeContainers {
+			if !knownContainers[container.ContainerId] {
+				knownContainers[container.ContainerId] = true
+				containers = append(containers, container)
+			}
+		}
+		for _, image := range runtimeImages {
+			key := image.ImageId + " " + image.Repository + ":" + image.Tag
+			if !knownImages[key] {
+				knownImages[key] = true
+				images = append(images, image)
+			}
+		}
+	}
+	log.Infof("Number of running containers collected: %v, number of images collected: %v", len(containers), len(images))
+	return
+}
+
+// collectDockerData lists the running containers and the images of docker
+func collectDockerData(log log.T) (containers []model.ContainerData, images []model.ContainerImageData, err error) {
+	var output []byte
+	if output, err = cmdExecutor(dockerCmd, "ps", "--no-trunc", "--format", "{{json .}}"); err != nil {
+		return
+	}
+	var lines [][]byte
+	if lines, err = jsonLines(output); err != nil {
+		return
+	}
+	for _, line := range lines {
+		var c dockerContainer
+		if err = json.Unmarshal(line, &c); err != nil {
+			return nil, nil, fmt.Errorf("unable to parse docker ps output: %v", err)
+		}
+		state := c.State
+		if state == "" {
+			// docker versions before 17.06 don't report the state, docker ps only lists running containers
+			state = "running"
+		}
+		containers = append(containers, model.ContainerData{
+			ContainerId: c.ID,
+			Name:        c.Names,
+			Image:       c.Image,
+			Command:     strings.Trim(c.Command, `"`),
+			State:       state,
+			Status:      c.Status,
+			CreatedTime: formatDockerTime(c.CreatedAt),
+			Ports:       c.Ports,
+			Runtime:     DockerRuntime,
+		})
+	}
+
+	if output, err = cmdExecutor(dockerCmd, "images", "--no-trunc", "--digests", "--format", "{{json .}}"); err != nil {
+		return
+	}
+	if lines, err = jsonLines(output); err != nil {
+		return
+	}
+	for _, line := range lines {
+		var i dockerImage
+		if err = json.Unmarshal(line, &i); err != nil {
+			return nil, nil, fmt.Errorf("unable to parse docker images output: %v", err)
+		}
+		digest := i.Digest
+		if digest == noneValue {
+			digest = ""
+		}
+		images = append(images, model.ContainerImageData{
+			ImageId:     i.ID,
+			Repository:  i.Repository,
+			Tag:         i.Tag,
+			Digest:      digest,
+			Size:        i.Size,
+			CreatedTime: formatDockerTime(i.CreatedAt),
+			Runtime:     DockerRuntime,
+		})
+	}
+	return
+}
+
+// collectContainerdData lists the running containers and the images of containerd with crictl
+func collectContainerdData(log log.T) (containers []model.ContainerData, images []model.ContainerImageData, err error) {
+	var output []byte
+	if output, err = cmdExecutor(crictlCmd, "ps", "-o", "json"); err != nil {
+		return
+	}
+	var criContainerList criContainers
+	if err = json.Unmarshal(output, &criContainerList); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse crictl ps output: %v", err)
+	}
+	for _, c := range criContainerList.Containers {
+		containers = append(containers, model.ContainerData{
+			ContainerId: c.ID,
+			Name:        c.Metadata.Name,
+			Image:       c.Image.Image,
+			ImageId:     c.ImageRef,
+			State:       strings.ToLower(strings.TrimPrefix(c.State, criPrefix)),
+			CreatedTime: formatUnixNanoTime(c.CreatedAt),
+			Runtime:     ContainerdRuntime,
+		})
+	}
+
+	if output, err = cmdExecutor(crictlCmd, "images", "-o", "json"); err != nil {
+		return
+	}
+	var criImageList criImages
+	if err = json.Unmarshal(output, &criImageList); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse crictl images output: %v", err)
+	}
+	for _, i := range criImageList.Images {
+		digest := ""
+		if len(i.RepoDigests) > 0 {
+			if at := strings.LastIndex(i.RepoDigests[0], "@"); at >= 0 {
+				digest = i.RepoDigests[0][at+1:]
+			}
+		}
+		repoTags := i.RepoTags
+		if len(repoTags) == 0 {
+			repoTags = []string{noneValue + ":" + noneValue}
+		}
+		for _, repoTag := range repoTags {
+			repository, tag := splitRepoTag(repoTag)
+			images = append(images, model.ContainerImageData{
+				ImageId:    i.ID,
+				Repository: repository,
+				Tag:        tag,
+				Digest:     digest,
+				Size:       i.Size,
+				Runtime:    ContainerdRuntime,
+			})
+		}
+	}
+	return
+}
+
+// jsonLines returns the lines of an output holding one json object per line
+func jsonLines(output []byte) (lines [][]byte, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitRepoTag splits an image reference such as registry:5000/app:1.0 into its repository and its tag
+func splitRepoTag(repoTag string) (repository string, tag string) {
+	if colon := strings.LastIndex(repoTag, ":"); colon > strings.LastIndex(repoTag, "/") {
+		return repoTag[:colon], repoTag[colon+1:]
+	}
+	return repoTag, noneValue
+}
+
+// formatDockerTime formats the times reported by docker, e.g. 2018-10-16 08:12:46 +0000 UTC, as RFC3339 UTC times
+func formatDockerTime(value string) string {
+	if t, err := time.Parse(dockerTime, value); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// formatUnixNanoTime formats the times reported by crictl, in nanoseconds since epoch, as RFC3339 UTC times
+func formatUnixNanoTime(value string) string {
+	if nanoseconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, nanoseconds).UTC().Format(time.RFC3339)
+	}
+	return value
+}
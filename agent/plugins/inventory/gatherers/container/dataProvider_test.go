@@ -0,0 +1,219 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDockerPsOutput = `{"Command":"\"nginx -g 'daemon off;'\"","CreatedAt":"2018-10-16 08:12:46 +0200 CEST","ID":"3f4e5d6c","Image":"nginx:1.15","Labels":"","LocalVolumes":"0","Mounts":"","Names":"web","Networks":"bridge","Ports":"0.0.0.0:80->80/tcp","RunningFor":"2 hours ago","Size":"0B","State":"running","Status":"Up 2 hours"}
+{"Command":"\"redis-server\"","CreatedAt":"2018-10-15 10:00:00 +0000 UTC","ID":"9a8b7c6d","Image":"redis","Labels":"","LocalVolumes":"1","Mounts":"","Names":"cache","Networks":"bridge","Ports":"6379/tcp","RunningFor":"22 hours ago","Size":"0B","Status":"Up 22 hours"}
+`
+	testDockerImagesOutput = `{"Containers":"N/A","CreatedAt":"2018-09-05 00:00:00 +0000 UTC","CreatedSince":"6 weeks ago","Digest":"sha256:9ad0746d8f2e","ID":"sha256:be1f31be9a87","Repository":"nginx","SharedSize":"N/A","Size":"109MB","Tag":"1.15","UniqueSize":"N/A","VirtualSize":"109MB"}
+{"Containers":"N/A","CreatedAt":"2018-09-01 00:00:00 +0000 UTC","CreatedSince":"6 weeks ago","Digest":"<none>","ID":"sha256:5d2989ac9711","Repository":"<none>","SharedSize":"N/A","Size":"94.9MB","Tag":"<none>","UniqueSize":"N/A","VirtualSize":"94.9MB"}
+`
+	testCrictlPsOutput     = `{"containers": [{"id": "c0ffee", "podSandboxId": "p0d", "metadata": {"name": "coredns", "attempt": 0}, "image": {"image": "sha256:8c811b4aec35"}, "imageRef": "sha256:8c811b4aec35", "state": "CONTAINER_RUNNING", "createdAt": "1539677566000000000", "labels": {}, "annotations": {}}]}`
+	testCrictlImagesOutput = `{"images": [{"id": "sha256:8c811b4aec35", "repoTags": ["k8s.gcr.io/coredns:1.2.2", "registry:5000/coredns:latest"], "repoDigests": ["k8s.gcr.io/coredns@sha256:3e2be1cec87a"], "size": "39218251", "username": ""}, {"id": "sha256:da86e6ba6ca1", "repoTags": [], "repoDigests": [], "size": "742472"}]}`
+)
+
+var testDockerContainers = []model.ContainerData{
+	{
+		ContainerId: "3f4e5d6c",
+		Name:        "web",
+		Image:       "nginx:1.15",
+		Command:     "nginx -g 'daemon off;'",
+		State:       "running",
+		Status:      "Up 2 hours",
+		CreatedTime: "2018-10-16T06:12:46Z",
+		Ports:       "0.0.0.0:80->80/tcp",
+		Runtime:     DockerRuntime,
+	},
+	{
+		ContainerId: "9a8b7c6d",
+		Name:        "cache",
+		Image:       "redis",
+		Command:     "redis-server",
+		State:       "running",
+		Status:      "Up 22 hours",
+		CreatedTime: "2018-10-15T10:00:00Z",
+		Ports:       "6379/tcp",
+		Runtime:     DockerRuntime,
+	},
+}
+
+var testDockerImages = []model.ContainerImageData{
+	{
+		ImageId:     "sha256:be1f31be9a87",
+		Repository:  "nginx",
+		Tag:         "1.15",
+		Digest:      "sha256:9ad0746d8f2e",
+		Size:        "109MB",
+		CreatedTime: "2018-09-05T00:00:00Z",
+		Runtime:     DockerRuntime,
+	},
+	{
+		ImageId:     "sha256:5d2989ac9711",
+		Repository:  "<none>",
+		Tag:         "<none>",
+		Size:        "94.9MB",
+		CreatedTime: "2018-09-01T00:00:00Z",
+		Runtime:     DockerRuntime,
+	},
+}
+
+var testContainerdContainers = []model.ContainerData{
+	{
+		ContainerId: "c0ffee",
+		Name:        "coredns",
+		Image:       "sha256:8c811b4aec35",
+		ImageId:     "sha256:8c811b4aec35",
+		State:       "running",
+		CreatedTime: "2018-10-16T08:12:46Z",
+		Runtime:     ContainerdRuntime,
+	},
+}
+
+var testContainerdImages = []model.ContainerImageData{
+	{
+		ImageId:    "sha256:8c811b4aec35",
+		Repository: "k8s.gcr.io/coredns",
+		Tag:        "1.2.2",
+		Digest:     "sha256:3e2be1cec87a",
+		Size:       "39218251",
+		Runtime:    ContainerdRuntime,
+	},
+	{
+		ImageId:    "sha256:8c811b4aec35",
+		Repository: "registry:5000/coredns",
+		Tag:        "latest",
+		Digest:     "sha256:3e2be1cec87a",
+		Size:       "39218251",
+		Runtime:    ContainerdRuntime,
+	},
+	{
+		ImageId:    "sha256:da86e6ba6ca1",
+		Repository: "<none>",
+		Tag:        "<none>",
+		Size:       "742472",
+		Runtime:    ContainerdRuntime,
+	},
+}
+
+// createMockExecutor returns the output of the commands by command line, and an error for the other commands
+func createMockExecutor(outputs map[string]string) func(string, ...string) ([]byte, error) {
+	return func(command string, args ...string) ([]byte, error) {
+		if output, found := outputs[command+" "+strings.Join(args, " ")]; found {
+			return []byte(output), nil
+		}
+		return nil, errors.New("Cannot connect to the daemon")
+	}
+}
+
+func createMockLookPath(installed ...string) func(string) (string, error) {
+	return func(command string) (string, error) {
+		for _, i := range installed {
+			if i == command {
+				return "/usr/bin/" + command, nil
+			}
+		}
+		return "", errors.New("executable file not found in $PATH")
+	}
+}
+
+var testOutputs = map[string]string{
+	"docker ps --no-trunc --format {{json .}}":               testDockerPsOutput,
+	"docker images --no-trunc --digests --format {{json .}}": testDockerImagesOutput,
+	"crictl ps -o json":     testCrictlPsOutput,
+	"crictl images -o json": testCrictlImagesOutput,
+}
+
+func TestCollectContainerData(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	cmdExecutor = createMockExecutor(testOutputs)
+	lookPath = createMockLookPath(dockerCmd, crictlCmd)
+
+	containers, images, err := collectContainerData(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, append(append([]model.ContainerData{}, testDockerContainers...), testContainerdContainers...), containers)
+	assert.Equal(t, append(append([]model.ContainerImageData{}, testDockerImages...), testContainerdImages...), images)
+}
+
+func TestCollectContainerDataNoRuntime(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	cmdExecutor = createMockExecutor(testOutputs)
+	lookPath = createMockLookPath()
+
+	containers, images, err := collectContainerData(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.ContainerData{}, containers)
+	assert.Equal(t, []model.ContainerImageData{}, images)
+}
+
+func TestCollectContainerDataRuntimeError(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	cmdExecutor = createMockExecutor(map[string]string{
+		"crictl ps -o json":     testCrictlPsOutput,
+		"crictl images -o json": testCrictlImagesOutput,
+	})
+	lookPath = createMockLookPath(dockerCmd, crictlCmd)
+
+	containers, images, err := collectContainerData(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, testContainerdContainers, containers)
+	assert.Equal(t, testContainerdImages, images)
+}
+
+func TestCollectContainerDataInvalidOutput(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	cmdExecutor = createMockExecutor(map[string]string{
+		"docker ps --no-trunc --format {{json .}}": "Got permission denied while trying to connect to the Docker daemon socket",
+	})
+	lookPath = createMockLookPath(dockerCmd)
+
+	containers, images, err := collectContainerData(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, containers)
+	assert.Empty(t, images)
+}
+
+func TestCollectContainerDataDuplicates(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	cmdExecutor = createMockExecutor(testOutputs)
+	lookPath = createMockLookPath(dockerCmd, crictlCmd)
+	runtimes = append(runtimes, runtimes[0])
+	defer func() { runtimes = runtimes[:len(runtimes)-1] }()
+
+	containers, images, err := collectContainerData(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, len(testDockerContainers)+len(testContainerdContainers), len(containers))
+	assert.Equal(t, len(testDockerImages)+len(testContainerdImages), len(images))
+}
+
+func TestSplitRepoTag(t *testing.T) {
+	for repoTag, expected := range map[string][2]string{
+		"nginx:1.15":                 {"nginx", "1.15"},
+		"registry:5000/app":          {"registry:5000/app", "<none>"},
+		"registry:5000/team/app:2.0": {"registry:5000/team/app", "2.0"},
+	} {
+		repository, tag := splitRepoTag(repoTag)
+		assert.Equal(t, expected, [2]string{repository, tag}, repoTag)
+	}
+}
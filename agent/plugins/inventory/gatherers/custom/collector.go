@@ -0,0 +1,265 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// CollectorSchemaFileSuffix represents the extension of the file declaring the schema of a custom inventory collector
+	CollectorSchemaFileSuffix = ".schema.json"
+	// CollectorTimeout represents how long a custom inventory collector can run
+	CollectorTimeout = 60 * time.Second
+	// CollectorOutputSizeLimit represents the size limit in bytes of the output of a custom inventory collector
+	CollectorOutputSizeLimit = model.SizeLimitKBPerInventoryType * 1024
+)
+
+// collector is an executable collecting one custom inventory type declared by its schema
+type collector struct {
+	path   string
+	schema model.CustomInventorySchema
+}
+
+// decoupling for easy testability
+var readCollectorDirFunc = ReadDir
+var runCollectorFunc = runCollector
+
+// collectorsFolder returns the configured folder of the custom inventory collectors
+func collectorsFolder(context context.T) string {
+	if folder := context.AppConfig().Ssm.CustomInventoryCollectorsLocation; folder != "" {
+		return folder
+	}
+	return appconfig.DefaultCustomInventoryCollectorsFolder
+}
+
+// getCollectorItems runs the custom inventory collectors of folder and returns the inventory items they collected,
+// it fails if the folder contains more than countLimit collectors.
+func getCollectorItems(log log.T, folder string, countLimit int) (items []model.Item, err error) {
+	collectors, err := getCollectors(log, folder)
+	if err != nil {
+		return nil, err
+	}
+	if len(collectors) > countLimit {
+		err = fmt.Errorf("Total custom inventory file and collector count (%v) exceed limit (%v)",
+			CustomInventoryCountLimit-countLimit+len(collectors), CustomInventoryCountLimit)
+		LogError(log, err)
+		return nil, err
+	}
+
+	for _, c := range collectors {
+		start := time.Now()
+		item, err := getItemFromCollector(log, c)
+		if err != nil {
+			LogError(log, fmt.Errorf("Failed to get item from collector %v, error %v. continue...", c.path, err))
+			continue
+		}
+		log.Debugf("Custom inventory collector %v collected %v in %v", c.path, item.Name, time.Since(start))
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// getCollectors finds the collectors of folder: each schema file <name>.schema.json declares the custom inventory type
+// collected by the executable <name> of the same folder, which may have an extension.
+func getCollectors(log log.T, folder string) (collectors []collector, err error) {
+	files, readDirError := readCollectorDirFunc(folder)
+	if readDirError != nil {
+		// In case of directory not found, there is no collector
+		log.Debugf("Read custom inventory collectors directory %v failed, error: %v", folder, readDirError)
+		return nil, nil
+	}
+
+	executables := make(map[string][]os.FileInfo)
+	var schemaFiles []os.FileInfo
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), CollectorSchemaFileSuffix) {
+			schemaFiles = append(schemaFiles, f)
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		executables[name] = append(executables[name], f)
+	}
+
+	for _, schemaFile := range schemaFiles {
+		name := strings.TrimSuffix(schemaFile.Name(), CollectorSchemaFileSuffix)
+		schemaPath := filepath.Join(folder, schemaFile.Name())
+		if len(executables[name]) != 1 {
+			LogError(log, fmt.Errorf("Custom inventory schema %v must have exactly one collector named %v, found %v. continue...",
+				schemaPath, name, len(executables[name])))
+			continue
+		}
+		executable := executables[name][0]
+		collectorPath := filepath.Join(folder, executable.Name())
+		if err := validateCollectorFiles(schemaFile, executable); err != nil {
+			LogError(log, fmt.Errorf("Custom inventory collector %v is not trusted, error %v. continue...", collectorPath, err))
+			continue
+		}
+
+		var content []byte
+		if content, err = readFileFunc(schemaPath); err != nil {
+			LogError(log, fmt.Errorf("Failed to read file: %v, error: %v. continue...", schemaPath, err))
+			continue
+		}
+		var schema model.CustomInventorySchema
+		if err = json.Unmarshal(content, &schema); err == nil {
+			err = validateCollectorSchema(log, schema)
+		}
+		if err != nil {
+			LogError(log, fmt.Errorf("Invalid custom inventory schema %v, error: %v. continue...", schemaPath, err))
+			continue
+		}
+		collectors = append(collectors, collector{path: collectorPath, schema: schema})
+	}
+	return collectors, nil
+}
+
+// validateCollectorSchema validates the custom inventory type declared for a collector
+func validateCollectorSchema(log log.T, schema model.CustomInventorySchema) (err error) {
+	declaredItem := model.CustomInventoryItem{TypeName: schema.TypeName, SchemaVersion: schema.SchemaVersion}
+	if err = validateTypeName(log, declaredItem); err != nil {
+		return
+	}
+	if err = validateSchemaVersion(log, declaredItem); err != nil {
+		return
+	}
+	if len(schema.Attributes) == 0 {
+		return errors.New("Custom inventory schema declares no attribute")
+	}
+	if len(schema.Attributes) > AttributeCountLimit {
+		return fmt.Errorf("Custom inventory schema declares %v attributes, exceed the limit %v",
+			len(schema.Attributes), AttributeCountLimit)
+	}
+	declared := make(map[string]bool)
+	for _, attribute := range schema.Attributes {
+		if attribute.Name == "" || len(attribute.Name) > AttributeNameLengthLimit {
+			return fmt.Errorf("Custom inventory schema attribute name (%v) length must be between 1 and %v",
+				attribute.Name, AttributeNameLengthLimit)
+		}
+		if declared[attribute.Name] {
+			return fmt.Errorf("Custom inventory schema declares attribute %v more than once", attribute.Name)
+		}
+		declared[attribute.Name] = true
+	}
+	return nil
+}
+
+// getItemFromCollector runs one collector and validates its output against its schema
+func getItemFromCollector(log log.T, c collector) (item model.Item, err error) {
+	var output []byte
+	if output, err = runCollectorFunc(log, c.path); err != nil {
+		return
+	}
+
+	// The collector writes the content of the custom inventory type, a json object or array of objects
+	var content interface{}
+	if err = json.Unmarshal(output, &content); err != nil {
+		return item, fmt.Errorf("Custom inventory collector output is not a valid json: %v", err)
+	}
+	if item, err = validateItem(log, model.CustomInventoryItem{
+		TypeName:      c.schema.TypeName,
+		SchemaVersion: c.schema.SchemaVersion,
+		Content:       content,
+	}); err != nil {
+		return
+	}
+
+	entries, _ := item.Content.([]map[string]interface{})
+	for _, entry := range entries {
+		if err = validateEntryAgainstSchema(entry, c.schema); err != nil {
+			return model.Item{}, err
+		}
+	}
+	return
+}
+
+// validateEntryAgainstSchema validates an entry only has the attributes declared by the schema and all the required ones
+func validateEntryAgainstSchema(entry map[string]interface{}, schema model.CustomInventorySchema) error {
+	declared := make(map[string]bool)
+	for _, attribute := range schema.Attributes {
+		declared[attribute.Name] = true
+		if _, found := entry[attribute.Name]; attribute.Required && !found {
+			return fmt.Errorf("Custom inventory entry misses required attribute %v of %v", attribute.Name, schema.TypeName)
+		}
+	}
+	for name := range entry {
+		if !declared[name] {
+			return fmt.Errorf("Custom inventory entry has attribute %v not declared by the schema of %v", name, schema.TypeName)
+		}
+	}
+	return nil
+}
+
+// runCollector runs a collector and returns its standard output, the collector is killed when it exceeds CollectorTimeout
+func runCollector(log log.T, collectorPath string) (output []byte, err error) {
+	command := collectorCommand(collectorPath)
+	command.Dir = filepath.Dir(collectorPath)
+	stdout := &limitedBuffer{limit: CollectorOutputSizeLimit}
+	stderr := &limitedBuffer{limit: AttributeValueLengthLimit}
+	command.Stdout = stdout
+	command.Stderr = stderr
+
+	log.Infof("Running custom inventory collector %v", collectorPath)
+	if err = command.Start(); err != nil {
+		return nil, err
+	}
+	timer := time.AfterFunc(CollectorTimeout, func() {
+		if killErr := killCollector(command.Process); killErr != nil {
+			log.Warnf("Failed to kill custom inventory collector %v: %v", collectorPath, killErr)
+		}
+	})
+	err = command.Wait()
+	if !timer.Stop() {
+		return nil, fmt.Errorf("Custom inventory collector timed out after %v", CollectorTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Custom inventory collector failed: %v, stderr: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.exceeded {
+		return nil, fmt.Errorf("Custom inventory collector output exceeded the limit of %v bytes", CollectorOutputSizeLimit)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+// Write writes p to the buffer until the limit is reached and discards the rest
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.exceeded = true
+		b.Buffer.Write(p[:remaining])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package custom
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockCollectorSchema() model.CustomInventorySchema {
+	return model.CustomInventorySchema{
+		TypeName:      "Custom:WebServer",
+		SchemaVersion: "1.0",
+		Attributes: []model.CustomInventoryAttribute{
+			{Name: "Name", Required: true},
+			{Name: "Port", Required: true},
+			{Name: "Version"},
+		},
+	}
+}
+
+func MockReadCollectorDir(dirname string) (files []os.FileInfo, err error) {
+	for _, f := range []MockFileInfo{
+		{name: "webserver.schema.json", mode: 0644},
+		{name: "webserver.sh", mode: 0755},
+		{name: "orphan.schema.json", mode: 0644},
+		{name: "README", mode: 0644},
+	} {
+		f.modTime = time.Now()
+		files = append(files, f)
+	}
+	return
+}
+
+func MockReadCollectorSchema(filename string) ([]byte, error) {
+	if strings.HasSuffix(filename, CollectorSchemaFileSuffix) {
+		return json.Marshal(MockCollectorSchema())
+	}
+	return MockReadFile(filename)
+}
+
+func mockCollectorOutput(output string, err error) func(log log.T, collectorPath string) ([]byte, error) {
+	return func(log log.T, collectorPath string) ([]byte, error) {
+		return []byte(output), err
+	}
+}
+
+func setCollectorMocks(readDir func(string) ([]os.FileInfo, error), run func(log.T, string) ([]byte, error)) func() {
+	readFileFunc = MockReadCollectorSchema
+	readDirFunc = MockReadDir
+	readCollectorDirFunc = readDir
+	runCollectorFunc = run
+	machineIDProvider = mockMachineIDProvider
+	return func() {
+		readCollectorDirFunc = ReadDir
+		runCollectorFunc = runCollector
+	}
+}
+
+func TestGathererWithCollector(t *testing.T) {
+	defer setCollectorMocks(MockReadCollectorDir, mockCollectorOutput(`[{"Name": "web1", "Port": "80", "Version": "2.4"}, {"Name": "web2", "Port": "8080"}]`, nil))()
+	c := context.NewMockDefault()
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(items), "Custom Gather should return the file and the collector inventory types.")
+	assert.Equal(t, "Custom:WebServer", items[1].Name)
+	assert.Equal(t, "1.0", items[1].SchemaVersion)
+	assert.Equal(t, []map[string]interface{}{
+		{"Name": "web1", "Port": "80", "Version": "2.4"},
+		{"Name": "web2", "Port": "8080"},
+	}, items[1].Content)
+}
+
+func TestCollectorOutputNotMatchingSchema(t *testing.T) {
+	outputs := []string{
+		`not json`,
+		`[{"Name": "web1"}]`,
+		`{"Name": "web1", "Port": "80", "Owner": "me"}`,
+		`{"Name": "web1", "Port": 80}`,
+	}
+	for _, output := range outputs {
+		restore := setCollectorMocks(MockReadCollectorDir, mockCollectorOutput(output, nil))
+		c := context.NewMockDefault()
+
+		items, err := Gatherer(c).Run(c, model.Config{})
+		assert.NoError(t, err, "err should be nil as gatherer continues with other custom inventory")
+		assert.Equal(t, 1, len(items), "output %v should be rejected", output)
+		restore()
+	}
+}
+
+func TestCollectorFailed(t *testing.T) {
+	defer setCollectorMocks(MockReadCollectorDir, mockCollectorOutput("", errors.New("exit status 1")))()
+	c := context.NewMockDefault()
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+}
+
+func TestCollectorDuplicateTypeName(t *testing.T) {
+	defer setCollectorMocks(MockReadCollectorDir, mockCollectorOutput(`{"Name": "web1", "Port": "80"}`, nil))()
+	readFileFunc = func(filename string) ([]byte, error) {
+		if strings.HasSuffix(filename, CollectorSchemaFileSuffix) {
+			schema := MockCollectorSchema()
+			schema.TypeName = MockCustomInventoryItem().TypeName
+			return json.Marshal(schema)
+		}
+		return MockReadFile(filename)
+	}
+	c := context.NewMockDefault()
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items), "the collector type duplicating a custom inventory file should be ignored")
+}
+
+func TestCollectorCountExceed(t *testing.T) {
+	readDir := func(dirname string) (files []os.FileInfo, err error) {
+		for i := 0; i < CustomInventoryCountLimit; i++ {
+			name := string(rune('a' + i))
+			files = append(files, MockFileInfo{name: name + CollectorSchemaFileSuffix, mode: 0644}, MockFileInfo{name: name, mode: 0755})
+		}
+		return
+	}
+	defer setCollectorMocks(readDir, mockCollectorOutput(`{"Name": "web1", "Port": "80"}`, nil))()
+	c := context.NewMockDefault()
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Total custom inventory file and collector count")
+	assert.Nil(t, items)
+}
+
+func TestGetCollectors(t *testing.T) {
+	defer setCollectorMocks(MockReadCollectorDir, nil)()
+
+	collectors, err := getCollectors(log.NewMockLog(), "collectors")
+	assert.NoError(t, err)
+	assert.Equal(t, []collector{{path: filepath.Join("collectors", "webserver.sh"), schema: MockCollectorSchema()}}, collectors)
+}
+
+func TestValidateCollectorSchema(t *testing.T) {
+	logger := log.NewMockLog()
+	assert.NoError(t, validateCollectorSchema(logger, MockCollectorSchema()))
+
+	invalidSchemas := []func(schema *model.CustomInventorySchema){
+		func(schema *model.CustomInventorySchema) { schema.TypeName = "WebServer" },
+		func(schema *model.CustomInventorySchema) { schema.SchemaVersion = "1" },
+		func(schema *model.CustomInventorySchema) { schema.Attributes = nil },
+		func(schema *model.CustomInventorySchema) { schema.Attributes[1].Name = "" },
+		func(schema *model.CustomInventorySchema) { schema.Attributes[1].Name = "Name" },
+		func(schema *model.CustomInventorySchema) {
+			schema.Attributes[0].Name = strings.Repeat("a", AttributeNameLengthLimit+1)
+		},
+	}
+	for _, invalidate := range invalidSchemas {
+		schema := MockCollectorSchema()
+		invalidate(&schema)
+		assert.Error(t, validateCollectorSchema(logger, schema), "schema %+v should be invalid", schema)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buffer := &limitedBuffer{limit: 5}
+	n, err := buffer.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, buffer.exceeded)
+
+	n, err = buffer.Write([]byte("defg"))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.True(t, buffer.exceeded)
+	assert.Equal(t, "abcde", buffer.String())
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package custom
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// collectorCommand returns the command running a collector in its own process group
+func collectorCommand(collectorPath string) *exec.Cmd {
+	command := exec.Command(collectorPath)
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return command
+}
+
+// killCollector kills the process group of a collector, so that its sub processes are killed too
+func killCollector(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
+
+// validateCollectorFiles validates a collector is executable and that neither the collector nor its schema
+// can be modified by other users than their owner
+func validateCollectorFiles(schema os.FileInfo, executable os.FileInfo) error {
+	if executable.Mode()&0111 == 0 {
+		return fmt.Errorf("%v is not executable", executable.Name())
+	}
+	for _, f := range []os.FileInfo{schema, executable} {
+		if f.Mode()&0022 != 0 {
+			return fmt.Errorf("%v is writable by group or others, mode %v", f.Name(), f.Mode())
+		}
+	}
+	return nil
+}
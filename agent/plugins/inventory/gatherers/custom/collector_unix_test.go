@@ -0,0 +1,60 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package custom
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCollector(t *testing.T, script string) (collectorPath string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "collectors")
+	assert.NoError(t, err)
+	collectorPath = filepath.Join(dir, "collector.sh")
+	assert.NoError(t, ioutil.WriteFile(collectorPath, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return collectorPath, func() { os.RemoveAll(dir) }
+}
+
+func TestRunCollector(t *testing.T) {
+	collectorPath, cleanup := writeCollector(t, `echo '{"Name": "'$(basename $(pwd))'"}'`)
+	defer cleanup()
+
+	output, err := runCollector(log.NewMockLog(), collectorPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Name": "`+filepath.Base(filepath.Dir(collectorPath))+`"}`+"\n", string(output))
+}
+
+func TestRunCollectorFailed(t *testing.T) {
+	collectorPath, cleanup := writeCollector(t, "echo 'no access' >&2; exit 3")
+	defer cleanup()
+
+	_, err := runCollector(log.NewMockLog(), collectorPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no access")
+}
+
+func TestValidateCollectorFiles(t *testing.T) {
+	schema := MockFileInfo{name: "webserver.schema.json", mode: 0644}
+	assert.NoError(t, validateCollectorFiles(schema, MockFileInfo{name: "webserver", mode: 0750}))
+	assert.Error(t, validateCollectorFiles(schema, MockFileInfo{name: "webserver", mode: 0644}))
+	assert.Error(t, validateCollectorFiles(schema, MockFileInfo{name: "webserver", mode: 0777}))
+	assert.Error(t, validateCollectorFiles(MockFileInfo{name: "webserver.schema.json", mode: 0666}, MockFileInfo{name: "webserver", mode: 0755}))
+}
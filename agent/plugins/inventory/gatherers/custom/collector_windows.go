@@ -0,0 +1,43 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package custom
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// collectorCommand returns the command running a collector, PowerShell scripts are run by powershell.exe
+func collectorCommand(collectorPath string) *exec.Cmd {
+	if strings.EqualFold(filepath.Ext(collectorPath), ".ps1") {
+		return exec.Command(appconfig.PowerShellPluginCommandName, "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", collectorPath)
+	}
+	return exec.Command(collectorPath)
+}
+
+// killCollector kills the process of a collector
+func killCollector(process *os.Process) error {
+	return process.Kill()
+}
+
+// validateCollectorFiles relies on the access control list of the collectors folder on windows
+func validateCollectorFiles(schema os.FileInfo, executable os.FileInfo) error {
+	return nil
+}
@@ -147,6 +147,23 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 		}
 	}
 
+	// Get custom inventory items from the collectors
+	collectorItems, err := getCollectorItems(log, collectorsFolder(context), CustomInventoryCountLimit-len(fileList))
+	if err != nil {
+		return nil, err
+	}
+	for _, collectorItem := range collectorItems {
+		if _, ok := setTypeName[collectorItem.Name]; ok {
+			LogError(log, fmt.Errorf("Custom inventory typeName (%v) of a collector already exists,"+
+				" i.e., a custom inventory file or another collector contains the same typeName,"+
+				" please remove the duplicate custom inventory collector.",
+				collectorItem.Name))
+		} else {
+			setTypeName[collectorItem.Name] = true
+			items = append(items, collectorItem)
+		}
+	}
+
 	count := len(items)
 	log.Debugf("Count of custom inventory items : %v.", count)
 	if count == 0 {
@@ -190,6 +207,11 @@ func convertToItem(log log.T, content []byte) (item model.Item, err error) {
 		LogError(log, err)
 		return
 	}
+	return validateItem(log, customInventoryItem)
+}
+
+// validateItem Validates custom inventory item's schema and convert to inventory.Item
+func validateItem(log log.T, customInventoryItem model.CustomInventoryItem) (item model.Item, err error) {
 
 	if err = validateTypeName(log, customInventoryItem); err != nil {
 		return
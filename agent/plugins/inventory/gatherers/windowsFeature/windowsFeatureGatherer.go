@@ -0,0 +1,97 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package windowsFeature contains a windows feature gatherer.
+package windowsFeature
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/wmi"
+)
+
+const (
+	// GathererName represents name of windows feature gatherer
+	GathererName = "AWS:WindowsFeature"
+
+	schemaVersionOfWindowsFeature = "1.0"
+	// Win32_OptionalFeature is available on both client and server versions of windows, InstallState 1 is Enabled
+	windowsFeatureQueryCmd = `
+  [Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+  Get-WmiObject -Class Win32_OptionalFeature -Filter "InstallState = 1" | ForEach-Object {
+    $_ | Select-Object Name,@{l="DisplayName";e={$_.Caption}} | ConvertTo-Json -Compress
+  }`
+)
+
+// T represents windows feature gatherer
+type T struct{}
+
+// Gatherer returns new windows feature gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+// Name returns name of windows feature gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// decouple wmi.Query for unit test
+var wmiQuery = wmi.Query
+
+// Run executes windows feature gatherer and returns list of inventory.Item comprising of the enabled windows features
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	log := context.Log()
+	var data []model.WindowsFeatureData
+	var partial bool
+	if partial, err = wmiQuery(log, windowsFeatureQueryCmd, wmi.QueryTimeout, &data); err != nil {
+		log.Errorf("Unable to fetch windows features - %v", err.Error())
+		return
+	}
+	if partial && len(data) == 0 {
+		log.Warnf("No windows feature fetched before the WMI query timed out, skipping %v", t.Name())
+		return
+	}
+
+	//WMI doesn't guarantee the order of the features, sort them to keep the content hash stable
+	sort.Slice(data, func(i, j int) bool { return data[i].Name < data[j].Name })
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z or else it will throw error
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	result := model.Item{
+		Name:          t.Name(),
+		SchemaVersion: schemaVersionOfWindowsFeature,
+		Content:       data,
+		CaptureTime:   captureTime,
+	}
+	if partial {
+		log.Warnf("%v windows features found before the WMI query timed out, the inventory is partial", len(data))
+	} else {
+		log.Infof("%v windows features found", len(data))
+	}
+	log.Debugf("feature info = %+v", result)
+	items = append(items, result)
+	return
+}
+
+// RequestStop stops the execution of windows feature gatherer
+func (t *T) RequestStop(stopType contracts.StopType) error {
+	var err error
+	return err
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package windowsFeature
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFeatures = []model.WindowsFeatureData{
+	{
+		Name:        "IIS-WebServer",
+		DisplayName: "World Wide Web Services",
+	},
+	{
+		Name:        "NetFx4-AdvSrvs",
+		DisplayName: ".NET Framework 4.8 Advanced Services",
+	},
+}
+
+func testWmiQuery(features []model.WindowsFeatureData, partial bool, err error) func(log.T, string, time.Duration, interface{}) (bool, error) {
+	return func(log log.T, script string, timeout time.Duration, result interface{}) (bool, error) {
+		output, _ := json.Marshal(features)
+		json.Unmarshal(output, result)
+		return partial, err
+	}
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery([]model.WindowsFeatureData{testFeatures[1], testFeatures[0]}, false, nil)
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(item))
+	assert.Equal(t, GathererName, item[0].Name)
+	assert.Equal(t, schemaVersionOfWindowsFeature, item[0].SchemaVersion)
+	assert.Equal(t, testFeatures, item[0].Content)
+}
+
+func TestGathererPartial(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(testFeatures[:1], true, nil)
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(item))
+	assert.Equal(t, testFeatures[:1], item[0].Content)
+}
+
+func TestGathererTimedOutWithoutFeature(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(nil, true, nil)
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, item)
+}
+
+func TestGathererError(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(nil, false, errors.New("WMI query failed"))
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Empty(t, item)
+}
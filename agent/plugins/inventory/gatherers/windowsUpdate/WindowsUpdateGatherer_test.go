@@ -13,45 +13,44 @@
 package windowsUpdate
 
 import (
-	"testing"
-
 	"encoding/json"
+	"errors"
+	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/stretchr/testify/assert"
 )
 
 var testUpdate = []model.WindowsUpdateData{
-	{
-		HotFixId:      "KB000001",
-		Description:   "Security Update",
-		InstalledTime: "Wednesday, October 15, 2014 12:00:00 AM",
-		InstalledBy:   "ADMINISTRATOR",
-	},
 	{
 		HotFixId:      "KB000002",
 		Description:   "Update",
-		InstalledTime: "Friday, June 20, 2014 12:00:00 AM",
+		InstalledTime: "2014-10-15T00:00:00Z",
 		InstalledBy:   "NT AUTHORITY SYSTEM",
 	},
+	{
+		HotFixId:      "KB000001",
+		Description:   "Security Update",
+		InstalledTime: "2014-06-20T00:00:00Z",
+		InstalledBy:   "ADMINISTRATOR",
+	},
 }
 
-func testExecuteCommand(command string, args ...string) ([]byte, error) {
-
-	output, _ := json.Marshal(testUpdate)
-	return output, nil
-}
-
-func testExecuteCommandEmpty(command string, args ...string) ([]byte, error) {
-
-	return make([]byte, 0), nil
+func testWmiQuery(updates []model.WindowsUpdateData, partial bool, err error) func(log.T, string, time.Duration, interface{}) (bool, error) {
+	return func(log log.T, script string, timeout time.Duration, result interface{}) (bool, error) {
+		output, _ := json.Marshal(updates)
+		json.Unmarshal(output, result)
+		return partial, err
+	}
 }
 
 func TestGatherer(t *testing.T) {
 	contextMock := context.NewMockDefault()
 	gatherer := Gatherer(contextMock)
-	cmdExecutor = testExecuteCommand
+	wmiQuery = testWmiQuery([]model.WindowsUpdateData{testUpdate[1], testUpdate[0]}, false, nil)
 	item, err := gatherer.Run(contextMock, model.Config{})
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(item))
@@ -63,7 +62,7 @@ func TestGatherer(t *testing.T) {
 func TestGathererEmpty(t *testing.T) {
 	contextMock := context.NewMockDefault()
 	gatherer := Gatherer(contextMock)
-	cmdExecutor = testExecuteCommandEmpty
+	wmiQuery = testWmiQuery(nil, false, nil)
 	var expectContent []model.WindowsUpdateData
 	item, err := gatherer.Run(contextMock, model.Config{})
 	assert.Nil(t, err)
@@ -72,3 +71,31 @@ func TestGathererEmpty(t *testing.T) {
 	assert.Equal(t, schemaVersionOfWindowsUpdate, item[0].SchemaVersion)
 	assert.Equal(t, expectContent, item[0].Content)
 }
+
+func TestGathererPartial(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(testUpdate[:1], true, nil)
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(item))
+	assert.Equal(t, testUpdate[:1], item[0].Content)
+}
+
+func TestGathererTimedOutWithoutUpdate(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(nil, true, nil)
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, item)
+}
+
+func TestGathererError(t *testing.T) {
+	contextMock := context.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	wmiQuery = testWmiQuery(nil, false, errors.New("WMI query failed"))
+	item, err := gatherer.Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Empty(t, item)
+}
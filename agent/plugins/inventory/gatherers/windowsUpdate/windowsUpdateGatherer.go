@@ -14,13 +14,13 @@ package windowsUpdate
 // permissions and limitations under the License.
 
 import (
-	"encoding/json"
-	"os/exec"
+	"sort"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/wmi"
 )
 
 const (
@@ -28,10 +28,11 @@ const (
 	GathererName = "AWS:WindowsUpdate"
 
 	schemaVersionOfWindowsUpdate = "1.0"
-	cmd                          = "powershell"
 	windowsUpdateQueryCmd        = `
   [Console]::OutputEncoding = [System.Text.Encoding]::UTF8
-  Get-WmiObject -Class win32_quickfixengineering | Select-Object HotFixId,Description,@{l="InstalledTime";e={[DateTime]::Parse($_.psbase.properties["installedon"].value,$([System.Globalization.CultureInfo]::GetCultureInfo("en-US"))).ToUniversalTime().ToString("yyyy-MM-ddTHH:mm:ssZ")}},InstalledBy | sort InstalledTime -desc | ConvertTo-Json`
+  Get-WmiObject -Class win32_quickfixengineering | ForEach-Object {
+    $_ | Select-Object HotFixId,Description,@{l="InstalledTime";e={[DateTime]::Parse($_.psbase.properties["installedon"].value,$([System.Globalization.CultureInfo]::GetCultureInfo("en-US"))).ToUniversalTime().ToString("yyyy-MM-ddTHH:mm:ssZ")}},InstalledBy | ConvertTo-Json -Compress
+  }`
 )
 
 // T represents windows update gatherer
@@ -47,35 +48,42 @@ func (t *T) Name() string {
 	return GathererName
 }
 
-// decouple exec.Command for unit test
-var cmdExecutor = executeCommand
+// decouple wmi.Query for unit test
+var wmiQuery = wmi.Query
 
 // Run executes windows update gatherer and returns list of inventory.Item
 func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
-	var result model.Item
 	log := context.Log()
 	var data []model.WindowsUpdateData
-	out, err := cmdExecutor(cmd, windowsUpdateQueryCmd)
-	if err == nil {
-		//If there is no windows update in instance, will return empty result instead of throwing error
-		if len(out) != 0 {
-			err = json.Unmarshal(out, &data)
-		}
-		//CaptureTime must comply with format: 2016-07-30T18:15:37Z or else it will throw error
-		currentTime := time.Now().UTC()
-		captureTime := currentTime.Format(time.RFC3339)
+	var partial bool
+	if partial, err = wmiQuery(log, windowsUpdateQueryCmd, wmi.QueryTimeout, &data); err != nil {
+		log.Errorf("Unable to fetch windows update - %v", err.Error())
+		return
+	}
+	if partial && len(data) == 0 {
+		log.Warnf("No windows update fetched before the WMI query timed out, skipping %v", t.Name())
+		return
+	}
 
-		result = model.Item{
-			Name:          t.Name(),
-			SchemaVersion: schemaVersionOfWindowsUpdate,
-			Content:       data,
-			CaptureTime:   captureTime,
-		}
-		log.Infof("%v windows update found", len(data))
-		log.Debugf("update info = %+v", result)
+	//Most recent updates first, InstalledTime has format: 2016-07-30T18:15:37Z
+	sort.SliceStable(data, func(i, j int) bool { return data[i].InstalledTime > data[j].InstalledTime })
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z or else it will throw error
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	result := model.Item{
+		Name:          t.Name(),
+		SchemaVersion: schemaVersionOfWindowsUpdate,
+		Content:       data,
+		CaptureTime:   captureTime,
+	}
+	if partial {
+		log.Warnf("%v windows update found before the WMI query timed out, the inventory is partial", len(data))
 	} else {
-		log.Errorf("Unable to fetch windows update - %v %v", err.Error(), string(out))
+		log.Infof("%v windows update found", len(data))
 	}
+	log.Debugf("update info = %+v", result)
 	items = append(items, result)
 	return
 }
@@ -85,7 +93,3 @@ func (t *T) RequestStop(stopType contracts.StopType) error {
 	var err error
 	return err
 }
-
-func executeCommand(command string, args ...string) ([]byte, error) {
-	return exec.Command(command, args...).CombinedOutput()
-}
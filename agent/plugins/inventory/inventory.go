@@ -33,6 +33,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/container"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
@@ -40,6 +41,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registry"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/role"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsFeature"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
@@ -57,6 +59,7 @@ const (
 	errorMsgForUnableToDetectInvocationType   = "it could not be detected if %v plugin was invoked via ssm-associate because - %v"
 	errorMsgForInabilityToSendDataToSSM       = "inventory data could not be uploaded to Systems Manager. Additional troubleshooting information - %v"
 	msgWhenNoDataToReturnForInventoryPlugin   = "Inventory policy has been successfully applied but there is no inventory data to upload to SSM"
+	msgWhenInventoryDataIsUnchanged           = "Inventory policy has been successfully applied and collected inventory data is unchanged since the last upload to SSM"
 	successfulMsgForInventoryPlugin           = "Inventory policy has been successfully applied and collected inventory data has been uploaded to SSM"
 )
 
@@ -71,9 +74,11 @@ type PluginInput struct {
 	Services                    string
 	WindowsRegistry             string
 	WindowsUpdates              string
+	WindowsFeatures             string
 	InstanceDetailedInformation string
 	CustomInventory             string
 	CustomInventoryDirectory    string
+	Containers                  string
 }
 
 // decoupling platform.InstanceID for easy testability
@@ -185,6 +190,14 @@ func (p *Plugin) ApplyInventoryPolicy(context context.T, inventoryInput PluginIn
 		optimizedInventoryItems,
 		nonOptimizedInventoryItems)
 
+	//unchanged inventory types are not uploaded again - no need to call PutInventory API when none changed
+	if len(optimizedInventoryItems) == 0 {
+		log.Info(msgWhenInventoryDataIsUnchanged)
+		output.SetExitCode(0)
+		output.AppendInfo(msgWhenInventoryDataIsUnchanged)
+		return
+	}
+
 	//first send data in optimized fashion
 	if err = p.uploader.SendDataToSSM(context, optimizedInventoryItems); err != nil {
 
@@ -381,7 +394,9 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		service.GathererName:                     input.Services,
 		network.GathererName:                     input.NetworkConfig,
 		windowsUpdate.GathererName:               input.WindowsUpdates,
+		windowsFeature.GathererName:              input.WindowsFeatures,
 		instancedetailedinformation.GathererName: input.InstanceDetailedInformation,
+		container.GathererName:                   input.Containers,
 	}
 
 	predefinedGatherersWithFilters := map[string]string{
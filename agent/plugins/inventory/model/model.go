@@ -158,6 +158,39 @@ type WindowsUpdateData struct {
 	InstalledBy   string
 }
 
+// WindowsFeatureData captures all attributes present in AWS:WindowsFeature inventory type
+type WindowsFeatureData struct {
+	Name        string
+	DisplayName string
+}
+
+// ContainerData captures all attributes present in AWS:Container inventory type
+type ContainerData struct {
+	// SSM Inventory expects it ContainerId and not ContainerID
+	ContainerId string
+	Name        string
+	Image       string
+	ImageId     string `json:",omitempty"`
+	Command     string `json:",omitempty"`
+	State       string
+	Status      string `json:",omitempty"`
+	CreatedTime string `json:",omitempty"`
+	Ports       string `json:",omitempty"`
+	Runtime     string
+}
+
+// ContainerImageData captures all attributes present in AWS:ContainerImage inventory type
+type ContainerImageData struct {
+	// SSM Inventory expects it ImageId and not ImageID
+	ImageId     string
+	Repository  string
+	Tag         string
+	Digest      string `json:",omitempty"`
+	Size        string
+	CreatedTime string `json:",omitempty"`
+	Runtime     string
+}
+
 // InstanceDetailedInformation captures all attributes present in AWS:InstanceDetailedInformation inventory type
 type InstanceDetailedInformation struct {
 	CPUModel              string
@@ -190,6 +223,19 @@ type CustomInventoryItem struct {
 	Content       interface{}
 }
 
+// CustomInventorySchema declares the custom inventory type produced by a custom inventory collector
+type CustomInventorySchema struct {
+	TypeName      string
+	SchemaVersion string
+	Attributes    []CustomInventoryAttribute
+}
+
+// CustomInventoryAttribute declares an attribute of the entries of a custom inventory type
+type CustomInventoryAttribute struct {
+	Name     string
+	Required bool
+}
+
 // FormatArchitecture converts different architecture values to the standard inventory value
 func FormatArchitecture(arch string) string {
 	arch = strings.ToLower(strings.TrimSpace(arch))
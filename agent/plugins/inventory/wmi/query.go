@@ -0,0 +1,96 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package wmi runs the WMI queries of inventory gatherers with a bounded timeout.
+package wmi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// PowershellCmd represents the command running the WMI queries
+	PowershellCmd = "powershell"
+	// QueryTimeout represents how long the WMI query of an inventory gatherer can run
+	QueryTimeout = 2 * time.Minute
+)
+
+// decoupling for easy testability
+var runPowershellFunc = runPowershell
+
+// Query runs script, a PowerShell script writing one compressed json object per line for every WMI object it
+// enumerates, and unmarshals the objects into result, a pointer to a slice. A script running longer than timeout is
+// killed, result then holds the objects written before the timeout and partial is true, so that a hung WMI provider
+// doesn't block the inventory collection.
+func Query(log log.T, script string, timeout time.Duration, result interface{}) (partial bool, err error) {
+	var output []byte
+	if output, partial, err = runPowershellFunc(script, timeout); err != nil {
+		return
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			if partial {
+				// the last object may have been cut off when the script was killed
+				log.Debugf("Discarding incomplete WMI query output - %v", line)
+				continue
+			}
+			return false, fmt.Errorf("Unable to parse WMI query output - %v", line)
+		}
+		entries = append(entries, line)
+	}
+	if partial {
+		log.Warnf("WMI query timed out after %v, %v objects were returned before the timeout", timeout, len(entries))
+	}
+
+	if err = json.Unmarshal([]byte("["+strings.Join(entries, ",")+"]"), result); err != nil {
+		err = fmt.Errorf("Unable to parse WMI query output - %v", err)
+	}
+	return
+}
+
+// runPowershell runs script and returns its standard output, timedOut is true when the script was killed for running
+// longer than timeout.
+func runPowershell(script string, timeout time.Duration) (output []byte, timedOut bool, err error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(PowershellCmd, script)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	if err = command.Start(); err != nil {
+		return
+	}
+	timer := time.AfterFunc(timeout, func() {
+		command.Process.Kill()
+	})
+	err = command.Wait()
+	if !timer.Stop() {
+		return stdout.Bytes(), true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("WMI query failed: %v, stderr: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), false, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wmi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject struct {
+	Name string
+}
+
+func testRunPowershell(output string, timedOut bool, err error) func(string, time.Duration) ([]byte, bool, error) {
+	return func(script string, timeout time.Duration) ([]byte, bool, error) {
+		return []byte(output), timedOut, err
+	}
+}
+
+func TestQuery(t *testing.T) {
+	runPowershellFunc = testRunPowershell("{\"Name\":\"a\"}\r\n\r\n{\"Name\":\"b\"}\r\n", false, nil)
+	var result []testObject
+	partial, err := Query(log.NewMockLog(), "script", QueryTimeout, &result)
+	assert.Nil(t, err)
+	assert.False(t, partial)
+	assert.Equal(t, []testObject{{Name: "a"}, {Name: "b"}}, result)
+}
+
+func TestQueryEmpty(t *testing.T) {
+	runPowershellFunc = testRunPowershell("", false, nil)
+	var result []testObject
+	partial, err := Query(log.NewMockLog(), "script", QueryTimeout, &result)
+	assert.Nil(t, err)
+	assert.False(t, partial)
+	assert.Empty(t, result)
+}
+
+func TestQueryInvalidOutput(t *testing.T) {
+	runPowershellFunc = testRunPowershell("{\"Name\":\"a\"}\r\n{\"Name\":", false, nil)
+	var result []testObject
+	_, err := Query(log.NewMockLog(), "script", QueryTimeout, &result)
+	assert.NotNil(t, err)
+}
+
+func TestQueryTimedOut(t *testing.T) {
+	runPowershellFunc = testRunPowershell("{\"Name\":\"a\"}\r\n{\"Name\":", true, nil)
+	var result []testObject
+	partial, err := Query(log.NewMockLog(), "script", QueryTimeout, &result)
+	assert.Nil(t, err)
+	assert.True(t, partial)
+	assert.Equal(t, []testObject{{Name: "a"}}, result)
+}
+
+func TestQueryError(t *testing.T) {
+	runPowershellFunc = testRunPowershell("", false, errors.New("WMI query failed"))
+	var result []testObject
+	_, err := Query(log.NewMockLog(), "script", QueryTimeout, &result)
+	assert.NotNil(t, err)
+}
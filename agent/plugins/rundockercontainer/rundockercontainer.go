@@ -0,0 +1,264 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package rundockercontainer implements the aws:runDockerContainer plugin
+package rundockercontainer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// PullPolicy values
+	PullAlways  = "Always"
+	PullMissing = "Missing"
+	PullNever   = "Never"
+)
+
+const (
+	dockerCommand = "docker"
+
+	// removeTimeoutInSeconds is the timeout of the command removing the container
+	removeTimeoutInSeconds = 60
+)
+
+var (
+	validImage       = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-/:@]*$`)
+	validEnvName     = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	validNetworkMode = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:\-]*$`)
+	validUser        = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+(:[a-zA-Z0-9_.\-]+)?$`)
+
+	// invalidNameCharacters matches the characters docker does not accept in container names
+	invalidNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.\-]+`)
+)
+
+// Plugin is the type for the aws:runDockerContainer plugin.
+type Plugin struct {
+	// CommandExecuter runs the docker commands.
+	CommandExecuter executers.T
+}
+
+// RunDockerContainerPluginInput represents the container run by the aws:runDockerContainer plugin
+type RunDockerContainerPluginInput struct {
+	contracts.PluginInput
+	// Image is the image the container runs
+	Image string `json:"image"`
+	// Command overrides the command of the image
+	Command []string `json:"command"`
+	// Env are the environment variables of the container
+	Env map[string]string `json:"env"`
+	// Mounts are the volumes of the container, in the docker source:destination[:options] format
+	Mounts []string `json:"mounts"`
+	// NetworkMode is the network of the container, such as host or none
+	NetworkMode string `json:"networkMode"`
+	// User and WorkingDirectory override the user and working directory of the image
+	User             string `json:"user"`
+	WorkingDirectory string `json:"workingDirectory"`
+	// PullPolicy is Always, Missing or Never, images are pulled when they are missing by default
+	PullPolicy     string      `json:"pullPolicy"`
+	TimeoutSeconds interface{} `json:"timeoutSeconds"`
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin() (*Plugin, error) {
+	var plugin Plugin
+	plugin.CommandExecuter = executers.ShellCommandExecuter{}
+	return &plugin, nil
+}
+
+// Name returns the plugin name
+func Name() string {
+	return appconfig.PluginRunDockerContainer
+}
+
+// Execute runs the container until it exits, its logs are the output of the plugin.
+func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	log.Infof("%v started", Name())
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else if input, err := parseAndValidateInput(config.Properties); err != nil {
+		output.MarkAsFailed(err)
+	} else {
+		p.runContainer(log, input, containerName(config), cancelFlag, output)
+	}
+}
+
+// runContainer pulls the image as requested and runs the container, the container is removed once it stopped,
+// timed out or was cancelled
+func (p *Plugin) runContainer(log log.T, input *RunDockerContainerPluginInput, name string, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	executionTimeout := pluginutil.ValidateExecutionTimeout(log, input.TimeoutSeconds)
+	deadline := time.Now().Add(time.Duration(executionTimeout) * time.Second)
+
+	// a container of an interrupted run of the plugin would conflict with the name
+	p.removeContainer(log, name)
+	defer p.removeContainer(log, name)
+
+	switch input.PullPolicy {
+	case PullAlways:
+		if !p.runDocker(log, cancelFlag, output, executionTimeout, "pull", input.Image) {
+			return
+		}
+	case PullNever:
+		if !p.runDocker(log, cancelFlag, output, executionTimeout, "image", "inspect", "--format", "{{.Id}}", input.Image) {
+			output.AppendErrorf("Image %v is not available and PullPolicy is %v", input.Image, PullNever)
+			return
+		}
+	}
+
+	// the pull counts towards the timeout of the plugin
+	remainingTimeout := int(time.Until(deadline) / time.Second)
+	if remainingTimeout < 1 {
+		remainingTimeout = 1
+	}
+	log.Infof("Running container %v from %v", name, input.Image)
+	p.runDocker(log, cancelFlag, output, remainingTimeout, runArguments(input, name)...)
+}
+
+// runDocker runs the docker command streaming its output and sets the status of the plugin from its exit code,
+// it returns whether the command succeeded
+func (p *Plugin) runDocker(log log.T, cancelFlag task.CancelFlag, output iohandler.IOHandler, executionTimeout int, arguments ...string) bool {
+	exitCode, err := p.CommandExecuter.NewExecute(log, "", output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, dockerCommand, arguments)
+
+	output.SetExitCode(exitCode)
+	output.SetStatus(pluginutil.GetStatus(exitCode, cancelFlag))
+
+	if err != nil {
+		status := output.GetStatus()
+		if status != contracts.ResultStatusCancelled &&
+			status != contracts.ResultStatusTimedOut &&
+			status != contracts.ResultStatusSuccessAndReboot {
+			output.MarkAsFailed(fmt.Errorf("failed to run docker %v: %v", arguments[0], err))
+		}
+		return false
+	}
+	return output.GetStatus() == contracts.ResultStatusSuccess
+}
+
+// removeContainer force removes the container, ignoring containers which do not exist
+func (p *Plugin) removeContainer(log log.T, name string) {
+	// the container is removed even when the plugin is cancelled
+	cancelFlag := task.NewChanneledCancelFlag()
+	defer cancelFlag.Set(task.Completed)
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := p.CommandExecuter.NewExecute(log, "", &stdout, &stderr, cancelFlag, removeTimeoutInSeconds, dockerCommand, []string{"rm", "--force", name})
+	if err != nil || exitCode != appconfig.SuccessExitCode {
+		log.Debugf("Container %v was not removed: %v %v", name, err, strings.TrimSpace(stderr.String()))
+	}
+}
+
+// runArguments returns the arguments of docker run, the container runs in the foreground so its logs are streamed
+func runArguments(input *RunDockerContainerPluginInput, name string) []string {
+	arguments := []string{"run", "--name", name}
+	if input.NetworkMode != "" {
+		arguments = append(arguments, "--network", input.NetworkMode)
+	}
+	if input.User != "" {
+		arguments = append(arguments, "--user", input.User)
+	}
+	if input.WorkingDirectory != "" {
+		arguments = append(arguments, "--workdir", input.WorkingDirectory)
+	}
+
+	names := make([]string, 0, len(input.Env))
+	for envName := range input.Env {
+		names = append(names, envName)
+	}
+	sort.Strings(names)
+	for _, envName := range names {
+		arguments = append(arguments, "--env", envName+"="+input.Env[envName])
+	}
+
+	for _, mount := range input.Mounts {
+		arguments = append(arguments, "--volume", mount)
+	}
+
+	arguments = append(arguments, input.Image)
+	return append(arguments, input.Command...)
+}
+
+// containerName returns the name of the container of the plugin, unique for the command and the step
+func containerName(config contracts.Configuration) string {
+	return "ssm-" + invalidNameCharacters.ReplaceAllString(config.MessageId+"-"+config.PluginID, "-")
+}
+
+// parseAndValidateInput parses the plugin properties and validates them
+func parseAndValidateInput(rawPluginInput interface{}) (*RunDockerContainerPluginInput, error) {
+	var input RunDockerContainerPluginInput
+	if err := jsonutil.Remarshal(rawPluginInput, &input); err != nil {
+		return nil, fmt.Errorf("invalid format in plugin properties %v; \nerror %v", rawPluginInput, err)
+	}
+
+	if err := validateInput(&input); err != nil {
+		return nil, fmt.Errorf("invalid input: %v", err)
+	}
+	return &input, nil
+}
+
+// validateInput ensures the plugin input matches the defined schema, options are never mistaken for values
+func validateInput(input *RunDockerContainerPluginInput) error {
+	if input.Image == "" {
+		return errors.New("Image must be specified")
+	}
+	if !validImage.MatchString(input.Image) {
+		return fmt.Errorf("invalid image %v", input.Image)
+	}
+	for name := range input.Env {
+		if !validEnvName.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %v", name)
+		}
+	}
+	for _, mount := range input.Mounts {
+		if mount == "" || strings.HasPrefix(mount, "-") {
+			return fmt.Errorf("invalid mount %v", mount)
+		}
+	}
+	if input.NetworkMode != "" && !validNetworkMode.MatchString(input.NetworkMode) {
+		return fmt.Errorf("invalid network mode %v", input.NetworkMode)
+	}
+	if input.User != "" && !validUser.MatchString(input.User) {
+		return fmt.Errorf("invalid user %v", input.User)
+	}
+	if strings.HasPrefix(input.WorkingDirectory, "-") {
+		return fmt.Errorf("invalid working directory %v", input.WorkingDirectory)
+	}
+	switch input.PullPolicy {
+	case "":
+		input.PullPolicy = PullMissing
+	case PullAlways, PullMissing, PullNever:
+	default:
+		return fmt.Errorf("PullPolicy is set to unsupported value: %v", input.PullPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package rundockercontainer implements the aws:runDockerContainer plugin
+package rundockercontainer
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logger = log.NewMockLog()
+
+const testContainerName = "ssm-command-step"
+
+// newOutputMock returns an output expecting the exit code and status of the last docker command
+func newOutputMock(exitCode int, status contracts.ResultStatus) *iohandlermocks.MockIOHandler {
+	output := new(iohandlermocks.MockIOHandler)
+	output.On("GetStdoutWriter").Return(new(multiwritermock.MockDocumentIOMultiWriter))
+	output.On("GetStderrWriter").Return(new(multiwritermock.MockDocumentIOMultiWriter))
+	output.On("SetExitCode", exitCode).Return()
+	output.On("SetStatus", status).Return()
+	output.On("GetStatus").Return(status)
+	return output
+}
+
+// expectDocker expects the docker command with the arguments and returns the exit code
+func expectDocker(executer *executers.MockCommandExecuter, exitCode int, arguments ...string) *mock.Call {
+	return executer.On("NewExecute", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything, mock.Anything, dockerCommand, arguments).Return(exitCode, nil)
+}
+
+func newCancelFlag() *task.MockCancelFlag {
+	cancelFlag := task.NewMockDefault()
+	cancelFlag.On("ShutDown").Return(false)
+	cancelFlag.On("Canceled").Return(false)
+	return cancelFlag
+}
+
+func TestRunContainer_RemovesContainer(t *testing.T) {
+	executer := new(executers.MockCommandExecuter)
+	expectDocker(executer, 0, "rm", "--force", testContainerName).Twice()
+	expectDocker(executer, 0, "run", "--name", testContainerName, "--env", "A=1", "--env", "B=2", "--volume", "/data:/data:ro", "alpine:3", "echo", "done").Once()
+	output := newOutputMock(0, contracts.ResultStatusSuccess)
+	input := &RunDockerContainerPluginInput{
+		Image:   "alpine:3",
+		Command: []string{"echo", "done"},
+		Env:     map[string]string{"B": "2", "A": "1"},
+		Mounts:  []string{"/data:/data:ro"},
+	}
+
+	p := &Plugin{CommandExecuter: executer}
+	p.runContainer(logger, input, testContainerName, newCancelFlag(), output)
+
+	executer.AssertExpectations(t)
+	output.AssertExpectations(t)
+}
+
+func TestRunContainer_PullNeverMissingImage(t *testing.T) {
+	executer := new(executers.MockCommandExecuter)
+	expectDocker(executer, 0, "rm", "--force", testContainerName).Twice()
+	expectDocker(executer, 1, "image", "inspect", "--format", "{{.Id}}", "alpine").Once()
+	output := newOutputMock(1, contracts.ResultStatusFailed)
+	output.On("AppendErrorf", mock.Anything, mock.Anything).Return()
+	input := &RunDockerContainerPluginInput{Image: "alpine", PullPolicy: PullNever}
+
+	p := &Plugin{CommandExecuter: executer}
+	p.runContainer(logger, input, testContainerName, newCancelFlag(), output)
+
+	// the container is never run
+	executer.AssertExpectations(t)
+	output.AssertExpectations(t)
+}
+
+func TestRunArguments(t *testing.T) {
+	input := &RunDockerContainerPluginInput{
+		Image:            "alpine",
+		NetworkMode:      "host",
+		User:             "1000:1000",
+		WorkingDirectory: "/work",
+	}
+
+	assert.Equal(t, []string{"run", "--name", testContainerName, "--network", "host", "--user", "1000:1000", "--workdir", "/work", "alpine"},
+		runArguments(input, testContainerName))
+}
+
+func TestContainerName(t *testing.T) {
+	config := contracts.Configuration{MessageId: "aws.ssm.command.i-123", PluginID: "run step"}
+
+	assert.Equal(t, "ssm-aws.ssm.command.i-123-run-step", containerName(config))
+}
+
+func TestValidateInput(t *testing.T) {
+	input := &RunDockerContainerPluginInput{Image: "registry.example.com:5000/team/app@sha256:abc"}
+	assert.NoError(t, validateInput(input))
+	assert.Equal(t, PullMissing, input.PullPolicy)
+
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "--privileged"}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "alpine", Env: map[string]string{"A B": ""}}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "alpine", Mounts: []string{"--privileged"}}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "alpine", NetworkMode: "-host"}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "alpine", User: "root;id"}))
+	assert.Error(t, validateInput(&RunDockerContainerPluginInput{Image: "alpine", PullPolicy: "Sometimes"}))
+}
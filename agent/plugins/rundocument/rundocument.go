@@ -34,6 +34,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"strings"
@@ -76,6 +77,39 @@ type RunDocumentPluginInput struct {
 	DocumentType       string      `json:"documentType"`
 	DocumentPath       string      `json:"documentPath"`
 	DocumentParameters interface{} `json:"documentParameters"`
+	// PassthroughParameters passes the parameters of the document running the plugin to the child document,
+	// DocumentParameters override them
+	PassthroughParameters bool `json:"passthroughParameters"`
+}
+
+// RunDocumentPluginOutput is the output of the plugin, the results of the steps of the child document
+type RunDocumentPluginOutput struct {
+	DocumentPath string                 `json:"documentPath"`
+	Status       contracts.ResultStatus `json:"status"`
+	Steps        []ChildStepResult      `json:"steps"`
+}
+
+// ChildStepResult is the result of a step of the child document, Output is the structured output of the
+// steps running documents themselves and StructuredOutput is the JSON payload written by step scripts
+type ChildStepResult struct {
+	Name             string                 `json:"name"`
+	Action           string                 `json:"action"`
+	Status           contracts.ResultStatus `json:"status"`
+	Code             int                    `json:"code"`
+	Output           interface{}            `json:"output"`
+	Error            string                 `json:"error,omitempty"`
+	StartDateTime    string                 `json:"startDateTime"`
+	EndDateTime      string                 `json:"endDateTime"`
+	StructuredOutput interface{}            `json:"structuredOutput,omitempty"`
+}
+
+// String returns the JSON of the output, which is how it appears in the replies
+func (output RunDocumentPluginOutput) String() string {
+	result, err := jsonutil.Marshal(output)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal the output of document %v - %v", output.DocumentPath, err)
+	}
+	return result
 }
 
 // ExecutePluginDepth is the struct that is sent through to the sub-documents to maintain the depth of execution
@@ -141,6 +175,7 @@ func (p *Plugin) runDocument(context context.T, input *RunDocumentPluginInput, c
 	if input.DocumentType == SSMDocumentType {
 		if documentPath, err = p.downloadDocumentFromSSM(log, config, input); err != nil {
 			output.MarkAsFailed(err)
+			return
 		}
 	} else {
 		if filepath.IsAbs(input.DocumentPath) {
@@ -151,7 +186,7 @@ func (p *Plugin) runDocument(context context.T, input *RunDocumentPluginInput, c
 			documentPath = filepath.Join(orchestrationDir, downloadsDir, input.DocumentPath)
 		}
 	}
-	if pluginsInfo, err = p.prepareDocumentForExecution(log, documentPath, config, input.DocumentParameters); err != nil {
+	if pluginsInfo, err = p.prepareDocumentForExecution(log, documentPath, config, input); err != nil {
 		output.MarkAsFailed(fmt.Errorf("There was an error while preparing documents - %v", err.Error()))
 		return
 	}
@@ -165,6 +200,7 @@ func (p *Plugin) runDocument(context context.T, input *RunDocumentPluginInput, c
 	var pluginOutput map[string]*contracts.PluginResult
 	if resultsChannel, err = p.execDoc.ExecuteDocument(config, context, pluginsInfo, config.BookKeepingFileName, times.ToIso8601UTC(time.Now())); err != nil {
 		output.MarkAsFailed(fmt.Errorf("There was an error while running documents - %v", err.Error()))
+		return
 	}
 	for res := range resultsChannel {
 		if res.LastPlugin == "" {
@@ -174,8 +210,28 @@ func (p *Plugin) runDocument(context context.T, input *RunDocumentPluginInput, c
 	}
 	if pluginOutput == nil {
 		output.MarkAsFailed(errors.New("No output obtained from executing document"))
+		return
 	}
-	for _, pluginOut := range pluginOutput {
+
+	documentOutput := RunDocumentPluginOutput{
+		DocumentPath: input.DocumentPath,
+		Status:       contracts.ResultStatusSuccess,
+	}
+	for _, pluginID := range orderedPluginIDs(pluginsInfo, pluginOutput) {
+		pluginOut := pluginOutput[pluginID]
+		documentOutput.Steps = append(documentOutput.Steps, ChildStepResult{
+			Name:             pluginOut.PluginID,
+			Action:           pluginOut.PluginName,
+			Status:           pluginOut.Status,
+			Code:             pluginOut.Code,
+			Output:           pluginOut.Output,
+			Error:            pluginOut.Error,
+			StartDateTime:    times.ToIso8601UTC(pluginOut.StartDateTime),
+			EndDateTime:      times.ToIso8601UTC(pluginOut.EndDateTime),
+			StructuredOutput: pluginOut.StructuredOutput,
+		})
+		documentOutput.Status = contracts.MergeResultStatus(documentOutput.Status, pluginOut.Status)
+
 		if pluginOut.StandardOutput != "" {
 			// separating the append so that the output is on a new line
 			output.AppendInfof("%v", pluginOut.StandardOutput)
@@ -189,6 +245,26 @@ func (p *Plugin) runDocument(context context.T, input *RunDocumentPluginInput, c
 		}
 		output.SetStatus(contracts.MergeResultStatus(output.GetStatus(), pluginOut.Status))
 	}
+	output.SetOutput(documentOutput)
+}
+
+// orderedPluginIDs returns the ids of the results in the order of the steps of the child document
+func orderedPluginIDs(pluginsInfo []contracts.PluginState, pluginOutput map[string]*contracts.PluginResult) (pluginIDs []string) {
+	ordered := make(map[string]bool)
+	for _, plugin := range pluginsInfo {
+		if _, ok := pluginOutput[plugin.Id]; ok && !ordered[plugin.Id] {
+			ordered[plugin.Id] = true
+			pluginIDs = append(pluginIDs, plugin.Id)
+		}
+	}
+	var others []string
+	for pluginID := range pluginOutput {
+		if !ordered[pluginID] {
+			others = append(others, pluginID)
+		}
+	}
+	sort.Strings(others)
+	return append(pluginIDs, others...)
 }
 
 func (p *Plugin) downloadDocumentFromSSM(log log.T, config contracts.Configuration, input *RunDocumentPluginInput) (string, error) {
@@ -221,9 +297,15 @@ func (p *Plugin) downloadDocumentFromSSM(log log.T, config contracts.Configurati
 }
 
 // PrepareDocumentForExecution parses the raw content of the document, validates it and returns a PluginState that can be executed.
-func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, config contracts.Configuration, params interface{}) (pluginsInfo []contracts.PluginState, err error) {
+func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, config contracts.Configuration, input *RunDocumentPluginInput) (pluginsInfo []contracts.PluginState, err error) {
 	parameters := make(map[string]interface{})
-	if params != nil {
+	if input.PassthroughParameters {
+		for k, v := range config.DocumentParameters {
+			parameters[k] = v
+		}
+		log.Infof("Passing %v parameters of the document through", len(config.DocumentParameters))
+	}
+	if params := input.DocumentParameters; params != nil {
 		switch params := params.(type) {
 		case string:
 			log.Debug("Document parameter type is String. Params to be unmarshaled - ", params)
@@ -16,6 +16,7 @@
 package rundocument
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -57,7 +58,7 @@ func TestReadFileContents(t *testing.T) {
 
 	fileMock.On("ReadFile", destinationDir).Return("content", nil)
 
-	rawFile, err := readFileContents(logMock, fileMock, destinationDir)
+	rawFile, err := readFileContents(logMock, &fileMock, destinationDir)
 
 	assert.NoError(t, err)
 	assert.Equal(t, []byte("content"), rawFile)
@@ -70,7 +71,7 @@ func TestReadFileContents_Fail(t *testing.T) {
 
 	fileMock.On("ReadFile", destinationDir).Return("content", fmt.Errorf("Error"))
 
-	_, err := readFileContents(logMock, fileMock, destinationDir)
+	_, err := readFileContents(logMock, &fileMock, destinationDir)
 
 	assert.Error(t, err)
 	fileMock.AssertExpectations(t)
@@ -174,11 +175,11 @@ func TestExecutePlugin_PrepareDocumentForExecution(t *testing.T) {
 	execMock.On("ParseDocument", logMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, "")
+	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, &RunDocumentPluginInput{DocumentParameters: ""})
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -195,11 +196,11 @@ func TestExecutePlugin_PrepareDocumentForExecutionFail(t *testing.T) {
 	localFileMock.On("ReadFile", "document/name.json").Return("", fmt.Errorf("File is empty!"))
 
 	p := Plugin{
-		filesys: localFileMock,
-		execDoc: execMock,
+		filesys: &localFileMock,
+		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, "")
+	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, &RunDocumentPluginInput{DocumentParameters: ""})
 
 	assert.Error(t, err)
 	assert.Equal(t, fmt.Errorf("File is empty!"), err)
@@ -227,11 +228,11 @@ param2: world`
 	execMock.On("ParseDocument", logMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.json", conf, params)
+	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.json", conf, &RunDocumentPluginInput{DocumentParameters: params})
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -259,11 +260,11 @@ func TestExecuteImpl_PrepareDocumentForExecutionParametersJSON(t *testing.T) {
 	execMock.On("ParseDocument", logMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.yaml", conf, params)
+	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.yaml", conf, &RunDocumentPluginInput{DocumentParameters: params})
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -291,8 +292,8 @@ func TestPlugin_RunDocumentMaxDepthExceeded(t *testing.T) {
 	mockIOHandler.On("MarkAsFailed", fmt.Errorf("Maximum depth for document execution exceeded. Maximum depth permitted - 3 and current depth - 5")).Return()
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 	p.execute(contextMock, conf, createMockCancelFlag(), mockIOHandler)
 
@@ -347,10 +348,11 @@ func TestPlugin_RunDocument(t *testing.T) {
 	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	mockIOHandler.On("SetOutput", mock.Anything).Return()
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 
 	p.execute(contextMock, conf, createMockCancelFlag(), mockIOHandler)
@@ -407,6 +409,7 @@ func TestPlugin_RunDocumentFromSSMDocument(t *testing.T) {
 	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	mockIOHandler.On("SetOutput", mock.Anything).Return()
 
 	var input RunDocumentPluginInput
 	input.DocumentType = "SSMDocument"
@@ -414,9 +417,9 @@ func TestPlugin_RunDocumentFromSSMDocument(t *testing.T) {
 	conf.Properties = &input
 
 	p := Plugin{
-		filesys: fileMock,
+		filesys: &fileMock,
 		ssmSvc:  ssmMock,
-		execDoc: execMock,
+		execDoc: &execMock,
 	}
 
 	p.runDocument(contextMock, &input, conf, mockIOHandler)
@@ -465,6 +468,7 @@ func TestPlugin_RunDocumentFromAbsLocalPath(t *testing.T) {
 	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	mockIOHandler.On("SetOutput", mock.Anything).Return()
 
 	var input RunDocumentPluginInput
 	input.DocumentType = "LocalPath"
@@ -472,8 +476,8 @@ func TestPlugin_RunDocumentFromAbsLocalPath(t *testing.T) {
 	conf.Properties = &input
 
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 	}
 
 	p.runDocument(contextMock, &input, conf, mockIOHandler)
@@ -483,6 +487,74 @@ func TestPlugin_RunDocumentFromAbsLocalPath(t *testing.T) {
 	mockIOHandler.AssertExpectations(t)
 }
 
+func TestExecuteImpl_PrepareDocumentForExecutionPassthroughParameters(t *testing.T) {
+	execMock := NewExecMock()
+	fileMock := filemock.FileSystemMock{}
+
+	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
+	conf.DocumentParameters = map[string]interface{}{"param1": "parent", "param2": "parent"}
+	parameters := map[string]interface{}{"param1": "parent", "param2": "child"}
+
+	fileMock.On("ReadFile", "document/doc-name.json").Return("content", nil)
+	execMock.On("ParseDocument", logMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return([]contracts.PluginState{}, nil)
+
+	p := Plugin{
+		filesys: &fileMock,
+		execDoc: &execMock,
+	}
+
+	input := RunDocumentPluginInput{DocumentParameters: `{"param2": "child"}`, PassthroughParameters: true}
+	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.json", conf, &input)
+
+	assert.NoError(t, err)
+	fileMock.AssertExpectations(t)
+	execMock.AssertExpectations(t)
+}
+
+func TestPlugin_RunDocumentCapturesChildResults(t *testing.T) {
+	execMock := NewExecMock()
+	fileMock := filemock.FileSystemMock{}
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+
+	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
+	plugins := []contracts.PluginState{{Id: "first"}, {Id: "second"}}
+	pluginResults := map[string]*contracts.PluginResult{
+		"second": {PluginID: "second", PluginName: "aws:runShellScript", Status: contracts.ResultStatusFailed, Code: 2, Output: "failed", Error: "exit status 2"},
+		"first":  {PluginID: "first", PluginName: "aws:runShellScript", Status: contracts.ResultStatusSuccess, Output: "done", StandardOutput: "done"},
+	}
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{Status: contracts.ResultStatusFailed, PluginResults: pluginResults}
+	close(resChan)
+
+	fileMock.On("ReadFile", "/var/tmp/document/docName.json").Return("content", nil)
+	execMock.On("ParseDocument", contextMock.Log(), []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, map[string]interface{}{}).Return(plugins, nil)
+	execMock.On("ExecuteDocument", contextMock, mock.Anything, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
+	mockIOHandler.On("AppendInfof", "%v", []interface{}{"done"}).Return()
+	mockIOHandler.On("MarkAsFailed", errors.New("exit status 2")).Return()
+	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
+	mockIOHandler.On("SetStatus", mock.Anything).Return()
+	var output RunDocumentPluginOutput
+	mockIOHandler.On("SetOutput", mock.Anything).Run(func(args mock.Arguments) {
+		output = args.Get(0).(RunDocumentPluginOutput)
+	}).Return()
+
+	input := RunDocumentPluginInput{DocumentType: LocalPathType, DocumentPath: "/var/tmp/document/docName.json"}
+	p := Plugin{
+		filesys: &fileMock,
+		execDoc: &execMock,
+	}
+	p.runDocument(contextMock, &input, conf, mockIOHandler)
+
+	mockIOHandler.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusFailed, output.Status)
+	assert.Len(t, output.Steps, 2)
+	assert.Equal(t, "first", output.Steps[0].Name)
+	assert.Equal(t, "done", output.Steps[0].Output)
+	assert.Equal(t, 2, output.Steps[1].Code)
+	assert.Equal(t, "exit status 2", output.Steps[1].Error)
+	assert.Contains(t, output.String(), `"name":"second","action":"aws:runShellScript","status":"Failed","code":2`)
+}
+
 func TestName(t *testing.T) {
 	assert.Equal(t, "aws:runDocument", Name())
 }
@@ -596,8 +668,8 @@ func TestDownloadDocumentFromSSM_ARNName(t *testing.T) {
 	fileMock.On("MakeDirs", "orch/downloads").Return(nil)
 	fileMock.On("WriteFile", "orch/downloads/mySharedDocument.json", content).Return(nil)
 	p := Plugin{
-		filesys: fileMock,
-		execDoc: execMock,
+		filesys: &fileMock,
+		execDoc: &execMock,
 		ssmSvc:  ssmMock,
 	}
 
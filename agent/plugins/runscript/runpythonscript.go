@@ -0,0 +1,186 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the RunScript plugin.
+// RunPythonScript contains implementation of the plugin that runs python scripts with the interpreter found on the instance
+package runscript
+
+import (
+	gocontext "context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+)
+
+const (
+	// pythonScriptName is the script name where the provided commands will be stored
+	pythonScriptName = "_script.py"
+	// defaultPythonMinimumVersion is the oldest interpreter used when the document doesn't set MinimumVersion
+	defaultPythonMinimumVersion = "3.0"
+	// pythonVersionTimeout bounds the version query of an interpreter
+	pythonVersionTimeout = 10 * time.Second
+	// pythonVersionScript prints the version of the interpreter, in a syntax python 2 and 3 both run
+	pythonVersionScript = "import sys; print('.'.join(map(str, sys.version_info[:3])))"
+)
+
+// pythonInterpreter is a command running a python interpreter
+type pythonInterpreter struct {
+	Command   string
+	Arguments []string
+}
+
+// String returns the command line of the interpreter
+func (i pythonInterpreter) String() string {
+	return strings.TrimSpace(i.Command + " " + strings.Join(i.Arguments, " "))
+}
+
+// lookPath and pythonVersion are variables to allow unit tests to override them
+var lookPath = exec.LookPath
+var pythonVersion = queryPythonVersion
+
+// RunPythonScriptPluginInput represents one python script executed by the RunPythonScript plugin.
+type RunPythonScriptPluginInput struct {
+	RunScriptPluginInput
+	// MinimumVersion is the oldest python version the script runs with, such as 3.6. Defaults to 3.0.
+	MinimumVersion string
+	// InterpreterPath is the interpreter to run the script with instead of the interpreters found on the instance
+	InterpreterPath string
+}
+
+// runPythonPlugin is the type for the RunPythonScript plugin and embeds Plugin struct.
+type runPythonPlugin struct {
+	Plugin
+}
+
+// NewRunPythonPlugin returns a new instance of the python plugin.
+// The exit code of the script sets the status of the plugin like for the other script plugins, exit code 194 reboots
+// the instance and other non zero exit codes fail the plugin.
+func NewRunPythonPlugin() (*runPythonPlugin, error) {
+	pyplugin := runPythonPlugin{
+		Plugin{
+			Name:            appconfig.PluginNameAwsRunPythonScript,
+			ScriptName:      pythonScriptName,
+			ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+			CommandExecuter: executers.ShellCommandExecuter{},
+		},
+	}
+
+	return &pyplugin, nil
+}
+
+// Execute runs a python script and returns its output.
+func (p *runPythonPlugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	log.Infof("%v started with configuration %v", p.Name, parameterstore.Redact(fmt.Sprintf("%v", config)))
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else {
+		p.runPythonRawInput(log, config.PluginID, config.Properties, config.OrchestrationDirectory, config.DefaultWorkingDirectory, cancelFlag, output)
+	}
+}
+
+// runPythonRawInput finds the interpreter of the script and runs the script with it.
+func (p *runPythonPlugin) runPythonRawInput(log log.T, pluginID string, rawPluginInput interface{}, orchestrationDirectory string, defaultWorkingDirectory string, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	var pluginInput RunPythonScriptPluginInput
+	if err := jsonutil.Remarshal(rawPluginInput, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", rawPluginInput, err))
+		return
+	}
+
+	interpreter, version, err := findPythonInterpreter(log, pluginInput.InterpreterPath, pluginInput.MinimumVersion)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	log.Infof("Running python script with %v, python %v", interpreter, version)
+
+	// -u keeps the output of the script unbuffered so stdout and stderr are captured in the order they are written
+	plugin := p.Plugin
+	plugin.ShellCommand = interpreter.Command
+	plugin.ShellArguments = append(append([]string{}, interpreter.Arguments...), "-u")
+	plugin.runCommands(log, pluginID, pluginInput.RunScriptPluginInput, orchestrationDirectory, defaultWorkingDirectory, cancelFlag, output)
+}
+
+// findPythonInterpreter returns the first interpreter, in the order of pythonCandidates, that is at least minimumVersion.
+// Only the given interpreter path is considered when set.
+func findPythonInterpreter(log log.T, interpreterPath string, minimumVersion string) (interpreter pythonInterpreter, version versionutil.Version, err error) {
+	if minimumVersion == "" {
+		minimumVersion = defaultPythonMinimumVersion
+	}
+	minimum, err := versionutil.Parse(minimumVersion)
+	if err != nil {
+		return interpreter, version, fmt.Errorf("invalid MinimumVersion: %v", err)
+	}
+
+	candidates := pythonCandidates
+	if interpreterPath != "" {
+		candidates = []pythonInterpreter{{Command: interpreterPath}}
+	}
+
+	var found []string
+	for _, candidate := range candidates {
+		path, lookErr := lookPath(candidate.Command)
+		if lookErr != nil {
+			continue
+		}
+		candidate.Command = path
+		output, versionErr := pythonVersion(candidate)
+		if versionErr != nil {
+			log.Debugf("Failed to get the version of python interpreter %v: %v", candidate, versionErr)
+			continue
+		}
+		if version, err = versionutil.Parse(strings.TrimSpace(output)); err != nil {
+			log.Debugf("Unexpected version of python interpreter %v: %v", candidate, err)
+			continue
+		}
+		if version.LessThan(minimum) {
+			found = append(found, fmt.Sprintf("%v (%v)", candidate, version))
+			continue
+		}
+		return candidate, version, nil
+	}
+
+	if len(found) > 0 {
+		return interpreter, version, fmt.Errorf("no python interpreter of version %v or later was found, found %v", minimum, strings.Join(found, ", "))
+	}
+	if interpreterPath != "" {
+		return interpreter, version, fmt.Errorf("python interpreter %v was not found", interpreterPath)
+	}
+	return interpreter, version, fmt.Errorf("no python interpreter was found, install python %v or later or set InterpreterPath", minimum)
+}
+
+// queryPythonVersion runs the interpreter to print its version
+func queryPythonVersion(interpreter pythonInterpreter) (string, error) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), pythonVersionTimeout)
+	defer cancel()
+
+	arguments := append(append([]string{}, interpreter.Arguments...), "-c", pythonVersionScript)
+	output, err := exec.CommandContext(ctx, interpreter.Command, arguments...).Output()
+	return string(output), err
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runscript
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stubPythonInterpreters makes the given interpreters, mapped to their versions, the only ones on the instance
+func stubPythonInterpreters(versions map[string]string) func() {
+	lookPathTemp, pythonVersionTemp := lookPath, pythonVersion
+	lookPath = func(file string) (string, error) {
+		if _, ok := versions[file]; ok {
+			return file, nil
+		}
+		return "", fmt.Errorf("%v not found", file)
+	}
+	pythonVersion = func(interpreter pythonInterpreter) (string, error) {
+		return versions[interpreter.Command] + "\n", nil
+	}
+	return func() { lookPath, pythonVersion = lookPathTemp, pythonVersionTemp }
+}
+
+func TestFindPythonInterpreter_PrefersFirstCandidate(t *testing.T) {
+	candidatesTemp := pythonCandidates
+	pythonCandidates = []pythonInterpreter{{Command: "python3"}, {Command: "python"}}
+	defer func() { pythonCandidates = candidatesTemp }()
+	defer stubPythonInterpreters(map[string]string{"python3": "3.6.8", "python": "3.9.1"})()
+
+	interpreter, version, err := findPythonInterpreter(logger, "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "python3", interpreter.Command)
+	assert.Equal(t, "3.6.8", version.String())
+}
+
+func TestFindPythonInterpreter_MinimumVersion(t *testing.T) {
+	candidatesTemp := pythonCandidates
+	pythonCandidates = []pythonInterpreter{{Command: "python3"}, {Command: "python"}, {Command: "python2"}}
+	defer func() { pythonCandidates = candidatesTemp }()
+	defer stubPythonInterpreters(map[string]string{"python3": "3.6.8", "python": "3.9.1", "python2": "2.7.18"})()
+
+	interpreter, _, err := findPythonInterpreter(logger, "", "3.7")
+	assert.NoError(t, err)
+	assert.Equal(t, "python", interpreter.Command)
+
+	_, _, err = findPythonInterpreter(logger, "", "3.10")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "python3 (3.6.8)")
+
+	_, _, err = findPythonInterpreter(logger, "", "three")
+	assert.Error(t, err)
+}
+
+func TestFindPythonInterpreter_InterpreterPath(t *testing.T) {
+	defer stubPythonInterpreters(map[string]string{"python3": "3.9.1", "/opt/python/bin/python": "3.11.2"})()
+
+	interpreter, _, err := findPythonInterpreter(logger, "/opt/python/bin/python", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/python/bin/python", interpreter.Command)
+
+	_, _, err = findPythonInterpreter(logger, "/missing/python", "")
+	assert.Error(t, err)
+}
+
+func TestFindPythonInterpreter_NotFound(t *testing.T) {
+	defer stubPythonInterpreters(map[string]string{})()
+
+	_, _, err := findPythonInterpreter(logger, "", "")
+
+	assert.Error(t, err)
+}
+
+func TestRunPythonScript(t *testing.T) {
+	candidatesTemp := pythonCandidates
+	pythonCandidates = []pythonInterpreter{{Command: "py", Arguments: []string{"-3"}}}
+	defer func() { pythonCandidates = candidatesTemp }()
+	defer stubPythonInterpreters(map[string]string{"py": "3.8.10"})()
+
+	testCase := generateTestCaseOk("0")
+	input := RunPythonScriptPluginInput{RunScriptPluginInput: testCase.Input, MinimumVersion: "3.8"}
+
+	mockCancelFlag := new(task.MockCancelFlag)
+	mockExecuter := new(executers.MockCommandExecuter)
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, _ := NewRunPythonPlugin()
+	p.CommandExecuter = mockExecuter
+
+	mockExecuter.On("ExecuteWithEnvironment", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, "py", mock.MatchedBy(func(arguments []string) bool {
+		return len(arguments) == 3 && arguments[0] == "-3" && arguments[1] == "-u"
+	}), mock.Anything).Return(testCase.Output.ExitCode, testCase.ExecuterError)
+	setIOHandlerExpectations(mockIOHandler, testCase)
+
+	p.runPythonRawInput(logger, pluginID, input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+
+	mockExecuter.AssertExpectations(t)
+	mockIOHandler.AssertExpectations(t)
+}
+
+func TestRunPythonScript_NoInterpreter(t *testing.T) {
+	defer stubPythonInterpreters(map[string]string{})()
+
+	mockCancelFlag := new(task.MockCancelFlag)
+	mockExecuter := new(executers.MockCommandExecuter)
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, _ := NewRunPythonPlugin()
+	p.CommandExecuter = mockExecuter
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+
+	p.runPythonRawInput(logger, pluginID, RunPythonScriptPluginInput{RunScriptPluginInput: generateTestCaseOk("0").Input}, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+
+	mockExecuter.AssertNotCalled(t, "ExecuteWithEnvironment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockIOHandler.AssertExpectations(t)
+}
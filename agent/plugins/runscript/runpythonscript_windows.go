@@ -0,0 +1,25 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package runscript
+
+// pythonCandidates are the interpreters probed for the python plugin, in order of preference.
+// The py launcher picks the latest installed python 3, the python.exe aliases of the Microsoft Store don't run scripts
+// and fail the version query.
+var pythonCandidates = []pythonInterpreter{
+	{Command: "py.exe", Arguments: []string{"-3"}},
+	{Command: "python3.exe"},
+	{Command: "python.exe"},
+}
@@ -16,8 +16,10 @@ package runscript
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
-
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -27,6 +29,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/parameterstore"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -54,13 +57,22 @@ type RunScriptPluginInput struct {
 	ID               string
 	WorkingDirectory string
 	TimeoutSeconds   interface{}
+	// Environment holds the environment variables set for the commands, such as {"LOG_LEVEL": "{{ logLevel }}"}.
+	// The values go through the parameter substitution of the document and take precedence over inherited variables.
+	Environment map[string]string
+	// CleanEnvironment starts the commands from the variables the platform needs to run the shell, such as PATH,
+	// instead of the environment of the agent. Accepts true, false or their string forms.
+	CleanEnvironment interface{}
+	// ResourceLimits caps the CPU, memory, processes and open files of the commands and of the processes they start,
+	// such as {"memoryMB": 512, "cpuPercent": 50}. Unset limits are not enforced.
+	ResourceLimits executers.ResourceLimits
 }
 
 // Execute runs multiple sets of commands and returns their outputs.
 // res.Output will contain a slice of RunScriptPluginOutput.
 func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
 	log := context.Log()
-	log.Infof("%v started with configuration %v", p.Name, config)
+	log.Infof("%v started with configuration %v", p.Name, parameterstore.Redact(fmt.Sprintf("%v", config)))
 	log.Debugf("DefaultWorkingDirectory %v", config.DefaultWorkingDirectory)
 
 	if cancelFlag.ShutDown() {
@@ -124,12 +136,28 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput RunScriptPl
 	// Set execution time
 	executionTimeout := pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
 
+	// Set execution environment
+	environment, err := parseEnvironment(pluginInput)
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("invalid environment. %v", err))
+		return
+	}
+	logEnvironment(log, environment)
+
+	// Remove the structured output of a previous run of the commands, such as before a reboot
+	structuredOutputFile := filepath.Join(orchestrationDir, structuredOutputFileName)
+	if err = fileutil.DeleteFile(structuredOutputFile); err != nil && !os.IsNotExist(err) {
+		output.MarkAsFailed(fmt.Errorf("failed to remove structured output file. %v", err))
+		return
+	}
+	environment = withStructuredOutputFile(environment, structuredOutputFile)
+
 	// Construct Command Name and Arguments
 	commandName := p.ShellCommand
 	commandArguments := append(p.ShellArguments, scriptPath)
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(log, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments)
+	exitCode, err := p.CommandExecuter.ExecuteWithEnvironment(log, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, environment)
 
 	// Set output status
 	output.SetExitCode(exitCode)
@@ -143,4 +171,61 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput RunScriptPl
 			output.MarkAsFailed(fmt.Errorf("failed to run commands: %v", err))
 		}
 	}
+
+	setStructuredOutput(log, structuredOutputFile, output)
+}
+
+// parseEnvironment validates the environment input of the plugin
+func parseEnvironment(pluginInput RunScriptPluginInput) (environment executers.Environment, err error) {
+	for name := range pluginInput.Environment {
+		if err = executers.ValidateEnvVariableName(name); err != nil {
+			return
+		}
+	}
+	environment.Variables = pluginInput.Environment
+
+	switch value := pluginInput.CleanEnvironment.(type) {
+	case nil:
+	case bool:
+		environment.Clean = value
+	case string:
+		if value == "" {
+			break
+		}
+		if environment.Clean, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			err = fmt.Errorf("CleanEnvironment value %v is not true or false", value)
+		}
+	default:
+		err = fmt.Errorf("CleanEnvironment value %v is not true or false", value)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = pluginInput.ResourceLimits.Validate(); err != nil {
+		err = fmt.Errorf("invalid ResourceLimits, %v", err)
+		return
+	}
+	environment.Limits = pluginInput.ResourceLimits
+	return
+}
+
+// logEnvironment logs the environment of the commands, the values of secure string parameters are redacted
+func logEnvironment(log log.T, environment executers.Environment) {
+	if environment.Limits.IsSet() {
+		log.Infof("Running commands with resource limits %+v", environment.Limits)
+	}
+	if len(environment.Variables) == 0 && !environment.Clean {
+		return
+	}
+	names := make([]string, 0, len(environment.Variables))
+	for name := range environment.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	variables := make([]string, len(names))
+	for i, name := range names {
+		variables[i] = fmt.Sprintf("%v=%v", name, parameterstore.Redact(environment.Variables[name]))
+	}
+	log.Infof("Running commands with clean environment %v and variables %v", environment.Clean, variables)
 }
@@ -15,11 +15,13 @@ package runscript
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
@@ -173,6 +175,77 @@ func testBucketsInDifferentRegions(t *testing.T, testCase TestCase, testingBucke
 	testExecution(t, runScriptTester)
 }
 
+// TestRunScriptsEnvironment tests the environment input is given to the executer.
+func TestRunScriptsEnvironment(t *testing.T) {
+	testCase := generateTestCaseOk("0")
+	testCase.Input.Environment = map[string]string{"LOG_LEVEL": "debug"}
+	testCase.Input.CleanEnvironment = "true"
+	structuredOutputFile := filepath.Join(fileutil.BuildPath(orchestrationDirectory, testCase.Input.ID), structuredOutputFileName)
+	expectedEnvironment := executers.Environment{Variables: map[string]string{"LOG_LEVEL": "debug", envVarStructuredOutputFile: structuredOutputFile}, Clean: true}
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *task.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockExecuter.On("ExecuteWithEnvironment", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, expectedEnvironment).Return(
+			testCase.Output.ExitCode, testCase.ExecuterError)
+		setIOHandlerExpectations(mockIOHandler, testCase)
+
+		var rawPluginInput interface{}
+		err := jsonutil.Remarshal(testCase.Input, &rawPluginInput)
+		assert.Nil(t, err)
+		p.runCommandsRawInput(logger, pluginID, rawPluginInput, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+	}
+
+	testExecution(t, runScriptTester)
+}
+
+// TestRunScriptsInvalidEnvironment tests the plugin fails without running the commands when the environment is invalid.
+func TestRunScriptsInvalidEnvironment(t *testing.T) {
+	testCase := generateTestCaseOk("0")
+	testCase.Input.Environment = map[string]string{"LOG=LEVEL": "debug"}
+
+	runScriptTester := func(p *Plugin, mockCancelFlag *task.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+
+		p.runCommands(logger, pluginID, testCase.Input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+	}
+
+	testExecution(t, runScriptTester)
+}
+
+func TestParseEnvironment(t *testing.T) {
+	for _, value := range []interface{}{nil, "", false, "false"} {
+		environment, err := parseEnvironment(RunScriptPluginInput{CleanEnvironment: value})
+		assert.NoError(t, err)
+		assert.False(t, environment.Clean)
+	}
+	for _, value := range []interface{}{true, "true", "True"} {
+		environment, err := parseEnvironment(RunScriptPluginInput{CleanEnvironment: value})
+		assert.NoError(t, err)
+		assert.True(t, environment.Clean)
+	}
+	for _, value := range []interface{}{"yes please", 1.0} {
+		_, err := parseEnvironment(RunScriptPluginInput{CleanEnvironment: value})
+		assert.Error(t, err)
+	}
+	_, err := parseEnvironment(RunScriptPluginInput{Environment: map[string]string{"": "value"}})
+	assert.Error(t, err)
+}
+
+func TestParseEnvironment_ResourceLimits(t *testing.T) {
+	var pluginInput RunScriptPluginInput
+	err := jsonutil.Remarshal(map[string]interface{}{
+		"runCommand":     []string{"make"},
+		"resourceLimits": map[string]interface{}{"memoryMB": "512", "maxProcesses": 64},
+	}, &pluginInput)
+	assert.NoError(t, err)
+
+	environment, err := parseEnvironment(pluginInput)
+	assert.NoError(t, err)
+	assert.Equal(t, executers.ResourceLimits{MemoryMB: 512, MaxProcesses: 64}, environment.Limits)
+
+	_, err = parseEnvironment(RunScriptPluginInput{ResourceLimits: executers.ResourceLimits{MemoryMB: -1}})
+	assert.Error(t, err)
+}
+
 // TestExecute tests the Execute method, which runs multiple sets of commands.
 func TestExecute(t *testing.T) {
 	// test each plugin input as a separate execution
@@ -323,7 +396,7 @@ func testExecution(t *testing.T, commandtester CommandTester) {
 }
 
 func setExecuterExpectations(mockExecuter *executers.MockCommandExecuter, t TestCase, cancelFlag task.CancelFlag, p *Plugin) {
-	mockExecuter.On("NewExecute", mock.Anything, t.Input.WorkingDirectory, t.Output.StdoutWriter, t.Output.StderrWriter, cancelFlag, mock.Anything, mock.Anything, mock.Anything).Return(
+	mockExecuter.On("ExecuteWithEnvironment", mock.Anything, t.Input.WorkingDirectory, t.Output.StdoutWriter, t.Output.StderrWriter, cancelFlag, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
 		t.Output.ExitCode, t.ExecuterError)
 }
 
@@ -0,0 +1,107 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// envVarStructuredOutputFile is the name of the environment variable holding the path of the file
+	// in which the commands can write a JSON payload returned as the structured output of the step
+	envVarStructuredOutputFile = "AWS_SSM_OUTPUT_FILE"
+
+	// structuredOutputFileName is the name of the structured output file in the orchestration directory
+	structuredOutputFileName = "structuredOutput.json"
+
+	// maxStructuredOutputSize is the maximum size in bytes of the structured output, it is sent with the
+	// results of the document so it is kept well below the size limits of the replies
+	maxStructuredOutputSize = 16 * 1024
+)
+
+// withStructuredOutputFile returns the environment with the variable pointing the commands to the
+// structured output file, the variables of the plugin input are left untouched
+func withStructuredOutputFile(environment executers.Environment, outputFile string) executers.Environment {
+	variables := make(map[string]string, len(environment.Variables)+1)
+	for name, value := range environment.Variables {
+		variables[name] = value
+	}
+	variables[envVarStructuredOutputFile] = outputFile
+	environment.Variables = variables
+	return environment
+}
+
+// setStructuredOutput attaches the JSON payload the commands wrote to the output file to the output.
+// An invalid payload fails a successful step, since the steps consuming it would otherwise break silently.
+func setStructuredOutput(log log.T, outputFile string, output iohandler.IOHandler) {
+	payload, err := readStructuredOutput(outputFile)
+	if err == nil {
+		if payload != nil {
+			log.Debugf("Commands wrote structured output to %v", outputFile)
+			output.SetStructuredOutput(payload)
+		}
+		return
+	}
+
+	err = fmt.Errorf("invalid structured output in %v: %v", envVarStructuredOutputFile, err)
+	log.Error(err)
+	if output.GetStatus() == contracts.ResultStatusSuccess {
+		output.MarkAsFailed(err)
+	} else {
+		output.AppendError(err.Error())
+	}
+}
+
+// readStructuredOutput parses the structured output file, it returns nil when the commands did not write it
+func readStructuredOutput(outputFile string) (payload interface{}, err error) {
+	info, err := os.Stat(outputFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxStructuredOutputSize {
+		return nil, fmt.Errorf("%v bytes exceeds the limit of %v bytes", info.Size(), maxStructuredOutputSize)
+	}
+
+	content, err := ioutil.ReadFile(outputFile)
+	if err != nil {
+		return nil, err
+	}
+	// Windows PowerShell writes files with a byte order mark by default
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err = decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected content after the JSON value")
+	}
+	return payload, nil
+}
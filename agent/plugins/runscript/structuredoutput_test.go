@@ -0,0 +1,108 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runscript
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReadStructuredOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "structuredoutput")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	outputFile := filepath.Join(dir, structuredOutputFileName)
+
+	payload, err := readStructuredOutput(outputFile)
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+
+	testCases := []struct {
+		content  string
+		expected interface{}
+		valid    bool
+	}{
+		{"", nil, true},
+		{" \n", nil, true},
+		{`{"id": "i-1", "count": 2}`, map[string]interface{}{"id": "i-1", "count": json.Number("2")}, true},
+		{"\xef\xbb\xbf[\"a\", true]\r\n", []interface{}{"a", true}, true},
+		{`"done"`, "done", true},
+		{`{"id": `, nil, false},
+		{`{"id": 1} {"id": 2}`, nil, false},
+		{`"` + strings.Repeat("a", maxStructuredOutputSize) + `"`, nil, false},
+	}
+	for _, testCase := range testCases {
+		assert.NoError(t, ioutil.WriteFile(outputFile, []byte(testCase.content), 0600))
+		payload, err := readStructuredOutput(outputFile)
+		if testCase.valid {
+			assert.NoError(t, err, testCase.content)
+			assert.Equal(t, testCase.expected, payload)
+		} else {
+			assert.Error(t, err, testCase.content)
+		}
+	}
+}
+
+func TestSetStructuredOutput_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "structuredoutput")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	outputFile := filepath.Join(dir, structuredOutputFileName)
+	assert.NoError(t, ioutil.WriteFile(outputFile, []byte("not json"), 0600))
+
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+	setStructuredOutput(logger, outputFile, mockIOHandler)
+	mockIOHandler.AssertExpectations(t)
+
+	mockIOHandler = new(iohandlermocks.MockIOHandler)
+	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusFailed)
+	mockIOHandler.On("AppendError", mock.Anything).Return()
+	setStructuredOutput(logger, outputFile, mockIOHandler)
+	mockIOHandler.AssertExpectations(t)
+	mockIOHandler.AssertNotCalled(t, "MarkAsFailed", mock.Anything)
+}
+
+// TestRunScriptsStructuredOutput tests the payload written by the commands is set as the structured output.
+func TestRunScriptsStructuredOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "structuredoutput")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCase := generateTestCaseOk("0")
+	runScriptTester := func(p *Plugin, mockCancelFlag *task.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockExecuter.On("ExecuteWithEnvironment", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			outputFile := args.Get(8).(executers.Environment).Variables[envVarStructuredOutputFile]
+			assert.NoError(t, ioutil.WriteFile(outputFile, []byte(`{"instances": ["i-1"]}`), 0600))
+		}).Return(testCase.Output.ExitCode, testCase.ExecuterError)
+		setIOHandlerExpectations(mockIOHandler, testCase)
+		mockIOHandler.On("SetStructuredOutput", map[string]interface{}{"instances": []interface{}{"i-1"}}).Return()
+
+		p.runCommands(logger, pluginID, testCase.Input, dir, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+	}
+
+	testExecution(t, runScriptTester)
+}
@@ -18,10 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"regexp"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
 )
 
 // Manifest represents the json structure of online manifest file.
@@ -45,8 +47,15 @@ type FileContent struct {
 
 // PackageVersion section in the PackageContent
 type PackageVersion struct {
-	Version  string `json:"Version"`
-	Checksum string `json:"Checksum"`
+	Version  string          `json:"Version"`
+	Checksum string          `json:"Checksum"`
+	Deltas   []*PackageDelta `json:"Deltas,omitempty"`
+}
+
+// PackageDelta section in the PackageVersion, a binary delta from an earlier version to the package version
+type PackageDelta struct {
+	SourceVersion string `json:"SourceVersion"`
+	Checksum      string `json:"Checksum"`
 }
 
 const (
@@ -57,8 +66,20 @@ const (
 
 	// ChinaManifestURL is the manifest URL for regions in China
 	ChinaManifestURL = "https://s3.{Region}.amazonaws.com.cn/amazon-ssm-{Region}/ssm-agent-manifest.json"
+
+	// deltaFileNameFormat is the file name of the delta from a source version to a package file
+	deltaFileNameFormat = "%v-from-%v.bsdiff"
+
+	// manifestSchemaVersion is the schema version of the manifests the agent supports
+	manifestSchemaVersion = "1.0"
+
+	// artifactPathFormat is the path of the packages relative to the artifact location
+	artifactPathFormat = "/" + updateutil.PackageNameHolder + "/" + updateutil.PackageVersionHolder + "/" + updateutil.FileNameHolder
 )
 
+// checksumPattern matches the hex encoded sha256 checksums of packages
+var checksumPattern = regexp.MustCompile("^[0-9a-fA-F]{64}$")
+
 // ParseManifest parses the public manifest file to provide agent update information.
 func ParseManifest(log log.T,
 	fileName string,
@@ -159,6 +180,88 @@ func (m *Manifest) DownloadURLAndHash(
 	return "", "", fmt.Errorf("incorrect package name or version, %v, %v", packageName, version)
 }
 
+// DeltaURLAndHash returns the download url and hash value of the delta from the source version to the target version
+func (m *Manifest) DeltaURLAndHash(
+	context *updateutil.InstanceContext,
+	packageName string,
+	sourceVersion string,
+	targetVersion string) (result string, hash string, err error) {
+	fileName := context.FileName(packageName)
+
+	for _, p := range m.Packages {
+		if p.Name == packageName {
+			for _, f := range p.Files {
+				if f.Name == fileName {
+					for _, v := range f.AvailableVersions {
+						if v.Version != targetVersion {
+							continue
+						}
+						for _, d := range v.Deltas {
+							if d.SourceVersion == sourceVersion {
+								result = m.URIFormat
+								result = strings.Replace(result, updateutil.RegionHolder, context.Region, -1)
+								result = strings.Replace(result, updateutil.PackageNameHolder, packageName, -1)
+								result = strings.Replace(result, updateutil.PackageVersionHolder, targetVersion, -1)
+								result = strings.Replace(result, updateutil.FileNameHolder, fmt.Sprintf(deltaFileNameFormat, f.Name, sourceVersion), -1)
+								return result, d.Checksum, nil
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no delta from %v to %v for package %v", sourceVersion, targetVersion, packageName)
+}
+
+// UseArtifactLocation downloads the packages from location instead of the location in the manifest
+func (m *Manifest) UseArtifactLocation(location string) {
+	m.URIFormat = strings.TrimRight(location, "/") + artifactPathFormat
+}
+
+// validateManifestSchema validates the manifest of a custom update source, which must have the supported schema,
+// versions and a sha256 checksum for every package and delta since the source is not trusted to be consistent.
+func validateManifestSchema(parsedManifest *Manifest, context *updateutil.InstanceContext, packageName string) error {
+	if parsedManifest.SchemaVersion != manifestSchemaVersion {
+		return fmt.Errorf("unsupported manifest schema version %v, supported version is %v",
+			parsedManifest.SchemaVersion,
+			manifestSchemaVersion)
+	}
+	if !strings.Contains(parsedManifest.URIFormat, updateutil.FileNameHolder) {
+		return fmt.Errorf("manifest UriFormat %v does not contain %v", parsedManifest.URIFormat, updateutil.FileNameHolder)
+	}
+
+	fileName := context.FileName(packageName)
+	for _, p := range parsedManifest.Packages {
+		if p.Name != packageName {
+			continue
+		}
+		for _, f := range p.Files {
+			if f.Name != fileName {
+				continue
+			}
+			for _, v := range f.AvailableVersions {
+				if _, err := versionutil.Parse(v.Version); err != nil {
+					return fmt.Errorf("invalid version of %v in the Manifest file, %v", fileName, err)
+				}
+				if !checksumPattern.MatchString(v.Checksum) {
+					return fmt.Errorf("invalid checksum of %v %v in the Manifest file", fileName, v.Version)
+				}
+				for _, d := range v.Deltas {
+					if !checksumPattern.MatchString(d.Checksum) {
+						return fmt.Errorf("invalid checksum of the delta from %v to %v %v in the Manifest file",
+							d.SourceVersion,
+							fileName,
+							v.Version)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // validateManifest makes sure all the fields are provided.
 func validateManifest(log log.T, parsedManifest *Manifest, context *updateutil.InstanceContext, packageName string) error {
 	if len(parsedManifest.URIFormat) == 0 {
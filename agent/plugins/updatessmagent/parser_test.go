@@ -97,6 +97,44 @@ func TestParseManifestWithError(t *testing.T) {
 	}
 }
 
+func TestValidateManifestSchema(t *testing.T) {
+	agentName := "amazon-ssm-agent"
+	context := mockInstanceContext()
+	manifest := loadManifestFromFile(t, "testdata/sampleManifest.json")
+
+	assert.NoError(t, validateManifestSchema(manifest, context, agentName))
+
+	manifest.SchemaVersion = "2.0"
+	assert.Error(t, validateManifestSchema(manifest, context, agentName))
+}
+
+func TestValidateManifestSchemaInvalidChecksum(t *testing.T) {
+	agentName := "amazon-ssm-agent"
+	context := mockInstanceContext()
+	manifest := loadManifestFromFile(t, "testdata/sampleManifest.json")
+	versions := manifest.Packages[0].Files[0].AvailableVersions
+
+	versions[0].Checksum = ""
+	assert.Error(t, validateManifestSchema(manifest, context, agentName))
+
+	versions[0].Checksum = "d2b67b804e0c3d3d83d09992a6a62b9e6a79fa3214b00685b07998b4e548870e"
+	versions[0].Deltas = []*PackageDelta{{SourceVersion: "1.0.0.0", Checksum: "not a checksum"}}
+	assert.Error(t, validateManifestSchema(manifest, context, agentName))
+}
+
+func TestUseArtifactLocation(t *testing.T) {
+	agentName := "amazon-ssm-agent"
+	context := mockInstanceContext()
+	manifest := loadManifestFromFile(t, "testdata/sampleManifest.json")
+
+	manifest.UseArtifactLocation("https://mirror.example.com/ssm/{Region}/")
+	source, hash, err := manifest.DownloadURLAndHash(context, agentName, "1.0.178.0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com/ssm/us-east-1/amazon-ssm-agent/1.0.178.0/amazon-ssm-agent-linux-amd64.tar.gz", source)
+	assert.Equal(t, "d2b67b804e0c3d3d83d09992a6a62b9e6a79fa3214b00685b07998b4e548870e", hash)
+}
+
 //Load specified file from file system
 func loadFile(t *testing.T, fileName string) (result []byte) {
 	var err error
@@ -40,6 +40,8 @@ import (
 type Plugin struct {
 	// Manifest location
 	ManifestLocation string
+	// Artifact location, overrides the location in the manifest when not empty
+	ArtifactLocation string
 }
 
 // UpdatePluginInput represents one set of commands executed by the UpdateAgent plugin.
@@ -50,11 +52,15 @@ type UpdatePluginInput struct {
 	TargetVersion  string `json:"targetVersion"`
 	Source         string `json:"source"`
 	UpdaterName    string `json:"-"`
+	CustomSource   bool   `json:"-"`
+	LogGroupName   string `json:"-"`
+	LogStreamName  string `json:"-"`
 }
 
 // UpdatePluginConfig is used for initializing update agent plugin with default values
 type UpdatePluginConfig struct {
 	ManifestLocation string
+	ArtifactLocation string
 }
 
 type updateManager struct{}
@@ -91,8 +97,12 @@ type pluginHelper interface {
 		out iohandler.IOHandler) (noNeedToUpdate bool, err error)
 }
 
+// updateProgressStreamName is the CloudWatch log stream the updater reports the phases of the update to
+const updateProgressStreamName = "updateProgress"
+
 // Assign method to global variables to allow unittest to override
 var getAppConfig = appconfig.Config
+var getRegion = platform.Region
 var fileDownload = artifact.Download
 var fileUncompress = fileutil.Uncompress
 var updateAgent = runUpdateAgent
@@ -101,6 +111,7 @@ var updateAgent = runUpdateAgent
 func NewPlugin(updatePluginConfig UpdatePluginConfig) (*Plugin, error) {
 	var plugin Plugin
 	plugin.ManifestLocation = updatePluginConfig.ManifestLocation
+	plugin.ArtifactLocation = updatePluginConfig.ArtifactLocation
 	return &plugin, nil
 }
 
@@ -135,10 +146,17 @@ func runUpdateAgent(
 	if len(pluginInput.Source) == 0 {
 		pluginInput.Source = p.ManifestLocation
 	}
+	//Manifests other than the manifests of the regions are validated strictly
+	pluginInput.CustomSource = pluginInput.Source != CommonManifestURL && pluginInput.Source != ChinaManifestURL
 	//Calculate manifest location base on current instance's region
 	pluginInput.Source = strings.Replace(pluginInput.Source, updateutil.RegionHolder, context.Region, -1)
 	//Calculate updater package name base on agent name
 	pluginInput.UpdaterName = pluginInput.AgentName + updateutil.UpdaterPackageNamePrefix
+	//Stream the update progress next to the output of the document when it is sent to CloudWatch
+	if cloudWatchConfig := output.GetIOConfig().CloudWatchConfig; cloudWatchConfig.LogGroupName != "" {
+		pluginInput.LogGroupName = cloudWatchConfig.LogGroupName
+		pluginInput.LogStreamName = fmt.Sprintf("%s/%s", cloudWatchConfig.LogStreamPrefix, updateProgressStreamName)
+	}
 	//Generate update output
 	targetVersion := pluginInput.TargetVersion
 	if len(targetVersion) == 0 {
@@ -155,6 +173,9 @@ func runUpdateAgent(
 		output.MarkAsFailed(downloadErr)
 		return
 	}
+	if len(p.ArtifactLocation) > 0 {
+		manifest.UseArtifactLocation(p.ArtifactLocation)
+	}
 
 	//Validate update details
 	noNeedToUpdate := false
@@ -263,6 +284,13 @@ func (m *updateManager) generateUpdateCmd(log log.T,
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.TargetLocationCmd, source)
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.TargetHashCmd, hash)
 
+	//Get download url and hash value of the delta from the current to the target version if there is one
+	if deltaSource, deltaHash, deltaErr := manifest.DeltaURLAndHash(
+		context, pluginInput.AgentName, version.Version, pluginInput.TargetVersion); deltaErr == nil {
+		cmd = updateutil.BuildUpdateCommand(cmd, updateutil.TargetDeltaLocationCmd, deltaSource)
+		cmd = updateutil.BuildUpdateCommand(cmd, updateutil.TargetDeltaHashCmd, deltaHash)
+	}
+
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.PackageNameCmd, pluginInput.AgentName)
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.MessageIDCmd, messageID)
 
@@ -271,6 +299,11 @@ func (m *updateManager) generateUpdateCmd(log log.T,
 
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.OutputKeyPrefixCmd, keyPrefix)
 	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.OutputBucketNameCmd, bucketName)
+	if allowDowngrade, _ := strconv.ParseBool(pluginInput.AllowDowngrade); allowDowngrade {
+		cmd = fmt.Sprintf("%v -%v", cmd, updateutil.AllowDowngradeCmd)
+	}
+	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.OutputLogGroupCmd, pluginInput.LogGroupName)
+	cmd = updateutil.BuildUpdateCommand(cmd, updateutil.OutputLogStreamCmd, pluginInput.LogStreamName)
 
 	return
 }
@@ -300,7 +333,15 @@ func (m *updateManager) downloadManifest(log log.T,
 		return nil, downloadErr
 	}
 	out.AppendInfof("Successfully downloaded %v\n", downloadInput.SourceURL)
-	return ParseManifest(log, downloadOutput.LocalFilePath, context, pluginInput.AgentName)
+	if manifest, err = ParseManifest(log, downloadOutput.LocalFilePath, context, pluginInput.AgentName); err != nil {
+		return nil, err
+	}
+	if pluginInput.CustomSource {
+		if err = validateManifestSchema(manifest, context, pluginInput.AgentName); err != nil {
+			return nil, fmt.Errorf("invalid manifest %v, %v", downloadInput.SourceURL, err)
+		}
+	}
+	return manifest, nil
 }
 
 //downloadUpdater downloads updater from the s3 bucket
@@ -443,7 +484,7 @@ func Name() string {
 // GetUpdatePluginConfig returns the default values for the update plugin
 func GetUpdatePluginConfig(context context.T) UpdatePluginConfig {
 	log := context.Log()
-	region, err := platform.Region()
+	region, err := getRegion()
 	if err != nil {
 		log.Errorf("Error retrieving agent region in update plugin config. error: %v\n", err)
 	}
@@ -455,7 +496,18 @@ func GetUpdatePluginConfig(context context.T) UpdatePluginConfig {
 		manifestUrl = CommonManifestURL
 	}
 
+	var artifactLocation string
+	if config, err := getAppConfig(false); err != nil {
+		log.Errorf("Error loading agent config in update plugin config. error: %v\n", err)
+	} else {
+		if len(config.Update.ManifestURL) > 0 {
+			manifestUrl = config.Update.ManifestURL
+		}
+		artifactLocation = config.Update.ArtifactBaseURL
+	}
+
 	return UpdatePluginConfig{
 		ManifestLocation: manifestUrl,
+		ArtifactLocation: artifactLocation,
 	}
 }
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
@@ -50,6 +51,77 @@ func TestGenerateUpdateCmd(t *testing.T) {
 	assert.Contains(t, result, "bucket")
 }
 
+func TestGenerateUpdateCmdWithDelta(t *testing.T) {
+	plugin := createStubPluginInput()
+	context := createStubInstanceContext()
+	manifest := createStubManifest(plugin, context, true, true)
+	for _, p := range manifest.Packages {
+		for _, f := range p.Files {
+			for _, v := range f.AvailableVersions {
+				if v.Version == plugin.TargetVersion {
+					v.Deltas = []*PackageDelta{{SourceVersion: version.Version, Checksum: "deltahash"}}
+				}
+			}
+		}
+	}
+	manager := updateManager{}
+
+	result, err := manager.generateUpdateCmd(logger, manifest, plugin, context,
+		"path", "messageID", "stdout", "stderr", "prefix", "bucket")
+
+	assert.NoError(t, err)
+	assert.Contains(t, result, updateutil.TargetDeltaLocationCmd)
+	assert.Contains(t, result, "-from-"+version.Version+".bsdiff")
+	assert.Contains(t, result, "deltahash")
+}
+
+func TestGenerateUpdateCmdWithoutDelta(t *testing.T) {
+	plugin := createStubPluginInput()
+	context := createStubInstanceContext()
+	manifest := createStubManifest(plugin, context, true, true)
+	manager := updateManager{}
+
+	result, err := manager.generateUpdateCmd(logger, manifest, plugin, context,
+		"path", "messageID", "stdout", "stderr", "prefix", "bucket")
+
+	assert.NoError(t, err)
+	assert.NotContains(t, result, updateutil.TargetDeltaLocationCmd)
+}
+
+func TestGenerateUpdateCmdWithProgressLogStream(t *testing.T) {
+	plugin := createStubPluginInput()
+	plugin.LogGroupName = "group"
+	plugin.LogStreamName = "prefix/updateProgress"
+	context := createStubInstanceContext()
+	manifest := createStubManifest(plugin, context, true, true)
+	manager := updateManager{}
+
+	result, err := manager.generateUpdateCmd(logger, manifest, plugin, context,
+		"path", "messageID", "stdout", "stderr", "prefix", "bucket")
+
+	assert.NoError(t, err)
+	assert.Contains(t, result, "-"+updateutil.OutputLogGroupCmd+" group")
+	assert.Contains(t, result, "-"+updateutil.OutputLogStreamCmd+" prefix/updateProgress")
+}
+
+func TestGenerateUpdateCmdAllowDowngrade(t *testing.T) {
+	plugin := createStubPluginInput()
+	context := createStubInstanceContext()
+	manifest := createStubManifest(plugin, context, true, true)
+	manager := updateManager{}
+
+	result, err := manager.generateUpdateCmd(logger, manifest, plugin, context,
+		"path", "messageID", "stdout", "stderr", "prefix", "bucket")
+	assert.NoError(t, err)
+	assert.Contains(t, result, "-"+updateutil.AllowDowngradeCmd)
+
+	plugin.AllowDowngrade = "false"
+	result, err = manager.generateUpdateCmd(logger, manifest, plugin, context,
+		"path", "messageID", "stdout", "stderr", "prefix", "bucket")
+	assert.NoError(t, err)
+	assert.NotContains(t, result, "-"+updateutil.AllowDowngradeCmd)
+}
+
 func TestDownloadManifest(t *testing.T) {
 	plugin := createStubPluginInput()
 	context := createStubInstanceContext()
@@ -71,6 +143,72 @@ func TestDownloadManifest(t *testing.T) {
 	assert.NotNil(t, manifest)
 }
 
+func TestDownloadManifestCustomSource(t *testing.T) {
+	plugin := createStubPluginInput()
+	plugin.CustomSource = true
+	context := createStubInstanceContext()
+
+	manager := updateManager{}
+	util := fakeUtility{}
+	out := iohandler.DefaultIOHandler{}
+
+	fileDownload = func(log log.T, input artifact.DownloadInput) (output artifact.DownloadOutput, err error) {
+		result := artifact.DownloadOutput{}
+		result.IsHashMatched = true
+		result.LocalFilePath = "testdata/sampleManifest.json"
+		return result, nil
+	}
+
+	manifest, err := manager.downloadManifest(logger, &util, plugin, context, &out)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, manifest)
+}
+
+func TestDownloadManifestCustomSourceInvalidSchema(t *testing.T) {
+	plugin := createStubPluginInput()
+	plugin.CustomSource = true
+	context := createStubInstanceContext()
+
+	manager := updateManager{}
+	util := fakeUtility{}
+	out := iohandler.DefaultIOHandler{}
+
+	fileDownload = func(log log.T, input artifact.DownloadInput) (output artifact.DownloadOutput, err error) {
+		result := artifact.DownloadOutput{}
+		result.IsHashMatched = true
+		result.LocalFilePath = "testdata/customManifest.json"
+		return result, nil
+	}
+
+	manifest, err := manager.downloadManifest(logger, &util, plugin, context, &out)
+	assert.Error(t, err)
+	assert.Nil(t, manifest)
+
+	// the same manifest is accepted from the manifest of the regions
+	plugin.CustomSource = false
+	manifest, err = manager.downloadManifest(logger, &util, plugin, context, &out)
+	assert.NoError(t, err)
+	assert.NotNil(t, manifest)
+}
+
+func TestGetUpdatePluginConfigCustomSource(t *testing.T) {
+	originalConfig, originalRegion := getAppConfig, getRegion
+	defer func() { getAppConfig, getRegion = originalConfig, originalRegion }()
+	getRegion = func() (string, error) { return "us-east-1", nil }
+	getAppConfig = func(bool) (appconfig.SsmagentConfig, error) {
+		config := appconfig.DefaultConfig()
+		config.Update.ManifestURL = "https://mirror.example.com/ssm/ssm-agent-manifest.json"
+		config.Update.ArtifactBaseURL = "https://mirror.example.com/ssm"
+		return config, nil
+	}
+
+	config := GetUpdatePluginConfig(context.NewMockDefault())
+
+	assert.Equal(t, "https://mirror.example.com/ssm/ssm-agent-manifest.json", config.ManifestLocation)
+	assert.Equal(t, "https://mirror.example.com/ssm", config.ArtifactLocation)
+}
+
 func TestDownloadUpdater(t *testing.T) {
 	plugin := createStubPluginInput()
 	context := createStubInstanceContext()
@@ -0,0 +1,77 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// maxHookErrorLength bounds the standard error of a failing hook reported in the reason of the denial
+const maxHookErrorLength = 512
+
+// runHook runs the hook with the document as json on its standard input and reads its verdict as json
+// from its standard output, such as {"allow": false, "reason": "..."}. A hook that fails, times out or
+// writes an invalid verdict denies the document.
+func runHook(log log.T, hookPath string, timeout time.Duration, input Input) Verdict {
+	content, err := json.Marshal(input)
+	if err != nil {
+		return Verdict{Reason: fmt.Sprintf("failed to serialize the document for the policy hook: %v", err)}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(hookPath)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Debugf("Running the policy hook %v", hookPath)
+	if err = cmd.Start(); err != nil {
+		return Verdict{Reason: fmt.Sprintf("the policy hook failed to start: %v", err)}
+	}
+	// don't wait for the children of the hook to release its output after a timeout
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return Verdict{Reason: fmt.Sprintf("the policy hook timed out after %v", timeout)}
+	}
+	if err != nil {
+		reason := fmt.Sprintf("the policy hook failed: %v", err)
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			if len(message) > maxHookErrorLength {
+				message = message[:maxHookErrorLength]
+			}
+			reason += ": " + message
+		}
+		return Verdict{Reason: reason}
+	}
+
+	var verdict Verdict
+	if err = json.Unmarshal(stdout.Bytes(), &verdict); err != nil {
+		return Verdict{Reason: fmt.Sprintf("the policy hook returned an invalid verdict: %v", err)}
+	}
+	if !verdict.Allow && verdict.Reason == "" {
+		verdict.Reason = "denied by the policy hook"
+	}
+	return verdict
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	input := Input{DocumentName: "AWS-RunShellScript", Steps: []Step{{Name: "run", Action: "aws:runShellScript"}}}
+
+	testCases := []struct {
+		name     string
+		script   string
+		expected Verdict
+	}{
+		{"allow", `grep -q '"documentName":"AWS-RunShellScript"' && echo '{"allow": true}'`, Verdict{Allow: true}},
+		{"deny", `echo '{"allow": false, "reason": "change freeze"}'`, Verdict{Reason: "change freeze"}},
+		{"deny without reason", `echo '{}'`, Verdict{Reason: "denied by the policy hook"}},
+		{"failure", `echo 'policy unavailable' >&2; exit 3`, Verdict{Reason: "the policy hook failed: exit status 3: policy unavailable"}},
+		{"invalid verdict", `echo 'yes'`, Verdict{Reason: "the policy hook returned an invalid verdict: invalid character 'y' looking for beginning of value"}},
+		{"timeout", `sleep 5`, Verdict{Reason: "the policy hook timed out after 500ms"}},
+	}
+	for _, testCase := range testCases {
+		hookPath := filepath.Join(dir, "hook.sh")
+		assert.NoError(t, ioutil.WriteFile(hookPath, []byte("#!/bin/sh\n"+testCase.script+"\n"), 0700))
+		assert.Equal(t, testCase.expected, runHook(log.NewMockLog(), hookPath, 500*time.Millisecond, input), testCase.name)
+	}
+}
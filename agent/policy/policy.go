@@ -0,0 +1,117 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package policy consults the local policy of the instance before the documents run, so the instance can refuse
+// documents independently of IAM.
+package policy
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// Input is the document submitted to the local policy
+type Input struct {
+	DocumentName    string `json:"documentName"`
+	DocumentVersion string `json:"documentVersion,omitempty"`
+	DocumentType    string `json:"documentType"`
+	CommandID       string `json:"commandId,omitempty"`
+	AssociationID   string `json:"associationId,omitempty"`
+	Requester       string `json:"requester,omitempty"`
+	Steps           []Step `json:"steps"`
+}
+
+// Step is a step of the document submitted to the local policy
+type Step struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// Inputs are the resolved inputs of the step, with the secrets redacted
+	Inputs interface{} `json:"inputs,omitempty"`
+}
+
+// Verdict is the decision of the local policy on a document
+type Verdict struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Enabled returns true when a local policy is configured
+func Enabled(config appconfig.PolicyCfg) bool {
+	return config.RulesPath != "" || config.HookPath != ""
+}
+
+// NewInput builds the input of the local policy from the state of a document. The SecureString parameters were
+// replaced by markers when the document was parsed, the other sensitive values of the inputs are redacted like in
+// the logs.
+func NewInput(docState *contracts.DocumentState) Input {
+	docInfo := docState.DocumentInformation
+	input := Input{
+		DocumentName:    docInfo.DocumentName,
+		DocumentVersion: docInfo.DocumentVersion,
+		DocumentType:    string(docState.DocumentType),
+		CommandID:       docInfo.CommandID,
+		AssociationID:   docInfo.AssociationID,
+		Requester:       docInfo.ClientId,
+		Steps:           []Step{},
+	}
+	for _, plugin := range docState.InstancePluginsInformation {
+		input.Steps = append(input.Steps, Step{
+			Name:   plugin.Id,
+			Action: plugin.Name,
+			Inputs: redactInputs(plugin.Configuration.Properties),
+		})
+	}
+	return input
+}
+
+// Evaluate consults the rules, then the hook of the local policy, both of them must allow the document.
+// A policy that cannot be evaluated denies the document.
+func Evaluate(log log.T, config appconfig.PolicyCfg, input Input) Verdict {
+	if config.RulesPath != "" {
+		if verdict := evaluateRules(config.RulesPath, input); !verdict.Allow {
+			return verdict
+		}
+	}
+	if config.HookPath != "" {
+		timeout := time.Duration(config.HookTimeoutSeconds) * time.Second
+		if verdict := runHook(log, config.HookPath, timeout, input); !verdict.Allow {
+			return verdict
+		}
+	}
+	return Verdict{Allow: true}
+}
+
+// redactInputs redacts the sensitive values of the strings of the inputs of a step
+func redactInputs(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return log.Redact(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactInputs(item)
+		}
+		return redacted
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = redactInputs(item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDocumentState() *contracts.DocumentState {
+	docState := &contracts.DocumentState{
+		DocumentType: contracts.SendCommand,
+		InstancePluginsInformation: []contracts.PluginState{
+			{
+				Id:   "install",
+				Name: "aws:runShellScript",
+				Configuration: contracts.Configuration{
+					Properties: map[string]interface{}{
+						"runCommand":     []interface{}{"mysql -u admin --password=hunter2", "echo {{ssm-secure:token}}"},
+						"timeoutSeconds": 60.0,
+					},
+				},
+			},
+		},
+	}
+	docState.DocumentInformation.DocumentName = "Install-Database"
+	docState.DocumentInformation.CommandID = "commandID"
+	docState.DocumentInformation.ClientId = "admin-session"
+	return docState
+}
+
+func TestNewInput(t *testing.T) {
+	input := NewInput(testDocumentState())
+
+	assert.Equal(t, "Install-Database", input.DocumentName)
+	assert.Equal(t, string(contracts.SendCommand), input.DocumentType)
+	assert.Equal(t, "commandID", input.CommandID)
+	assert.Equal(t, "admin-session", input.Requester)
+	assert.Equal(t, []Step{{
+		Name:   "install",
+		Action: "aws:runShellScript",
+		Inputs: map[string]interface{}{
+			"runCommand":     []interface{}{"mysql -u admin --password=" + log.RedactedMask, "echo {{ssm-secure:token}}"},
+			"timeoutSeconds": 60.0,
+		},
+	}}, input.Steps)
+}
+
+func TestEvaluate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	rulesPath := filepath.Join(dir, "rules.json")
+	input := NewInput(testDocumentState())
+	logger := log.NewMockLog()
+
+	// no policy
+	assert.Equal(t, Verdict{Allow: true}, Evaluate(logger, appconfig.PolicyCfg{}, input))
+	assert.False(t, Enabled(appconfig.PolicyCfg{}))
+
+	// missing rules file
+	config := appconfig.PolicyCfg{RulesPath: rulesPath}
+	assert.True(t, Enabled(config))
+	verdict := Evaluate(logger, config, input)
+	assert.False(t, verdict.Allow)
+	assert.Contains(t, verdict.Reason, "failed to load the policy rules")
+
+	rules := `{"rules": [{"effect": "deny", "actions": ["aws:runShellScript"], "reason": "shell scripts are not allowed"}]}`
+	assert.NoError(t, ioutil.WriteFile(rulesPath, []byte(rules), 0600))
+	assert.Equal(t, Verdict{Reason: "shell scripts are not allowed"}, Evaluate(logger, config, input))
+}
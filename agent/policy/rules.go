@@ -0,0 +1,135 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+// Effects of the rules
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Rules is the content of the rules file of the local policy. The first rule matching a document decides,
+// the default effect decides when no rule matches and allows the documents when empty.
+type Rules struct {
+	DefaultEffect string `json:"defaultEffect"`
+	Rules         []Rule `json:"rules"`
+}
+
+// Rule allows or denies the documents it matches, a rule without conditions matches all the documents
+type Rule struct {
+	Effect string `json:"effect"`
+	// DocumentNames are patterns of the names of the documents, such as AWS-*
+	DocumentNames []string `json:"documentNames,omitempty"`
+	// DocumentTypes are the types of the documents, such as SendCommand, Association or StartSession
+	DocumentTypes []string `json:"documentTypes,omitempty"`
+	// Actions are patterns of the actions of the steps, a document matches when one of its steps does
+	Actions []string `json:"actions,omitempty"`
+	// Reason is reported when the rule denies a document
+	Reason string `json:"reason,omitempty"`
+}
+
+// evaluateRules loads the rules file and evaluates the document against its rules
+func evaluateRules(rulesPath string, input Input) Verdict {
+	var rules Rules
+	if err := jsonutil.UnmarshalFile(rulesPath, &rules); err != nil {
+		return Verdict{Reason: fmt.Sprintf("failed to load the policy rules: %v", err)}
+	}
+	if err := rules.validate(); err != nil {
+		return Verdict{Reason: fmt.Sprintf("invalid policy rules %v: %v", rulesPath, err)}
+	}
+	return rules.evaluate(input)
+}
+
+// validate checks the effects and the patterns of the rules
+func (r Rules) validate() error {
+	if r.DefaultEffect != "" && r.DefaultEffect != EffectAllow && r.DefaultEffect != EffectDeny {
+		return fmt.Errorf("unknown default effect %q", r.DefaultEffect)
+	}
+	for i, rule := range r.Rules {
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return fmt.Errorf("unknown effect %q of rule %v", rule.Effect, i)
+		}
+		for _, pattern := range append(rule.DocumentNames, rule.Actions...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q of rule %v", pattern, i)
+			}
+		}
+	}
+	return nil
+}
+
+// evaluate returns the verdict of the first rule matching the document
+func (r Rules) evaluate(input Input) Verdict {
+	for i, rule := range r.Rules {
+		if !rule.matches(input) {
+			continue
+		}
+		if rule.Effect == EffectAllow {
+			return Verdict{Allow: true}
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("denied by rule %v of the local policy", i)
+		}
+		return Verdict{Reason: reason}
+	}
+	if r.DefaultEffect == EffectDeny {
+		return Verdict{Reason: "no rule of the local policy allows the document"}
+	}
+	return Verdict{Allow: true}
+}
+
+// matches returns true when the document meets all the conditions of the rule
+func (rule Rule) matches(input Input) bool {
+	if len(rule.DocumentNames) > 0 && !matchesAny(rule.DocumentNames, input.DocumentName) {
+		return false
+	}
+	if len(rule.DocumentTypes) > 0 && !containsString(rule.DocumentTypes, input.DocumentType) {
+		return false
+	}
+	if len(rule.Actions) > 0 {
+		for _, step := range input.Steps {
+			if matchesAny(rule.Actions, step.Action) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
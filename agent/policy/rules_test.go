@@ -0,0 +1,71 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRulesEvaluate(t *testing.T) {
+	input := Input{
+		DocumentName: "AWS-RunShellScript",
+		DocumentType: "SendCommand",
+		Steps:        []Step{{Name: "first", Action: "aws:downloadContent"}, {Name: "second", Action: "aws:runShellScript"}},
+	}
+
+	testCases := []struct {
+		name     string
+		rules    Rules
+		expected Verdict
+	}{
+		{"no rule", Rules{}, Verdict{Allow: true}},
+		{"default deny", Rules{DefaultEffect: EffectDeny}, Verdict{Reason: "no rule of the local policy allows the document"}},
+		{
+			"first matching rule decides",
+			Rules{DefaultEffect: EffectDeny, Rules: []Rule{
+				{Effect: EffectDeny, DocumentNames: []string{"Custom-*"}},
+				{Effect: EffectAllow, DocumentNames: []string{"AWS-*"}, DocumentTypes: []string{"SendCommand"}},
+				{Effect: EffectDeny},
+			}},
+			Verdict{Allow: true},
+		},
+		{
+			"any step matches",
+			Rules{Rules: []Rule{{Effect: EffectDeny, Actions: []string{"aws:run*"}, Reason: "no scripts"}}},
+			Verdict{Reason: "no scripts"},
+		},
+		{
+			"all conditions must match",
+			Rules{Rules: []Rule{{Effect: EffectDeny, Actions: []string{"aws:run*"}, DocumentTypes: []string{"Association"}}}},
+			Verdict{Allow: true},
+		},
+		{
+			"reason defaults to the rule",
+			Rules{Rules: []Rule{{Effect: EffectAllow, DocumentTypes: []string{"Association"}}, {Effect: EffectDeny}}},
+			Verdict{Reason: "denied by rule 1 of the local policy"},
+		},
+	}
+	for _, testCase := range testCases {
+		assert.NoError(t, testCase.rules.validate(), testCase.name)
+		assert.Equal(t, testCase.expected, testCase.rules.evaluate(input), testCase.name)
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	assert.Error(t, Rules{DefaultEffect: "maybe"}.validate())
+	assert.Error(t, Rules{Rules: []Rule{{Effect: "Deny"}}}.validate())
+	assert.Error(t, Rules{Rules: []Rule{{Effect: EffectDeny, DocumentNames: []string{"AWS-["}}}}.validate())
+}
@@ -57,19 +57,15 @@ If the user didn't specify one, it will return the Amazon MGS endpoint in a cert
 func GetMgsEndpoint(region string) (mgsEndpoint string) {
 	if appConfig, err := appconfig.Config(false); err == nil {
 		if appConfig.Mgs.Endpoint != "" {
-			// use net/url package to parse endpoint, if endpoint doesn't contain protocol,
-			// fullUrl.Host is empty, should return fullUrl.Path. For backwards compatible, return the non-empty one.
-			fullUrl, err := url.Parse(appConfig.Mgs.Endpoint)
-			if err != nil {
-				return ""
-			}
-			if fullUrl.Host != "" {
-				return fullUrl.Host
-			}
-			return fullUrl.Path
+			return endpointHost(appConfig.Mgs.Endpoint)
 		}
 	}
 
+	// endpoint overrides such as VPC endpoints take precedence over the endpoints of the regions
+	if defaultEndpoint := appconfig.GetDefaultEndPoint(region, MgsServiceName); defaultEndpoint != "" {
+		return endpointHost(defaultEndpoint)
+	}
+
 	if mgsEndpoint, ok := awsMessageGatewayServiceEndpointMap[region]; ok {
 		return mgsEndpoint
 	}
@@ -83,11 +79,19 @@ func GetMgsEndpoint(region string) (mgsEndpoint string) {
 
 // GetDefaultServiceEndpoint returns the default endpoint for a service, it should not be empty.
 func GetDefaultServiceEndpoint(region string, service string) (endpoint string) {
-	defaultEndpoint := appconfig.GetDefaultEndPoint(region, service)
-	if defaultEndpoint != "" {
-		endpoint = defaultEndpoint
-	} else {
-		endpoint = service + "." + region + ".amazonaws.com"
+	return endpointHost(appconfig.ResolveEndpoint(region, service))
+}
+
+// endpointHost returns the host of an endpoint
+func endpointHost(endpoint string) string {
+	// use net/url package to parse endpoint, if endpoint doesn't contain protocol,
+	// fullUrl.Host is empty, should return fullUrl.Path. For backwards compatible, return the non-empty one.
+	fullUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	if fullUrl.Host != "" {
+		return fullUrl.Host
 	}
-	return endpoint
+	return fullUrl.Path
 }
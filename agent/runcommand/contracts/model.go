@@ -47,6 +47,26 @@ type SendReplyPayload struct {
 	RuntimeStatus       map[string]*contracts.PluginRuntimeStatus `json:"runtimeStatus"`
 }
 
+// OfflineCommandResult represents the json structure of the result file written next to a local command document.
+type OfflineCommandResult struct {
+	CommandID      string                 `json:"commandId"`
+	DocumentName   string                 `json:"documentName"`
+	DocumentStatus contracts.ResultStatus `json:"documentStatus"`
+	DateTime       string                 `json:"dateTime"`
+	Steps          []OfflineStepResult    `json:"steps"`
+}
+
+// OfflineStepResult represents the result of a step of a local command document.
+type OfflineStepResult struct {
+	StepName           string                 `json:"stepName"`
+	PluginName         string                 `json:"pluginName"`
+	Status             contracts.ResultStatus `json:"status"`
+	ExitCode           int                    `json:"exitCode"`
+	OutputDirectory    string                 `json:"outputDirectory,omitempty"`
+	StandardOutputPath string                 `json:"standardOutputPath,omitempty"`
+	StandardErrorPath  string                 `json:"standardErrorPath,omitempty"`
+}
+
 //getCommandID gets CommandID from given MessageID
 func getCommandID(messageID string) string {
 	// MdsMessageID is in the format of : aws.ssm.CommandId.InstanceId
@@ -67,6 +67,10 @@ func (s *RunCommandService) ModuleExecute(context context.T) (err error) {
 
 	go s.listenReply(resultChan)
 
+	if s.reloadOnConfigChange {
+		appconfig.Subscribe(s.name, s.onConfigChange)
+	}
+
 	if err = s.processor.InitialProcessing(); err != nil {
 		log.Errorf("initial processing in EngineProcessor encountered error: %v", err)
 		return
@@ -90,6 +94,9 @@ func (s *RunCommandService) ModuleExecute(context context.T) (err error) {
 }
 
 func (s *RunCommandService) ModuleRequestStop(stopType contracts.StopType) (err error) {
+	if s.reloadOnConfigChange {
+		appconfig.Unsubscribe(s.name)
+	}
 	//first stop sending failed replies to the service and the message poller
 	s.stop()
 	//second stop the message processor
@@ -177,12 +184,12 @@ func (s *RunCommandService) processMessage(msg *ssmmds.Message) {
 
 	if err != nil {
 		log.Error("format of received message is invalid ", err)
-		if err = s.service.FailMessage(log, *msg.MessageId, mdsService.InternalHandlerException); err != nil {
+		if err = s.messageService().FailMessage(log, *msg.MessageId, mdsService.InternalHandlerException); err != nil {
 			sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
 		}
 		return
 	}
-	if err = s.service.AcknowledgeMessage(log, *msg.MessageId); err != nil {
+	if err = s.messageService().AcknowledgeMessage(log, *msg.MessageId); err != nil {
 		sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
 		return
 	}
@@ -212,7 +219,8 @@ func (s *RunCommandService) sendFailedReplies() {
 	log := s.context.Log()
 
 	log.Debug("Checking if there are document replies that failed to reach the service, and retry sending them")
-	replies := s.service.LoadFailedReplies(log)
+	service := s.messageService()
+	replies := service.LoadFailedReplies(log)
 
 	if len(replies) != 0 {
 		log.Infof("Found document replies that need to be sent to the service")
@@ -220,22 +228,22 @@ func (s *RunCommandService) sendFailedReplies() {
 			log.Debug("Loading reply ", reply)
 			if isValidReplyRequest(reply) == false {
 				log.Debug("Reply is old, document execution must have timed out. Deleting the reply")
-				s.service.DeleteFailedReply(log, reply)
+				service.DeleteFailedReply(log, reply)
 				continue
 			}
-			sendReplyRequest, err := s.service.GetFailedReply(log, reply)
+			sendReplyRequest, err := service.GetFailedReply(log, reply)
 			if err != nil {
 				log.Error("Couldn't load the reply from disk ", err)
 				continue
 			}
 
 			log.Info("Sending reply ", reply)
-			if err = s.service.SendReplyWithInput(log, sendReplyRequest); err != nil {
+			if err = service.SendReplyWithInput(log, sendReplyRequest); err != nil {
 				sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
 				break
 			} else {
 				log.Infof("Sending reply %v succeeded, deleting the reply file from disk", reply)
-				s.service.DeleteFailedReply(log, reply)
+				service.DeleteFailedReply(log, reply)
 			}
 		}
 	} else {
@@ -15,8 +15,11 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -25,6 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
@@ -33,6 +37,9 @@ import (
 	"github.com/twinj/uuid"
 )
 
+// CommandResultFileSuffix is appended to the name of a submitted command document to name its result file
+const CommandResultFileSuffix = ".result.json"
+
 type offlineService struct {
 	TopicPrefix         string
 	newCommandDir       string
@@ -162,9 +169,82 @@ func (ols *offlineService) SendReply(log log.T, messageID string, payload string
 	if err := fileutil.WriteAllText(filepath.Join(ols.commandResultDir, commandID), payload); err != nil {
 		log.Errorf("failed to write command %v result: %v", commandID, err)
 	}
+	if err := ols.writeCommandResultFile(commandID, payload); err != nil {
+		log.Errorf("failed to write command %v result file: %v", commandID, err)
+	}
 	return nil
 }
 
+// writeCommandResultFile writes the structured result of a command next to its submitted document,
+// the file is replaced on each reply so it always holds the latest status of the command
+func (ols *offlineService) writeCommandResultFile(commandID string, payload string) error {
+	docPaths, err := filepath.Glob(filepath.Join(ols.submittedCommandDir, "*."+commandID))
+	if err != nil {
+		return err
+	}
+	if len(docPaths) != 1 {
+		return fmt.Errorf("found %v submitted documents for the command", len(docPaths))
+	}
+	docName := strings.TrimSuffix(filepath.Base(docPaths[0]), "."+commandID)
+
+	var reply messageContracts.SendReplyPayload
+	if err = json.Unmarshal([]byte(payload), &reply); err != nil {
+		return err
+	}
+	result := newOfflineCommandResult(commandID, docName, reply)
+	content, err := jsonutil.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	// write a temporary file first so the result file is never read partially written
+	resultPath := docPaths[0] + CommandResultFileSuffix
+	tempPath := resultPath + ".tmp"
+	if err = fileutil.WriteAllText(tempPath, jsonutil.Indent(content)); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, resultPath)
+}
+
+// newOfflineCommandResult builds the result of a command from its reply, the steps are sorted by name
+func newOfflineCommandResult(commandID string, docName string, reply messageContracts.SendReplyPayload) messageContracts.OfflineCommandResult {
+	result := messageContracts.OfflineCommandResult{
+		CommandID:      commandID,
+		DocumentName:   docName,
+		DocumentStatus: reply.DocumentStatus,
+		DateTime:       reply.AdditionalInfo.DateTime,
+		Steps:          []messageContracts.OfflineStepResult{},
+	}
+
+	var stepNames []string
+	for stepName := range reply.RuntimeStatus {
+		stepNames = append(stepNames, stepName)
+	}
+	sort.Strings(stepNames)
+
+	outputConfig := iohandler.DefaultOutputConfig()
+	for _, stepName := range stepNames {
+		status := reply.RuntimeStatus[stepName]
+		step := messageContracts.OfflineStepResult{
+			StepName:        stepName,
+			PluginName:      status.Name,
+			Status:          status.Status,
+			ExitCode:        status.Code,
+			OutputDirectory: status.OutputDirectory,
+		}
+		if status.OutputDirectory != "" {
+			if stdoutPath := filepath.Join(status.OutputDirectory, outputConfig.StdoutFileName); fileutil.Exists(stdoutPath) {
+				step.StandardOutputPath = stdoutPath
+			}
+			if stderrPath := filepath.Join(status.OutputDirectory, outputConfig.StderrFileName); fileutil.Exists(stderrPath) {
+				step.StandardErrorPath = stderrPath
+			}
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	return result
+}
+
 func (ols *offlineService) FailMessage(log log.T, messageID string, failureType FailureType) error {
 	return nil
 }
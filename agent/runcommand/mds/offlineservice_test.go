@@ -15,11 +15,16 @@
 package service
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -87,6 +92,50 @@ func TestOfflineService_SendReply(t *testing.T) {
 	assert.Equal(t, 1, FileCount(completeDir))
 }
 
+func TestOfflineService_WriteCommandResultFile(t *testing.T) {
+	submittedDir, err := ioutil.TempDir("", "submitted")
+	assert.NoError(t, err)
+	defer os.RemoveAll(submittedDir)
+	outputDir, err := ioutil.TempDir("", "output")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outputDir)
+
+	service := &offlineService{submittedCommandDir: submittedDir}
+	docPath := filepath.Join(submittedDir, "command.json.testCommandID")
+	assert.NoError(t, fileutil.WriteAllText(docPath, "{}"))
+	assert.NoError(t, fileutil.WriteAllText(filepath.Join(outputDir, "stdout"), "hello"))
+
+	payload, _ := jsonutil.Marshal(messageContracts.SendReplyPayload{
+		DocumentStatus: contracts.ResultStatusFailed,
+		RuntimeStatus: map[string]*contracts.PluginRuntimeStatus{
+			"step2": {Name: "aws:runShellScript", Status: contracts.ResultStatusFailed, Code: 2},
+			"step1": {Name: "aws:runShellScript", Status: contracts.ResultStatusSuccess, OutputDirectory: outputDir},
+		},
+	})
+	assert.NoError(t, service.writeCommandResultFile("testCommandID", payload))
+
+	var result messageContracts.OfflineCommandResult
+	assert.NoError(t, jsonutil.UnmarshalFile(docPath+CommandResultFileSuffix, &result))
+	assert.Equal(t, messageContracts.OfflineCommandResult{
+		CommandID:      "testCommandID",
+		DocumentName:   "command.json",
+		DocumentStatus: contracts.ResultStatusFailed,
+		Steps: []messageContracts.OfflineStepResult{
+			{
+				StepName:           "step1",
+				PluginName:         "aws:runShellScript",
+				Status:             contracts.ResultStatusSuccess,
+				OutputDirectory:    outputDir,
+				StandardOutputPath: filepath.Join(outputDir, "stdout"),
+			},
+			{StepName: "step2", PluginName: "aws:runShellScript", Status: contracts.ResultStatusFailed, ExitCode: 2},
+		},
+	}, result)
+
+	// a command without submitted document has no result file
+	assert.Error(t, service.writeCommandResultFile("otherCommandID", payload))
+}
+
 func GetTestService() Service {
 	CleanTestDirs()
 	return &offlineService{
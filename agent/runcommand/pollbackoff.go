@@ -0,0 +1,97 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runcommand implements runcommand core processing module
+package runcommand
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
+)
+
+const (
+	// fastPollDuration is the duration under which a poll is considered as returned without blocking
+	fastPollDuration = time.Second
+
+	// minPollDelay is the delay after a poll returned without blocking, so we don't flood the service with requests
+	minPollDelay = 2 * time.Second
+
+	// idlePollDelayBase is the delay after the first poll without message, it doubles after each poll without message
+	idlePollDelayBase = 2 * time.Second
+
+	// maxIdlePollsDoubling bounds the doubling of the idle delay to avoid overflows
+	maxIdlePollsDoubling = 16
+
+	// pollJitterRatio is the ratio of the delay randomly added or removed so the fleet doesn't poll in lockstep
+	pollJitterRatio = 0.25
+)
+
+var (
+	pollIntervalGauge = metrics.NewGauge("ssm_agent_poll_interval_seconds",
+		"Effective interval between the starts of the last two polls for messages, by service.", "service")
+	pollsCounter = metrics.NewCounter("ssm_agent_polls_total",
+		"Polls for messages, by service and result.", "service", "result")
+)
+
+// poll results counted by pollsCounter
+const (
+	pollResultMessages = "messages"
+	pollResultEmpty    = "empty"
+	pollResultError    = "error"
+)
+
+// randInt63n is decoupled for testability
+var randInt63n = rand.Int63n
+
+// pollBackoff computes the delay between two polls for messages, its zero value is ready to use
+type pollBackoff struct {
+	// idlePolls counts the polls without message since the last message was received
+	idlePolls int32
+}
+
+// nextDelay returns the delay before the next poll given the outcome of the last one. The next poll starts right
+// away after messages were received, the delay doubles after each poll without message or with an error up to
+// maxIdleDelay, and never goes below minPollDelay when the last poll returned without blocking.
+func (b *pollBackoff) nextDelay(receivedMessages bool, pollDuration time.Duration, maxIdleDelay time.Duration) time.Duration {
+	if receivedMessages {
+		atomic.StoreInt32(&b.idlePolls, 0)
+		return 0
+	}
+
+	doubling := atomic.AddInt32(&b.idlePolls, 1) - 1
+	if doubling > maxIdlePollsDoubling {
+		doubling = maxIdlePollsDoubling
+	}
+	delay := idlePollDelayBase << uint(doubling)
+	if delay > maxIdleDelay {
+		delay = maxIdleDelay
+	}
+	delay = jitter(delay)
+
+	if pollDuration < fastPollDuration && delay < minPollDelay {
+		delay = minPollDelay + time.Duration(randInt63n(int64(minPollDelay/4)))
+	}
+	return delay
+}
+
+// jitter randomly adds or removes up to pollJitterRatio of delay
+func jitter(delay time.Duration) time.Duration {
+	spread := int64(float64(delay) * pollJitterRatio)
+	if spread <= 0 {
+		return delay
+	}
+	return delay - time.Duration(spread) + time.Duration(randInt63n(2*spread+1))
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runcommand implements runcommand core processing module
+package runcommand
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setJitter replaces the random source of the jitter and returns the function restoring it
+func setJitter(random func(n int64) int64) (restore func()) {
+	randInt63n = random
+	return func() { randInt63n = rand.Int63n }
+}
+
+func TestPollBackoffIdle(t *testing.T) {
+	defer setJitter(func(n int64) int64 { return n / 2 })()
+	var backoff pollBackoff
+
+	// the delay doubles after each poll without message up to the max
+	assert.Equal(t, 2*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+	assert.Equal(t, 4*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+	assert.Equal(t, 8*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+	assert.Equal(t, 16*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+	assert.Equal(t, 30*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+	for i := 0; i < 100; i++ {
+		backoff.nextDelay(false, 20*time.Second, 30*time.Second)
+	}
+	assert.Equal(t, 30*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+
+	// the next poll starts right away after receiving messages and the backoff restarts
+	assert.Equal(t, time.Duration(0), backoff.nextDelay(true, 20*time.Second, 30*time.Second))
+	assert.Equal(t, 2*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+}
+
+func TestPollBackoffDisabled(t *testing.T) {
+	defer setJitter(func(n int64) int64 { return 0 })()
+	var backoff pollBackoff
+
+	// a blocking poll is followed right away by the next one
+	assert.Equal(t, time.Duration(0), backoff.nextDelay(false, 20*time.Second, 0))
+	assert.Equal(t, time.Duration(0), backoff.nextDelay(false, 20*time.Second, 0))
+
+	// a poll returned without blocking still waits the min delay
+	assert.Equal(t, minPollDelay, backoff.nextDelay(false, 10*time.Millisecond, 0))
+}
+
+func TestPollBackoffJitter(t *testing.T) {
+	var backoff pollBackoff
+
+	defer setJitter(func(n int64) int64 { return 0 })()
+	assert.Equal(t, 1500*time.Millisecond, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+
+	randInt63n = func(n int64) int64 { return n - 1 }
+	assert.Equal(t, 5*time.Second, backoff.nextDelay(false, 20*time.Second, 30*time.Second))
+
+	// a poll returned without blocking waits between min delay and 25% more
+	assert.Equal(t, minPollDelay+minPollDelay/4-1, backoff.nextDelay(false, 10*time.Millisecond, time.Second))
+}
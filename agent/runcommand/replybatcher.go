@@ -0,0 +1,158 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runcommand implements runcommand core processing module
+package runcommand
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+)
+
+// sendReplyFunc sends the reply of a message to the service
+type sendReplyFunc func(messageID string, payloadDoc messageContracts.SendReplyPayload)
+
+// replyBatcher batches the replies of the messages still in progress within a window. Each reply carries the whole
+// status of its document, so only the latest reply of a message is sent when the window expires.
+// The replies of finished documents are sent right away and replace the pending reply of their message.
+// The replies are sent outside of the lock of the batcher, a reply is dropped when a more recent reply of its message
+// was sent in the meantime.
+// The replies are not compressed, the payload of SendReply is a plain json string and MDS supports no content encoding.
+type replyBatcher struct {
+	window   time.Duration
+	send     sendReplyFunc
+	lock     sync.Mutex
+	messages map[string]*messageReplies
+}
+
+// messageReplies tracks the replies of a message, received and pending are guarded by the lock of the batcher
+type messageReplies struct {
+	received   int
+	pending    *messageContracts.SendReplyPayload
+	pendingSeq int
+	timer      *time.Timer
+
+	// sendLock orders the replies of the message, sent is the sequence number of the last reply sent
+	sendLock sync.Mutex
+	sent     int
+}
+
+// newReplyBatcher creates a reply batcher, the replies are sent right away when window is 0
+func newReplyBatcher(window time.Duration, send sendReplyFunc) *replyBatcher {
+	return &replyBatcher{
+		window:   window,
+		send:     send,
+		messages: make(map[string]*messageReplies),
+	}
+}
+
+// reply sends the reply of a message or batches it until the end of the window of the message
+func (b *replyBatcher) reply(messageID string, payloadDoc messageContracts.SendReplyPayload) {
+	if b.window <= 0 {
+		b.send(messageID, payloadDoc)
+		return
+	}
+
+	b.lock.Lock()
+	message, found := b.messages[messageID]
+	if !found {
+		message = &messageReplies{}
+		b.messages[messageID] = message
+	}
+	message.received++
+	seq := message.received
+
+	if isBatchable(payloadDoc.DocumentStatus) {
+		message.pending = &payloadDoc
+		message.pendingSeq = seq
+		if message.timer == nil {
+			message.timer = time.AfterFunc(b.window, func() { b.flushMessage(messageID) })
+		}
+		b.lock.Unlock()
+		return
+	}
+
+	// the document finished, the message has no more replies
+	if message.timer != nil {
+		message.timer.Stop()
+	}
+	delete(b.messages, messageID)
+	b.lock.Unlock()
+	b.sendInOrder(messageID, message, seq, payloadDoc)
+}
+
+// flushMessage sends the pending reply of a message at the end of its window
+func (b *replyBatcher) flushMessage(messageID string) {
+	b.lock.Lock()
+	// the reply may have been replaced by the reply of the finished document in the meantime
+	message, found := b.messages[messageID]
+	if !found || message.pending == nil {
+		b.lock.Unlock()
+		return
+	}
+	payloadDoc, seq := *message.pending, message.pendingSeq
+	message.pending = nil
+	message.timer = nil
+	b.lock.Unlock()
+
+	b.sendInOrder(messageID, message, seq, payloadDoc)
+}
+
+// flush sends all the pending replies
+func (b *replyBatcher) flush() {
+	type flushedReply struct {
+		messageID  string
+		message    *messageReplies
+		seq        int
+		payloadDoc messageContracts.SendReplyPayload
+	}
+
+	b.lock.Lock()
+	var replies []flushedReply
+	for messageID, message := range b.messages {
+		if message.pending == nil {
+			continue
+		}
+		if message.timer != nil {
+			message.timer.Stop()
+			message.timer = nil
+		}
+		replies = append(replies, flushedReply{messageID, message, message.pendingSeq, *message.pending})
+		message.pending = nil
+	}
+	b.lock.Unlock()
+
+	for _, reply := range replies {
+		b.sendInOrder(reply.messageID, reply.message, reply.seq, reply.payloadDoc)
+	}
+}
+
+// sendInOrder sends a reply unless a more recent reply of its message was sent
+func (b *replyBatcher) sendInOrder(messageID string, message *messageReplies, seq int, payloadDoc messageContracts.SendReplyPayload) {
+	message.sendLock.Lock()
+	defer message.sendLock.Unlock()
+
+	if seq <= message.sent {
+		return
+	}
+	message.sent = seq
+	b.send(messageID, payloadDoc)
+}
+
+// isBatchable returns true for the statuses of the documents still in progress
+func isBatchable(documentStatus contracts.ResultStatus) bool {
+	return documentStatus == contracts.ResultStatusInProgress || documentStatus == contracts.ResultStatusNotStarted
+}
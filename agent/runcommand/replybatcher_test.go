@@ -0,0 +1,133 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runcommand implements runcommand core processing module
+package runcommand
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// sentReplies records the replies sent by a reply batcher
+type sentReplies struct {
+	lock    sync.Mutex
+	replies []string
+}
+
+func (s *sentReplies) send(messageID string, payloadDoc messageContracts.SendReplyPayload) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.replies = append(s.replies, messageID+":"+payloadDoc.DocumentTraceOutput)
+}
+
+func (s *sentReplies) get() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.replies...)
+}
+
+func replyPayload(status contracts.ResultStatus, output string) messageContracts.SendReplyPayload {
+	return messageContracts.SendReplyPayload{DocumentStatus: status, DocumentTraceOutput: output}
+}
+
+func TestReplyBatcherSendsLatestReplyOfWindow(t *testing.T) {
+	sent := &sentReplies{}
+	batcher := newReplyBatcher(50*time.Millisecond, sent.send)
+
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step1"))
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step2"))
+	batcher.reply("message2", replyPayload(contracts.ResultStatusInProgress, "step1"))
+	assert.Empty(t, sent.get())
+
+	time.Sleep(200 * time.Millisecond)
+	replies := sent.get()
+	sort.Strings(replies)
+	assert.Equal(t, []string{"message1:step2", "message2:step1"}, replies)
+}
+
+func TestReplyBatcherSendsFinalReplyRightAway(t *testing.T) {
+	sent := &sentReplies{}
+	batcher := newReplyBatcher(50*time.Millisecond, sent.send)
+
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step1"))
+	batcher.reply("message1", replyPayload(contracts.ResultStatusSuccess, "done"))
+	assert.Equal(t, []string{"message1:done"}, sent.get())
+
+	// the pending reply was replaced by the final one
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, []string{"message1:done"}, sent.get())
+}
+
+func TestReplyBatcherFlush(t *testing.T) {
+	sent := &sentReplies{}
+	batcher := newReplyBatcher(time.Hour, sent.send)
+
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step1"))
+	batcher.flush()
+	assert.Equal(t, []string{"message1:step1"}, sent.get())
+
+	batcher.flush()
+	assert.Equal(t, []string{"message1:step1"}, sent.get())
+}
+
+func TestReplyBatcherDisabled(t *testing.T) {
+	sent := &sentReplies{}
+	batcher := newReplyBatcher(0, sent.send)
+
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step1"))
+	batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step2"))
+	assert.Equal(t, []string{"message1:step1", "message1:step2"}, sent.get())
+}
+
+func TestReplyBatcherSendsOutsideOfLock(t *testing.T) {
+	sent := &sentReplies{}
+	var batcher *replyBatcher
+	batcher = newReplyBatcher(time.Hour, func(messageID string, payloadDoc messageContracts.SendReplyPayload) {
+		sent.send(messageID, payloadDoc)
+		if messageID == "message1" {
+			// a reply sent while another one is being sent doesn't wait for it
+			batcher.reply("message2", replyPayload(contracts.ResultStatusSuccess, "done"))
+		}
+	})
+
+	done := make(chan bool)
+	go func() {
+		batcher.reply("message1", replyPayload(contracts.ResultStatusInProgress, "step1"))
+		batcher.flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the reply batcher held its lock while sending")
+	}
+	assert.Equal(t, []string{"message1:step1", "message2:done"}, sent.get())
+}
+
+func TestReplyBatcherDropsStaleReply(t *testing.T) {
+	sent := &sentReplies{}
+	batcher := newReplyBatcher(time.Hour, sent.send)
+	message := &messageReplies{}
+
+	// the pending reply was taken by the end of the window while the document finished
+	batcher.sendInOrder("message1", message, 2, replyPayload(contracts.ResultStatusSuccess, "done"))
+	batcher.sendInOrder("message1", message, 1, replyPayload(contracts.ResultStatusInProgress, "step1"))
+	assert.Equal(t, []string{"message1:done"}, sent.get())
+}
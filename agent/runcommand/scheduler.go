@@ -16,12 +16,13 @@ package runcommand
 
 import (
 	"fmt"
-	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 	"github.com/carlescere/scheduler"
 )
 
@@ -30,6 +31,12 @@ var lock sync.RWMutex
 
 var processMessage = (*RunCommandService).processMessage
 
+var recordAgentCheck = updateutil.RecordAgentCheck
+
+var setHealthReady = health.SetReady
+
+var setHealthNotReady = health.SetNotReady
+
 func updateLastPollTime(processorType string, currentTime time.Time) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -68,16 +75,24 @@ func (s *RunCommandService) messagePollLoop() {
 		return
 	}
 
-	s.pollOnce()
+	receivedMessages := s.pollOnce()
 	if s.name == mdsName {
 		log.Debugf("%v's stoppolicy after polling is %v", s.name, s.processorStopPolicy)
 	}
 
-	// Slow down a bit in case GetMessages returns
+	// Slow down while idle and in case GetMessages returns
 	// without blocking, which may cause us to
 	// flood the service with requests.
-	if time.Since(pollStartTime) < 1*time.Second {
-		time.Sleep(time.Duration(2000+rand.Intn(500)) * time.Millisecond)
+	pollDuration := time.Since(pollStartTime)
+	var maxIdleDelay time.Duration
+	if s.name == mdsName {
+		maxIdleDelay = time.Duration(s.context.AppConfig().Mds.MaxIdlePollDelaySeconds) * time.Second
+	}
+	delay := s.pollBackoff.nextDelay(receivedMessages, pollDuration, maxIdleDelay)
+	pollIntervalGauge.Set((pollDuration + delay).Seconds(), s.name)
+	if delay > 0 {
+		log.Debugf("Waiting %v before polling for messages", delay)
+		time.Sleep(delay)
 	}
 
 	// check if any other poll loop has started in the meantime
@@ -118,9 +133,8 @@ func (s *RunCommandService) reset() {
 
 	// creating a new mds service object for the retry
 	// this is extra insurance to avoid service object getting corrupted - adding resiliency
-	config := s.context.AppConfig()
 	if s.name == mdsName {
-		s.service = newMdsService(config)
+		s.replaceService(newMdsService(s.currentConfig()))
 	}
 }
 
@@ -128,7 +142,11 @@ func (s *RunCommandService) reset() {
 func (s *RunCommandService) stop() {
 	log := s.context.Log()
 	log.Debugf("Stopping processor:%v", s.name)
-	s.service.Stop()
+	if s.replyBatcher != nil {
+		// send the replies still waiting for the end of their batch window before the service stops
+		s.replyBatcher.flush()
+	}
+	s.messageService().Stop()
 
 	if s.messagePollJob != nil {
 		s.messagePollJob.Quit <- true
@@ -138,19 +156,30 @@ func (s *RunCommandService) stop() {
 	}
 }
 
-// pollOnce calls GetMessages once and processes the result.
-func (s *RunCommandService) pollOnce() {
+// pollOnce calls GetMessages once and processes the result, it returns whether messages were received.
+func (s *RunCommandService) pollOnce() (receivedMessages bool) {
 	log := s.context.Log()
 	if s.name == mdsName {
 		log.Debugf("Polling for messages")
 	}
-	messages, err := s.service.GetMessages(log, s.config.InstanceID)
+	messages, err := s.messageService().GetMessages(log, s.config.InstanceID)
 	if err != nil {
 		sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
-		return
+		if s.name == mdsName {
+			setHealthNotReady(health.ComponentMds, err)
+		}
+		pollsCounter.Inc(s.name, pollResultError)
+		return false
+	}
+	if s.name == mdsName {
+		recordAgentCheck(log, updateutil.CheckMdsConnected)
+		setHealthReady(health.ComponentMds)
 	}
 	if len(messages.Messages) > 0 {
 		log.Debugf("Got %v messages", len(messages.Messages))
+		pollsCounter.Inc(s.name, pollResultMessages)
+	} else {
+		pollsCounter.Inc(s.name, pollResultEmpty)
 	}
 
 	for _, msg := range messages.Messages {
@@ -159,4 +188,5 @@ func (s *RunCommandService) pollOnce() {
 	if s.name == mdsName {
 		log.Debugf("Done poll once")
 	}
+	return len(messages.Messages) > 0
 }
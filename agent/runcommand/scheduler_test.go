@@ -88,10 +88,11 @@ func TestPollOnce(t *testing.T) {
 	}
 
 	// execute pollOnce
-	proc.pollOnce()
+	receivedMessages := proc.pollOnce()
 
 	// check expectations
 	tc.MdsMock.AssertExpectations(t)
+	assert.True(t, receivedMessages)
 	assert.Equal(t, countMessageProcessed, 1)
 }
 
@@ -115,10 +116,11 @@ func TestPollOnceWithZeroMessage(t *testing.T) {
 	}
 
 	// execute pollOnce
-	proc.pollOnce()
+	receivedMessages := proc.pollOnce()
 
 	// check expectations
 	tc.MdsMock.AssertExpectations(t)
+	assert.False(t, receivedMessages)
 	assert.Equal(t, countMessageProcessed, 0)
 }
 
@@ -142,10 +144,11 @@ func TestPollOnceMultipleTimes(t *testing.T) {
 	}
 
 	// execute pollOnce
-	proc.pollOnce()
+	receivedMessages := proc.pollOnce()
 
 	// check expectations
 	tc.MdsMock.AssertExpectations(t)
+	assert.True(t, receivedMessages)
 	assert.Equal(t, countMessageProcessed, 5)
 }
 
@@ -169,9 +172,10 @@ func TestPollOnceWithGetMessagesReturnError(t *testing.T) {
 	}
 
 	// execute pollOnce
-	proc.pollOnce()
+	receivedMessages := proc.pollOnce()
 
 	// check expectations
 	tc.MdsMock.AssertExpectations(t)
+	assert.False(t, receivedMessages)
 	assert.False(t, isMessageProcessed)
 }
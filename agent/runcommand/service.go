@@ -17,6 +17,8 @@ package runcommand
 import (
 	"encoding/json"
 	"path/filepath"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -87,16 +89,21 @@ type RunCommandService struct {
 	name                 string
 	config               contracts.AgentConfiguration
 	service              mdsService.Service
+	serviceLock          sync.RWMutex
 	sendDocLevelResponse SendDocumentLevelResponse
 	sendResponse         SendResponse
+	replyBatcher         *replyBatcher
 	orchestrationRootDir string
 	messagePollJob       *scheduler.Job
 	sendReplyJob         *scheduler.Job
 	//TODO move association poller out, we surely have to
 	assocProcessor      *associationProcessor.Processor
 	processorStopPolicy *sdkutil.StopPolicy
+	pollBackoff         pollBackoff
 	pollAssociations    bool
 	processor           processor.Processor
+	// reloadOnConfigChange resizes the command workers and reconnects to MDS on reloads of the agent configuration
+	reloadOnConfigChange bool
 }
 
 // NewOfflineProcessor initialize a new offline command document processor
@@ -119,7 +126,49 @@ func NewMDSService(context context.T) *RunCommandService {
 	mdsService := newMdsService(context.AppConfig())
 	config := context.AppConfig()
 
-	return NewService(messageContext, mdsName, mdsService, config.Mds.CommandWorkersLimit, CancelWorkersLimit, true, []contracts.DocumentType{contracts.SendCommand, contracts.CancelCommand})
+	service := NewService(messageContext, mdsName, mdsService, config.Mds.CommandWorkersLimit, CancelWorkersLimit, true, []contracts.DocumentType{contracts.SendCommand, contracts.CancelCommand})
+	if service != nil {
+		service.reloadOnConfigChange = true
+	}
+	return service
+}
+
+// onConfigChange resizes the command workers when the agent configuration is reloaded with a new limit, and
+// replaces the MDS service when the endpoint of MDS changed. The poll in progress completes with the previous service.
+func (s *RunCommandService) onConfigChange(previous appconfig.SsmagentConfig, current appconfig.SsmagentConfig) {
+	if previous.Mds.CommandWorkersLimit != current.Mds.CommandWorkersLimit {
+		s.processor.SetCommandWorkersLimit(current.Mds.CommandWorkersLimit)
+	}
+	if previous.Agent.Region != current.Agent.Region ||
+		previous.Mds.Endpoint != current.Mds.Endpoint ||
+		previous.Mds.StopTimeoutMillis != current.Mds.StopTimeoutMillis ||
+		!reflect.DeepEqual(previous.Endpoints, current.Endpoints) {
+		s.context.Log().Info("MDS settings changed, reconnecting to MDS")
+		s.replaceService(newMdsService(current))
+	}
+}
+
+// messageService returns the service the messages are exchanged with
+func (s *RunCommandService) messageService() mdsService.Service {
+	s.serviceLock.RLock()
+	defer s.serviceLock.RUnlock()
+	return s.service
+}
+
+// replaceService replaces the service the messages are exchanged with
+func (s *RunCommandService) replaceService(service mdsService.Service) {
+	s.serviceLock.Lock()
+	defer s.serviceLock.Unlock()
+	s.service = service
+}
+
+// currentConfig returns the agent configuration loaded last, which differs from the configuration of the context
+// once the configuration is reloaded
+func (s *RunCommandService) currentConfig() appconfig.SsmagentConfig {
+	if config, err := appconfig.Config(false); err == nil {
+		return config
+	}
+	return s.context.AppConfig()
 }
 
 // NewProcessor performs common initialization for Mds and Offline processors
@@ -152,16 +201,35 @@ func NewService(ctx context.T, serviceName string, service mdsService.Service, c
 	// create a stop policy where we will stop after 10 consecutive errors and if time period expires.
 	stopPolicy := newStopPolicy(serviceName)
 
+	// batch the replies of the commands in progress to reduce the calls to MDS
+	var replyBatchWindow time.Duration
+	if serviceName == mdsName {
+		replyBatchWindow = time.Duration(config.Mds.ReplyBatchWindowMillis) * time.Millisecond
+	}
+	// the replies go through the current service, which is replaced when the MDS settings are reloaded
+	runCommandService := &RunCommandService{
+		context:              ctx,
+		name:                 serviceName,
+		config:               agentConfig,
+		service:              service,
+		orchestrationRootDir: orchestrationRootDir,
+		processorStopPolicy:  stopPolicy,
+		pollAssociations:     pollAssoc,
+	}
+	replyBatcher := newReplyBatcher(replyBatchWindow, func(messageID string, payloadDoc messageContracts.SendReplyPayload) {
+		processSendReply(log, messageID, runCommandService.messageService(), payloadDoc, stopPolicy)
+	})
+
 	// SendDocLevelResponse is used to send document level update
 	// Specify a new status of the document
 	sendDocLevelResponse := func(messageID string, resultStatus contracts.ResultStatus, documentTraceOutput string) {
 		payloadDoc := prepareReplyPayloadToUpdateDocumentStatus(agentInfo, resultStatus, documentTraceOutput)
-		processSendReply(log, messageID, service, payloadDoc, stopPolicy)
+		replyBatcher.reply(messageID, payloadDoc)
 	}
 
 	sendResponse := func(messageID string, res contracts.DocumentResult) {
 		pluginID := res.LastPlugin
-		processSendReply(log, messageID, service, FormatPayload(log, pluginID, agentInfo, res.PluginResults), stopPolicy)
+		replyBatcher.reply(messageID, FormatPayload(log, pluginID, agentInfo, res.PluginResults))
 	}
 
 	var assocProc *associationProcessor.Processor
@@ -169,20 +237,12 @@ func NewService(ctx context.T, serviceName string, service mdsService.Service, c
 		assocProc = associationProcessor.NewAssociationProcessor(ctx)
 	}
 
-	processor := processor.NewEngineProcessor(ctx, commandWorkerLimit, cancelWorkerLimit, supportedDocs)
-	return &RunCommandService{
-		context:              ctx,
-		name:                 serviceName,
-		config:               agentConfig,
-		service:              service,
-		sendDocLevelResponse: sendDocLevelResponse,
-		sendResponse:         sendResponse,
-		orchestrationRootDir: orchestrationRootDir,
-		processorStopPolicy:  stopPolicy,
-		assocProcessor:       assocProc,
-		pollAssociations:     pollAssoc,
-		processor:            processor,
-	}
+	runCommandService.sendDocLevelResponse = sendDocLevelResponse
+	runCommandService.sendResponse = sendResponse
+	runCommandService.replyBatcher = replyBatcher
+	runCommandService.assocProcessor = assocProc
+	runCommandService.processor = processor.NewEngineProcessor(ctx, commandWorkerLimit, cancelWorkerLimit, supportedDocs)
+	return runCommandService
 }
 
 // prepareReplyPayloadToUpdateDocumentStatus creates the payload object for SendReply based on document status change.
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"path"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/docparser"
@@ -31,6 +32,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	mds "github.com/aws/amazon-ssm-agent/agent/runcommand/mds"
 	runcommandmock "github.com/aws/amazon-ssm-agent/agent/runcommand/mock"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 	"github.com/aws/aws-sdk-go/aws"
@@ -423,3 +425,34 @@ func GenerateCancelDocState(t *testing.T, testCase TestCaseCancelCommand) (docSt
 	docState, _ = parseCancelCommandMessage(context, &mdsCancelMessage, testCase.OrchestrationDir)
 	return
 }
+
+// TestOnConfigChange tests that a reload resizes the command workers and reconnects to MDS when its endpoint changed
+func TestOnConfigChange(t *testing.T) {
+	previousMds := new(runcommandmock.MockedMDS)
+	reloadedMds := new(runcommandmock.MockedMDS)
+	processorMock := new(processormock.MockedProcessor)
+	processorMock.On("SetCommandWorkersLimit", 10).Return()
+	var newServiceConfig appconfig.SsmagentConfig
+	defer func(original func(appconfig.SsmagentConfig) mds.Service) { newMdsService = original }(newMdsService)
+	newMdsService = func(config appconfig.SsmagentConfig) mds.Service {
+		newServiceConfig = config
+		return reloadedMds
+	}
+	svc := &RunCommandService{
+		context:   context.NewMockDefault(),
+		service:   previousMds,
+		processor: processorMock,
+	}
+
+	previous := appconfig.DefaultConfig()
+	current := appconfig.DefaultConfig()
+	current.Mds.CommandWorkersLimit = 10
+	svc.onConfigChange(previous, current)
+	processorMock.AssertExpectations(t)
+	assert.Equal(t, previousMds, svc.messageService())
+
+	current.Endpoints.Overrides = map[string]string{"ec2messages": "vpce-1.ec2messages.{region}.vpce.amazonaws.com"}
+	svc.onConfigChange(previous, current)
+	assert.Equal(t, reloadedMds, svc.messageService())
+	assert.Equal(t, current.Endpoints, newServiceConfig.Endpoints)
+}
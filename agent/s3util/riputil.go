@@ -50,6 +50,11 @@ func GetS3Endpoint(region string) (s3Endpoint string) {
 		}
 	}
 
+	// endpoint overrides such as VPC endpoints take precedence over the endpoints of the regions
+	if defaultEndpoint := appconfig.GetDefaultEndPoint(region, "s3"); defaultEndpoint != "" {
+		return defaultEndpoint
+	}
+
 	if s3Endpoint, ok := awsS3EndpointMap[region]; ok {
 		return s3Endpoint
 	}
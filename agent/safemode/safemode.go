@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package safemode detects when the agent keeps crashing on startup and starts it in safe mode.
+//
+// Every start of the agent is recorded until the agent either stops cleanly or runs for StableDuration.
+// When more than MaxUnstableStarts starts are recorded within CrashLoopWindow, the agent is crash looping,
+// for instance on a corrupt state file or an incompatible plugin, and starts in safe mode: the persisted
+// documents are not restored and only the essential plugins are loaded, so that the instance can still
+// be fixed remotely. Safe mode lasts until the next start of the agent.
+package safemode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// MaxUnstableStarts is the number of unstable starts within CrashLoopWindow tolerated before safe mode
+	MaxUnstableStarts = 3
+	// CrashLoopWindow is the period in which the unstable starts are counted
+	CrashLoopWindow = 10 * time.Minute
+	// StableDuration is the time after which a running agent is considered stable
+	StableDuration = 5 * time.Minute
+
+	// HealthComponent is the component reporting safe mode in the health of the agent
+	HealthComponent = "SafeMode"
+	// HealthStatus is the agent status reported in safe mode
+	HealthStatus = "SafeModeCrashLoop"
+
+	stateFileName = "crashloop.json"
+)
+
+// EssentialPlugins are the plugins loaded in safe mode, enough to fix or update the agent
+var EssentialPlugins = map[string]bool{
+	appconfig.PluginNameAwsRunShellScript:      true,
+	appconfig.PluginNameAwsRunPowerShellScript: true,
+	appconfig.PluginNameAwsAgentUpdate:         true,
+}
+
+// state is persisted so that it survives crashes and is shared with the worker processes
+type state struct {
+	Starts   []time.Time `json:"starts"`
+	SafeMode bool        `json:"safeMode"`
+}
+
+var stateFile = filepath.Join(appconfig.DefaultDataStorePath, stateFileName)
+var timeNow = time.Now
+
+var enabled bool
+var loadOnce sync.Once
+var stateLock sync.Mutex
+
+// RecordStart records a start of the agent and returns true if the agent must start in safe mode.
+// The start is forgotten once the agent has run for StableDuration.
+func RecordStart(log log.T) (safeMode bool) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	now := timeNow()
+	current := readState(log)
+	var starts []time.Time
+	for _, start := range current.Starts {
+		if now.Sub(start) < CrashLoopWindow {
+			starts = append(starts, start)
+		}
+	}
+	starts = append(starts, now)
+	safeMode = len(starts) > MaxUnstableStarts
+	writeState(log, state{Starts: starts, SafeMode: safeMode})
+
+	loadOnce.Do(func() {})
+	enabled = safeMode
+	time.AfterFunc(StableDuration, func() { RecordStable(log) })
+	return
+}
+
+// RecordStable forgets the recorded starts, the agent is stable since it stopped cleanly or ran long enough.
+func RecordStable(log log.T) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	current := readState(log)
+	if len(current.Starts) == 0 {
+		return
+	}
+	writeState(log, state{SafeMode: current.SafeMode})
+}
+
+// Enabled returns true if the agent runs in safe mode. Processes other than the agent,
+// such as the document workers, read the mode the agent recorded when it started.
+func Enabled() bool {
+	loadOnce.Do(func() {
+		stateLock.Lock()
+		defer stateLock.Unlock()
+		enabled = readState(nil).SafeMode
+	})
+	return enabled
+}
+
+func readState(log log.T) (current state) {
+	content, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &current); err != nil && log != nil {
+		log.Warnf("Ignoring the corrupt crash loop state %v. %v", stateFile, err)
+	}
+	return
+}
+
+func writeState(log log.T, current state) {
+	content, err := json.Marshal(current)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(stateFile), appconfig.ReadWriteExecuteAccess); err == nil {
+			err = ioutil.WriteFile(stateFile, content, appconfig.ReadWriteAccess)
+		}
+	}
+	if err != nil {
+		log.Warnf("Failed to record the crash loop state in %v. %v", stateFile, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package safemode detects when the agent keeps crashing on startup and starts it in safe mode.
+package safemode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// withStateFile records the state in a temporary file and sets the clock for the duration of a test
+func withStateFile(t *testing.T, now *time.Time) (restore func()) {
+	dir, err := ioutil.TempDir("", "safemode")
+	assert.NoError(t, err)
+	stateFile = filepath.Join(dir, stateFileName)
+	timeNow = func() time.Time { return *now }
+	return func() {
+		os.RemoveAll(dir)
+		timeNow = time.Now
+		enabled = false
+		loadOnce = sync.Once{}
+	}
+}
+
+func TestRecordStartEntersSafeModeWhenCrashLooping(t *testing.T) {
+	now := time.Now()
+	defer withStateFile(t, &now)()
+	logger := log.NewMockLog()
+
+	for i := 0; i < MaxUnstableStarts; i++ {
+		assert.False(t, RecordStart(logger))
+		now = now.Add(time.Minute)
+	}
+	assert.True(t, RecordStart(logger))
+	assert.True(t, Enabled())
+
+	// the worker processes read the mode recorded by the agent
+	loadOnce = sync.Once{}
+	enabled = false
+	assert.True(t, Enabled())
+}
+
+func TestRecordStartForgetsOldStarts(t *testing.T) {
+	now := time.Now()
+	defer withStateFile(t, &now)()
+	logger := log.NewMockLog()
+
+	for i := 0; i < MaxUnstableStarts*2; i++ {
+		assert.False(t, RecordStart(logger))
+		now = now.Add(CrashLoopWindow / MaxUnstableStarts)
+	}
+}
+
+func TestRecordStableResetsCrashLoop(t *testing.T) {
+	now := time.Now()
+	defer withStateFile(t, &now)()
+	logger := log.NewMockLog()
+
+	for i := 0; i < MaxUnstableStarts*2; i++ {
+		assert.False(t, RecordStart(logger))
+		RecordStable(logger)
+	}
+}
+
+func TestCorruptStateIsIgnored(t *testing.T) {
+	now := time.Now()
+	defer withStateFile(t, &now)()
+	assert.NoError(t, ioutil.WriteFile(stateFile, []byte("{"), 0600))
+
+	assert.False(t, Enabled())
+	assert.False(t, RecordStart(log.NewMockLog()))
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/metrics"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/request"
 )
@@ -28,6 +29,22 @@ type SsmRetryer struct {
 	client.DefaultRetryer
 }
 
+// throttles counts the requests throttled by the services the agent calls
+var throttles = metrics.NewCounter("ssm_agent_api_throttles_total",
+	"Requests to AWS APIs that were throttled.", "service", "operation")
+
+// ShouldRetry counts the throttled requests and returns whether the request should be retried
+func (s SsmRetryer) ShouldRetry(r *request.Request) bool {
+	if r.IsErrorThrottle() {
+		operation := ""
+		if r.Operation != nil {
+			operation = r.Operation.Name
+		}
+		throttles.Inc(r.ClientInfo.ServiceName, operation)
+	}
+	return s.DefaultRetryer.ShouldRetry(r)
+}
+
 // RetryRules returns the delay duration before retrying this request again
 func (s SsmRetryer) RetryRules(r *request.Request) time.Duration {
 	// Handle GetMessages Client.Timeout error
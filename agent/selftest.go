@@ -0,0 +1,111 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/docparser"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const selfTestDocumentName = "AgentSelfTest"
+
+// selfTestDocument returns the document the agent processes after an update,
+// the updater rolls the update back unless the agent processes it successfully
+func selfTestDocument() *docparser.DocContent {
+	action := appconfig.PluginNameAwsRunShellScript
+	if runtime.GOOS == "windows" {
+		action = appconfig.PluginNameAwsRunPowerShellScript
+	}
+	return &docparser.DocContent{
+		SchemaVersion: "2.2",
+		Description:   "Verifies that the agent processes documents after an update",
+		MainSteps: []*contracts.InstancePluginConfig{
+			{
+				Action: action,
+				Name:   "selfTest",
+				Inputs: map[string]interface{}{
+					"runCommand": []interface{}{"echo " + selfTestDocumentName},
+				},
+			},
+		},
+	}
+}
+
+// verifyUpdate resets the checks the updater verifies after an update, and runs the self-test when the agent
+// starts for the first time after an update
+func verifyUpdate(context context.T) {
+	log := context.Log()
+	previousVersion := updateutil.ResetAgentChecks(log)
+	if previousVersion == "" || previousVersion == version.Version {
+		return
+	}
+
+	log.Infof("Running self-test after update from %v to %v", previousVersion, version.Version)
+	go runSelfTest(context)
+}
+
+// runSelfTest processes the self-test document and records the self-test check if it succeeds
+func runSelfTest(context context.T) {
+	log := context.Log()
+	defer func() {
+		if msg := recover(); msg != nil {
+			log.Errorf("Self-test panic: %v", msg)
+		}
+	}()
+
+	orchestrationDir, err := ioutil.TempDir("", selfTestDocumentName)
+	if err != nil {
+		log.Errorf("Failed to create self-test orchestration directory, %v", err)
+		return
+	}
+	defer os.RemoveAll(orchestrationDir)
+
+	docState, err := docparser.InitializeDocState(log,
+		contracts.SendCommand,
+		selfTestDocument(),
+		contracts.DocumentInfo{DocumentID: selfTestDocumentName, DocumentName: selfTestDocumentName},
+		docparser.DocumentParserInfo{OrchestrationDir: orchestrationDir, DocumentId: selfTestDocumentName},
+		nil)
+	if err != nil {
+		log.Errorf("Failed to parse self-test document, %v", err)
+		return
+	}
+
+	resChan := make(chan contracts.PluginResult, len(docState.InstancePluginsInformation))
+	outputs := runpluginutil.RunPlugins(context,
+		docState.InstancePluginsInformation,
+		docState.IOConfig,
+		runpluginutil.SSMPluginRegistry,
+		resChan,
+		task.NewChanneledCancelFlag())
+	for name, output := range outputs {
+		if output.Status != contracts.ResultStatusSuccess {
+			log.Errorf("Self-test step %v failed with status %v, %v", name, output.Status, output.Error)
+			return
+		}
+	}
+
+	log.Infof("Self-test succeeded")
+	updateutil.RecordAgentCheck(log, updateutil.CheckSelfTest)
+}
@@ -57,18 +57,69 @@ const (
 	RetryGeometricRatio                   = 2
 	ControlChannelNumMaxRetries           = -1 //forever retries for control channel
 	ControlChannelRetryInitialDelayMillis = 5000
-	ControlChannelRetryMaxIntervalMillis  = 1000 * 60 * 60 // 1 hour
+	ControlChannelRetryMaxIntervalMillis  = 1000 * 60 * 5 // 5 minutes
+	// Each control channel retry delay is shortened by a random part of up to ControlChannelRetryJitterRatio of it.
+	ControlChannelRetryJitterRatio = 0.5
+	// The agent reports to the health service once the control channel is disconnected for ControlChannelDisconnectedReportDelay.
+	ControlChannelDisconnectedReportDelay = 5 * time.Minute
 
 	DataChannelNumMaxAttempts          = 5
 	DataChannelRetryInitialDelayMillis = 100
 	DataChannelRetryMaxIntervalMillis  = 5000
 
+	// Port forwarding: bytes a stream may have in flight in each direction before the peer acknowledges them,
+	// and number of streams multiplexed over the data channel of a session.
+	PortStreamWindowSize = 64 * 1024
+	PortStreamsLimit     = 64
+	// UDP flows no datagram went through in either direction for PortDatagramFlowIdleTimeout are closed.
+	PortDatagramFlowIdleTimeout = 60 * time.Second
+
+	// File transfer: largest chunk of a file sent in a single stream data message.
+	FileTransferMaxChunkSize = StreamDataPayloadSize
+
 	IpcFileName      = "ipcTempFile"
 	LogFileExtension = ".log"
+
+	// Session recordings are asciinema v2 files, uploaded next to the session logs.
+	// The terminal size is unknown until the client sends it so recordings start with a default size.
+	RecordingFileExtension = ".cast"
+	RecordingStreamSuffix  = "-recording"
+	RecordingDefaultWidth  = 80
+	RecordingDefaultHeight = 24
+
+	// Session timeouts in minutes, read from the session document. Sessions have no maximum duration unless the document sets one.
+	// The client is warned SessionTimeoutWarningPeriod before the agent terminates the session.
+	DefaultIdleSessionTimeoutMinutes = 20
+	MinIdleSessionTimeoutMinutes     = 1
+	MaxIdleSessionTimeoutMinutes     = 60
+	MinSessionDurationMinutes        = 1
+	MaxSessionDurationMinutes        = 1440
+	SessionTimeoutWarningPeriod      = 1 * time.Minute
+	SessionTimeoutCheckInterval      = 1 * time.Second
+
+	// Data keys encrypting the data channel are rotated after EncryptionKeyRotationInterval or EncryptionKeyMaxMessages payloads.
+	EncryptionKeyRotationInterval = 1 * time.Hour
+	EncryptionKeyMaxMessages      = 1 << 24
+
+	// The agent waits HandshakeTimeout for the capabilities of the client before falling back to the features of clients predating the handshake.
+	HandshakeTimeout = 1 * time.Second
+
+	// The shell waits TerminalSetupTimeout for the terminal type and size of the client before starting with DefaultTerminalType.
+	TerminalSetupTimeout = 1 * time.Second
+	DefaultTerminalType  = "xterm-256color"
+
+	// Non-interactive commands sessions stream at most CommandOutputLimit bytes of output to the client.
+	CommandOutputLimit = 10 * 1024 * 1024
+
 	ScreenBufferSize = 30000
 	Exit             = "exit"
 
 	CloudWatchEncryptionErrorMsg = "We couldn't start the session because encryption is not set up on the selected CloudWatch Logs log group. Either encrypt the log group or choose an option to enable logging without encryption."
+	IdleSessionTimeoutWarningMsg = "\r\nThis session will be terminated in %d seconds due to inactivity.\r\n"
+	IdleSessionTimeoutMsg        = "\r\nThis session was terminated due to inactivity.\r\n"
+	MaxSessionDurationWarningMsg = "\r\nThis session will be terminated in %d seconds as it reaches its maximum duration.\r\n"
+	MaxSessionDurationMsg        = "\r\nThis session was terminated as it reached its maximum duration.\r\n"
+	RunAsUserEmptyErrorMsg       = "We couldn't start the session because RunAs support is enabled but no RunAs user is specified in the session preferences."
 	S3EncryptionErrorMsg         = "We couldn't start the session because encryption is not set up on the selected Amazon S3 bucket. Either encrypt the bucket or choose an option to enable logging without encryption."
 )
 
@@ -299,6 +299,7 @@ func (agentMessage *AgentMessage) ParseAgentMessage(context context.T,
 		SessionType:   parsedMessagePayload.DocumentContent.SessionType,
 		Inputs:        parsedMessagePayload.DocumentContent.Inputs,
 		Parameters:    parsedMessagePayload.DocumentContent.Parameters,
+		Properties:    parsedMessagePayload.DocumentContent.Properties,
 	}
 
 	docState, err := docparser.InitializeDocState(
@@ -307,7 +308,7 @@ func (agentMessage *AgentMessage) ParseAgentMessage(context context.T,
 		docContent,
 		documentInfo,
 		parserInfo,
-		nil)
+		parsedMessagePayload.Parameters)
 	if err != nil {
 		return nil, fmt.Errorf("error initialing document state: %s", err)
 	}
@@ -316,6 +317,11 @@ func (agentMessage *AgentMessage) ParseAgentMessage(context context.T,
 	return &docState, nil
 }
 
+// ParseAgentTaskPayload returns the payload of a start session message, without building its document state.
+func (agentMessage *AgentMessage) ParseAgentTaskPayload(log logger.T) (AgentTaskPayload, error) {
+	return deserializeAgentTaskPayload(log, *agentMessage)
+}
+
 // deserializeAgentTaskPayload parses agent task message payloads received.
 func deserializeAgentTaskPayload(log logger.T, agentMessage AgentMessage) (agentTaskPayload AgentTaskPayload, err error) {
 	if agentMessage.MessageType != InteractiveShellMessage {
@@ -91,6 +91,10 @@ type AgentTaskPayload struct {
 	DocumentName    string                           `json:"DocumentName"`
 	DocumentContent contracts.SessionDocumentContent `json:"DocumentContent"`
 	SessionId       string                           `json:"SessionId"`
+	// ClientId identifies the client which started the session, sessions of the same client count against the same quota.
+	ClientId string `json:"ClientId"`
+	// Parameters holds the values of the document parameters given when starting the session.
+	Parameters map[string]interface{} `json:"Parameters"`
 }
 
 // AcknowledgeContent is used to inform the sender of an acknowledge message that the message has been received.
@@ -186,6 +190,12 @@ type AgentTaskCompletePayload struct {
 	S3UrlSuffix      string `json:"S3UrlSuffix"`
 	CwlGroup         string `json:"CwlGroup"`
 	CwlStream        string `json:"CwlStream"`
+	// TerminationReason is set when the agent terminated the session, for example on idle timeout.
+	TerminationReason string `json:"TerminationReason,omitempty"`
+	// Metrics are the traffic and latency of the data channel of the session.
+	Metrics *SessionMetrics `json:"Metrics,omitempty"`
+	// CommandResult is the result of the commands of a non-interactive commands session.
+	CommandResult *CommandResultPayload `json:"CommandResult,omitempty"`
 }
 
 // SessionPluginResultOutput represents PluginResult output sent to MGS as part of AgentTaskComplete message
@@ -195,6 +205,34 @@ type SessionPluginResultOutput struct {
 	S3UrlSuffix string
 	CwlGroup    string
 	CwlStream   string
+	// TerminationReason is set when the agent terminated the session.
+	TerminationReason TerminationReason `json:",omitempty"`
+	// Metrics are the traffic and latency of the data channel of the session.
+	Metrics *SessionMetrics `json:",omitempty"`
+	// CommandResult is the result of the commands of a non-interactive commands session.
+	CommandResult *CommandResultPayload `json:",omitempty"`
+}
+
+// SessionMetrics are the traffic and latency of the data channel of a session.
+// Bytes count stream data payloads as sent over the data channel, after compression and encryption.
+// Round trip times are measured between sending the stream data messages of the agent and receiving their acknowledgements.
+type SessionMetrics struct {
+	BytesIn                    int64   `json:"bytesIn"`
+	BytesOut                   int64   `json:"bytesOut"`
+	MessagesIn                 int64   `json:"messagesIn"`
+	MessagesOut                int64   `json:"messagesOut"`
+	Retransmissions            int64   `json:"retransmissions"`
+	MinRoundTripTimeMillis     float64 `json:"minRoundTripTimeMillis"`
+	MaxRoundTripTimeMillis     float64 `json:"maxRoundTripTimeMillis"`
+	AverageRoundTripTimeMillis float64 `json:"averageRoundTripTimeMillis"`
+}
+
+// String returns the metrics in a form suitable for logs.
+func (m SessionMetrics) String() string {
+	return fmt.Sprintf("bytes in: %d, bytes out: %d, messages in: %d, messages out: %d, retransmissions: %d, "+
+		"round trip time min/avg/max: %.1f/%.1f/%.1f ms",
+		m.BytesIn, m.BytesOut, m.MessagesIn, m.MessagesOut, m.Retransmissions,
+		m.MinRoundTripTimeMillis, m.AverageRoundTripTimeMillis, m.MaxRoundTripTimeMillis)
 }
 
 type PayloadType uint32
@@ -204,8 +242,80 @@ const (
 	Error     PayloadType = 2
 	Size      PayloadType = 3
 	Parameter PayloadType = 4
+	// EncryptionKey payloads carry an EncryptionKeyData, they are never compressed nor encrypted.
+	EncryptionKey PayloadType = 5
+	// Handshake payloads carry the capability exchange at the start of the session, they are never compressed nor encrypted.
+	HandshakeRequest  PayloadType = 6
+	HandshakeResponse PayloadType = 7
+	HandshakeComplete PayloadType = 8
+	// CommandResult payloads carry a CommandResultPayload, sent once the commands of a non-interactive session exit.
+	CommandResult PayloadType = 9
+)
+
+// CommandResultPayload is the result of the commands of a non-interactive commands session.
+// OutputTruncated is set when the commands wrote more output than the agent streams to the client.
+type CommandResultPayload struct {
+	ExitCode        int   `json:"exitCode"`
+	DurationMillis  int64 `json:"durationMillis"`
+	OutputTruncated bool  `json:"outputTruncated"`
+}
+
+// HandshakeVersion is the version of the capability exchange, clients ignore the capabilities of versions they don't know.
+const HandshakeVersion = "1.0"
+
+const (
+	// CompressionZlib and CompressionDeflate compress each stream data payload independently,
+	// with the zlib format (RFC 1950) or raw DEFLATE (RFC 1951).
+	CompressionZlib    = "zlib"
+	CompressionDeflate = "deflate"
+	// EncryptionKMS encrypts stream data payloads with data keys generated by a KMS key, see EncryptionKeyData.
+	EncryptionKMS = "kms"
 )
 
+// SessionCapabilities are the features one side of a session supports.
+// Compression and Encryption list algorithms by order of preference, and MaxPayloadSize is the largest
+// stream data payload, before compression and encryption, the side sends and accepts.
+// The Encryption of the agent is only set when the session requires encryption.
+type SessionCapabilities struct {
+	PluginFeatures []string `json:"pluginFeatures,omitempty"`
+	Compression    []string `json:"compression,omitempty"`
+	Encryption     []string `json:"encryption,omitempty"`
+	MaxPayloadSize int      `json:"maxPayloadSize,omitempty"`
+}
+
+// HandshakeRequestPayload is sent by the agent when the session starts. Clients that predate the handshake don't answer it.
+type HandshakeRequestPayload struct {
+	AgentVersion     string              `json:"agentVersion"`
+	HandshakeVersion string              `json:"handshakeVersion"`
+	Capabilities     SessionCapabilities `json:"capabilities"`
+}
+
+// HandshakeResponsePayload is the answer of the client to the HandshakeRequestPayload.
+// The client sends no other stream data payload until it receives the HandshakeCompletePayload.
+type HandshakeResponsePayload struct {
+	ClientVersion    string              `json:"clientVersion"`
+	HandshakeVersion string              `json:"handshakeVersion"`
+	Capabilities     SessionCapabilities `json:"capabilities"`
+}
+
+// HandshakeCompletePayload holds the features enabled on the session, both sides apply them to the payloads sent from then on.
+// Compression and Encryption are empty when disabled.
+type HandshakeCompletePayload struct {
+	HandshakeVersion string   `json:"handshakeVersion"`
+	PluginFeatures   []string `json:"pluginFeatures"`
+	Compression      string   `json:"compression"`
+	Encryption       string   `json:"encryption"`
+	MaxPayloadSize   int      `json:"maxPayloadSize"`
+}
+
+// EncryptionKeyData announces the data key encrypting the stream data payloads of a session from then on.
+// The client decrypts CiphertextKey with KMS, using the session and target ids as encryption context.
+type EncryptionKeyData struct {
+	KmsKeyId      string `json:"kmsKeyId"`
+	Generation    uint32 `json:"generation"`
+	CiphertextKey []byte `json:"ciphertextKey"`
+}
+
 type SessionStatus string
 
 const (
@@ -213,7 +323,20 @@ const (
 	Terminating SessionStatus = "Terminating"
 )
 
+// TerminationReason is the reason the agent terminated a session before the client or the shell ended it.
+type TerminationReason string
+
+const (
+	IdleSessionTimeout TerminationReason = "IdleSessionTimeout"
+	MaxSessionDuration TerminationReason = "MaxSessionDuration"
+	// SessionLimitExceeded is reported for sessions rejected because too many sessions are running.
+	SessionLimitExceeded TerminationReason = "SessionLimitExceeded"
+)
+
+// SizeData is the terminal size of the client, sent when the session starts and whenever the client terminal is resized.
+// Term is the terminal type of the client, only taken into account before the shell starts.
 type SizeData struct {
 	Cols uint32 `json:"cols"`
 	Rows uint32 `json:"rows"`
+	Term string `json:"term,omitempty"`
 }
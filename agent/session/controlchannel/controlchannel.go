@@ -17,13 +17,14 @@ package controlchannel
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
+	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/session/communicator"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
@@ -36,6 +37,13 @@ import (
 	"github.com/twinj/uuid"
 )
 
+const (
+	// healthComponent is the agent component reporting controlchannel issues to the health service.
+	healthComponent = "SessionManagerControlChannel"
+	// disconnectedStatus is the agent status reported while the controlchannel stays disconnected.
+	disconnectedStatus = "ControlChannelDisconnected"
+)
+
 type IControlChannel interface {
 	Initialize(context context.T, mgsService service.Service, processor processor.Processor, instanceId string)
 	SetWebSocket(context context.T, mgsService service.Service, processor processor.Processor, instanceId string) error
@@ -52,6 +60,8 @@ type ControlChannel struct {
 	ChannelId   string
 	Service     service.Service
 	channelType string
+	// SessionLimiter rejects new sessions beyond the concurrent session limits, no limit applies when nil.
+	SessionLimiter *SessionLimiter
 }
 
 // Initialize populates controlchannel object and opens controlchannel to communicate with mgs.
@@ -91,10 +101,10 @@ func (controlChannel *ControlChannel) SetWebSocket(context context.T,
 	config := context.AppConfig()
 	orchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath, instanceId, appconfig.DefaultSessionRootDirName, config.Agent.OrchestrationRootDir)
 	onMessageHandler := func(input []byte) {
-		controlChannelIncomingMessageHandler(context, processor, input, orchestrationRootDir, instanceId)
+		controlChannelIncomingMessageHandler(context, controlChannel, processor, input, orchestrationRootDir, instanceId)
 	}
 	onErrorHandler := func(err error) {
-		callable := func() (channel interface{}, err error) {
+		retryer := NewRetryer(log, func() (channel interface{}, err error) {
 			uuid.SwitchFormat(uuid.CleanHyphen)
 			requestId := uuid.NewV4().String()
 			tokenValue, err := getControlChannelToken(log, mgsService, instanceId, requestId)
@@ -106,14 +116,7 @@ func (controlChannel *ControlChannel) SetWebSocket(context context.T,
 				return controlChannel, err
 			}
 			return controlChannel, nil
-		}
-		retryer := retry.ExponentialRetryer{
-			CallableFunc:        callable,
-			GeometricRatio:      mgsConfig.RetryGeometricRatio,
-			InitialDelayInMilli: rand.Intn(mgsConfig.ControlChannelRetryInitialDelayMillis) + mgsConfig.ControlChannelRetryInitialDelayMillis,
-			MaxDelayInMilli:     mgsConfig.ControlChannelRetryMaxIntervalMillis,
-			MaxAttempts:         mgsConfig.ControlChannelNumMaxRetries,
-		}
+		})
 
 		if _, err := retryer.Call(); err != nil {
 			// should never happen
@@ -136,6 +139,48 @@ func (controlChannel *ControlChannel) SetWebSocket(context context.T,
 	return nil
 }
 
+var disconnectedReportDelay = mgsConfig.ControlChannelDisconnectedReportDelay
+
+var reportHealthIssue = health.ReportIssue
+
+var clearHealthIssue = health.ClearIssue
+
+var setHealthReady = health.SetReady
+
+var setHealthNotReady = health.SetNotReady
+
+// NewRetryer returns the retryer (re)connecting a controlchannel with callable, retrying forever with capped exponential backoff and jitter.
+// Disconnections lasting ControlChannelDisconnectedReportDelay are reported to the health service until the controlchannel connects.
+func NewRetryer(log log.T, callable func() (interface{}, error)) *retry.ExponentialRetryer {
+	disconnectedSince := time.Now()
+	reported := false
+	return &retry.ExponentialRetryer{
+		CallableFunc: func() (interface{}, error) {
+			channel, err := callable()
+			if err == nil {
+				setHealthReady(health.ComponentMgs)
+				if reported {
+					log.Infof("Controlchannel connected after %v", time.Since(disconnectedSince))
+					clearHealthIssue(healthComponent)
+				}
+				return channel, nil
+			}
+			setHealthNotReady(health.ComponentMgs, err)
+			if !reported && time.Since(disconnectedSince) >= disconnectedReportDelay {
+				log.Warnf("Controlchannel disconnected since %v: %v", disconnectedSince, err)
+				reportHealthIssue(healthComponent, disconnectedStatus)
+				reported = true
+			}
+			return channel, err
+		},
+		GeometricRatio:      mgsConfig.RetryGeometricRatio,
+		InitialDelayInMilli: mgsConfig.ControlChannelRetryInitialDelayMillis,
+		MaxDelayInMilli:     mgsConfig.ControlChannelRetryMaxIntervalMillis,
+		MaxAttempts:         mgsConfig.ControlChannelNumMaxRetries,
+		JitterRatio:         mgsConfig.ControlChannelRetryJitterRatio,
+	}
+}
+
 // SendMessage sends a message to the service through controlchannel.
 func (controlChannel *ControlChannel) SendMessage(log log.T, input []byte, inputType int) error {
 	return controlChannel.wsChannel.SendMessage(log, input, inputType)
@@ -191,6 +236,7 @@ func (controlChannel *ControlChannel) Open(log log.T) error {
 
 // controlChannelIncomingMessageHandler handles the incoming messages coming to the agent.
 func controlChannelIncomingMessageHandler(context context.T,
+	controlChannel *ControlChannel,
 	processor processor.Processor,
 	rawMessage []byte,
 	orchestrationRootDir string,
@@ -212,7 +258,7 @@ func controlChannelIncomingMessageHandler(context context.T,
 	if agentMessage.MessageType == mgsContracts.InteractiveShellMessage {
 		uuid.SwitchFormat(uuid.CleanHyphen)
 		clientId := uuid.NewV4().String()
-		return sendStartSessionMessageToProcessor(processor, context, agentMessage, orchestrationRootDir, instanceId, clientId)
+		return sendStartSessionMessageToProcessor(controlChannel, processor, context, agentMessage, orchestrationRootDir, instanceId, clientId)
 	} else if agentMessage.MessageType == mgsContracts.ChannelClosedMessage {
 		return sendTerminateSessionMessageToProcessor(processor, context, instanceId, *agentMessage)
 	}
@@ -220,8 +266,10 @@ func controlChannelIncomingMessageHandler(context context.T,
 	return fmt.Errorf("invalid message type: %s", agentMessage.MessageType)
 }
 
-// sendStartSessionMessageToProcessor sends a StartSession message to the processor.
+// sendStartSessionMessageToProcessor sends a StartSession message to the processor,
+// unless the session exceeds the concurrent session limits, in which case it fails the session right away.
 func sendStartSessionMessageToProcessor(
+	controlChannel *ControlChannel,
 	processor processor.Processor,
 	context context.T,
 	agentMessage *mgsContracts.AgentMessage,
@@ -232,9 +280,20 @@ func sendStartSessionMessageToProcessor(
 	log := context.Log()
 	log.Debugf("Processing StartSession message %s", agentMessage.MessageId.String())
 
+	agentTaskPayload, err := agentMessage.ParseAgentTaskPayload(log)
+	if err != nil {
+		log.Errorf("Cannot parse AgentTask message payload: %s, err: %v.", agentMessage.MessageId, err)
+		return err
+	}
+	if err = controlChannel.SessionLimiter.Acquire(agentTaskPayload.SessionId, agentTaskPayload.ClientId); err != nil {
+		log.Warnf("Rejecting session %s: %v", agentTaskPayload.SessionId, err)
+		return controlChannel.sendSessionRejected(log, agentTaskPayload.SessionId, instanceId, err)
+	}
+
 	docState, err := agentMessage.ParseAgentMessage(context, orchestrationRootDir, instanceId, clientId)
 	if err != nil {
 		log.Errorf("Cannot parse AgentTask message to documentState: %s, err: %v.", agentMessage.MessageId, err)
+		controlChannel.SessionLimiter.Release(agentTaskPayload.SessionId)
 		return err
 	}
 
@@ -243,6 +302,37 @@ func sendStartSessionMessageToProcessor(
 	return nil
 }
 
+// sendSessionRejected sends the AgentTaskComplete message failing a session rejected before it started.
+func (controlChannel *ControlChannel) sendSessionRejected(log log.T, sessionId string, instanceId string, reason error) error {
+	payload := mgsContracts.AgentTaskCompletePayload{
+		SchemaVersion:     1,
+		TaskId:            sessionId,
+		Topic:             mgsContracts.TaskCompleteMessage,
+		FinalTaskStatus:   string(contracts.ResultStatusFailed),
+		InstanceId:        instanceId,
+		Output:            reason.Error(),
+		TerminationReason: string(mgsContracts.SessionLimitExceeded),
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal AgentTaskComplete payload of session %s: %v", sessionId, err)
+	}
+
+	uuid.SwitchFormat(uuid.CleanHyphen)
+	agentMessage := &mgsContracts.AgentMessage{
+		MessageType:   mgsContracts.TaskCompleteMessage,
+		SchemaVersion: 1,
+		CreatedDate:   uint64(time.Now().UnixNano() / 1000000),
+		MessageId:     uuid.NewV4(),
+		Payload:       payloadBytes,
+	}
+	msg, err := agentMessage.Serialize(log)
+	if err != nil {
+		return fmt.Errorf("cannot serialize AgentTaskComplete message of session %s: %v", sessionId, err)
+	}
+	return controlChannel.SendMessage(log, msg, websocket.BinaryMessage)
+}
+
 // sendTerminateSessionMessageToProcessor sends a TerminateSession message to the processor.
 func sendTerminateSessionMessageToProcessor(
 	processor processor.Processor,
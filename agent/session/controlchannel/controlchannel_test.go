@@ -16,10 +16,12 @@ package controlchannel
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	processorMock "github.com/aws/amazon-ssm-agent/agent/framework/processor/mock"
+	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	communicatorMocks "github.com/aws/amazon-ssm-agent/agent/session/communicator/mocks"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
@@ -28,6 +30,7 @@ import (
 	serviceMock "github.com/aws/amazon-ssm-agent/agent/session/service/mocks"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/twinj/uuid"
@@ -116,6 +119,34 @@ func TestReconnect(t *testing.T) {
 	mockWsChannel.AssertExpectations(t)
 }
 
+func TestRetryerReportsProlongedDisconnection(t *testing.T) {
+	var issues []string
+	reportHealthIssue = func(component string, status string) { issues = append(issues, status) }
+	clearHealthIssue = func(component string) { issues = append(issues, "cleared") }
+	disconnectedReportDelay = 0
+	defer func() {
+		reportHealthIssue = health.ReportIssue
+		clearHealthIssue = health.ClearIssue
+		disconnectedReportDelay = mgsConfig.ControlChannelDisconnectedReportDelay
+	}()
+	attempts := 0
+	retryer := NewRetryer(mockLog, func() (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return attempts, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		retryer.CallableFunc()
+	}
+
+	assert.Equal(t, []string{disconnectedStatus, "cleared"}, issues)
+	assert.Equal(t, mgsConfig.ControlChannelRetryJitterRatio, retryer.JitterRatio)
+	assert.Equal(t, mgsConfig.ControlChannelRetryMaxIntervalMillis, retryer.MaxDelayInMilli)
+}
+
 func TestClose(t *testing.T) {
 	controlChannel := getControlChannel()
 	mockWsChannel.On("Close", mock.Anything).Return(nil)
@@ -163,12 +194,51 @@ func TestControlChannelIncomingMessageHandlerForStartSessionMessage(t *testing.T
 	serializedBytes, _ := agentMessage.Serialize(log.NewMockLog())
 	mockProcessor.On("Submit", mock.Anything).Return(nil)
 
-	err := controlChannelIncomingMessageHandler(mockContext, mockProcessor, serializedBytes, "", "")
+	err := controlChannelIncomingMessageHandler(mockContext, getControlChannel(), mockProcessor, serializedBytes, "", "")
 
 	assert.Nil(t, err)
 	mockProcessor.AssertExpectations(t)
 }
 
+func TestControlChannelIncomingMessageHandlerRejectsSessionsBeyondLimit(t *testing.T) {
+	agentJson := "{\"documentContent\":{\"schemaVersion\":\"1.0\",\"sessionType\":\"Standard_Stream\"}," +
+		"\"sessionId\":\"44da928d-1200-4501-a38a-f10d72e38cc4\",\"ClientId\":\"client\"}"
+	mgsPayloadJson, _ := json.Marshal(mgsContracts.MGSPayload{Payload: agentJson, TaskId: taskId, Topic: topic, SchemaVersion: 1})
+	agentMessage := &mgsContracts.AgentMessage{
+		MessageType:   mgsContracts.InteractiveShellMessage,
+		SchemaVersion: schemaVersion,
+		CreatedDate:   createdDate,
+		MessageId:     uuid.NewV4(),
+		Payload:       mgsPayloadJson,
+	}
+	serializedBytes, _ := agentMessage.Serialize(log.NewMockLog())
+
+	wsChannel := &communicatorMocks.IWebSocketChannel{}
+	var reply []byte
+	wsChannel.On("SendMessage", mock.Anything, mock.Anything, websocket.BinaryMessage).Return(nil).Run(func(args mock.Arguments) {
+		reply = args.Get(1).([]byte)
+	})
+	processor := new(processorMock.MockedProcessor)
+	controlChannel := &ControlChannel{wsChannel: wsChannel, Processor: processor, SessionLimiter: NewSessionLimiter(1, 0)}
+	assert.Nil(t, controlChannel.SessionLimiter.Acquire("running-session", "other"))
+
+	err := controlChannelIncomingMessageHandler(mockContext, controlChannel, processor, serializedBytes, "", instanceId)
+
+	assert.Nil(t, err)
+	processor.AssertNotCalled(t, "Submit", mock.Anything)
+	replyMessage := &mgsContracts.AgentMessage{}
+	assert.Nil(t, replyMessage.Deserialize(log.NewMockLog(), reply))
+	assert.Equal(t, mgsContracts.TaskCompleteMessage, replyMessage.MessageType)
+	var payload mgsContracts.AgentTaskCompletePayload
+	assert.Nil(t, json.Unmarshal(replyMessage.Payload, &payload))
+	assert.Equal(t, "44da928d-1200-4501-a38a-f10d72e38cc4", payload.TaskId)
+	assert.Equal(t, instanceId, payload.InstanceId)
+	assert.Equal(t, "Failed", payload.FinalTaskStatus)
+	assert.Equal(t, string(mgsContracts.SessionLimitExceeded), payload.TerminationReason)
+	assert.Contains(t, payload.Output, "session limit exceeded")
+	assert.Equal(t, 1, controlChannel.SessionLimiter.Count())
+}
+
 func TestControlChannelIncomingMessageHandlerForTerminateSessionMessage(t *testing.T) {
 	u, _ := uuid.Parse(messageId)
 	agentJson := "{\"MessageType\":\"channel_closed\"," +
@@ -188,7 +258,7 @@ func TestControlChannelIncomingMessageHandlerForTerminateSessionMessage(t *testi
 	serializedBytes, _ := agentMessage.Serialize(log.NewMockLog())
 	mockProcessor.On("Cancel", mock.Anything).Return(nil)
 
-	err := controlChannelIncomingMessageHandler(mockContext, mockProcessor, serializedBytes, "", "")
+	err := controlChannelIncomingMessageHandler(mockContext, getControlChannel(), mockProcessor, serializedBytes, "", "")
 
 	assert.Nil(t, err)
 	mockProcessor.AssertExpectations(t)
@@ -0,0 +1,97 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controlchannel
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SessionLimiter bounds the number of sessions running at the same time on the instance and for each client,
+// so that new sessions beyond the limits are rejected instead of degrading the sessions already running.
+// A limit of zero or less means no limit.
+type SessionLimiter struct {
+	instanceLimit int
+	clientLimit   int
+	// sessions holds the client of each running session
+	sessions map[string]string
+	clients  map[string]int
+	m        sync.Mutex
+}
+
+// NewSessionLimiter returns a SessionLimiter enforcing the given limits.
+func NewSessionLimiter(instanceLimit int, clientLimit int) *SessionLimiter {
+	return &SessionLimiter{
+		instanceLimit: instanceLimit,
+		clientLimit:   clientLimit,
+		sessions:      make(map[string]string),
+		clients:       make(map[string]int),
+	}
+}
+
+// Acquire registers a new session of the client, failing if it would exceed a limit.
+// Sessions already registered are accepted again, as the service may deliver a session more than once.
+func (l *SessionLimiter) Acquire(sessionId string, clientId string) error {
+	if l == nil {
+		return nil
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if _, found := l.sessions[sessionId]; found {
+		return nil
+	}
+	if l.instanceLimit > 0 && len(l.sessions) >= l.instanceLimit {
+		return fmt.Errorf("session limit exceeded: %d sessions are already running on this instance", len(l.sessions))
+	}
+	if l.clientLimit > 0 && clientId != "" && l.clients[clientId] >= l.clientLimit {
+		return fmt.Errorf("session limit exceeded: %d sessions of client %s are already running on this instance", l.clients[clientId], clientId)
+	}
+	l.sessions[sessionId] = clientId
+	if clientId != "" {
+		l.clients[clientId]++
+	}
+	return nil
+}
+
+// Release unregisters a completed session, ignoring sessions which were not registered.
+func (l *SessionLimiter) Release(sessionId string) {
+	if l == nil {
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	clientId, found := l.sessions[sessionId]
+	if !found {
+		return
+	}
+	delete(l.sessions, sessionId)
+	if clientId == "" {
+		return
+	}
+	if l.clients[clientId]--; l.clients[clientId] <= 0 {
+		delete(l.clients, clientId)
+	}
+}
+
+// Count returns the number of running sessions.
+func (l *SessionLimiter) Count() int {
+	if l == nil {
+		return 0
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	return len(l.sessions)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controlchannel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionLimiterEnforcesInstanceLimit(t *testing.T) {
+	limiter := NewSessionLimiter(2, 0)
+
+	assert.Nil(t, limiter.Acquire("session1", "alice"))
+	assert.Nil(t, limiter.Acquire("session2", "alice"))
+	assert.NotNil(t, limiter.Acquire("session3", "bob"))
+
+	// sessions delivered again are accepted
+	assert.Nil(t, limiter.Acquire("session2", "alice"))
+	assert.Equal(t, 2, limiter.Count())
+
+	limiter.Release("session1")
+	assert.Nil(t, limiter.Acquire("session3", "bob"))
+	assert.Equal(t, 2, limiter.Count())
+}
+
+func TestSessionLimiterEnforcesClientLimit(t *testing.T) {
+	limiter := NewSessionLimiter(0, 1)
+
+	assert.Nil(t, limiter.Acquire("session1", "alice"))
+	assert.NotNil(t, limiter.Acquire("session2", "alice"))
+	assert.Nil(t, limiter.Acquire("session3", "bob"))
+
+	// sessions of unknown clients only count against the instance limit
+	assert.Nil(t, limiter.Acquire("session4", ""))
+	assert.Nil(t, limiter.Acquire("session5", ""))
+
+	limiter.Release("session1")
+	assert.Nil(t, limiter.Acquire("session2", "alice"))
+	assert.Equal(t, 4, limiter.Count())
+}
+
+func TestSessionLimiterIgnoresUnknownSessions(t *testing.T) {
+	limiter := NewSessionLimiter(1, 1)
+	limiter.Release("unknown")
+	assert.Nil(t, limiter.Acquire("session1", "alice"))
+	limiter.Release("unknown")
+	assert.Equal(t, 1, limiter.Count())
+
+	var unlimited *SessionLimiter
+	assert.Nil(t, unlimited.Acquire("session1", "alice"))
+	unlimited.Release("session1")
+	assert.Equal(t, 0, unlimited.Count())
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+)
+
+// compressionCodec creates the writers compressing and the readers decompressing payloads with an algorithm.
+// Payloads are small and mostly interactive, so they are compressed for speed.
+type compressionCodec struct {
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var compressionCodecs = map[string]compressionCodec{
+	mgsContracts.CompressionZlib: {
+		newWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriterLevel(w, zlib.BestSpeed) },
+		newReader: zlib.NewReader,
+	},
+	mgsContracts.CompressionDeflate: {
+		newWriter: func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.BestSpeed) },
+		newReader: func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+	},
+}
+
+// supportedCompression lists the compression algorithms of the agent by order of preference.
+var supportedCompression = []string{mgsContracts.CompressionZlib, mgsContracts.CompressionDeflate}
+
+// compressPayload compresses a stream data payload with the negotiated algorithm.
+func compressPayload(compression string, payload []byte) ([]byte, error) {
+	codec, ok := compressionCodecs[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+	var compressed bytes.Buffer
+	writer, err := codec.newWriter(&compressed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// decompressPayload decompresses a stream data payload with the negotiated algorithm,
+// failing for payloads larger than maxPayloadSize once decompressed.
+func decompressPayload(compression string, compressed []byte, maxPayloadSize int) ([]byte, error) {
+	codec, ok := compressionCodecs[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+	reader, err := codec.newReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	payload, err := ioutil.ReadAll(io.LimitReader(reader, int64(maxPayloadSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxPayloadSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", maxPayloadSize)
+	}
+	return payload, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"bytes"
+	"testing"
+
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressPayload(t *testing.T) {
+	input := bytes.Repeat([]byte("compressible "), 50)
+
+	for _, compression := range supportedCompression {
+		compressed, err := compressPayload(compression, input)
+		assert.Nil(t, err)
+		assert.True(t, len(compressed) < len(input))
+
+		decompressed, err := decompressPayload(compression, compressed, len(input))
+		assert.Nil(t, err)
+		assert.Equal(t, input, decompressed)
+	}
+}
+
+func TestDecompressPayloadWithOtherCompression(t *testing.T) {
+	compressed, err := compressPayload(mgsContracts.CompressionDeflate, payload)
+	assert.Nil(t, err)
+
+	_, err = decompressPayload(mgsContracts.CompressionZlib, compressed, 1024)
+	assert.NotNil(t, err)
+}
+
+func TestDecompressPayloadExceedingMaxPayloadSize(t *testing.T) {
+	compressed, err := compressPayload(mgsContracts.CompressionDeflate, make([]byte, 2048))
+	assert.Nil(t, err)
+
+	_, err = decompressPayload(mgsContracts.CompressionDeflate, compressed, 1024)
+	assert.NotNil(t, err)
+}
+
+func TestUnsupportedCompression(t *testing.T) {
+	_, err := compressPayload("zstd", payload)
+	assert.NotNil(t, err)
+	_, err = decompressPayload("zstd", payload, 1024)
+	assert.NotNil(t, err)
+}
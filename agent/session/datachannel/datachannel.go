@@ -62,6 +62,10 @@ type IDataChannel interface {
 	AddDataToIncomingMessageBuffer(streamMessage StreamingMessage)
 	RemoveDataFromIncomingMessageBuffer(sequenceNumber int64)
 	DataChannelIncomingMessageHandler(log log.T, rawMessage []byte) error
+	EnableEncryption(log log.T, kmsKeyId string) error
+	PerformHandshake(log log.T, pluginFeatures []string, encryptionRequired bool) (mgsContracts.HandshakeCompletePayload, error)
+	GetMetrics() mgsContracts.SessionMetrics
+	IsPluginFeatureEnabled(feature string) bool
 }
 
 // DataChannel used for session communication between the message gateway service and the agent.
@@ -94,6 +98,16 @@ type DataChannel struct {
 	cancelFlag task.CancelFlag
 	//inputStreamMessageHandler is responsible for handling plugin specific input_stream_data message
 	inputStreamMessageHandler func(log log.T, streamDataMessage mgsContracts.AgentMessage) error
+	//encrypter encrypts stream data payloads in both directions once encryption is enabled
+	encrypter     *payloadEncrypter
+	encrypterLock sync.RWMutex
+	//handshakeResponses passes the handshake response of the client to PerformHandshake
+	handshakeResponses chan mgsContracts.HandshakeResponsePayload
+	//capabilities are the session features negotiated in the handshake
+	capabilities     mgsContracts.HandshakeCompletePayload
+	capabilitiesLock sync.RWMutex
+	//metrics collects the traffic and latency of the data channel
+	metrics sessionMetrics
 }
 
 type ListMessageBuffer struct {
@@ -212,6 +226,7 @@ func (dataChannel *DataChannel) Initialize(context context.T,
 	dataChannel.wsChannel = &communicator.WebSocketChannel{}
 	dataChannel.cancelFlag = cancelFlag
 	dataChannel.inputStreamMessageHandler = inputStreamMessageHandler
+	dataChannel.handshakeResponses = make(chan mgsContracts.HandshakeResponsePayload, 1)
 }
 
 // SetWebSocket populates webchannel object.
@@ -233,6 +248,10 @@ func (dataChannel *DataChannel) SetWebSocket(context context.T,
 	}
 
 	onErrorHandler := func(err error) {
+		log.Warnf("Datachannel %s lost its connection, reconnecting: %v", sessionId, err)
+		// Keep buffering outgoing stream data while reconnecting, it is replayed once the connection is back.
+		dataChannel.Pause = true
+
 		uuid.SwitchFormat(uuid.CleanHyphen)
 		requestId := uuid.NewV4().String()
 		callable := func() (channel interface{}, err error) {
@@ -254,7 +273,8 @@ func (dataChannel *DataChannel) SetWebSocket(context context.T,
 			MaxAttempts:         mgsConfig.DataChannelNumMaxAttempts,
 		}
 		if _, err := retryer.Call(); err != nil {
-			log.Error(err)
+			log.Errorf("Failed to reconnect datachannel %s, terminating the session: %v", sessionId, err)
+			dataChannel.cancelFlag.Set(task.Canceled)
 		}
 	}
 
@@ -317,23 +337,111 @@ func (dataChannel *DataChannel) Reconnect(log log.T) error {
 	}
 
 	dataChannel.Pause = false
+	if err := dataChannel.replayUnacknowledgedMessages(log); err != nil {
+		return fmt.Errorf("failed to replay unacknowledged messages with error: %s", err)
+	}
+
 	log.Debugf("Successfully reconnected to datachannel %s", dataChannel.ChannelId)
 	return nil
 }
 
+// replayUnacknowledgedMessages resends, in sequence order, the stream data messages of OutgoingMessageBuffer.
+// Messages sent while the connection was down are lost, the service discards the ones it already received.
+func (dataChannel *DataChannel) replayUnacknowledgedMessages(log log.T) error {
+	dataChannel.OutgoingMessageBuffer.Mutex.Lock()
+	defer dataChannel.OutgoingMessageBuffer.Mutex.Unlock()
+
+	replayed := 0
+	for streamMessageElement := dataChannel.OutgoingMessageBuffer.Messages.Front(); streamMessageElement != nil; streamMessageElement = streamMessageElement.Next() {
+		streamMessage := streamMessageElement.Value.(StreamingMessage)
+		if err := dataChannel.SendMessage(log, streamMessage.Content, websocket.BinaryMessage); err != nil {
+			return err
+		}
+		streamMessage.LastSentTime = time.Now()
+		streamMessageElement.Value = streamMessage
+		dataChannel.metrics.messageResent()
+		replayed++
+	}
+	log.Debugf("Replayed %d unacknowledged stream data messages", replayed)
+	return nil
+}
+
 // Close closes datachannel - its web socket connection.
 func (dataChannel *DataChannel) Close(log log.T) error {
 	log.Infof("Closing datachannel with channel Id %s", dataChannel.ChannelId)
 	return dataChannel.wsChannel.Close(log)
 }
 
+// GetMetrics returns the traffic and latency of the data channel so far.
+func (dataChannel *DataChannel) GetMetrics() mgsContracts.SessionMetrics {
+	return dataChannel.metrics.snapshot()
+}
+
+// EnableEncryption encrypts the stream data payloads of the session, in both directions, with data keys generated by the KMS key.
+// The data key is sent to the client, encrypted by KMS, before any encrypted payload and rotated on long sessions.
+// Incoming payloads which are not encrypted are rejected from then on.
+func (dataChannel *DataChannel) EnableEncryption(log log.T, kmsKeyId string) error {
+	encrypter := newPayloadEncrypter(newDataKeyGenerator(), kmsKeyId, dataChannel.ChannelId, dataChannel.InstanceId)
+	dataChannel.encrypterLock.Lock()
+	dataChannel.encrypter = encrypter
+	dataChannel.encrypterLock.Unlock()
+
+	if err := dataChannel.sendEncryptionKey(log, encrypter); err != nil {
+		return err
+	}
+	log.Infof("Encrypting datachannel %s with KMS key %s", dataChannel.ChannelId, kmsKeyId)
+	return nil
+}
+
+// getEncrypter returns the encrypter of the session, nil unless encryption is enabled.
+func (dataChannel *DataChannel) getEncrypter() *payloadEncrypter {
+	dataChannel.encrypterLock.RLock()
+	defer dataChannel.encrypterLock.RUnlock()
+	return dataChannel.encrypter
+}
+
+// sendEncryptionKey generates a new data key and sends it to the client.
+func (dataChannel *DataChannel) sendEncryptionKey(log log.T, encrypter *payloadEncrypter) error {
+	keyData, err := encrypter.rotate(log)
+	if err != nil {
+		return err
+	}
+	keyDataBytes, err := json.Marshal(keyData)
+	if err != nil {
+		return fmt.Errorf("cannot serialize encryption key: %s", err)
+	}
+	return dataChannel.sendStreamDataMessage(log, mgsContracts.EncryptionKey, keyDataBytes)
+}
+
 // SendStreamDataMessage sends a data message in a form of AgentMessage for streaming.
-func (dataChannel *DataChannel) SendStreamDataMessage(log log.T, payloadType mgsContracts.PayloadType, inputData []byte) error {
+// Payloads are compressed when compression is negotiated, then encrypted when encryption is enabled.
+func (dataChannel *DataChannel) SendStreamDataMessage(log log.T, payloadType mgsContracts.PayloadType, inputData []byte) (err error) {
 	if len(inputData) == 0 {
 		log.Debugf("Ignoring empty stream data payload. PayloadType: %d", payloadType)
 		return nil
 	}
 
+	if compression := dataChannel.getCapabilities().Compression; compression != "" {
+		if inputData, err = compressPayload(compression, inputData); err != nil {
+			return fmt.Errorf("unable to compress stream data payload: %s", err)
+		}
+	}
+
+	if encrypter := dataChannel.getEncrypter(); encrypter != nil {
+		if encrypter.needsRotation() {
+			if err = dataChannel.sendEncryptionKey(log, encrypter); err != nil {
+				return fmt.Errorf("unable to rotate encryption key: %s", err)
+			}
+		}
+		if inputData, err = encrypter.encrypt(payloadType, inputData); err != nil {
+			return fmt.Errorf("unable to encrypt stream data payload: %s", err)
+		}
+	}
+	return dataChannel.sendStreamDataMessage(log, payloadType, inputData)
+}
+
+// sendStreamDataMessage sends a stream data message with the given payload as is.
+func (dataChannel *DataChannel) sendStreamDataMessage(log log.T, payloadType mgsContracts.PayloadType, inputData []byte) error {
 	var flag uint64 = 0
 	if dataChannel.StreamDataSequenceNumber == 0 {
 		flag = 1
@@ -372,6 +480,7 @@ func (dataChannel *DataChannel) SendStreamDataMessage(log log.T, payloadType mgs
 	}
 	log.Tracef("Add stream data to OutgoingMessageBuffer. Sequence Number: %d", streamingMessage.SequenceNumber)
 	dataChannel.AddDataToOutgoingMessageBuffer(streamingMessage)
+	dataChannel.metrics.messageSent(len(inputData))
 	dataChannel.StreamDataSequenceNumber = dataChannel.StreamDataSequenceNumber + 1
 	return nil
 }
@@ -399,6 +508,7 @@ func (dataChannel *DataChannel) ResendStreamDataMessageScheduler(log log.T) erro
 				}
 				streamMessage.LastSentTime = time.Now()
 				streamMessageElement.Value = streamMessage
+				dataChannel.metrics.messageResent()
 			}
 		}
 	}()
@@ -566,7 +676,9 @@ func (dataChannel *DataChannel) DataChannelIncomingMessageHandler(log log.T, raw
 
 // calculateRetransmissionTimeout calculates message retransmission timeout value based on round trip time on given message.
 func (dataChannel *DataChannel) calculateRetransmissionTimeout(log log.T, streamingMessage StreamingMessage) {
-	newRoundTripTime := float64(time.Since(streamingMessage.LastSentTime))
+	roundTripTime := time.Since(streamingMessage.LastSentTime)
+	dataChannel.metrics.messageAcknowledged(roundTripTime)
+	newRoundTripTime := float64(roundTripTime)
 
 	dataChannel.RoundTripTimeVariation = ((1 - mgsConfig.RTTVConstant) * dataChannel.RoundTripTimeVariation) +
 		(mgsConfig.RTTVConstant * math.Abs(dataChannel.RoundTripTime-newRoundTripTime))
@@ -596,6 +708,7 @@ func (dataChannel *DataChannel) handleStreamDataMessage(log log.T,
 	rawMessage []byte) (err error) {
 
 	dataChannel.Pause = false
+	dataChannel.metrics.messageReceived(len(streamDataMessage.Payload))
 	// On receiving expected stream data message, send acknowledgement, process it and increment expected sequence number by 1.
 	// Further process messages from IncomingMessageBuffer
 	if streamDataMessage.SequenceNumber == dataChannel.ExpectedSequenceNumber {
@@ -604,7 +717,7 @@ func (dataChannel *DataChannel) handleStreamDataMessage(log log.T,
 		}
 
 		log.Tracef("Process new incoming stream data message. Sequence Number: %d", streamDataMessage.SequenceNumber)
-		if err = dataChannel.inputStreamMessageHandler(log, streamDataMessage); err != nil {
+		if err = dataChannel.processStreamDataMessage(log, streamDataMessage); err != nil {
 			log.Errorf("Unable to process stream data payload, err: %v.", err)
 			return err
 		}
@@ -618,6 +731,12 @@ func (dataChannel *DataChannel) handleStreamDataMessage(log log.T,
 		log.Debugf("Unexpected sequence message received. Received Sequence Number: %d. Expected Sequence Number: %d",
 			streamDataMessage.SequenceNumber, dataChannel.ExpectedSequenceNumber)
 
+		// A message already buffered is a retransmission, acknowledge it again without buffering it twice.
+		if _, buffered := dataChannel.IncomingMessageBuffer.Messages[streamDataMessage.SequenceNumber]; buffered {
+			log.Tracef("Discarding already buffered message. Received Sequence Number: %d", streamDataMessage.SequenceNumber)
+			return dataChannel.SendAcknowledgeMessage(log, streamDataMessage)
+		}
+
 		if len(dataChannel.IncomingMessageBuffer.Messages) < dataChannel.IncomingMessageBuffer.Capacity {
 			if err = dataChannel.SendAcknowledgeMessage(log, streamDataMessage); err != nil {
 				return err
@@ -636,10 +755,36 @@ func (dataChannel *DataChannel) handleStreamDataMessage(log log.T,
 	} else {
 		log.Tracef("Discarding already processed message. Received Sequence Number: %d. Expected Sequence Number: %d",
 			streamDataMessage.SequenceNumber, dataChannel.ExpectedSequenceNumber)
+
+		// The acknowledgement may have been lost with the connection, acknowledge again so the service stops resending it.
+		return dataChannel.SendAcknowledgeMessage(log, streamDataMessage)
 	}
 	return nil
 }
 
+// processStreamDataMessage decrypts the payload of encrypted sessions, decompresses it when compression is negotiated,
+// and passes the stream data message to the plugin. Handshake responses are handled by the data channel.
+func (dataChannel *DataChannel) processStreamDataMessage(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	if mgsContracts.PayloadType(streamDataMessage.PayloadType) == mgsContracts.HandshakeResponse {
+		return dataChannel.handleHandshakeResponse(log, streamDataMessage.Payload)
+	}
+	if encrypter := dataChannel.getEncrypter(); encrypter != nil {
+		payload, err := encrypter.decrypt(mgsContracts.PayloadType(streamDataMessage.PayloadType), streamDataMessage.Payload)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt stream data payload: %s", err)
+		}
+		streamDataMessage.Payload = payload
+	}
+	if capabilities := dataChannel.getCapabilities(); capabilities.Compression != "" {
+		payload, err := decompressPayload(capabilities.Compression, streamDataMessage.Payload, capabilities.MaxPayloadSize)
+		if err != nil {
+			return fmt.Errorf("unable to decompress stream data payload: %s", err)
+		}
+		streamDataMessage.Payload = payload
+	}
+	return dataChannel.inputStreamMessageHandler(log, streamDataMessage)
+}
+
 // handleAcknowledgeMessage deserialize acknowledge content and process it.
 func (dataChannel *DataChannel) handleAcknowledgeMessage(log log.T, streamDataMessage mgsContracts.AgentMessage) (err error) {
 	dataChannel.Pause = false
@@ -694,7 +839,7 @@ func (dataChannel *DataChannel) processIncomingMessageBufferItems(log log.T) (er
 				log.Errorf("Cannot deserialize raw message: %d, err: %v.", bufferedStreamMessage.SequenceNumber, err)
 				return err
 			}
-			if err = dataChannel.inputStreamMessageHandler(log, *streamDataMessage); err != nil {
+			if err = dataChannel.processStreamDataMessage(log, *streamDataMessage); err != nil {
 				log.Errorf("Unable to process stream data payload, err: %v.", err)
 				return err
 			}
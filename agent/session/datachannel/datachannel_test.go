@@ -15,6 +15,7 @@
 package datachannel
 
 import (
+	"errors"
 	"strconv"
 	"sync"
 	"testing"
@@ -30,6 +31,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/twinj/uuid"
@@ -142,6 +144,50 @@ func TestReconnect(t *testing.T) {
 	mockWsChannel.AssertExpectations(t)
 }
 
+func TestReconnectReplaysUnacknowledgedMessages(t *testing.T) {
+	dataChannel := getDataChannel()
+	dataChannel.Pause = true
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	dataChannel.wsChannel = mockChannel
+	for i := 0; i < 3; i++ {
+		dataChannel.AddDataToOutgoingMessageBuffer(streamingMessages[i])
+	}
+
+	mockChannel.On("Close", mock.Anything).Return(nil)
+	mockChannel.On("Open", mock.Anything).Return(nil)
+	mockChannel.On("GetChannelToken").Return(token)
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := dataChannel.Reconnect(mockLog)
+
+	assert.Nil(t, err)
+	assert.False(t, dataChannel.Pause)
+	// open handshake followed by the unacknowledged messages, which stay buffered until acknowledged
+	mockChannel.AssertNumberOfCalls(t, "SendMessage", 4)
+	for i := 0; i < 3; i++ {
+		mockChannel.AssertCalled(t, "SendMessage", mock.Anything, serializedAgentMessages[i], websocket.BinaryMessage)
+	}
+	assert.Equal(t, 3, dataChannel.OutgoingMessageBuffer.Messages.Len())
+}
+
+func TestReconnectWhenReplayFails(t *testing.T) {
+	dataChannel := getDataChannel()
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	dataChannel.wsChannel = mockChannel
+	dataChannel.AddDataToOutgoingMessageBuffer(streamingMessages[0])
+
+	mockChannel.On("Close", mock.Anything).Return(nil)
+	mockChannel.On("Open", mock.Anything).Return(nil)
+	mockChannel.On("GetChannelToken").Return(token)
+	mockChannel.On("SendMessage", mock.Anything, serializedAgentMessages[0], mock.Anything).Return(errors.New("connection reset"))
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := dataChannel.Reconnect(mockLog)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, dataChannel.OutgoingMessageBuffer.Messages.Len())
+}
+
 func TestClose(t *testing.T) {
 	dataChannel := getDataChannel()
 
@@ -371,6 +417,32 @@ func TestDataChannelIncomingMessageHandlerForUnexpectedInputStreamDataMessage(t
 	assert.Nil(t, bufferedStreamMessage.Content)
 }
 
+func TestDataChannelIncomingMessageHandlerForDuplicateInputStreamDataMessage(t *testing.T) {
+	dataChannel := getDataChannel()
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	dataChannel.wsChannel = mockChannel
+	processed := 0
+	dataChannel.inputStreamMessageHandler = func(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+		processed++
+		return nil
+	}
+
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Messages replayed after a reconnect are acknowledged again but processed only once
+	for i := 0; i < 2; i++ {
+		err := dataChannel.DataChannelIncomingMessageHandler(mockLog, serializedAgentMessages[0])
+		assert.Nil(t, err)
+		err = dataChannel.DataChannelIncomingMessageHandler(mockLog, serializedAgentMessages[2])
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, int64(1), dataChannel.ExpectedSequenceNumber)
+	assert.Equal(t, 1, len(dataChannel.IncomingMessageBuffer.Messages))
+	mockChannel.AssertNumberOfCalls(t, "SendMessage", 4)
+}
+
 func TestDataChannelIncomingMessageHandlerForAcknowledgeMessage(t *testing.T) {
 	dataChannel := getDataChannel()
 	dataChannel.Pause = true
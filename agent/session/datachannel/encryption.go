@@ -0,0 +1,195 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+const (
+	// dataKeySize is the size of the AES-256 data keys.
+	dataKeySize = 32
+	// generationSize is the size of the key generation prefixing encrypted payloads.
+	generationSize = 4
+
+	encryptionContextSessionId = "aws:ssm:SessionId"
+	encryptionContextTargetId  = "aws:ssm:TargetId"
+)
+
+// dataKeyGenerator generates the data keys encrypting the payloads of the data channel.
+type dataKeyGenerator interface {
+	GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error)
+}
+
+var newDataKeyGenerator = func() dataKeyGenerator {
+	appConfig, _ := appconfig.Config(false)
+	sess := session.New(sdkutil.AwsConfig())
+	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+	return kms.New(sess)
+}
+
+// encryptionKey is a data key of an encrypted session.
+type encryptionKey struct {
+	generation uint32
+	aead       cipher.AEAD
+}
+
+// payloadEncrypter encrypts the stream data payloads of a session with AES-GCM data keys generated by KMS.
+// An encrypted payload is the generation of its key (big endian uint32), the nonce and the sealed payload;
+// the payload type is authenticated along with the payload.
+// Keys are rotated on long sessions, the previous key still decrypts the payloads the client sent before it got the new one.
+type payloadEncrypter struct {
+	lock              sync.Mutex
+	kmsKeyId          string
+	encryptionContext map[string]*string
+	kms               dataKeyGenerator
+	current           *encryptionKey
+	previous          *encryptionKey
+	rotatedTime       time.Time
+	messages          uint64
+	now               func() time.Time
+}
+
+// newPayloadEncrypter returns a payloadEncrypter generating its data keys with the KMS key of the session.
+func newPayloadEncrypter(kms dataKeyGenerator, kmsKeyId string, sessionId string, instanceId string) *payloadEncrypter {
+	return &payloadEncrypter{
+		kmsKeyId: kmsKeyId,
+		encryptionContext: map[string]*string{
+			encryptionContextSessionId: aws.String(sessionId),
+			encryptionContextTargetId:  aws.String(instanceId),
+		},
+		kms: kms,
+		now: time.Now,
+	}
+}
+
+// rotate generates a new data key, used to encrypt payloads from then on, and returns the data announcing it to the client.
+func (e *payloadEncrypter) rotate(log log.T) (keyData mgsContracts.EncryptionKeyData, err error) {
+	output, err := e.kms.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:             aws.String(e.kmsKeyId),
+		NumberOfBytes:     aws.Int64(dataKeySize),
+		EncryptionContext: e.encryptionContext,
+	})
+	if err != nil {
+		return keyData, fmt.Errorf("failed to generate data key with KMS key %s: %s", e.kmsKeyId, err)
+	}
+	block, err := aes.NewCipher(output.Plaintext)
+	if err != nil {
+		return keyData, fmt.Errorf("invalid data key: %s", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return keyData, fmt.Errorf("invalid data key: %s", err)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	key := &encryptionKey{aead: aead}
+	if e.current != nil {
+		key.generation = e.current.generation + 1
+	}
+	e.previous = e.current
+	e.current = key
+	e.rotatedTime = e.now()
+	e.messages = 0
+	log.Debugf("Generated data key %d with KMS key %s", key.generation, e.kmsKeyId)
+
+	return mgsContracts.EncryptionKeyData{
+		KmsKeyId:      aws.StringValue(output.KeyId),
+		Generation:    key.generation,
+		CiphertextKey: output.CiphertextBlob,
+	}, nil
+}
+
+// needsRotation returns true if the current data key expired or encrypted too many payloads.
+func (e *payloadEncrypter) needsRotation() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.current == nil ||
+		e.now().Sub(e.rotatedTime) >= mgsConfig.EncryptionKeyRotationInterval ||
+		e.messages >= mgsConfig.EncryptionKeyMaxMessages
+}
+
+// encrypt encrypts a payload of the given type with the current data key.
+func (e *payloadEncrypter) encrypt(payloadType mgsContracts.PayloadType, payload []byte) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.current == nil {
+		return nil, errors.New("no data key")
+	}
+
+	nonceSize := e.current.aead.NonceSize()
+	prefix := make([]byte, generationSize+nonceSize, generationSize+nonceSize+len(payload)+e.current.aead.Overhead())
+	binary.BigEndian.PutUint32(prefix, e.current.generation)
+	if _, err := rand.Read(prefix[generationSize:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %s", err)
+	}
+	e.messages++
+	return e.current.aead.Seal(prefix, prefix[generationSize:], payload, additionalData(payloadType)), nil
+}
+
+// decrypt decrypts a payload of the given type with the data key it was encrypted with.
+func (e *payloadEncrypter) decrypt(payloadType mgsContracts.PayloadType, encrypted []byte) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(encrypted) < generationSize {
+		return nil, errors.New("encrypted payload is too short")
+	}
+
+	generation := binary.BigEndian.Uint32(encrypted)
+	var key *encryptionKey
+	for _, candidate := range []*encryptionKey{e.current, e.previous} {
+		if candidate != nil && candidate.generation == generation {
+			key = candidate
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("payload is encrypted with unknown data key %d", generation)
+	}
+
+	nonceSize := key.aead.NonceSize()
+	if len(encrypted) < generationSize+nonceSize {
+		return nil, errors.New("encrypted payload is too short")
+	}
+	nonce := encrypted[generationSize : generationSize+nonceSize]
+	payload, err := key.aead.Open(nil, nonce, encrypted[generationSize+nonceSize:], additionalData(payloadType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %s", err)
+	}
+	return payload, nil
+}
+
+// additionalData returns the data authenticated along with payloads of the given type.
+func additionalData(payloadType mgsContracts.PayloadType) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(payloadType))
+	return data
+}
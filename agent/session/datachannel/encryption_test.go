@@ -0,0 +1,176 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	communicatorMocks "github.com/aws/amazon-ssm-agent/agent/session/communicator/mocks"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeDataKeyGenerator generates random data keys, their ciphertext is the generation count.
+type fakeDataKeyGenerator struct {
+	inputs []*kms.GenerateDataKeyInput
+	err    error
+}
+
+func (g *fakeDataKeyGenerator) GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	g.inputs = append(g.inputs, input)
+	key := make([]byte, aws.Int64Value(input.NumberOfBytes))
+	rand.Read(key)
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          aws.String("arn:aws:kms:us-east-1:123456789012:key/" + aws.StringValue(input.KeyId)),
+		Plaintext:      key,
+		CiphertextBlob: []byte{byte(len(g.inputs))},
+	}, nil
+}
+
+// getEncryptedDataChannel returns a data channel encrypted with keys of generator, recording the messages it sends.
+func getEncryptedDataChannel(t *testing.T, generator *fakeDataKeyGenerator) (dataChannel *DataChannel, sent *[]mgsContracts.AgentMessage) {
+	defaultDataKeyGenerator := newDataKeyGenerator
+	newDataKeyGenerator = func() dataKeyGenerator { return generator }
+	defer func() { newDataKeyGenerator = defaultDataKeyGenerator }()
+
+	sent = &[]mgsContracts.AgentMessage{}
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		message := mgsContracts.AgentMessage{}
+		assert.Nil(t, message.Deserialize(mockLog, args.Get(1).([]byte)))
+		*sent = append(*sent, message)
+	})
+	dataChannel = getDataChannel()
+	dataChannel.wsChannel = mockChannel
+
+	assert.Nil(t, dataChannel.EnableEncryption(mockLog, "key-id"))
+	return
+}
+
+func TestEnableEncryption(t *testing.T) {
+	generator := &fakeDataKeyGenerator{}
+	_, sent := getEncryptedDataChannel(t, generator)
+
+	assert.Equal(t, 1, len(generator.inputs))
+	assert.Equal(t, "key-id", aws.StringValue(generator.inputs[0].KeyId))
+	assert.Equal(t, int64(dataKeySize), aws.Int64Value(generator.inputs[0].NumberOfBytes))
+	assert.Equal(t, map[string]*string{
+		encryptionContextSessionId: aws.String(sessionId),
+		encryptionContextTargetId:  aws.String(instanceId),
+	}, generator.inputs[0].EncryptionContext)
+
+	assert.Equal(t, 1, len(*sent))
+	assert.Equal(t, uint32(mgsContracts.EncryptionKey), (*sent)[0].PayloadType)
+	var keyData mgsContracts.EncryptionKeyData
+	assert.Nil(t, json.Unmarshal((*sent)[0].Payload, &keyData))
+	assert.Equal(t, mgsContracts.EncryptionKeyData{
+		KmsKeyId:      "arn:aws:kms:us-east-1:123456789012:key/key-id",
+		Generation:    0,
+		CiphertextKey: []byte{1},
+	}, keyData)
+}
+
+func TestEnableEncryptionWhenKMSFails(t *testing.T) {
+	defaultDataKeyGenerator := newDataKeyGenerator
+	newDataKeyGenerator = func() dataKeyGenerator { return &fakeDataKeyGenerator{err: errors.New("AccessDeniedException")} }
+	defer func() { newDataKeyGenerator = defaultDataKeyGenerator }()
+	dataChannel := getDataChannel()
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	dataChannel.wsChannel = mockChannel
+
+	assert.NotNil(t, dataChannel.EnableEncryption(mockLog, "key-id"))
+	mockChannel.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSendStreamDataMessageEncryptsPayload(t *testing.T) {
+	dataChannel, sent := getEncryptedDataChannel(t, &fakeDataKeyGenerator{})
+
+	assert.Nil(t, dataChannel.SendStreamDataMessage(mockLog, mgsContracts.Output, payload))
+
+	assert.Equal(t, 2, len(*sent))
+	message := (*sent)[1]
+	assert.Equal(t, uint32(mgsContracts.Output), message.PayloadType)
+	assert.NotContains(t, string(message.Payload), string(payload))
+	decrypted, err := dataChannel.encrypter.decrypt(mgsContracts.Output, message.Payload)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, decrypted)
+	// the payload type is authenticated
+	_, err = dataChannel.encrypter.decrypt(mgsContracts.Size, message.Payload)
+	assert.NotNil(t, err)
+}
+
+func TestSendStreamDataMessageRotatesEncryptionKey(t *testing.T) {
+	dataChannel, sent := getEncryptedDataChannel(t, &fakeDataKeyGenerator{})
+	encrypter := dataChannel.encrypter
+	previousPayload, _ := encrypter.encrypt(mgsContracts.Output, payload)
+	encrypter.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	assert.Nil(t, dataChannel.SendStreamDataMessage(mockLog, mgsContracts.Output, payload))
+
+	assert.Equal(t, 3, len(*sent))
+	var keyData mgsContracts.EncryptionKeyData
+	assert.Equal(t, uint32(mgsContracts.EncryptionKey), (*sent)[1].PayloadType)
+	assert.Nil(t, json.Unmarshal((*sent)[1].Payload, &keyData))
+	assert.Equal(t, uint32(1), keyData.Generation)
+	assert.Equal(t, []byte{2}, keyData.CiphertextKey)
+
+	// payloads the client encrypted with the previous key are still accepted
+	decrypted, err := encrypter.decrypt(mgsContracts.Output, previousPayload)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, decrypted)
+	assert.False(t, encrypter.needsRotation())
+}
+
+func TestDataChannelIncomingMessageHandlerDecryptsPayload(t *testing.T) {
+	dataChannel, _ := getEncryptedDataChannel(t, &fakeDataKeyGenerator{})
+	var received [][]byte
+	dataChannel.inputStreamMessageHandler = func(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+		received = append(received, streamDataMessage.Payload)
+		return nil
+	}
+
+	encrypted, _ := dataChannel.encrypter.encrypt(mgsContracts.Output, []byte("ls\n"))
+	message, _ := getAgentMessage(0, mgsContracts.InputStreamDataMessage, uint32(mgsContracts.Output), encrypted).Serialize(mockLog)
+	assert.Nil(t, dataChannel.DataChannelIncomingMessageHandler(mockLog, message))
+
+	// plaintext payloads are rejected once the session is encrypted
+	message, _ = getAgentMessage(1, mgsContracts.InputStreamDataMessage, uint32(mgsContracts.Output), []byte("ls\n")).Serialize(mockLog)
+	assert.NotNil(t, dataChannel.DataChannelIncomingMessageHandler(mockLog, message))
+
+	assert.Equal(t, [][]byte{[]byte("ls\n")}, received)
+}
+
+func TestDecryptWithUnknownKey(t *testing.T) {
+	encrypter := newPayloadEncrypter(&fakeDataKeyGenerator{}, "key-id", sessionId, instanceId)
+	encrypter.rotate(mockLog)
+	encrypted, _ := encrypter.encrypt(mgsContracts.Output, payload)
+	encrypter.rotate(mockLog)
+	encrypter.rotate(mockLog)
+
+	_, err := encrypter.decrypt(mgsContracts.Output, encrypted)
+	assert.NotNil(t, err)
+	_, err = encrypter.decrypt(mgsContracts.Output, []byte{0, 0})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+// handshakeTimeout is the time the agent waits for the handshake response, overridden in tests.
+var handshakeTimeout = mgsConfig.HandshakeTimeout
+
+// PerformHandshake exchanges capabilities with the client and enables the negotiated compression on the data channel,
+// unless compression is disabled in the agent configuration.
+// Clients that don't answer within handshakeTimeout predate the handshake: the session falls back to the features
+// they support, which include encryption but no plugin features nor compression.
+// An error is returned when the client answers without supporting the encryption the session requires.
+func (dataChannel *DataChannel) PerformHandshake(log log.T, pluginFeatures []string, encryptionRequired bool) (negotiated mgsContracts.HandshakeCompletePayload, err error) {
+	request := mgsContracts.HandshakeRequestPayload{
+		AgentVersion:     version.Version,
+		HandshakeVersion: mgsContracts.HandshakeVersion,
+		Capabilities: mgsContracts.SessionCapabilities{
+			PluginFeatures: pluginFeatures,
+			MaxPayloadSize: mgsConfig.StreamDataPayloadSize,
+		},
+	}
+	if !dataChannel.context.AppConfig().Mgs.DisableCompression {
+		request.Capabilities.Compression = supportedCompression
+	}
+	if encryptionRequired {
+		request.Capabilities.Encryption = []string{mgsContracts.EncryptionKMS}
+	}
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return negotiated, fmt.Errorf("cannot serialize handshake request: %s", err)
+	}
+	if err = dataChannel.sendStreamDataMessage(log, mgsContracts.HandshakeRequest, requestBytes); err != nil {
+		return negotiated, err
+	}
+
+	var response mgsContracts.HandshakeResponsePayload
+	select {
+	case response = <-dataChannel.handshakeResponses:
+	case <-time.After(handshakeTimeout):
+		log.Infof("Client of session %s did not answer the handshake, using the default session features", dataChannel.ChannelId)
+		negotiated = mgsContracts.HandshakeCompletePayload{MaxPayloadSize: mgsConfig.StreamDataPayloadSize}
+		if encryptionRequired {
+			negotiated.Encryption = mgsContracts.EncryptionKMS
+		}
+		return negotiated, nil
+	}
+
+	log.Debugf("Handshake response of client version %s: %+v", response.ClientVersion, response.Capabilities)
+	if negotiated, err = negotiateCapabilities(request.Capabilities, response.Capabilities); err != nil {
+		return negotiated, err
+	}
+	completeBytes, err := json.Marshal(negotiated)
+	if err != nil {
+		return negotiated, fmt.Errorf("cannot serialize handshake complete: %s", err)
+	}
+	if err = dataChannel.sendStreamDataMessage(log, mgsContracts.HandshakeComplete, completeBytes); err != nil {
+		return negotiated, err
+	}
+
+	dataChannel.capabilitiesLock.Lock()
+	dataChannel.capabilities = negotiated
+	dataChannel.capabilitiesLock.Unlock()
+	log.Infof("Negotiated session features: %+v", negotiated)
+	return negotiated, nil
+}
+
+// negotiateCapabilities returns the features both the agent and the client support, by order of preference of the client.
+func negotiateCapabilities(agent mgsContracts.SessionCapabilities, client mgsContracts.SessionCapabilities) (negotiated mgsContracts.HandshakeCompletePayload, err error) {
+	negotiated.HandshakeVersion = mgsContracts.HandshakeVersion
+	negotiated.PluginFeatures = []string{}
+	for _, feature := range agent.PluginFeatures {
+		if contains(client.PluginFeatures, feature) {
+			negotiated.PluginFeatures = append(negotiated.PluginFeatures, feature)
+		}
+	}
+	for _, compression := range client.Compression {
+		if contains(agent.Compression, compression) {
+			negotiated.Compression = compression
+			break
+		}
+	}
+	if len(agent.Encryption) > 0 {
+		for _, encryption := range client.Encryption {
+			if contains(agent.Encryption, encryption) {
+				negotiated.Encryption = encryption
+				break
+			}
+		}
+		if negotiated.Encryption == "" {
+			return negotiated, fmt.Errorf("client does not support the encryption required by the session")
+		}
+	}
+	// plugins send payloads up to the size of the agent, which clients must accept
+	if client.MaxPayloadSize != 0 && client.MaxPayloadSize < agent.MaxPayloadSize {
+		return negotiated, fmt.Errorf("client accepts payloads up to %d bytes, less than the %d bytes of the agent", client.MaxPayloadSize, agent.MaxPayloadSize)
+	}
+	negotiated.MaxPayloadSize = agent.MaxPayloadSize
+	return negotiated, nil
+}
+
+// handleHandshakeResponse passes the handshake response of the client to PerformHandshake.
+func (dataChannel *DataChannel) handleHandshakeResponse(log log.T, payload []byte) error {
+	var response mgsContracts.HandshakeResponsePayload
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return fmt.Errorf("cannot deserialize handshake response: %s", err)
+	}
+	select {
+	case dataChannel.handshakeResponses <- response:
+	default:
+		log.Warnf("Ignoring unexpected handshake response of session %s", dataChannel.ChannelId)
+	}
+	return nil
+}
+
+// getCapabilities returns the features negotiated in the handshake.
+func (dataChannel *DataChannel) getCapabilities() mgsContracts.HandshakeCompletePayload {
+	dataChannel.capabilitiesLock.RLock()
+	defer dataChannel.capabilitiesLock.RUnlock()
+	return dataChannel.capabilities
+}
+
+// IsPluginFeatureEnabled returns true if the plugin feature was negotiated in the handshake.
+func (dataChannel *DataChannel) IsPluginFeatureEnabled(feature string) bool {
+	return contains(dataChannel.getCapabilities().PluginFeatures, feature)
+}
+
+// contains returns true if values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	communicatorMocks "github.com/aws/amazon-ssm-agent/agent/session/communicator/mocks"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// getRecordingDataChannel returns a data channel recording the messages it sends.
+func getRecordingDataChannel(t *testing.T) (dataChannel *DataChannel, sent *[]mgsContracts.AgentMessage) {
+	sent = &[]mgsContracts.AgentMessage{}
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		message := mgsContracts.AgentMessage{}
+		assert.Nil(t, message.Deserialize(mockLog, args.Get(1).([]byte)))
+		*sent = append(*sent, message)
+	})
+	dataChannel = getDataChannel()
+	dataChannel.wsChannel = mockChannel
+	return
+}
+
+// receiveHandshakeResponse processes the handshake response of the client, ahead of PerformHandshake.
+func receiveHandshakeResponse(t *testing.T, dataChannel *DataChannel, capabilities mgsContracts.SessionCapabilities) {
+	response, _ := json.Marshal(mgsContracts.HandshakeResponsePayload{
+		ClientVersion:    "1.2.0.0",
+		HandshakeVersion: mgsContracts.HandshakeVersion,
+		Capabilities:     capabilities,
+	})
+	assert.Nil(t, dataChannel.processStreamDataMessage(mockLog, mgsContracts.AgentMessage{
+		MessageType: mgsContracts.InputStreamDataMessage,
+		PayloadType: uint32(mgsContracts.HandshakeResponse),
+		Payload:     response,
+	}))
+}
+
+func TestPerformHandshake(t *testing.T) {
+	dataChannel, sent := getRecordingDataChannel(t)
+	receiveHandshakeResponse(t, dataChannel, mgsContracts.SessionCapabilities{
+		PluginFeatures: []string{"udp", "unknown"},
+		Compression:    []string{"zstd", mgsContracts.CompressionDeflate, mgsContracts.CompressionZlib},
+		Encryption:     []string{mgsContracts.EncryptionKMS},
+		MaxPayloadSize: 4096,
+	})
+
+	negotiated, err := dataChannel.PerformHandshake(mockLog, []string{"multiplexing", "udp"}, true)
+
+	assert.Nil(t, err)
+	expected := mgsContracts.HandshakeCompletePayload{
+		HandshakeVersion: mgsContracts.HandshakeVersion,
+		PluginFeatures:   []string{"udp"},
+		Compression:      mgsContracts.CompressionDeflate,
+		Encryption:       mgsContracts.EncryptionKMS,
+		MaxPayloadSize:   mgsConfig.StreamDataPayloadSize,
+	}
+	assert.Equal(t, expected, negotiated)
+	assert.Equal(t, expected, dataChannel.getCapabilities())
+	assert.True(t, dataChannel.IsPluginFeatureEnabled("udp"))
+	assert.False(t, dataChannel.IsPluginFeatureEnabled("multiplexing"))
+
+	assert.Equal(t, 2, len(*sent))
+	assert.Equal(t, uint32(mgsContracts.HandshakeRequest), (*sent)[0].PayloadType)
+	var request mgsContracts.HandshakeRequestPayload
+	assert.Nil(t, json.Unmarshal((*sent)[0].Payload, &request))
+	assert.Equal(t, mgsContracts.HandshakeRequestPayload{
+		AgentVersion:     version.Version,
+		HandshakeVersion: mgsContracts.HandshakeVersion,
+		Capabilities: mgsContracts.SessionCapabilities{
+			PluginFeatures: []string{"multiplexing", "udp"},
+			Compression:    []string{mgsContracts.CompressionZlib, mgsContracts.CompressionDeflate},
+			Encryption:     []string{mgsContracts.EncryptionKMS},
+			MaxPayloadSize: mgsConfig.StreamDataPayloadSize,
+		},
+	}, request)
+	assert.Equal(t, uint32(mgsContracts.HandshakeComplete), (*sent)[1].PayloadType)
+	var complete mgsContracts.HandshakeCompletePayload
+	assert.Nil(t, json.Unmarshal((*sent)[1].Payload, &complete))
+	assert.Equal(t, expected, complete)
+}
+
+func TestPerformHandshakeWithCompressionDisabled(t *testing.T) {
+	dataChannel, sent := getRecordingDataChannel(t)
+	agentContext := new(context.Mock)
+	agentContext.On("AppConfig").Return(appconfig.SsmagentConfig{Mgs: appconfig.MgsConfig{DisableCompression: true}})
+	dataChannel.context = agentContext
+	receiveHandshakeResponse(t, dataChannel, mgsContracts.SessionCapabilities{
+		Compression: []string{mgsContracts.CompressionZlib},
+	})
+
+	negotiated, err := dataChannel.PerformHandshake(mockLog, nil, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", negotiated.Compression)
+	var request mgsContracts.HandshakeRequestPayload
+	assert.Nil(t, json.Unmarshal((*sent)[0].Payload, &request))
+	assert.Empty(t, request.Capabilities.Compression)
+}
+
+func TestPerformHandshakeWithClientPredatingHandshake(t *testing.T) {
+	defer func(timeout time.Duration) { handshakeTimeout = timeout }(handshakeTimeout)
+	handshakeTimeout = 10 * time.Millisecond
+	dataChannel, sent := getRecordingDataChannel(t)
+
+	negotiated, err := dataChannel.PerformHandshake(mockLog, []string{"udp"}, true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, mgsContracts.HandshakeCompletePayload{
+		Encryption:     mgsContracts.EncryptionKMS,
+		MaxPayloadSize: mgsConfig.StreamDataPayloadSize,
+	}, negotiated)
+	assert.Equal(t, "", dataChannel.getCapabilities().Compression)
+	assert.False(t, dataChannel.IsPluginFeatureEnabled("udp"))
+	assert.Equal(t, 1, len(*sent))
+}
+
+func TestPerformHandshakeWhenClientDoesNotSupportEncryption(t *testing.T) {
+	dataChannel, sent := getRecordingDataChannel(t)
+	receiveHandshakeResponse(t, dataChannel, mgsContracts.SessionCapabilities{
+		Compression: []string{mgsContracts.CompressionDeflate},
+	})
+
+	_, err := dataChannel.PerformHandshake(mockLog, nil, true)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(*sent))
+	assert.Equal(t, "", dataChannel.getCapabilities().Compression)
+}
+
+func TestPerformHandshakeWhenClientAcceptsSmallerPayloads(t *testing.T) {
+	dataChannel, _ := getRecordingDataChannel(t)
+	receiveHandshakeResponse(t, dataChannel, mgsContracts.SessionCapabilities{
+		MaxPayloadSize: mgsConfig.StreamDataPayloadSize / 2,
+	})
+
+	_, err := dataChannel.PerformHandshake(mockLog, nil, false)
+
+	assert.NotNil(t, err)
+}
+
+func TestStreamDataMessagesAreCompressedOnceNegotiated(t *testing.T) {
+	dataChannel, sent := getRecordingDataChannel(t)
+	var received []byte
+	dataChannel.inputStreamMessageHandler = func(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+		received = streamDataMessage.Payload
+		return nil
+	}
+	receiveHandshakeResponse(t, dataChannel, mgsContracts.SessionCapabilities{
+		Compression: []string{mgsContracts.CompressionZlib},
+	})
+	_, err := dataChannel.PerformHandshake(mockLog, nil, false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, dataChannel.SendStreamDataMessage(mockLog, mgsContracts.Output, payload))
+	assert.Equal(t, 3, len(*sent))
+	decompressed, err := decompressPayload(mgsContracts.CompressionZlib, (*sent)[2].Payload, mgsConfig.StreamDataPayloadSize)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, decompressed)
+
+	compressed, _ := compressPayload(mgsContracts.CompressionZlib, payload)
+	assert.Nil(t, dataChannel.processStreamDataMessage(mockLog, mgsContracts.AgentMessage{
+		MessageType: mgsContracts.InputStreamDataMessage,
+		PayloadType: uint32(mgsContracts.Output),
+		Payload:     compressed,
+	}))
+	assert.Equal(t, payload, received)
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"sync"
+	"time"
+
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+)
+
+// sessionMetrics collects the traffic and latency of a data channel, its zero value is ready to use.
+type sessionMetrics struct {
+	lock    sync.Mutex
+	metrics mgsContracts.SessionMetrics
+	// roundTripTimes is the number of round trip times measured, totalling roundTripTimeTotal
+	roundTripTimes     int64
+	roundTripTimeTotal time.Duration
+	minRoundTripTime   time.Duration
+	maxRoundTripTime   time.Duration
+}
+
+// messageSent counts a stream data message sent for the first time.
+func (m *sessionMetrics) messageSent(payloadSize int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.metrics.MessagesOut++
+	m.metrics.BytesOut += int64(payloadSize)
+}
+
+// messageResent counts a stream data message sent again because it was not acknowledged in time.
+func (m *sessionMetrics) messageResent() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.metrics.Retransmissions++
+}
+
+// messageReceived counts a stream data message received, duplicates included.
+func (m *sessionMetrics) messageReceived(payloadSize int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.metrics.MessagesIn++
+	m.metrics.BytesIn += int64(payloadSize)
+}
+
+// messageAcknowledged records the round trip time of a stream data message.
+func (m *sessionMetrics) messageAcknowledged(roundTripTime time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.roundTripTimes == 0 || roundTripTime < m.minRoundTripTime {
+		m.minRoundTripTime = roundTripTime
+	}
+	if roundTripTime > m.maxRoundTripTime {
+		m.maxRoundTripTime = roundTripTime
+	}
+	m.roundTripTimes++
+	m.roundTripTimeTotal += roundTripTime
+}
+
+// snapshot returns the metrics collected so far.
+func (m *sessionMetrics) snapshot() mgsContracts.SessionMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	metrics := m.metrics
+	if m.roundTripTimes > 0 {
+		metrics.MinRoundTripTimeMillis = milliseconds(m.minRoundTripTime)
+		metrics.MaxRoundTripTimeMillis = milliseconds(m.maxRoundTripTime)
+		metrics.AverageRoundTripTimeMillis = milliseconds(m.roundTripTimeTotal / time.Duration(m.roundTripTimes))
+	}
+	return metrics
+}
+
+// milliseconds returns d in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datachannel
+
+import (
+	"testing"
+	"time"
+
+	communicatorMocks "github.com/aws/amazon-ssm-agent/agent/session/communicator/mocks"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSessionMetrics(t *testing.T) {
+	var metrics sessionMetrics
+	assert.Equal(t, mgsContracts.SessionMetrics{}, metrics.snapshot())
+
+	metrics.messageSent(100)
+	metrics.messageSent(50)
+	metrics.messageResent()
+	metrics.messageReceived(10)
+	metrics.messageAcknowledged(30 * time.Millisecond)
+	metrics.messageAcknowledged(10 * time.Millisecond)
+	metrics.messageAcknowledged(20 * time.Millisecond)
+
+	assert.Equal(t, mgsContracts.SessionMetrics{
+		BytesIn:                    10,
+		BytesOut:                   150,
+		MessagesIn:                 1,
+		MessagesOut:                2,
+		Retransmissions:            1,
+		MinRoundTripTimeMillis:     10,
+		MaxRoundTripTimeMillis:     30,
+		AverageRoundTripTimeMillis: 20,
+	}, metrics.snapshot())
+}
+
+func TestGetMetrics(t *testing.T) {
+	dataChannel := getDataChannel()
+	mockChannel := &communicatorMocks.IWebSocketChannel{}
+	mockChannel.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	dataChannel.wsChannel = mockChannel
+
+	assert.Nil(t, dataChannel.SendStreamDataMessage(mockLog, mgsContracts.Output, payload))
+	// the client acknowledges the message and sends one
+	dataChannel.ProcessAcknowledgedMessage(mockLog, mgsContracts.AcknowledgeContent{SequenceNumber: 0})
+	agentMessage := getAgentMessage(0, mgsContracts.InputStreamDataMessage, uint32(mgsContracts.Output), []byte("ls"))
+	rawMessage, _ := agentMessage.Serialize(mockLog)
+	assert.Nil(t, dataChannel.DataChannelIncomingMessageHandler(mockLog, rawMessage))
+
+	metrics := dataChannel.GetMetrics()
+	assert.Equal(t, int64(len(payload)), metrics.BytesOut)
+	assert.Equal(t, int64(1), metrics.MessagesOut)
+	assert.Equal(t, int64(2), metrics.BytesIn)
+	assert.Equal(t, int64(1), metrics.MessagesIn)
+	assert.Equal(t, int64(0), metrics.Retransmissions)
+	assert.True(t, metrics.MaxRoundTripTimeMillis > 0)
+	assert.Equal(t, metrics.MinRoundTripTimeMillis, metrics.MaxRoundTripTimeMillis)
+}
@@ -66,6 +66,69 @@ func (_m *IDataChannel) DataChannelIncomingMessageHandler(_a0 log.T, rawMessage
 	return r0
 }
 
+// GetMetrics provides a mock function with given fields:
+func (_m *IDataChannel) GetMetrics() contracts.SessionMetrics {
+	ret := _m.Called()
+
+	var r0 contracts.SessionMetrics
+	if rf, ok := ret.Get(0).(func() contracts.SessionMetrics); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(contracts.SessionMetrics)
+	}
+
+	return r0
+}
+
+// IsPluginFeatureEnabled provides a mock function with given fields: feature
+func (_m *IDataChannel) IsPluginFeatureEnabled(feature string) bool {
+	ret := _m.Called(feature)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(feature)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// PerformHandshake provides a mock function with given fields: _a0, pluginFeatures, encryptionRequired
+func (_m *IDataChannel) PerformHandshake(_a0 log.T, pluginFeatures []string, encryptionRequired bool) (contracts.HandshakeCompletePayload, error) {
+	ret := _m.Called(_a0, pluginFeatures, encryptionRequired)
+
+	var r0 contracts.HandshakeCompletePayload
+	if rf, ok := ret.Get(0).(func(log.T, []string, bool) contracts.HandshakeCompletePayload); ok {
+		r0 = rf(_a0, pluginFeatures, encryptionRequired)
+	} else {
+		r0 = ret.Get(0).(contracts.HandshakeCompletePayload)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(log.T, []string, bool) error); ok {
+		r1 = rf(_a0, pluginFeatures, encryptionRequired)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnableEncryption provides a mock function with given fields: _a0, kmsKeyId
+func (_m *IDataChannel) EnableEncryption(_a0 log.T, kmsKeyId string) error {
+	ret := _m.Called(_a0, kmsKeyId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(log.T, string) error); ok {
+		r0 = rf(_a0, kmsKeyId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Initialize provides a mock function with given fields: _a0, mgsService, sessionId, clientId, instanceId, role, cancelFlag, inputStreamMessageHandler
 func (_m *IDataChannel) Initialize(_a0 context.T, mgsService service.Service, sessionId string, clientId string, instanceId string, role string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) {
 	_m.Called(_a0, mgsService, sessionId, clientId, instanceId, role, cancelFlag, inputStreamMessageHandler)
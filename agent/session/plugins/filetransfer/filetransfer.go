@@ -0,0 +1,135 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package filetransfer implements session file transfer plugin.
+// The client uploads and downloads files of a directory of the instance in chunks: each stream data
+// message carries a Message, chunks are checksummed, and interrupted transfers are resumed from
+// the last offset written (upload) or requested (download).
+package filetransfer
+
+import (
+	"os"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// FileTransferPlugin is the type for the file transfer plugin.
+type FileTransferPlugin struct {
+	dataChannel datachannel.IDataChannel
+	transfers   *transferManager
+	m           sync.RWMutex
+}
+
+// NewPlugin returns a new instance of the File Transfer Plugin
+func NewPlugin() (sessionplugin.ISessionPlugin, error) {
+	var plugin = FileTransferPlugin{}
+	return &plugin, nil
+}
+
+// name returns the name of File Transfer Plugin
+func (p *FileTransferPlugin) name() string {
+	return appconfig.PluginNameFileTransfer
+}
+
+// Execute serves the transfers requested by the client until the session is terminated.
+func (p *FileTransferPlugin) Execute(context context.T,
+	config agentContracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler,
+	dataChannel datachannel.IDataChannel) {
+
+	log := context.Log()
+	p.dataChannel = dataChannel
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Error occurred while executing plugin %s: \n%v", p.name(), err)
+			log.Flush()
+			os.Exit(1)
+		}
+	}()
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else {
+		p.execute(context, config, cancelFlag, output)
+	}
+}
+
+// execute serves the transfers requested by the client until the session is canceled.
+func (p *FileTransferPlugin) execute(context context.T,
+	config agentContracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler) {
+
+	log := context.Log()
+	root := config.FileTransferDirectory
+	if root == "" {
+		root = defaultTransferDirectory
+	}
+	transfers, err := newTransferManager(p.dataChannel, root)
+	if err != nil {
+		log.Error(err)
+		output.MarkAsFailed(err)
+		return
+	}
+	p.m.Lock()
+	p.transfers = transfers
+	p.m.Unlock()
+	log.Infof("Plugin %s started, serving files of %s", p.name(), transfers.root)
+
+	cancelState := cancelFlag.Wait()
+	log.Debugf("Cancel flag set to %v in session", cancelState)
+
+	transfers.close(log)
+	if err := p.dataChannel.SendAgentSessionStateMessage(log, mgsContracts.Terminating); err != nil {
+		log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Terminating, err)
+	}
+	output.SetExitCode(appconfig.SuccessExitCode)
+	output.SetStatus(agentContracts.ResultStatusSuccess)
+	log.Debug("File transfer session execution complete")
+}
+
+// InputStreamMessageHandler passes the messages received from the data channel to the transfer manager
+func (p *FileTransferPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	p.m.RLock()
+	transfers := p.transfers
+	p.m.RUnlock()
+	if transfers == nil {
+		// This is to handle scenario when the client starts sending requests before the plugin has started
+		// Since packets are rejected, the client will resend these packets until the plugin starts
+		log.Tracef("File transfer unavailable. Reject incoming message packet")
+		return nil
+	}
+
+	if mgsContracts.PayloadType(streamDataMessage.PayloadType) != mgsContracts.Output {
+		log.Tracef("Ignoring message of payload type %d", streamDataMessage.PayloadType)
+		return nil
+	}
+	message, err := DeserializeMessage(streamDataMessage.Payload)
+	if err != nil {
+		log.Errorf("Invalid file transfer message: %v", err)
+		return err
+	}
+	return transfers.handleMessage(log, message)
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filetransfer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type FileTransferTestSuite struct {
+	suite.Suite
+	mockContext     *context.Mock
+	mockCancelFlag  *task.MockCancelFlag
+	mockDataChannel *dataChannelMock.IDataChannel
+	mockIohandler   *iohandlermocks.MockIOHandler
+	plugin          *FileTransferPlugin
+}
+
+func (suite *FileTransferTestSuite) SetupTest() {
+	suite.mockContext = context.NewMockDefault()
+	suite.mockCancelFlag = &task.MockCancelFlag{}
+	suite.mockDataChannel = &dataChannelMock.IDataChannel{}
+	suite.mockIohandler = new(iohandlermocks.MockIOHandler)
+	suite.plugin = &FileTransferPlugin{}
+}
+
+// Testing Name
+func (suite *FileTransferTestSuite) TestName() {
+	assert.Equal(suite.T(), appconfig.PluginNameFileTransfer, suite.plugin.name())
+}
+
+// Testing Execute
+func (suite *FileTransferTestSuite) TestExecuteWhenCancelFlagIsShutDown() {
+	suite.mockCancelFlag.On("ShutDown").Return(true)
+	suite.mockIohandler.On("MarkAsShutdown").Return(nil)
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockCancelFlag.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *FileTransferTestSuite) TestExecuteWithMissingDirectory() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{FileTransferDirectory: "/missing/file/transfer/directory"},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *FileTransferTestSuite) TestExecuteUntilCanceled() {
+	root, _ := ioutil.TempDir("", "filetransfer")
+	defer os.RemoveAll(root)
+	canceled := make(chan bool)
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Canceled).Run(func(mock.Arguments) { <-canceled })
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.Output, mock.Anything).Return(nil)
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", mock.Anything, mgsContracts.Terminating).Return(nil)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+
+	done := make(chan bool)
+	go func() {
+		suite.plugin.Execute(suite.mockContext,
+			contracts.Configuration{FileTransferDirectory: root},
+			suite.mockCancelFlag,
+			suite.mockIohandler,
+			suite.mockDataChannel)
+		done <- true
+	}()
+
+	// requests are served once the plugin has started
+	message, _ := NewMessage(MessageStat, StatRequest{TransferID: "t1", Path: "missing"}, nil)
+	agentMessage := mgsContracts.AgentMessage{PayloadType: uint32(mgsContracts.Output), Payload: message.Serialize()}
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		suite.plugin.m.RLock()
+		started := suite.plugin.transfers != nil
+		suite.plugin.m.RUnlock()
+		if started {
+			break
+		}
+	}
+	assert.Nil(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockContext.Log(), agentMessage))
+	suite.mockDataChannel.AssertCalled(suite.T(), "SendStreamDataMessage", mock.Anything, mgsContracts.Output, mock.Anything)
+
+	close(canceled)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(suite.T(), "plugin did not stop")
+	}
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing InputStreamMessageHandler
+func (suite *FileTransferTestSuite) TestInputStreamMessageHandlerBeforeStart() {
+	agentMessage := mgsContracts.AgentMessage{PayloadType: uint32(mgsContracts.Output), Payload: []byte{1}}
+	assert.Nil(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockContext.Log(), agentMessage))
+}
+
+// Execute the test suite
+func TestFileTransferTestSuite(t *testing.T) {
+	suite.Run(t, new(FileTransferTestSuite))
+}
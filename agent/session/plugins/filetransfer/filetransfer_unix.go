@@ -0,0 +1,56 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//
+// +build darwin freebsd linux netbsd openbsd
+
+package filetransfer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// defaultTransferDirectory is the home directory of the default RunAs user.
+var defaultTransferDirectory = filepath.Join("/home", appconfig.DefaultRunAsUserName)
+
+// openNoFollow makes opening a file that is a symbolic link fail.
+const openNoFollow = syscall.O_NOFOLLOW
+
+// isPlainFile returns true for the regular files with a single link, a hard link planted by the RunAs user
+// to a file it can't write to has more than one.
+func isPlainFile(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || stat.Nlink <= 1
+}
+
+// setOwner gives the uploaded file to the owner of its directory, the agent runs as root.
+func setOwner(log log.T, path string, dir string) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		log.Warnf("Unable to set owner of %s: %v", path, err)
+		return
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err = os.Lchown(path, int(stat.Uid), int(stat.Gid)); err != nil {
+			log.Warnf("Unable to set owner of %s: %v", path, err)
+		}
+	}
+}
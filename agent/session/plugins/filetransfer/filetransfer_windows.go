@@ -0,0 +1,39 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//
+// +build windows
+
+package filetransfer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// defaultTransferDirectory is the profile directory of the default RunAs user.
+var defaultTransferDirectory = filepath.Join(os.Getenv("SystemDrive")+`\`, "Users", appconfig.DefaultRunAsUserName)
+
+// openNoFollow is not needed on Windows, creating symbolic links requires to be an administrator.
+const openNoFollow = 0
+
+// isPlainFile returns true for the regular files.
+func isPlainFile(info os.FileInfo) bool {
+	return info.Mode().IsRegular()
+}
+
+// setOwner does nothing on Windows, uploaded files inherit the permissions of their directory.
+func setOwner(log log.T, path string, dir string) {}
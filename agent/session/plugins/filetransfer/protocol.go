@@ -0,0 +1,165 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filetransfer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageType is the type of a file transfer message.
+type MessageType uint8
+
+const (
+	// MessageStat requests the size and checksum of a file, answered by MessageStatResult.
+	MessageStat MessageType = 1
+	// MessageUploadStart starts or resumes an upload, answered by MessageUploadStatus with the offset to upload from.
+	MessageUploadStart MessageType = 2
+	// MessageUploadChunk carries the data of an upload at the given offset, answered by MessageUploadStatus.
+	MessageUploadChunk MessageType = 3
+	// MessageUploadComplete verifies the checksum of an upload and moves the file in place, answered by MessageUploadStatus.
+	MessageUploadComplete MessageType = 4
+	// MessageDownloadChunk requests the data of a file at the given offset, answered by MessageChunk.
+	MessageDownloadChunk MessageType = 5
+
+	// MessageStatResult describes a file.
+	MessageStatResult MessageType = 101
+	// MessageUploadStatus reports the offset up to which an upload has been written.
+	MessageUploadStatus MessageType = 102
+	// MessageChunk carries the data of a download.
+	MessageChunk MessageType = 103
+	// MessageError reports a request that failed, the transfer cannot continue.
+	MessageError MessageType = 200
+)
+
+// messageHeaderOffset is the length of the message type and of the header length preceding the header.
+const messageHeaderOffset = 5
+
+// Message is a file transfer message, carried in the payload of the stream data messages.
+// Header holds one of the request or response structures below, encoded in JSON, and Data the bytes of a chunk.
+type Message struct {
+	Type   MessageType
+	Header []byte
+	Data   []byte
+}
+
+// StatRequest is the header of MessageStat.
+type StatRequest struct {
+	TransferID string `json:"transferId"`
+	Path       string `json:"path"`
+}
+
+// StatResult is the header of MessageStatResult.
+type StatResult struct {
+	TransferID string `json:"transferId"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum"`
+	Mode       uint32 `json:"mode"`
+}
+
+// UploadStartRequest is the header of MessageUploadStart.
+type UploadStartRequest struct {
+	TransferID string `json:"transferId"`
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	// Checksum is the hex encoded sha256 of the whole file.
+	Checksum string `json:"checksum"`
+}
+
+// ChunkHeader is the header of MessageUploadChunk and MessageChunk.
+type ChunkHeader struct {
+	TransferID string `json:"transferId"`
+	Offset     int64  `json:"offset"`
+	// Checksum is the hex encoded sha256 of the chunk data.
+	Checksum string `json:"checksum"`
+	// EOF is set on the last chunk of a download.
+	EOF bool `json:"eof,omitempty"`
+}
+
+// UploadCompleteRequest is the header of MessageUploadComplete.
+type UploadCompleteRequest struct {
+	TransferID string `json:"transferId"`
+}
+
+// UploadStatus is the header of MessageUploadStatus.
+type UploadStatus struct {
+	TransferID string `json:"transferId"`
+	Offset     int64  `json:"offset"`
+	Complete   bool   `json:"complete,omitempty"`
+}
+
+// DownloadChunkRequest is the header of MessageDownloadChunk.
+type DownloadChunkRequest struct {
+	TransferID string `json:"transferId"`
+	Path       string `json:"path"`
+	Offset     int64  `json:"offset"`
+	Length     int    `json:"length"`
+}
+
+// ErrorResult is the header of MessageError.
+type ErrorResult struct {
+	TransferID string `json:"transferId"`
+	Message    string `json:"message"`
+}
+
+// NewMessage returns a message with the given header encoded in JSON.
+func NewMessage(messageType MessageType, header interface{}, data []byte) (Message, error) {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Type: messageType, Header: headerBytes, Data: data}, nil
+}
+
+// Serialize returns the bytes of the message: the type, the header length (big endian uint32), the header and the data.
+func (m Message) Serialize() []byte {
+	result := make([]byte, messageHeaderOffset+len(m.Header)+len(m.Data))
+	result[0] = byte(m.Type)
+	binary.BigEndian.PutUint32(result[1:], uint32(len(m.Header)))
+	copy(result[messageHeaderOffset:], m.Header)
+	copy(result[messageHeaderOffset+len(m.Header):], m.Data)
+	return result
+}
+
+// DeserializeMessage parses the bytes of a message.
+func DeserializeMessage(input []byte) (message Message, err error) {
+	if len(input) < messageHeaderOffset {
+		return message, fmt.Errorf("message of %d bytes is shorter than its header", len(input))
+	}
+	headerLength := binary.BigEndian.Uint32(input[1:])
+	if uint64(headerLength) > uint64(len(input)-messageHeaderOffset) {
+		return message, fmt.Errorf("message header of %d bytes exceeds the message length", headerLength)
+	}
+	message.Type = MessageType(input[0])
+	message.Header = input[messageHeaderOffset : messageHeaderOffset+headerLength]
+	message.Data = input[messageHeaderOffset+headerLength:]
+	return
+}
+
+// DecodeHeader decodes the JSON header of the message into header.
+func (m Message) DecodeHeader(header interface{}) error {
+	if err := json.Unmarshal(m.Header, header); err != nil {
+		return fmt.Errorf("invalid header for message type %d: %v", m.Type, err)
+	}
+	return nil
+}
+
+// Checksum returns the hex encoded sha256 of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
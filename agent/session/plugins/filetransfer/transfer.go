@@ -0,0 +1,413 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filetransfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+)
+
+// partFileSuffix is appended to the name of a file being uploaded, the partial file is kept to resume the upload.
+const partFileSuffix = ".ssmpart"
+
+// upload is an upload in progress.
+type upload struct {
+	path     string
+	partPath string
+	size     int64
+	checksum string
+	file     *os.File
+	offset   int64
+}
+
+// transferManager serves the file transfer requests of a session, within a root directory.
+type transferManager struct {
+	dataChannel datachannel.IDataChannel
+	root        string
+	uploads     map[string]*upload
+	closed      bool
+	m           sync.Mutex
+	// sendLock serializes the stream data messages sent over the data channel
+	sendLock sync.Mutex
+}
+
+// newTransferManager creates a transfer manager serving files below root.
+func newTransferManager(dataChannel datachannel.IDataChannel, root string) (*transferManager, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file transfer directory %s: %v", root, err)
+	}
+	if info, err := os.Stat(realRoot); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("file transfer directory %s is not a directory", root)
+	}
+	return &transferManager{
+		dataChannel: dataChannel,
+		root:        realRoot,
+		uploads:     make(map[string]*upload),
+	}, nil
+}
+
+// handleMessage processes a message received from the data channel.
+// Failed requests are reported to the client, only failures to answer are returned.
+func (t *transferManager) handleMessage(log log.T, message Message) error {
+	var transferID string
+	var response Message
+	var err error
+	switch message.Type {
+	case MessageStat:
+		var request StatRequest
+		if err = message.DecodeHeader(&request); err == nil {
+			transferID = request.TransferID
+			response, err = t.stat(request)
+		}
+	case MessageUploadStart:
+		var request UploadStartRequest
+		if err = message.DecodeHeader(&request); err == nil {
+			transferID = request.TransferID
+			response, err = t.startUpload(log, request)
+		}
+	case MessageUploadChunk:
+		var header ChunkHeader
+		if err = message.DecodeHeader(&header); err == nil {
+			transferID = header.TransferID
+			response, err = t.writeChunk(log, header, message.Data)
+		}
+	case MessageUploadComplete:
+		var request UploadCompleteRequest
+		if err = message.DecodeHeader(&request); err == nil {
+			transferID = request.TransferID
+			response, err = t.completeUpload(log, request)
+		}
+	case MessageDownloadChunk:
+		var request DownloadChunkRequest
+		if err = message.DecodeHeader(&request); err == nil {
+			transferID = request.TransferID
+			response, err = t.readChunk(request)
+		}
+	default:
+		err = fmt.Errorf("unknown message type %d", message.Type)
+	}
+
+	if err != nil {
+		log.Warnf("File transfer %s failed: %v", transferID, err)
+		if response, err = NewMessage(MessageError, ErrorResult{TransferID: transferID, Message: err.Error()}, nil); err != nil {
+			return err
+		}
+	}
+	return t.send(log, response)
+}
+
+// send sends a message over the data channel.
+func (t *transferManager) send(log log.T, message Message) error {
+	t.sendLock.Lock()
+	defer t.sendLock.Unlock()
+	return t.dataChannel.SendStreamDataMessage(log, mgsContracts.Output, message.Serialize())
+}
+
+// stat describes a file.
+func (t *transferManager) stat(request StatRequest) (Message, error) {
+	path, err := t.resolvePath(request.Path)
+	if err != nil {
+		return Message{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Message{}, err
+	}
+	if info.IsDir() {
+		return Message{}, fmt.Errorf("%s is a directory", request.Path)
+	}
+	checksum, err := fileChecksum(path, info.Size())
+	if err != nil {
+		return Message{}, err
+	}
+	return NewMessage(MessageStatResult, StatResult{
+		TransferID: request.TransferID,
+		Size:       info.Size(),
+		Checksum:   checksum,
+		Mode:       uint32(info.Mode().Perm()),
+	}, nil)
+}
+
+// startUpload opens the partial file of an upload, resuming where a previous upload of the same file stopped.
+func (t *transferManager) startUpload(log log.T, request UploadStartRequest) (Message, error) {
+	if request.TransferID == "" {
+		return Message{}, errors.New("missing transfer id")
+	}
+	if request.Size < 0 {
+		return Message{}, fmt.Errorf("invalid size %d", request.Size)
+	}
+	path, err := t.resolvePath(request.Path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.closed {
+		return Message{}, errors.New("session is closing")
+	}
+	if _, found := t.uploads[request.TransferID]; found {
+		return Message{}, fmt.Errorf("transfer %s already started", request.TransferID)
+	}
+
+	partPath := path + partFileSuffix
+	file, info, err := openPartFile(partPath)
+	if err != nil {
+		return Message{}, err
+	}
+
+	// Resume from the end of the partial file, unless it cannot belong to this upload
+	offset := info.Size()
+	if offset > request.Size {
+		if err = file.Truncate(0); err != nil {
+			file.Close()
+			return Message{}, err
+		}
+		offset = 0
+	}
+	if offset > 0 {
+		log.Infof("Resuming upload of %s at offset %d", path, offset)
+	}
+
+	t.uploads[request.TransferID] = &upload{
+		path:     path,
+		partPath: partPath,
+		size:     request.Size,
+		checksum: strings.ToLower(request.Checksum),
+		file:     file,
+		offset:   offset,
+	}
+	return NewMessage(MessageUploadStatus, UploadStatus{TransferID: request.TransferID, Offset: offset}, nil)
+}
+
+// writeChunk writes a chunk of an upload. Chunks that are out of order or corrupted are not written,
+// the returned offset tells the client where to resume from.
+func (t *transferManager) writeChunk(log log.T, header ChunkHeader, data []byte) (Message, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	upload, found := t.uploads[header.TransferID]
+	if !found {
+		return Message{}, fmt.Errorf("unknown transfer %s", header.TransferID)
+	}
+
+	switch {
+	case header.Offset != upload.offset:
+		log.Debugf("Ignoring chunk at offset %d of transfer %s, expecting offset %d", header.Offset, header.TransferID, upload.offset)
+	case Checksum(data) != strings.ToLower(header.Checksum):
+		log.Warnf("Ignoring corrupted chunk at offset %d of transfer %s", header.Offset, header.TransferID)
+	case upload.offset+int64(len(data)) > upload.size:
+		return Message{}, fmt.Errorf("chunk at offset %d exceeds the file size %d", header.Offset, upload.size)
+	default:
+		if _, err := upload.file.WriteAt(data, upload.offset); err != nil {
+			return Message{}, err
+		}
+		upload.offset += int64(len(data))
+	}
+	return NewMessage(MessageUploadStatus, UploadStatus{TransferID: header.TransferID, Offset: upload.offset}, nil)
+}
+
+// completeUpload verifies the uploaded file and moves it in place.
+func (t *transferManager) completeUpload(log log.T, request UploadCompleteRequest) (Message, error) {
+	t.m.Lock()
+	upload, found := t.uploads[request.TransferID]
+	delete(t.uploads, request.TransferID)
+	t.m.Unlock()
+	if !found {
+		return Message{}, fmt.Errorf("unknown transfer %s", request.TransferID)
+	}
+
+	var err error
+	if upload.offset != upload.size {
+		err = fmt.Errorf("upload incomplete, received %d of %d bytes", upload.offset, upload.size)
+	}
+	if err == nil {
+		// the checksum is computed on the opened file, the partial file may have been replaced since
+		var checksum string
+		if checksum, err = readerChecksum(upload.file, upload.size); err == nil && checksum != upload.checksum {
+			err = fmt.Errorf("checksum mismatch, expected %s, got %s", upload.checksum, checksum)
+		}
+	}
+	if err == nil {
+		err = checkSameFile(upload.file, upload.partPath)
+	}
+	if closeErr := upload.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if err = os.Rename(upload.partPath, upload.path); err == nil {
+			setOwner(log, upload.path, filepath.Dir(upload.path))
+		}
+	}
+	if err != nil {
+		// the partial file cannot be resumed
+		os.Remove(upload.partPath)
+		return Message{}, err
+	}
+
+	log.Infof("Uploaded %s, %d bytes", upload.path, upload.size)
+	return NewMessage(MessageUploadStatus, UploadStatus{TransferID: request.TransferID, Offset: upload.size, Complete: true}, nil)
+}
+
+// readChunk reads a chunk of a file to download.
+func (t *transferManager) readChunk(request DownloadChunkRequest) (Message, error) {
+	if request.Offset < 0 || request.Length <= 0 {
+		return Message{}, fmt.Errorf("invalid chunk at offset %d of %d bytes", request.Offset, request.Length)
+	}
+	if request.Length > mgsConfig.FileTransferMaxChunkSize {
+		request.Length = mgsConfig.FileTransferMaxChunkSize
+	}
+	path, err := t.resolvePath(request.Path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Message{}, err
+	}
+	defer file.Close()
+
+	data := make([]byte, request.Length)
+	read, err := file.ReadAt(data, request.Offset)
+	if err != nil && err != io.EOF {
+		return Message{}, err
+	}
+	data = data[:read]
+	return NewMessage(MessageChunk, ChunkHeader{
+		TransferID: request.TransferID,
+		Offset:     request.Offset,
+		Checksum:   Checksum(data),
+		EOF:        err == io.EOF || read < request.Length,
+	}, data)
+}
+
+// close closes the uploads in progress, their partial files are kept so they can be resumed by another session.
+func (t *transferManager) close(log log.T) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.closed = true
+	for transferID, upload := range t.uploads {
+		log.Debugf("Interrupting upload %s of %s at offset %d", transferID, upload.path, upload.offset)
+		upload.file.Close()
+	}
+	t.uploads = make(map[string]*upload)
+}
+
+// resolvePath returns the path of a file below the root directory.
+// Paths use forward slashes and are relative to the root, symbolic links must not lead out of the root.
+func (t *transferManager) resolvePath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("missing path")
+	}
+	// cleaning the path as an absolute one drops the leading .. elements
+	cleaned := filepath.Clean(string(filepath.Separator) + filepath.FromSlash(path))
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid path %s", path)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join(t.root, filepath.Dir(cleaned)))
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %v", path, err)
+	}
+	resolved := filepath.Join(dir, filepath.Base(cleaned))
+	if info, err := os.Lstat(resolved); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if resolved, err = filepath.EvalSymlinks(resolved); err != nil {
+			return "", fmt.Errorf("invalid path %s: %v", path, err)
+		}
+	}
+
+	if !isWithin(t.root, resolved) {
+		return "", fmt.Errorf("path %s is outside of the file transfer directory", path)
+	}
+	return resolved, nil
+}
+
+// isWithin returns true when path is below root.
+func isWithin(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileChecksum returns the hex encoded sha256 of the first size bytes of the file.
+func fileChecksum(path string, size int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readerChecksum(file, size)
+}
+
+// readerChecksum returns the hex encoded sha256 of the first size bytes read from reader.
+func readerChecksum(reader io.ReaderAt, size int64) (string, error) {
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, io.NewSectionReader(reader, 0, size), size); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// openPartFile opens or creates the partial file of an upload. The agent writes as root in a directory the RunAs
+// user can write to, so a partial file that is a link to another file planted by the user is rejected.
+func openPartFile(partPath string) (*os.File, os.FileInfo, error) {
+	if info, err := os.Lstat(partPath); err == nil && !isPlainFile(info) {
+		return nil, nil, fmt.Errorf("partial file %s is not a regular file", partPath)
+	}
+	// the link may be planted after the check, the partial file is opened without following it
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE|openNoFollow, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && !isPlainFile(info) {
+		err = fmt.Errorf("partial file %s is not a regular file", partPath)
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// checkSameFile checks that path is still the opened file, before the file at path is moved in place.
+func checkSameFile(file *os.File, path string) error {
+	opened, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(opened, current) {
+		return fmt.Errorf("partial file %s was replaced during the upload", path)
+	}
+	return nil
+}
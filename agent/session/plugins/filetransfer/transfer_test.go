@@ -0,0 +1,243 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filetransfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockLog = log.NewMockLog()
+
+// messageRecorder is a data channel recording the messages sent by a transfer manager.
+type messageRecorder struct {
+	dataChannelMock.IDataChannel
+	messages []Message
+}
+
+// SendStreamDataMessage records the message.
+func (r *messageRecorder) SendStreamDataMessage(log log.T, dataType mgsContracts.PayloadType, inputData []byte) error {
+	message, err := DeserializeMessage(append([]byte(nil), inputData...))
+	r.messages = append(r.messages, message)
+	return err
+}
+
+// newTestTransferManager returns a transfer manager serving a temporary directory.
+func newTestTransferManager(t *testing.T) (*transferManager, *messageRecorder, string) {
+	root, _ := ioutil.TempDir("", "filetransfer")
+	recorder := &messageRecorder{}
+	transfers, err := newTransferManager(recorder, root)
+	assert.Nil(t, err)
+	return transfers, recorder, root
+}
+
+// request sends a request to the transfer manager and decodes the header of the response into response.
+func request(t *testing.T, transfers *transferManager, recorder *messageRecorder, messageType MessageType, header interface{}, data []byte, response interface{}) Message {
+	message, _ := NewMessage(messageType, header, data)
+	assert.Nil(t, transfers.handleMessage(mockLog, message))
+	result := recorder.messages[len(recorder.messages)-1]
+	assert.Nil(t, result.DecodeHeader(response))
+	return result
+}
+
+func TestMessageSerialization(t *testing.T) {
+	message := Message{Type: MessageChunk, Header: []byte(`{}`), Data: []byte("data")}
+	serialized := message.Serialize()
+	assert.Equal(t, []byte{103, 0, 0, 0, 2, '{', '}', 'd', 'a', 't', 'a'}, serialized)
+
+	deserialized, err := DeserializeMessage(serialized)
+	assert.Nil(t, err)
+	assert.Equal(t, message, deserialized)
+
+	_, err = DeserializeMessage([]byte{1, 0, 0})
+	assert.NotNil(t, err)
+	_, err = DeserializeMessage([]byte{1, 0, 0, 0, 9, '{', '}'})
+	assert.NotNil(t, err)
+}
+
+func TestUpload(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	content := []byte("hello file transfer")
+
+	var status UploadStatus
+	request(t, transfers, recorder, MessageUploadStart,
+		UploadStartRequest{TransferID: "t1", Path: "dir/../hello.txt", Size: int64(len(content)), Checksum: Checksum(content)}, nil, &status)
+	assert.Equal(t, UploadStatus{TransferID: "t1", Offset: 0}, status)
+
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 0, Checksum: Checksum(content[:5])}, content[:5], &status)
+	assert.Equal(t, int64(5), status.Offset)
+
+	// chunks out of order or corrupted are not written
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 10, Checksum: Checksum(content[10:])}, content[10:], &status)
+	assert.Equal(t, int64(5), status.Offset)
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 5, Checksum: Checksum(content[5:])}, []byte("corrupted data!"), &status)
+	assert.Equal(t, int64(5), status.Offset)
+
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 5, Checksum: Checksum(content[5:])}, content[5:], &status)
+	assert.Equal(t, int64(len(content)), status.Offset)
+
+	request(t, transfers, recorder, MessageUploadComplete, UploadCompleteRequest{TransferID: "t1"}, nil, &status)
+	assert.True(t, status.Complete)
+
+	uploaded, err := ioutil.ReadFile(filepath.Join(root, "hello.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, uploaded)
+	_, err = os.Stat(filepath.Join(root, "hello.txt"+partFileSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadResumesAfterInterruption(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	content := []byte("resumable upload content")
+	start := UploadStartRequest{TransferID: "t1", Path: "resume.txt", Size: int64(len(content)), Checksum: Checksum(content)}
+
+	var status UploadStatus
+	request(t, transfers, recorder, MessageUploadStart, start, nil, &status)
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 0, Checksum: Checksum(content[:8])}, content[:8], &status)
+	transfers.close(mockLog)
+
+	// a new session resumes the upload where the previous one stopped
+	transfers, err := newTransferManager(recorder, root)
+	assert.Nil(t, err)
+	start.TransferID = "t2"
+	request(t, transfers, recorder, MessageUploadStart, start, nil, &status)
+	assert.Equal(t, int64(8), status.Offset)
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t2", Offset: 8, Checksum: Checksum(content[8:])}, content[8:], &status)
+	request(t, transfers, recorder, MessageUploadComplete, UploadCompleteRequest{TransferID: "t2"}, nil, &status)
+	assert.True(t, status.Complete)
+
+	uploaded, _ := ioutil.ReadFile(filepath.Join(root, "resume.txt"))
+	assert.Equal(t, content, uploaded)
+}
+
+func TestUploadChecksumMismatch(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	content := []byte("content")
+
+	var status UploadStatus
+	request(t, transfers, recorder, MessageUploadStart,
+		UploadStartRequest{TransferID: "t1", Path: "bad.txt", Size: int64(len(content)), Checksum: Checksum([]byte("other"))}, nil, &status)
+	request(t, transfers, recorder, MessageUploadChunk,
+		ChunkHeader{TransferID: "t1", Offset: 0, Checksum: Checksum(content)}, content, &status)
+
+	var errorResult ErrorResult
+	response := request(t, transfers, recorder, MessageUploadComplete, UploadCompleteRequest{TransferID: "t1"}, nil, &errorResult)
+	assert.Equal(t, MessageError, response.Type)
+	assert.Equal(t, "t1", errorResult.TransferID)
+	assert.Contains(t, errorResult.Message, "checksum mismatch")
+
+	_, err := os.Stat(filepath.Join(root, "bad.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(root, "bad.txt"+partFileSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownload(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	content := []byte(strings.Repeat("0123456789", mgsConfig.FileTransferMaxChunkSize/5))
+	ioutil.WriteFile(filepath.Join(root, "download.txt"), content, 0600)
+
+	var stat StatResult
+	request(t, transfers, recorder, MessageStat, StatRequest{TransferID: "t1", Path: "/download.txt"}, nil, &stat)
+	assert.Equal(t, StatResult{TransferID: "t1", Size: int64(len(content)), Checksum: Checksum(content), Mode: 0600}, stat)
+
+	var downloaded []byte
+	for {
+		var chunk ChunkHeader
+		response := request(t, transfers, recorder, MessageDownloadChunk,
+			DownloadChunkRequest{TransferID: "t1", Path: "download.txt", Offset: int64(len(downloaded)), Length: 1 << 20}, nil, &chunk)
+		assert.Equal(t, MessageChunk, response.Type)
+		assert.Equal(t, int64(len(downloaded)), chunk.Offset)
+		assert.Equal(t, Checksum(response.Data), chunk.Checksum)
+		assert.True(t, len(response.Data) <= mgsConfig.FileTransferMaxChunkSize)
+		downloaded = append(downloaded, response.Data...)
+		if chunk.EOF {
+			break
+		}
+	}
+	assert.Equal(t, content, downloaded)
+}
+
+func TestPathsOutsideOfTheRootAreRejected(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	outside, _ := ioutil.TempDir("", "outside")
+	defer os.RemoveAll(outside)
+	ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0600)
+	os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "link"))
+	os.Symlink(outside, filepath.Join(root, "dirlink"))
+
+	for _, path := range []string{"", "/", "link", "dirlink/secret", "missing/file"} {
+		var errorResult ErrorResult
+		response := request(t, transfers, recorder, MessageDownloadChunk,
+			DownloadChunkRequest{TransferID: "t1", Path: path, Length: 10}, nil, &errorResult)
+		assert.Equal(t, MessageError, response.Type, path)
+	}
+
+	// parent elements cannot climb out of the root
+	resolved, err := transfers.resolvePath("../../secret")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(transfers.root, "secret"), resolved)
+}
+
+func TestUploadRejectsPlantedPartialFile(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+	outside, _ := ioutil.TempDir("", "outside")
+	defer os.RemoveAll(outside)
+	target := filepath.Join(outside, "shadow")
+	ioutil.WriteFile(target, []byte("secret"), 0600)
+	content := []byte("content")
+
+	// the RunAs user can't make the agent write to another file through the partial file of an upload
+	assert.Nil(t, os.Symlink(target, filepath.Join(root, "foo"+partFileSuffix)))
+	var errorResult ErrorResult
+	response := request(t, transfers, recorder, MessageUploadStart,
+		UploadStartRequest{TransferID: "t1", Path: "foo", Size: int64(len(content)), Checksum: Checksum(content)}, nil, &errorResult)
+	assert.Equal(t, MessageError, response.Type)
+	assert.Contains(t, errorResult.Message, "not a regular file")
+
+	written, _ := ioutil.ReadFile(target)
+	assert.Equal(t, "secret", string(written))
+	_, err := os.Stat(filepath.Join(root, "foo"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnknownMessageType(t *testing.T) {
+	transfers, recorder, root := newTestTransferManager(t)
+	defer os.RemoveAll(root)
+
+	var errorResult ErrorResult
+	response := request(t, transfers, recorder, MessageType(42), struct{}{}, nil, &errorResult)
+	assert.Equal(t, MessageError, response.Type)
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package interactivecommands
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// commandFilter accepts the commands matching an allow list and none of the patterns of a deny list.
+// Patterns are regular expressions matching the whole command.
+type commandFilter struct {
+	allowList []*regexp.Regexp
+	denyList  []*regexp.Regexp
+}
+
+// newCommandFilter compiles the allow and deny lists of the session document.
+// An empty allow list accepts any command not denied.
+func newCommandFilter(allowList []string, denyList []string) (filter commandFilter, err error) {
+	if filter.allowList, err = compilePatterns(allowList); err != nil {
+		return
+	}
+	filter.denyList, err = compilePatterns(denyList)
+	return
+}
+
+// compilePatterns compiles patterns anchored at the start and the end of the command,
+// so that "ls .*" does not accept "ls; rm -rf /" once a command is chained.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid command filter pattern %q: %v", pattern, err)
+		}
+		result = append(result, compiled)
+	}
+	return result, nil
+}
+
+// check returns an error when the command is not allowed to run.
+// Deny patterns are evaluated first and win over the allow list.
+func (f commandFilter) check(command string) error {
+	for _, pattern := range f.denyList {
+		if pattern.MatchString(command) {
+			return fmt.Errorf("command is denied by pattern %q", trimAnchors(pattern))
+		}
+	}
+	if len(f.allowList) == 0 {
+		return nil
+	}
+	for _, pattern := range f.allowList {
+		if pattern.MatchString(command) {
+			return nil
+		}
+	}
+	return fmt.Errorf("command does not match any allowed pattern")
+}
+
+// trimAnchors returns the pattern as written in the session document.
+func trimAnchors(pattern *regexp.Regexp) string {
+	expr := pattern.String()
+	return expr[len("^(?:") : len(expr)-len(")$")]
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package interactivecommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandFilter(t *testing.T) {
+	filter, err := newCommandFilter([]string{`top`, `tail -f /var/log/[a-z]+\.log`, `psql .*`}, []string{`.*--password.*`})
+	assert.Nil(t, err)
+
+	for _, command := range []string{"top", "tail -f /var/log/syslog.log", "psql -h db orders"} {
+		assert.Nil(t, filter.check(command), command)
+	}
+	for _, command := range []string{
+		"htop",
+		"top; rm -rf /",
+		"tail -f /var/log/../../etc/shadow",
+		"tail -f /var/log/syslog.log\nrm -rf /",
+		"psql -h db --password orders",
+	} {
+		assert.NotNil(t, filter.check(command), command)
+	}
+}
+
+func TestCommandFilterDenyListOnly(t *testing.T) {
+	filter, err := newCommandFilter(nil, []string{`rm .*`, `shutdown.*`})
+	assert.Nil(t, err)
+
+	assert.Nil(t, filter.check("ls -la"))
+	assert.Nil(t, filter.check("echo rm -rf"))
+	err = filter.check("rm -rf /tmp")
+	assert.EqualError(t, err, `command is denied by pattern "rm .*"`)
+}
+
+func TestCommandFilterInvalidPattern(t *testing.T) {
+	_, err := newCommandFilter([]string{`top(`}, nil)
+	assert.NotNil(t, err)
+	_, err = newCommandFilter(nil, []string{`[a-`})
+	assert.NotNil(t, err)
+}
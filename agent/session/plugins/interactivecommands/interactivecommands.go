@@ -0,0 +1,196 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package interactivecommands implements session interactive commands plugin.
+// The session runs the commands of the session document in a pseudo terminal instead of an interactive shell.
+// The commands are checked against the allow and deny lists of the session document before they run.
+// Non-interactive commands sessions run the commands without a pseudo terminal and report their exit code.
+package interactivecommands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/shell"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// InteractiveCommandsProperties are the properties of the session document.
+type InteractiveCommandsProperties struct {
+	Commands string `json:"commands"`
+}
+
+// InteractiveCommandsPlugin is the type for the interactive commands plugin.
+type InteractiveCommandsPlugin struct {
+	auditLogger    audit.Logger
+	nonInteractive bool
+	shell       sessionplugin.ISessionPlugin
+	m           sync.RWMutex
+}
+
+// NewPlugin returns a new instance of the Interactive Commands Plugin
+func NewPlugin() (sessionplugin.ISessionPlugin, error) {
+	var plugin = InteractiveCommandsPlugin{
+		auditLogger: audit.Default(),
+	}
+	return &plugin, nil
+}
+
+// NewNonInteractivePlugin returns a new instance of the Non-Interactive Commands Plugin
+func NewNonInteractivePlugin() (sessionplugin.ISessionPlugin, error) {
+	var plugin = InteractiveCommandsPlugin{
+		auditLogger:    audit.Default(),
+		nonInteractive: true,
+	}
+	return &plugin, nil
+}
+
+// PluginFeatures returns the optional features of the shell running the commands.
+func (p *InteractiveCommandsPlugin) PluginFeatures() []string {
+	if p.nonInteractive {
+		return []string{shell.FeatureStderr}
+	}
+	return []string{shell.FeatureTerminalType}
+}
+
+// name returns the name of Interactive Commands Plugin
+func (p *InteractiveCommandsPlugin) name() string {
+	if p.nonInteractive {
+		return appconfig.PluginNameNonInteractiveCommands
+	}
+	return appconfig.PluginNameInteractiveCommands
+}
+
+var newShellPlugin = func(pluginName string, commands string, nonInteractive bool) sessionplugin.ISessionPlugin {
+	if nonInteractive {
+		return shell.NewNonInteractiveCommandsPlugin(pluginName, commands)
+	}
+	return shell.NewCommandsPlugin(pluginName, commands)
+}
+
+// Execute checks the commands of the session document and runs them, in a pseudo terminal unless the session is non-interactive.
+func (p *InteractiveCommandsPlugin) Execute(context context.T,
+	config agentContracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler,
+	dataChannel datachannel.IDataChannel) {
+
+	log := context.Log()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Error occurred while executing plugin %s: \n%v", p.name(), err)
+			log.Flush()
+			os.Exit(1)
+		}
+	}()
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	commands, err := getCommands(p.name(), config)
+	if err == nil {
+		err = p.checkCommands(log, config, commands)
+	}
+	if err != nil {
+		log.Error(err)
+		p.reject(log, dataChannel, err)
+		output.MarkAsFailed(err)
+		return
+	}
+
+	shellPlugin := newShellPlugin(p.name(), commands, p.nonInteractive)
+	p.m.Lock()
+	p.shell = shellPlugin
+	p.m.Unlock()
+	shellPlugin.Execute(context, config, cancelFlag, output, dataChannel)
+}
+
+// getCommands returns the commands of the session document properties.
+func getCommands(pluginName string, config agentContracts.Configuration) (string, error) {
+	var properties InteractiveCommandsProperties
+	if err := jsonutil.Remarshal(config.Properties, &properties); err != nil {
+		return "", fmt.Errorf("invalid %s session properties: %v", pluginName, err)
+	}
+	commands := strings.TrimSpace(properties.Commands)
+	if commands == "" {
+		return "", errors.New("no commands specified in the session document")
+	}
+	return commands, nil
+}
+
+// checkCommands checks the commands against the command filters of the session document
+// and records rejected commands in the audit log.
+func (p *InteractiveCommandsPlugin) checkCommands(log log.T, config agentContracts.Configuration, commands string) error {
+	filter, err := newCommandFilter(config.CommandAllowList, config.CommandDenyList)
+	if err != nil {
+		return err
+	}
+	if err = filter.check(commands); err == nil {
+		return nil
+	}
+
+	entry := audit.Entry{
+		Event:        audit.SessionCommandRejected,
+		DocumentType: string(agentContracts.StartSession),
+		SessionID:    config.SessionId,
+		Requester:    config.ClientId,
+		Command:      commands,
+		Status:       err.Error(),
+	}
+	if auditErr := p.auditLogger.Log(entry); auditErr != nil {
+		log.Warnf("failed to write audit log entry %v: %v", entry.Event, auditErr)
+	}
+	return fmt.Errorf("Command rejected: %v", err)
+}
+
+// reject tells the client why the session does not run and terminates it.
+func (p *InteractiveCommandsPlugin) reject(log log.T, dataChannel datachannel.IDataChannel, err error) {
+	if sendErr := dataChannel.SendStreamDataMessage(log, mgsContracts.Output, []byte(err.Error()+"\r\n")); sendErr != nil {
+		log.Errorf("Unable to send message to the client: %v", sendErr)
+	}
+	if sendErr := dataChannel.SendAgentSessionStateMessage(log, mgsContracts.Terminating); sendErr != nil {
+		log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Terminating, sendErr)
+	}
+}
+
+// InputStreamMessageHandler passes the messages received from the data channel to the commands
+func (p *InteractiveCommandsPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	p.m.RLock()
+	shellPlugin := p.shell
+	p.m.RUnlock()
+	if shellPlugin == nil {
+		// This is to handle scenario when the client starts sending input before the commands have started
+		// Since packets are rejected, the client will resend these packets until the commands start
+		log.Tracef("Commands not started. Reject incoming message packet")
+		return nil
+	}
+	return shellPlugin.InputStreamMessageHandler(log, streamDataMessage)
+}
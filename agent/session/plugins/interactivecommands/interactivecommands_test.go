@@ -0,0 +1,175 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package interactivecommands
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
+	sessionPluginMock "github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/shell"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type InteractiveCommandsTestSuite struct {
+	suite.Suite
+	mockContext     *context.Mock
+	mockCancelFlag  *task.MockCancelFlag
+	mockDataChannel *dataChannelMock.IDataChannel
+	mockIohandler   *iohandlermocks.MockIOHandler
+	mockShell       *sessionPluginMock.ISessionPlugin
+	auditLogger     *audit.MockedLogger
+	plugin          *InteractiveCommandsPlugin
+	commands        string
+	nonInteractive  bool
+}
+
+func (suite *InteractiveCommandsTestSuite) SetupTest() {
+	suite.mockContext = context.NewMockDefault()
+	suite.mockCancelFlag = &task.MockCancelFlag{}
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockDataChannel = &dataChannelMock.IDataChannel{}
+	suite.mockIohandler = new(iohandlermocks.MockIOHandler)
+	suite.mockShell = new(sessionPluginMock.ISessionPlugin)
+	suite.auditLogger = audit.NewMockedLogger()
+	suite.plugin = &InteractiveCommandsPlugin{auditLogger: suite.auditLogger}
+	suite.commands = ""
+	suite.nonInteractive = false
+	newShellPlugin = func(pluginName string, commands string, nonInteractive bool) sessionplugin.ISessionPlugin {
+		suite.commands = commands
+		suite.nonInteractive = nonInteractive
+		return suite.mockShell
+	}
+}
+
+// Testing Name
+func (suite *InteractiveCommandsTestSuite) TestName() {
+	assert.Equal(suite.T(), appconfig.PluginNameInteractiveCommands, suite.plugin.name())
+}
+
+// Testing Execute
+func (suite *InteractiveCommandsTestSuite) TestExecuteAllowedCommands() {
+	config := contracts.Configuration{
+		Properties:       map[string]interface{}{"commands": " tail -f /var/log/messages "},
+		CommandAllowList: []string{`tail -f /var/log/\w+`},
+	}
+	suite.mockShell.On("Execute", suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel).Return()
+
+	suite.plugin.Execute(suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel)
+
+	assert.Equal(suite.T(), "tail -f /var/log/messages", suite.commands)
+	suite.mockShell.AssertExpectations(suite.T())
+	suite.auditLogger.AssertNotCalled(suite.T(), "Log", mock.Anything)
+
+	// input is passed to the commands once they run
+	message := mgsContracts.AgentMessage{PayloadType: uint32(mgsContracts.Output), Payload: []byte("q")}
+	suite.mockShell.On("InputStreamMessageHandler", suite.mockContext.Log(), message).Return(nil)
+	assert.Nil(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockContext.Log(), message))
+	suite.mockShell.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *InteractiveCommandsTestSuite) TestExecuteNonInteractiveCommands() {
+	plugin, _ := NewNonInteractivePlugin()
+	suite.plugin = plugin.(*InteractiveCommandsPlugin)
+	suite.plugin.auditLogger = suite.auditLogger
+	config := contracts.Configuration{
+		Properties: map[string]interface{}{"commands": "systemctl status sshd"},
+	}
+	suite.mockShell.On("Execute", suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel).Return()
+
+	suite.plugin.Execute(suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel)
+
+	assert.Equal(suite.T(), appconfig.PluginNameNonInteractiveCommands, suite.plugin.name())
+	assert.Equal(suite.T(), []string{shell.FeatureStderr}, suite.plugin.PluginFeatures())
+	assert.Equal(suite.T(), "systemctl status sshd", suite.commands)
+	assert.True(suite.T(), suite.nonInteractive)
+	suite.mockShell.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *InteractiveCommandsTestSuite) TestExecuteRejectedCommands() {
+	config := contracts.Configuration{
+		SessionId:        "s-1",
+		ClientId:         "client-1",
+		Properties:       map[string]interface{}{"commands": "cat /etc/shadow"},
+		CommandAllowList: []string{`tail -f /var/log/\w+`},
+	}
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockContext.Log(), mgsContracts.Output, mock.Anything).Return(nil)
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Terminating).Return(nil)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.plugin.Execute(suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel)
+
+	suite.mockShell.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.auditLogger.AssertCalled(suite.T(), "Log", audit.Entry{
+		Event:        audit.SessionCommandRejected,
+		DocumentType: string(contracts.StartSession),
+		SessionID:    "s-1",
+		Requester:    "client-1",
+		Command:      "cat /etc/shadow",
+		Status:       "command does not match any allowed pattern",
+	})
+
+	// input is rejected as no command runs
+	message := mgsContracts.AgentMessage{PayloadType: uint32(mgsContracts.Output), Payload: []byte("q")}
+	assert.Nil(suite.T(), suite.plugin.InputStreamMessageHandler(suite.mockContext.Log(), message))
+}
+
+// Testing Execute
+func (suite *InteractiveCommandsTestSuite) TestExecuteWithoutCommands() {
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockContext.Log(), mgsContracts.Output, mock.Anything).Return(nil)
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Terminating).Return(nil)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.plugin.Execute(suite.mockContext, contracts.Configuration{}, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel)
+
+	suite.mockShell.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.auditLogger.AssertNotCalled(suite.T(), "Log", mock.Anything)
+}
+
+// Testing Execute
+func (suite *InteractiveCommandsTestSuite) TestExecuteWithInvalidFilter() {
+	config := contracts.Configuration{
+		Properties:      map[string]interface{}{"commands": "top"},
+		CommandDenyList: []string{`rm (`},
+	}
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockContext.Log(), mgsContracts.Output, mock.Anything).Return(nil)
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Terminating).Return(nil)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.plugin.Execute(suite.mockContext, config, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel)
+
+	suite.mockShell.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Execute the test suite
+func TestInteractiveCommandsTestSuite(t *testing.T) {
+	suite.Run(t, new(InteractiveCommandsTestSuite))
+}
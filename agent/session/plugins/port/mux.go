@@ -0,0 +1,415 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+)
+
+// FrameType is the type of a frame of the multiplexing protocol.
+type FrameType uint8
+
+const (
+	// FrameOpen opens a stream to the port given in its OpenStreamData payload.
+	FrameOpen FrameType = 1
+	// FrameData carries bytes of a stream.
+	FrameData FrameType = 2
+	// FrameWindowUpdate grants the peer the number of bytes, a big endian uint32, it may send in addition.
+	FrameWindowUpdate FrameType = 3
+	// FrameClose closes a stream.
+	FrameClose FrameType = 4
+)
+
+// frameHeaderLength is the length of the stream id and of the frame type preceding the frame payload
+const frameHeaderLength = 5
+
+const (
+	// ProtocolTCP streams forward a TCP connection, flow controlled by windows.
+	ProtocolTCP = "tcp"
+	// ProtocolUDP streams forward a UDP flow: each FrameData frame carries a single datagram and the flow is not flow controlled.
+	ProtocolUDP = "udp"
+)
+
+// OpenStreamData is the payload of a FrameOpen frame.
+// Protocol is ProtocolTCP when empty.
+type OpenStreamData struct {
+	PortNumber string `json:"portNumber"`
+	Protocol   string `json:"protocol,omitempty"`
+}
+
+// Frame is a frame of the multiplexing protocol, carried in the payload of the stream data messages.
+type Frame struct {
+	StreamID uint32
+	Type     FrameType
+	Payload  []byte
+}
+
+// Serialize returns the bytes of the frame: the stream id (big endian uint32), the frame type and the payload.
+func (f Frame) Serialize() []byte {
+	result := make([]byte, frameHeaderLength+len(f.Payload))
+	binary.BigEndian.PutUint32(result, f.StreamID)
+	result[4] = byte(f.Type)
+	copy(result[frameHeaderLength:], f.Payload)
+	return result
+}
+
+// DeserializeFrame parses the bytes of a frame.
+func DeserializeFrame(input []byte) (frame Frame, err error) {
+	if len(input) < frameHeaderLength {
+		return frame, fmt.Errorf("frame of %d bytes is shorter than its header", len(input))
+	}
+	frame.StreamID = binary.BigEndian.Uint32(input)
+	frame.Type = FrameType(input[4])
+	frame.Payload = input[frameHeaderLength:]
+	return
+}
+
+// windowUpdateFrame returns a frame granting the peer the given number of bytes.
+func windowUpdateFrame(streamID uint32, increment int) Frame {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, uint32(increment))
+	return Frame{StreamID: streamID, Type: FrameWindowUpdate, Payload: payload}
+}
+
+// dialer opens the connection to a port of the host, overridden in tests.
+var dialer = func(protocol string, host string, portNumber string) (net.Conn, error) {
+	return net.Dial(protocol, net.JoinHostPort(host, portNumber))
+}
+
+// multiplexer forwards several streams, each one connected to a port of the host, over a single data channel.
+// When portNumber is set, streams may only connect to that port and the client may omit it.
+type multiplexer struct {
+	dataChannel datachannel.IDataChannel
+	host        string
+	portNumber  string
+	streams     map[uint32]*stream
+	closed      bool
+	m           sync.Mutex
+	// sendLock serializes the stream data messages sent over the data channel
+	sendLock sync.Mutex
+}
+
+// newMultiplexer creates a multiplexer sending its frames over the given data channel, and connecting its streams to host.
+func newMultiplexer(dataChannel datachannel.IDataChannel, host string, portNumber string) *multiplexer {
+	return &multiplexer{
+		dataChannel: dataChannel,
+		host:        host,
+		portNumber:  portNumber,
+		streams:     make(map[uint32]*stream),
+	}
+}
+
+// send sends a frame over the data channel.
+func (mux *multiplexer) send(log log.T, frame Frame) error {
+	mux.sendLock.Lock()
+	defer mux.sendLock.Unlock()
+	return mux.dataChannel.SendStreamDataMessage(log, mgsContracts.Output, frame.Serialize())
+}
+
+// handleFrame processes a frame received from the data channel.
+func (mux *multiplexer) handleFrame(log log.T, frame Frame) error {
+	if frame.Type == FrameOpen {
+		return mux.open(log, frame)
+	}
+
+	mux.m.Lock()
+	stream, found := mux.streams[frame.StreamID]
+	mux.m.Unlock()
+	if !found {
+		log.Debugf("Ignoring frame of type %d for unknown stream %d", frame.Type, frame.StreamID)
+		return nil
+	}
+
+	switch frame.Type {
+	case FrameData:
+		if err := stream.receive(frame.Payload); err != nil {
+			log.Warnf("Closing stream %d: %v", frame.StreamID, err)
+			mux.closeStream(log, stream, true)
+		}
+	case FrameWindowUpdate:
+		if len(frame.Payload) != 4 {
+			return fmt.Errorf("invalid window update of %d bytes for stream %d", len(frame.Payload), frame.StreamID)
+		}
+		stream.grant(int(binary.BigEndian.Uint32(frame.Payload)))
+	case FrameClose:
+		mux.closeStream(log, stream, false)
+	default:
+		return fmt.Errorf("unknown frame type %d for stream %d", frame.Type, frame.StreamID)
+	}
+	return nil
+}
+
+// open registers a new stream and connects it to its port.
+func (mux *multiplexer) open(log log.T, frame Frame) error {
+	var openData OpenStreamData
+	if err := json.Unmarshal(frame.Payload, &openData); err != nil {
+		return fmt.Errorf("invalid open message for stream %d: %v", frame.StreamID, err)
+	}
+	if mux.portNumber != "" {
+		if openData.PortNumber == "" {
+			openData.PortNumber = mux.portNumber
+		} else if openData.PortNumber != mux.portNumber {
+			log.Warnf("Rejecting stream %d to port %v, the session document only allows port %v", frame.StreamID, openData.PortNumber, mux.portNumber)
+			return mux.send(log, Frame{StreamID: frame.StreamID, Type: FrameClose})
+		}
+	}
+	if _, err := strconv.ParseUint(openData.PortNumber, 10, 16); err != nil {
+		log.Warnf("Rejecting stream %d to invalid port %v", frame.StreamID, openData.PortNumber)
+		return mux.send(log, Frame{StreamID: frame.StreamID, Type: FrameClose})
+	}
+	if openData.Protocol == "" {
+		openData.Protocol = ProtocolTCP
+	}
+	if openData.Protocol != ProtocolTCP && openData.Protocol != ProtocolUDP {
+		log.Warnf("Rejecting stream %d with unsupported protocol %v", frame.StreamID, openData.Protocol)
+		return mux.send(log, Frame{StreamID: frame.StreamID, Type: FrameClose})
+	}
+
+	mux.m.Lock()
+	_, duplicate := mux.streams[frame.StreamID]
+	full := len(mux.streams) >= mgsConfig.PortStreamsLimit
+	if mux.closed || duplicate || full {
+		mux.m.Unlock()
+		log.Warnf("Rejecting stream %d to port %v, closed: %v, duplicate: %v, streams limit reached: %v",
+			frame.StreamID, openData.PortNumber, mux.closed, duplicate, full)
+		return mux.send(log, Frame{StreamID: frame.StreamID, Type: FrameClose})
+	}
+	stream := newStream(mux, frame.StreamID, openData.Protocol)
+	mux.streams[frame.StreamID] = stream
+	mux.m.Unlock()
+
+	// connect without blocking the data channel; data received in the meantime is queued within the window
+	go stream.run(log, openData.PortNumber)
+	return nil
+}
+
+// closeStream unregisters the stream and closes its connection, notifying the peer if requested.
+func (mux *multiplexer) closeStream(log log.T, stream *stream, notifyPeer bool) {
+	mux.m.Lock()
+	if mux.streams[stream.id] != stream {
+		mux.m.Unlock()
+		return
+	}
+	delete(mux.streams, stream.id)
+	mux.m.Unlock()
+
+	stream.close()
+	if notifyPeer {
+		if err := mux.send(log, Frame{StreamID: stream.id, Type: FrameClose}); err != nil {
+			log.Errorf("Unable to send close message for stream %d: %v", stream.id, err)
+		}
+	}
+}
+
+// close closes all the streams and rejects new ones.
+func (mux *multiplexer) close(log log.T) {
+	mux.m.Lock()
+	mux.closed = true
+	streams := make([]*stream, 0, len(mux.streams))
+	for _, stream := range mux.streams {
+		streams = append(streams, stream)
+	}
+	mux.m.Unlock()
+
+	for _, stream := range streams {
+		mux.closeStream(log, stream, true)
+	}
+}
+
+// streamCount returns the number of open streams.
+func (mux *multiplexer) streamCount() int {
+	mux.m.Lock()
+	defer mux.m.Unlock()
+	return len(mux.streams)
+}
+
+// stream is a connection to a local port forwarded over the data channel.
+// Each direction of TCP streams is flow controlled by a window of mgsConfig.PortStreamWindowSize bytes.
+// UDP streams are not: datagrams beyond the window are dropped, as a full socket buffer would.
+type stream struct {
+	id       uint32
+	mux      *multiplexer
+	protocol string
+
+	conn net.Conn
+	// pending holds the data received from the peer and not yet written to the connection
+	pending [][]byte
+	// receiveWindow is the number of bytes the peer may still send
+	receiveWindow int
+	// sendWindow is the number of bytes that may still be sent to the peer
+	sendWindow int
+	closed     bool
+	cond       *sync.Cond
+	// lastActivity is the last time a datagram went through a UDP stream, closed once idle for idleTimeout
+	lastActivity time.Time
+	idleTimeout  time.Duration
+}
+
+// newStream creates a stream with full windows.
+func newStream(mux *multiplexer, id uint32, protocol string) *stream {
+	return &stream{
+		id:            id,
+		mux:           mux,
+		protocol:      protocol,
+		lastActivity:  time.Now(),
+		idleTimeout:   datagramFlowIdleTimeout,
+		receiveWindow: mgsConfig.PortStreamWindowSize,
+		sendWindow:    mgsConfig.PortStreamWindowSize,
+		cond:          sync.NewCond(new(sync.Mutex)),
+	}
+}
+
+// receive queues data received from the peer, failing if the peer exceeds the window of a TCP stream.
+func (s *stream) receive(data []byte) error {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	if s.protocol == ProtocolUDP {
+		s.lastActivity = time.Now()
+		if len(data) > s.receiveWindow {
+			return nil
+		}
+	} else if len(data) > s.receiveWindow {
+		return fmt.Errorf("received %d bytes exceeding the window of %d bytes", len(data), s.receiveWindow)
+	}
+	s.receiveWindow -= len(data)
+	s.pending = append(s.pending, append([]byte(nil), data...))
+	s.cond.Broadcast()
+	return nil
+}
+
+// grant allows sending more bytes to the peer.
+func (s *stream) grant(increment int) {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	s.sendWindow += increment
+	s.cond.Broadcast()
+}
+
+// close closes the connection of the stream and stops its pumps.
+func (s *stream) close() {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.cond.Broadcast()
+}
+
+// run connects the stream to the port of the host and pumps the data in both directions until the stream is closed.
+func (s *stream) run(log log.T, portNumber string) {
+	conn, err := dialer(s.protocol, s.mux.host, portNumber)
+	if err != nil {
+		log.Warnf("Unable to connect stream %d to %s port %v of %v: %v", s.id, s.protocol, portNumber, s.mux.host, err)
+		s.mux.closeStream(log, s, true)
+		return
+	}
+
+	s.cond.L.Lock()
+	if s.closed {
+		s.cond.L.Unlock()
+		conn.Close()
+		return
+	}
+	s.conn = conn
+	s.cond.L.Unlock()
+	log.Debugf("Stream %d connected to %s port %v of %v", s.id, s.protocol, portNumber, s.mux.host)
+
+	go s.writePump(log)
+	if s.protocol == ProtocolUDP {
+		s.datagramReadPump(log)
+	} else {
+		s.readPump(log)
+	}
+}
+
+// readPump reads from the connection and sends the data to the peer, within the send window.
+func (s *stream) readPump(log log.T) {
+	buffer := make([]byte, mgsConfig.StreamDataPayloadSize-frameHeaderLength)
+	for {
+		s.cond.L.Lock()
+		for s.sendWindow == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		closed, size := s.closed, s.sendWindow
+		s.cond.L.Unlock()
+		if closed {
+			return
+		}
+		if size > len(buffer) {
+			size = len(buffer)
+		}
+
+		read, err := s.conn.Read(buffer[:size])
+		if read > 0 {
+			s.cond.L.Lock()
+			s.sendWindow -= read
+			s.cond.L.Unlock()
+			if sendErr := s.mux.send(log, Frame{StreamID: s.id, Type: FrameData, Payload: buffer[:read]}); sendErr != nil {
+				log.Errorf("Unable to send data of stream %d: %v", s.id, sendErr)
+				s.mux.closeStream(log, s, true)
+				return
+			}
+		}
+		if err != nil {
+			log.Debugf("Connection of stream %d ended: %v", s.id, err)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+	}
+}
+
+// writePump writes the data received from the peer to the connection, granting the peer a new window as it goes.
+func (s *stream) writePump(log log.T) {
+	for {
+		s.cond.L.Lock()
+		for len(s.pending) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.cond.L.Unlock()
+			return
+		}
+		data := s.pending[0]
+		s.pending = s.pending[1:]
+		s.cond.L.Unlock()
+
+		if _, err := s.conn.Write(data); err != nil {
+			log.Debugf("Unable to write to the connection of stream %d: %v", s.id, err)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+
+		s.cond.L.Lock()
+		s.receiveWindow += len(data)
+		s.cond.L.Unlock()
+		if s.protocol == ProtocolUDP {
+			continue
+		}
+		if err := s.mux.send(log, windowUpdateFrame(s.id, len(data))); err != nil {
+			log.Errorf("Unable to send window update of stream %d: %v", s.id, err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// frameRecorder is a data channel recording the frames sent by a multiplexer.
+type frameRecorder struct {
+	dataChannelMock.IDataChannel
+	frames chan Frame
+}
+
+// SendStreamDataMessage records the frame of the message.
+func (r *frameRecorder) SendStreamDataMessage(log log.T, dataType mgsContracts.PayloadType, inputData []byte) error {
+	frame, err := DeserializeFrame(inputData)
+	frame.Payload = append([]byte(nil), frame.Payload...)
+	r.frames <- frame
+	return err
+}
+
+// newTestMultiplexer returns a multiplexer whose sent frames are delivered to the returned channel,
+// and whose streams connect to the returned channel of local connections.
+func newTestMultiplexer() (*multiplexer, chan Frame, chan net.Conn) {
+	recorder := &frameRecorder{frames: make(chan Frame, 100)}
+	conns := make(chan net.Conn, 10)
+	dialer = func(protocol string, host string, portNumber string) (net.Conn, error) {
+		local, remote := net.Pipe()
+		conns <- remote
+		return local, nil
+	}
+	return newMultiplexer(recorder, localHost, ""), recorder.frames, conns
+}
+
+func openFrame(streamID uint32, portNumber string) Frame {
+	return Frame{StreamID: streamID, Type: FrameOpen, Payload: []byte(`{"portNumber":"` + portNumber + `"}`)}
+}
+
+func openDatagramFrame(streamID uint32, portNumber string) Frame {
+	return Frame{StreamID: streamID, Type: FrameOpen, Payload: []byte(`{"portNumber":"` + portNumber + `","protocol":"udp"}`)}
+}
+
+func nextFrame(t *testing.T, frames chan Frame) Frame {
+	select {
+	case frame := <-frames:
+		return frame
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no frame sent")
+		return Frame{}
+	}
+}
+
+func TestFrameSerialization(t *testing.T) {
+	frame := Frame{StreamID: 258, Type: FrameData, Payload: []byte("data")}
+	serialized := frame.Serialize()
+	assert.Equal(t, []byte{0, 0, 1, 2, 2, 'd', 'a', 't', 'a'}, serialized)
+
+	deserialized, err := DeserializeFrame(serialized)
+	assert.Nil(t, err)
+	assert.Equal(t, frame, deserialized)
+
+	_, err = DeserializeFrame([]byte{0, 0, 1})
+	assert.NotNil(t, err)
+}
+
+func TestMultiplexerForwardsStreams(t *testing.T) {
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	assert.Nil(t, mux.handleFrame(logger, openFrame(1, "80")))
+	first := <-conns
+	assert.Nil(t, mux.handleFrame(logger, openFrame(2, "443")))
+	second := <-conns
+	assert.Equal(t, 2, mux.streamCount())
+
+	// client to instance, acknowledged with a window update
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("ping")}))
+	buffer := make([]byte, 4)
+	_, err := first.Read(buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, "ping", string(buffer))
+	assert.Equal(t, windowUpdateFrame(1, 4), nextFrame(t, frames))
+
+	// instance to client
+	go second.Write([]byte("pong"))
+	assert.Equal(t, Frame{StreamID: 2, Type: FrameData, Payload: []byte("pong")}, nextFrame(t, frames))
+
+	// closing a stream keeps the other one open
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameClose}))
+	assert.Equal(t, 1, mux.streamCount())
+
+	mux.close(logger)
+	assert.Equal(t, Frame{StreamID: 2, Type: FrameClose}, nextFrame(t, frames))
+	assert.Equal(t, 0, mux.streamCount())
+}
+
+func TestMultiplexerHonorsSendWindow(t *testing.T) {
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	assert.Nil(t, mux.handleFrame(logger, openFrame(1, "80")))
+	conn := <-conns
+	go conn.Write(make([]byte, mgsConfig.PortStreamWindowSize+10))
+
+	sent := 0
+	for sent < mgsConfig.PortStreamWindowSize {
+		frame := nextFrame(t, frames)
+		assert.True(t, len(frame.Payload) <= mgsConfig.StreamDataPayloadSize-frameHeaderLength)
+		sent += len(frame.Payload)
+	}
+	assert.Equal(t, mgsConfig.PortStreamWindowSize, sent)
+
+	// nothing more is sent until the client grants a new window
+	select {
+	case <-frames:
+		assert.Fail(t, "data sent beyond the window")
+	case <-time.After(100 * time.Millisecond):
+	}
+	increment := make([]byte, 4)
+	binary.BigEndian.PutUint32(increment, 10)
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameWindowUpdate, Payload: increment}))
+	assert.Equal(t, 10, len(nextFrame(t, frames).Payload))
+
+	mux.close(logger)
+}
+
+func TestMultiplexerClosesStreamExceedingReceiveWindow(t *testing.T) {
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	assert.Nil(t, mux.handleFrame(logger, openFrame(1, "80")))
+	<-conns
+	// the connection is not read, so no window is granted back
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: make([]byte, mgsConfig.PortStreamWindowSize)}))
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("x")}))
+
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameClose}, nextFrame(t, frames))
+	assert.Equal(t, 0, mux.streamCount())
+}
+
+func TestMultiplexerRejectsStreams(t *testing.T) {
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	// invalid port
+	assert.Nil(t, mux.handleFrame(logger, openFrame(1, "http")))
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameClose}, nextFrame(t, frames))
+
+	// unsupported protocol
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameOpen, Payload: []byte(`{"portNumber":"80","protocol":"sctp"}`)}))
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameClose}, nextFrame(t, frames))
+
+	// duplicate stream
+	assert.Nil(t, mux.handleFrame(logger, openFrame(2, "80")))
+	<-conns
+	assert.Nil(t, mux.handleFrame(logger, openFrame(2, "80")))
+	assert.Equal(t, Frame{StreamID: 2, Type: FrameClose}, nextFrame(t, frames))
+	assert.Equal(t, 1, mux.streamCount())
+
+	// closed multiplexer
+	mux.close(logger)
+	nextFrame(t, frames)
+	assert.Nil(t, mux.handleFrame(logger, openFrame(3, "80")))
+	assert.Equal(t, Frame{StreamID: 3, Type: FrameClose}, nextFrame(t, frames))
+	assert.Equal(t, 0, mux.streamCount())
+}
+
+func TestMultiplexerForwardsDatagrams(t *testing.T) {
+	mux, frames, _ := newTestMultiplexer()
+	logger := log.NewMockLog()
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer server.Close()
+	dialer = func(protocol string, host string, portNumber string) (net.Conn, error) {
+		assert.Equal(t, ProtocolUDP, protocol)
+		return net.Dial(protocol, server.LocalAddr().String())
+	}
+
+	_, portNumber, _ := net.SplitHostPort(server.LocalAddr().String())
+	assert.Nil(t, mux.handleFrame(logger, openDatagramFrame(1, portNumber)))
+
+	// client to instance, each frame is a datagram and no window update is sent
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("query")}))
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("again")}))
+	buffer := make([]byte, 100)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	read, client, err := server.ReadFrom(buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, "query", string(buffer[:read]))
+	read, _, err = server.ReadFrom(buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, "again", string(buffer[:read]))
+
+	// instance to client, dropping datagrams too large for a frame
+	_, err = server.WriteTo(make([]byte, mgsConfig.StreamDataPayloadSize), client)
+	assert.Nil(t, err)
+	_, err = server.WriteTo([]byte("answer"), client)
+	assert.Nil(t, err)
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameData, Payload: []byte("answer")}, nextFrame(t, frames))
+
+	mux.close(logger)
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameClose}, nextFrame(t, frames))
+}
+
+func TestMultiplexerDropsDatagramsExceedingReceiveWindow(t *testing.T) {
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	assert.Nil(t, mux.handleFrame(logger, openDatagramFrame(1, "53")))
+	conn := <-conns
+	// the connection is not read, so the datagram beyond the window is dropped without closing the flow
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: make([]byte, mgsConfig.PortStreamWindowSize)}))
+	assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("x")}))
+	assert.Equal(t, 1, mux.streamCount())
+
+	buffer := make([]byte, mgsConfig.PortStreamWindowSize)
+	read, err := conn.Read(buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, mgsConfig.PortStreamWindowSize, read)
+
+	mux.close(logger)
+	nextFrame(t, frames)
+}
+
+func TestMultiplexerExpiresIdleDatagramFlows(t *testing.T) {
+	defer func(timeout time.Duration) { datagramFlowIdleTimeout = timeout }(datagramFlowIdleTimeout)
+	datagramFlowIdleTimeout = 200 * time.Millisecond
+	mux, frames, conns := newTestMultiplexer()
+	logger := log.NewMockLog()
+
+	assert.Nil(t, mux.handleFrame(logger, openDatagramFrame(1, "514")))
+	conn := <-conns
+
+	// datagrams from the client keep the flow open
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		assert.Nil(t, mux.handleFrame(logger, Frame{StreamID: 1, Type: FrameData, Payload: []byte("log")}))
+		buffer := make([]byte, 3)
+		_, err := conn.Read(buffer)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, mux.streamCount())
+
+	assert.Equal(t, Frame{StreamID: 1, Type: FrameClose}, nextFrame(t, frames))
+	assert.Equal(t, 0, mux.streamCount())
+}
+
+func TestMultiplexerConnectsToDocumentPort(t *testing.T) {
+	recorder := &frameRecorder{frames: make(chan Frame, 100)}
+	dialed := make(chan string, 10)
+	dialer = func(protocol string, host string, portNumber string) (net.Conn, error) {
+		dialed <- net.JoinHostPort(host, portNumber)
+		local, _ := net.Pipe()
+		return local, nil
+	}
+	mux := newMultiplexer(recorder, "10.0.0.5", "5432")
+	logger := log.NewMockLog()
+
+	// the port may be omitted by the client
+	assert.Nil(t, mux.handleFrame(logger, openFrame(1, "")))
+	assert.Equal(t, "10.0.0.5:5432", <-dialed)
+	assert.Nil(t, mux.handleFrame(logger, openFrame(2, "5432")))
+	assert.Equal(t, "10.0.0.5:5432", <-dialed)
+
+	// other ports are rejected
+	assert.Nil(t, mux.handleFrame(logger, openFrame(3, "22")))
+	assert.Equal(t, Frame{StreamID: 3, Type: FrameClose}, nextFrame(t, recorder.frames))
+	assert.Equal(t, 2, mux.streamCount())
+
+	mux.close(logger)
+}
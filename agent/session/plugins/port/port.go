@@ -0,0 +1,157 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package port implements session port forwarding plugin.
+// Several local connections are multiplexed over the data channel of a single session:
+// each stream data message carries a Frame addressed to a stream, and each stream is
+// connected to a port of the instance and flow controlled independently of the others.
+// UDP flows are forwarded as streams too, one datagram per frame, and closed once idle.
+package port
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// FeatureUDP is announced in the handshake: streams may be opened with ProtocolUDP.
+const FeatureUDP = "udp"
+
+// PortPlugin is the type for the port plugin.
+type PortPlugin struct {
+	dataChannel datachannel.IDataChannel
+	mux         *multiplexer
+	m           sync.RWMutex
+}
+
+// NewPlugin returns a new instance of the Port Plugin
+func NewPlugin() (sessionplugin.ISessionPlugin, error) {
+	var plugin = PortPlugin{}
+	return &plugin, nil
+}
+
+// PluginFeatures returns the optional features of port forwarding.
+func (p *PortPlugin) PluginFeatures() []string {
+	return []string{FeatureUDP}
+}
+
+// name returns the name of Port Plugin
+func (p *PortPlugin) name() string {
+	return appconfig.PluginNamePort
+}
+
+// Execute forwards the streams opened by the client until the session is terminated.
+func (p *PortPlugin) Execute(context context.T,
+	config agentContracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler,
+	dataChannel datachannel.IDataChannel) {
+
+	log := context.Log()
+	p.dataChannel = dataChannel
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Error occurred while executing plugin %s: \n%v", p.name(), err)
+			log.Flush()
+			os.Exit(1)
+		}
+	}()
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+	} else {
+		p.execute(context, config, cancelFlag, output)
+	}
+}
+
+// execute accepts the streams opened by the client until the session is canceled, then closes them.
+// Streams connect to localhost unless the session document sets a host allowed by the agent configuration.
+func (p *PortPlugin) execute(context context.T, config agentContracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := context.Log()
+	host, portNumber, err := getTarget(context, config)
+	if err != nil {
+		errorString := fmt.Errorf("Unable to forward ports of session %s: %s", config.SessionId, err)
+		log.Error(errorString)
+		output.MarkAsFailed(errorString)
+		return
+	}
+	log.Infof("Forwarding streams of session %s to %v", config.SessionId, host)
+	mux := newMultiplexer(p.dataChannel, host, portNumber)
+	p.m.Lock()
+	p.mux = mux
+	p.m.Unlock()
+	log.Infof("Plugin %s started", p.name())
+
+	cancelState := cancelFlag.Wait()
+	log.Debugf("Cancel flag set to %v in session", cancelState)
+
+	mux.close(log)
+	if err := p.dataChannel.SendAgentSessionStateMessage(log, mgsContracts.Terminating); err != nil {
+		log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Terminating, err)
+	}
+	output.SetExitCode(appconfig.SuccessExitCode)
+	output.SetStatus(agentContracts.ResultStatusSuccess)
+	log.Debug("Port session execution complete")
+}
+
+// getTarget returns the host and the port the streams of the session connect to.
+func getTarget(context context.T, config agentContracts.Configuration) (host string, portNumber string, err error) {
+	properties, err := getPortProperties(config)
+	if err != nil {
+		return "", "", err
+	}
+	allowlist, err := newHostAllowlist(context.AppConfig().Mgs.PortForwardingAllowlist)
+	if err != nil {
+		return "", "", err
+	}
+	if host, err = allowlist.resolve(properties.Host); err != nil {
+		return "", "", err
+	}
+	return host, properties.PortNumber, nil
+}
+
+// InputStreamMessageHandler passes the frames received from the data channel to their stream
+func (p *PortPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	p.m.RLock()
+	mux := p.mux
+	p.m.RUnlock()
+	if mux == nil {
+		// This is to handle scenario when the client starts sending frames before the plugin has started
+		// Since packets are rejected, the client will resend these packets until the plugin starts
+		log.Tracef("Port forwarding unavailable. Reject incoming message packet")
+		return nil
+	}
+
+	if mgsContracts.PayloadType(streamDataMessage.PayloadType) != mgsContracts.Output {
+		log.Tracef("Ignoring message of payload type %d", streamDataMessage.PayloadType)
+		return nil
+	}
+	frame, err := DeserializeFrame(streamDataMessage.Payload)
+	if err != nil {
+		log.Errorf("Invalid port forwarding message: %v", err)
+		return err
+	}
+	return mux.handleFrame(log, frame)
+}
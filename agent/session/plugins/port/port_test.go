@@ -0,0 +1,144 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PortTestSuite struct {
+	suite.Suite
+	mockContext     *context.Mock
+	mockCancelFlag  *task.MockCancelFlag
+	mockDataChannel *dataChannelMock.IDataChannel
+	mockIohandler   *iohandlermocks.MockIOHandler
+	plugin          *PortPlugin
+}
+
+func (suite *PortTestSuite) SetupTest() {
+	suite.mockContext = context.NewMockDefault()
+	suite.mockCancelFlag = &task.MockCancelFlag{}
+	suite.mockDataChannel = &dataChannelMock.IDataChannel{}
+	suite.mockIohandler = new(iohandlermocks.MockIOHandler)
+	suite.plugin = &PortPlugin{}
+}
+
+// Testing Name
+func (suite *PortTestSuite) TestName() {
+	assert.Equal(suite.T(), appconfig.PluginNamePort, suite.plugin.name())
+}
+
+// Testing Execute
+func (suite *PortTestSuite) TestExecuteWhenCancelFlagIsShutDown() {
+	suite.mockCancelFlag.On("ShutDown").Return(true)
+	suite.mockIohandler.On("MarkAsShutdown").Return(nil)
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockCancelFlag.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *PortTestSuite) TestExecuteUntilCanceled() {
+	canceled := make(chan bool)
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Canceled).Run(func(mock.Arguments) { <-canceled })
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", mock.Anything, mgsContracts.Terminating).Return(nil)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+
+	done := make(chan bool)
+	go func() {
+		suite.plugin.Execute(suite.mockContext,
+			contracts.Configuration{},
+			suite.mockCancelFlag,
+			suite.mockIohandler,
+			suite.mockDataChannel)
+		done <- true
+	}()
+
+	// frames are accepted once the plugin has started
+	for accepted := false; !accepted; {
+		suite.plugin.m.RLock()
+		accepted = suite.plugin.mux != nil
+		suite.plugin.m.RUnlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(canceled)
+	<-done
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing Execute
+func (suite *PortTestSuite) TestExecuteWhenHostIsNotAllowed() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{Properties: map[string]interface{}{"host": "192.0.2.10", "portNumber": "22"}},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.mockCancelFlag.AssertNotCalled(suite.T(), "Wait")
+	assert.Nil(suite.T(), suite.plugin.mux)
+}
+
+// Testing InputStreamMessageHandler
+func (suite *PortTestSuite) TestInputStreamMessageHandlerBeforeStart() {
+	agentMessage := mgsContracts.AgentMessage{
+		PayloadType: uint32(mgsContracts.Output),
+		Payload:     Frame{StreamID: 1, Type: FrameData, Payload: []byte("data")}.Serialize(),
+	}
+	assert.Nil(suite.T(), suite.plugin.InputStreamMessageHandler(log.NewMockLog(), agentMessage))
+	suite.mockDataChannel.AssertNotCalled(suite.T(), "SendStreamDataMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Testing InputStreamMessageHandler
+func (suite *PortTestSuite) TestInputStreamMessageHandlerInvalidFrame() {
+	suite.plugin.mux = newMultiplexer(suite.mockDataChannel, localHost, "")
+	agentMessage := mgsContracts.AgentMessage{
+		PayloadType: uint32(mgsContracts.Output),
+		Payload:     []byte{0, 1},
+	}
+	assert.NotNil(suite.T(), suite.plugin.InputStreamMessageHandler(log.NewMockLog(), agentMessage))
+}
+
+// Execute the test suite
+func TestPortTestSuite(t *testing.T) {
+	suite.Run(t, new(PortTestSuite))
+}
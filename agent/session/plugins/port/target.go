@@ -0,0 +1,137 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+)
+
+// localHost is the host streams connect to unless the session document sets one.
+const localHost = "localhost"
+
+// PortProperties are the properties of port session documents.
+// Streams connect to Host, localhost when empty. When PortNumber is set, streams may only connect to that port.
+type PortProperties struct {
+	Host       string `json:"host"`
+	PortNumber string `json:"portNumber"`
+}
+
+// lookupHost resolves host names, overridden in tests.
+var lookupHost = net.LookupHost
+
+// getPortProperties returns the properties of the session document.
+func getPortProperties(config agentContracts.Configuration) (properties PortProperties, err error) {
+	if config.Properties == nil {
+		return properties, nil
+	}
+	if err = jsonutil.Remarshal(config.Properties, &properties); err != nil {
+		return properties, fmt.Errorf("invalid %s session properties: %v", appconfig.PluginNamePort, err)
+	}
+	if properties.PortNumber != "" {
+		if _, err = strconv.ParseUint(properties.PortNumber, 10, 16); err != nil {
+			return properties, fmt.Errorf("invalid port number %v", properties.PortNumber)
+		}
+	}
+	return properties, nil
+}
+
+// hostAllowlist holds the networks and DNS suffixes of the remote hosts port sessions may connect to,
+// so that the instance cannot be used as an open proxy. Sessions may only connect to localhost when it is empty.
+type hostAllowlist struct {
+	networks []*net.IPNet
+	suffixes []string
+}
+
+// newHostAllowlist parses the allowlist of the agent configuration, whose entries are CIDR blocks or DNS suffixes.
+// A suffix allows the host of that name and its subdomains.
+func newHostAllowlist(entries []string) (allowlist hostAllowlist, err error) {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return allowlist, fmt.Errorf("invalid port forwarding allowlist network %v: %v", entry, err)
+			}
+			allowlist.networks = append(allowlist.networks, network)
+			continue
+		}
+		suffix := normalizeHostName(strings.TrimPrefix(entry, "."))
+		if suffix == "" || net.ParseIP(suffix) != nil {
+			return allowlist, fmt.Errorf("invalid port forwarding allowlist entry %v", entry)
+		}
+		allowlist.suffixes = append(allowlist.suffixes, suffix)
+	}
+	return allowlist, nil
+}
+
+// resolve returns the host streams connect to, failing unless host is allowed.
+// Host names outside the allowed suffixes are allowed when they resolve to an allowed address, which is returned
+// so that streams keep connecting to it whatever the name resolves to afterwards.
+func (a hostAllowlist) resolve(host string) (string, error) {
+	host = normalizeHostName(host)
+	if host == "" || host == localHost {
+		return localHost, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || a.allowsAddress(ip) {
+			return ip.String(), nil
+		}
+		return "", fmt.Errorf("host %v is not in the port forwarding allowlist", host)
+	}
+	for _, suffix := range a.suffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return host, nil
+		}
+	}
+
+	if len(a.networks) == 0 {
+		return "", fmt.Errorf("host %v is not in the port forwarding allowlist", host)
+	}
+	addresses, err := lookupHost(host)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve host %v: %v", host, err)
+	}
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip == nil || !a.allowsAddress(ip) {
+			return "", fmt.Errorf("host %v resolves to %v, which is not in the port forwarding allowlist", host, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("host %v has no address", host)
+	}
+	return addresses[0], nil
+}
+
+// allowsAddress returns true if ip belongs to an allowed network.
+func (a hostAllowlist) allowsAddress(ip net.IP) bool {
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHostName returns host in lower case, without the brackets of IPv6 addresses nor the trailing dot of fully qualified names.
+func normalizeHostName(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
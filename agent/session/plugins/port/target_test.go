@@ -0,0 +1,101 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPortProperties(t *testing.T) {
+	properties, err := getPortProperties(contracts.Configuration{})
+	assert.Nil(t, err)
+	assert.Equal(t, PortProperties{}, properties)
+
+	properties, err = getPortProperties(contracts.Configuration{Properties: map[string]interface{}{"host": "db.internal", "portNumber": "5432"}})
+	assert.Nil(t, err)
+	assert.Equal(t, PortProperties{Host: "db.internal", PortNumber: "5432"}, properties)
+
+	_, err = getPortProperties(contracts.Configuration{Properties: map[string]interface{}{"portNumber": "70000"}})
+	assert.NotNil(t, err)
+}
+
+func TestNewHostAllowlist(t *testing.T) {
+	allowlist, err := newHostAllowlist([]string{"10.0.0.0/16", " .Example.com. ", "fd00::/8"})
+	assert.Nil(t, err)
+	assert.Len(t, allowlist.networks, 2)
+	assert.Equal(t, []string{"example.com"}, allowlist.suffixes)
+
+	_, err = newHostAllowlist([]string{"10.0.0.0/33"})
+	assert.NotNil(t, err)
+	_, err = newHostAllowlist([]string{"10.0.0.1"})
+	assert.NotNil(t, err)
+	_, err = newHostAllowlist([]string{"."})
+	assert.NotNil(t, err)
+}
+
+func TestHostAllowlistResolve(t *testing.T) {
+	defer func(original func(string) ([]string, error)) { lookupHost = original }(lookupHost)
+	lookupHost = func(host string) ([]string, error) {
+		switch host {
+		case "db.internal":
+			return []string{"10.0.1.5", "10.0.2.5"}, nil
+		case "split.internal":
+			return []string{"10.0.1.6", "192.0.2.6"}, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+	allowlist, err := newHostAllowlist([]string{"10.0.0.0/16", "example.com"})
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		host     string
+		resolved string
+		allowed  bool
+	}{
+		{"", localHost, true},
+		{"LocalHost", localHost, true},
+		{"127.0.0.1", "127.0.0.1", true},
+		{"[::1]", "::1", true},
+		{"10.0.3.4", "10.0.3.4", true},
+		{"10.1.0.1", "", false},
+		{"example.com", "example.com", true},
+		{"db.Example.com.", "db.example.com", true},
+		{"badexample.com", "", false},
+		{"db.internal", "10.0.1.5", true},
+		{"split.internal", "", false},
+		{"unknown.internal", "", false},
+	}
+	for _, testCase := range testCases {
+		resolved, err := allowlist.resolve(testCase.host)
+		assert.Equal(t, testCase.allowed, err == nil, testCase.host)
+		assert.Equal(t, testCase.resolved, resolved, testCase.host)
+	}
+}
+
+func TestEmptyHostAllowlistOnlyAllowsLocalhost(t *testing.T) {
+	allowlist, err := newHostAllowlist(nil)
+	assert.Nil(t, err)
+
+	resolved, err := allowlist.resolve("")
+	assert.Nil(t, err)
+	assert.Equal(t, localHost, resolved)
+	_, err = allowlist.resolve("10.0.0.1")
+	assert.NotNil(t, err)
+	_, err = allowlist.resolve("db.internal")
+	assert.NotNil(t, err)
+}
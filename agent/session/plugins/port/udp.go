@@ -0,0 +1,76 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package port
+
+import (
+	"net"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+)
+
+// datagramFlowIdleTimeout is the time after which an idle UDP flow is closed, overridden in tests.
+var datagramFlowIdleTimeout = mgsConfig.PortDatagramFlowIdleTimeout
+
+// datagramReadPump reads datagrams from the connection of a UDP stream and sends each of them to the peer in its own frame.
+// Datagrams too large for a frame are dropped, and the stream is closed once no datagram went through it for its idle timeout.
+func (s *stream) datagramReadPump(log log.T) {
+	maxDatagramSize := mgsConfig.StreamDataPayloadSize - frameHeaderLength
+	// read one more byte to detect the datagrams that don't fit, which the connection truncates
+	buffer := make([]byte, maxDatagramSize+1)
+	for {
+		s.cond.L.Lock()
+		closed, idleSince := s.closed, s.lastActivity
+		s.cond.L.Unlock()
+		if closed {
+			return
+		}
+		deadline := idleSince.Add(s.idleTimeout)
+		if !time.Now().Before(deadline) {
+			log.Debugf("Closing stream %d idle since %v", s.id, idleSince)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			log.Debugf("Unable to set the read deadline of stream %d: %v", s.id, err)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+
+		read, err := s.conn.Read(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				// datagrams may have been received from the peer in the meantime
+				continue
+			}
+			log.Debugf("Connection of stream %d ended: %v", s.id, err)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+		if read > maxDatagramSize {
+			log.Warnf("Dropping datagram of stream %d larger than %d bytes", s.id, maxDatagramSize)
+			continue
+		}
+
+		s.cond.L.Lock()
+		s.lastActivity = time.Now()
+		s.cond.L.Unlock()
+		if sendErr := s.mux.send(log, Frame{StreamID: s.id, Type: FrameData, Payload: buffer[:read]}); sendErr != nil {
+			log.Errorf("Unable to send data of stream %d: %v", s.id, sendErr)
+			s.mux.closeStream(log, s, true)
+			return
+		}
+	}
+}
@@ -17,7 +17,9 @@ package sessionplugin
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
@@ -37,6 +39,12 @@ type ISessionPlugin interface {
 	InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error
 }
 
+// IPluginFeatures is implemented by session plugins with optional features. The features are announced to the client
+// in the handshake so that clients only use them with agents supporting them.
+type IPluginFeatures interface {
+	PluginFeatures() []string
+}
+
 // SessionPlugin is the wrapper for all session manager plugins and implements all functions of Runpluginutil.T interface
 type SessionPlugin struct {
 	sessionPlugin ISessionPlugin
@@ -55,8 +63,10 @@ func (p *SessionPlugin) Execute(context context.T,
 	output iohandler.IOHandler) {
 
 	log := context.Log()
+	timer := newSessionTimer(log, config)
 
-	dataChannel, err := getDataChannelForSessionPlugin(context, config.SessionId, config.ClientId, cancelFlag, p.sessionPlugin.InputStreamMessageHandler)
+	dataChannel, err := getDataChannelForSessionPlugin(context, config.SessionId, config.ClientId, cancelFlag,
+		timer.inputStreamMessageHandler(p.sessionPlugin.InputStreamMessageHandler))
 	if err != nil {
 		errorString := fmt.Errorf("Setting up data channel with id %s failed: %s", config.SessionId, err)
 		output.MarkAsFailed(errorString)
@@ -69,7 +79,55 @@ func (p *SessionPlugin) Execute(context context.T,
 		log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Connected, err)
 	}
 
-	p.sessionPlugin.Execute(context, config, cancelFlag, output, dataChannel)
+	var pluginFeatures []string
+	if featuredPlugin, ok := p.sessionPlugin.(IPluginFeatures); ok {
+		pluginFeatures = featuredPlugin.PluginFeatures()
+	}
+	capabilities, err := dataChannel.PerformHandshake(log, pluginFeatures, config.KmsKeyId != "")
+	if err != nil {
+		errorString := fmt.Errorf("Handshake on data channel %s failed: %s", config.SessionId, err)
+		output.MarkAsFailed(errorString)
+		log.Error(errorString)
+		return
+	}
+
+	// Encryption is done by the data channel so that it covers every session type.
+	if capabilities.Encryption == mgsContracts.EncryptionKMS {
+		if err = dataChannel.EnableEncryption(log, config.KmsKeyId); err != nil {
+			errorString := fmt.Errorf("Encrypting data channel %s with KMS key %s failed: %s", config.SessionId, config.KmsKeyId, err)
+			output.MarkAsFailed(errorString)
+			log.Error(errorString)
+			return
+		}
+	}
+
+	done := make(chan struct{})
+	go timer.run(log, cancelFlag, func(message string) {
+		notifyClient(log, config, dataChannel, message)
+	}, done)
+
+	sessionOutput := &sessionOutput{IOHandler: output}
+	p.sessionPlugin.Execute(context, config, cancelFlag, sessionOutput, dataChannel)
+	close(done)
+
+	if reason := timer.terminationReason(); reason != "" {
+		sessionOutput.setTerminationReason(reason)
+	}
+	metrics := dataChannel.GetMetrics()
+	log.Infof("Data channel metrics of session %s: %s", config.SessionId, metrics)
+	sessionOutput.setMetrics(metrics)
+}
+
+// notifyClient writes message to the terminal of shell sessions.
+// The streams of the other session types carry binary protocols, the message is only logged for them.
+func notifyClient(log log.T, config contracts.Configuration, dataChannel datachannel.IDataChannel, message string) {
+	log.Info(strings.TrimSpace(message))
+	if config.PluginName != appconfig.PluginNameStandardStream {
+		return
+	}
+	if err := dataChannel.SendStreamDataMessage(log, mgsContracts.Output, []byte(message)); err != nil {
+		log.Errorf("Unable to send message to the client: %v", err)
+	}
 }
 
 // getDataChannelForSessionPlugin opens new data channel to MGS service
@@ -15,17 +15,24 @@
 package sessionplugin
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	iohandlerMock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
 	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
 	sessionPluginMock "github.com/aws/amazon-ssm-agent/agent/session/plugins/sessionplugin/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -65,8 +72,14 @@ func (suite *SessionPluginTestSuite) TestExecute() {
 			return suite.mockDataChannel, nil
 		}
 	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("PerformHandshake", suite.mockContext.Log(), []string(nil), false).Return(mgsContracts.HandshakeCompletePayload{}, nil)
 	suite.mockDataChannel.On("Close", suite.mockContext.Log()).Return(nil)
-	suite.mockSessionPlugin.On("Execute", suite.mockContext, mock.Anything, suite.mockCancelFlag, suite.mockIohandler, suite.mockDataChannel).Return()
+	metrics := mgsContracts.SessionMetrics{BytesIn: 10, MessagesIn: 1}
+	suite.mockDataChannel.On("GetMetrics").Return(metrics)
+	// the plugin sets no output, its stdout is reported with the metrics
+	suite.mockIohandler.On("String").Return("stdout")
+	suite.mockIohandler.On("SetOutput", mgsContracts.SessionPluginResultOutput{Output: "stdout", Metrics: &metrics}).Return()
+	suite.mockSessionPlugin.On("Execute", suite.mockContext, mock.Anything, suite.mockCancelFlag, &sessionOutput{IOHandler: suite.mockIohandler}, suite.mockDataChannel).Return()
 
 	suite.sessionPlugin.Execute(suite.mockContext,
 		contracts.Configuration{},
@@ -75,4 +88,181 @@ func (suite *SessionPluginTestSuite) TestExecute() {
 
 	suite.mockDataChannel.AssertExpectations(suite.T())
 	suite.mockSessionPlugin.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// Testing Execute with a KMS key
+func (suite *SessionPluginTestSuite) TestExecuteEnablesEncryption() {
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return suite.mockDataChannel, nil
+		}
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("PerformHandshake", suite.mockContext.Log(), []string(nil), true).Return(mgsContracts.HandshakeCompletePayload{Encryption: mgsContracts.EncryptionKMS}, nil)
+	suite.mockDataChannel.On("EnableEncryption", suite.mockContext.Log(), "kms-key-id").Return(nil)
+	suite.mockDataChannel.On("Close", suite.mockContext.Log()).Return(nil)
+	suite.mockDataChannel.On("GetMetrics").Return(mgsContracts.SessionMetrics{})
+	suite.mockIohandler.On("String").Return("")
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+	suite.mockSessionPlugin.On("Execute", suite.mockContext, mock.Anything, suite.mockCancelFlag, &sessionOutput{IOHandler: suite.mockIohandler}, suite.mockDataChannel).Return()
+
+	suite.sessionPlugin.Execute(suite.mockContext,
+		contracts.Configuration{KmsKeyId: "kms-key-id"},
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockSessionPlugin.AssertExpectations(suite.T())
+}
+
+// Testing Execute when the data channel cannot be encrypted
+func (suite *SessionPluginTestSuite) TestExecuteWhenEncryptionFails() {
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return suite.mockDataChannel, nil
+		}
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("PerformHandshake", suite.mockContext.Log(), []string(nil), true).Return(mgsContracts.HandshakeCompletePayload{Encryption: mgsContracts.EncryptionKMS}, nil)
+	suite.mockDataChannel.On("EnableEncryption", suite.mockContext.Log(), "kms-key-id").Return(errors.New("AccessDeniedException"))
+	suite.mockDataChannel.On("Close", suite.mockContext.Log()).Return(nil)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.sessionPlugin.Execute(suite.mockContext,
+		contracts.Configuration{KmsKeyId: "kms-key-id"},
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.mockSessionPlugin.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Testing Execute when the client doesn't support the encryption required by the session
+func (suite *SessionPluginTestSuite) TestExecuteWhenHandshakeFails() {
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return suite.mockDataChannel, nil
+		}
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", suite.mockContext.Log(), mgsContracts.Connected).Return(nil)
+	suite.mockDataChannel.On("PerformHandshake", suite.mockContext.Log(), []string(nil), true).Return(mgsContracts.HandshakeCompletePayload{}, errors.New("client does not support the encryption required by the session"))
+	suite.mockDataChannel.On("Close", suite.mockContext.Log()).Return(nil)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	suite.sessionPlugin.Execute(suite.mockContext,
+		contracts.Configuration{KmsKeyId: "kms-key-id"},
+		suite.mockCancelFlag,
+		suite.mockIohandler)
+
+	suite.mockDataChannel.AssertExpectations(suite.T())
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.mockDataChannel.AssertNotCalled(suite.T(), "EnableEncryption", mock.Anything, mock.Anything)
+	suite.mockSessionPlugin.AssertNotCalled(suite.T(), "Execute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Testing Execute with a plugin announcing optional features
+func (suite *SessionPluginTestSuite) TestExecuteAnnouncesPluginFeatures() {
+	dataChannel := &streamRecorder{}
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return dataChannel, nil
+		}
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+	suite.sessionPlugin.sessionPlugin = &waitingPlugin{features: []string{"feature"}}
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+
+	suite.sessionPlugin.Execute(suite.mockContext,
+		contracts.Configuration{},
+		cancelFlag,
+		suite.mockIohandler)
+
+	assert.Equal(suite.T(), []string{"feature"}, dataChannel.pluginFeatures)
+}
+
+// Testing Execute
+func (suite *SessionPluginTestSuite) TestExecuteTerminatesIdleSession() {
+	dataChannel := &streamRecorder{}
+	getDataChannelForSessionPlugin =
+		func(context context.T, sessionId string, clientId string, cancelFlag task.CancelFlag, inputStreamMessageHandler datachannel.InputStreamMessageHandler) (datachannel.IDataChannel, error) {
+			return dataChannel, nil
+		}
+	// the session is idle for 2 minutes as soon as it starts
+	start := time.Now()
+	var calls int32
+	timeNow = func() time.Time {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return start
+		}
+		return start.Add(2 * time.Minute)
+	}
+	sessionTimeoutCheckInterval = time.Millisecond
+	defer func() {
+		timeNow = time.Now
+		sessionTimeoutCheckInterval = mgsConfig.SessionTimeoutCheckInterval
+	}()
+	cancelFlag := task.NewChanneledCancelFlag()
+	suite.sessionPlugin.sessionPlugin = &waitingPlugin{result: mgsContracts.SessionPluginResultOutput{S3Bucket: "bucket"}}
+	suite.mockIohandler.On("SetOutput", mgsContracts.SessionPluginResultOutput{S3Bucket: "bucket"}).Return()
+	suite.mockIohandler.On("SetOutput", mgsContracts.SessionPluginResultOutput{S3Bucket: "bucket", TerminationReason: mgsContracts.IdleSessionTimeout}).Return()
+	suite.mockIohandler.On("SetOutput", mgsContracts.SessionPluginResultOutput{S3Bucket: "bucket", TerminationReason: mgsContracts.IdleSessionTimeout, Metrics: &streamRecorderMetrics}).Return()
+
+	suite.sessionPlugin.Execute(suite.mockContext,
+		contracts.Configuration{PluginName: appconfig.PluginNameStandardStream, IdleSessionTimeout: "1"},
+		cancelFlag,
+		suite.mockIohandler)
+
+	assert.True(suite.T(), cancelFlag.Canceled())
+	assert.Equal(suite.T(), [][]byte{[]byte(mgsConfig.IdleSessionTimeoutMsg)}, dataChannel.messages)
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
+// waitingPlugin is a session plugin running until the session is canceled.
+type waitingPlugin struct {
+	result   mgsContracts.SessionPluginResultOutput
+	features []string
+}
+
+func (p *waitingPlugin) PluginFeatures() []string {
+	return p.features
+}
+
+func (p *waitingPlugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler, dataChannel datachannel.IDataChannel) {
+	cancelFlag.Wait()
+	output.SetOutput(p.result)
+}
+
+func (p *waitingPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+	return nil
+}
+
+// streamRecorder is a data channel recording the stream data sent to the client.
+type streamRecorder struct {
+	dataChannelMock.IDataChannel
+	messages       [][]byte
+	pluginFeatures []string
+}
+
+// streamRecorderMetrics are the metrics of every streamRecorder.
+var streamRecorderMetrics = mgsContracts.SessionMetrics{BytesOut: 64, MessagesOut: 1}
+
+func (r *streamRecorder) GetMetrics() mgsContracts.SessionMetrics {
+	return streamRecorderMetrics
+}
+
+func (r *streamRecorder) PerformHandshake(log log.T, pluginFeatures []string, encryptionRequired bool) (mgsContracts.HandshakeCompletePayload, error) {
+	r.pluginFeatures = pluginFeatures
+	return mgsContracts.HandshakeCompletePayload{}, nil
+}
+
+func (r *streamRecorder) SendStreamDataMessage(log log.T, dataType mgsContracts.PayloadType, inputData []byte) error {
+	r.messages = append(r.messages, inputData)
+	return nil
+}
+
+func (r *streamRecorder) SendAgentSessionStateMessage(log log.T, sessionStatus mgsContracts.SessionStatus) error {
+	return nil
+}
+
+func (r *streamRecorder) Close(log log.T) error {
+	return nil
 }
@@ -0,0 +1,209 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessionplugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// sessionTimeoutCheckInterval is the interval at which the session timeouts are checked.
+var sessionTimeoutCheckInterval = mgsConfig.SessionTimeoutCheckInterval
+
+var timeNow = time.Now
+
+// sessionTimer terminates sessions left idle longer than the idle timeout or running longer than the maximum duration.
+// Only the stream data received from the client counts as activity, output of the session does not keep it alive.
+type sessionTimer struct {
+	lock           sync.Mutex
+	idleTimeout    time.Duration
+	maxDuration    time.Duration
+	start          time.Time
+	lastActivity   time.Time
+	idleWarned     bool
+	durationWarned bool
+	reason         mgsContracts.TerminationReason
+	now            func() time.Time
+	checkInterval  time.Duration
+}
+
+// newSessionTimer returns a session timer with the timeouts of the session document, starting now.
+func newSessionTimer(log log.T, config contracts.Configuration) *sessionTimer {
+	return newSessionTimerWithClock(
+		parseSessionTimeout(log, "idle session timeout", config.IdleSessionTimeout,
+			mgsConfig.DefaultIdleSessionTimeoutMinutes, mgsConfig.MinIdleSessionTimeoutMinutes, mgsConfig.MaxIdleSessionTimeoutMinutes),
+		parseSessionTimeout(log, "maximum session duration", config.MaxSessionDuration,
+			0, mgsConfig.MinSessionDurationMinutes, mgsConfig.MaxSessionDurationMinutes),
+		timeNow)
+}
+
+func newSessionTimerWithClock(idleTimeout time.Duration, maxDuration time.Duration, now func() time.Time) *sessionTimer {
+	start := now()
+	return &sessionTimer{
+		idleTimeout:   idleTimeout,
+		maxDuration:   maxDuration,
+		start:         start,
+		lastActivity:  start,
+		now:           now,
+		checkInterval: sessionTimeoutCheckInterval,
+	}
+}
+
+// parseSessionTimeout parses a timeout in minutes of the session document.
+// Missing, invalid and out of range values fall back to defaultMinutes, 0 meaning no timeout.
+func parseSessionTimeout(log log.T, name string, value string, defaultMinutes int, minMinutes int, maxMinutes int) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Duration(defaultMinutes) * time.Minute
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < minMinutes || minutes > maxMinutes {
+		log.Warnf("Invalid %s %q, expected minutes between %d and %d. Using %d minutes", name, value, minMinutes, maxMinutes, defaultMinutes)
+		return time.Duration(defaultMinutes) * time.Minute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// inputStreamMessageHandler returns handler recording the messages received from the client as activity.
+func (t *sessionTimer) inputStreamMessageHandler(handler datachannel.InputStreamMessageHandler) datachannel.InputStreamMessageHandler {
+	return func(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
+		t.recordActivity()
+		return handler(log, streamDataMessage)
+	}
+}
+
+// recordActivity restarts the idle timeout.
+func (t *sessionTimer) recordActivity() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lastActivity = t.now()
+	t.idleWarned = false
+}
+
+// terminationReason returns the timeout that terminated the session, empty while no timeout expired.
+func (t *sessionTimer) terminationReason() mgsContracts.TerminationReason {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.reason
+}
+
+// check returns the message to send to the client, if any, and the termination reason once a timeout expired.
+// The client is warned once per timeout, SessionTimeoutWarningPeriod before it expires.
+func (t *sessionTimer) check() (message string, reason mgsContracts.TerminationReason) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.reason != "" {
+		return "", t.reason
+	}
+	now := t.now()
+	if t.maxDuration > 0 {
+		remaining := t.start.Add(t.maxDuration).Sub(now)
+		if remaining <= 0 {
+			t.reason = mgsContracts.MaxSessionDuration
+			return mgsConfig.MaxSessionDurationMsg, t.reason
+		}
+		if !t.durationWarned && remaining <= mgsConfig.SessionTimeoutWarningPeriod {
+			t.durationWarned = true
+			return fmt.Sprintf(mgsConfig.MaxSessionDurationWarningMsg, seconds(remaining)), ""
+		}
+	}
+	if t.idleTimeout > 0 {
+		remaining := t.lastActivity.Add(t.idleTimeout).Sub(now)
+		if remaining <= 0 {
+			t.reason = mgsContracts.IdleSessionTimeout
+			return mgsConfig.IdleSessionTimeoutMsg, t.reason
+		}
+		if !t.idleWarned && remaining <= mgsConfig.SessionTimeoutWarningPeriod {
+			t.idleWarned = true
+			return fmt.Sprintf(mgsConfig.IdleSessionTimeoutWarningMsg, seconds(remaining)), ""
+		}
+	}
+	return "", ""
+}
+
+// run checks the timeouts until done is closed, sends the warnings to the client with notify and
+// cancels the session once a timeout expired.
+func (t *sessionTimer) run(log log.T, cancelFlag task.CancelFlag, notify func(message string), done <-chan struct{}) {
+	ticker := time.NewTicker(t.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			message, reason := t.check()
+			if message != "" {
+				notify(message)
+			}
+			if reason != "" {
+				log.Infof("Terminating session, reason: %s", reason)
+				cancelFlag.Set(task.Canceled)
+				return
+			}
+		}
+	}
+}
+
+// seconds returns d rounded to the second.
+func seconds(d time.Duration) int {
+	return int((d + time.Second/2) / time.Second)
+}
+
+// sessionOutput keeps the output set by the session plugin so that the termination reason can be added to it.
+type sessionOutput struct {
+	iohandler.IOHandler
+	output interface{}
+}
+
+// SetOutput sets the output
+func (o *sessionOutput) SetOutput(output interface{}) {
+	o.output = output
+	o.IOHandler.SetOutput(output)
+}
+
+// setTerminationReason adds the reason the agent terminated the session to the output reported to MGS.
+func (o *sessionOutput) setTerminationReason(reason mgsContracts.TerminationReason) {
+	result, _ := o.output.(mgsContracts.SessionPluginResultOutput)
+	result.TerminationReason = reason
+	o.SetOutput(result)
+}
+
+// setMetrics adds the data channel metrics to the output reported to MGS.
+// Plugins which set no output report their stdout and stderr, which are kept.
+func (o *sessionOutput) setMetrics(metrics mgsContracts.SessionMetrics) {
+	result, ok := o.output.(mgsContracts.SessionPluginResultOutput)
+	if !ok {
+		if o.output != nil {
+			return
+		}
+		result.Output = o.IOHandler.String()
+	}
+	result.Metrics = &metrics
+	o.SetOutput(result)
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessionplugin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSessionTimeout(t *testing.T) {
+	mockLog := log.NewMockLog()
+	for _, test := range []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 20 * time.Minute},
+		{" 30 ", 30 * time.Minute},
+		{"1", time.Minute},
+		{"60", 60 * time.Minute},
+		{"0", 20 * time.Minute},
+		{"61", 20 * time.Minute},
+		{"ten", 20 * time.Minute},
+	} {
+		assert.Equal(t, test.expected, parseSessionTimeout(mockLog, "idle session timeout", test.value,
+			mgsConfig.DefaultIdleSessionTimeoutMinutes, mgsConfig.MinIdleSessionTimeoutMinutes, mgsConfig.MaxIdleSessionTimeoutMinutes), test.value)
+	}
+	assert.Equal(t, time.Duration(0), parseSessionTimeout(mockLog, "maximum session duration", "",
+		0, mgsConfig.MinSessionDurationMinutes, mgsConfig.MaxSessionDurationMinutes))
+}
+
+func TestSessionTimerIdleTimeout(t *testing.T) {
+	start := time.Unix(1540000000, 0)
+	current := start
+	timer := newSessionTimerWithClock(5*time.Minute, 0, func() time.Time { return current })
+
+	current = start.Add(3 * time.Minute)
+	message, reason := timer.check()
+	assert.Equal(t, "", message)
+	assert.Equal(t, mgsContracts.TerminationReason(""), reason)
+
+	current = start.Add(4*time.Minute + 30*time.Second)
+	message, reason = timer.check()
+	assert.Equal(t, fmt.Sprintf(mgsConfig.IdleSessionTimeoutWarningMsg, 30), message)
+	assert.Equal(t, mgsContracts.TerminationReason(""), reason)
+
+	// the client is warned once
+	message, _ = timer.check()
+	assert.Equal(t, "", message)
+
+	// activity restarts the timeout and rearms the warning
+	timer.recordActivity()
+	current = start.Add(8 * time.Minute)
+	message, reason = timer.check()
+	assert.Equal(t, "", message)
+	current = start.Add(9 * time.Minute)
+	message, _ = timer.check()
+	assert.Equal(t, fmt.Sprintf(mgsConfig.IdleSessionTimeoutWarningMsg, 30), message)
+
+	current = start.Add(9*time.Minute + 30*time.Second)
+	message, reason = timer.check()
+	assert.Equal(t, mgsConfig.IdleSessionTimeoutMsg, message)
+	assert.Equal(t, mgsContracts.IdleSessionTimeout, reason)
+	assert.Equal(t, mgsContracts.IdleSessionTimeout, timer.terminationReason())
+
+	// the session is terminated once
+	message, reason = timer.check()
+	assert.Equal(t, "", message)
+	assert.Equal(t, mgsContracts.IdleSessionTimeout, reason)
+}
+
+func TestSessionTimerMaxDuration(t *testing.T) {
+	start := time.Unix(1540000000, 0)
+	current := start
+	timer := newSessionTimerWithClock(5*time.Minute, 10*time.Minute, func() time.Time { return current })
+
+	for elapsed := time.Duration(0); elapsed < 9*time.Minute; elapsed += time.Minute {
+		current = start.Add(elapsed)
+		timer.recordActivity()
+		message, reason := timer.check()
+		assert.Equal(t, "", message)
+		assert.Equal(t, mgsContracts.TerminationReason(""), reason)
+	}
+
+	current = start.Add(9 * time.Minute)
+	message, _ := timer.check()
+	assert.Equal(t, fmt.Sprintf(mgsConfig.MaxSessionDurationWarningMsg, 60), message)
+
+	// activity does not extend the maximum duration
+	timer.recordActivity()
+	current = start.Add(10 * time.Minute)
+	message, reason := timer.check()
+	assert.Equal(t, mgsConfig.MaxSessionDurationMsg, message)
+	assert.Equal(t, mgsContracts.MaxSessionDuration, reason)
+}
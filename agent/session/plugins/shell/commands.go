@@ -0,0 +1,185 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+)
+
+// commandsProcess is the process running the commands of a non-interactive session.
+type commandsProcess interface {
+	// Wait waits for the commands to exit and returns their exit code.
+	Wait() (exitCode int, err error)
+	// Kill stops the commands.
+	Kill() error
+}
+
+// execCommandsProcess is a commandsProcess started with os/exec.
+type execCommandsProcess struct {
+	cmd *exec.Cmd
+}
+
+// Wait waits for the commands to exit and returns their exit code.
+func (p *execCommandsProcess) Wait() (int, error) {
+	err := p.cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return appconfig.ErrorExitCode, err
+	}
+	return appconfig.SuccessExitCode, nil
+}
+
+// Kill stops the commands.
+func (p *execCommandsProcess) Kill() error {
+	return p.cmd.Process.Kill()
+}
+
+// startCommandsProcess starts cmd with its output written to the returned stdout.
+// The error of cmd is written to the returned stderr when separateStderr is set, to stdout otherwise.
+func startCommandsProcess(cmd *exec.Cmd, separateStderr bool) (process commandsProcess, stdout *os.File, stderr *os.File, err error) {
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stdoutWriter
+	var stderrWriter *os.File
+	if separateStderr {
+		if stderr, stderrWriter, err = os.Pipe(); err != nil {
+			stdout.Close()
+			stdoutWriter.Close()
+			return nil, nil, nil, err
+		}
+		cmd.Stderr = stderrWriter
+	}
+	err = cmd.Start()
+	// The commands hold their own handles of the pipes, closing ours ends reads once they exit.
+	stdoutWriter.Close()
+	if stderrWriter != nil {
+		stderrWriter.Close()
+	}
+	if err != nil {
+		stdout.Close()
+		if stderr != nil {
+			stderr.Close()
+		}
+		return nil, nil, nil, err
+	}
+	return &execCommandsProcess{cmd: cmd}, stdout, stderr, nil
+}
+
+// errorPump reads from the stderr of the commands and sends it to the client as Error payloads.
+func (p *ShellPlugin) errorPump(log log.T, file *os.File) {
+	stderrBytes := make([]byte, mgsConfig.StreamDataPayloadSize)
+	var unprocessedBuf bytes.Buffer
+	for {
+		stderrBytesLen, err := p.stderr.Read(stderrBytes)
+		if err != nil {
+			log.Debugf("Failed to read from stderr: %s", err)
+			return
+		}
+		if stderrBytesLen = p.limitOutput(stderrBytesLen); stderrBytesLen == 0 {
+			continue
+		}
+		if unprocessedBuf, err = p.processOutputData(log, mgsContracts.Error, stderrBytes, stderrBytesLen, unprocessedBuf, file); err != nil {
+			log.Errorf("Error processing stderr data, %v", err)
+			return
+		}
+	}
+}
+
+// tagStderr prefixes the lines of stderr output with stderrLogPrefix so that session logs tell errors from normal output.
+func (p *ShellPlugin) tagStderr(data []byte) []byte {
+	var tagged bytes.Buffer
+	for len(data) > 0 {
+		if !p.stderrMidLine {
+			tagged.WriteString(stderrLogPrefix)
+		}
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			tagged.Write(data)
+			p.stderrMidLine = true
+			break
+		}
+		tagged.Write(data[:i+1])
+		data = data[i+1:]
+		p.stderrMidLine = false
+	}
+	return tagged.Bytes()
+}
+
+// limitOutput returns how many of the stdoutBytesLen bytes read from the commands are streamed to the client.
+func (p *ShellPlugin) limitOutput(stdoutBytesLen int) int {
+	p.outputLock.Lock()
+	defer p.outputLock.Unlock()
+	if remaining := commandOutputLimit - p.outputLength; stdoutBytesLen > remaining {
+		if remaining < 0 {
+			remaining = 0
+		}
+		stdoutBytesLen = remaining
+		p.outputTruncated = true
+	}
+	p.outputLength += stdoutBytesLen
+	return stdoutBytesLen
+}
+
+// sendCommandResult waits for the commands to exit and streams their result to the client.
+func (p *ShellPlugin) sendCommandResult(log log.T) {
+	exitCode, err := p.process.Wait()
+	if err != nil {
+		log.Errorf("Failed to wait for commands: %s", err)
+	}
+	p.commandResult = &mgsContracts.CommandResultPayload{
+		ExitCode:        exitCode,
+		DurationMillis:  int64(time.Since(p.startTime) / time.Millisecond),
+		OutputTruncated: p.outputTruncated,
+	}
+	log.Infof("Commands exited with code %d after %d ms", exitCode, p.commandResult.DurationMillis)
+
+	resultBytes, err := json.Marshal(p.commandResult)
+	if err != nil {
+		log.Errorf("Unable to serialize command result: %s", err)
+		return
+	}
+	if err = p.dataChannel.SendStreamDataMessage(log, mgsContracts.CommandResult, resultBytes); err != nil {
+		log.Errorf("Unable to send command result: %s", err)
+	}
+}
+
+// stopCommands stops the commands when they still run and closes their output.
+func (p *ShellPlugin) stopCommands(log log.T) {
+	if p.process != nil && p.commandResult == nil {
+		log.Info("Stopping commands")
+		if err := p.process.Kill(); err != nil {
+			log.Debugf("Unable to stop commands: %s", err)
+		}
+	}
+	if p.stdout != nil {
+		p.stdout.Close()
+	}
+	if p.stderr != nil {
+		p.stderr.Close()
+	}
+}
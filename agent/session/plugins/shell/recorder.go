@@ -0,0 +1,130 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package shell implements session shell plugin.
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	asciicastVersion = 2
+
+	// asciicast event types, input is never recorded as it may contain secrets typed by the user.
+	asciicastOutputEvent = "o"
+	asciicastResizeEvent = "r"
+)
+
+// asciicastHeader is the first line of an asciinema v2 recording.
+// https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     uint32 `json:"width"`
+	Height    uint32 `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// sessionRecorder records the output of a shell session as an asciinema v2 recording,
+// one JSON event per line with the time elapsed since the start of the session.
+type sessionRecorder struct {
+	lock  sync.Mutex
+	file  *os.File
+	start time.Time
+	now   func() time.Time
+}
+
+// newSessionRecorder creates the recording file and writes its header.
+func newSessionRecorder(filePath string, title string, width uint32, height uint32) (*sessionRecorder, error) {
+	return newSessionRecorderWithClock(filePath, title, width, height, time.Now)
+}
+
+func newSessionRecorderWithClock(filePath string, title string, width uint32, height uint32, now func() time.Time) (*sessionRecorder, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session recording %s: %v", filePath, err)
+	}
+
+	recorder := &sessionRecorder{
+		file:  file,
+		start: now(),
+		now:   now,
+	}
+	header := asciicastHeader{
+		Version:   asciicastVersion,
+		Width:     width,
+		Height:    height,
+		Timestamp: recorder.start.Unix(),
+		Title:     title,
+	}
+	if err = recorder.writeLine(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// RecordOutput records data written by the shell, data must hold complete utf8 encoded characters.
+func (r *sessionRecorder) RecordOutput(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return r.recordEvent(asciicastOutputEvent, string(data))
+}
+
+// RecordResize records a change of the terminal size.
+func (r *sessionRecorder) RecordResize(cols uint32, rows uint32) error {
+	return r.recordEvent(asciicastResizeEvent, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close closes the recording file, events recorded afterwards are dropped.
+func (r *sessionRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// recordEvent appends an event to the recording.
+func (r *sessionRecorder) recordEvent(eventType string, data string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	elapsed := r.now().Sub(r.start).Seconds()
+	return r.writeLine([]interface{}{elapsed, eventType, data})
+}
+
+// writeLine writes value as a single line of JSON.
+func (r *sessionRecorder) writeLine(value interface{}) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err = r.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write session recording: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package shell implements session shell plugin.
+package shell
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "recording")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "session.cast")
+
+	start := time.Unix(1540000000, 0)
+	current := start
+	recorder, err := newSessionRecorderWithClock(filePath, "Session s-1", 80, 24, func() time.Time { return current })
+	assert.Nil(t, err)
+
+	current = start.Add(1500 * time.Millisecond)
+	assert.Nil(t, recorder.RecordOutput([]byte("$ ls\r\n")))
+	assert.Nil(t, recorder.RecordOutput([]byte{}))
+	current = start.Add(2 * time.Second)
+	assert.Nil(t, recorder.RecordResize(120, 40))
+	assert.Nil(t, recorder.Close())
+
+	// events recorded after close are dropped
+	assert.Nil(t, recorder.RecordOutput([]byte("late")))
+	assert.Nil(t, recorder.Close())
+
+	content, err := ioutil.ReadFile(filePath)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 3, len(lines))
+
+	var header asciicastHeader
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, asciicastHeader{Version: 2, Width: 80, Height: 24, Timestamp: 1540000000, Title: "Session s-1"}, header)
+
+	var event []interface{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, []interface{}{1.5, "o", "$ ls\r\n"}, event)
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), &event))
+	assert.Equal(t, []interface{}{2.0, "r", "120x40"}, event)
+}
+
+func TestSessionRecorderInvalidPath(t *testing.T) {
+	_, err := newSessionRecorder(filepath.Join("missing", "dir", "session.cast"), "", 80, 24)
+	assert.NotNil(t, err)
+}
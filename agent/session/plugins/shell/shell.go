@@ -22,6 +22,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -33,6 +36,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
@@ -44,32 +48,117 @@ import (
 var ShellPluginCommandName = "sh"
 var ShellPluginCommandArgs = []string{"-c"}
 
+// FeatureTerminalType is announced in the handshake: the shell starts with the terminal type the client sends in its first SizeData.
+const FeatureTerminalType = "terminalType"
+
+// FeatureStderr is announced in the handshake of non-interactive sessions: what the commands write to stderr is sent
+// as Error payloads and tagged with stderrLogPrefix in the session log. Pseudo terminals merge stdout and stderr
+// so that interactive sessions can't tell them apart.
+const FeatureStderr = "stderr"
+
+// stderrLogPrefix starts the lines the commands write to stderr in the session log.
+const stderrLogPrefix = "[stderr] "
+
+// runAsNamePattern matches the user and group names accepted from session documents.
+// Names may be domain qualified and end with $, as Windows group managed service accounts do.
+// Names never start with - so that they can't be taken for command line options.
+var runAsNamePattern = regexp.MustCompile(`^([A-Za-z0-9._][A-Za-z0-9._-]*\\)?[A-Za-z0-9._][A-Za-z0-9._-]*\$?$`)
+
+// privilegedGroups lists the groups a session document can't add the runas user to,
+// since their members can become root or Administrator.
+var privilegedGroups = map[string]bool{
+	"root":           true,
+	"wheel":          true,
+	"sudo":           true,
+	"admin":          true,
+	"adm":            true,
+	"shadow":         true,
+	"disk":           true,
+	"docker":         true,
+	"lxd":            true,
+	"administrators": true,
+}
+
 // Plugin is the type for the plugin.
 type ShellPlugin struct {
+	pluginName  string
+	commands    string
 	stdin       *os.File
 	stdout      *os.File
 	ipcFilePath string
 	logFilePath string
 	dataChannel datachannel.IDataChannel
+	auditLogger audit.Logger
+	recorder    *sessionRecorder
+	terminal    terminalState
+
+	// Non-interactive sessions run the commands without a pty and report their result.
+	nonInteractive  bool
+	process         commandsProcess
+	stderr          *os.File
+	stderrMidLine   bool
+	outputLock      sync.Mutex
+	startTime       time.Time
+	outputLength    int
+	outputTruncated bool
+	commandResult   *mgsContracts.CommandResultPayload
 }
 
 // NewPlugin returns a new instance of the Shell Plugin
 func NewPlugin() (sessionplugin.ISessionPlugin, error) {
-	var plugin = ShellPlugin{}
+	var plugin = ShellPlugin{
+		pluginName:  appconfig.PluginNameStandardStream,
+		auditLogger: audit.Default(),
+	}
 	return &plugin, nil
 }
 
+// NewCommandsPlugin returns a shell plugin running commands in the pseudo terminal instead of an interactive shell.
+// The session ends when the commands exit.
+func NewCommandsPlugin(pluginName string, commands string) *ShellPlugin {
+	return &ShellPlugin{
+		pluginName:  pluginName,
+		commands:    commands,
+		auditLogger: audit.Default(),
+	}
+}
+
+// NewNonInteractiveCommandsPlugin returns a shell plugin running commands without a pseudo terminal.
+// The session ends when the commands exit, after their exit code is streamed to the client as a CommandResult message.
+func NewNonInteractiveCommandsPlugin(pluginName string, commands string) *ShellPlugin {
+	return &ShellPlugin{
+		pluginName:     pluginName,
+		commands:       commands,
+		auditLogger:    audit.Default(),
+		nonInteractive: true,
+	}
+}
+
+// PluginFeatures returns the optional features of the shell.
+func (p *ShellPlugin) PluginFeatures() []string {
+	if p.nonInteractive {
+		return []string{FeatureStderr}
+	}
+	return []string{FeatureTerminalType}
+}
+
 // name returns the name of Shell Plugin
 func (p *ShellPlugin) name() string {
-	return appconfig.PluginNameStandardStream
+	return p.pluginName
 }
 
-// validate validates the cloudwatch and s3 encryption configuration.
+// validate validates the runas user, cloudwatch and s3 encryption configuration.
 func (p *ShellPlugin) validate(context context.T,
 	config agentContracts.Configuration,
 	cwl cloudwatchlogsinterface.ICloudWatchLogsService,
 	s3Util s3util.IAmazonS3Util) error {
 
+	if config.RunAsEnabled {
+		if err := validateRunAs(config); err != nil {
+			return err
+		}
+	}
+
 	if config.CloudWatchLogGroup != "" && config.CloudWatchEncryptionEnabled {
 		if encrypted := cwl.IsLogGroupEncryptedWithKMS(context.Log(), config.CloudWatchLogGroup); !encrypted {
 			return errors.New(mgsConfig.CloudWatchEncryptionErrorMsg)
@@ -84,6 +173,36 @@ func (p *ShellPlugin) validate(context context.T,
 	return nil
 }
 
+// validateRunAs validates the runas user and groups of the session document.
+// The names end up in OS commands that create the user so only a conservative character set is accepted.
+func validateRunAs(config agentContracts.Configuration) error {
+	runAsUser := strings.TrimSpace(config.RunAsUser)
+	if runAsUser == "" {
+		return errors.New(mgsConfig.RunAsUserEmptyErrorMsg)
+	}
+	if !runAsNamePattern.MatchString(runAsUser) {
+		return fmt.Errorf("invalid RunAs user name %q", runAsUser)
+	}
+	for _, group := range config.RunAsGroups {
+		if !runAsNamePattern.MatchString(group) {
+			return fmt.Errorf("invalid RunAs group name %q", group)
+		}
+		name := group[strings.LastIndex(group, `\`)+1:]
+		if privilegedGroups[strings.ToLower(name)] {
+			return fmt.Errorf("RunAs group %q is not allowed", group)
+		}
+	}
+	return nil
+}
+
+// getRunAsUser returns the OS user the session shell runs as, ssm-user unless the document enables RunAs.
+func getRunAsUser(config agentContracts.Configuration) string {
+	if config.RunAsEnabled {
+		return strings.TrimSpace(config.RunAsUser)
+	}
+	return appconfig.DefaultRunAsUserName
+}
+
 // Execute starts pseudo terminal.
 // It reads incoming message from data channel and writes to pty.stdin.
 // It reads message from pty.stdout and writes to data channel
@@ -96,7 +215,9 @@ func (p *ShellPlugin) Execute(context context.T,
 	log := context.Log()
 	p.dataChannel = dataChannel
 	defer func() {
-		if err := Stop(log); err != nil {
+		if p.nonInteractive {
+			p.stopCommands(log)
+		} else if err := Stop(log); err != nil {
 			log.Errorf("Error occured while closing pty: %v", err)
 		}
 		if err := recover(); err != nil {
@@ -115,8 +236,20 @@ func (p *ShellPlugin) Execute(context context.T,
 	}
 }
 
-var startPty = func(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File, err error) {
-	return StartPty(log, isSessionShell)
+var startPty = func(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
+	return StartPty(log, isSessionShell, runAsUser, commands, terminal)
+}
+
+var startCommands = func(log log.T, runAsUser string, commands string, separateStderr bool) (process commandsProcess, stdout *os.File, stderr *os.File, err error) {
+	return StartCommands(log, runAsUser, commands, separateStderr)
+}
+
+var terminalSetupTimeout = mgsConfig.TerminalSetupTimeout
+
+var commandOutputLimit = mgsConfig.CommandOutputLimit
+
+var createRunAsUserIfMissing = func(log log.T, runAsUser string, groups []string) error {
+	return CreateRunAsUserIfMissing(log, runAsUser, groups)
 }
 
 // execute starts pseudo terminal.
@@ -144,17 +277,52 @@ func (p *ShellPlugin) execute(context context.T,
 		output.SetStatus(agentContracts.ResultStatusFailed)
 		sessionPluginResultOutput.Output = err.Error()
 		output.SetOutput(sessionPluginResultOutput)
-		log.Errorf("Encryption validation failed, err: %s", err)
+		log.Errorf("Session validation failed, err: %s", err)
 		return
 	}
 
-	p.stdin, p.stdout, err = startPty(log, true)
+	runAsUser := getRunAsUser(config)
+	if config.RunAsEnabled && config.RunAsCreateUser {
+		if err = createRunAsUserIfMissing(log, runAsUser, config.RunAsGroups); err != nil {
+			errorString := fmt.Errorf("Unable to create RunAs user %s: %s", runAsUser, err)
+			log.Error(errorString)
+			output.MarkAsFailed(errorString)
+			return
+		}
+	}
+
+	var terminal mgsContracts.SizeData
+	if p.nonInteractive {
+		p.startTime = time.Now()
+		separateStderr := p.dataChannel.IsPluginFeatureEnabled(FeatureStderr)
+		p.process, p.stdout, p.stderr, err = startCommands(log, runAsUser, p.commands, separateStderr)
+	} else {
+		// Clients send their terminal type and size as soon as the session is established, starting the shell
+		// with them spares full screen programs from rendering for a wrong terminal first.
+		p.terminal.waitForInitialSize(log, terminalSetupTimeout)
+		terminal = p.terminal.terminal()
+		p.stdin, p.stdout, err = startPty(log, true, runAsUser, p.commands, terminal)
+	}
 	if err != nil {
 		errorString := fmt.Errorf("Unable to start shell: %s", err)
 		log.Error(errorString)
 		output.MarkAsFailed(errorString)
 		return
 	}
+	log.Infof("Shell for session %s started as %s", config.SessionId, runAsUser)
+	p.auditRunAsUser(log, config, runAsUser)
+
+	recordingFilePath := filepath.Join(config.OrchestrationDirectory, config.SessionId+mgsConfig.RecordingFileExtension)
+	if config.RecordingEnabled {
+		p.startRecording(log, config, recordingFilePath, terminal)
+	}
+
+	// Apply the size the client may have sent while the shell was starting.
+	if size := p.terminal.setStarted(); !p.nonInteractive && (size.Cols != terminal.Cols || size.Rows != terminal.Rows) {
+		if err = p.resize(log, size); err != nil {
+			log.Warn(err)
+		}
+	}
 
 	// Generate ipc file path
 	p.ipcFilePath = filepath.Join(config.OrchestrationDirectory, mgsConfig.IpcFileName+mgsConfig.LogFileExtension)
@@ -190,7 +358,16 @@ func (p *ShellPlugin) execute(context context.T,
 		log.Info("The session was cancelled")
 
 	case exitCode := <-done:
-		if exitCode == 1 {
+		if p.commandResult != nil {
+			// Non-interactive sessions succeed when their commands do.
+			output.SetExitCode(p.commandResult.ExitCode)
+			if p.commandResult.ExitCode == appconfig.SuccessExitCode {
+				output.SetStatus(agentContracts.ResultStatusSuccess)
+			} else {
+				output.SetStatus(agentContracts.ResultStatusFailed)
+			}
+			sessionPluginResultOutput.CommandResult = p.commandResult
+		} else if exitCode == 1 {
 			output.SetExitCode(appconfig.ErrorExitCode)
 			output.SetStatus(agentContracts.ResultStatusFailed)
 		} else {
@@ -212,6 +389,9 @@ func (p *ShellPlugin) execute(context context.T,
 			output.MarkAsFailed(errorString)
 			return
 		}
+		if config.MetricsLoggingEnabled {
+			p.appendMetricsToLog(log)
+		}
 
 		log.Debug("Starting S3 logging")
 		if config.OutputS3BucketName != "" {
@@ -228,11 +408,95 @@ func (p *ShellPlugin) execute(context context.T,
 			sessionPluginResultOutput.CwlStream = config.SessionId
 		}
 	}
+
+	if p.recorder != nil {
+		p.uploadSessionRecording(log, config, s3Util, cwl, recordingFilePath)
+	}
 	output.SetOutput(sessionPluginResultOutput)
 
 	log.Debug("Shell session execution complete")
 }
 
+// appendMetricsToLog appends the data channel metrics of the session to the session log.
+func (p *ShellPlugin) appendMetricsToLog(log log.T) {
+	logFile, err := os.OpenFile(p.logFilePath, os.O_APPEND|os.O_WRONLY, appconfig.ReadWriteAccess)
+	if err != nil {
+		log.Errorf("Unable to open session log to append metrics: %v", err)
+		return
+	}
+	defer logFile.Close()
+	if _, err = fmt.Fprintf(logFile, "\nSession metrics: %s\n", p.dataChannel.GetMetrics()); err != nil {
+		log.Errorf("Unable to append metrics to session log: %v", err)
+	}
+}
+
+// auditRunAsUser records the effective user of the session shell in the audit log.
+func (p *ShellPlugin) auditRunAsUser(log log.T, config agentContracts.Configuration, runAsUser string) {
+	if p.auditLogger == nil {
+		return
+	}
+	entry := audit.Entry{
+		Event:        audit.SessionShellStarted,
+		DocumentType: string(agentContracts.StartSession),
+		SessionID:    config.SessionId,
+		Requester:    config.ClientId,
+		RunAsUser:    runAsUser,
+	}
+	if err := p.auditLogger.Log(entry); err != nil {
+		log.Warnf("failed to write audit log entry %v: %v", entry.Event, err)
+	}
+}
+
+// startRecording starts recording the session output, failing to record does not fail the session.
+// The recording starts with the terminal size of the client when known.
+func (p *ShellPlugin) startRecording(log log.T, config agentContracts.Configuration, recordingFilePath string, terminal mgsContracts.SizeData) {
+	if config.OutputS3BucketName == "" && config.CloudWatchLogGroup == "" {
+		log.Warnf("Session recording is enabled but neither S3 nor CloudWatch logging is configured, session %s is not recorded", config.SessionId)
+		return
+	}
+
+	width, height := uint32(mgsConfig.RecordingDefaultWidth), uint32(mgsConfig.RecordingDefaultHeight)
+	if terminal.Cols != 0 && terminal.Rows != 0 {
+		width, height = terminal.Cols, terminal.Rows
+	}
+	recorder, err := newSessionRecorder(recordingFilePath,
+		fmt.Sprintf("Session %s", config.SessionId),
+		width,
+		height)
+	if err != nil {
+		log.Errorf("Unable to record session %s: %s", config.SessionId, err)
+		return
+	}
+	log.Debugf("Recording session %s at %s", config.SessionId, recordingFilePath)
+	p.recorder = recorder
+}
+
+// uploadSessionRecording closes the session recording and uploads it to the configured S3 bucket and CloudWatch log group.
+func (p *ShellPlugin) uploadSessionRecording(log log.T,
+	config agentContracts.Configuration,
+	s3Util s3util.IAmazonS3Util,
+	cwl cloudwatchlogsinterface.ICloudWatchLogsService,
+	recordingFilePath string) {
+
+	if err := p.recorder.Close(); err != nil {
+		log.Errorf("Failed to close session recording: %s", err)
+	}
+
+	if config.OutputS3BucketName != "" {
+		s3Key := fileutil.BuildS3Path(config.OutputS3KeyPrefix, config.SessionId+mgsConfig.RecordingFileExtension)
+		log.Debugf("Uploading session recording to S3 bucket %s and key %s", config.OutputS3BucketName, s3Key)
+		if err := s3Util.S3Upload(log, config.OutputS3BucketName, s3Key, recordingFilePath); err != nil {
+			log.Errorf("Failed to upload session recording to S3: %s", err)
+		}
+	}
+
+	if config.CloudWatchLogGroup != "" {
+		streamName := config.SessionId + mgsConfig.RecordingStreamSuffix
+		log.Debugf("Uploading session recording to CloudWatch log group %s and stream %s", config.CloudWatchLogGroup, streamName)
+		cwl.StreamData(log, config.CloudWatchLogGroup, streamName, recordingFilePath, true, false)
+	}
+}
+
 // uploadShellSessionLogsToS3 uploads shell session logs to S3 bucket specified.
 func (p *ShellPlugin) uploadShellSessionLogsToS3(log log.T, s3UploaderUtil s3util.IAmazonS3Util, config agentContracts.Configuration, s3KeyPrefix string) {
 	log.Debugf("Preparing to upload session logs to S3 bucket %s and prefix %s", config.OutputS3BucketName, s3KeyPrefix)
@@ -265,18 +529,39 @@ func (p *ShellPlugin) writePump(log log.T) (errorCode int) {
 	// Wait for all input commands to run.
 	time.Sleep(time.Second)
 
+	errorsDone := make(chan struct{})
+	if p.stderr != nil {
+		go func() {
+			defer close(errorsDone)
+			p.errorPump(log, file)
+		}()
+	} else {
+		close(errorsDone)
+	}
+
 	var unprocessedBuf bytes.Buffer
 	for {
 		stdoutBytesLen, err := reader.Read(stdoutBytes)
 		if err != nil {
 			// Terminating session
 			log.Debugf("Failed to read from pty master: %s", err)
+			if p.nonInteractive {
+				<-errorsDone
+				p.sendCommandResult(log)
+			}
 			if err = p.dataChannel.SendAgentSessionStateMessage(log, mgsContracts.Terminating); err != nil {
 				log.Errorf("Unable to send AgentSessionState message with session status %s. %v", mgsContracts.Terminating, err)
 			}
 			return appconfig.SuccessExitCode
 		}
 
+		if p.nonInteractive {
+			// Output beyond the limit is read so that the commands don't block but is not streamed.
+			if stdoutBytesLen = p.limitOutput(stdoutBytesLen); stdoutBytesLen == 0 {
+				continue
+			}
+		}
+
 		// unprocessedBuf contains incomplete utf8 encoded unicode bytes returned after processing of stdoutBytes
 		if unprocessedBuf, err = p.processStdoutData(log, stdoutBytes, stdoutBytesLen, unprocessedBuf, file); err != nil {
 			log.Errorf("Error processing stdout data, %v", err)
@@ -293,6 +578,19 @@ func (p *ShellPlugin) processStdoutData(
 	unprocessedBuf bytes.Buffer,
 	file *os.File) (bytes.Buffer, error) {
 
+	return p.processOutputData(log, mgsContracts.Output, stdoutBytes, stdoutBytesLen, unprocessedBuf, file)
+}
+
+// processOutputData reads utf8 encoded unicode characters from stdoutBytes and sends them over websocket channel as payloadType.
+// Error payloads are tagged in the session log.
+func (p *ShellPlugin) processOutputData(
+	log log.T,
+	payloadType mgsContracts.PayloadType,
+	stdoutBytes []byte,
+	stdoutBytesLen int,
+	unprocessedBuf bytes.Buffer,
+	file *os.File) (bytes.Buffer, error) {
+
 	// append stdoutBytes to unprocessedBytes and then read rune from appended bytes to send it over websocket channel
 	unprocessedBytes := unprocessedBuf.Bytes()
 	unprocessedBytes = append(unprocessedBytes[:], stdoutBytes[:stdoutBytesLen]...)
@@ -315,14 +613,28 @@ func (p *ShellPlugin) processStdoutData(
 		processedBuf.WriteRune(stdoutRune)
 	}
 
-	if err := p.dataChannel.SendStreamDataMessage(log, mgsContracts.Output, processedBuf.Bytes()); err != nil {
+	// stdout and stderr are processed concurrently by non-interactive sessions.
+	p.outputLock.Lock()
+	defer p.outputLock.Unlock()
+
+	if err := p.dataChannel.SendStreamDataMessage(log, payloadType, processedBuf.Bytes()); err != nil {
 		return processedBuf, fmt.Errorf("unable to send stream data message: %s", err)
 	}
 
-	if _, err := file.Write(processedBuf.Bytes()); err != nil {
+	logBytes := processedBuf.Bytes()
+	if payloadType == mgsContracts.Error {
+		logBytes = p.tagStderr(logBytes)
+	}
+	if _, err := file.Write(logBytes); err != nil {
 		return processedBuf, fmt.Errorf("encountered an error while writing to file: %s", err)
 	}
 
+	if p.recorder != nil {
+		if err := p.recorder.RecordOutput(processedBuf.Bytes()); err != nil {
+			log.Warnf("Unable to record session output: %s", err)
+		}
+	}
+
 	// return incomplete utf8 encoded unicode bytes to be processed with next batch of stdoutBytes
 	unprocessedBuf.Reset()
 	if i < unprocessedBytesLen {
@@ -332,16 +644,20 @@ func (p *ShellPlugin) processStdoutData(
 }
 
 // InputStreamMessageHandler passes payload byte stream to shell stdin
+// and applies the terminal size of the client to the pty.
 func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error {
-	if p.stdin == nil || p.stdout == nil {
-		// This is to handle scenario when cli/console starts sending size data but pty has not been started yet
-		// Since packets are rejected, cli/console will resend these packets until pty starts successfully in separate thread
-		log.Tracef("Pty unavailable. Reject incoming message packet")
+	if p.nonInteractive {
+		// Non-interactive commands have neither input nor terminal.
+		log.Tracef("Ignoring incoming message packet of non-interactive session")
 		return nil
 	}
-
 	switch mgsContracts.PayloadType(streamDataMessage.PayloadType) {
 	case mgsContracts.Output:
+		if p.stdin == nil || p.stdout == nil {
+			// This is to handle scenario when cli/console starts sending input but pty has not been started yet
+			log.Tracef("Pty unavailable. Reject incoming message packet")
+			return nil
+		}
 		log.Tracef("Output message received: %d", streamDataMessage.SequenceNumber)
 		if _, err := p.stdin.Write(streamDataMessage.Payload); err != nil {
 			log.Errorf("Unable to write to stdin, err: %v.", err)
@@ -353,10 +669,26 @@ func (p *ShellPlugin) InputStreamMessageHandler(log log.T, streamDataMessage mgs
 			log.Errorf("Invalid size message: %s", err)
 			return err
 		}
-		log.Tracef("Resize data received: cols: %d, rows: %d", size.Cols, size.Rows)
-		if err := SetSize(log, size.Cols, size.Rows); err != nil {
-			log.Errorf("Unable to set pty size: %s", err)
-			return err
+		log.Tracef("Resize data received: cols: %d, rows: %d, term: %s", size.Cols, size.Rows, size.Term)
+		// Sizes received before the pty starts are kept until it starts.
+		if p.terminal.update(log, size) {
+			if err := p.resize(log, size); err != nil {
+				log.Error(err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resize applies the terminal size of the client to the pty and records it.
+func (p *ShellPlugin) resize(log log.T, size mgsContracts.SizeData) error {
+	if err := SetSize(log, size.Cols, size.Rows); err != nil {
+		return fmt.Errorf("Unable to set pty size: %s", err)
+	}
+	if p.recorder != nil {
+		if err := p.recorder.RecordResize(size.Cols, size.Rows); err != nil {
+			log.Warnf("Unable to record terminal size: %s", err)
 		}
 	}
 	return nil
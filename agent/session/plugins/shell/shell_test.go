@@ -16,8 +16,12 @@ package shell
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,7 +31,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/audit"
 	"github.com/aws/amazon-ssm-agent/agent/s3util"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -78,9 +84,11 @@ func (suite *ShellTestSuite) SetupTest() {
 	suite.stdin = stdin
 	suite.stdout = stdout
 	suite.plugin = &ShellPlugin{
-		stdin:  stdin,
-		stdout: stdout,
+		pluginName: appconfig.PluginNameStandardStream,
+		stdin:      stdin,
+		stdout:     stdout,
 	}
+	terminalSetupTimeout = 10 * time.Millisecond
 }
 
 func (suite *ShellTestSuite) TearDownTest() {
@@ -136,7 +144,7 @@ func (suite *ShellTestSuite) TestExecute() {
 
 	stdout, stdin, _ := os.Pipe()
 	stdin.Write(payload)
-	startPty = func(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File, err error) {
+	startPty = func(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
 		return stdin, stdout, nil
 	}
 	plugin := &ShellPlugin{
@@ -157,6 +165,86 @@ func (suite *ShellTestSuite) TestExecute() {
 	stdout.Close()
 }
 
+// Testing Execute with a RunAs user created on demand
+func (suite *ShellTestSuite) TestExecuteWithRunAsUser() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+
+	stdout, stdin, _ := os.Pipe()
+	stdin.Write(payload)
+	var createdUser, ptyUser string
+	var createdGroups []string
+	createRunAsUserIfMissing = func(log log.T, runAsUser string, groups []string) error {
+		createdUser, createdGroups = runAsUser, groups
+		return nil
+	}
+	startPty = func(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
+		ptyUser = runAsUser
+		return stdin, stdout, nil
+	}
+	auditLogger := audit.NewMockedLogger()
+	plugin := &ShellPlugin{
+		stdout:      stdout,
+		dataChannel: suite.mockDataChannel,
+		auditLogger: auditLogger,
+	}
+
+	plugin.Execute(suite.mockContext,
+		contracts.Configuration{
+			SessionId:       "session-id",
+			ClientId:        "client-id",
+			RunAsEnabled:    true,
+			RunAsUser:       " developer ",
+			RunAsCreateUser: true,
+			RunAsGroups:     []string{"developers"},
+		},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockIohandler.AssertExpectations(suite.T())
+	assert.Equal(suite.T(), "developer", createdUser)
+	assert.Equal(suite.T(), []string{"developers"}, createdGroups)
+	assert.Equal(suite.T(), "developer", ptyUser)
+	auditLogger.AssertCalled(suite.T(), "Log", audit.Entry{
+		Event:        audit.SessionShellStarted,
+		DocumentType: string(contracts.StartSession),
+		SessionID:    "session-id",
+		Requester:    "client-id",
+		RunAsUser:    "developer",
+	})
+
+	stdin.Close()
+	stdout.Close()
+}
+
+// Testing Execute fails when the RunAs user cannot be created
+func (suite *ShellTestSuite) TestExecuteWhenRunAsUserCreationFails() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockIohandler.On("MarkAsFailed", mock.Anything).Return()
+
+	createRunAsUserIfMissing = func(log log.T, runAsUser string, groups []string) error {
+		return errors.New("useradd failed")
+	}
+	startPty = func(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
+		assert.Fail(suite.T(), "pty must not be started without the RunAs user")
+		return nil, nil, nil
+	}
+
+	suite.plugin.Execute(suite.mockContext,
+		contracts.Configuration{RunAsEnabled: true, RunAsUser: "developer", RunAsCreateUser: true},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	suite.mockIohandler.AssertExpectations(suite.T())
+}
+
 // Testing writepump separately
 func (suite *ShellTestSuite) TestWritePump() {
 	stdout, stdin, _ := os.Pipe()
@@ -206,6 +294,86 @@ func (suite *ShellTestSuite) TestProcessStdoutData() {
 	assert.Nil(suite.T(), err)
 }
 
+// TestProcessStdoutDataIsRecorded tests stdout data is added to the session recording
+func (suite *ShellTestSuite) TestProcessStdoutDataIsRecorded() {
+	stdoutBytes := []byte("$ ls\r\n")
+	file, _ := ioutil.TempFile("/tmp", "file")
+	defer os.Remove(file.Name())
+	recordingFile, _ := ioutil.TempFile("/tmp", "recording")
+	defer os.Remove(recordingFile.Name())
+	recorder, _ := newSessionRecorder(recordingFile.Name(), "", 80, 24)
+
+	plugin := &ShellPlugin{
+		dataChannel: suite.mockDataChannel,
+		recorder:    recorder,
+	}
+
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mgsContracts.Output, stdoutBytes).Return(nil)
+	_, err := plugin.processStdoutData(suite.mockLog, stdoutBytes, len(stdoutBytes), bytes.Buffer{}, file)
+	recorder.Close()
+
+	assert.Nil(suite.T(), err)
+	content, _ := ioutil.ReadFile(recordingFile.Name())
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(suite.T(), 2, len(lines))
+	assert.Contains(suite.T(), lines[1], `"o","$ ls\r\n"]`)
+}
+
+// TestAppendMetricsToLog tests the data channel metrics are appended to the session log
+func (suite *ShellTestSuite) TestAppendMetricsToLog() {
+	logFile, _ := ioutil.TempFile("/tmp", "session")
+	defer os.Remove(logFile.Name())
+	logFile.WriteString("$ ls\r\n")
+	logFile.Close()
+	metrics := mgsContracts.SessionMetrics{BytesIn: 4, BytesOut: 6, MessagesIn: 1, MessagesOut: 1}
+	suite.mockDataChannel.On("GetMetrics").Return(metrics)
+	plugin := &ShellPlugin{
+		dataChannel: suite.mockDataChannel,
+		logFilePath: logFile.Name(),
+	}
+
+	plugin.appendMetricsToLog(suite.mockLog)
+
+	content, _ := ioutil.ReadFile(logFile.Name())
+	assert.Equal(suite.T(), "$ ls\r\n\nSession metrics: "+metrics.String()+"\n", string(content))
+}
+
+// TestUploadSessionRecording tests the recording is uploaded to S3 and CloudWatch
+func (suite *ShellTestSuite) TestUploadSessionRecording() {
+	recordingFile, _ := ioutil.TempFile("/tmp", "recording")
+	defer os.Remove(recordingFile.Name())
+	recorder, _ := newSessionRecorder(recordingFile.Name(), "", 80, 24)
+	plugin := &ShellPlugin{recorder: recorder}
+	configuration := contracts.Configuration{
+		SessionId:          "session-id",
+		OutputS3BucketName: "bucket",
+		OutputS3KeyPrefix:  "prefix",
+		CloudWatchLogGroup: "group",
+	}
+
+	suite.mockS3.On("S3Upload", "bucket", "prefix/session-id.cast", recordingFile.Name()).Return(nil)
+	suite.mockCWL.On("StreamData", mock.Anything, "group", "session-id-recording", recordingFile.Name(), true, false).Return()
+
+	plugin.uploadSessionRecording(suite.mockLog, configuration, suite.mockS3, suite.mockCWL, recordingFile.Name())
+
+	suite.mockS3.AssertExpectations(suite.T())
+	suite.mockCWL.AssertExpectations(suite.T())
+	// the recording is complete once uploaded
+	assert.Nil(suite.T(), recorder.RecordOutput([]byte("late")))
+	content, _ := ioutil.ReadFile(recordingFile.Name())
+	assert.NotContains(suite.T(), string(content), "late")
+}
+
+// TestStartRecordingWithoutLogDestination tests sessions are not recorded when there is nowhere to upload the recording
+func (suite *ShellTestSuite) TestStartRecordingWithoutLogDestination() {
+	plugin := &ShellPlugin{}
+	plugin.startRecording(suite.mockLog, contracts.Configuration{RecordingEnabled: true}, "session.cast", mgsContracts.SizeData{})
+
+	assert.Nil(suite.T(), plugin.recorder)
+	_, err := os.Stat("session.cast")
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
 func (suite *ShellTestSuite) TestProcessStreamMessage() {
 	stdinFile, _ := ioutil.TempFile("/tmp", "stdin")
 	stdoutFile, _ := ioutil.TempFile("/tmp", "stdout")
@@ -222,6 +390,163 @@ func (suite *ShellTestSuite) TestProcessStreamMessage() {
 	assert.Equal(suite.T(), "testPayload", string(stdinFileContent))
 }
 
+// TestExecuteStartsPtyWithClientTerminal tests the pty starts with the terminal sent by the client before the session starts
+func (suite *ShellTestSuite) TestExecuteStartsPtyWithClientTerminal() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", mock.Anything, mgsContracts.Terminating).Return(nil)
+
+	stdout, stdin, _ := os.Pipe()
+	stdin.Close()
+	var started mgsContracts.SizeData
+	startPty = func(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (*os.File, *os.File, error) {
+		started = terminal
+		return stdin, stdout, nil
+	}
+	plugin := &ShellPlugin{dataChannel: suite.mockDataChannel}
+	sizeMessage := getAgentMessage(uint32(mgsContracts.Size), []byte(`{"cols":132,"rows":43,"term":"screen-256color"}`))
+	assert.Nil(suite.T(), plugin.InputStreamMessageHandler(mockLog, *sizeMessage))
+
+	plugin.Execute(suite.mockContext,
+		contracts.Configuration{},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	assert.Equal(suite.T(), mgsContracts.SizeData{Cols: 132, Rows: 43, Term: "screen-256color"}, started)
+	stdout.Close()
+}
+
+// fakeCommandsProcess is a commandsProcess exiting with exitCode.
+type fakeCommandsProcess struct {
+	exitCode int
+}
+
+func (p *fakeCommandsProcess) Wait() (int, error) { return p.exitCode, nil }
+
+func (p *fakeCommandsProcess) Kill() error { return nil }
+
+// Testing Execute of non-interactive commands
+func (suite *ShellTestSuite) TestExecuteNonInteractiveCommands() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+	suite.mockIohandler.On("SetExitCode", 3).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusFailed).Return()
+	var result mgsContracts.SessionPluginResultOutput
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return().Run(func(args mock.Arguments) {
+		result = args.Get(0).(mgsContracts.SessionPluginResultOutput)
+	})
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.Output, []byte("hel")).Return(nil)
+	var commandResult mgsContracts.CommandResultPayload
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.CommandResult, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		json.Unmarshal(args.Get(2).([]byte), &commandResult)
+	})
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", mock.Anything, mgsContracts.Terminating).Return(nil)
+	suite.mockDataChannel.On("IsPluginFeatureEnabled", FeatureStderr).Return(false)
+
+	stdout, writer, _ := os.Pipe()
+	writer.Write([]byte("hello"))
+	writer.Close()
+	var started string
+	startCommands = func(log log.T, runAsUser string, commands string, separateStderr bool) (commandsProcess, *os.File, *os.File, error) {
+		started = commands
+		return &fakeCommandsProcess{exitCode: 3}, stdout, nil, nil
+	}
+	commandOutputLimit = 3
+	defer func() { commandOutputLimit = mgsConfig.CommandOutputLimit }()
+	orchestrationDir, _ := ioutil.TempDir("", "commands")
+	defer os.RemoveAll(orchestrationDir)
+
+	plugin := NewNonInteractiveCommandsPlugin(appconfig.PluginNameNonInteractiveCommands, "echo hello; exit 3")
+	plugin.Execute(suite.mockContext,
+		contracts.Configuration{OrchestrationDirectory: orchestrationDir},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	assert.Equal(suite.T(), "echo hello; exit 3", started)
+	assert.Equal(suite.T(), 3, commandResult.ExitCode)
+	assert.True(suite.T(), commandResult.OutputTruncated)
+	assert.Equal(suite.T(), &commandResult, result.CommandResult)
+	assert.Equal(suite.T(), []string{FeatureStderr}, plugin.PluginFeatures())
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.mockDataChannel.AssertExpectations(suite.T())
+}
+
+// Testing Execute of non-interactive commands with stderr separated from stdout
+func (suite *ShellTestSuite) TestExecuteNonInteractiveCommandsWithSeparateStderr() {
+	suite.mockCancelFlag.On("Canceled").Return(false)
+	suite.mockCancelFlag.On("ShutDown").Return(false)
+	suite.mockCancelFlag.On("Wait").Return(task.Completed)
+	suite.mockIohandler.On("SetExitCode", 0).Return(nil)
+	suite.mockIohandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+	suite.mockIohandler.On("SetOutput", mock.Anything).Return()
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.Output, []byte("out\n")).Return(nil)
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.Error, []byte("err\n")).Return(nil)
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mgsContracts.CommandResult, mock.Anything).Return(nil)
+	suite.mockDataChannel.On("SendAgentSessionStateMessage", mock.Anything, mgsContracts.Terminating).Return(nil)
+	suite.mockDataChannel.On("IsPluginFeatureEnabled", FeatureStderr).Return(true)
+
+	stdout, stdoutWriter, _ := os.Pipe()
+	stdoutWriter.Write([]byte("out\n"))
+	stdoutWriter.Close()
+	stderr, stderrWriter, _ := os.Pipe()
+	stderrWriter.Write([]byte("err\n"))
+	stderrWriter.Close()
+	startCommands = func(log log.T, runAsUser string, commands string, separateStderr bool) (commandsProcess, *os.File, *os.File, error) {
+		assert.True(suite.T(), separateStderr)
+		return &fakeCommandsProcess{}, stdout, stderr, nil
+	}
+	orchestrationDir, _ := ioutil.TempDir("", "commands")
+	defer os.RemoveAll(orchestrationDir)
+
+	plugin := NewNonInteractiveCommandsPlugin(appconfig.PluginNameNonInteractiveCommands, "echo out; echo err >&2")
+	plugin.Execute(suite.mockContext,
+		contracts.Configuration{OrchestrationDirectory: orchestrationDir},
+		suite.mockCancelFlag,
+		suite.mockIohandler,
+		suite.mockDataChannel)
+
+	logData, _ := ioutil.ReadFile(filepath.Join(orchestrationDir, mgsConfig.IpcFileName+mgsConfig.LogFileExtension))
+	assert.Contains(suite.T(), string(logData), "out\n")
+	assert.Contains(suite.T(), string(logData), stderrLogPrefix+"err\n")
+	suite.mockIohandler.AssertExpectations(suite.T())
+	suite.mockDataChannel.AssertExpectations(suite.T())
+}
+
+// TestTagStderr tests every line of stderr output is tagged once, across chunks
+func (suite *ShellTestSuite) TestTagStderr() {
+	plugin := &ShellPlugin{}
+
+	assert.Equal(suite.T(), stderrLogPrefix+"no such file\n"+stderrLogPrefix+"perm", string(plugin.tagStderr([]byte("no such file\nperm"))))
+	assert.Equal(suite.T(), "ission denied\n", string(plugin.tagStderr([]byte("ission denied\n"))))
+}
+
+// TestProcessStreamMessageSizeBeforePtyStarts tests sizes received before the pty starts are kept rather than rejected
+func (suite *ShellTestSuite) TestProcessStreamMessageSizeBeforePtyStarts() {
+	plugin := &ShellPlugin{}
+	sizeMessage := getAgentMessage(uint32(mgsContracts.Size), []byte(`{"cols":100,"rows":30}`))
+	inputMessage := getAgentMessage(uint32(mgsContracts.Output), payload)
+
+	assert.Nil(suite.T(), plugin.InputStreamMessageHandler(mockLog, *sizeMessage))
+	assert.Nil(suite.T(), plugin.InputStreamMessageHandler(mockLog, *inputMessage))
+
+	assert.Equal(suite.T(), mgsContracts.SizeData{Cols: 100, Rows: 30, Term: "xterm-256color"}, plugin.terminal.terminal())
+}
+
+// TestProcessStreamMessageInvalidSize tests malformed size messages are reported
+func (suite *ShellTestSuite) TestProcessStreamMessageInvalidSize() {
+	plugin := &ShellPlugin{}
+	sizeMessage := getAgentMessage(uint32(mgsContracts.Size), []byte(`{"cols":`))
+
+	assert.NotNil(suite.T(), plugin.InputStreamMessageHandler(mockLog, *sizeMessage))
+}
+
 //Execute the test suite
 func TestShellTestSuite(t *testing.T) {
 	suite.Run(t, new(ShellTestSuite))
@@ -333,3 +658,35 @@ func (suite *ShellTestSuite) TestValidateBypassS3BucketEncryptionCheck() {
 	err := suite.plugin.validate(suite.mockContext, configuration, suite.mockCWL, suite.mockS3)
 	assert.Nil(suite.T(), err)
 }
+
+func (suite *ShellTestSuite) TestValidateRunAs() {
+	valid := []contracts.Configuration{
+		{RunAsEnabled: true, RunAsUser: "developer"},
+		{RunAsEnabled: true, RunAsUser: "dev.user-1", RunAsGroups: []string{"developers", "audio"}},
+		{RunAsEnabled: true, RunAsUser: `CORP\svc-shell$`},
+		{RunAsEnabled: false, RunAsUser: ""},
+	}
+	for _, configuration := range valid {
+		assert.Nil(suite.T(), suite.plugin.validate(suite.mockContext, configuration, suite.mockCWL, suite.mockS3), configuration.RunAsUser)
+	}
+
+	invalid := []contracts.Configuration{
+		{RunAsEnabled: true, RunAsUser: " "},
+		{RunAsEnabled: true, RunAsUser: "dev; rm -rf /"},
+		{RunAsEnabled: true, RunAsUser: "developer", RunAsGroups: []string{"docker wheel"}},
+		{RunAsEnabled: true, RunAsUser: "-ofoo"},
+		{RunAsEnabled: true, RunAsUser: `-CORP\svc-shell$`},
+		{RunAsEnabled: true, RunAsUser: "developer", RunAsGroups: []string{"-Groot"}},
+		{RunAsEnabled: true, RunAsUser: "developer", RunAsGroups: []string{"wheel"}},
+		{RunAsEnabled: true, RunAsUser: "developer", RunAsGroups: []string{"Sudo"}},
+		{RunAsEnabled: true, RunAsUser: "developer", RunAsGroups: []string{`BUILTIN\Administrators`}},
+	}
+	for _, configuration := range invalid {
+		assert.NotNil(suite.T(), suite.plugin.validate(suite.mockContext, configuration, suite.mockCWL, suite.mockS3), configuration.RunAsUser)
+	}
+}
+
+func (suite *ShellTestSuite) TestGetRunAsUser() {
+	assert.Equal(suite.T(), appconfig.DefaultRunAsUserName, getRunAsUser(contracts.Configuration{RunAsUser: "developer"}))
+	assert.Equal(suite.T(), "developer", getRunAsUser(contracts.Configuration{RunAsEnabled: true, RunAsUser: "developer"}))
+}
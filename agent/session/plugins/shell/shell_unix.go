@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -29,46 +31,73 @@ import (
 	agentContracts "github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/kr/pty"
 )
 
 var ptyFile *os.File
 
 const (
-	termEnvVariable       = "TERM=xterm-256color"
 	startRecordSessionCmd = "script"
 	newLineCharacter      = "\n"
 	screenBufferSizeCmd   = "screen -h %d%s"
 	homeEnvVariable       = "HOME=/home/" + appconfig.DefaultRunAsUserName
 )
 
-var getUserAndGroupIdCall = func(log log.T) (uid int, gid int, err error) {
-	return getUserAndGroupId(log)
+// runAsUserInfo holds the credentials and home directory of a runas user.
+type runAsUserInfo struct {
+	uid     uint32
+	gid     uint32
+	groups  []uint32
+	homeDir string
+}
+
+var getRunAsUserInfoCall = func(log log.T, runAsUser string) (info runAsUserInfo, err error) {
+	return getRunAsUserInfo(log, runAsUser)
+}
+
+var setupHomeDirCall = func(log log.T, info runAsUserInfo) error {
+	return setupHomeDir(log, info)
 }
 
 //StartPty starts pty and provides handles to stdin and stdout
-func StartPty(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File, err error) {
+//The pty runs commands when given, an interactive shell otherwise.
+//The pty starts with the terminal type and, when not zero, the size of the client terminal.
+func StartPty(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
 	log.Info("Starting pty")
 	//Start the command with a pty
 	cmd := exec.Command("sh")
+	if commands != "" {
+		cmd = exec.Command("sh", "-c", commands)
+	}
 
-	//TERM is set as linux by pty which has an issue where vi editor screen does not get cleared.
-	//Setting TERM as xterm-256color as used by standard terminals to fix this issue
-	cmd.Env = append(os.Environ(),
-		termEnvVariable,
-		homeEnvVariable,
-	)
+	homeEnv := homeEnvVariable
 
-	// Get the uid and gid of the runas user.
+	// Get the uid, gid and groups of the runas user.
 	if isSessionShell {
-		log.Info("Starting pty")
-		uid, gid, err := getUserAndGroupIdCall(log)
+		log.Infof("Starting pty as %s", runAsUser)
+		info, err := getRunAsUserInfoCall(log, runAsUser)
 		if err != nil {
 			return nil, nil, err
 		}
+		if err = setupHomeDirCall(log, info); err != nil {
+			return nil, nil, err
+		}
+		homeEnv = "HOME=" + info.homeDir
 		cmd.SysProcAttr = &syscall.SysProcAttr{}
-		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: info.uid, Gid: info.gid, Groups: info.groups}
+	}
+
+	//TERM is set as linux by pty which has an issue where vi editor screen does not get cleared.
+	//Setting TERM as the terminal type of the client, xterm-256color as used by standard terminals by default
+	term := terminal.Term
+	if term == "" {
+		term = mgsConfig.DefaultTerminalType
 	}
+	cmd.Env = append(os.Environ(),
+		"TERM="+term,
+		homeEnv,
+	)
 
 	ptyFile, err = pty.Start(cmd)
 	if err != nil {
@@ -76,9 +105,64 @@ func StartPty(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File,
 		return nil, nil, fmt.Errorf("Failed to start pty: %s\n", err)
 	}
 
+	if terminal.Cols != 0 && terminal.Rows != 0 {
+		if err = SetSize(log, terminal.Cols, terminal.Rows); err != nil {
+			log.Warnf("Unable to set initial pty size: %s", err)
+		}
+	}
+
 	return ptyFile, ptyFile, nil
 }
 
+//StartCommands starts the commands of a non-interactive session as the runas user, without a pty.
+//The error of the commands is returned separately when separateStderr is set, combined with their output otherwise.
+func StartCommands(log log.T, runAsUser string, commands string, separateStderr bool) (process commandsProcess, stdout *os.File, stderr *os.File, err error) {
+	log.Infof("Starting commands as %s", runAsUser)
+	info, err := getRunAsUserInfoCall(log, runAsUser)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err = setupHomeDirCall(log, info); err != nil {
+		return nil, nil, nil, err
+	}
+
+	cmd := exec.Command(ShellPluginCommandName, append(ShellPluginCommandArgs, commands)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: info.uid, Gid: info.gid, Groups: info.groups}
+	cmd.Env = append(os.Environ(), "HOME="+info.homeDir)
+	cmd.Dir = info.homeDir
+
+	if process, stdout, stderr, err = startCommandsProcess(cmd, separateStderr); err != nil {
+		log.Errorf("Failed to start commands: %s", err)
+		return nil, nil, nil, fmt.Errorf("Failed to start commands: %s", err)
+	}
+	return process, stdout, stderr, nil
+}
+
+// CreateRunAsUserIfMissing creates the runas user with a home directory and the given supplementary groups.
+// Existing users are left untouched.
+func CreateRunAsUserIfMissing(log log.T, runAsUser string, groups []string) error {
+	if _, err := user.Lookup(runAsUser); err == nil {
+		log.Infof("%s already exists.", runAsUser)
+		return nil
+	} else if _, ok := err.(user.UnknownUserError); !ok {
+		return fmt.Errorf("encountered an error while checking for %s: %v", runAsUser, err)
+	}
+
+	useraddArgs := []string{"-m"}
+	if len(groups) > 0 {
+		useraddArgs = append(useraddArgs, "-G", strings.Join(groups, ","))
+	}
+	useraddArgs = append(useraddArgs, "--", runAsUser)
+	cmd := exec.Command("useradd", useraddArgs...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Errorf("Failed to create %s: %v, %s", runAsUser, err, strings.TrimSpace(string(out)))
+		return err
+	}
+	log.Infof("Successfully created %s", runAsUser)
+	return nil
+}
+
 //Stop closes pty file.
 func Stop(log log.T) (err error) {
 	log.Info("Stopping pty")
@@ -101,44 +185,69 @@ func SetSize(log log.T, ws_col, ws_row uint32) (err error) {
 	return nil
 }
 
-//getUserAndGroupId returns the uid and gid of the runas user.
-func getUserAndGroupId(log log.T) (uid int, gid int, err error) {
-	shellCmdArgs := append(ShellPluginCommandArgs, fmt.Sprintf("id -u %s", appconfig.DefaultRunAsUserName))
-	cmd := exec.Command(ShellPluginCommandName, shellCmdArgs...)
-	out, err := cmd.Output()
+//getRunAsUserInfo returns the uid, gid, supplementary groups and home directory of the runas user.
+func getRunAsUserInfo(log log.T, runAsUser string) (info runAsUserInfo, err error) {
+	u, err := user.Lookup(runAsUser)
 	if err != nil {
-		log.Errorf("Failed retrieve uid for %s: %v", appconfig.DefaultRunAsUserName, err)
+		log.Errorf("Failed to retrieve %s: %v", runAsUser, err)
 		return
 	}
 
-	u, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
 	if err != nil {
-		log.Errorf("%s not found: %v", appconfig.DefaultRunAsUserName, err)
+		return info, fmt.Errorf("invalid uid %s for %s: %v", u.Uid, runAsUser, err)
 	}
-
-	shellCmdArgs = append(ShellPluginCommandArgs, fmt.Sprintf("id -g %s", appconfig.DefaultRunAsUserName))
-	cmd = exec.Command(ShellPluginCommandName, shellCmdArgs...)
-	out, err = cmd.Output()
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
 	if err != nil {
-		log.Errorf("Failed retrieve gid for %s: %v", appconfig.DefaultRunAsUserName, err)
-		return
+		return info, fmt.Errorf("invalid gid %s for %s: %v", u.Gid, runAsUser, err)
 	}
 
-	g, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	// Never start a session shell as root, even when the document names a root user.
+	if uid == 0 || gid == 0 {
+		return info, fmt.Errorf("%s is not allowed as RunAs user", runAsUser)
+	}
+
+	groupIds, err := u.GroupIds()
 	if err != nil {
-		log.Errorf("%s not found: %v", appconfig.DefaultRunAsUserName, err)
+		return info, fmt.Errorf("failed to retrieve groups of %s: %v", runAsUser, err)
+	}
+	for _, groupId := range groupIds {
+		g, err := strconv.ParseUint(groupId, 10, 32)
+		if err != nil {
+			log.Warnf("Ignoring invalid group id %s of %s", groupId, runAsUser)
+			continue
+		}
+		info.groups = append(info.groups, uint32(g))
+	}
+
+	info.uid = uint32(uid)
+	info.gid = uint32(gid)
+	info.homeDir = u.HomeDir
+	if info.homeDir == "" {
+		info.homeDir = filepath.Join("/home", runAsUser)
+	}
+	return info, nil
+}
+
+//setupHomeDir creates the home directory of the runas user when it is missing.
+func setupHomeDir(log log.T, info runAsUserInfo) error {
+	if _, err := os.Stat(info.homeDir); err == nil {
+		return nil
 	}
 
-	// Make sure they are non-zero valid positive ids
-	if u > 0 && g > 0 {
-		return u, g, nil
+	log.Infof("Creating home directory %s", info.homeDir)
+	if err := os.MkdirAll(info.homeDir, 0700); err != nil {
+		return fmt.Errorf("failed to create home directory %s: %v", info.homeDir, err)
+	}
+	if err := os.Chown(info.homeDir, int(info.uid), int(info.gid)); err != nil {
+		return fmt.Errorf("failed to change owner of home directory %s: %v", info.homeDir, err)
 	}
-	return
+	return nil
 }
 
 // generateLogData generates a log file with the executed commands.
 func (p *ShellPlugin) generateLogData(log log.T, config agentContracts.Configuration) error {
-	shadowShellInput, _, err := StartPty(log, false)
+	shadowShellInput, _, err := StartPty(log, false, "", "", mgsContracts.SizeData{})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,67 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+// +build darwin freebsd linux netbsd openbsd
+
+// Package shell implements session shell plugin.
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRunAsUserInfo_RootIsRejected(t *testing.T) {
+	_, err := getRunAsUserInfo(mockLog, "root")
+	assert.NotNil(t, err)
+}
+
+func TestGetRunAsUserInfo_UnknownUser(t *testing.T) {
+	_, err := getRunAsUserInfo(mockLog, "ssm-unknown-runas-user")
+	assert.NotNil(t, err)
+}
+
+func TestSetupHomeDir_CreatesMissingDirectory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "home")
+	defer os.RemoveAll(dir)
+	homeDir := filepath.Join(dir, "developer")
+
+	err := setupHomeDir(mockLog, runAsUserInfo{
+		uid:     uint32(os.Getuid()),
+		gid:     uint32(os.Getgid()),
+		homeDir: homeDir,
+	})
+
+	assert.Nil(t, err)
+	fileInfo, err := os.Stat(homeDir)
+	assert.Nil(t, err)
+	assert.True(t, fileInfo.IsDir())
+	assert.Equal(t, os.FileMode(0700), fileInfo.Mode().Perm())
+}
+
+func TestStartPtyRunsCommands(t *testing.T) {
+	stdin, stdout, err := StartPty(mockLog, false, "", "echo interactive commands", mgsContracts.SizeData{})
+	assert.Nil(t, err)
+	assert.Equal(t, stdin, stdout)
+	defer Stop(mockLog)
+
+	// the pty is closed once the commands exit
+	output, _ := ioutil.ReadAll(stdout)
+	assert.True(t, strings.Contains(string(output), "interactive commands"), string(output))
+}
@@ -18,8 +18,11 @@ package shell
 
 import (
 	"bufio"
+	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -28,6 +31,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf16"
 	"unsafe"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -36,6 +40,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/utility"
 	"github.com/aws/amazon-ssm-agent/agent/session/winpty"
 )
@@ -53,7 +58,14 @@ const (
 	newLineCharacter       = "\r\n"
 	screenBufferSizeCmd    = "$host.UI.RawUI.BufferSize = New-Object System.Management.Automation.Host.Size($host.UI.RawUI.BufferSize.Width,%d)%s"
 	logon32LogonNetwork    = uintptr(3)
+	logon32LogonService    = uintptr(5)
 	logon32ProviderDefault = uintptr(0)
+	maximumAllowed         = uintptr(0x02000000)
+	securityImpersonation  = uintptr(2)
+	tokenPrimary           = uintptr(1)
+
+	// managedServiceAccountPassword is the well known password LogonUser accepts for managed service accounts.
+	managedServiceAccountPassword = "_SA_{262E99C9-6160-4871-ACEC-4E61736B6F21}"
 )
 
 var (
@@ -61,38 +73,41 @@ var (
 	logonProc         = advapi32.NewProc("LogonUserW")
 	impersonateProc   = advapi32.NewProc("ImpersonateLoggedOnUser")
 	revertSelfProc    = advapi32.NewProc("RevertToSelf")
+	duplicateTokenEx  = advapi32.NewProc("DuplicateTokenEx")
 	winptyDllDir      = fileutil.BuildPath(appconfig.DefaultPluginPath, winptyDllFolderName)
 	winptyDllFilePath = filepath.Join(winptyDllDir, winptyDllName)
 )
 
 //StartPty starts winpty agent and provides handles to stdin and stdout.
-func StartPty(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File, err error) {
+//The pty runs commands when given, an interactive shell otherwise.
+//The console starts with the size of the client terminal when not zero, Windows consoles have no terminal type.
+func StartPty(log log.T, isSessionShell bool, runAsUser string, commands string, terminal mgsContracts.SizeData) (stdin *os.File, stdout *os.File, err error) {
 	log.Info("Starting winpty")
 	if _, err := os.Stat(winptyDllFilePath); os.IsNotExist(err) {
 		return nil, nil, fmt.Errorf("Missing %s file.", winptyDllFilePath)
 	}
+	commandLine := ptyCommandLine(commands)
+	cols, rows := uint32(defaultConsoleCol), uint32(defaultConsoleRow)
+	if terminal.Cols != 0 && terminal.Rows != 0 {
+		cols, rows = terminal.Cols, terminal.Rows
+	}
 
 	if isSessionShell {
-		// Reset password for default ssm user
-		var newPassword string
-		newPassword, err = u.GeneratePasswordForDefaultUser()
-		if err != nil {
+		var password string
+		var logonType uintptr
+		if password, logonType, err = runAsLogon(log, runAsUser); err != nil {
 			return nil, nil, err
 		}
-		if err = u.ChangePassword(appconfig.DefaultRunAsUserName, newPassword); err != nil {
-			log.Errorf("Failed to generate new password for %s: %v", appconfig.DefaultRunAsUserName, err)
-			return
-		}
 
 		var wg sync.WaitGroup
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err = startPtyAsUser(log, appconfig.DefaultRunAsUserName, newPassword)
+			err = startPtyAsUser(log, runAsUser, password, logonType, commandLine, cols, rows)
 		}()
 		wg.Wait()
 	} else {
-		pty, err = winpty.Start(winptyDllFilePath, winptyCmd, defaultConsoleCol, defaultConsoleRow, winpty.DEFAULT_WINPTY_FLAGS)
+		pty, err = winpty.Start(winptyDllFilePath, commandLine, cols, rows, winpty.DEFAULT_WINPTY_FLAGS)
 	}
 
 	if err != nil {
@@ -102,6 +117,114 @@ func StartPty(log log.T, isSessionShell bool) (stdin *os.File, stdout *os.File,
 	return pty.StdIn, pty.StdOut, err
 }
 
+//StartCommands starts the commands of a non-interactive session as the runas user, without a pty.
+//The error of the commands is returned separately when separateStderr is set, combined with their output otherwise.
+func StartCommands(log log.T, runAsUser string, commands string, separateStderr bool) (process commandsProcess, stdout *os.File, stderr *os.File, err error) {
+	log.Infof("Starting commands as %s", runAsUser)
+	password, logonType, err := runAsLogon(log, runAsUser)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	token, err := logonUser(runAsUser, password, logonType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer mustCloseHandle(log, token)
+
+	// Processes are created with primary tokens, network logons return impersonation tokens.
+	var primary syscall.Handle
+	if rc, _, ec := syscall.Syscall6(duplicateTokenEx.Addr(), 6,
+		uintptr(token),
+		maximumAllowed,
+		0,
+		securityImpersonation,
+		tokenPrimary,
+		uintptr(unsafe.Pointer(&primary))); rc == 0 {
+		return nil, nil, nil, error(ec)
+	}
+	defer mustCloseHandle(log, primary)
+
+	cmd := exec.Command(winptyCmd)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine:    ptyCommandLine(commands),
+		Token:      syscall.Token(primary),
+		HideWindow: true,
+	}
+
+	if process, stdout, stderr, err = startCommandsProcess(cmd, separateStderr); err != nil {
+		log.Errorf("Failed to start commands: %s", err)
+		return nil, nil, nil, fmt.Errorf("Failed to start commands: %s", err)
+	}
+	return process, stdout, stderr, nil
+}
+
+//runAsLogon returns the password and logon type the runas user logs on with.
+func runAsLogon(log log.T, runAsUser string) (password string, logonType uintptr, err error) {
+	if isManagedServiceAccount(runAsUser) {
+		// Managed service accounts have no password the agent can know, Windows retrieves it from the domain.
+		return managedServiceAccountPassword, logon32LogonService, nil
+	}
+
+	// Reset password for the local runas user
+	if password, err = u.GeneratePasswordForDefaultUser(); err != nil {
+		return "", 0, err
+	}
+	if err = u.ChangePassword(runAsUser, password); err != nil {
+		log.Errorf("Failed to generate new password for %s: %v", runAsUser, err)
+		return "", 0, err
+	}
+	return password, logon32LogonNetwork, nil
+}
+
+// CreateRunAsUserIfMissing creates the local runas user and adds it to the given local groups.
+// Managed service accounts live in the domain and are never created by the agent.
+func CreateRunAsUserIfMissing(log log.T, runAsUser string, groups []string) error {
+	if isManagedServiceAccount(runAsUser) {
+		log.Infof("%s is a managed service account, skipping user creation.", runAsUser)
+		return nil
+	}
+
+	userExists, err := u.DoesUserExist(runAsUser)
+	if err != nil {
+		return fmt.Errorf("Error occurred while checking if %s user exists, %v", runAsUser, err)
+	}
+	if userExists {
+		log.Infof("%s already exists.", runAsUser)
+		return nil
+	}
+
+	// net is started directly rather than through PowerShell so that the names are never parsed as script.
+	cmd := exec.Command("net", "user", runAsUser, "/add")
+	if err = cmd.Run(); err != nil {
+		log.Errorf("Failed to create %s: %v", runAsUser, err)
+		return err
+	}
+	log.Infof("Successfully created %s", runAsUser)
+
+	for _, group := range groups {
+		cmd = exec.Command("net", "localgroup", group, runAsUser, "/add")
+		if err = cmd.Run(); err != nil {
+			log.Errorf("Failed to add %s to %s group: %v", runAsUser, group, err)
+			return err
+		}
+		log.Infof("Successfully added %s to %s group", runAsUser, group)
+	}
+	return nil
+}
+
+// isManagedServiceAccount returns true for (group) managed service account names, which end with $.
+func isManagedServiceAccount(runAsUser string) bool {
+	return strings.HasSuffix(runAsUser, "$")
+}
+
+// splitDomainUser splits a DOMAIN\user name, users without domain belong to this computer.
+func splitDomainUser(runAsUser string) (domain string, user string) {
+	if i := strings.Index(runAsUser, `\`); i >= 0 {
+		return runAsUser[:i], runAsUser[i+1:]
+	}
+	return ".", runAsUser
+}
+
 //Stop closes winpty process handle and stdin/stdout.
 func Stop(log log.T) (err error) {
 	log.Info("Stopping winpty")
@@ -113,7 +236,11 @@ func Stop(log log.T) (err error) {
 }
 
 //SetSize sets size of console terminal window.
+//winpty resizes both the console screen buffer and window so that full screen programs redraw for the new size.
 func SetSize(log log.T, ws_col, ws_row uint32) (err error) {
+	if ws_col == 0 || ws_row == 0 {
+		return fmt.Errorf("Invalid winpty size cols: %d, rows: %d", ws_col, ws_row)
+	}
 	if err = pty.SetSize(ws_col, ws_row); err != nil {
 		return fmt.Errorf("Set winpty size failed: %s", err)
 	}
@@ -121,19 +248,33 @@ func SetSize(log log.T, ws_col, ws_row uint32) (err error) {
 	return nil
 }
 
+// ptyCommandLine returns the PowerShell command line running commands, an interactive PowerShell when empty.
+// Commands are passed base64 encoded so that they need no quoting.
+func ptyCommandLine(commands string) string {
+	if commands == "" {
+		return winptyCmd
+	}
+	encoded := utf16.Encode([]rune(commands))
+	bytes := make([]byte, 2*len(encoded))
+	for i, c := range encoded {
+		binary.LittleEndian.PutUint16(bytes[2*i:], c)
+	}
+	return winptyCmd + " -EncodedCommand " + base64.StdEncoding.EncodeToString(bytes)
+}
+
 //startPtyAsUser starts a winpty process in runas user context.
-func startPtyAsUser(log log.T, user string, pass string) (err error) {
+func startPtyAsUser(log log.T, user string, pass string, logonType uintptr, commandLine string, cols uint32, rows uint32) (err error) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	log.Debugf("Impersonating %s", appconfig.DefaultRunAsUserName)
-	if err = impersonate(log, user, pass); err != nil {
+	log.Debugf("Impersonating %s", user)
+	if err = impersonate(log, user, pass, logonType); err != nil {
 		log.Error(err)
 		return
 	}
 
 	// Start Winpty under the user context thread.
-	if pty, err = winpty.Start(winptyDllFilePath, winptyCmd, defaultConsoleCol, defaultConsoleRow, winpty.WINPTY_FLAG_IMPERSONATE_THREAD); err != nil {
+	if pty, err = winpty.Start(winptyDllFilePath, commandLine, cols, rows, winpty.WINPTY_FLAG_IMPERSONATE_THREAD); err != nil {
 		log.Error(err)
 		return
 	}
@@ -148,8 +289,8 @@ func startPtyAsUser(log log.T, user string, pass string) (err error) {
 }
 
 //impersonate attempts to impersonate the user.
-func impersonate(log log.T, user string, pass string) error {
-	token, err := logonUser(user, pass)
+func impersonate(log log.T, user string, pass string, logonType uintptr) error {
+	token, err := logonUser(user, pass, logonType)
 	if err != nil {
 		return err
 	}
@@ -161,13 +302,15 @@ func impersonate(log log.T, user string, pass string) error {
 	return nil
 }
 
-//logonUser attempts to log a user on to the local computer to generate a token.
-func logonUser(user, pass string) (token syscall.Handle, err error) {
-	// ".\0" meaning "this computer:
-	domain := [2]uint16{uint16('.'), 0}
+//logonUser attempts to log a user on to the computer to generate a token.
+func logonUser(user, pass string, logonType uintptr) (token syscall.Handle, err error) {
+	domainName, userName := splitDomainUser(user)
 
-	var pu, pp []uint16
-	if pu, err = syscall.UTF16FromString(user); err != nil {
+	var pu, pd, pp []uint16
+	if pu, err = syscall.UTF16FromString(userName); err != nil {
+		return
+	}
+	if pd, err = syscall.UTF16FromString(domainName); err != nil {
 		return
 	}
 	if pp, err = syscall.UTF16FromString(pass); err != nil {
@@ -176,9 +319,9 @@ func logonUser(user, pass string) (token syscall.Handle, err error) {
 
 	if rc, _, ec := syscall.Syscall6(logonProc.Addr(), 6,
 		uintptr(unsafe.Pointer(&pu[0])),
-		uintptr(unsafe.Pointer(&domain[0])),
+		uintptr(unsafe.Pointer(&pd[0])),
 		uintptr(unsafe.Pointer(&pp[0])),
-		logon32LogonNetwork,
+		logonType,
 		logon32ProviderDefault,
 		uintptr(unsafe.Pointer(&token))); rc == 0 {
 		err = error(ec)
@@ -242,7 +385,7 @@ func (p *ShellPlugin) generateLogData(log log.T, config agentContracts.Configura
 
 // generateTranscriptFile generates a transcript file using PowerShell
 func generateTranscriptFile(log log.T, transcriptFile string, loggerFile string, enableVirtualTerminalProcessingForWindows bool) error {
-	shadowShellInput, _, err := StartPty(log, false)
+	shadowShellInput, _, err := StartPty(log, false, "", "", mgsContracts.SizeData{})
 	if err != nil {
 		return err
 	}
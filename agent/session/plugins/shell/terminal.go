@@ -0,0 +1,107 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+)
+
+// terminalTypePattern matches the terminal types accepted from the client, the type ends up in the TERM environment variable.
+var terminalTypePattern = regexp.MustCompile(`^[A-Za-z0-9._+-]{1,64}$`)
+
+// terminalState holds the terminal type and size of the client.
+// Clients send them in size messages which may arrive before the pty is started:
+// they are kept until the pty starts and applied to it afterwards.
+type terminalState struct {
+	lock     sync.Mutex
+	term     string
+	size     mgsContracts.SizeData
+	started  bool
+	received chan struct{}
+}
+
+// receivedChannel returns the channel closed once the client sent its terminal size, lock must be held.
+func (t *terminalState) receivedChannel() chan struct{} {
+	if t.received == nil {
+		t.received = make(chan struct{})
+	}
+	return t.received
+}
+
+// update records the terminal type and size sent by the client.
+// It returns true when the size must be applied to the pty, that is when the pty is started and the size changed.
+func (t *terminalState) update(log log.T, size mgsContracts.SizeData) bool {
+	if size.Cols == 0 || size.Rows == 0 {
+		log.Debugf("Ignoring terminal size cols: %d, rows: %d", size.Cols, size.Rows)
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if size.Term != "" && !t.started {
+		if terminalTypePattern.MatchString(size.Term) {
+			t.term = size.Term
+		} else {
+			log.Warnf("Ignoring invalid terminal type %q", size.Term)
+		}
+	}
+
+	first := t.size.Cols == 0
+	changed := size.Cols != t.size.Cols || size.Rows != t.size.Rows
+	t.size = mgsContracts.SizeData{Cols: size.Cols, Rows: size.Rows}
+	if first {
+		close(t.receivedChannel())
+	}
+	return t.started && changed
+}
+
+// waitForInitialSize waits up to timeout for the client to send its terminal size.
+func (t *terminalState) waitForInitialSize(log log.T, timeout time.Duration) {
+	t.lock.Lock()
+	received := t.receivedChannel()
+	t.lock.Unlock()
+
+	select {
+	case <-received:
+	case <-time.After(timeout):
+		log.Debugf("No terminal size received from the client after %v, starting with the default size", timeout)
+	}
+}
+
+// terminal returns the terminal the pty starts with, the size is zero when the client did not send it yet.
+func (t *terminalState) terminal() mgsContracts.SizeData {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	terminal := t.size
+	terminal.Term = t.term
+	if terminal.Term == "" {
+		terminal.Term = mgsConfig.DefaultTerminalType
+	}
+	return terminal
+}
+
+// setStarted marks the pty as started and returns the last size sent by the client.
+// Sizes received from then on are applied by the input stream message handler.
+func (t *terminalState) setStarted() mgsContracts.SizeData {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.started = true
+	return t.size
+}
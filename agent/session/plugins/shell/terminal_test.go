@@ -0,0 +1,70 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shell
+
+import (
+	"testing"
+	"time"
+
+	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalStateDefaults(t *testing.T) {
+	var terminal terminalState
+	assert.Equal(t, mgsContracts.SizeData{Term: "xterm-256color"}, terminal.terminal())
+	assert.Equal(t, mgsContracts.SizeData{}, terminal.setStarted())
+}
+
+func TestTerminalStateUpdateBeforeStart(t *testing.T) {
+	var terminal terminalState
+	assert.False(t, terminal.update(mockLog, mgsContracts.SizeData{Cols: 80, Rows: 24, Term: "vt100"}))
+	assert.False(t, terminal.update(mockLog, mgsContracts.SizeData{Cols: 120, Rows: 40}))
+
+	assert.Equal(t, mgsContracts.SizeData{Cols: 120, Rows: 40, Term: "vt100"}, terminal.terminal())
+	assert.Equal(t, mgsContracts.SizeData{Cols: 120, Rows: 40}, terminal.setStarted())
+}
+
+func TestTerminalStateUpdateAfterStart(t *testing.T) {
+	var terminal terminalState
+	terminal.update(mockLog, mgsContracts.SizeData{Cols: 80, Rows: 24})
+	terminal.setStarted()
+
+	// unchanged sizes are not applied again
+	assert.False(t, terminal.update(mockLog, mgsContracts.SizeData{Cols: 80, Rows: 24}))
+	assert.True(t, terminal.update(mockLog, mgsContracts.SizeData{Cols: 100, Rows: 24, Term: "vt100"}))
+	// the terminal type cannot change once the shell started
+	assert.Equal(t, mgsContracts.SizeData{Cols: 100, Rows: 24, Term: "xterm-256color"}, terminal.terminal())
+}
+
+func TestTerminalStateIgnoresInvalidTerminal(t *testing.T) {
+	var terminal terminalState
+	assert.False(t, terminal.update(mockLog, mgsContracts.SizeData{Cols: 0, Rows: 24, Term: "vt100"}))
+	terminal.update(mockLog, mgsContracts.SizeData{Cols: 80, Rows: 24, Term: "xterm\nEVIL=1"})
+
+	assert.Equal(t, mgsContracts.SizeData{Cols: 80, Rows: 24, Term: "xterm-256color"}, terminal.terminal())
+}
+
+func TestTerminalStateWaitForInitialSize(t *testing.T) {
+	var terminal terminalState
+	start := time.Now()
+	terminal.waitForInitialSize(mockLog, 10*time.Millisecond)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	go terminal.update(mockLog, mgsContracts.SizeData{Cols: 80, Rows: 24})
+	start = time.Now()
+	terminal.waitForInitialSize(mockLog, time.Minute)
+	assert.True(t, time.Since(start) < time.Minute)
+	assert.Equal(t, uint32(80), terminal.terminal().Cols)
+}
@@ -16,6 +16,7 @@ package retry
 
 import (
 	"math"
+	"math/rand"
 	"time"
 )
 
@@ -31,6 +32,9 @@ type ExponentialRetryer struct {
 	InitialDelayInMilli int
 	MaxDelayInMilli     int
 	MaxAttempts         int
+	// JitterRatio shortens each delay by a random part of up to JitterRatio of it,
+	// so that agents disconnected together don't retry together. Zero disables jitter.
+	JitterRatio float64
 }
 
 // NextSleepTime calculates the next delay of retry.
@@ -53,7 +57,15 @@ func (retryer *ExponentialRetryer) Call() (channel interface{}, err error) {
 		} else {
 			attempt++
 		}
-		time.Sleep(sleep)
+		time.Sleep(retryer.jitter(sleep))
 		failedAttemptsSoFar++
 	}
 }
+
+// jitter returns sleep shortened by a random part of up to JitterRatio of it.
+func (retryer *ExponentialRetryer) jitter(sleep time.Duration) time.Duration {
+	if retryer.JitterRatio <= 0 {
+		return sleep
+	}
+	return sleep - time.Duration(rand.Float64()*retryer.JitterRatio*float64(sleep))
+}
@@ -17,6 +17,7 @@ package retry
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -44,6 +45,7 @@ func TestRepeatableExponentialRetryerRetriesForGivenNumberOfMaxAttempts(t *testi
 		initialDelayInMilli,
 		maxDelayInMilli,
 		maxAttempts,
+		0,
 	}
 
 	retryCounterInterface, err := retryer.Call()
@@ -52,3 +54,14 @@ func TestRepeatableExponentialRetryerRetriesForGivenNumberOfMaxAttempts(t *testi
 	assert.NotNil(t, err)
 	assert.Equal(t, retryCounter.TotalAttempts, maxAttempts+1)
 }
+
+func TestExponentialRetryerJitterShortensDelays(t *testing.T) {
+	retryer := ExponentialRetryer{JitterRatio: 0.5}
+	for i := 0; i < 100; i++ {
+		sleep := retryer.jitter(time.Second)
+		assert.True(t, sleep > 500*time.Millisecond && sleep <= time.Second, "delay %v out of range", sleep)
+	}
+
+	retryer.JitterRatio = 0
+	assert.Equal(t, time.Second, retryer.jitter(time.Second))
+}
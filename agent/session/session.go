@@ -18,7 +18,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"runtime/debug"
 	"time"
 
@@ -33,8 +32,8 @@ import (
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/controlchannel"
-	"github.com/aws/amazon-ssm-agent/agent/session/retry"
 	"github.com/aws/amazon-ssm-agent/agent/session/service"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 	"github.com/gorilla/websocket"
 	"github.com/twinj/uuid"
 )
@@ -48,6 +47,7 @@ type Session struct {
 	service        service.Service
 	controlChannel controlchannel.IControlChannel
 	processor      processor.Processor
+	sessionLimiter *controlchannel.SessionLimiter
 }
 
 // NewSession gets session core module that manages the web-socket connection between Agent and message gateway service.
@@ -111,6 +111,9 @@ func NewSession(context context.T) *Session {
 		[]contracts.DocumentType{contracts.StartSession, contracts.TerminateSession})
 
 	controlChannel := &controlchannel.ControlChannel{}
+	sessionLimiter := controlchannel.NewSessionLimiter(
+		messageGatewayServiceConfig.ConcurrentSessionsLimit,
+		messageGatewayServiceConfig.ConcurrentSessionsPerClientLimit)
 
 	return &Session{
 		context:        sessionContext,
@@ -120,6 +123,7 @@ func NewSession(context context.T) *Session {
 		service:        mgsService,
 		processor:      processor,
 		controlChannel: controlChannel,
+		sessionLimiter: sessionLimiter,
 	}
 }
 
@@ -130,26 +134,21 @@ func (s *Session) ModuleName() string {
 	return s.name
 }
 
-var setupControlChannel = func(context context.T, service service.Service, processor processor.Processor, instanceId string) (controlchannel.IControlChannel, error) {
-	retryer := retry.ExponentialRetryer{
-		CallableFunc: func() (channel interface{}, err error) {
-			controlChannel := &controlchannel.ControlChannel{}
-			controlChannel.Initialize(context, service, processor, instanceId)
-			if err := controlChannel.SetWebSocket(context, service, processor, instanceId); err != nil {
-				return nil, err
-			}
+var setupControlChannel = func(context context.T, service service.Service, processor processor.Processor, sessionLimiter *controlchannel.SessionLimiter, instanceId string) (controlchannel.IControlChannel, error) {
+	retryer := controlchannel.NewRetryer(context.Log(), func() (channel interface{}, err error) {
+		controlChannel := &controlchannel.ControlChannel{}
+		controlChannel.Initialize(context, service, processor, instanceId)
+		controlChannel.SessionLimiter = sessionLimiter
+		if err := controlChannel.SetWebSocket(context, service, processor, instanceId); err != nil {
+			return nil, err
+		}
 
-			if err := controlChannel.Open(context.Log()); err != nil {
-				return nil, err
-			}
+		if err := controlChannel.Open(context.Log()); err != nil {
+			return nil, err
+		}
 
-			return controlChannel, nil
-		},
-		GeometricRatio:      mgsConfig.RetryGeometricRatio,
-		InitialDelayInMilli: rand.Intn(mgsConfig.ControlChannelRetryInitialDelayMillis) + mgsConfig.ControlChannelRetryInitialDelayMillis,
-		MaxDelayInMilli:     mgsConfig.ControlChannelRetryMaxIntervalMillis,
-		MaxAttempts:         mgsConfig.ControlChannelNumMaxRetries,
-	}
+		return controlChannel, nil
+	})
 
 	channel, err := retryer.Call()
 	if err != nil {
@@ -157,9 +156,12 @@ var setupControlChannel = func(context context.T, service service.Service, proce
 		return nil, err
 	}
 	controlChannel := channel.(*controlchannel.ControlChannel)
+	recordAgentCheck(context.Log(), updateutil.CheckMgsConnected)
 	return controlChannel, nil
 }
 
+var recordAgentCheck = updateutil.RecordAgentCheck
+
 // ModuleExecute starts the scheduling of the session module
 func (s *Session) ModuleExecute(context context.T) (err error) {
 	log := s.context.Log()
@@ -183,7 +185,7 @@ func (s *Session) ModuleExecute(context context.T) (err error) {
 	go s.listenReply(resultChan, instanceId)
 
 	log.Info("SSM Agent is trying to setup control channel for Session Manager module.")
-	s.controlChannel, err = setupControlChannel(s.context, s.service, s.processor, instanceId)
+	s.controlChannel, err = setupControlChannel(s.context, s.service, s.processor, s.sessionLimiter, instanceId)
 	if err != nil {
 		log.Errorf("Failed to setup control channel, err: %v", err)
 		return
@@ -234,6 +236,7 @@ func (s *Session) listenReply(resultChan chan contracts.DocumentResult, instance
 			log.Infof("received plugin: %s result from Processor", res.LastPlugin)
 		} else {
 			log.Infof("session: %s complete", res.MessageID)
+			s.sessionLimiter.Release(res.MessageID)
 
 			//Deleting Old Log Files
 			instanceID, _ := platform.InstanceID()
@@ -335,18 +338,24 @@ func formatAgentTaskCompletePayload(log log.T,
 	}
 
 	payload := mgsContracts.AgentTaskCompletePayload{
-		SchemaVersion:    1,
-		TaskId:           sessionId,
-		Topic:            topic,
-		FinalTaskStatus:  string(pluginResult.Status),
-		IsRoutingFailure: false,
-		AwsAccountId:     "",
-		InstanceId:       instanceId,
-		Output:           sessionPluginResultOutput.Output,
-		S3Bucket:         sessionPluginResultOutput.S3Bucket,
-		S3UrlSuffix:      sessionPluginResultOutput.S3UrlSuffix,
-		CwlGroup:         sessionPluginResultOutput.CwlGroup,
-		CwlStream:        sessionPluginResultOutput.CwlStream,
+		SchemaVersion:     1,
+		TaskId:            sessionId,
+		Topic:             topic,
+		FinalTaskStatus:   string(pluginResult.Status),
+		IsRoutingFailure:  false,
+		AwsAccountId:      "",
+		InstanceId:        instanceId,
+		Output:            sessionPluginResultOutput.Output,
+		S3Bucket:          sessionPluginResultOutput.S3Bucket,
+		S3UrlSuffix:       sessionPluginResultOutput.S3UrlSuffix,
+		CwlGroup:          sessionPluginResultOutput.CwlGroup,
+		CwlStream:         sessionPluginResultOutput.CwlStream,
+		TerminationReason: string(sessionPluginResultOutput.TerminationReason),
+		Metrics:           sessionPluginResultOutput.Metrics,
+		CommandResult:     sessionPluginResultOutput.CommandResult,
+	}
+	if payload.Metrics != nil {
+		log.Infof("Session %s metrics: %s", sessionId, payload.Metrics)
 	}
 	return payload
 }
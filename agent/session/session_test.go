@@ -91,7 +91,7 @@ func (suite *SessionTestSuite) TestModuleExecute() {
 	suite.mockProcessor.On("Start").Return(resChan, nil)
 	suite.mockControlChannel.On("SendMessage", mock.Anything, mock.Anything, websocket.BinaryMessage).Return(nil)
 
-	setupControlChannel = func(context context.T, service service.Service, processor processor.Processor, instanceId string) (controlchannel.IControlChannel, error) {
+	setupControlChannel = func(context context.T, service service.Service, processor processor.Processor, sessionLimiter *controlchannel.SessionLimiter, instanceId string) (controlchannel.IControlChannel, error) {
 		return suite.mockControlChannel, nil
 	}
 
@@ -261,6 +261,42 @@ func (suite *SessionTestSuite) TestBuildAgentTaskCompleteWhenPluginResultOutputH
 	assert.Equal(suite.T(), cwlStream, payload.CwlStream)
 }
 
+func (suite *SessionTestSuite) TestBuildAgentTaskCompleteWhenPluginResultOutputHasMetrics() {
+	log := log.NewMockLog()
+	metrics := mgsContracts.SessionMetrics{
+		BytesIn:                    100,
+		BytesOut:                   2048,
+		MessagesIn:                 10,
+		MessagesOut:                3,
+		Retransmissions:            1,
+		MinRoundTripTimeMillis:     20,
+		MaxRoundTripTimeMillis:     80,
+		AverageRoundTripTimeMillis: 42.5,
+	}
+	pluginResults := make(map[string]*contracts.PluginResult)
+	pluginResults["Standard_Stream"] = &contracts.PluginResult{
+		PluginName: "Standard_Stream",
+		Status:     contracts.ResultStatusSuccess,
+		Output:     mgsContracts.SessionPluginResultOutput{Metrics: &metrics},
+	}
+	result := contracts.DocumentResult{
+		Status:        status,
+		PluginResults: pluginResults,
+		LastPlugin:    "Standard_Stream",
+		MessageID:     messageId,
+		NPlugins:      1,
+	}
+
+	msg, err := buildAgentTaskComplete(log, result, instanceId)
+	assert.Nil(suite.T(), err)
+
+	agentMessage := &mgsContracts.AgentMessage{}
+	agentMessage.Deserialize(log, msg)
+	payload := &mgsContracts.AgentTaskCompletePayload{}
+	json.Unmarshal(agentMessage.Payload, payload)
+	assert.Equal(suite.T(), &metrics, payload.Metrics)
+}
+
 func (suite *SessionTestSuite) TestBuildAgentTaskCompleteWhenPluginIdIsEmpty() {
 	log := log.NewMockLog()
 	pluginResults := make(map[string]*contracts.PluginResult)
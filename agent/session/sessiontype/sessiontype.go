@@ -0,0 +1,113 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package sessiontype keeps the session types known to the agent, built in or registered by custom builds,
+// and the document properties the custom session types require.
+package sessiontype
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// Definition describes a custom session type.
+type Definition struct {
+	// Name is the sessionType of the session documents served by the session type, also used as plugin name.
+	Name string
+	// RequiredProperties are the properties session documents of this type must set.
+	RequiredProperties []string
+}
+
+// builtInSessionTypes is the list of the session types implemented by the agent.
+var builtInSessionTypes = map[string]struct{}{
+	appconfig.PluginNameStandardStream:         {},
+	appconfig.PluginNamePort:                   {},
+	appconfig.PluginNameFileTransfer:           {},
+	appconfig.PluginNameInteractiveCommands:    {},
+	appconfig.PluginNameNonInteractiveCommands: {},
+}
+
+var lock sync.RWMutex
+
+// registeredSessionTypes stores the custom session types, indexed by name.
+var registeredSessionTypes = map[string]Definition{}
+
+// Register registers a custom session type.
+// Built in session types cannot be replaced and a session type can only be registered once.
+func Register(definition Definition) error {
+	if definition.Name == "" {
+		return errors.New("session type name is empty")
+	}
+	if IsBuiltIn(definition.Name) {
+		return fmt.Errorf("session type %s is built in", definition.Name)
+	}
+	for _, property := range definition.RequiredProperties {
+		if property == "" {
+			return fmt.Errorf("session type %s requires a property with an empty name", definition.Name)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := registeredSessionTypes[definition.Name]; ok {
+		return fmt.Errorf("session type %s is already registered", definition.Name)
+	}
+	definition.RequiredProperties = append([]string(nil), definition.RequiredProperties...)
+	registeredSessionTypes[definition.Name] = definition
+	return nil
+}
+
+// IsBuiltIn returns true if the session type is implemented by the agent.
+func IsBuiltIn(name string) bool {
+	_, ok := builtInSessionTypes[name]
+	return ok
+}
+
+// IsKnown returns true if the session type is built in or registered.
+func IsKnown(name string) bool {
+	if IsBuiltIn(name) {
+		return true
+	}
+	_, ok := Registered(name)
+	return ok
+}
+
+// Registered returns the definition of a registered custom session type.
+func Registered(name string) (definition Definition, ok bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	definition, ok = registeredSessionTypes[name]
+	return
+}
+
+// ValidateProperties checks the session document properties set every property required by the session type.
+// Properties set to null or to an empty string are missing.
+func (definition Definition) ValidateProperties(properties interface{}) error {
+	if len(definition.RequiredProperties) == 0 {
+		return nil
+	}
+	propertyMap, ok := properties.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("session type %s requires properties %v", definition.Name, definition.RequiredProperties)
+	}
+	for _, property := range definition.RequiredProperties {
+		value, ok := propertyMap[property]
+		if !ok || value == nil || value == "" {
+			return fmt.Errorf("session type %s requires property %s", definition.Name, property)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sessiontype
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	definition := Definition{Name: "Custom_Register", RequiredProperties: []string{"target"}}
+
+	assert.False(t, IsKnown(definition.Name))
+	assert.Nil(t, Register(definition))
+	assert.True(t, IsKnown(definition.Name))
+	assert.False(t, IsBuiltIn(definition.Name))
+	registered, ok := Registered(definition.Name)
+	assert.True(t, ok)
+	assert.Equal(t, definition, registered)
+
+	assert.NotNil(t, Register(definition))
+}
+
+func TestRegisterInvalidDefinition(t *testing.T) {
+	assert.NotNil(t, Register(Definition{}))
+	assert.NotNil(t, Register(Definition{Name: appconfig.PluginNamePort}))
+	assert.NotNil(t, Register(Definition{Name: "Custom_EmptyProperty", RequiredProperties: []string{""}}))
+	assert.False(t, IsKnown("Custom_EmptyProperty"))
+}
+
+func TestIsKnownBuiltIn(t *testing.T) {
+	for _, name := range []string{
+		appconfig.PluginNameStandardStream,
+		appconfig.PluginNamePort,
+		appconfig.PluginNameFileTransfer,
+		appconfig.PluginNameInteractiveCommands,
+	} {
+		assert.True(t, IsKnown(name), name)
+		assert.True(t, IsBuiltIn(name), name)
+	}
+	assert.False(t, IsKnown("Unknown"))
+}
+
+func TestValidateProperties(t *testing.T) {
+	definition := Definition{Name: "Custom_Validate", RequiredProperties: []string{"host", "port"}}
+
+	assert.Nil(t, definition.ValidateProperties(map[string]interface{}{"host": "db", "port": 5432, "extra": true}))
+	assert.NotNil(t, definition.ValidateProperties(map[string]interface{}{"host": "db"}))
+	assert.NotNil(t, definition.ValidateProperties(map[string]interface{}{"host": "", "port": 5432}))
+	assert.NotNil(t, definition.ValidateProperties(map[string]interface{}{"host": nil, "port": 5432}))
+	assert.NotNil(t, definition.ValidateProperties(nil))
+	assert.NotNil(t, definition.ValidateProperties("db:5432"))
+
+	assert.Nil(t, Definition{Name: "Custom_NoProperties"}.ValidateProperties(nil))
+}
@@ -187,6 +187,29 @@ func (_m *Service) GetParameters(_a0 log.T, paramNames []string) (*ssm.GetParame
 	return r0, r1
 }
 
+// GetParametersByPath provides a mock function with given fields: _a0, path, recursive, withDecryption, nextToken
+func (_m *Service) GetParametersByPath(_a0 log.T, path string, recursive bool, withDecryption bool, nextToken *string) (*ssm.GetParametersByPathOutput, error) {
+	ret := _m.Called(_a0, path, recursive, withDecryption, nextToken)
+
+	var r0 *ssm.GetParametersByPathOutput
+	if rf, ok := ret.Get(0).(func(log.T, string, bool, bool, *string) *ssm.GetParametersByPathOutput); ok {
+		r0 = rf(_a0, path, recursive, withDecryption, nextToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ssm.GetParametersByPathOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(log.T, string, bool, bool, *string) error); ok {
+		r1 = rf(_a0, path, recursive, withDecryption, nextToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListAssociations provides a mock function with given fields: _a0, instanceID
 func (_m *Service) ListAssociations(_a0 log.T, instanceID string) (*ssm.ListAssociationsOutput, error) {
 	ret := _m.Called(_a0, instanceID)
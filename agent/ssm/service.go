@@ -72,6 +72,7 @@ type Service interface {
 	UpdateEmptyInstanceInformation(log log.T, agentVersion, agentName string) (response *ssm.UpdateInstanceInformationOutput, err error)
 	GetParameters(log log.T, paramNames []string) (response *ssm.GetParametersOutput, err error)
 	GetDecryptedParameters(log log.T, paramNames []string) (response *ssm.GetParametersOutput, err error)
+	GetParametersByPath(log log.T, path string, recursive bool, withDecryption bool, nextToken *string) (response *ssm.GetParametersByPathOutput, err error)
 }
 
 var ssmStopPolicy *sdkutil.StopPolicy
@@ -530,3 +531,22 @@ func (svc *sdkService) GetDecryptedParameters(log log.T, paramNames []string) (r
 	}
 	return
 }
+
+func (svc *sdkService) GetParametersByPath(log log.T, path string, recursive bool, withDecryption bool, nextToken *string) (response *ssm.GetParametersByPathOutput, err error) {
+	serviceParams := ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(recursive),
+		WithDecryption: aws.Bool(withDecryption),
+		NextToken:      nextToken,
+	}
+
+	log.Debugf("Calling GetParametersByPath API with path - %v, recursive - %v", path, recursive)
+
+	if response, err = svc.sdk.GetParametersByPath(&serviceParams); err != nil {
+		errorString := fmt.Errorf("Encountered error while calling GetParametersByPath API. Error: %v", err)
+		log.Debug(err)
+		sdkutil.HandleAwsError(log, err, ssmStopPolicy)
+		return nil, errorString
+	}
+	return
+}
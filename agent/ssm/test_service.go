@@ -145,6 +145,12 @@ func (m *Mock) GetDecryptedParameters(log log.T, paramNames []string) (response
 	return args.Get(0).(*ssm.GetParametersOutput), args.Error(1)
 }
 
+// GetParametersByPath mocks the GetParametersByPath function.
+func (m *Mock) GetParametersByPath(log log.T, path string, recursive bool, withDecryption bool, nextToken *string) (response *ssm.GetParametersByPathOutput, err error) {
+	args := m.Called(log, path, recursive, withDecryption, nextToken)
+	return args.Get(0).(*ssm.GetParametersByPathOutput), args.Error(1)
+}
+
 // PutComplianceItem mocks the PutComplianceItem function
 func (m *Mock) PutComplianceItems(
 	log log.T,
@@ -15,7 +15,9 @@
 package task
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // State represents the state of a job.
@@ -30,6 +32,9 @@ const (
 
 	// ShutDown indicates a job for which ShutDown has been requested.
 	ShutDown State = 3
+
+	// Failed indicates a job that terminated abnormally by panicking.
+	Failed State = 4
 )
 
 // CancelFlag is an object that is passed to any job submitted to a task in order to
@@ -50,8 +55,8 @@ type CancelFlag interface {
 	State() State
 
 	// Wait blocks the caller until either a cancel has been requested or the
-	// task has completed normally. Returns Canceled if cancel has been requested,
-	// or Completed if the task completed normally.
+	// task has completed. Returns Canceled if cancel has been requested,
+	// Completed if the task completed normally, or Failed if the task panicked.
 	// This is intended to be used to wake up a job that may be waiting on some resources, as follows:
 	// The main job starts a go routine that calls Wait. The main job then does its processing.
 	// During processing the job may be waiting on certain events/conditions.
@@ -65,12 +70,30 @@ type ChanneledCancelFlag struct {
 	state  State
 	ch     chan struct{}
 	closed bool
-	m      sync.RWMutex
+	// done is closed once the flag is set to either Canceled or ShutDown state
+	done       chan struct{}
+	doneClosed bool
+	m          sync.RWMutex
 }
 
 // NewChanneledCancelFlag creates a new instance of ChanneledCancelFlag.
 func NewChanneledCancelFlag() *ChanneledCancelFlag {
-	flag := &ChanneledCancelFlag{ch: make(chan struct{})}
+	flag := &ChanneledCancelFlag{ch: make(chan struct{}), done: make(chan struct{})}
+	return flag
+}
+
+// NewChanneledCancelFlagFromContext creates a new instance of ChanneledCancelFlag
+// that is set to Canceled once the given context is done.
+func NewChanneledCancelFlagFromContext(ctx context.Context) *ChanneledCancelFlag {
+	flag := NewChanneledCancelFlag()
+	go func() {
+		select {
+		case <-ctx.Done():
+			flag.setIfUnset(Canceled)
+		case <-flag.ch:
+			// the flag has been set by other means, stop watching the context
+		}
+	}()
 	return flag
 }
 
@@ -101,6 +124,36 @@ func (t *ChanneledCancelFlag) Wait() (state State) {
 	return t.State()
 }
 
+// Done returns a channel that is closed once this flag is set to either Canceled or ShutDown state.
+func (t *ChanneledCancelFlag) Done() <-chan struct{} {
+	return t.done
+}
+
+// Context returns a context that is canceled once this flag is set to either
+// Canceled or ShutDown state. The context is not canceled when the flag is set
+// to Completed state.
+func (t *ChanneledCancelFlag) Context() context.Context {
+	return flagContext{flag: t}
+}
+
+// flagContext is a context canceled by a cancel flag, it has no deadline and no values.
+type flagContext struct {
+	flag *ChanneledCancelFlag
+}
+
+func (c flagContext) Deadline() (deadline time.Time, ok bool) { return }
+func (c flagContext) Done() <-chan struct{}                   { return c.flag.Done() }
+func (c flagContext) Value(key interface{}) interface{}       { return nil }
+
+func (c flagContext) Err() error {
+	select {
+	case <-c.flag.Done():
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
 // Set sets the state of this flag and wakes up waiting callers.
 func (t *ChanneledCancelFlag) Set(state State) {
 	t.m.Lock()
@@ -113,4 +166,25 @@ func (t *ChanneledCancelFlag) Set(state State) {
 		close(t.ch)
 		t.closed = true
 	}
+	t.closeDone()
+}
+
+// setIfUnset sets the state of this flag only if it has not been set before.
+func (t *ChanneledCancelFlag) setIfUnset(state State) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if !t.closed {
+		t.state = state
+		close(t.ch)
+		t.closed = true
+		t.closeDone()
+	}
+}
+
+// closeDone closes the done channel when the flag is canceled or shut down, the lock must be held.
+func (t *ChanneledCancelFlag) closeDone() {
+	if !t.doneClosed && (t.state == Canceled || t.state == ShutDown) {
+		close(t.done)
+		t.doneClosed = true
+	}
 }
@@ -14,7 +14,10 @@
 package task
 
 import (
+	"context"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +70,56 @@ func testWait(t *testing.T, state State) {
 	assert.Equal(t, state, <-ch)
 	assert.Equal(t, flag.Canceled(), state == Canceled)
 }
+
+// TestContext tests that the derived context is canceled only when the flag
+// is set to Canceled or ShutDown
+func TestContext(t *testing.T) {
+	for _, state := range []State{Canceled, ShutDown} {
+		flag := NewChanneledCancelFlag()
+		ctx := flag.Context()
+		assert.Equal(t, ctx, flag.Context())
+		assert.Nil(t, ctx.Err())
+
+		flag.Set(state)
+		<-ctx.Done()
+		assert.Equal(t, context.Canceled, ctx.Err())
+	}
+
+	flag := NewChanneledCancelFlag()
+	ctx := flag.Context()
+	flag.Set(Completed)
+	select {
+	case <-ctx.Done():
+		assert.Fail(t, "context should not be canceled when the flag is completed")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// TestContextOfUnsetFlag tests that the contexts of flags that are never set don't leak goroutines
+func TestContextOfUnsetFlag(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		ctx := NewChanneledCancelFlag().Context()
+		assert.Nil(t, ctx.Err())
+	}
+	assert.True(t, runtime.NumGoroutine() <= goroutines)
+}
+
+// TestNewChanneledCancelFlagFromContext tests that the flag is canceled when the context is done
+func TestNewChanneledCancelFlagFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	flag := NewChanneledCancelFlagFromContext(ctx)
+	assert.False(t, flag.Canceled())
+
+	cancel()
+	assert.Equal(t, Canceled, flag.Wait())
+	assert.True(t, flag.Canceled())
+
+	// a flag set by other means is not affected by the context
+	ctx, cancel = context.WithCancel(context.Background())
+	flag = NewChanneledCancelFlagFromContext(ctx)
+	flag.Set(Completed)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, Completed, flag.State())
+}
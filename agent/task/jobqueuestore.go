@@ -0,0 +1,116 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// JobQueueStore persists the ids of the jobs that have been submitted to a pool
+// but have not started yet, so that they can be re-submitted after a restart.
+type JobQueueStore interface {
+	// Enqueue records that the job has been accepted by the pool.
+	Enqueue(jobID string) error
+
+	// Dequeue records that the job has left the queue, either because it
+	// started or because it was discarded before starting.
+	Dequeue(jobID string) error
+
+	// QueuedJobs returns the ids of the queued jobs in submission order.
+	QueuedJobs() ([]string, error)
+}
+
+// FileJobQueueStore is a JobQueueStore that keeps one file per queued job in a directory.
+// The content of each file is the submission sequence of the job.
+type FileJobQueueStore struct {
+	dir          string
+	lastSequence int64
+	m            sync.Mutex
+}
+
+// NewFileJobQueueStore creates a new FileJobQueueStore persisting jobs in the given directory.
+func NewFileJobQueueStore(dir string) *FileJobQueueStore {
+	return &FileJobQueueStore{dir: dir}
+}
+
+// Enqueue writes the file of the given job.
+func (s *FileJobQueueStore) Enqueue(jobID string) (err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err = fileutil.MakeDirs(s.dir); err != nil {
+		return
+	}
+	// keep the sequence strictly increasing even if the clock resolution is coarse
+	sequence := time.Now().UnixNano()
+	if sequence <= s.lastSequence {
+		sequence = s.lastSequence + 1
+	}
+	s.lastSequence = sequence
+	_, err = fileutil.WriteIntoFileWithPermissions(filepath.Join(s.dir, jobID), strconv.FormatInt(sequence, 10), os.FileMode(int(appconfig.ReadWriteAccess)))
+	return
+}
+
+// Dequeue deletes the file of the given job, if any.
+func (s *FileJobQueueStore) Dequeue(jobID string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	fileName := filepath.Join(s.dir, jobID)
+	if !fileutil.Exists(fileName) {
+		return nil
+	}
+	return fileutil.DeleteFile(fileName)
+}
+
+// QueuedJobs reads the files of the queued jobs and returns their ids ordered by submission sequence.
+func (s *FileJobQueueStore) QueuedJobs() (jobIDs []string, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	jobIDs = []string{}
+	if !fileutil.Exists(s.dir) {
+		return
+	}
+
+	var files []string
+	if files, err = fileutil.GetFileNames(s.dir); err != nil {
+		return
+	}
+
+	sequences := make(map[string]int64, len(files))
+	for _, jobID := range files {
+		var content string
+		if content, err = fileutil.ReadAllText(filepath.Join(s.dir, jobID)); err != nil {
+			return
+		}
+		// unreadable sequences sort first, which keeps the job rather than dropping it
+		sequences[jobID], _ = strconv.ParseInt(strings.TrimSpace(content), 10, 64)
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	sort.SliceStable(jobIDs, func(i, j int) bool {
+		return sequences[jobIDs[i]] < sequences[jobIDs[j]]
+	})
+	return
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/times"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileJobQueueStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobqueue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileJobQueueStore(filepath.Join(dir, "queued"))
+
+	// missing directory means no queued jobs
+	jobs, err := store.QueuedJobs()
+	assert.Nil(t, err)
+	assert.Empty(t, jobs)
+
+	assert.Nil(t, store.Enqueue("job-b"))
+	assert.Nil(t, store.Enqueue("job-a"))
+	assert.Nil(t, store.Enqueue("job-c"))
+
+	jobs, err = store.QueuedJobs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"job-b", "job-a", "job-c"}, jobs)
+
+	assert.Nil(t, store.Dequeue("job-a"))
+	// dequeue of an unknown job is a no-op
+	assert.Nil(t, store.Dequeue("job-a"))
+
+	jobs, err = store.QueuedJobs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"job-b", "job-c"}, jobs)
+}
+
+// memoryJobQueueStore is an in-memory JobQueueStore used to observe the pool.
+type memoryJobQueueStore struct {
+	jobs []string
+	m    sync.Mutex
+}
+
+func (s *memoryJobQueueStore) Enqueue(jobID string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.jobs = append(s.jobs, jobID)
+	return nil
+}
+
+func (s *memoryJobQueueStore) Dequeue(jobID string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i, id := range s.jobs {
+		if id == jobID {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memoryJobQueueStore) QueuedJobs() ([]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]string{}, s.jobs...), nil
+}
+
+func TestPoolWithJobQueueStore(t *testing.T) {
+	clock := times.NewMockedClock()
+	waitTimeout := 100 * time.Millisecond
+	shutdownTimeout := 10000 * time.Millisecond
+	clock.On("After", waitTimeout).Return(clock.AfterChannel)
+	clock.On("After", shutdownTimeout).Return(clock.AfterChannel)
+	clock.On("After", shutdownTimeout+waitTimeout).Return(clock.AfterChannel)
+
+	store := &memoryJobQueueStore{}
+	pool := NewPoolWithJobQueueStore(logger, 1, waitTimeout, clock, store)
+
+	started := make(chan bool)
+	release := make(chan bool)
+	err := pool.Submit(logger, "running", func(CancelFlag) {
+		started <- true
+		<-release
+	})
+	assert.Nil(t, err)
+	<-started
+
+	// the running job has left the queue
+	jobs, _ := store.QueuedJobs()
+	assert.Empty(t, jobs)
+
+	// the only worker is busy, so these jobs stay queued
+	go pool.Submit(logger, "canceled", func(CancelFlag) {})
+	go pool.Submit(logger, "queued", func(CancelFlag) {})
+	time.Sleep(10 * time.Millisecond)
+	jobs, _ = store.QueuedJobs()
+	assert.Len(t, jobs, 2)
+
+	// canceled jobs are removed from the queue
+	assert.True(t, pool.Cancel("canceled"))
+	jobs, _ = store.QueuedJobs()
+	assert.Equal(t, []string{"queued"}, jobs)
+
+	// jobs that have not started before shutdown are kept in the queue
+	pool.Shutdown()
+	close(release)
+	jobs, _ = store.QueuedJobs()
+	assert.Equal(t, []string{"queued"}, jobs)
+}
@@ -15,9 +15,40 @@ package task
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
+// Submitter identifies the component that submitted a job.
+type Submitter string
+
+const (
+	// SubmitterUnknown is the submitter of jobs submitted without submitter.
+	SubmitterUnknown Submitter = ""
+
+	// SubmitterMDS is the submitter of commands received from the Message Delivery Service.
+	SubmitterMDS Submitter = "MDS"
+
+	// SubmitterMGS is the submitter of sessions received from the Message Gateway Service.
+	SubmitterMGS Submitter = "MGS"
+
+	// SubmitterAssociation is the submitter of association documents.
+	SubmitterAssociation Submitter = "Association"
+
+	// SubmitterOffline is the submitter of commands received from the offline service.
+	SubmitterOffline Submitter = "Offline"
+)
+
+// JobInfo holds the metadata of a job.
+type JobInfo struct {
+	JobID       string
+	Submitter   Submitter
+	SubmittedAt time.Time
+	// StartedAt is the zero time if the job has not been started yet.
+	StartedAt time.Time
+}
+
 // JobStore is a collection of jobs.
 type JobStore struct {
 	jobs map[string]*JobToken
@@ -46,6 +77,17 @@ func (t *JobStore) AddJob(jobID string, token *JobToken) error {
 	return nil
 }
 
+// ReplaceJob adds a job to this task, replacing any job with the same id.
+// Returns the replaced job, if any.
+func (t *JobStore) ReplaceJob(jobID string, token *JobToken) (previous *JobToken, found bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	previous, found = t.jobs[jobID]
+	t.jobs[jobID] = token
+	return
+}
+
 // GetJob retrieves a job from a task.
 func (t *JobStore) GetJob(jobID string) (token *JobToken, found bool) {
 	t.m.RLock()
@@ -61,6 +103,19 @@ func (t *JobStore) DeleteJob(jobID string) {
 	delete(t.jobs, jobID)
 }
 
+// DeleteJobIfMatches deletes the job with the given jobID only if it is still
+// the job identified by the given token. Returns true if the job was deleted.
+func (t *JobStore) DeleteJobIfMatches(jobID string, token *JobToken) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	s, ok := t.jobs[jobID]
+	if !ok || s.cancelFlag != token.cancelFlag {
+		return false
+	}
+	delete(t.jobs, jobID)
+	return true
+}
+
 // DeleteAllJobs deletes all the jobs of this task.
 // Returns the deleted jobs.
 func (t *JobStore) DeleteAllJobs() map[string]*JobToken {
@@ -70,3 +125,40 @@ func (t *JobStore) DeleteAllJobs() map[string]*JobToken {
 	t.jobs = map[string]*JobToken{}
 	return jobs
 }
+
+// Size returns the number of jobs of this task.
+func (t *JobStore) Size() int {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return len(t.jobs)
+}
+
+// MarkJobStarted records the start time of the job with the given jobID, only
+// if it is still the job identified by the given token.
+func (t *JobStore) MarkJobStarted(jobID string, token *JobToken, startedAt time.Time) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	s, ok := t.jobs[jobID]
+	if ok && s.cancelFlag == token.cancelFlag {
+		s.startedAt = startedAt
+	}
+}
+
+// ListJobs returns the metadata of the jobs of this task, ordered by submission time.
+func (t *JobStore) ListJobs() []JobInfo {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	jobs := make([]JobInfo, 0, len(t.jobs))
+	for jobID, token := range t.jobs {
+		jobs = append(jobs, JobInfo{
+			JobID:       jobID,
+			Submitter:   token.submitter,
+			SubmittedAt: token.submittedAt,
+			StartedAt:   token.startedAt,
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].SubmittedAt.Before(jobs[j].SubmittedAt)
+	})
+	return jobs
+}
@@ -16,6 +16,7 @@ package task
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -76,3 +77,45 @@ func testAddAndGet(t *testing.T, jobs map[string]*JobToken) (tsk *JobStore) {
 	}
 	return
 }
+
+func TestReplaceJob(t *testing.T) {
+	tsk := NewJobStore()
+	token := &JobToken{id: "job", cancelFlag: NewChanneledCancelFlag()}
+	previous, found := tsk.ReplaceJob("job", token)
+	assert.False(t, found)
+	assert.Nil(t, previous)
+
+	token2 := &JobToken{id: "job", cancelFlag: NewChanneledCancelFlag()}
+	previous, found = tsk.ReplaceJob("job", token2)
+	assert.True(t, found)
+	assert.Equal(t, token, previous)
+
+	// the replaced token no longer matches the stored job
+	assert.False(t, tsk.DeleteJobIfMatches("job", token))
+	j, found := tsk.GetJob("job")
+	assert.True(t, found)
+	assert.Equal(t, token2, j)
+
+	assert.True(t, tsk.DeleteJobIfMatches("job", token2))
+	_, found = tsk.GetJob("job")
+	assert.False(t, found)
+}
+
+func TestListJobs(t *testing.T) {
+	store := NewJobStore()
+	submittedAt := time.Now()
+	first := &JobToken{id: "first", cancelFlag: NewChanneledCancelFlag(), submitter: SubmitterMDS, submittedAt: submittedAt}
+	second := &JobToken{id: "second", cancelFlag: NewChanneledCancelFlag(), submitter: SubmitterAssociation, submittedAt: submittedAt.Add(time.Second)}
+	assert.Nil(t, store.AddJob("second", second))
+	assert.Nil(t, store.AddJob("first", first))
+
+	startedAt := submittedAt.Add(2 * time.Second)
+	store.MarkJobStarted("first", first, startedAt)
+	// a token that no longer matches the stored job is ignored
+	store.MarkJobStarted("second", &JobToken{id: "second", cancelFlag: NewChanneledCancelFlag()}, startedAt)
+
+	assert.Equal(t, []JobInfo{
+		{JobID: "first", Submitter: SubmitterMDS, SubmittedAt: submittedAt, StartedAt: startedAt},
+		{JobID: "second", Submitter: SubmitterAssociation, SubmittedAt: submittedAt.Add(time.Second)},
+	}, store.ListJobs())
+}
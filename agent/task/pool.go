@@ -15,6 +15,8 @@ package task
 
 import (
 	"fmt"
+	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,12 +24,41 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/times"
 )
 
+// drainPollInterval is how often Drain checks whether the jobs of a pool have terminated.
+const drainPollInterval = 100 * time.Millisecond
+
 // Pool is a pool of jobs.
 type Pool interface {
 	// Submit schedules a job to be executed in the associated worker pool.
 	// Returns an error if a job with the same name already exists.
 	Submit(log log.T, jobID string, job Job) error
 
+	// SubmitWithPolicy schedules a job to be executed in the associated worker pool.
+	// The policy decides what happens when a job with the same name already exists.
+	SubmitWithPolicy(log log.T, jobID string, job Job, policy SubmitPolicy) error
+
+	// SubmitSerialized schedules a job to be executed in the associated worker pool,
+	// recording the given submitter in the metadata of the job.
+	// Jobs submitted with the same serialization key are executed one at a time,
+	// in submission order, while jobs with different keys run in parallel.
+	// Returns an error if a job with the same name already exists.
+	SubmitSerialized(log log.T, submitter Submitter, jobID string, serialKey string, job Job) error
+
+	// SubmitFrom schedules a job to be executed in the associated worker pool,
+	// recording the given submitter in the metadata of the job.
+	// Returns an error if a job with the same name already exists.
+	SubmitFrom(log log.T, submitter Submitter, jobID string, job Job) error
+
+	// SubmitAfter schedules a job to be executed in the associated worker pool once
+	// the given delay has elapsed. The job can be canceled before it is due.
+	// Returns an error if a job with the same name already exists.
+	SubmitAfter(log log.T, delay time.Duration, jobID string, job Job) error
+
+	// SubmitAt schedules a job to be executed in the associated worker pool at the given time.
+	// The job can be canceled before it is due.
+	// Returns an error if a job with the same name already exists.
+	SubmitAt(log log.T, at time.Time, jobID string, job Job) error
+
 	// Cancel cancels the given job. Jobs that have not started yet will never be started.
 	// Jobs that are running will have their CancelFlag set to the Canceled state.
 	// It is the responsibility of the job to terminate within a reasonable time.
@@ -38,59 +69,259 @@ type Pool interface {
 	// Shutdown cancels all the jobs and shuts down the workers.
 	Shutdown()
 
+	// Drain stops accepting new jobs and waits until the queued and running jobs
+	// have terminated or until the timeout has elapsed, whichever comes first.
+	// Unlike Shutdown, jobs are not canceled and the workers keep running, the
+	// pool accepts new jobs again once Drain returns.
+	// Returns true if all the jobs terminated before the timeout.
+	Drain(timeout time.Duration) (drained bool)
+
 	// ShutdownAndWait calls Shutdown then waits until all the workers have exited
-	// or until the timeout has elapsed, whichever comes first. Returns true if all
-	// workers terminated before the timeout or false if the timeout expired.
-	ShutdownAndWait(timeout time.Duration) (finished bool)
+	// or until the timeout has elapsed, whichever comes first. Returns a report
+	// of the jobs that were running when the shutdown was requested.
+	ShutdownAndWait(timeout time.Duration) (report ShutdownReport)
 
 	// HasJob returns if jobStore has specified job
 	HasJob(jobID string) bool
+
+	// ListJobs returns the metadata of the queued, scheduled and running jobs, ordered by submission time.
+	ListJobs() []JobInfo
+
+	// SetJobEventHandler sets the handler that receives the lifecycle events of the jobs of this pool.
+	SetJobEventHandler(handler JobEventHandler)
+
+	// Resize changes the number of workers of the pool. Running jobs are not interrupted,
+	// the workers in excess exit once they are done with their current job.
+	Resize(maxParallel int)
+}
+
+// JobEventType is the type of a job lifecycle event.
+type JobEventType int
+
+const (
+	// JobStarted indicates that a worker started the job.
+	JobStarted JobEventType = 1
+
+	// JobCompleted indicates that the job returned normally.
+	JobCompleted JobEventType = 2
+
+	// JobFailed indicates that the job panicked.
+	JobFailed JobEventType = 3
+
+	// JobAbandoned indicates that the job failed to terminate within the cancel wait duration.
+	JobAbandoned JobEventType = 4
+)
+
+// JobEvent describes a change in the lifecycle of a job.
+type JobEvent struct {
+	JobID string
+	Type  JobEventType
+	// State is the state of the cancel flag of the job when the event occurred.
+	State State
+	// Err is the reason of the failure for JobFailed events.
+	Err error
+}
+
+// JobEventHandler is a function that receives job lifecycle events.
+type JobEventHandler func(event JobEvent)
+
+// SubmitPolicy defines how a pool handles a job submitted with the id of an existing job.
+type SubmitPolicy int
+
+const (
+	// Reject fails the submission if a job with the same id already exists.
+	Reject SubmitPolicy = 0
+
+	// Replace cancels the existing job with the same id and schedules the new job.
+	Replace SubmitPolicy = 1
+
+	// Coalesce drops the new job if a job with the same id is already queued or running.
+	Coalesce SubmitPolicy = 2
+)
+
+// String returns the name of the policy.
+func (policy SubmitPolicy) String() string {
+	switch policy {
+	case Reject:
+		return "Reject"
+	case Replace:
+		return "Replace"
+	case Coalesce:
+		return "Coalesce"
+	default:
+		return fmt.Sprintf("SubmitPolicy(%d)", int(policy))
+	}
+}
+
+// JobOutcome is the final state of a job that was running when its pool was shut down.
+type JobOutcome int
+
+const (
+	// JobFinished indicates that the job returned before the shutdown timeout.
+	JobFinished JobOutcome = 1
+
+	// JobCanceled indicates that the job returned after being canceled at the shutdown timeout.
+	JobCanceled JobOutcome = 2
+
+	// JobAbandonedAtShutdown indicates that the job failed to terminate and was abandoned.
+	JobAbandonedAtShutdown JobOutcome = 3
+)
+
+// String returns the name of the outcome.
+func (outcome JobOutcome) String() string {
+	switch outcome {
+	case JobFinished:
+		return "Finished"
+	case JobCanceled:
+		return "Canceled"
+	case JobAbandonedAtShutdown:
+		return "Abandoned"
+	default:
+		return fmt.Sprintf("JobOutcome(%d)", int(outcome))
+	}
+}
+
+// JobReport describes the final state of a job interrupted by a pool shutdown.
+type JobReport struct {
+	JobID   string
+	Outcome JobOutcome
+	// Duration is how long the job ran, until it returned or was abandoned.
+	Duration time.Duration
+}
+
+// ShutdownReport describes how the jobs of a pool terminated during ShutdownAndWait.
+type ShutdownReport struct {
+	// Finished is true if all workers terminated before the timeout.
+	Finished bool
+	// Jobs holds the reports of the jobs that were running when the shutdown was requested.
+	Jobs []JobReport
+}
+
+// Log logs the jobs that were interrupted by the shutdown of the named pool.
+func (report ShutdownReport) Log(log log.T, poolName string) {
+	if !report.Finished {
+		log.Warnf("%v did not shut down within the timeout", poolName)
+	}
+	for _, job := range report.Jobs {
+		if job.Outcome == JobFinished {
+			log.Infof("%v: job %v finished during shutdown after %v", poolName, job.JobID, job.Duration)
+		} else {
+			log.Warnf("%v: job %v was %v during shutdown after %v", poolName, job.JobID, strings.ToLower(job.Outcome.String()), job.Duration)
+		}
+	}
+}
+
+// runningJob holds the info of a job that is being processed by a worker.
+type runningJob struct {
+	id    string
+	start time.Time
 }
 
 // pool implements a task pool where all jobs are managed by a root task
 type pool struct {
 	log            log.T
 	jobQueue       chan JobToken
+	shutdownChan   chan struct{}
 	nWorkers       int
 	doneWorker     chan struct{}
 	isShutdown     bool
+	isDraining     bool
 	clock          times.Clock
 	mut            sync.Mutex
 	jobStore       *JobStore
+	queueStore     JobQueueStore
+	eventHandler   JobEventHandler
 	cancelDuration time.Duration
+	running        map[*ChanneledCancelFlag]runningJob
+	// shutdownJobs is non-nil once ShutdownAndWait has been called and collects the reports of the running jobs.
+	shutdownJobs     []JobReport
+	shutdownCanceled bool
+	// serialKeys holds the serialization keys of the jobs that are queued or running,
+	// along with the jobs of the same key that wait for their turn.
+	serialKeys map[string][]JobToken
+	// maxWorkers is the number of workers requested, the workers in excess retire
+	// when resizedChan is closed or after their current job.
+	maxWorkers   int
+	resizedChan  chan struct{}
+	workerSeq    int
+	jobProcessor func(JobToken)
+	jobSkipped   func(JobToken)
 }
 
 // JobToken embeds a job and its associated info
 type JobToken struct {
-	id         string
-	job        Job
-	cancelFlag *ChanneledCancelFlag
-	log        log.T
+	id          string
+	job         Job
+	cancelFlag  *ChanneledCancelFlag
+	log         log.T
+	serialKey   string
+	submitter   Submitter
+	submittedAt time.Time
+	// startedAt is set by the job store once a worker starts the job.
+	startedAt time.Time
+}
+
+// newJobToken creates the token of a job submitted now.
+func newJobToken(log log.T, jobID string, job Job) JobToken {
+	return JobToken{
+		id:          jobID,
+		job:         job,
+		cancelFlag:  NewChanneledCancelFlag(),
+		log:         log,
+		submittedAt: time.Now(),
+	}
 }
 
 // NewPool creates a new task pool and launches maxParallel workers.
 // The cancelWaitDuration parameter defines how long to wait for a job
 // to complete a cancellation request.
 func NewPool(log log.T, maxParallel int, cancelWaitDuration time.Duration, clock times.Clock) Pool {
+	return NewPoolWithJobQueueStore(log, maxParallel, cancelWaitDuration, clock, nil)
+}
+
+// NewPoolWithJobQueueStore creates a new task pool that records the jobs waiting
+// to be started in the given queue store. Jobs still waiting when the pool is
+// shut down are kept in the store so that they can be re-submitted later.
+func NewPoolWithJobQueueStore(log log.T, maxParallel int, cancelWaitDuration time.Duration, clock times.Clock, queueStore JobQueueStore) Pool {
 	p := &pool{
 		log:            log,
 		jobQueue:       make(chan JobToken),
-		nWorkers:       maxParallel,
+		shutdownChan:   make(chan struct{}),
 		doneWorker:     make(chan struct{}),
+		maxWorkers:     maxParallel,
+		resizedChan:    make(chan struct{}),
 		clock:          clock,
+		queueStore:     queueStore,
 		cancelDuration: cancelWaitDuration,
+		running:        make(map[*ChanneledCancelFlag]runningJob),
+		serialKeys:     make(map[string][]JobToken),
 	}
 
 	p.jobStore = NewJobStore()
 
 	// defines the job processing function.
 	processor := func(j JobToken) {
-		defer p.jobStore.DeleteJob(j.id)
-		process(j.log, j.job, j.cancelFlag, cancelWaitDuration, p.clock)
+		defer p.releaseSerialKey(j)
+		p.dequeue(j)
+		// only delete the job if it has not been replaced in the meantime
+		defer p.jobStore.DeleteJobIfMatches(j.id, &j)
+		p.jobStarted(j)
+		p.jobStore.MarkJobStarted(j.id, &j, time.Now())
+		p.emit(JobEvent{JobID: j.id, Type: JobStarted, State: j.cancelFlag.State()})
+		finished, err := process(j.log, j.job, j.cancelFlag, cancelWaitDuration, p.clock)
+		p.jobDone(j, finished)
+		switch {
+		case !finished:
+			p.emit(JobEvent{JobID: j.id, Type: JobAbandoned, State: j.cancelFlag.State()})
+		case err != nil:
+			p.emit(JobEvent{JobID: j.id, Type: JobFailed, State: j.cancelFlag.State(), Err: err})
+		default:
+			p.emit(JobEvent{JobID: j.id, Type: JobCompleted, State: j.cancelFlag.State()})
+		}
 	}
 
 	// start the workers
-	p.start(processor)
+	p.start(processor, p.releaseSerialKey)
 
 	return p
 }
@@ -103,23 +334,42 @@ func (p *pool) Shutdown() {
 	p.mut.Lock()
 	defer p.mut.Unlock()
 	if !p.isShutdown {
-		// close the channel to makes all workers terminate once the pending
-		// jobs have been consumed (the pending jobs are in the Canceled state
-		// so they will simply be discarded)
-		close(p.jobQueue)
+		// close the shutdown channel to make all workers terminate; jobs that
+		// are still waiting to be picked up are discarded (and kept in the
+		// queue store, if any, so that they can be re-submitted later)
+		close(p.shutdownChan)
 		p.isShutdown = true
+		// jobs waiting for their serialization key will never be started
+		p.serialKeys = make(map[string][]JobToken)
 	}
 }
 
 // ShutdownAndWait calls Shutdown then waits until all the workers have exited
-// or until the timeout has elapsed, whichever comes first. Returns true if all
-// workers terminated before the timeout or false if the timeout expired.
-func (p *pool) ShutdownAndWait(timeout time.Duration) (finished bool) {
+// or until the timeout has elapsed, whichever comes first. Returns a report
+// of the jobs that were running when the shutdown was requested.
+func (p *pool) ShutdownAndWait(timeout time.Duration) (report ShutdownReport) {
+	p.mut.Lock()
+	if p.shutdownJobs == nil {
+		p.shutdownJobs = []JobReport{}
+	}
+	p.mut.Unlock()
+
 	p.Shutdown()
 
+	report.Finished = p.waitWorkers(timeout)
+	report.Jobs = p.collectShutdownJobs()
+	return
+}
+
+// waitWorkers waits until all the workers have exited, canceling the jobs
+// once the timeout has elapsed. Returns false if the workers did not exit
+// within the cancel wait duration that follows the timeout.
+func (p *pool) waitWorkers(timeout time.Duration) (finished bool) {
 	timeoutTimer := p.clock.After(timeout)
 	exitTimer := p.clock.After(timeout + p.cancelDuration)
+	p.mut.Lock()
 	workersRunning := p.nWorkers
+	p.mut.Unlock()
 	for workersRunning > 0 {
 		select {
 		case <-p.doneWorker:
@@ -134,6 +384,7 @@ func (p *pool) ShutdownAndWait(timeout time.Duration) (finished bool) {
 			p.log.Debugf("Pool shutdown timed out with %d workers still running, start cancelling jobs...", workersRunning)
 			// wait for the worker pool to react to the cancel flag and fail the ongoing jobs
 			p.CancelAll()
+			p.cancelRunning()
 		case <-exitTimer:
 			p.log.Debugf("Pool eventual timeout with %d workers still running ", workersRunning)
 			return false
@@ -142,53 +393,398 @@ func (p *pool) ShutdownAndWait(timeout time.Duration) (finished bool) {
 	return true
 }
 
+// cancelRunning cancels the jobs that are being processed by the workers.
+// Those jobs are no longer in the job store once the pool has been shut down.
+func (p *pool) cancelRunning() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.shutdownCanceled = true
+	for flag := range p.running {
+		if state := flag.State(); state != Completed && state != Failed {
+			flag.Set(Canceled)
+		}
+	}
+}
+
+// collectShutdownJobs returns the reports of the jobs that terminated during
+// the shutdown, followed by the reports of the jobs that are still running.
+func (p *pool) collectShutdownJobs() []JobReport {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	jobs := append([]JobReport{}, p.shutdownJobs...)
+	for _, job := range p.running {
+		jobs = append(jobs, JobReport{JobID: job.id, Outcome: JobAbandonedAtShutdown, Duration: time.Since(job.start)})
+	}
+	return jobs
+}
+
+// jobStarted records that a worker started the given job.
+func (p *pool) jobStarted(token JobToken) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.running[token.cancelFlag] = runningJob{id: token.id, start: time.Now()}
+}
+
+// jobDone records that the given job returned or was abandoned, reporting it if the pool is being shut down.
+func (p *pool) jobDone(token JobToken, finished bool) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	job, found := p.running[token.cancelFlag]
+	if !found {
+		return
+	}
+	delete(p.running, token.cancelFlag)
+	if p.shutdownJobs == nil {
+		return
+	}
+	outcome := JobFinished
+	switch {
+	case !finished:
+		outcome = JobAbandonedAtShutdown
+	case p.shutdownCanceled:
+		outcome = JobCanceled
+	}
+	p.shutdownJobs = append(p.shutdownJobs, JobReport{JobID: job.id, Outcome: outcome, Duration: time.Since(job.start)})
+}
+
+// Drain stops accepting new jobs and waits until the queued and running jobs
+// have terminated or until the timeout has elapsed, whichever comes first.
+func (p *pool) Drain(timeout time.Duration) (drained bool) {
+	p.mut.Lock()
+	p.isDraining = true
+	p.mut.Unlock()
+	defer func() {
+		p.mut.Lock()
+		p.isDraining = false
+		p.mut.Unlock()
+	}()
+
+	timeoutTimer := p.clock.After(timeout)
+	for !p.isIdle() {
+		select {
+		case <-p.clock.After(drainPollInterval):
+		case <-timeoutTimer:
+			p.log.Debugf("Pool drain timed out with %d jobs left", p.jobStore.Size())
+			return false
+		}
+	}
+	p.log.Debug("Pool drained.")
+	return true
+}
+
+// isIdle returns true if this pool has no queued, scheduled or running job.
+func (p *pool) isIdle() bool {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.jobStore.Size() == 0 && len(p.running) == 0
+}
+
+// checkAccepting returns an error if this pool no longer accepts new jobs.
+func (p *pool) checkAccepting(jobID string) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.isDraining {
+		return fmt.Errorf("Pool is draining, job %v is rejected", jobID)
+	}
+	return nil
+}
+
 // start starts the workers of this pool
-func (p *pool) start(jobProcessor func(JobToken)) {
-	for i := 0; i < p.nWorkers; i++ {
-		workerName := fmt.Sprintf("worker-%d", i)
-		go func() {
-			defer p.workerDone()
-			worker(workerName, p.jobQueue, jobProcessor)
-		}()
+func (p *pool) start(jobProcessor func(JobToken), jobSkipped func(JobToken)) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.jobProcessor = jobProcessor
+	p.jobSkipped = jobSkipped
+	for p.nWorkers < p.maxWorkers {
+		p.startWorker()
+	}
+}
+
+// startWorker starts a new worker, must be called with the pool mutex held.
+func (p *pool) startWorker() {
+	workerName := fmt.Sprintf("worker-%d", p.workerSeq)
+	p.workerSeq++
+	p.nWorkers++
+	go func() {
+		if p.worker(workerName) {
+			p.workerDone()
+		}
+	}()
+}
+
+// Resize changes the number of workers of the pool. Running jobs are not interrupted,
+// the workers in excess exit once they are done with their current job.
+func (p *pool) Resize(maxParallel int) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.isShutdown || maxParallel < 1 || maxParallel == p.maxWorkers {
+		return
+	}
+
+	p.log.Infof("Resizing pool from %d to %d workers", p.maxWorkers, maxParallel)
+	p.maxWorkers = maxParallel
+	for p.nWorkers < p.maxWorkers {
+		p.startWorker()
+	}
+	if p.nWorkers > p.maxWorkers {
+		// wake up the idle workers so that the ones in excess retire
+		close(p.resizedChan)
+		p.resizedChan = make(chan struct{})
 	}
 }
 
+// retireWorker returns true if the calling worker is in excess and must exit, otherwise
+// it returns the channel closed on the next resize of the pool.
+func (p *pool) retireWorker() (retire bool, resized chan struct{}) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	// after the shutdown, workers exit on the shutdown channel and are waited for
+	if p.isShutdown || p.nWorkers <= p.maxWorkers {
+		return false, p.resizedChan
+	}
+	p.nWorkers--
+	return true, nil
+}
+
 // workerDone signals that a worker has terminated.
 func (p *pool) workerDone() {
 	p.doneWorker <- struct{}{}
 }
 
-// worker processes jobs from a channel.
-func worker(workerName string, queue chan JobToken, processor func(JobToken)) {
-	for token := range queue {
-		if !token.cancelFlag.Canceled() {
-			processor(token)
+// worker processes jobs from the job queue. Jobs canceled before they start are passed to jobSkipped.
+// Returns true if the worker exited on shutdown, false if it retired after the pool was resized.
+func (p *pool) worker(workerName string) (shutdown bool) {
+	for {
+		retire, resized := p.retireWorker()
+		if retire {
+			p.log.Debugf("Pool %v retired", workerName)
+			return false
+		}
+		select {
+		case token := <-p.jobQueue:
+			if !token.cancelFlag.Canceled() {
+				runProcessor(p.jobProcessor, token)
+			} else {
+				p.jobSkipped(token)
+			}
+		case <-p.shutdownChan:
+			return true
+		case <-resized:
+		}
+	}
+}
+
+// runProcessor processes a job, recovering from panics so that the worker keeps running.
+func runProcessor(processor func(JobToken), token JobToken) {
+	defer func() {
+		if msg := recover(); msg != nil {
+			token.log.Errorf("Processing of job %v failed with message %v\n%s", token.id, msg, debug.Stack())
 		}
+	}()
+	processor(token)
+}
+
+// SetJobEventHandler sets the handler that receives the lifecycle events of the jobs of this pool.
+func (p *pool) SetJobEventHandler(handler JobEventHandler) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.eventHandler = handler
+}
+
+// emit sends the given event to the event handler, if any.
+func (p *pool) emit(event JobEvent) {
+	p.mut.Lock()
+	handler := p.eventHandler
+	p.mut.Unlock()
+	if handler != nil {
+		handler(event)
+	}
+}
+
+// enqueue records the given job in the queue store, if any.
+func (p *pool) enqueue(token JobToken) {
+	if p.queueStore == nil {
+		return
+	}
+	if err := p.queueStore.Enqueue(token.id); err != nil {
+		token.log.Warnf("Failed to persist queued job %v: %v", token.id, err)
+	}
+}
+
+// dequeue removes the given job from the queue store, if any.
+// Jobs that have not started before the pool shut down are kept in the store.
+func (p *pool) dequeue(token JobToken) {
+	if p.queueStore == nil || token.cancelFlag.ShutDown() {
+		return
+	}
+	if err := p.queueStore.Dequeue(token.id); err != nil {
+		token.log.Warnf("Failed to remove queued job %v: %v", token.id, err)
 	}
 }
 
 // Submit adds a job to the execution queue of this pool.
 func (p *pool) Submit(log log.T, jobID string, job Job) (err error) {
-	token := JobToken{
-		id:         jobID,
-		job:        job,
-		cancelFlag: NewChanneledCancelFlag(),
-		log:        log,
-	}
-	err = p.jobStore.AddJob(jobID, &token)
-	if err != nil {
+	return p.SubmitWithPolicy(log, jobID, job, Reject)
+}
+
+// SubmitWithPolicy adds a job to the execution queue of this pool, resolving
+// conflicts with an existing job of the same id according to the given policy.
+func (p *pool) SubmitWithPolicy(log log.T, jobID string, job Job, policy SubmitPolicy) (err error) {
+	return p.submit(newJobToken(log, jobID, job), policy)
+}
+
+// SubmitSerialized adds a job to the execution queue of this pool on behalf of the given submitter once
+// all the jobs previously submitted with the same serialization key have terminated.
+func (p *pool) SubmitSerialized(log log.T, submitter Submitter, jobID string, serialKey string, job Job) (err error) {
+	token := newJobToken(log, jobID, job)
+	token.submitter = submitter
+	token.serialKey = serialKey
+	return p.submit(token, Reject)
+}
+
+// SubmitFrom adds a job to the execution queue of this pool on behalf of the given submitter.
+func (p *pool) SubmitFrom(log log.T, submitter Submitter, jobID string, job Job) (err error) {
+	token := newJobToken(log, jobID, job)
+	token.submitter = submitter
+	return p.submit(token, Reject)
+}
+
+// submit adds a job to this pool according to the given policy. Jobs with a
+// non-empty serialization key wait until the key is released by the previous job.
+func (p *pool) submit(token JobToken, policy SubmitPolicy) (err error) {
+	log, jobID, serialKey := token.log, token.id, token.serialKey
+	if err = p.checkAccepting(jobID); err != nil {
+		return
+	}
+
+	switch policy {
+	case Reject:
+		if err = p.jobStore.AddJob(jobID, &token); err != nil {
+			return
+		}
+	case Replace:
+		if previous, found := p.jobStore.ReplaceJob(jobID, &token); found {
+			log.Debugf("Replacing job %v, canceling the existing job", jobID)
+			previous.cancelFlag.Set(Canceled)
+		}
+	case Coalesce:
+		if err = p.jobStore.AddJob(jobID, &token); err != nil {
+			log.Debugf("Job %v is already queued or running, coalescing the submission", jobID)
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported submit policy %v", policy)
+	}
+
+	p.enqueue(token)
+	if !p.acquireSerialKey(token) {
+		log.Debugf("Job %v waits for the jobs with serialization key %v", jobID, serialKey)
 		return
 	}
-	p.jobQueue <- token
+	p.dispatch(token)
 	return
 }
 
+// SubmitAfter adds a job to the execution queue of this pool once the given delay has elapsed.
+func (p *pool) SubmitAfter(log log.T, delay time.Duration, jobID string, job Job) (err error) {
+	token := newJobToken(log, jobID, job)
+	if err = p.checkAccepting(jobID); err != nil {
+		return
+	}
+	if err = p.jobStore.AddJob(jobID, &token); err != nil {
+		return
+	}
+
+	go p.submitWhenDue(token, p.clock.After(delay))
+	return
+}
+
+// SubmitAt adds a job to the execution queue of this pool at the given time.
+func (p *pool) SubmitAt(log log.T, at time.Time, jobID string, job Job) (err error) {
+	return p.SubmitAfter(log, at.Sub(p.clock.Now()), jobID, job)
+}
+
+// submitWhenDue waits until the given timer expires then hands the job over to a worker.
+// The job is dropped if it is canceled or shut down in the meantime.
+func (p *pool) submitWhenDue(token JobToken, due chan struct{}) {
+	select {
+	case <-due:
+	case <-token.cancelFlag.ch:
+		token.log.Debugf("Scheduled job %v will not be started", token.id)
+		return
+	}
+
+	p.enqueue(token)
+	p.dispatch(token)
+}
+
+// dispatch hands the given job over to a worker, unless the pool is shut down.
+func (p *pool) dispatch(token JobToken) {
+	select {
+	case p.jobQueue <- token:
+	case <-p.shutdownChan:
+		token.log.Debugf("Pool is shut down, job %v will not be started", token.id)
+		p.jobStore.DeleteJobIfMatches(token.id, &token)
+		token.cancelFlag.Set(ShutDown)
+	}
+}
+
+// acquireSerialKey returns true if the given job can be dispatched right away.
+// Otherwise the job is put on hold until the jobs with the same key have terminated.
+func (p *pool) acquireSerialKey(token JobToken) bool {
+	if token.serialKey == "" {
+		return true
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.isShutdown {
+		return true
+	}
+	waiting, busy := p.serialKeys[token.serialKey]
+	if !busy {
+		p.serialKeys[token.serialKey] = []JobToken{}
+		return true
+	}
+	p.serialKeys[token.serialKey] = append(waiting, token)
+	return false
+}
+
+// releaseSerialKey dispatches the next job waiting for the key of the given job, if any.
+// Jobs canceled while waiting are dropped.
+func (p *pool) releaseSerialKey(token JobToken) {
+	if token.serialKey == "" {
+		return
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	waiting, busy := p.serialKeys[token.serialKey]
+	if !busy {
+		return
+	}
+	for len(waiting) > 0 {
+		next := waiting[0]
+		waiting = waiting[1:]
+		if !next.cancelFlag.Canceled() {
+			p.serialKeys[token.serialKey] = waiting
+			// dispatch asynchronously since the caller may be the only worker of the pool
+			go p.dispatch(next)
+			return
+		}
+	}
+	delete(p.serialKeys, token.serialKey)
+}
+
 // HasJob returns if jobStore has specified job
 func (p *pool) HasJob(jobID string) bool {
 	_, found := p.jobStore.GetJob(jobID)
 	return found
 }
 
+// ListJobs returns the metadata of the jobs of this pool, ordered by submission time.
+func (p *pool) ListJobs() []JobInfo {
+	return p.jobStore.ListJobs()
+}
+
 // Cancel cancels the job with the given id.
 func (p *pool) Cancel(jobID string) (canceled bool) {
 	jobToken, found := p.jobStore.GetJob(jobID)
@@ -200,6 +796,7 @@ func (p *pool) Cancel(jobID string) (canceled bool) {
 	p.jobStore.DeleteJob(jobID)
 
 	jobToken.cancelFlag.Set(Canceled)
+	p.dequeue(*jobToken)
 	return true
 }
 
@@ -211,6 +808,7 @@ func (p *pool) CancelAll() {
 	// cancel each job
 	for _, token := range jobs {
 		token.cancelFlag.Set(Canceled)
+		p.dequeue(*token)
 	}
 }
 
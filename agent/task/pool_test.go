@@ -77,7 +77,7 @@ func testPool(t *testing.T, nWorkers int, nJobs int, shouldCancel bool) {
 	time.Sleep(10 * time.Millisecond)
 
 	// send cancel signal to all running jobs and wait to finish
-	assert.True(t, pool.ShutdownAndWait(shutdownTimeout))
+	assert.True(t, pool.ShutdownAndWait(shutdownTimeout).Finished)
 
 	// Not verifying clock.After(waitTimeout) here. Refer to proc.go. We can't guarantee that 'doneChan' is not set the
 	// first time waitEither(doneChan, clock.After) is checked.
@@ -119,3 +119,498 @@ func exercisePool(t *testing.T, pool Pool, jobID string, shouldCancel bool) {
 	// see that job completes
 	assert.True(t, <-jobState)
 }
+
+func newPolicyTestPool(nWorkers int) (Pool, *times.MockedClock) {
+	clock := times.NewMockedClock()
+	waitTimeout := 100 * time.Millisecond
+	shutdownTimeout := 10000 * time.Millisecond
+	clock.On("After", waitTimeout).Return(clock.AfterChannel)
+	clock.On("After", shutdownTimeout).Return(clock.AfterChannel)
+	clock.On("After", shutdownTimeout+waitTimeout).Return(clock.AfterChannel)
+	return NewPool(logger, nWorkers, waitTimeout, clock), clock
+}
+
+func TestSubmitWithPolicyReject(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	started := make(chan bool)
+	release := make(chan bool)
+	err := pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {
+		started <- true
+		<-release
+	}, Reject)
+	assert.Nil(t, err)
+	<-started
+
+	err = pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {}, Reject)
+	assert.NotNil(t, err)
+
+	close(release)
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitWithPolicyReplace(t *testing.T) {
+	pool, _ := newPolicyTestPool(2)
+	started := make(chan bool)
+	states := make(chan State, 1)
+	err := pool.SubmitWithPolicy(logger, "job", func(flag CancelFlag) {
+		started <- true
+		states <- flag.Wait()
+	}, Replace)
+	assert.Nil(t, err)
+	<-started
+
+	done := make(chan bool, 1)
+	err = pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {
+		done <- true
+	}, Replace)
+	assert.Nil(t, err)
+
+	// the existing job is canceled and the new job runs
+	assert.Equal(t, Canceled, <-states)
+	assert.True(t, <-done)
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitWithPolicyReplaceKeepsNewJob(t *testing.T) {
+	pool, _ := newPolicyTestPool(2)
+	first := make(chan bool)
+	release := make(chan bool)
+	err := pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {
+		first <- true
+		<-release
+	}, Replace)
+	assert.Nil(t, err)
+	<-first
+
+	second := make(chan bool)
+	err = pool.SubmitWithPolicy(logger, "job", func(flag CancelFlag) {
+		second <- true
+		flag.Wait()
+	}, Replace)
+	assert.Nil(t, err)
+	<-second
+
+	// completion of the replaced job must not remove the new job from the pool
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, pool.HasJob("job"))
+	assert.True(t, pool.Cancel("job"))
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitWithPolicyCoalesce(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	started := make(chan bool)
+	release := make(chan bool)
+	err := pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {
+		started <- true
+		<-release
+	}, Coalesce)
+	assert.Nil(t, err)
+	<-started
+
+	coalesced := false
+	err = pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {
+		coalesced = true
+	}, Coalesce)
+	assert.Nil(t, err)
+
+	close(release)
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+	assert.False(t, coalesced)
+}
+
+func TestSubmitWithUnknownPolicy(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	err := pool.SubmitWithPolicy(logger, "job", func(CancelFlag) {}, SubmitPolicy(42))
+	assert.NotNil(t, err)
+	assert.False(t, pool.HasJob("job"))
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestPoolJobPanic(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	events := make(chan JobEvent, 10)
+	pool.SetJobEventHandler(func(event JobEvent) {
+		events <- event
+	})
+
+	err := pool.Submit(logger, "panicking", func(CancelFlag) {
+		panic("panic")
+	})
+	assert.Nil(t, err)
+
+	event := <-events
+	assert.Equal(t, JobStarted, event.Type)
+	event = <-events
+	assert.Equal(t, JobFailed, event.Type)
+	assert.Equal(t, "panicking", event.JobID)
+	assert.Equal(t, Failed, event.State)
+	assert.NotNil(t, event.Err)
+
+	// the worker is still alive and processes the next job
+	err = pool.Submit(logger, "next", func(CancelFlag) {})
+	assert.Nil(t, err)
+	assert.Equal(t, JobStarted, (<-events).Type)
+	event = <-events
+	assert.Equal(t, JobCompleted, event.Type)
+	assert.Equal(t, Completed, event.State)
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestPoolJobPanicDuringCancel(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	events := make(chan JobEvent, 10)
+	pool.SetJobEventHandler(func(event JobEvent) {
+		events <- event
+	})
+
+	err := pool.Submit(logger, "panicking", func(flag CancelFlag) {
+		flag.Wait()
+		panic("panic during cancel")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, JobStarted, (<-events).Type)
+
+	assert.True(t, pool.Cancel("panicking"))
+	event := <-events
+	assert.Equal(t, JobFailed, event.Type)
+	assert.NotNil(t, event.Err)
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestShutdownAndWaitReportsFinishedJobs(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	started := make(chan bool)
+	err := pool.Submit(logger, "job", func(flag CancelFlag) {
+		started <- true
+		flag.Wait()
+	})
+	assert.Nil(t, err)
+	<-started
+
+	report := pool.ShutdownAndWait(10000 * time.Millisecond)
+	assert.True(t, report.Finished)
+	assert.Equal(t, 1, len(report.Jobs))
+	assert.Equal(t, "job", report.Jobs[0].JobID)
+	assert.Equal(t, JobFinished, report.Jobs[0].Outcome)
+}
+
+func TestShutdownAndWaitReportsCanceledAndAbandonedJobs(t *testing.T) {
+	clock := times.NewMockedClock()
+	waitTimeout := 100 * time.Millisecond
+	shutdownTimeout := 10000 * time.Millisecond
+	timeoutChan := make(chan struct{}, 1)
+	exitChan := make(chan struct{}, 1)
+	clock.On("After", waitTimeout).Return(make(chan struct{}))
+	clock.On("After", shutdownTimeout).Return(timeoutChan)
+	clock.On("After", shutdownTimeout+waitTimeout).Return(exitChan)
+	pool := NewPool(logger, 2, waitTimeout, clock)
+
+	events := make(chan JobEvent, 10)
+	pool.SetJobEventHandler(func(event JobEvent) {
+		events <- event
+	})
+
+	release := make(chan bool)
+	defer close(release)
+	err := pool.Submit(logger, "stuck", func(CancelFlag) {
+		<-release
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, JobStarted, (<-events).Type)
+	err = pool.Submit(logger, "canceled", func(flag CancelFlag) {
+		for !flag.Canceled() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, JobStarted, (<-events).Type)
+
+	reports := make(chan ShutdownReport)
+	go func() {
+		reports <- pool.ShutdownAndWait(shutdownTimeout)
+	}()
+
+	// expire the shutdown timeout to cancel the jobs, then give up on the stuck job
+	timeoutChan <- struct{}{}
+	event := <-events
+	assert.Equal(t, "canceled", event.JobID)
+	assert.Equal(t, JobCompleted, event.Type)
+	exitChan <- struct{}{}
+
+	report := <-reports
+	assert.False(t, report.Finished)
+	outcomes := make(map[string]JobOutcome)
+	for _, job := range report.Jobs {
+		outcomes[job.JobID] = job.Outcome
+	}
+	assert.Equal(t, map[string]JobOutcome{"canceled": JobCanceled, "stuck": JobAbandonedAtShutdown}, outcomes)
+}
+
+func TestSubmitSerialized(t *testing.T) {
+	pool, _ := newPolicyTestPool(3)
+	started := make(chan string, 3)
+	release := make(chan bool)
+	job := func(jobID string) Job {
+		return func(CancelFlag) {
+			started <- jobID
+			<-release
+		}
+	}
+
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "first", "key", job("first")))
+	assert.Equal(t, "first", <-started)
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterAssociation, "second", "key", job("second")))
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "other", "other-key", job("other")))
+
+	// the job with another key runs in parallel while the second job waits for the first one
+	assert.Equal(t, "other", <-started)
+	assert.True(t, pool.HasJob("second"))
+	assert.NotNil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "second", "key", job("second")))
+	for _, info := range pool.ListJobs() {
+		if info.JobID == "second" {
+			assert.Equal(t, SubmitterAssociation, info.Submitter)
+		}
+	}
+
+	release <- true
+	release <- true
+	assert.Equal(t, "second", <-started)
+	release <- true
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitSerializedCancelWaitingJob(t *testing.T) {
+	pool, _ := newPolicyTestPool(2)
+	started := make(chan string, 3)
+	release := make(chan bool)
+	job := func(jobID string) Job {
+		return func(CancelFlag) {
+			started <- jobID
+			<-release
+		}
+	}
+
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "first", "key", job("first")))
+	assert.Equal(t, "first", <-started)
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "second", "key", job("second")))
+	assert.Nil(t, pool.SubmitSerialized(logger, SubmitterUnknown, "third", "key", job("third")))
+
+	// the canceled job is never started and the key goes to the next job
+	assert.True(t, pool.Cancel("second"))
+	release <- true
+	assert.Equal(t, "third", <-started)
+	release <- true
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitAfter(t *testing.T) {
+	pool, clock := newPolicyTestPool(1)
+	delay := 5 * time.Second
+	due := make(chan struct{}, 1)
+	clock.On("After", delay).Return(due)
+
+	done := make(chan bool, 1)
+	assert.Nil(t, pool.SubmitAfter(logger, delay, "job", func(CancelFlag) {
+		done <- true
+	}))
+	assert.True(t, pool.HasJob("job"))
+	assert.NotNil(t, pool.Submit(logger, "job", func(CancelFlag) {}))
+
+	due <- struct{}{}
+	assert.True(t, <-done)
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestSubmitAtCancelBeforeDue(t *testing.T) {
+	pool, clock := newPolicyTestPool(1)
+	now := time.Now()
+	delay := 5 * time.Second
+	due := make(chan struct{}, 1)
+	clock.On("Now").Return(now)
+	clock.On("After", delay).Return(due)
+
+	assert.Nil(t, pool.SubmitAt(logger, now.Add(delay), "job", func(CancelFlag) {
+		assert.Fail(t, "canceled job should not run")
+	}))
+	assert.True(t, pool.Cancel("job"))
+	assert.False(t, pool.HasJob("job"))
+
+	// the job id can be reused once the scheduled job is canceled
+	done := make(chan bool, 1)
+	assert.Nil(t, pool.Submit(logger, "job", func(CancelFlag) {
+		done <- true
+	}))
+	assert.True(t, <-done)
+
+	due <- struct{}{}
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestDrain(t *testing.T) {
+	pool, clock := newPolicyTestPool(1)
+	drainTimeout := time.Minute
+	clock.On("After", drainTimeout).Return(make(chan struct{}))
+	// the poll interval of the drain is the cancel wait duration of the test pool
+	poll := clock.AfterChannel
+
+	release := make(chan bool)
+	states := make(chan State, 2)
+	job := func(flag CancelFlag) {
+		<-release
+		states <- flag.State()
+	}
+	assert.Nil(t, pool.Submit(logger, "running", job))
+	go func() {
+		assert.Nil(t, pool.Submit(logger, "queued", job))
+	}()
+	for !pool.HasJob("queued") {
+		time.Sleep(time.Millisecond)
+	}
+
+	drained := make(chan bool)
+	go func() {
+		drained <- pool.Drain(drainTimeout)
+	}()
+
+	// new jobs are rejected once the pool is draining
+	for !isDraining(pool) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NotNil(t, pool.Submit(logger, "new", func(CancelFlag) {}))
+	assert.NotNil(t, pool.SubmitAfter(logger, time.Second, "new", func(CancelFlag) {}))
+
+	// the queued and running jobs terminate normally
+	release <- true
+	release <- true
+	// the jobs are not canceled
+	assert.Equal(t, State(0), <-states)
+	assert.Equal(t, State(0), <-states)
+	// the pool checks its jobs on the ticks of its clock
+	var isDrained bool
+	for done := false; !done; {
+		select {
+		case isDrained = <-drained:
+			done = true
+		case poll <- struct{}{}:
+		}
+	}
+	assert.True(t, isDrained)
+	// the shutdown below must not see a leftover tick
+	select {
+	case <-poll:
+	default:
+	}
+
+	// new jobs are accepted again once drained
+	assert.False(t, isDraining(pool))
+	assert.Nil(t, pool.Submit(logger, "new", func(CancelFlag) {}))
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	pool, clock := newPolicyTestPool(1)
+	drainTimeout := time.Minute
+	timeoutChan := make(chan struct{}, 1)
+	clock.On("After", drainTimeout).Return(timeoutChan)
+
+	release := make(chan bool)
+	assert.Nil(t, pool.Submit(logger, "job", func(CancelFlag) {
+		<-release
+	}))
+
+	timeoutChan <- struct{}{}
+	assert.False(t, pool.Drain(drainTimeout))
+	assert.True(t, pool.HasJob("job"))
+	assert.False(t, isDraining(pool))
+
+	close(release)
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func isDraining(p Pool) bool {
+	impl := p.(*pool)
+	impl.mut.Lock()
+	defer impl.mut.Unlock()
+	return impl.isDraining
+}
+
+func TestPoolListJobs(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+	started := make(chan bool)
+	release := make(chan bool)
+	assert.Nil(t, pool.SubmitFrom(logger, SubmitterMGS, "running", func(CancelFlag) {
+		started <- true
+		<-release
+	}))
+	<-started
+	go func() {
+		assert.Nil(t, pool.Submit(logger, "queued", func(CancelFlag) {}))
+	}()
+	for !pool.HasJob("queued") {
+		time.Sleep(time.Millisecond)
+	}
+
+	jobs := pool.ListJobs()
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, "running", jobs[0].JobID)
+	assert.Equal(t, SubmitterMGS, jobs[0].Submitter)
+	assert.False(t, jobs[0].StartedAt.IsZero())
+	assert.Equal(t, "queued", jobs[1].JobID)
+	assert.Equal(t, SubmitterUnknown, jobs[1].Submitter)
+	assert.True(t, jobs[1].StartedAt.IsZero())
+
+	close(release)
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func TestPoolResize(t *testing.T) {
+	pool, _ := newPolicyTestPool(1)
+
+	// two jobs run in parallel once the pool has grown
+	pool.Resize(2)
+	started := make(chan bool, 2)
+	release := make(chan bool)
+	job := func(CancelFlag) {
+		started <- true
+		<-release
+	}
+	assert.Nil(t, pool.Submit(logger, "job1", job))
+	assert.Nil(t, pool.Submit(logger, "job2", job))
+	<-started
+	<-started
+
+	// the running jobs are not interrupted by shrinking the pool
+	pool.Resize(1)
+	assert.Equal(t, 2, workerCount(pool))
+	release <- true
+	release <- true
+	for workerCount(pool) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// idle workers in excess retire right away
+	pool.Resize(3)
+	assert.Equal(t, 3, workerCount(pool))
+	pool.Resize(1)
+	for workerCount(pool) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.True(t, pool.ShutdownAndWait(10000*time.Millisecond).Finished)
+}
+
+func workerCount(p Pool) int {
+	impl := p.(*pool)
+	impl.mut.Lock()
+	defer impl.mut.Unlock()
+	return impl.nWorkers
+}
@@ -14,6 +14,8 @@
 package task
 
 import (
+	"fmt"
+	"runtime/debug"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -25,55 +27,59 @@ import (
 // If cancel is requested, this function waits for some time to allow the
 // job to complete. If the job does not complete by the timeout, the go routine
 // of the job is abandoned, and this function returns.
-func process(log log.T, job Job, cancelFlag *ChanneledCancelFlag, cancelWait time.Duration, clock times.Clock) {
+// Returns false if the job was abandoned, and the error of the job if it panicked.
+func process(log log.T, job Job, cancelFlag *ChanneledCancelFlag, cancelWait time.Duration, clock times.Clock) (finished bool, err error) {
 	// Make a buffered channel to avoid blocking on send. This helps
 	// in case the job fails to cancel on time and we give up on it.
 	// If the job finally ends, it will succeed to send a signal
 	// on the channel and then it will terminate. This will allow
 	// the garbage collector to collect the go routine's resources
 	// and the channel.
-	doneChan := make(chan struct{}, 1)
+	doneChan := make(chan error, 1)
 
 	go runJob(log, func() { job(cancelFlag) }, doneChan)
 
-	done := waitEither(doneChan, cancelFlag.ch)
-	if done {
+	select {
+	case err = <-doneChan:
 		// task done, set the flag to wake up waiting routines
-		cancelFlag.Set(Completed)
-		return
+		setFinalState(cancelFlag, err)
+		return true, err
+	case <-cancelFlag.ch:
 	}
 
 	log.Debugf("Execution has been canceled, waiting up to %v to finish", cancelWait)
-	done = waitEither(doneChan, clock.After(cancelWait))
-	if done {
+	select {
+	case err = <-doneChan:
 		// job completed within cancel waiting window
-		cancelFlag.Set(Completed)
-		return
+		setFinalState(cancelFlag, err)
+		return true, err
+	case <-clock.After(cancelWait):
 	}
 
 	log.Debugf("Job failed to terminate within %v!", cancelWait)
+	return false, nil
 }
 
-// waitEither waits until one of the two channels receives something.
-// Returns true if the first channel received, false if the second one received.
-func waitEither(chan1 chan struct{}, chan2 chan struct{}) (chan1Done bool) {
-	select {
-	case <-chan1:
-		return true
-
-	case <-chan2:
-		return false
+// setFinalState sets the flag of a job that has returned to either Completed or Failed state.
+func setFinalState(cancelFlag *ChanneledCancelFlag, err error) {
+	if err != nil {
+		cancelFlag.Set(Failed)
+		return
 	}
+	cancelFlag.Set(Completed)
 }
 
-// runJob executes a job and then sends a signal on the given channel
-func runJob(log log.T, job func(), doneChannel chan struct{}) {
+// runJob executes a job and then sends the error of the job on the given channel.
+// The error is nil unless the job panicked.
+func runJob(log log.T, job func(), doneChannel chan error) {
+	var err error
 	defer func() {
 		// recover in case the job panics
 		if msg := recover(); msg != nil {
-			log.Errorf("Job failed with message %v", msg)
+			log.Errorf("Job failed with message %v\n%s", msg, debug.Stack())
+			err = fmt.Errorf("job panicked: %v", msg)
 		}
-		doneChannel <- struct{}{}
+		doneChannel <- err
 	}()
 	job()
 }
@@ -94,6 +94,41 @@ func TestProcessCancelAbandon(t *testing.T) {
 	testCase.assertExpectations()
 }
 
+// TestProcessPanic tests process on a job that panics before cancel.
+func TestProcessPanic(t *testing.T) {
+	flag := NewChanneledCancelFlag()
+	finished, err := process(logger, func(CancelFlag) {
+		panic("panic")
+	}, flag, 100*time.Millisecond, times.NewMockedClock())
+
+	assert.True(t, finished)
+	assert.NotNil(t, err)
+	assert.Equal(t, Failed, flag.State())
+}
+
+// TestProcessPanicDuringCancel tests process on a job that panics while reacting to a cancel.
+func TestProcessPanicDuringCancel(t *testing.T) {
+	clock := times.NewMockedClock()
+	cancelWait := 100 * time.Millisecond
+	clock.On("After", cancelWait).Return(clock.AfterChannel)
+	flag := NewChanneledCancelFlag()
+	started := make(chan bool)
+
+	go func() {
+		<-started
+		flag.Set(Canceled)
+	}()
+	finished, err := process(logger, func(cancelFlag CancelFlag) {
+		started <- true
+		cancelFlag.Wait()
+		panic("panic during cancel")
+	}, flag, cancelWait, clock)
+
+	assert.True(t, finished)
+	assert.NotNil(t, err)
+	assert.Equal(t, Failed, flag.State())
+}
+
 // TestRunJob tests the runJob method.
 func TestRunJob(t *testing.T) {
 	testRunJob(t, false)
@@ -102,7 +137,7 @@ func TestRunJob(t *testing.T) {
 
 func testRunJob(t *testing.T, innerFunctionPanics bool) {
 	// create buffered channel for job done notification
-	doneChannel := make(chan struct{}, 1)
+	doneChannel := make(chan error, 1)
 	testCase := createTestCaseForRunJob(t, doneChannel, innerFunctionPanics)
 
 	// see that job starts
@@ -112,8 +147,9 @@ func testRunJob(t *testing.T, innerFunctionPanics bool) {
 		testCase.assertInnerFunctionCompletes()
 	}
 
-	// check that runJob sends signal and exits
-	assert.NotNil(t, <-doneChannel)
+	// check that runJob sends signal and exits, reporting the panic if any
+	err := <-doneChannel
+	assert.Equal(t, innerFunctionPanics, err != nil)
 	testCase.assertTestMethodCompletes()
 
 	testCase.assertExpectations()
@@ -154,7 +190,7 @@ func createTestCaseForProcess(t *testing.T) TestCase {
 	return testCase
 }
 
-func createTestCaseForRunJob(t *testing.T, doneChannel chan struct{}, innerFunctionPanics bool) TestCase {
+func createTestCaseForRunJob(t *testing.T, doneChannel chan error, innerFunctionPanics bool) TestCase {
 	testCase := createTestCase(t, innerFunctionPanics)
 	testMethod := func() {
 		runJob(logger, testCase.innerFunction, doneChannel)
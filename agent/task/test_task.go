@@ -33,6 +33,31 @@ func (mockPool *MockedPool) Submit(log log.T, jobID string, job Job) error {
 	return mockPool.Called(log, jobID, job).Error(0)
 }
 
+// SubmitWithPolicy mocks the method with the same name.
+func (mockPool *MockedPool) SubmitWithPolicy(log log.T, jobID string, job Job, policy SubmitPolicy) error {
+	return mockPool.Called(log, jobID, job, policy).Error(0)
+}
+
+// SubmitSerialized mocks the method with the same name.
+func (mockPool *MockedPool) SubmitSerialized(log log.T, submitter Submitter, jobID string, serialKey string, job Job) error {
+	return mockPool.Called(log, submitter, jobID, serialKey, job).Error(0)
+}
+
+// SubmitAfter mocks the method with the same name.
+func (mockPool *MockedPool) SubmitAfter(log log.T, delay time.Duration, jobID string, job Job) error {
+	return mockPool.Called(log, delay, jobID, job).Error(0)
+}
+
+// SubmitAt mocks the method with the same name.
+func (mockPool *MockedPool) SubmitAt(log log.T, at time.Time, jobID string, job Job) error {
+	return mockPool.Called(log, at, jobID, job).Error(0)
+}
+
+// SubmitFrom mocks the method with the same name.
+func (mockPool *MockedPool) SubmitFrom(log log.T, submitter Submitter, jobID string, job Job) error {
+	return mockPool.Called(log, submitter, jobID, job).Error(0)
+}
+
 // Cancel mocks the method with the same name.
 func (mockPool *MockedPool) Cancel(jobID string) bool {
 	return mockPool.Called(jobID).Bool(0)
@@ -43,10 +68,15 @@ func (mockPool *MockedPool) Shutdown() {
 	mockPool.Called()
 }
 
+// Drain mocks the method with the same name.
+func (mockPool *MockedPool) Drain(timeout time.Duration) (drained bool) {
+	return mockPool.Called(timeout).Bool(0)
+}
+
 // ShutdownAndWait mocks the method with the same name.
-func (mockPool *MockedPool) ShutdownAndWait(timeout time.Duration) (finished bool) {
+func (mockPool *MockedPool) ShutdownAndWait(timeout time.Duration) (report ShutdownReport) {
 	args := mockPool.Called(timeout)
-	return args.Bool(0)
+	return args.Get(0).(ShutdownReport)
 }
 
 // ShutdownAndWait mocks the method with the same name.
@@ -55,6 +85,21 @@ func (mockPool *MockedPool) HasJob(jobID string) bool {
 	return args.Bool(0)
 }
 
+// ListJobs mocks the method with the same name.
+func (mockPool *MockedPool) ListJobs() []JobInfo {
+	return mockPool.Called().Get(0).([]JobInfo)
+}
+
+// SetJobEventHandler mocks the method with the same name.
+func (mockPool *MockedPool) SetJobEventHandler(handler JobEventHandler) {
+	mockPool.Called(handler)
+}
+
+// Resize mocks the method with the same name.
+func (mockPool *MockedPool) Resize(maxParallel int) {
+	mockPool.Called(maxParallel)
+}
+
 // MockCancelFlag mocks a cancel flag.
 type MockCancelFlag struct {
 	mock.Mock
@@ -45,6 +45,9 @@ const (
 	// Initialized represents the state value initialized for agent update
 	Initialized UpdateState = "Initialized"
 
+	// Pending represents the state value pending the next maintenance window for agent update
+	Pending UpdateState = "Pending"
+
 	// Staged represents the state value staged for agent update
 	Staged UpdateState = "Staged"
 
@@ -61,6 +64,26 @@ const (
 	Completed UpdateState = "Completed"
 )
 
+// UpdatePhase represents the phase of an update in progress, reported to the requesting document
+type UpdatePhase string
+
+const (
+	// PhaseVerifying represents verifying the update can be installed on the instance
+	PhaseVerifying UpdatePhase = "Verifying"
+
+	// PhaseDownloading represents downloading the installation packages
+	PhaseDownloading UpdatePhase = "Downloading"
+
+	// PhaseInstalling represents installing the target version
+	PhaseInstalling UpdatePhase = "Installing"
+
+	// PhaseVerifyingNewAgent represents waiting for the target version to start and pass its checks
+	PhaseVerifyingNewAgent UpdatePhase = "VerifyingNewAgent"
+
+	// PhaseRollingBack represents reinstalling the source version
+	PhaseRollingBack UpdatePhase = "RollingBack"
+)
+
 const (
 	// maxAllowedUpdateDuration represents the maximum allowed agent update time in seconds
 	maxAllowedUpdateDuration = 180
@@ -76,26 +99,34 @@ type contextManager struct{}
 
 // UpdateDetail Book keeping detail for Agent Update
 type UpdateDetail struct {
-	State              UpdateState            `json:"State"`
-	Result             contracts.ResultStatus `json:"Result"`
-	StandardOut        string                 `json:"StandardOut"`
-	StandardError      string                 `json:"StandardError"`
-	OutputS3KeyPrefix  string                 `json:"OutputS3KeyPrefix"`
-	OutputS3BucketName string                 `json:"OutputS3BucketName"`
-	StdoutFileName     string                 `json:"StdoutFileName"`
-	StderrFileName     string                 `json:"StderrFileName"`
-	SourceVersion      string                 `json:"SourceVersion"`
-	SourceLocation     string                 `json:"SourceLocation"`
-	SourceHash         string                 `json:"SourceHash"`
-	TargetVersion      string                 `json:"TargetVersion"`
-	TargetLocation     string                 `json:"TargetLocation"`
-	TargetHash         string                 `json:"TargetHash"`
-	PackageName        string                 `json:"PackageName"`
-	StartDateTime      time.Time              `json:"StartDateTime"`
-	EndDateTime        time.Time              `json:"EndDateTime"`
-	MessageID          string                 `json:"MessageId"`
-	UpdateRoot         string                 `json:"UpdateRoot"`
-	RequiresUninstall  bool                   `json:"RequiresUninstall"`
+	State               UpdateState            `json:"State"`
+	Phase               UpdatePhase            `json:"Phase"`
+	Result              contracts.ResultStatus `json:"Result"`
+	StandardOut         string                 `json:"StandardOut"`
+	StandardError       string                 `json:"StandardError"`
+	OutputS3KeyPrefix   string                 `json:"OutputS3KeyPrefix"`
+	OutputS3BucketName  string                 `json:"OutputS3BucketName"`
+	OutputLogGroupName  string                 `json:"OutputLogGroupName"`
+	OutputLogStreamName string                 `json:"OutputLogStreamName"`
+	StdoutFileName      string                 `json:"StdoutFileName"`
+	StderrFileName      string                 `json:"StderrFileName"`
+	SourceVersion       string                 `json:"SourceVersion"`
+	SourceLocation      string                 `json:"SourceLocation"`
+	SourceHash          string                 `json:"SourceHash"`
+	TargetVersion       string                 `json:"TargetVersion"`
+	TargetLocation      string                 `json:"TargetLocation"`
+	TargetHash          string                 `json:"TargetHash"`
+	TargetDeltaLocation string                 `json:"TargetDeltaLocation"`
+	TargetDeltaHash     string                 `json:"TargetDeltaHash"`
+	PackageName         string                 `json:"PackageName"`
+	StartDateTime       time.Time              `json:"StartDateTime"`
+	EndDateTime         time.Time              `json:"EndDateTime"`
+	WindowStartDateTime time.Time              `json:"WindowStartDateTime"`
+	MessageID           string                 `json:"MessageId"`
+	UpdateRoot          string                 `json:"UpdateRoot"`
+	RequiresUninstall   bool                   `json:"RequiresUninstall"`
+	AllowDowngrade      bool                   `json:"AllowDowngrade"`
+	RequiredChecks      []string               `json:"RequiredChecks"`
 }
 
 // UpdateContext holds the book keeping details for Update context
@@ -110,6 +141,11 @@ func (update *UpdateDetail) HasMessageID() bool {
 	return len(update.MessageID) > 0
 }
 
+// HasTargetDelta represents if the target package can be built from a delta to the source package
+func (update *UpdateDetail) HasTargetDelta() bool {
+	return len(update.TargetDeltaLocation) > 0
+}
+
 // IsUpdateInProgress represents if the another update is running
 func (context *UpdateContext) IsUpdateInProgress(log log.T) bool {
 	//System will check the start time of the last update
@@ -0,0 +1,167 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+)
+
+// deltaMagic starts the header of deltas in the bsdiff format
+const deltaMagic = "BSDIFF40"
+
+// deltaHeaderLength is the length of the magic followed by the lengths of the control and diff blocks and of the new file
+const deltaHeaderLength = 32
+
+var (
+	readFile   = ioutil.ReadFile
+	writeFile  = ioutil.WriteFile
+	verifyHash = artifact.VerifyHash
+)
+
+// downloadAndApplyDelta builds the target package from the source package and the delta between them,
+// verifies it against the target hash and uncompresses it in the artifact folder of the target version
+func downloadAndApplyDelta(
+	mgr *updateManager,
+	log log.T,
+	sourceInput artifact.DownloadInput,
+	deltaInput artifact.DownloadInput,
+	context *UpdateContext) (err error) {
+
+	log.Infof("Preparing source for version %v from delta", context.Current.TargetVersion)
+	// the source package has already been downloaded, this only resolves its local path
+	sourceOutput, err := downloadArtifact(log, sourceInput)
+	if err != nil || !sourceOutput.IsHashMatched || sourceOutput.LocalFilePath == "" {
+		return fmt.Errorf("failed to locate source package %v, %v", sourceInput.SourceURL, err)
+	}
+
+	deltaOutput, err := downloadArtifact(log, deltaInput)
+	if err != nil || !deltaOutput.IsHashMatched || deltaOutput.LocalFilePath == "" {
+		return fmt.Errorf("failed to download delta reliably, %v, %v", deltaInput.SourceURL, err)
+	}
+	context.Current.AppendInfo(log, "Successfully downloaded %v", deltaInput.SourceURL)
+
+	var source, delta, target []byte
+	if source, err = readFile(sourceOutput.LocalFilePath); err != nil {
+		return fmt.Errorf("failed to read source package, %v", err)
+	}
+	if delta, err = readFile(deltaOutput.LocalFilePath); err != nil {
+		return fmt.Errorf("failed to read delta, %v", err)
+	}
+	if target, err = applyDelta(source, delta); err != nil {
+		return fmt.Errorf("failed to apply delta %v, %v", deltaInput.SourceURL, err)
+	}
+
+	targetOutput := artifact.DownloadOutput{
+		LocalFilePath: filepath.Join(deltaInput.DestinationDirectory,
+			fmt.Sprintf("%v-%v", context.Current.PackageName, context.Current.TargetVersion)),
+	}
+	if err = writeFile(targetOutput.LocalFilePath, target, appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("failed to write target package, %v", err)
+	}
+
+	targetInput := artifact.DownloadInput{
+		SourceChecksums: map[string]string{
+			updateutil.HashType: context.Current.TargetHash,
+		},
+	}
+	if targetOutput.IsHashMatched, err = verifyHash(log, targetInput, targetOutput); err != nil || !targetOutput.IsHashMatched {
+		return fmt.Errorf("target package built from delta %v does not match its hash, %v", deltaInput.SourceURL, err)
+	}
+
+	if err = uncompress(
+		log,
+		targetOutput.LocalFilePath,
+		updateutil.UpdateArtifactFolder(context.Current.UpdateRoot, context.Current.PackageName, context.Current.TargetVersion)); err != nil {
+		return fmt.Errorf("failed to uncompress installation package, %v", err.Error())
+	}
+
+	return nil
+}
+
+// applyDelta returns the file built from old and a delta in the bsdiff format.
+// The delta starts with a header followed by bzip2 compressed blocks of control triples, diff bytes and extra bytes.
+// Each triple adds the next x diff bytes to the old bytes, copies the next y extra bytes and seeks z bytes in old.
+func applyDelta(old []byte, delta []byte) ([]byte, error) {
+	if len(delta) < deltaHeaderLength || string(delta[:len(deltaMagic)]) != deltaMagic {
+		return nil, fmt.Errorf("delta is not in the %v format", deltaMagic)
+	}
+	controlLength := offtin(delta[8:16])
+	diffLength := offtin(delta[16:24])
+	newLength := offtin(delta[24:32])
+	if controlLength < 0 || diffLength < 0 || newLength < 0 ||
+		controlLength > int64(len(delta)-deltaHeaderLength) ||
+		diffLength > int64(len(delta)-deltaHeaderLength)-controlLength {
+		return nil, fmt.Errorf("delta header is corrupt")
+	}
+
+	controlStart := int64(deltaHeaderLength)
+	diffStart := controlStart + controlLength
+	extraStart := diffStart + diffLength
+	control := bzip2.NewReader(bytes.NewReader(delta[controlStart:diffStart]))
+	diff := bzip2.NewReader(bytes.NewReader(delta[diffStart:extraStart]))
+	extra := bzip2.NewReader(bytes.NewReader(delta[extraStart:]))
+
+	result := make([]byte, newLength)
+	var newPosition, oldPosition int64
+	triple := make([]byte, 24)
+	for newPosition < newLength {
+		if _, err := io.ReadFull(control, triple); err != nil {
+			return nil, fmt.Errorf("failed to read delta control block, %v", err)
+		}
+		diffCount, extraCount, seek := offtin(triple[0:8]), offtin(triple[8:16]), offtin(triple[16:24])
+		if diffCount < 0 || extraCount < 0 || newPosition+diffCount+extraCount > newLength {
+			return nil, fmt.Errorf("delta control block is corrupt")
+		}
+
+		if _, err := io.ReadFull(diff, result[newPosition:newPosition+diffCount]); err != nil {
+			return nil, fmt.Errorf("failed to read delta diff block, %v", err)
+		}
+		for i := int64(0); i < diffCount; i++ {
+			if position := oldPosition + i; position >= 0 && position < int64(len(old)) {
+				result[newPosition+i] += old[position]
+			}
+		}
+		newPosition += diffCount
+		oldPosition += diffCount
+
+		if _, err := io.ReadFull(extra, result[newPosition:newPosition+extraCount]); err != nil {
+			return nil, fmt.Errorf("failed to read delta extra block, %v", err)
+		}
+		newPosition += extraCount
+		oldPosition += seek
+	}
+	return result, nil
+}
+
+// offtin decodes the little endian sign and magnitude integers of the bsdiff format
+func offtin(buf []byte) int64 {
+	y := int64(buf[7] & 0x7f)
+	for i := 6; i >= 0; i-- {
+		y = y*256 + int64(buf[i])
+	}
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
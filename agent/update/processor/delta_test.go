@@ -0,0 +1,123 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	deltaSourceFile = "testdata/delta-source.bin"
+	deltaTargetFile = "testdata/delta-target.bin"
+	deltaFile       = "testdata/delta-target.bsdiff"
+	deltaTargetHash = "59ee3fa39986e6c3e0934187af6c8e85579de028930bc064ec9281d04a4cc0e3"
+)
+
+func TestApplyDelta(t *testing.T) {
+	source, _ := ioutil.ReadFile(deltaSourceFile)
+	target, _ := ioutil.ReadFile(deltaTargetFile)
+	delta, _ := ioutil.ReadFile(deltaFile)
+
+	result, err := applyDelta(source, delta)
+
+	assert.NoError(t, err)
+	assert.Equal(t, target, result)
+}
+
+func TestApplyDeltaInvalid(t *testing.T) {
+	source, _ := ioutil.ReadFile(deltaSourceFile)
+	delta, _ := ioutil.ReadFile(deltaFile)
+
+	_, err := applyDelta(source, []byte("not a delta"))
+	assert.Error(t, err)
+
+	// truncated blocks
+	_, err = applyDelta(source, delta[:deltaHeaderLength+10])
+	assert.Error(t, err)
+
+	// new file longer than the control block describes
+	corrupt := append([]byte{}, delta...)
+	corrupt[24]++
+	_, err = applyDelta(source, corrupt)
+	assert.Error(t, err)
+}
+
+func TestDownloadAndApplyDelta(t *testing.T) {
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	context.Current.TargetHash = deltaTargetHash
+	downloadFolder, _ := ioutil.TempDir("", "delta")
+	defer os.RemoveAll(downloadFolder)
+
+	downloadArtifact = stubDeltaDownload
+	uncompressed := ""
+	uncompress = func(log log.T, src, dest string) error {
+		uncompressed, _ = readTextFile(src)
+		return nil
+	}
+
+	err := downloadAndApplyDelta(updater.mgr, logger,
+		artifact.DownloadInput{SourceURL: "source"},
+		artifact.DownloadInput{SourceURL: "delta", DestinationDirectory: downloadFolder},
+		context)
+
+	assert.NoError(t, err)
+	target, _ := readTextFile(deltaTargetFile)
+	assert.Equal(t, target, uncompressed)
+}
+
+func TestDownloadAndApplyDeltaHashMismatch(t *testing.T) {
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	context.Current.TargetHash = "0000"
+	downloadFolder, _ := ioutil.TempDir("", "delta")
+	defer os.RemoveAll(downloadFolder)
+
+	downloadArtifact = stubDeltaDownload
+	isUncompressed := false
+	uncompress = func(log log.T, src, dest string) error {
+		isUncompressed = true
+		return nil
+	}
+
+	err := downloadAndApplyDelta(updater.mgr, logger,
+		artifact.DownloadInput{SourceURL: "source"},
+		artifact.DownloadInput{SourceURL: "delta", DestinationDirectory: downloadFolder},
+		context)
+
+	assert.Error(t, err)
+	assert.False(t, isUncompressed)
+}
+
+// stubDeltaDownload resolves the source and delta urls to their test files
+func stubDeltaDownload(log log.T, input artifact.DownloadInput) (output artifact.DownloadOutput, err error) {
+	output.IsHashMatched = true
+	if input.SourceURL == "source" {
+		output.LocalFilePath = deltaSourceFile
+	} else {
+		output.LocalFilePath = deltaFile
+	}
+	return output, nil
+}
+
+func readTextFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	return string(content), err
+}
@@ -31,6 +31,9 @@ type T interface {
 
 	// Failed sets update to failed with error messages
 	Failed(context *UpdateContext, log log.T, code updateutil.ErrorCode, errMessage string, noRollbackMessage bool) (err error)
+
+	// Rejected reports an update that cannot start as failed, without saving it to the update context
+	Rejected(log log.T, detail *UpdateDetail, errMessage string)
 }
 
 type prepare func(mgr *updateManager, log log.T, context *UpdateContext) (err error)
@@ -41,17 +44,26 @@ type uninstall func(mgr *updateManager, log log.T, version string, context *Upda
 type install func(mgr *updateManager, log log.T, version string, context *UpdateContext) (err error)
 type download func(mgr *updateManager, log log.T, downloadInput artifact.DownloadInput, context *UpdateContext, version string) (err error)
 
+type downloadDelta func(mgr *updateManager, log log.T, sourceInput artifact.DownloadInput, deltaInput artifact.DownloadInput, context *UpdateContext) (err error)
+
+type deferUpdate func(mgr *updateManager, log log.T, context *UpdateContext) (proceed bool, err error)
+
+type preflight func(mgr *updateManager, log log.T, context *UpdateContext, instanceContext *updateutil.InstanceContext) (code updateutil.ErrorCode, err error)
+
 type updateManager struct {
-	util      updateutil.T
-	svc       Service
-	ctxMgr    ContextMgr
-	prepare   prepare
-	update    update
-	verify    verify
-	rollback  rollback
-	uninstall uninstall
-	install   install
-	download  download
+	util          updateutil.T
+	svc           Service
+	ctxMgr        ContextMgr
+	prepare       prepare
+	update        update
+	verify        verify
+	rollback      rollback
+	uninstall     uninstall
+	install       install
+	download      download
+	downloadDelta downloadDelta
+	deferUpdate   deferUpdate
+	preflight     preflight
 }
 
 // Updater contains logic for performing agent update
@@ -0,0 +1,107 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+)
+
+// downgradePolicy returns an error when the agent must not be downgraded to the target version of the update
+type downgradePolicy func(log log.T, detail *UpdateDetail) error
+
+// stateFormat is a format of the state the agent keeps on disk
+type stateFormat struct {
+	name string
+	// path returns the location of the state, the format is in use when it exists
+	path func() string
+	// firstVersion is the first agent version reading the format
+	firstVersion string
+}
+
+// stateFormats lists the on-disk state formats older agents cannot read
+var stateFormats = []stateFormat{
+	{name: "agent checks", path: updateutil.AgentChecksFilePath, firstVersion: "2.3.0.0"},
+}
+
+// downgradePolicies are evaluated for updates to older versions, unless the update document allows downgrades
+var downgradePolicies = []downgradePolicy{minimumVersionPolicy, stateFormatPolicy}
+
+var fileExists = fileutil.Exists
+
+// evaluateDowngradePolicies returns the error of the first policy blocking the update when it downgrades the agent
+func evaluateDowngradePolicies(log log.T, detail *UpdateDetail) (err error) {
+	var result int
+	if result, err = updateutil.CompareVersion(detail.TargetVersion, detail.SourceVersion); err != nil {
+		return fmt.Errorf("failed to compare %v to %v, %v", detail.TargetVersion, detail.SourceVersion, err)
+	}
+	if result >= 0 {
+		return nil
+	}
+	if detail.AllowDowngrade {
+		log.Infof("Downgrade of %v to %v is allowed by the update document", detail.PackageName, detail.TargetVersion)
+		return nil
+	}
+
+	for _, policy := range downgradePolicies {
+		if err = policy(log, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minimumVersionPolicy blocks downgrades below the minimum version of the agent configuration
+func minimumVersionPolicy(log log.T, detail *UpdateDetail) (err error) {
+	var config appconfig.SsmagentConfig
+	if config, err = getAppConfig(false); err != nil {
+		return fmt.Errorf("could not load config file %v", err.Error())
+	}
+	if config.Update.MinimumVersion == "" {
+		return nil
+	}
+
+	var result int
+	if result, err = updateutil.CompareVersion(detail.TargetVersion, config.Update.MinimumVersion); err != nil {
+		return fmt.Errorf("invalid minimum version %v, %v", config.Update.MinimumVersion, err)
+	}
+	if result < 0 {
+		return fmt.Errorf("downgrading %v to %v is blocked, the minimum version is %v, allow downgrade in the update document to proceed",
+			detail.PackageName,
+			detail.TargetVersion,
+			config.Update.MinimumVersion)
+	}
+	return nil
+}
+
+// stateFormatPolicy blocks downgrades below the first version reading the state formats found on disk
+func stateFormatPolicy(log log.T, detail *UpdateDetail) error {
+	for _, format := range stateFormats {
+		if !fileExists(format.path()) {
+			continue
+		}
+		if result, err := updateutil.CompareVersion(detail.TargetVersion, format.firstVersion); err == nil && result < 0 {
+			return fmt.Errorf("downgrading %v to %v is blocked, versions older than %v cannot read the %v state on disk, allow downgrade in the update document to proceed",
+				detail.PackageName,
+				detail.TargetVersion,
+				format.firstVersion,
+				format.name)
+		}
+	}
+	return nil
+}
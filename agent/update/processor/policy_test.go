@@ -0,0 +1,115 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDowngradePolicies stubs the minimum version of the configuration and whether state is found on disk
+func stubDowngradePolicies(minimumVersion string, stateOnDisk bool) func() {
+	originalConfig, originalFileExists := getAppConfig, fileExists
+	getAppConfig = func(bool) (appconfig.SsmagentConfig, error) {
+		config := appconfig.DefaultConfig()
+		config.Update.MinimumVersion = minimumVersion
+		return config, nil
+	}
+	fileExists = func(filePath string) bool { return stateOnDisk }
+	return func() { getAppConfig, fileExists = originalConfig, originalFileExists }
+}
+
+func createDowngradeDetail(sourceVersion string, targetVersion string) *UpdateDetail {
+	return &UpdateDetail{
+		PackageName:   "amazon-ssm-agent",
+		SourceVersion: sourceVersion,
+		TargetVersion: targetVersion,
+	}
+}
+
+func TestEvaluateDowngradePoliciesUpgrade(t *testing.T) {
+	defer stubDowngradePolicies("6.0.0.0", true)()
+
+	err := evaluateDowngradePolicies(logger, createDowngradeDetail("5.0.0.0", "5.1.0.0"))
+
+	assert.NoError(t, err)
+}
+
+func TestEvaluateDowngradePoliciesBelowMinimumVersion(t *testing.T) {
+	defer stubDowngradePolicies("2.3.50.0", false)()
+
+	err := evaluateDowngradePolicies(logger, createDowngradeDetail("2.3.100.0", "2.3.60.0"))
+	assert.NoError(t, err)
+
+	err = evaluateDowngradePolicies(logger, createDowngradeDetail("2.3.100.0", "2.3.40.0"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the minimum version is 2.3.50.0")
+}
+
+func TestEvaluateDowngradePoliciesInvalidMinimumVersion(t *testing.T) {
+	defer stubDowngradePolicies("latest", false)()
+
+	err := evaluateDowngradePolicies(logger, createDowngradeDetail("2.3.100.0", "2.3.40.0"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid minimum version")
+}
+
+func TestEvaluateDowngradePoliciesBelowStateFormat(t *testing.T) {
+	defer stubDowngradePolicies("", true)()
+
+	err := evaluateDowngradePolicies(logger, createDowngradeDetail("2.3.100.0", "2.2.900.0"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot read the agent checks state")
+
+	// the state format is not in use
+	fileExists = func(filePath string) bool { return false }
+	err = evaluateDowngradePolicies(logger, createDowngradeDetail("2.3.100.0", "2.2.900.0"))
+	assert.NoError(t, err)
+}
+
+func TestEvaluateDowngradePoliciesAllowDowngrade(t *testing.T) {
+	defer stubDowngradePolicies("2.3.50.0", true)()
+	detail := createDowngradeDetail("2.3.100.0", "2.2.900.0")
+	detail.AllowDowngrade = true
+
+	err := evaluateDowngradePolicies(logger, detail)
+
+	assert.NoError(t, err)
+}
+
+func TestPrepareInstallationPackagesDowngradeBlocked(t *testing.T) {
+	defer stubDowngradePolicies("", true)()
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	context.Current.SourceVersion = "2.3.100.0"
+	context.Current.TargetVersion = "2.2.900.0"
+	isDownloadCalled := false
+	updater.mgr.download = func(mgr *updateManager, log log.T, downloadInput artifact.DownloadInput, context *UpdateContext, version string) (err error) {
+		isDownloadCalled = true
+		return nil
+	}
+
+	err := prepareInstallationPackages(updater.mgr, logger, context)
+
+	assert.NoError(t, err)
+	assert.False(t, isDownloadCalled)
+	assert.Equal(t, Completed, context.Histories[0].State)
+	assert.Equal(t, contracts.ResultStatusFailed, context.Histories[0].Result)
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+)
+
+var (
+	getDiskSpaceInfo  = fileutil.GetDiskSpaceInfoOfPath
+	lookPath          = exec.LookPath
+	getInstallBackend = updateutil.GetInstallBackend
+)
+
+// requiredTools are the tools the installers of each installer name run
+var requiredTools = map[string][]string{
+	updateutil.PlatformLinux:      {"tar", "rpm"},
+	updateutil.PlatformUbuntu:     {"tar", "dpkg"},
+	updateutil.PlatformUbuntuSnap: {"snap"},
+	updateutil.PlatformWindows:    {"msiexec"},
+}
+
+// runPreflightChecks verifies the instance can install the update before anything is downloaded,
+// it returns the error code of the first check that fails.
+func runPreflightChecks(
+	mgr *updateManager,
+	log log.T,
+	context *UpdateContext,
+	instanceContext *updateutil.InstanceContext) (code updateutil.ErrorCode, err error) {
+
+	log.Infof("Running update preflight checks")
+	if err = checkDiskSpace(context.Current.UpdateRoot); err != nil {
+		return updateutil.ErrorInsufficientDiskSpace, err
+	}
+
+	for _, tool := range requiredTools[instanceContext.InstallerName] {
+		if _, lookErr := lookPath(tool); lookErr != nil {
+			return updateutil.ErrorMissingDependency,
+				fmt.Errorf("%v is required to install %v but was not found, %v", tool, context.Current.PackageName, lookErr)
+		}
+	}
+
+	var manager string
+	if manager, err = getInstallBackend().ServiceManager(log, instanceContext); err != nil {
+		return updateutil.ErrorServiceManagerUnavailable, fmt.Errorf("failed to detect the service manager, %v", err)
+	}
+	// agents running in containers are restarted by the container supervisor
+	if manager == "" {
+		return "", nil
+	}
+	if _, lookErr := lookPath(manager); lookErr != nil {
+		return updateutil.ErrorServiceManagerUnavailable,
+			fmt.Errorf("service manager %v is required to restart %v but was not found, %v", manager, context.Current.PackageName, lookErr)
+	}
+	return "", nil
+}
+
+// checkDiskSpace verifies the disk of the update root has enough space for the update
+func checkDiskSpace(updateRoot string) error {
+	// the update root may not exist yet, its closest existing parent is on the same disk
+	path := updateRoot
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+
+	diskSpaceInfo, err := getDiskSpaceInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to load disk space of %v, %v", updateRoot, err)
+	}
+	if diskSpaceInfo.AvailBytes < updateutil.MinimumDiskSpaceForUpdate {
+		return fmt.Errorf("insufficient disk space in %v, %d Mb available, %d Mb required",
+			updateRoot,
+			diskSpaceInfo.AvailBytes/int64(1024*1024),
+			updateutil.MinimumDiskSpaceForUpdate/int64(1024*1024))
+	}
+	return nil
+}
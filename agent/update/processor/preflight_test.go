@@ -0,0 +1,146 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubPreflight stubs the disk space of the update root and the tools found on the instance
+func stubPreflight(availBytes int64, missingTools ...string) func() {
+	originalDiskSpace, originalLookPath := getDiskSpaceInfo, lookPath
+	getDiskSpaceInfo = func(path string) (fileutil.DiskSpaceInfo, error) {
+		return fileutil.DiskSpaceInfo{AvailBytes: availBytes}, nil
+	}
+	lookPath = func(file string) (string, error) {
+		for _, tool := range missingTools {
+			if tool == file {
+				return "", fmt.Errorf("executable file not found in $PATH")
+			}
+		}
+		return "/usr/bin/" + file, nil
+	}
+	return func() { getDiskSpaceInfo, lookPath = originalDiskSpace, originalLookPath }
+}
+
+func preflightInstanceContext(installerName string) *updateutil.InstanceContext {
+	return &updateutil.InstanceContext{
+		Platform:        updateutil.PlatformRedHat,
+		PlatformVersion: "7.4",
+		InstallerName:   installerName,
+	}
+}
+
+func TestRunPreflightChecks(t *testing.T) {
+	defer stubPreflight(updateutil.MinimumDiskSpaceForUpdate)()
+
+	code, err := runPreflightChecks(nil, logger, createUpdateContext(Initialized), preflightInstanceContext(updateutil.PlatformLinux))
+
+	assert.NoError(t, err)
+	assert.Equal(t, updateutil.ErrorCode(""), code)
+}
+
+func TestRunPreflightChecksInsufficientDiskSpace(t *testing.T) {
+	defer stubPreflight(updateutil.MinimumDiskSpaceForUpdate - 1)()
+
+	code, err := runPreflightChecks(nil, logger, createUpdateContext(Initialized), preflightInstanceContext(updateutil.PlatformLinux))
+
+	assert.Error(t, err)
+	assert.Equal(t, updateutil.ErrorInsufficientDiskSpace, code)
+}
+
+func TestRunPreflightChecksMissingDependency(t *testing.T) {
+	defer stubPreflight(updateutil.MinimumDiskSpaceForUpdate, "dpkg")()
+	context := createUpdateContext(Initialized)
+
+	// dpkg is only required by the ubuntu installer
+	code, err := runPreflightChecks(nil, logger, context, preflightInstanceContext(updateutil.PlatformLinux))
+	assert.NoError(t, err)
+
+	code, err = runPreflightChecks(nil, logger, context, preflightInstanceContext(updateutil.PlatformUbuntu))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dpkg")
+	assert.Equal(t, updateutil.ErrorMissingDependency, code)
+}
+
+func TestRunPreflightChecksServiceManagerUnavailable(t *testing.T) {
+	defer stubPreflight(updateutil.MinimumDiskSpaceForUpdate, "systemctl")()
+
+	code, err := runPreflightChecks(nil, logger, createUpdateContext(Initialized), preflightInstanceContext(updateutil.PlatformLinux))
+
+	assert.Error(t, err)
+	assert.Equal(t, updateutil.ErrorServiceManagerUnavailable, code)
+}
+
+// containerInstallBackend stubs the installation backend of agents running in containers
+type containerInstallBackend struct{}
+
+func (b *containerInstallBackend) Name() string { return updateutil.InstallBackendContainer }
+
+func (b *containerInstallBackend) InstallerName(platformInstallerName string) string {
+	return platformInstallerName
+}
+
+func (b *containerInstallBackend) Installer() string { return updateutil.ContainerInstaller }
+
+func (b *containerInstallBackend) UnInstaller() string { return updateutil.ContainerUnInstaller }
+
+func (b *containerInstallBackend) ServiceManager(log log.T, i *updateutil.InstanceContext) (string, error) {
+	return "", nil
+}
+
+func (b *containerInstallBackend) IsAgentRunning(log log.T, i *updateutil.InstanceContext) (bool, error) {
+	return true, nil
+}
+
+func TestRunPreflightChecksInContainer(t *testing.T) {
+	defer stubPreflight(updateutil.MinimumDiskSpaceForUpdate, "systemctl", "status")()
+	getInstallBackend = func() updateutil.InstallBackend { return &containerInstallBackend{} }
+	defer func() { getInstallBackend = updateutil.GetInstallBackend }()
+
+	// no service manager runs in containers
+	code, err := runPreflightChecks(nil, logger, createUpdateContext(Initialized), preflightInstanceContext(updateutil.PlatformLinux))
+
+	assert.NoError(t, err)
+	assert.Equal(t, updateutil.ErrorCode(""), code)
+}
+
+func TestPrepareInstallationPackagesPreflightFailed(t *testing.T) {
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	isDownloadCalled := false
+
+	updater.mgr.preflight = func(mgr *updateManager, log log.T, context *UpdateContext, instanceContext *updateutil.InstanceContext) (updateutil.ErrorCode, error) {
+		return updateutil.ErrorMissingDependency, fmt.Errorf("rpm is required")
+	}
+	updater.mgr.download = func(mgr *updateManager, log log.T, downloadInput artifact.DownloadInput, context *UpdateContext, version string) (err error) {
+		isDownloadCalled = true
+		return nil
+	}
+
+	err := prepareInstallationPackages(updater.mgr, logger, context)
+
+	assert.NoError(t, err)
+	assert.False(t, isDownloadCalled)
+	assert.Equal(t, Completed, context.Histories[0].State)
+	assert.Equal(t, contracts.ResultStatusFailed, context.Histories[0].Result)
+}
@@ -17,7 +17,7 @@ package processor
 
 import (
 	"fmt"
-	"sync"
+	"strings"
 
 	"time"
 
@@ -25,11 +25,9 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
 )
 
-var minimumSupportedVersions map[string]string
-var once sync.Once
-
 var (
 	downloadArtifact = artifact.Download
 	uncompress       = fileutil.Uncompress
@@ -39,16 +37,19 @@ var (
 func NewUpdater() *Updater {
 	updater := &Updater{
 		mgr: &updateManager{
-			util:      &updateutil.Utility{},
-			svc:       &svcManager{},
-			ctxMgr:    &contextManager{},
-			prepare:   prepareInstallationPackages,
-			update:    proceedUpdate,
-			verify:    verifyInstallation,
-			rollback:  rollbackInstallation,
-			uninstall: uninstallAgent,
-			install:   installAgent,
-			download:  downloadAndUnzipArtifact,
+			util:          &updateutil.Utility{},
+			svc:           &svcManager{},
+			ctxMgr:        &contextManager{},
+			prepare:       prepareInstallationPackages,
+			update:        proceedUpdate,
+			verify:        verifyInstallation,
+			rollback:      rollbackInstallation,
+			uninstall:     uninstallAgent,
+			install:       installAgent,
+			download:      downloadAndUnzipArtifact,
+			downloadDelta: downloadAndApplyDelta,
+			deferUpdate:   deferUntilMaintenanceWindow,
+			preflight:     runPreflightChecks,
 		},
 	}
 
@@ -58,7 +59,7 @@ func NewUpdater() *Updater {
 // StartOrResumeUpdate starts/resume update.
 func (u *Updater) StartOrResumeUpdate(log log.T, context *UpdateContext) (err error) {
 	switch {
-	case context.Current.State == Initialized:
+	case context.Current.State == Initialized, context.Current.State == Pending:
 		return u.mgr.prepare(u.mgr, log, context)
 	case context.Current.State == Staged:
 		return u.mgr.update(u.mgr, log, context)
@@ -110,33 +111,17 @@ func (u *Updater) Failed(context *UpdateContext, log log.T, code updateutil.Erro
 	return u.mgr.failed(context, log, code, errMessage, noRollbackMessage)
 }
 
+// Rejected reports an update that cannot start because another update is running as failed,
+// the update context of the running update is left untouched
+func (u *Updater) Rejected(log log.T, detail *UpdateDetail, errMessage string) {
+	u.mgr.rejected(log, detail, errMessage)
+}
+
 // validateUpdateVersion validates target version number base on the current platform
 // to avoid accidentally downgrade agent to the earlier version that doesn't support current platform
+// or upgrade it to a version that no longer supports it
 func validateUpdateVersion(log log.T, detail *UpdateDetail, instanceContext *updateutil.InstanceContext) (err error) {
-	compareResult := 0
-	minimumVersions := getMinimumVSupportedVersions()
-
-	// check if current platform has minimum supported version
-	if val, ok := (*minimumVersions)[instanceContext.Platform]; ok {
-		// compare current agent version with minimum supported version
-		if compareResult, err = updateutil.VersionCompare(detail.TargetVersion, val); err != nil {
-			return err
-		}
-		if compareResult < 0 {
-			return fmt.Errorf("Agent version %v is unsupported on current platform", detail.TargetVersion)
-		}
-	}
-
-	return nil
-}
-
-// getMinimumVSupportedVersions returns a map of minimum supported version and it's platform
-func getMinimumVSupportedVersions() (versions *map[string]string) {
-	once.Do(func() {
-		minimumSupportedVersions = make(map[string]string)
-		minimumSupportedVersions[updateutil.PlatformCentOS] = "1.0.187.0"
-	})
-	return &minimumSupportedVersions
+	return versionutil.ValidatePlatformSupport(instanceContext.Platform, instanceContext.PlatformVersion, detail.TargetVersion)
 }
 
 // prepareInstallationPackages downloads artifacts from public s3 storage
@@ -148,10 +133,31 @@ func prepareInstallationPackages(mgr *updateManager, log log.T, context *UpdateC
 	if instanceContext, err = mgr.util.CreateInstanceContext(log); err != nil {
 		return mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, err.Error(), false)
 	}
+	if err = mgr.reportPhase(context, log, PhaseVerifying); err != nil {
+		return err
+	}
 	if err = validateUpdateVersion(log, context.Current, instanceContext); err != nil {
 		return mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, err.Error(), true)
 	}
+	if err = evaluateDowngradePolicies(log, context.Current); err != nil {
+		return mgr.failed(context, log, updateutil.ErrorAttemptToDowngrade, err.Error(), true)
+	}
 
+	// Wait for the next maintenance window when requested outside of the windows
+	var proceed bool
+	if proceed, err = mgr.deferUpdate(mgr, log, context); err != nil || !proceed {
+		return err
+	}
+
+	// Fail early with the cause when the update cannot be installed on this instance
+	var code updateutil.ErrorCode
+	if code, err = mgr.preflight(mgr, log, context, instanceContext); err != nil {
+		return mgr.failed(context, log, code, err.Error(), true)
+	}
+
+	if err = mgr.reportPhase(context, log, PhaseDownloading); err != nil {
+		return err
+	}
 	if updateDownload, err = mgr.util.CreateUpdateDownloadFolder(); err != nil {
 		message := updateutil.BuildMessage(
 			err,
@@ -162,7 +168,7 @@ func prepareInstallationPackages(mgr *updateManager, log log.T, context *UpdateC
 	}
 
 	// Download source
-	downloadInput := artifact.DownloadInput{
+	sourceInput := artifact.DownloadInput{
 		SourceURL: context.Current.SourceLocation,
 		SourceChecksums: map[string]string{
 			updateutil.HashType: context.Current.SourceHash,
@@ -170,21 +176,46 @@ func prepareInstallationPackages(mgr *updateManager, log log.T, context *UpdateC
 		DestinationDirectory: updateDownload,
 	}
 
-	if err = mgr.download(mgr, log, downloadInput, context, context.Current.SourceVersion); err != nil {
+	if err = mgr.download(mgr, log, sourceInput, context, context.Current.SourceVersion); err != nil {
 		return mgr.failed(context, log, updateutil.ErrorInvalidPackage, err.Error(), true)
 	}
 
-	// Download target
-	downloadInput = artifact.DownloadInput{
-		SourceURL: context.Current.TargetLocation,
-		SourceChecksums: map[string]string{
-			updateutil.HashType: context.Current.TargetHash,
-		},
-		DestinationDirectory: updateDownload,
+	// Build target from the delta to the source when available, it is smaller than the target package
+	targetPrepared := false
+	if context.Current.HasTargetDelta() {
+		deltaInput := artifact.DownloadInput{
+			SourceURL: context.Current.TargetDeltaLocation,
+			SourceChecksums: map[string]string{
+				updateutil.HashType: context.Current.TargetDeltaHash,
+			},
+			DestinationDirectory: updateDownload,
+		}
+
+		if err = mgr.downloadDelta(mgr, log, sourceInput, deltaInput, context); err != nil {
+			log.Warnf("Failed to prepare %v from delta, downloading the full package: %v", context.Current.TargetVersion, err)
+			context.Current.AppendInfo(
+				log,
+				"Failed to apply delta for %v %v, falling back to full package download",
+				context.Current.PackageName,
+				context.Current.TargetVersion)
+		} else {
+			targetPrepared = true
+		}
 	}
 
-	if err = mgr.download(mgr, log, downloadInput, context, context.Current.TargetVersion); err != nil {
-		return mgr.failed(context, log, updateutil.ErrorInvalidPackage, err.Error(), true)
+	// Download target
+	if !targetPrepared {
+		downloadInput := artifact.DownloadInput{
+			SourceURL: context.Current.TargetLocation,
+			SourceChecksums: map[string]string{
+				updateutil.HashType: context.Current.TargetHash,
+			},
+			DestinationDirectory: updateDownload,
+		}
+
+		if err = mgr.download(mgr, log, downloadInput, context, context.Current.TargetVersion); err != nil {
+			return mgr.failed(context, log, updateutil.ErrorInvalidPackage, err.Error(), true)
+		}
 	}
 
 	// Update stdout
@@ -210,6 +241,13 @@ func proceedUpdate(mgr *updateManager, log log.T, context *UpdateContext) (err e
 		context.Current.SourceVersion,
 		context.Current.TargetVersion)
 
+	if err = mgr.reportPhase(context, log, PhaseInstalling); err != nil {
+		return err
+	}
+
+	// the source agent checks are overwritten once the target version starts
+	setRequiredChecks(log, context)
+
 	// Uninstall only when the target version is lower than the source version
 	if context.Current.RequiresUninstall {
 		if err = mgr.uninstall(mgr, log, context.Current.SourceVersion, context); err != nil {
@@ -263,6 +301,11 @@ func verifyInstallation(mgr *updateManager, log log.T, context *UpdateContext, i
 	if instanceContext, err = mgr.util.CreateInstanceContext(log); err != nil {
 		return mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, err.Error(), false)
 	}
+	if !isRollback {
+		if err = mgr.reportPhase(context, log, PhaseVerifyingNewAgent); err != nil {
+			return err
+		}
+	}
 
 	log.Infof("Initiating update health check")
 	if isRunning, err = mgr.util.WaitForServiceToStart(log, instanceContext); err != nil || !isRunning {
@@ -297,7 +340,29 @@ func verifyInstallation(mgr *updateManager, log log.T, context *UpdateContext, i
 
 	log.Infof("%v is running", context.Current.PackageName)
 	if !isRollback {
-		return mgr.succeeded(context, log)
+		var missing []string
+		if missing, err = waitForAgentChecks(log, context); err != nil {
+			return mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, err.Error(), false)
+		}
+		if len(missing) == 0 {
+			return mgr.succeeded(context, log)
+		}
+
+		context.Current.AppendError(
+			log,
+			"failed to update %v to %v, the agent did not pass checks %v",
+			context.Current.PackageName,
+			context.Current.TargetVersion,
+			strings.Join(missing, ", "))
+		context.Current.AppendInfo(
+			log,
+			"Initiating rollback %v to %v",
+			context.Current.PackageName,
+			context.Current.SourceVersion)
+		if err = mgr.inProgress(context, log, Rollback); err != nil {
+			return err
+		}
+		return mgr.rollback(mgr, log, context)
 	}
 
 	message := fmt.Sprintf("rolledback %v to %v", context.Current.PackageName, context.Current.SourceVersion)
@@ -307,6 +372,10 @@ func verifyInstallation(mgr *updateManager, log log.T, context *UpdateContext, i
 
 // rollbackInstallation rollback installation to the source version
 func rollbackInstallation(mgr *updateManager, log log.T, context *UpdateContext) (err error) {
+	if err = mgr.reportPhase(context, log, PhaseRollingBack); err != nil {
+		return err
+	}
+
 	if err = mgr.uninstall(mgr, log, context.Current.TargetVersion, context); err != nil {
 		// Fail the rollback process as a result of target version cannot be uninstalled
 		message := updateutil.BuildMessage(
@@ -44,6 +44,10 @@ func (s *serviceStub) UpdateHealthCheck(log log.T, update *UpdateDetail, errorCo
 	return nil
 }
 
+func (s *serviceStub) PublishProgress(log log.T, update *UpdateDetail, message string) error {
+	return nil
+}
+
 type contextMgrStub struct{}
 
 func (c *contextMgrStub) saveUpdateContext(log log.T, context *UpdateContext, contextLocation string) (err error) {
@@ -142,6 +146,62 @@ func TestPrepareInstallationPackages(t *testing.T) {
 	assert.True(t, isUpdateCalled)
 }
 
+func TestPrepareInstallationPackagesWithDelta(t *testing.T) {
+	// setup
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	context.Current.TargetDeltaLocation = "delta"
+	downloadedVersions := []string{}
+
+	updater.mgr.download = func(mgr *updateManager, log log.T, downloadInput artifact.DownloadInput, context *UpdateContext, version string) (err error) {
+		downloadedVersions = append(downloadedVersions, version)
+		return nil
+	}
+	updater.mgr.downloadDelta = func(mgr *updateManager, log log.T, sourceInput artifact.DownloadInput, deltaInput artifact.DownloadInput, context *UpdateContext) (err error) {
+		assert.Equal(t, "delta", deltaInput.SourceURL)
+		return nil
+	}
+	updater.mgr.update = func(mgr *updateManager, log log.T, context *UpdateContext) (err error) {
+		return nil
+	}
+	// action
+	err := prepareInstallationPackages(updater.mgr, logger, context)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, context.Current.State, Staged)
+	assert.Equal(t, []string{context.Current.SourceVersion}, downloadedVersions)
+}
+
+func TestPrepareInstallationPackagesDeltaFallback(t *testing.T) {
+	// setup
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	context.Current.SourceVersion = "2.3.0.0"
+	context.Current.TargetVersion = "2.3.1.0"
+	context.Current.TargetDeltaLocation = "delta"
+	downloadedVersions := []string{}
+
+	updater.mgr.download = func(mgr *updateManager, log log.T, downloadInput artifact.DownloadInput, context *UpdateContext, version string) (err error) {
+		downloadedVersions = append(downloadedVersions, version)
+		return nil
+	}
+	updater.mgr.downloadDelta = func(mgr *updateManager, log log.T, sourceInput artifact.DownloadInput, deltaInput artifact.DownloadInput, context *UpdateContext) (err error) {
+		return fmt.Errorf("corrupt delta")
+	}
+	updater.mgr.update = func(mgr *updateManager, log log.T, context *UpdateContext) (err error) {
+		return nil
+	}
+	// action
+	err := prepareInstallationPackages(updater.mgr, logger, context)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, context.Current.State, Staged)
+	assert.Equal(t, []string{"2.3.0.0", "2.3.1.0"}, downloadedVersions)
+	assert.Contains(t, context.Current.StandardOut, "falling back to full package download")
+}
+
 func TestPreparePackagesFailCreateInstanceContext(t *testing.T) {
 	// setup
 	control := &stubControl{failCreateInstanceContext: true}
@@ -227,6 +287,23 @@ func TestValidateUpdateVersionFailCentOs(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestValidateUpdateVersionFailUbuntu1404(t *testing.T) {
+	context := createUpdateContext(Initialized)
+	context.Current.TargetVersion = "3.0.0.0"
+	instanceContext := &updateutil.InstanceContext{
+		Region:          "us-east-1",
+		Platform:        updateutil.PlatformUbuntu,
+		PlatformVersion: "14.04",
+		InstallerName:   "ubuntu",
+		Arch:            "amd64",
+		CompressFormat:  "tar.gz",
+	}
+
+	err := validateUpdateVersion(logger, context.Current, instanceContext)
+
+	assert.Error(t, err)
+}
+
 func TestProceedUpdate(t *testing.T) {
 	// setup
 	updater := createDefaultUpdaterStub()
@@ -630,6 +707,9 @@ func createUpdaterStubs(control *stubControl) *Updater {
 	updater.mgr.svc = &serviceStub{}
 	updater.mgr.util = &utilityStub{controller: control}
 	updater.mgr.ctxMgr = &contextMgrStub{}
+	updater.mgr.preflight = func(mgr *updateManager, log log.T, context *UpdateContext, instanceContext *updateutil.InstanceContext) (updateutil.ErrorCode, error) {
+		return "", nil
+	}
 
 	return updater
 }
@@ -16,6 +16,7 @@
 package processor
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -50,6 +51,33 @@ func (u *updateManager) inProgress(context *UpdateContext, log log.T, state Upda
 	return nil
 }
 
+// reportPhase records the phase the update entered, and reports it in the output of the update and to
+// CloudWatch Logs when the output is streamed there, so that operators can see where an update in progress is
+func (u *updateManager) reportPhase(context *UpdateContext, log log.T, phase UpdatePhase) (err error) {
+	update := context.Current
+	update.Phase = phase
+	message := fmt.Sprintf("Update of %v to %v entered phase %v", update.PackageName, update.TargetVersion, phase)
+	update.AppendInfo(log, "%v", message)
+
+	// resolve context location base on the UpdateRoot
+	contextLocation := updateutil.UpdateContextFilePath(update.UpdateRoot)
+	if err = u.ctxMgr.saveUpdateContext(log, context, contextLocation); err != nil {
+		return err
+	}
+
+	if update.HasMessageID() {
+		if err = u.svc.SendReply(log, update); err != nil {
+			log.Error(err)
+		}
+	}
+
+	if err = u.svc.PublishProgress(log, update, message); err != nil {
+		log.Error(err)
+	}
+
+	return nil
+}
+
 // succeeded sets update to completed
 func (u *updateManager) succeeded(context *UpdateContext, log log.T) (err error) {
 	update := context.Current
@@ -61,9 +89,28 @@ func (u *updateManager) succeeded(context *UpdateContext, log log.T) (err error)
 		update.PackageName,
 		update.TargetVersion)
 
+	cleanupArtifacts(log, update)
 	return u.finalizeUpdateAndSendReply(log, context, "")
 }
 
+// rejected reports an update that does not own the update context as failed, without saving the context
+func (u *updateManager) rejected(log log.T, update *UpdateDetail, errMessage string) {
+	if !update.HasMessageID() {
+		return
+	}
+
+	update.State = Completed
+	update.Result = contracts.ResultStatusFailed
+	update.EndDateTime = timeNow().UTC()
+	update.AppendInfo(log, "%v", errMessage)
+	if err := u.svc.SendReply(log, update); err != nil {
+		log.Error(err)
+	}
+	if err := u.svc.DeleteMessage(log, update); err != nil {
+		log.Error(err)
+	}
+}
+
 // failed sets update to failed with error messages
 func (u *updateManager) failed(context *UpdateContext, log log.T, code updateutil.ErrorCode, errMessage string, noRollbackMessage bool) (err error) {
 	update := context.Current
@@ -66,6 +66,17 @@ func TestUpdateFailed(t *testing.T) {
 	assert.Equal(t, context.Histories[0].Result, contracts.ResultStatusFailed)
 }
 
+func TestUpdateReportPhase(t *testing.T) {
+	updater := createDefaultUpdaterStub()
+	context := generateTestCase().Context
+	context.Current = &UpdateDetail{PackageName: "amazon-ssm-agent", TargetVersion: "5.0.0.0"}
+	err := updater.mgr.reportPhase(context, logger, PhaseDownloading)
+
+	assert.NoError(t, err)
+	assert.Equal(t, PhaseDownloading, context.Current.Phase)
+	assert.Contains(t, context.Current.StandardOut, "entered phase Downloading")
+}
+
 type ContextTestCase struct {
 	Context      *UpdateContext
 	InfoMessage  string
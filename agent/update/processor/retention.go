@@ -0,0 +1,155 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+)
+
+// artifactVersion is the folder of one version of an update package in an update root
+type artifactVersion struct {
+	path    string
+	version versionutil.Version
+	size    int64
+}
+
+var removeArtifacts = fileutil.DeleteDirectory
+
+// cleanupArtifacts enforces the artifact retention policy of the configuration in the update root and in
+// the legacy update root, the versions of the update and of the running updater are always kept.
+func cleanupArtifacts(log log.T, update *UpdateDetail) {
+	config, err := getAppConfig(false)
+	if err != nil {
+		log.Warnf("Skipping update artifacts cleanup, could not load config file %v", err)
+		return
+	}
+
+	protected := map[string]bool{
+		update.SourceVersion: true,
+		update.TargetVersion: true,
+		version.Version:      true,
+	}
+	maxDiskUsage := int64(config.Update.ArtifactMaxDiskUsageMB) * 1024 * 1024
+
+	roots := []string{update.UpdateRoot}
+	if updateutil.LegacyUpdaterArtifactsRoot != "" && filepath.Clean(updateutil.LegacyUpdaterArtifactsRoot) != filepath.Clean(update.UpdateRoot) {
+		roots = append(roots, updateutil.LegacyUpdaterArtifactsRoot)
+	}
+	for _, root := range roots {
+		if !fileutil.Exists(root) {
+			continue
+		}
+		enforceRetention(log, root, protected, config.Update.ArtifactRetentionCount, maxDiskUsage)
+	}
+}
+
+// enforceRetention keeps the protected versions and the newest retentionCount versions of each package of the
+// update root, then removes the oldest kept versions until they use less than maxDiskUsage bytes, when it is not zero.
+func enforceRetention(log log.T, root string, protected map[string]bool, retentionCount int, maxDiskUsage int64) {
+	var kept []artifactVersion
+	var usage int64
+	for _, versions := range listArtifactVersions(log, root) {
+		count := 0
+		for _, artifact := range versions {
+			if protected[artifact.version.Original] || count < retentionCount {
+				count++
+				kept = append(kept, artifact)
+				usage += artifact.size
+				continue
+			}
+			removeArtifactVersion(log, artifact)
+		}
+	}
+
+	if maxDiskUsage == 0 || usage <= maxDiskUsage {
+		return
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].version.Compare(kept[j].version) < 0 })
+	for _, artifact := range kept {
+		if usage <= maxDiskUsage {
+			break
+		}
+		if protected[artifact.version.Original] {
+			continue
+		}
+		if removeArtifactVersion(log, artifact) {
+			usage -= artifact.size
+		}
+	}
+	if usage > maxDiskUsage {
+		log.Warnf("Update artifacts in %v use %d bytes after cleanup, more than %d bytes", root, usage, maxDiskUsage)
+	}
+}
+
+// listArtifactVersions returns the version folders of each package of the update root, newest first.
+// Folders whose name is not a version, such as the output folder, are not artifacts.
+func listArtifactVersions(log log.T, root string) map[string][]artifactVersion {
+	packages, err := fileutil.GetDirectoryNames(root)
+	if err != nil {
+		log.Warnf("Failed to list update packages in %v, %v", root, err)
+		return nil
+	}
+
+	artifacts := make(map[string][]artifactVersion)
+	for _, packageName := range packages {
+		versionNames, err := fileutil.GetDirectoryNames(filepath.Join(root, packageName))
+		if err != nil {
+			log.Warnf("Failed to list versions of %v in %v, %v", packageName, root, err)
+			continue
+		}
+		var versions []artifactVersion
+		for _, versionName := range versionNames {
+			parsed, err := versionutil.Parse(versionName)
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(root, packageName, versionName)
+			versions = append(versions, artifactVersion{path: path, version: parsed, size: directorySize(path)})
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i].version.Compare(versions[j].version) > 0 })
+		if len(versions) > 0 {
+			artifacts[packageName] = versions
+		}
+	}
+	return artifacts
+}
+
+// removeArtifactVersion removes the folder of an artifact version, it returns whether it was removed
+func removeArtifactVersion(log log.T, artifact artifactVersion) bool {
+	log.Infof("Removing update artifacts %v", artifact.path)
+	if err := removeArtifacts(artifact.path); err != nil {
+		log.Warnf("Failed to remove update artifacts %v, %v", artifact.path, err)
+		return false
+	}
+	return true
+}
+
+// directorySize returns the size of the files in a directory
+func directorySize(path string) (size int64) {
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// createArtifacts creates an update root with a package file of the given size in each version folder
+func createArtifacts(t *testing.T, size int, packageVersions map[string][]string) string {
+	root, err := ioutil.TempDir("", "updateroot")
+	assert.NoError(t, err)
+	for packageName, versions := range packageVersions {
+		for _, version := range versions {
+			folder := updateutil.UpdateArtifactFolder(root, packageName, version)
+			assert.NoError(t, os.MkdirAll(folder, appconfig.ReadWriteExecuteAccess))
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "package.tar.gz"), make([]byte, size), appconfig.ReadWriteAccess))
+		}
+	}
+	assert.NoError(t, os.MkdirAll(updateutil.UpdateOutputDirectory(root), appconfig.ReadWriteExecuteAccess))
+	return root
+}
+
+// remainingVersions returns the version folders of a package, sorted by name
+func remainingVersions(root string, packageName string) (versions []string) {
+	infos, _ := ioutil.ReadDir(filepath.Join(root, packageName))
+	for _, info := range infos {
+		versions = append(versions, info.Name())
+	}
+	return versions
+}
+
+func TestEnforceRetentionKeepsNewestVersions(t *testing.T) {
+	root := createArtifacts(t, 10, map[string][]string{
+		"amazon-ssm-agent":         {"2.2.9.0", "2.2.10.0", "2.3.1.0", "2.3.2.0"},
+		"amazon-ssm-agent-updater": {"2.2.10.0", "2.3.2.0"},
+	})
+	defer os.RemoveAll(root)
+
+	// the source version is kept in addition to the newest versions
+	enforceRetention(logger, root, map[string]bool{"2.2.9.0": true}, 2, 0)
+
+	assert.Equal(t, []string{"2.2.9.0", "2.3.1.0", "2.3.2.0"}, remainingVersions(root, "amazon-ssm-agent"))
+	assert.Equal(t, []string{"2.2.10.0", "2.3.2.0"}, remainingVersions(root, "amazon-ssm-agent-updater"))
+	assert.True(t, fileExists(updateutil.UpdateOutputDirectory(root)))
+}
+
+func TestEnforceRetentionMaxDiskUsage(t *testing.T) {
+	root := createArtifacts(t, 100, map[string][]string{
+		"amazon-ssm-agent": {"2.3.1.0", "2.3.2.0", "2.3.3.0"},
+	})
+	defer os.RemoveAll(root)
+
+	enforceRetention(logger, root, map[string]bool{"2.3.1.0": true}, 3, 250)
+
+	// the oldest version is protected, the next oldest is removed instead
+	assert.Equal(t, []string{"2.3.1.0", "2.3.3.0"}, remainingVersions(root, "amazon-ssm-agent"))
+}
+
+func TestCleanupArtifacts(t *testing.T) {
+	root := createArtifacts(t, 10, map[string][]string{
+		"amazon-ssm-agent": {"2.3.1.0", "2.3.2.0", "2.3.3.0", "2.3.4.0"},
+	})
+	defer os.RemoveAll(root)
+	original := getAppConfig
+	getAppConfig = func(bool) (appconfig.SsmagentConfig, error) {
+		config := appconfig.DefaultConfig()
+		config.Update.ArtifactRetentionCount = 1
+		return config, nil
+	}
+	defer func() { getAppConfig = original }()
+
+	cleanupArtifacts(logger, &UpdateDetail{SourceVersion: "2.3.3.0", TargetVersion: "2.3.4.0", UpdateRoot: root})
+
+	assert.Equal(t, []string{"2.3.3.0", "2.3.4.0"}, remainingVersions(root, "amazon-ssm-agent"))
+}
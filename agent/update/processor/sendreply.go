@@ -21,6 +21,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
+	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher/cloudwatchlogsinterface"
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
@@ -28,6 +30,8 @@ import (
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
 	messageService "github.com/aws/amazon-ssm-agent/agent/runcommand/mds"
 	"github.com/aws/amazon-ssm-agent/agent/times"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 )
 
 var msgSvc messageService.Service
@@ -35,12 +39,16 @@ var msgSvcOnce sync.Once
 
 var newMsgSvc = messageService.NewService
 var getAppConfig = appconfig.Config
+var newCloudWatchLogsService = func() cloudwatchlogsinterface.ICloudWatchLogsService {
+	return cloudwatchlogspublisher.NewCloudWatchLogsService()
+}
 
 // Service is an interface represents for SendReply, UpdateInstanceInfo
 type Service interface {
 	SendReply(log log.T, update *UpdateDetail) error
 	DeleteMessage(log log.T, update *UpdateDetail) error
 	UpdateHealthCheck(log log.T, update *UpdateDetail, errorCode string) error
+	PublishProgress(log log.T, update *UpdateDetail, message string) error
 }
 
 type svcManager struct{}
@@ -82,6 +90,40 @@ func (s *svcManager) DeleteMessage(log log.T, update *UpdateDetail) (err error)
 	return svc.DeleteMessage(log, update.MessageID)
 }
 
+// PublishProgress publishes a progress message of the update to its CloudWatch log stream, when the update has one
+func (s *svcManager) PublishProgress(log log.T, update *UpdateDetail, message string) (err error) {
+	if update.OutputLogGroupName == "" || update.OutputLogStreamName == "" {
+		return nil
+	}
+
+	cwl := newCloudWatchLogsService()
+	if !cwl.IsLogGroupPresent(log, update.OutputLogGroupName) {
+		if err = cwl.CreateLogGroup(log, update.OutputLogGroupName); err != nil {
+			return fmt.Errorf("could not create log group %v, %v", update.OutputLogGroupName, err)
+		}
+	}
+	if !cwl.IsLogStreamPresent(log, update.OutputLogGroupName, update.OutputLogStreamName) {
+		if err = cwl.CreateLogStream(log, update.OutputLogGroupName, update.OutputLogStreamName); err != nil {
+			return fmt.Errorf("could not create log stream %v, %v", update.OutputLogStreamName, err)
+		}
+	}
+
+	event := &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(message),
+		Timestamp: aws.Int64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+	sequenceToken := cwl.GetSequenceTokenForStream(log, update.OutputLogGroupName, update.OutputLogStreamName)
+	if _, err = cwl.PutLogEvents(
+		log,
+		[]*cloudwatchlogs.InputLogEvent{event},
+		update.OutputLogGroupName,
+		update.OutputLogStreamName,
+		sequenceToken); err != nil {
+		return fmt.Errorf("could not publish update progress, %v", err)
+	}
+	return nil
+}
+
 // getMsgSvc gets cached message service
 func getMsgSvc(config appconfig.SsmagentConfig) (svc messageService.Service, err error) {
 	msgSvcOnce.Do(func() {
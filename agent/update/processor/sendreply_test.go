@@ -19,12 +19,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher/cloudwatchlogsinterface"
+	cloudwatchlogspublisher_mock "github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher/mock"
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	messageService "github.com/aws/amazon-ssm-agent/agent/runcommand/mds"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/service/ssmmds"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // stubSdkService is the stub for sdkService
@@ -108,3 +111,38 @@ func TestSendReplyDeleteMessage(t *testing.T) {
 	// assert
 	assert.NoError(t, err)
 }
+
+func TestPublishProgressWithoutLogStream(t *testing.T) {
+	context := createUpdateContext(Installed)
+	service := svcManager{}
+	cwl := new(cloudwatchlogspublisher_mock.CloudWatchLogsServiceMock)
+	newCloudWatchLogsService = func() cloudwatchlogsinterface.ICloudWatchLogsService { return cwl }
+
+	// action
+	err := service.PublishProgress(logger, context.Current, "progress")
+
+	// assert
+	assert.NoError(t, err)
+	cwl.AssertNotCalled(t, "PutLogEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPublishProgress(t *testing.T) {
+	context := createUpdateContext(Installed)
+	context.Current.OutputLogGroupName = "group"
+	context.Current.OutputLogStreamName = "prefix/updateProgress"
+	service := svcManager{}
+	cwl := new(cloudwatchlogspublisher_mock.CloudWatchLogsServiceMock)
+	cwl.On("IsLogGroupPresent", mock.Anything, "group").Return(true)
+	cwl.On("IsLogStreamPresent", mock.Anything, "group", "prefix/updateProgress").Return(false)
+	cwl.On("CreateLogStream", mock.Anything, "group", "prefix/updateProgress").Return(nil)
+	cwl.On("GetSequenceTokenForStream", mock.Anything, "group", "prefix/updateProgress").Return(nil)
+	cwl.On("PutLogEvents", mock.Anything, mock.Anything, "group", "prefix/updateProgress", mock.Anything).Return(nil, nil)
+	newCloudWatchLogsService = func() cloudwatchlogsinterface.ICloudWatchLogsService { return cwl }
+
+	// action
+	err := service.PublishProgress(logger, context.Current, "progress")
+
+	// assert
+	assert.NoError(t, err)
+	cwl.AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+)
+
+// agentChecksPollInterval is how often the updater reloads the checks passed by the updated agent
+const agentChecksPollInterval = 10 * time.Second
+
+var loadAgentChecks = updateutil.LoadAgentChecks
+
+// defaultRequiredChecks are required from the updated agent when the source agent recorded no checks
+var defaultRequiredChecks = []string{updateutil.CheckRegistered, updateutil.CheckMdsConnected}
+
+// setRequiredChecks sets the checks the updated agent must pass, which are the checks the source agent passed,
+// so that an instance without connectivity to MGS for instance does not roll back every update, and the self-test.
+func setRequiredChecks(log log.T, context *UpdateContext) {
+	required := defaultRequiredChecks
+	if checks, err := loadAgentChecks(); err == nil && checks.Version == context.Current.SourceVersion {
+		required = checks.PassedChecks()
+	} else {
+		log.Infof("Source agent recorded no checks, requiring %v", strings.Join(required, ", "))
+	}
+
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest}
+	for _, check := range required {
+		if check != updateutil.CheckSelfTest {
+			context.Current.RequiredChecks = append(context.Current.RequiredChecks, check)
+		}
+	}
+}
+
+// waitForAgentChecks waits for the updated agent to pass the required checks within the verification timeout,
+// it returns the checks that have not been passed when the timeout expires.
+func waitForAgentChecks(log log.T, context *UpdateContext) (missing []string, err error) {
+	// updates started by an updater without checks have none
+	if len(context.Current.RequiredChecks) == 0 {
+		return nil, nil
+	}
+	// agents older than the source agent may not record checks
+	if result, err := updateutil.CompareVersion(context.Current.TargetVersion, context.Current.SourceVersion); err != nil || result < 0 {
+		log.Infof("Skipping agent checks of %v %v", context.Current.PackageName, context.Current.TargetVersion)
+		return nil, nil
+	}
+
+	var config appconfig.SsmagentConfig
+	if config, err = getAppConfig(false); err != nil {
+		return nil, fmt.Errorf("could not load config file %v", err.Error())
+	}
+	timeout := time.Duration(config.Update.VerificationTimeoutMinutes) * time.Minute
+
+	log.Infof("Waiting up to %v for the agent to pass checks %v", timeout, strings.Join(context.Current.RequiredChecks, ", "))
+	deadline := timeNow().Add(timeout)
+	for {
+		missing = context.Current.RequiredChecks
+		if checks, loadErr := loadAgentChecks(); loadErr == nil && checks.Version == context.Current.TargetVersion {
+			missing = checks.Missing(context.Current.RequiredChecks)
+		}
+		if len(missing) == 0 || !timeNow().Before(deadline) {
+			return missing, nil
+		}
+		sleep(agentChecksPollInterval)
+	}
+}
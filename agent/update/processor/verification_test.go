@@ -0,0 +1,138 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubAgentChecks stubs the checks recorded by the agent and the clock the updater polls them with
+func stubAgentChecks(checks *updateutil.AgentChecks) func() {
+	originalLoad, originalNow, originalSleep := loadAgentChecks, timeNow, sleep
+	now := sundayNight
+	loadAgentChecks = func() (*updateutil.AgentChecks, error) {
+		if checks == nil {
+			return nil, fmt.Errorf("no checks recorded")
+		}
+		return checks, nil
+	}
+	timeNow = func() time.Time { return now }
+	sleep = func(d time.Duration) { now = now.Add(d) }
+	return func() { loadAgentChecks, timeNow, sleep = originalLoad, originalNow, originalSleep }
+}
+
+func passedChecks(version string, checks ...string) *updateutil.AgentChecks {
+	passed := map[string]time.Time{}
+	for _, check := range checks {
+		passed[check] = sundayNight
+	}
+	return &updateutil.AgentChecks{Version: version, Passed: passed}
+}
+
+func TestSetRequiredChecksFromSourceAgent(t *testing.T) {
+	defer stubAgentChecks(passedChecks("5.0.0.0", updateutil.CheckRegistered, updateutil.CheckMgsConnected))()
+	context := createUpdateContext(Staged)
+
+	setRequiredChecks(logger, context)
+
+	assert.Equal(t, []string{updateutil.CheckSelfTest, updateutil.CheckMgsConnected, updateutil.CheckRegistered}, context.Current.RequiredChecks)
+}
+
+func TestSetRequiredChecksDefault(t *testing.T) {
+	// checks recorded by another version than the source are ignored
+	defer stubAgentChecks(passedChecks("4.0.0.0", updateutil.CheckMgsConnected))()
+	context := createUpdateContext(Staged)
+
+	setRequiredChecks(logger, context)
+
+	assert.Equal(t, []string{updateutil.CheckSelfTest, updateutil.CheckRegistered, updateutil.CheckMdsConnected}, context.Current.RequiredChecks)
+}
+
+func TestWaitForAgentChecks(t *testing.T) {
+	defer stubMaintenanceWindows()()
+	defer stubAgentChecks(passedChecks("6.0.0.0", updateutil.CheckSelfTest, updateutil.CheckRegistered))()
+	context := createUpdateContext(Installed)
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest, updateutil.CheckRegistered}
+
+	missing, err := waitForAgentChecks(logger, context)
+
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestWaitForAgentChecksTimeout(t *testing.T) {
+	defer stubMaintenanceWindows()()
+	defer stubAgentChecks(passedChecks("6.0.0.0", updateutil.CheckRegistered))()
+	context := createUpdateContext(Installed)
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest, updateutil.CheckRegistered}
+
+	missing, err := waitForAgentChecks(logger, context)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{updateutil.CheckSelfTest}, missing)
+	assert.False(t, timeNow().Before(sundayNight.Add(5*time.Minute)))
+}
+
+func TestWaitForAgentChecksOfSourceAgent(t *testing.T) {
+	// checks of the source agent do not count for the target agent
+	defer stubMaintenanceWindows()()
+	defer stubAgentChecks(passedChecks("5.0.0.0", updateutil.CheckSelfTest))()
+	context := createUpdateContext(Installed)
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest}
+
+	missing, err := waitForAgentChecks(logger, context)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{updateutil.CheckSelfTest}, missing)
+}
+
+func TestWaitForAgentChecksSkippedOnDowngrade(t *testing.T) {
+	defer stubAgentChecks(nil)()
+	context := createUpdateContext(Installed)
+	context.Current.SourceVersion = "7.0.0.0"
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest}
+
+	missing, err := waitForAgentChecks(logger, context)
+
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestVerifyInstallationRollbackOnMissingChecks(t *testing.T) {
+	defer stubMaintenanceWindows()()
+	defer stubAgentChecks(passedChecks("6.0.0.0", updateutil.CheckRegistered))()
+	control := &stubControl{serviceIsRunning: true}
+	updater := createUpdaterStubs(control)
+	context := createUpdateContext(Installed)
+	context.Current.RequiredChecks = []string{updateutil.CheckSelfTest, updateutil.CheckRegistered}
+	isRollbackCalled := false
+
+	updater.mgr.rollback = func(mgr *updateManager, log log.T, context *UpdateContext) (err error) {
+		isRollbackCalled = true
+		return nil
+	}
+
+	err := verifyInstallation(updater.mgr, logger, context, false)
+
+	assert.NoError(t, err)
+	assert.True(t, isRollbackCalled)
+	assert.Equal(t, Rollback, context.Current.State)
+	assert.Contains(t, context.Current.StandardError, "did not pass checks SelfTest")
+}
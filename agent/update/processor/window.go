@@ -0,0 +1,139 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/scheduleexpression"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil"
+)
+
+var (
+	timeNow           = time.Now
+	sleep             = time.Sleep
+	loadUpdateContext = LoadUpdateContext
+)
+
+// maintenanceWindow is a recurring window agent updates are applied in
+type maintenanceWindow struct {
+	schedule scheduleexpression.ScheduleExpression
+	duration time.Duration
+}
+
+// loadMaintenanceWindows parses the maintenance windows of the agent configuration
+func loadMaintenanceWindows(log log.T) (windows []maintenanceWindow, err error) {
+	var config appconfig.SsmagentConfig
+	if config, err = getAppConfig(false); err != nil {
+		return nil, fmt.Errorf("could not load config file %v", err.Error())
+	}
+
+	for _, cfg := range config.Update.MaintenanceWindows {
+		if cfg.DurationMinutes <= 0 {
+			return nil, fmt.Errorf("maintenance window %v must last at least one minute", cfg.Schedule)
+		}
+		schedule, err := scheduleexpression.CreateScheduleExpression(log, cfg.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %v, %v", cfg.Schedule, err)
+		}
+		windows = append(windows, maintenanceWindow{
+			schedule: schedule,
+			duration: time.Duration(cfg.DurationMinutes) * time.Minute,
+		})
+	}
+	return windows, nil
+}
+
+// nextWindowStart returns now when now is within one of the windows or there are none,
+// otherwise the earliest start of the windows, zero if they never start again
+func nextWindowStart(windows []maintenanceWindow, now time.Time) time.Time {
+	if len(windows) == 0 {
+		return now
+	}
+
+	var next time.Time
+	for _, window := range windows {
+		// a window started within its duration before now is open
+		if start := window.schedule.Next(now.Add(-window.duration)); !start.IsZero() && !start.After(now) {
+			return now
+		}
+		if start := window.schedule.Next(now); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return next
+}
+
+// deferUntilMaintenanceWindow waits for the next maintenance window when the update is requested outside of the windows.
+// The update is persisted as pending and reported in progress meanwhile. It returns false if the update must not
+// proceed, because it has been failed or superseded by another update while pending.
+func deferUntilMaintenanceWindow(mgr *updateManager, log log.T, context *UpdateContext) (proceed bool, err error) {
+	var windows []maintenanceWindow
+	if windows, err = loadMaintenanceWindows(log); err != nil {
+		return false, mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, err.Error(), true)
+	}
+
+	now := timeNow()
+	start := nextWindowStart(windows, now)
+	if start.Equal(now) {
+		return true, nil
+	}
+	if start.IsZero() {
+		return false, mgr.failed(context, log, updateutil.ErrorEnvironmentIssue, "maintenance windows never start again", true)
+	}
+
+	if context.Current.State != Pending {
+		context.Current.AppendInfo(
+			log,
+			"Update of %v to %v deferred to the maintenance window starting at %v",
+			context.Current.PackageName,
+			context.Current.TargetVersion,
+			start.Format(time.RFC3339))
+	}
+	context.Current.WindowStartDateTime = start.UTC()
+	if err = mgr.inProgress(context, log, Pending); err != nil {
+		return false, err
+	}
+
+	sleep(start.Sub(now))
+
+	// another update may have replaced the pending one in the meantime
+	contextLocation := updateutil.UpdateContextFilePath(context.Current.UpdateRoot)
+	var saved *UpdateContext
+	if saved, err = loadUpdateContext(log, contextLocation); err != nil {
+		return false, err
+	}
+	if saved.Current == nil ||
+		saved.Current.State != Pending ||
+		saved.Current.MessageID != context.Current.MessageID ||
+		!saved.Current.StartDateTime.Equal(context.Current.StartDateTime) {
+		reportSuperseded(mgr, log, context.Current)
+		return false, nil
+	}
+
+	// check the window again, the configuration may have changed while pending
+	return deferUntilMaintenanceWindow(mgr, log, context)
+}
+
+// reportSuperseded reports a pending update replaced by another update as failed, the context now belongs to the other update
+func reportSuperseded(mgr *updateManager, log log.T, update *UpdateDetail) {
+	log.Infof("Pending update of %v to %v has been superseded", update.PackageName, update.TargetVersion)
+	mgr.rejected(log, update, fmt.Sprintf(
+		"Pending update of %v to %v has been superseded by another update",
+		update.PackageName,
+		update.TargetVersion))
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// sundayNight is the start of the maintenance window of the tests
+var sundayNight = time.Date(2018, time.June, 3, 2, 0, 0, 0, time.Local)
+
+func stubMaintenanceWindows(windows ...appconfig.MaintenanceWindowCfg) func() {
+	original := getAppConfig
+	getAppConfig = func(bool) (appconfig.SsmagentConfig, error) {
+		config := appconfig.DefaultConfig()
+		config.Update.MaintenanceWindows = windows
+		return config, nil
+	}
+	return func() { getAppConfig = original }
+}
+
+func TestLoadMaintenanceWindows(t *testing.T) {
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)", DurationMinutes: 180})()
+
+	windows, err := loadMaintenanceWindows(logger)
+
+	assert.NoError(t, err)
+	assert.Len(t, windows, 1)
+	assert.Equal(t, 3*time.Hour, windows[0].duration)
+}
+
+func TestLoadMaintenanceWindowsInvalid(t *testing.T) {
+	restore := stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "every sunday", DurationMinutes: 180})
+	_, err := loadMaintenanceWindows(logger)
+	assert.Error(t, err)
+	restore()
+
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)"})()
+	_, err = loadMaintenanceWindows(logger)
+	assert.Error(t, err)
+}
+
+func TestNextWindowStart(t *testing.T) {
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)", DurationMinutes: 180})()
+	windows, _ := loadMaintenanceWindows(logger)
+
+	// no windows
+	now := sundayNight.Add(-time.Hour)
+	assert.Equal(t, now, nextWindowStart(nil, now))
+
+	// before the window
+	assert.Equal(t, sundayNight, nextWindowStart(windows, now))
+
+	// within the window
+	now = sundayNight.Add(time.Hour)
+	assert.Equal(t, now, nextWindowStart(windows, now))
+
+	// after the window
+	now = sundayNight.Add(4 * time.Hour)
+	assert.Equal(t, sundayNight.AddDate(0, 0, 7), nextWindowStart(windows, now))
+}
+
+func TestDeferUntilMaintenanceWindowWithinWindow(t *testing.T) {
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)", DurationMinutes: 180})()
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	timeNow = func() time.Time { return sundayNight.Add(time.Minute) }
+	defer func() { timeNow = time.Now }()
+
+	proceed, err := deferUntilMaintenanceWindow(updater.mgr, logger, context)
+
+	assert.NoError(t, err)
+	assert.True(t, proceed)
+	assert.Equal(t, Initialized, context.Current.State)
+}
+
+func TestDeferUntilMaintenanceWindowOutsideWindow(t *testing.T) {
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)", DurationMinutes: 180})()
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	now := sundayNight.Add(-time.Hour)
+	var saved UpdateDetail
+	slept := time.Duration(0)
+	timeNow = func() time.Time { return now }
+	sleep = func(d time.Duration) {
+		saved = *context.Current
+		slept += d
+		now = now.Add(d)
+	}
+	loadUpdateContext = func(log log.T, source string) (*UpdateContext, error) {
+		return &UpdateContext{Current: &saved}, nil
+	}
+	defer func() { timeNow, sleep, loadUpdateContext = time.Now, time.Sleep, LoadUpdateContext }()
+
+	proceed, err := deferUntilMaintenanceWindow(updater.mgr, logger, context)
+
+	assert.NoError(t, err)
+	assert.True(t, proceed)
+	assert.Equal(t, time.Hour, slept)
+	assert.Equal(t, Pending, saved.State)
+	assert.Equal(t, sundayNight.UTC(), context.Current.WindowStartDateTime)
+	assert.Contains(t, context.Current.StandardOut, "deferred to the maintenance window")
+}
+
+func TestDeferUntilMaintenanceWindowSuperseded(t *testing.T) {
+	defer stubMaintenanceWindows(appconfig.MaintenanceWindowCfg{Schedule: "cron(0 2 ? * SUN *)", DurationMinutes: 180})()
+	updater := createDefaultUpdaterStub()
+	context := createUpdateContext(Initialized)
+	timeNow = func() time.Time { return sundayNight.Add(-time.Hour) }
+	sleep = func(d time.Duration) {}
+	loadUpdateContext = func(log log.T, source string) (*UpdateContext, error) {
+		other := createUpdateContext(Pending)
+		other.Current.MessageID = "other message id"
+		return other, nil
+	}
+	defer func() { timeNow, sleep, loadUpdateContext = time.Now, time.Sleep, LoadUpdateContext }()
+
+	proceed, err := deferUntilMaintenanceWindow(updater.mgr, logger, context)
+
+	assert.NoError(t, err)
+	assert.False(t, proceed)
+	assert.Equal(t, Completed, context.Current.State)
+	assert.Contains(t, context.Current.StandardOut, "superseded")
+}
+
+func TestStartOrResumeUpdateFromPendingState(t *testing.T) {
+	updater := createDefaultUpdaterStub()
+	isMethodExecuted := false
+	context := createUpdateContext(Pending)
+	updater.mgr.prepare = func(mgr *updateManager, log log.T, context *UpdateContext) error {
+		isMethodExecuted = true
+		return nil
+	}
+
+	updater.StartOrResumeUpdate(logger, context)
+
+	assert.True(t, isMethodExecuted)
+}
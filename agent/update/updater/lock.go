@@ -0,0 +1,80 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package main represents the entry point of the ssm agent updater.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filelock"
+)
+
+const (
+	// updaterLockFileName is the name of the lock file only one updater at a time holds in the update root
+	updaterLockFileName = "updater.lock"
+
+	// updaterLockTimeoutSeconds expires locks left by updaters whose process id has been reused,
+	// an updater holds the lock while it waits for the next maintenance window, which is at most a week away
+	updaterLockTimeoutSeconds = 8 * 24 * 60 * 60
+)
+
+var (
+	lockFile         = filelock.LockFile
+	unlockFile       = filelock.UnlockFile
+	isProcessRunning = processRunning
+	lockOwner        = filelock.GetOwnerIdForProcess()
+)
+
+// acquireUpdaterLock locks the update root for this updater, it returns false if another updater holds the lock
+func acquireUpdaterLock(updateRoot string) (locked bool, err error) {
+	if err = os.MkdirAll(updateRoot, appconfig.ReadWriteExecuteAccess); err != nil {
+		return false, fmt.Errorf("failed to create update root %v, %v", updateRoot, err)
+	}
+
+	lockPath := filepath.Join(updateRoot, updaterLockFileName)
+	removeStaleLock(lockPath)
+	return lockFile(lockPath, lockOwner, updaterLockTimeoutSeconds)
+}
+
+// releaseUpdaterLock unlocks the update root if this updater holds the lock
+func releaseUpdaterLock(updateRoot string) {
+	if _, err := unlockFile(filepath.Join(updateRoot, updaterLockFileName), lockOwner); err != nil {
+		log.Errorf("Failed to release updater lock, %v", err)
+	}
+}
+
+// removeStaleLock removes the lock of an updater which is no longer running, as when it crashed or the instance rebooted
+func removeStaleLock(lockPath string) {
+	content, err := fileutil.ReadAllText(lockPath)
+	if err != nil {
+		return
+	}
+
+	owner := strings.TrimSpace(content)
+	var pid, gid int
+	if _, err = fmt.Sscanf(owner, "pid-%d-gid-%d", &pid, &gid); err != nil || isProcessRunning(pid) {
+		return
+	}
+
+	log.Infof("Removing stale updater lock of process %v", pid)
+	// the lock may have been released and acquired by another updater meanwhile
+	if content, err = fileutil.ReadAllText(lockPath); err == nil && strings.TrimSpace(content) == owner {
+		os.Remove(lockPath)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package main represents the entry point of the ssm agent updater.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func createLockRoot(t *testing.T) string {
+	root, err := ioutil.TempDir("", "updaterlock")
+	assert.NoError(t, err)
+	return root
+}
+
+func TestAcquireUpdaterLock(t *testing.T) {
+	log = logger.NewMockLog()
+	root := createLockRoot(t)
+	defer os.RemoveAll(root)
+
+	locked, err := acquireUpdaterLock(root)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	// a second updater of a running process does not get the lock
+	originalOwner := lockOwner
+	lockOwner = "pid-1-gid-0"
+	locked, err = acquireUpdaterLock(root)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+	lockOwner = originalOwner
+
+	releaseUpdaterLock(root)
+	_, err = os.Stat(filepath.Join(root, updaterLockFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAcquireUpdaterLockStale(t *testing.T) {
+	log = logger.NewMockLog()
+	root := createLockRoot(t)
+	defer os.RemoveAll(root)
+	originalIsProcessRunning := isProcessRunning
+	defer func() { isProcessRunning = originalIsProcessRunning }()
+
+	// lock left by an updater that is no longer running
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, updaterLockFileName), []byte("pid-123456-gid-0"), 0600))
+	isProcessRunning = func(pid int) bool { return pid != 123456 }
+
+	locked, err := acquireUpdaterLock(root)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	releaseUpdaterLock(root)
+}
+
+func TestAcquireUpdaterLockHeldByRunningUpdater(t *testing.T) {
+	log = logger.NewMockLog()
+	root := createLockRoot(t)
+	defer os.RemoveAll(root)
+	originalIsProcessRunning := isProcessRunning
+	defer func() { isProcessRunning = originalIsProcessRunning }()
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, updaterLockFileName), []byte("pid-123456-gid-0"), 0600))
+	isProcessRunning = func(pid int) bool { return true }
+
+	locked, err := acquireUpdaterLock(root)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestProcessRunning(t *testing.T) {
+	assert.True(t, processRunning(os.Getpid()))
+}
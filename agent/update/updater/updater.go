@@ -39,19 +39,24 @@ var (
 )
 
 var (
-	update          *bool
-	sourceVersion   *string
-	sourceLocation  *string
-	sourceHash      *string
-	targetVersion   *string
-	targetLocation  *string
-	targetHash      *string
-	packageName     *string
-	messageID       *string
-	stdout          *string
-	stderr          *string
-	outputKeyPrefix *string
-	outputBucket    *string
+	update              *bool
+	sourceVersion       *string
+	sourceLocation      *string
+	sourceHash          *string
+	targetVersion       *string
+	targetLocation      *string
+	targetHash          *string
+	targetDeltaLocation *string
+	targetDeltaHash     *string
+	packageName         *string
+	messageID           *string
+	stdout              *string
+	stderr              *string
+	outputKeyPrefix     *string
+	outputBucket        *string
+	allowDowngrade      *bool
+	outputLogGroup      *string
+	outputLogStream     *string
 )
 
 func init() {
@@ -70,12 +75,17 @@ func init() {
 	targetVersion = flag.String(updateutil.TargetVersionCmd, "", "target Agent Version")
 	targetLocation = flag.String(updateutil.TargetLocationCmd, "", "target Agent installer source")
 	targetHash = flag.String(updateutil.TargetHashCmd, "", "target Agent installer hash")
+	targetDeltaLocation = flag.String(updateutil.TargetDeltaLocationCmd, "", "delta from current to target Agent installer source")
+	targetDeltaHash = flag.String(updateutil.TargetDeltaHashCmd, "", "delta from current to target Agent installer hash")
 	packageName = flag.String(updateutil.PackageNameCmd, "", "target Agent Version")
 	messageID = flag.String(updateutil.MessageIDCmd, "", "target Agent Version")
 	stdout = flag.String(updateutil.StdoutFileName, "", "standard output file path")
 	stderr = flag.String(updateutil.StderrFileName, "", "standard error file path")
 	outputKeyPrefix = flag.String(updateutil.OutputKeyPrefixCmd, "", "output key prefix")
 	outputBucket = flag.String(updateutil.OutputBucketNameCmd, "", "output bucket name")
+	allowDowngrade = flag.Bool(updateutil.AllowDowngradeCmd, false, "allow downgrades below the minimum versions")
+	outputLogGroup = flag.String(updateutil.OutputLogGroupCmd, "", "output log group name")
+	outputLogStream = flag.String(updateutil.OutputLogStreamCmd, "", "update progress log stream name")
 }
 
 // Config holds Runtime info of plugins.
@@ -110,22 +120,27 @@ func main() {
 
 	// Create new UpdateDetail
 	detail := &processor.UpdateDetail{
-		State:              processor.NotStarted,
-		Result:             contracts.ResultStatusInProgress,
-		SourceVersion:      *sourceVersion,
-		SourceLocation:     *sourceLocation,
-		SourceHash:         *sourceHash,
-		TargetVersion:      *targetVersion,
-		TargetLocation:     *targetLocation,
-		TargetHash:         *targetHash,
-		StdoutFileName:     *stdout,
-		StderrFileName:     *stderr,
-		OutputS3KeyPrefix:  *outputKeyPrefix,
-		OutputS3BucketName: *outputBucket,
-		PackageName:        *packageName,
-		MessageID:          *messageID,
-		StartDateTime:      time.Now().UTC(),
-		RequiresUninstall:  false,
+		State:               processor.NotStarted,
+		Result:              contracts.ResultStatusInProgress,
+		SourceVersion:       *sourceVersion,
+		SourceLocation:      *sourceLocation,
+		SourceHash:          *sourceHash,
+		TargetVersion:       *targetVersion,
+		TargetLocation:      *targetLocation,
+		TargetHash:          *targetHash,
+		TargetDeltaLocation: *targetDeltaLocation,
+		TargetDeltaHash:     *targetDeltaHash,
+		StdoutFileName:      *stdout,
+		StderrFileName:      *stderr,
+		OutputS3KeyPrefix:   *outputKeyPrefix,
+		OutputS3BucketName:  *outputBucket,
+		AllowDowngrade:      *allowDowngrade,
+		OutputLogGroupName:  *outputLogGroup,
+		OutputLogStreamName: *outputLogStream,
+		PackageName:         *packageName,
+		MessageID:           *messageID,
+		StartDateTime:       time.Now().UTC(),
+		RequiresUninstall:   false,
 	}
 
 	if err := resolveUpdateDetail(detail); err != nil {
@@ -135,6 +150,20 @@ func main() {
 
 	log.Infof("Update root is: %v", detail.UpdateRoot)
 
+	// Only one updater at a time, concurrent updates would corrupt the installation
+	locked, err := acquireUpdaterLock(detail.UpdateRoot)
+	if err != nil || !locked {
+		message := updateutil.BuildMessage(err,
+			"failed to update %v to %v, %v",
+			detail.PackageName,
+			detail.TargetVersion,
+			"another update is in progress, please retry later")
+		log.Error(message)
+		updater.Rejected(log, detail, message)
+		return
+	}
+	defer releaseUpdaterLock(detail.UpdateRoot)
+
 	// Load UpdateContext from local storage, set current update with the new UpdateDetail
 	context, err := updater.InitializeUpdate(log, detail)
 	if err != nil {
@@ -38,9 +38,12 @@ func regionFailedStub() (string, error) {
 
 type stubUpdater struct {
 	returnUpdateError bool
+	updateStarted     bool
+	rejected          bool
 }
 
 func (u *stubUpdater) StartOrResumeUpdate(log logger.T, context *processor.UpdateContext) (err error) {
+	u.updateStarted = true
 	if u.returnUpdateError {
 		return fmt.Errorf("Fail update")
 	}
@@ -64,8 +67,25 @@ func (u *stubUpdater) Failed(
 	return nil
 }
 
+func (u *stubUpdater) Rejected(log logger.T, detail *processor.UpdateDetail, errMessage string) {
+	u.rejected = true
+}
+
+// stubUpdaterLock stubs the updater lock, held by another updater when locked is false
+func stubUpdaterLock(locked bool) func() {
+	originalLock, originalUnlock := lockFile, unlockFile
+	lockFile = func(lockPath string, ownerId string, timeoutSeconds int) (bool, error) {
+		return locked, nil
+	}
+	unlockFile = func(lockPath string, ownerId string) (bool, error) {
+		return locked, nil
+	}
+	return func() { lockFile, unlockFile = originalLock, originalUnlock }
+}
+
 func TestUpdater(t *testing.T) {
 	// setup
+	defer stubUpdaterLock(true)()
 	log = logger.NewMockLog()
 	region = regionStub
 	updater = &stubUpdater{}
@@ -76,8 +96,27 @@ func TestUpdater(t *testing.T) {
 	main()
 }
 
+func TestUpdaterLockedByAnotherUpdater(t *testing.T) {
+	// setup
+	defer stubUpdaterLock(false)()
+	log = logger.NewMockLog()
+	region = regionStub
+	stub := &stubUpdater{}
+	updater = stub
+
+	os.Args = updateCommand
+
+	// action
+	main()
+
+	// assert
+	assert.True(t, stub.rejected)
+	assert.False(t, stub.updateStarted)
+}
+
 func TestUpdaterFailedStartOrResume(t *testing.T) {
 	// setup
+	defer stubUpdaterLock(true)()
 	log = logger.NewMockLog()
 	region = regionStub
 	updater = &stubUpdater{returnUpdateError: true}
@@ -90,6 +129,7 @@ func TestUpdaterFailedStartOrResume(t *testing.T) {
 
 func TestUpdaterFailedSetRegion(t *testing.T) {
 	// setup
+	defer stubUpdaterLock(true)()
 	log = logger.NewMockLog()
 	region = regionFailedStub
 	updater = &stubUpdater{returnUpdateError: true}
@@ -102,6 +142,7 @@ func TestUpdaterFailedSetRegion(t *testing.T) {
 
 func TestUpdaterWithDowngrade(t *testing.T) {
 	// setup
+	defer stubUpdaterLock(true)()
 	log = logger.NewMockLog()
 	region = regionStub
 	updater = &stubUpdater{returnUpdateError: true}
@@ -119,6 +160,7 @@ func TestUpdaterWithDowngrade(t *testing.T) {
 
 func TestUpdaterFailedWithoutSourceTargetCmd(t *testing.T) {
 	// setup
+	defer stubUpdaterLock(true)()
 	log = logger.NewMockLog()
 	region = regionStub
 	updater = &stubUpdater{returnUpdateError: true}
@@ -17,15 +17,14 @@
 package main
 
 import (
+	"syscall"
+
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/update/processor"
 	"github.com/aws/amazon-ssm-agent/agent/updateutil"
 )
 
-const (
-	legacyUpdaterArtifactsRoot   = "/var/log/amazon/ssm/update/"
-	firstAgentWithNewUpdaterPath = "1.1.86.0"
-)
+const firstAgentWithNewUpdaterPath = "1.1.86.0"
 
 // updateRoot returns the platform specific path to update artifacts
 func updateRoot(detail *processor.UpdateDetail) error {
@@ -37,7 +36,14 @@ func updateRoot(detail *processor.UpdateDetail) error {
 	if compareResult >= 0 {
 		detail.UpdateRoot = appconfig.UpdaterArtifactsRoot
 	} else {
-		detail.UpdateRoot = legacyUpdaterArtifactsRoot
+		detail.UpdateRoot = updateutil.LegacyUpdaterArtifactsRoot
 	}
 	return nil
 }
+
+// processRunning returns true if a process with the given id is running
+func processRunning(pid int) bool {
+	// signal 0 only checks whether the process exists, EPERM means it exists under another user
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -17,12 +17,32 @@
 package main
 
 import (
+	"syscall"
+
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/update/processor"
 )
 
+// stillActive is the exit code of processes that have not exited
+const stillActive = 259
+
 // updateRoot returns the platform specific path to update artifacts
 func updateRoot(detail *processor.UpdateDetail) error {
 	detail.UpdateRoot = appconfig.UpdaterArtifactsRoot
 	return nil
 }
+
+// processRunning returns true if a process with the given id is running
+func processRunning(pid int) bool {
+	process, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(process)
+
+	var exitCode uint32
+	if err = syscall.GetExitCodeProcess(process, &exitCode); err != nil {
+		return true
+	}
+	return exitCode == stillActive
+}
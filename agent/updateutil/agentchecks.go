@@ -0,0 +1,145 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package updateutil
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const (
+	// CheckRegistered is passed once the agent reports its health to SSM
+	CheckRegistered = "Registered"
+
+	// CheckMdsConnected is passed once the agent polls MDS for messages
+	CheckMdsConnected = "MdsConnected"
+
+	// CheckMgsConnected is passed once the agent opens its control channel to MGS
+	CheckMgsConnected = "MgsConnected"
+
+	// CheckSelfTest is passed once the agent processes the self-test document it runs after an update
+	CheckSelfTest = "SelfTest"
+
+	// agentChecksFileName is the name of the file the agent records the checks it passed in
+	agentChecksFileName = "agentchecks.json"
+)
+
+// AgentChecks holds the checks the running agent passed since it started,
+// the updater waits for them after an update before rolling back
+type AgentChecks struct {
+	Version string               `json:"Version"`
+	Passed  map[string]time.Time `json:"Passed"`
+}
+
+var agentChecksRoot = appconfig.UpdaterArtifactsRoot
+
+// recordedChecks are the checks the running agent recorded, to only record them once
+var recordedChecks = make(map[string]bool)
+var agentChecksLock sync.Mutex
+
+// AgentChecksFilePath returns the path of the file the agent records the checks it passed in
+func AgentChecksFilePath() string {
+	return filepath.Join(agentChecksRoot, agentChecksFileName)
+}
+
+// ResetAgentChecks clears the checks recorded by the previous agent when the agent starts,
+// it returns the version of the previous agent, empty if it recorded none.
+func ResetAgentChecks(log log.T) (previousVersion string) {
+	agentChecksLock.Lock()
+	defer agentChecksLock.Unlock()
+
+	if previous, err := LoadAgentChecks(); err == nil {
+		previousVersion = previous.Version
+	}
+	recordedChecks = make(map[string]bool)
+	saveAgentChecks(log, &AgentChecks{Version: version.Version, Passed: map[string]time.Time{}})
+	return previousVersion
+}
+
+// RecordAgentCheck records that the running agent passed a check, only the first time it passes.
+func RecordAgentCheck(log log.T, check string) {
+	agentChecksLock.Lock()
+	defer agentChecksLock.Unlock()
+	if recordedChecks[check] {
+		return
+	}
+	recordedChecks[check] = true
+
+	checks, err := LoadAgentChecks()
+	if err != nil || checks.Version != version.Version {
+		checks = &AgentChecks{Version: version.Version, Passed: map[string]time.Time{}}
+	}
+	if _, found := checks.Passed[check]; found {
+		return
+	}
+	checks.Passed[check] = time.Now().UTC()
+	saveAgentChecks(log, checks)
+}
+
+// LoadAgentChecks loads the checks recorded by the agent
+func LoadAgentChecks() (checks *AgentChecks, err error) {
+	var content []byte
+	if content, err = ioutil.ReadFile(AgentChecksFilePath()); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &checks); err != nil {
+		return nil, err
+	}
+	if checks.Passed == nil {
+		checks.Passed = map[string]time.Time{}
+	}
+	return checks, nil
+}
+
+// Missing returns the checks among required that have not been passed, sorted
+func (checks *AgentChecks) Missing(required []string) (missing []string) {
+	for _, check := range required {
+		if _, found := checks.Passed[check]; !found {
+			missing = append(missing, check)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// PassedChecks returns the checks that have been passed, sorted
+func (checks *AgentChecks) PassedChecks() (passed []string) {
+	for check := range checks.Passed {
+		passed = append(passed, check)
+	}
+	sort.Strings(passed)
+	return passed
+}
+
+// saveAgentChecks writes the checks of the agent, failures are only logged as the checks are informational to the agent
+func saveAgentChecks(log log.T, checks *AgentChecks) {
+	content, err := json.Marshal(checks)
+	if err == nil {
+		if err = os.MkdirAll(agentChecksRoot, appconfig.ReadWriteExecuteAccess); err == nil {
+			err = ioutil.WriteFile(AgentChecksFilePath(), content, appconfig.ReadWriteAccess)
+		}
+	}
+	if err != nil {
+		log.Debugf("Failed to record agent checks: %v", err)
+	}
+}
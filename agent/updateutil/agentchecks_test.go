@@ -0,0 +1,67 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package updateutil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubAgentChecksRoot(t *testing.T) func() {
+	original := agentChecksRoot
+	root, err := ioutil.TempDir("", "agentchecks")
+	assert.NoError(t, err)
+	agentChecksRoot = root
+	return func() {
+		agentChecksRoot = original
+		os.RemoveAll(root)
+	}
+}
+
+func TestRecordAgentChecks(t *testing.T) {
+	defer stubAgentChecksRoot(t)()
+
+	assert.Equal(t, "", ResetAgentChecks(logger))
+	RecordAgentCheck(logger, CheckRegistered)
+	RecordAgentCheck(logger, CheckMdsConnected)
+	RecordAgentCheck(logger, CheckRegistered)
+
+	checks, err := LoadAgentChecks()
+	assert.NoError(t, err)
+	assert.Equal(t, version.Version, checks.Version)
+	assert.Equal(t, []string{CheckMdsConnected, CheckRegistered}, checks.PassedChecks())
+	assert.Equal(t, []string{CheckMgsConnected, CheckSelfTest}, checks.Missing([]string{CheckSelfTest, CheckRegistered, CheckMgsConnected}))
+}
+
+func TestResetAgentChecks(t *testing.T) {
+	defer stubAgentChecksRoot(t)()
+	ResetAgentChecks(logger)
+	RecordAgentCheck(logger, CheckRegistered)
+
+	assert.Equal(t, version.Version, ResetAgentChecks(logger))
+
+	checks, err := LoadAgentChecks()
+	assert.NoError(t, err)
+	assert.Empty(t, checks.PassedChecks())
+
+	// checks are recorded again after the reset
+	RecordAgentCheck(logger, CheckRegistered)
+	checks, err = LoadAgentChecks()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CheckRegistered}, checks.PassedChecks())
+}
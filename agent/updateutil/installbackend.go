@@ -0,0 +1,164 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package updateutil
+
+import (
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// InstallBackendPackage represents agents installed with the package manager of the platform
+	InstallBackendPackage = "package"
+
+	// InstallBackendSnap represents agents installed as a snap
+	InstallBackendSnap = "snap"
+
+	// InstallBackendContainer represents agents running in a container without a service manager
+	InstallBackendContainer = "container"
+)
+
+// InstallBackend represents the way the agent is installed on the instance, it decides the installation
+// package and scripts the updater uses and how it finds whether the agent is running
+type InstallBackend interface {
+	// Name returns the name of the installation backend
+	Name() string
+	// InstallerName returns the installer name of the packages to download, given the one of the platform
+	InstallerName(platformInstallerName string) string
+	// Installer returns the name of the script installing the agent
+	Installer() string
+	// UnInstaller returns the name of the script uninstalling the agent
+	UnInstaller() string
+	// ServiceManager returns the command of the service manager restarting the agent, empty if there is none
+	ServiceManager(log log.T, i *InstanceContext) (string, error)
+	// IsAgentRunning returns whether the agent is running
+	IsAgentRunning(log log.T, i *InstanceContext) (bool, error)
+}
+
+// installBackend is the installation backend detected when creating the instance context
+var installBackend InstallBackend = &packageBackend{installer: InstallScript, uninstaller: UninstallScript}
+
+// GetInstallBackend returns the installation backend detected when creating the instance context
+func GetInstallBackend() InstallBackend {
+	return installBackend
+}
+
+// packageBackend installs the agent with the package manager of the platform and runs it as a service
+type packageBackend struct {
+	installer   string
+	uninstaller string
+}
+
+// Name returns the name of the installation backend
+func (b *packageBackend) Name() string {
+	return InstallBackendPackage
+}
+
+// InstallerName returns the installer name of the platform
+func (b *packageBackend) InstallerName(platformInstallerName string) string {
+	return platformInstallerName
+}
+
+// Installer returns the name of the script installing the agent
+func (b *packageBackend) Installer() string {
+	return b.installer
+}
+
+// UnInstaller returns the name of the script uninstalling the agent
+func (b *packageBackend) UnInstaller() string {
+	return b.uninstaller
+}
+
+// ServiceManager returns the command of the service manager the agent runs under
+func (b *packageBackend) ServiceManager(log log.T, i *InstanceContext) (string, error) {
+	switch i.Platform {
+	case PlatformWindows, PlatformWindowsNano:
+		return "sc", nil
+	}
+
+	isSystemD, err := i.IsPlatformUsingSystemD(log)
+	if err != nil {
+		return "", err
+	}
+	if isSystemD {
+		return "systemctl", nil
+	}
+	// upstart
+	return "status", nil
+}
+
+// IsAgentRunning returns whether the agent service is running
+func (b *packageBackend) IsAgentRunning(log log.T, i *InstanceContext) (result bool, err error) {
+	commandOutput := []byte{}
+	expectedOutput := ""
+	isSystemD := false
+
+	// isSystemD will always be false for Windows
+	if isSystemD, err = i.IsPlatformUsingSystemD(log); err != nil {
+		return false, err
+	}
+
+	if isSystemD {
+		expectedOutput = "Active: active (running)"
+		if commandOutput, err = execCommand("systemctl", "status", "amazon-ssm-agent.service").Output(); err != nil {
+			return false, err
+		}
+	} else {
+		expectedOutput = agentExpectedStatus()
+		if commandOutput, err = agentStatusOutput(); err != nil {
+			return false, err
+		}
+	}
+
+	agentStatus := strings.TrimSpace(string(commandOutput))
+	return strings.Contains(agentStatus, expectedOutput), nil
+}
+
+// snapBackend installs the agent as a classic snap, the snap runs it as a systemd service
+type snapBackend struct{}
+
+// Name returns the name of the installation backend
+func (b *snapBackend) Name() string {
+	return InstallBackendSnap
+}
+
+// InstallerName returns the installer name of the snap packages
+func (b *snapBackend) InstallerName(platformInstallerName string) string {
+	return PlatformUbuntuSnap
+}
+
+// Installer returns the name of the script installing the agent
+func (b *snapBackend) Installer() string {
+	return SnapInstaller
+}
+
+// UnInstaller returns the name of the script uninstalling the agent
+func (b *snapBackend) UnInstaller() string {
+	return SnapUnInstaller
+}
+
+// ServiceManager returns the command of the service manager the snap runs the agent under
+func (b *snapBackend) ServiceManager(log log.T, i *InstanceContext) (string, error) {
+	return "systemctl", nil
+}
+
+// IsAgentRunning returns whether the snap service of the agent is running
+func (b *snapBackend) IsAgentRunning(log log.T, i *InstanceContext) (bool, error) {
+	commandOutput, err := execCommand("systemctl", "status", "snap.amazon-ssm-agent.amazon-ssm-agent.service").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(strings.TrimSpace(string(commandOutput)), "Active: active (running)"), nil
+}
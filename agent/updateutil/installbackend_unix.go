@@ -0,0 +1,128 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package updateutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// agentProcessName is the name of the agent process in the process table
+const agentProcessName = "amazon-ssm-agent"
+
+var (
+	procRoot = "/proc"
+
+	// containerEnvFiles are created by the container runtimes in the root of the containers
+	containerEnvFiles = []string{"/.dockerenv", "/run/.containerenv"}
+
+	// containerEnvVariables are set by ECS in the containers of its tasks
+	containerEnvVariables = []string{"ECS_CONTAINER_METADATA_URI", "ECS_CONTAINER_METADATA_URI_V4"}
+
+	// containerCgroupMarkers are found in the cgroups of the processes running in containers
+	containerCgroupMarkers = []string{"docker", "kubepods", "containerd", "/ecs/"}
+)
+
+var isRunningInContainer = runningInContainer
+
+// detectInstallBackend returns the installation backend of the agent, the package backend
+// with the given scripts is used unless the agent runs in a container or is installed as a snap
+func detectInstallBackend(log log.T, platformName string, installer string, uninstaller string) InstallBackend {
+	if isRunningInContainer(log) {
+		log.Debug("Agent is running in a container")
+		return &containerBackend{}
+	}
+	if platformName == PlatformUbuntu {
+		if isSnap, err := isAgentInstalledUsingSnap(log); err == nil && isSnap {
+			return &snapBackend{}
+		}
+	}
+	return &packageBackend{installer: installer, uninstaller: uninstaller}
+}
+
+// runningInContainer returns whether the agent runs in a container
+func runningInContainer(log log.T) bool {
+	for _, variable := range containerEnvVariables {
+		if os.Getenv(variable) != "" {
+			return true
+		}
+	}
+	for _, file := range containerEnvFiles {
+		if _, err := os.Stat(file); err == nil {
+			return true
+		}
+	}
+
+	cgroup, err := ioutil.ReadFile(filepath.Join(procRoot, "1", "cgroup"))
+	if err != nil {
+		log.Debugf("Failed to read the cgroup of the init process, %v", err)
+		return false
+	}
+	for _, marker := range containerCgroupMarkers {
+		if strings.Contains(string(cgroup), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerBackend installs the agent in a container, where no service manager runs the agent.
+// The installer stops the running agent, the container supervisor is expected to start it again.
+type containerBackend struct{}
+
+// Name returns the name of the installation backend
+func (b *containerBackend) Name() string {
+	return InstallBackendContainer
+}
+
+// InstallerName returns the installer name of the platform, containers install the platform packages
+func (b *containerBackend) InstallerName(platformInstallerName string) string {
+	return platformInstallerName
+}
+
+// Installer returns the name of the script installing the agent
+func (b *containerBackend) Installer() string {
+	return ContainerInstaller
+}
+
+// UnInstaller returns the name of the script uninstalling the agent
+func (b *containerBackend) UnInstaller() string {
+	return ContainerUnInstaller
+}
+
+// ServiceManager returns an empty command, no service manager runs in containers
+func (b *containerBackend) ServiceManager(log log.T, i *InstanceContext) (string, error) {
+	return "", nil
+}
+
+// IsAgentRunning returns whether an agent process is found in the process table of the container
+func (b *containerBackend) IsAgentRunning(log log.T, i *InstanceContext) (bool, error) {
+	processes, err := filepath.Glob(filepath.Join(procRoot, "[0-9]*", "comm"))
+	if err != nil {
+		return false, err
+	}
+	for _, process := range processes {
+		// processes may exit while the process table is read
+		if comm, readErr := ioutil.ReadFile(process); readErr == nil && strings.TrimSpace(string(comm)) == agentProcessName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
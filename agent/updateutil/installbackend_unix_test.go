@@ -0,0 +1,117 @@
+// Copyright 2018 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package updateutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProcRoot creates a process table with the given process names and cgroup of the init process
+func stubProcRoot(t *testing.T, initCgroup string, processNames ...string) func() {
+	dir, err := ioutil.TempDir("", "proc")
+	assert.NoError(t, err)
+	for pid, name := range append([]string{"init"}, processNames...) {
+		processDir := filepath.Join(dir, strconv.Itoa(pid+1))
+		assert.NoError(t, os.MkdirAll(processDir, 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(processDir, "comm"), []byte(name+"\n"), 0644))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "1", "cgroup"), []byte(initCgroup), 0644))
+
+	originalProcRoot, originalEnvFiles, originalEnvVariables := procRoot, containerEnvFiles, containerEnvVariables
+	procRoot = dir
+	containerEnvFiles = []string{filepath.Join(dir, ".dockerenv")}
+	containerEnvVariables = []string{}
+	return func() {
+		procRoot, containerEnvFiles, containerEnvVariables = originalProcRoot, originalEnvFiles, originalEnvVariables
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRunningInContainer(t *testing.T) {
+	testCases := []struct {
+		cgroup string
+		result bool
+	}{
+		{"12:pids:/\n11:memory:/init.scope\n", false},
+		{"12:pids:/docker/3f4d5e\n", true},
+		{"12:pids:/kubepods/burstable/pod1/3f4d5e\n", true},
+		{"12:pids:/ecs/task/3f4d5e\n", true},
+	}
+
+	for _, test := range testCases {
+		restore := stubProcRoot(t, test.cgroup)
+		assert.Equal(t, test.result, runningInContainer(logger), test.cgroup)
+		restore()
+	}
+}
+
+func TestRunningInContainerWithEnvFile(t *testing.T) {
+	defer stubProcRoot(t, "")()
+	assert.NoError(t, ioutil.WriteFile(containerEnvFiles[0], []byte{}, 0644))
+
+	assert.True(t, runningInContainer(logger))
+}
+
+func TestDetectInstallBackend(t *testing.T) {
+	originalIsRunningInContainer := isRunningInContainer
+	defer func() { isRunningInContainer = originalIsRunningInContainer }()
+
+	isRunningInContainer = func(log log.T) bool { return true }
+	backend := detectInstallBackend(logger, PlatformUbuntu, DebInstaller, DebUnInstaller)
+	assert.Equal(t, InstallBackendContainer, backend.Name())
+	assert.Equal(t, PlatformUbuntu, backend.InstallerName(PlatformUbuntu))
+	assert.Equal(t, ContainerInstaller, backend.Installer())
+
+	isRunningInContainer = func(log log.T) bool { return false }
+	execCommand = fakeExecCommand
+	backend = detectInstallBackend(logger, PlatformUbuntu, DebInstaller, DebUnInstaller)
+	assert.Equal(t, InstallBackendSnap, backend.Name())
+	assert.Equal(t, PlatformUbuntuSnap, backend.InstallerName(PlatformUbuntu))
+	assert.Equal(t, SnapInstaller, backend.Installer())
+
+	backend = detectInstallBackend(logger, PlatformRedHat, InstallScript, UninstallScript)
+	assert.Equal(t, InstallBackendPackage, backend.Name())
+	assert.Equal(t, PlatformLinux, backend.InstallerName(PlatformLinux))
+	assert.Equal(t, InstallScript, backend.Installer())
+}
+
+func TestContainerBackendIsAgentRunning(t *testing.T) {
+	backend := &containerBackend{}
+	context := &InstanceContext{Platform: PlatformUbuntu}
+
+	restore := stubProcRoot(t, "", "bash", agentProcessName)
+	running, err := backend.IsAgentRunning(logger, context)
+	restore()
+	assert.NoError(t, err)
+	assert.True(t, running)
+
+	restore = stubProcRoot(t, "", "bash", "updater")
+	running, err = backend.IsAgentRunning(logger, context)
+	restore()
+	assert.NoError(t, err)
+	assert.False(t, running)
+
+	manager, err := backend.ServiceManager(logger, context)
+	assert.NoError(t, err)
+	assert.Empty(t, manager)
+}
@@ -26,7 +26,6 @@ import (
 	"time"
 
 	"errors"
-	"strconv"
 
 	"io"
 
@@ -34,6 +33,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
 )
 
 const (
@@ -173,6 +173,15 @@ const (
 
 	// ErrorLoadingAgentVersion represents failed for loading agent version
 	ErrorLoadingAgentVersion ErrorCode = "ErrorLoadingAgentVersion"
+
+	// ErrorInsufficientDiskSpace represents the update root does not have enough disk space for the update
+	ErrorInsufficientDiskSpace ErrorCode = "ErrorInsufficientDiskSpace"
+
+	// ErrorMissingDependency represents a tool required to install the agent is not available
+	ErrorMissingDependency ErrorCode = "ErrorMissingDependency"
+
+	// ErrorServiceManagerUnavailable represents the service manager the agent runs under is not available
+	ErrorServiceManagerUnavailable ErrorCode = "ErrorServiceManagerUnavailable"
 )
 
 // MinimumDiskSpaceForUpdate represents 100 Mb in bytes
@@ -237,6 +246,11 @@ const (
 	SnapInstaller = "snap-install.sh"
 	// uninstaller script for snap
 	SnapUnInstaller = "snap-uninstall.sh"
+
+	// installer script for containers
+	ContainerInstaller = "container-install.sh"
+	// uninstaller script for containers
+	ContainerUnInstaller = "container-uninstall.sh"
 )
 
 var possiblyUsingSystemD = map[string]bool{
@@ -270,15 +284,9 @@ func (util *Utility) CreateInstanceContext(log log.T) (context *InstanceContext,
 		UnInstaller = UninstallScript
 	} else if strings.Contains(platformName, PlatformUbuntu) {
 		platformName = PlatformUbuntu
-		if isSnap, err := isAgentInstalledUsingSnap(log); err == nil && isSnap {
-			installerName = PlatformUbuntuSnap
-			Installer = SnapInstaller
-			UnInstaller = SnapUnInstaller
-		} else {
-			installerName = PlatformUbuntu
-			Installer = DebInstaller
-			UnInstaller = DebUnInstaller
-		}
+		installerName = PlatformUbuntu
+		Installer = DebInstaller
+		UnInstaller = DebUnInstaller
 	} else if strings.Contains(platformName, PlatformCentOS) {
 		platformName = PlatformCentOS
 		installerName = PlatformLinux
@@ -307,6 +315,13 @@ func (util *Utility) CreateInstanceContext(log log.T) (context *InstanceContext,
 		UnInstaller = UninstallScript
 	}
 
+	// snaps and containers replace the installation packages and scripts of the platform
+	installBackend = detectInstallBackend(log, platformName, Installer, UnInstaller)
+	installerName = installBackend.InstallerName(installerName)
+	Installer = installBackend.Installer()
+	UnInstaller = installBackend.UnInstaller()
+	log.Debugf("Using the %v installation backend", installBackend.Name())
+
 	if platformVersion, err = getPlatformVersion(log); err != nil {
 		return
 	}
@@ -496,36 +511,7 @@ func (util *Utility) NewExeCommandOutput(
 
 // IsServiceRunning returns is service running
 func (util *Utility) IsServiceRunning(log log.T, i *InstanceContext) (result bool, err error) {
-	commandOutput := []byte{}
-	expectedOutput := ""
-	isSystemD := false
-
-	// isSystemD will always be false for Windows
-	if isSystemD, err = i.IsPlatformUsingSystemD(log); err != nil {
-		return false, err
-	}
-
-	if isSystemD {
-		expectedOutput = "Active: active (running)"
-		if commandOutput, err = execCommand("systemctl", "status", "amazon-ssm-agent.service").Output(); err != nil {
-			//test snap service enabled
-			if commandOutput, err = execCommand("systemctl", "status", "snap.amazon-ssm-agent.amazon-ssm-agent.service").Output(); err != nil {
-				return false, err
-			}
-		}
-	} else {
-		expectedOutput = agentExpectedStatus()
-		if commandOutput, err = agentStatusOutput(); err != nil {
-			return false, err
-		}
-	}
-
-	agentStatus := strings.TrimSpace(string(commandOutput))
-	if strings.Contains(agentStatus, expectedOutput) {
-		return true, nil
-	}
-
-	return false, nil
+	return installBackend.IsAgentRunning(log, i)
 }
 
 // WaitForServiceToStart wait for service to start and returns is service started
@@ -740,69 +726,29 @@ func setExeOutErr(
 	return stdoutWriter, stderrWriter, nil
 }
 
+// CompareVersion compares two Major.Minor.Build.Patch agent versions
 func CompareVersion(versionOne string, versionTwo string) (int, error) {
-	majorOne, minorOne, buildOne, patchOne, err := parseVersion(versionOne)
+	one, err := parseVersion(versionOne)
 	if err != nil {
 		return 0, err
 	}
 
-	majorTwo, minorTwo, buildTwo, patchTwo, err := parseVersion(versionTwo)
+	two, err := parseVersion(versionTwo)
 	if err != nil {
 		return 0, err
 	}
 
-	if majorOne < majorTwo {
-		return -1, nil
-	} else if majorOne > majorTwo {
-		return 1, nil
-	}
-
-	if minorOne < minorTwo {
-		return -1, nil
-	} else if minorOne > minorTwo {
-		return 1, nil
-	}
-
-	if buildOne < buildTwo {
-		return -1, nil
-	} else if buildOne > buildTwo {
-		return 1, nil
-	}
-
-	if patchOne < patchTwo {
-		return -1, nil
-	} else if patchOne > patchTwo {
-		return 1, nil
-	}
-
-	return 0, nil
+	return one.Compare(two), nil
 }
 
-func parseVersion(version string) (uint64, uint64, uint64, uint64, error) {
-	parts := strings.SplitN(version, ".", 4)
-	if len(parts) != 4 {
-		return 0, 0, 0, 0, errors.New("No Major.Minor.Build.Patch elements found")
-	}
-
-	major, err := strconv.ParseUint(parts[0], 10, 64)
-	if err != nil {
-		return 0, 0, 0, 0, err
-	}
-
-	minor, err := strconv.ParseUint(parts[1], 10, 64)
-	if err != nil {
-		return 0, 0, 0, 0, err
-	}
-
-	build, err := strconv.ParseUint(parts[2], 10, 64)
+// parseVersion parses an agent version made of Major.Minor.Build.Patch elements
+func parseVersion(version string) (versionutil.Version, error) {
+	parsed, err := versionutil.Parse(version)
 	if err != nil {
-		return 0, 0, 0, 0, err
+		return parsed, err
 	}
-
-	patch, err := strconv.ParseUint(parts[3], 10, 64)
-	if err != nil {
-		return 0, 0, 0, 0, err
+	if len(parsed.Components) != 4 || parsed.PreRelease != "" {
+		return parsed, errors.New("No Major.Minor.Build.Patch elements found")
 	}
-
-	return major, minor, build, patch, nil
+	return parsed, nil
 }
@@ -385,6 +385,7 @@ func TestIsServiceRunning(t *testing.T) {
 
 	// Stub exec.Command
 	execCommand = fakeExecCommand
+	installBackend = &packageBackend{}
 
 	for _, test := range testCases {
 		result, _ := util.IsServiceRunning(logger, &test.context)
@@ -405,6 +406,7 @@ func TestIsServiceRunningWithErrorMessageFromCommandExec(t *testing.T) {
 
 	// Stub exec.Command
 	execCommand = fakeExecCommandWithError
+	installBackend = &packageBackend{}
 
 	for _, test := range testCases {
 		_, err := util.IsServiceRunning(logger, &test.context)
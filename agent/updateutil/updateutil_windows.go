@@ -47,6 +47,12 @@ const (
 	// TargetHashCmd represents the command argument for target hash value
 	TargetHashCmd = "target-hash"
 
+	// TargetDeltaLocationCmd represents the command argument for the location of the delta from source to target
+	TargetDeltaLocationCmd = "target-delta-location"
+
+	// TargetDeltaHashCmd represents the command argument for the delta hash value
+	TargetDeltaHashCmd = "target-delta-hash"
+
 	// PackageNameCmd represents the command argument for package name
 	PackageNameCmd = "package-name"
 
@@ -64,11 +70,23 @@ const (
 
 	// OutputBucketNameCmd represents the command argument for output bucket name
 	OutputBucketNameCmd = "output-bucket"
+
+	// AllowDowngradeCmd represents the command argument allowing the update to downgrade below the safety rails
+	AllowDowngradeCmd = "allow-downgrade"
+
+	// OutputLogGroupCmd represents the command argument for the CloudWatch log group of the output
+	OutputLogGroupCmd = "output-loggroup"
+
+	// OutputLogStreamCmd represents the command argument for the CloudWatch log stream of the update progress
+	OutputLogStreamCmd = "output-logstream"
 )
 
 const (
 	// CompressFormat represents the compress format for windows platform
 	CompressFormat = "zip"
+
+	// LegacyUpdaterArtifactsRoot is empty, the update root of windows did not change
+	LegacyUpdaterArtifactsRoot = ""
 )
 
 const (
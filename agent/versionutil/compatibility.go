@@ -0,0 +1,64 @@
+package versionutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// platformCompatibility restricts the agent versions supported on some versions of a platform
+type platformCompatibility struct {
+	// platform is the lower case platform name, as in the update instance context
+	platform string
+	// description names the platform versions in error messages
+	description string
+	// platformVersions are the platform versions the restriction applies to
+	platformVersions *Constraint
+	// agentVersions are the agent versions supported on these platform versions
+	agentVersions *Constraint
+}
+
+// compatibilityMatrix lists the agent versions supported by platform, platforms not listed support all versions.
+var compatibilityMatrix = []platformCompatibility{
+	newPlatformCompatibility("centos", "CentOS", "*", ">=1.0.187.0"),
+	// Ubuntu 14.04 and earlier, and Windows Server 2003 (Windows 5.2) are supported up to the 2.x agents
+	newPlatformCompatibility("ubuntu", "Ubuntu 14.04 and earlier", "<=14.04", "<3.0.0.0"),
+	newPlatformCompatibility("windows", "Windows Server 2003", "=5.2.x", "<3.0.0.0"),
+}
+
+// newPlatformCompatibility creates an entry of the compatibility matrix from its constraint expressions
+func newPlatformCompatibility(platform string, description string, platformVersions string, agentVersions string) platformCompatibility {
+	return platformCompatibility{
+		platform:         platform,
+		description:      description,
+		platformVersions: mustParseConstraint(platformVersions),
+		agentVersions:    mustParseConstraint(agentVersions),
+	}
+}
+
+// mustParseConstraint parses a constraint of the compatibility matrix, which are known to be valid
+func mustParseConstraint(expression string) *Constraint {
+	constraint, err := ParseConstraint(expression)
+	if err != nil {
+		panic(err)
+	}
+	return constraint
+}
+
+// ValidatePlatformSupport returns an error if agentVersion is not supported on the given version of the platform,
+// platform being a platform name such as "ubuntu" or "windows" and platformVersion its version as detected by
+// the platform package.
+func ValidatePlatformSupport(platform string, platformVersion string, agentVersion string) error {
+	for _, entry := range compatibilityMatrix {
+		if entry.platform != strings.ToLower(platform) || !entry.platformVersions.Match(platformVersion) {
+			continue
+		}
+		if !entry.agentVersions.Match(agentVersion) {
+			return fmt.Errorf("Agent version %v is unsupported on %v (platform version %v), supported versions are %v",
+				agentVersion,
+				entry.description,
+				platformVersion,
+				entry.agentVersions)
+		}
+	}
+	return nil
+}
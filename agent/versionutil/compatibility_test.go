@@ -0,0 +1,37 @@
+package versionutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePlatformSupport(t *testing.T) {
+	tests := []struct {
+		platform        string
+		platformVersion string
+		agentVersion    string
+		supported       bool
+	}{
+		{"centos", "6.5", "1.0.187.0", true},
+		{"centos", "7", "1.0.0.0", false},
+		{"ubuntu", "14.04", "2.3.0.0", true},
+		{"ubuntu", "14.04", "3.0.0.0", false},
+		{"ubuntu", "12.04", "3.0.655.0", false},
+		{"ubuntu", "16.04", "3.0.655.0", true},
+		{"windows", "5.2.3790", "2.3.0.0", true},
+		{"windows", "5.2.3790", "3.0.655.0", false},
+		{"Windows", "5.2.3790", "3.0.655.0", false},
+		{"windows", "6.1.7601", "3.0.655.0", true},
+		{"red hat", "6.5", "1.0.0.0", true},
+	}
+	for _, test := range tests {
+		err := ValidatePlatformSupport(test.platform, test.platformVersion, test.agentVersion)
+		assert.Equal(t, test.supported, err == nil, "%s %s %s: %v", test.platform, test.platformVersion, test.agentVersion, err)
+	}
+}
+
+func TestValidatePlatformSupportMessage(t *testing.T) {
+	err := ValidatePlatformSupport("ubuntu", "14.04", "3.0.0.0")
+	assert.EqualError(t, err, "Agent version 3.0.0.0 is unsupported on Ubuntu 14.04 and earlier (platform version 14.04), supported versions are <3.0.0.0")
+}
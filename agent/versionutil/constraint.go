@@ -0,0 +1,209 @@
+package versionutil
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Constraint is a set of version ranges parsed from an expression such as ">=2.3.0, <3.0.0 || =1.9.x".
+// Comparisons separated by commas or spaces must all match, || separates alternatives of which one must match.
+// Comparisons use the operators =, !=, >, >=, <, <= as well as ~ (same minor version) and ^ (same major version),
+// = when omitted. Trailing x, X or * components of a version match any value.
+// Versions are compared with Compare so that agent versions with four components are supported.
+type Constraint struct {
+	expression   string
+	alternatives [][]comparison
+}
+
+// comparison matches the versions between min and max, or outside of them when negated. Empty bounds are unbounded.
+type comparison struct {
+	min          string
+	minInclusive bool
+	max          string
+	maxInclusive bool
+	negated      bool
+}
+
+// operators are the comparison operators, the longer ones first so that they are matched before their prefixes.
+var operators = []string{">=", "<=", "!=", "==", "=", ">", "<", "~", "^"}
+
+var constraintVersionPattern = regexp.MustCompile(`^[0-9A-Za-z*+-]+(\.[0-9A-Za-z*+-]+)*$`)
+
+// ParseConstraint parses a version constraint expression.
+func ParseConstraint(expression string) (*Constraint, error) {
+	constraint := &Constraint{expression: expression}
+	for _, alternative := range strings.Split(expression, "||") {
+		fields := strings.FieldsFunc(alternative, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty alternative", expression)
+		}
+
+		var comparisons []comparison
+		for i := 0; i < len(fields); i++ {
+			text := fields[i]
+			// operators may be separated from their version, as in ">= 2.3"
+			if isOperator(text) && i+1 < len(fields) {
+				i++
+				text += fields[i]
+			}
+			c, err := parseComparison(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %v", expression, err)
+			}
+			comparisons = append(comparisons, c)
+		}
+		constraint.alternatives = append(constraint.alternatives, comparisons)
+	}
+	return constraint, nil
+}
+
+// Match returns true if version satisfies the constraint.
+func (constraint *Constraint) Match(version string) bool {
+	for _, comparisons := range constraint.alternatives {
+		matched := true
+		for _, c := range comparisons {
+			if !c.match(version) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the expression the constraint was parsed from.
+func (constraint *Constraint) String() string {
+	return constraint.expression
+}
+
+// match returns true if version is within the bounds of the comparison, false when negated.
+func (c comparison) match(version string) bool {
+	within := true
+	if c.min != "" {
+		result := Compare(version, c.min, false)
+		within = result > 0 || (result == 0 && c.minInclusive)
+	}
+	if within && c.max != "" {
+		result := Compare(version, c.max, false)
+		within = result < 0 || (result == 0 && c.maxInclusive)
+	}
+	return within != c.negated
+}
+
+// isOperator returns true if text is a comparison operator.
+func isOperator(text string) bool {
+	for _, operator := range operators {
+		if text == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// parseComparison parses an operator followed by a version into the bounds of the versions it matches.
+func parseComparison(text string) (c comparison, err error) {
+	operator := "="
+	for _, candidate := range operators {
+		if strings.HasPrefix(text, candidate) {
+			operator = candidate
+			text = text[len(candidate):]
+			break
+		}
+	}
+	if !constraintVersionPattern.MatchString(text) {
+		return c, fmt.Errorf("invalid version %q", text)
+	}
+
+	// Versions ending with wildcards stand for the range of versions starting with the components before them.
+	components := strings.Split(text, ".")
+	wildcard := len(components)
+	for i, component := range components {
+		if isWildcard(component) {
+			wildcard = i
+			break
+		}
+	}
+	for _, component := range components[wildcard:] {
+		if !isWildcard(component) {
+			return c, fmt.Errorf("invalid version %q: wildcards must end the version", text)
+		}
+	}
+	prefix := components[:wildcard]
+	if len(prefix) == 0 {
+		if operator != "=" && operator != "==" && operator != "!=" {
+			return c, fmt.Errorf("invalid version %q: %s needs a version", text, operator)
+		}
+		// any version
+		return comparison{negated: operator == "!="}, nil
+	}
+	isRange := wildcard < len(components)
+	lower := strings.Join(prefix, ".")
+
+	switch operator {
+	case "=", "==", "!=":
+		c = comparison{min: lower, minInclusive: true, max: lower, maxInclusive: true, negated: operator == "!="}
+		if isRange {
+			c.maxInclusive = false
+			c.max, err = increment(prefix, len(prefix)-1)
+		}
+	case ">":
+		c = comparison{min: lower}
+		if isRange {
+			c.minInclusive = true
+			c.min, err = increment(prefix, len(prefix)-1)
+		}
+	case ">=":
+		c = comparison{min: lower, minInclusive: true}
+	case "<":
+		c = comparison{max: lower}
+	case "<=":
+		c = comparison{max: lower, maxInclusive: true}
+		if isRange {
+			c.maxInclusive = false
+			c.max, err = increment(prefix, len(prefix)-1)
+		}
+	case "~":
+		// same minor version, same major version when only the major version is given
+		c = comparison{min: lower, minInclusive: true}
+		if len(prefix) > 1 {
+			c.max, err = increment(prefix, 1)
+		} else {
+			c.max, err = increment(prefix, 0)
+		}
+	case "^":
+		// same major version, or same first non zero component for versions starting with 0
+		c = comparison{min: lower, minInclusive: true}
+		significant := len(prefix) - 1
+		for i, component := range prefix {
+			if n, convErr := strconv.Atoi(component); convErr != nil || n != 0 {
+				significant = i
+				break
+			}
+		}
+		c.max, err = increment(prefix, significant)
+	}
+	return c, err
+}
+
+// isWildcard returns true if the version component matches any value.
+func isWildcard(component string) bool {
+	return component == "x" || component == "X" || component == "*"
+}
+
+// increment returns the version made of the components up to index, the one at index incremented.
+func increment(components []string, index int) (string, error) {
+	n, err := strconv.Atoi(components[index])
+	if err != nil {
+		return "", fmt.Errorf("version component %q is not numeric", components[index])
+	}
+	incremented := append(append([]string{}, components[:index]...), strconv.Itoa(n+1))
+	return strings.Join(incremented, "."), nil
+}
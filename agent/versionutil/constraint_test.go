@@ -0,0 +1,46 @@
+package versionutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraintMatch(t *testing.T) {
+	tests := []struct {
+		expression string
+		matching   []string
+		others     []string
+	}{
+		{">=2.3.0, <3.0.0 || =1.9.x", []string{"2.3.0", "2.3.0.0", "2.9.9", "1.9.0", "1.9.12"}, []string{"2.2.9", "3.0.0", "1.10.0", "1.8"}},
+		{"2.3.0.1", []string{"2.3.0.1", "2.3.0.01"}, []string{"2.3.0.2", "2.3"}},
+		{"!=2.3.x", []string{"2.2.1", "2.4.0"}, []string{"2.3.0", "2.3.7.1"}},
+		{"> 1.2.x <= 1.4.*", []string{"1.3.0", "1.4.9"}, []string{"1.2.9", "1.5"}},
+		{">1.2 <1.4", []string{"1.2.1", "1.3"}, []string{"1.2", "1.4.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.10"}, []string{"1.2.2", "1.3.0"}},
+		{"~1", []string{"1.0", "1.9.9"}, []string{"0.9", "2.0.0"}},
+		{"^2.3.1", []string{"2.3.1", "2.9"}, []string{"2.3.0", "3.0.0"}},
+		{"^0.2.3", []string{"0.2.5"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"*", []string{"0.0.1", "3.0.0.2000"}, []string{}},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.expression)
+		assert.NoError(t, err, test.expression)
+		assert.Equal(t, test.expression, constraint.String())
+		for _, version := range test.matching {
+			assert.True(t, constraint.Match(version), "%s should match %s", test.expression, version)
+		}
+		for _, version := range test.others {
+			assert.False(t, constraint.Match(version), "%s should not match %s", test.expression, version)
+		}
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, expression := range []string{"", ">=1.0 ||", ">=1.0 <", "1.x.2", ">*", "=a.x", "1.0;2.0"} {
+		_, err := ParseConstraint(expression)
+		assert.Error(t, err, expression)
+	}
+}
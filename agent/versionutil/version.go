@@ -0,0 +1,96 @@
+package versionutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a version parsed into its numeric components and pre-release, as in 3.0.655.0-beta1
+type Version struct {
+	// Components are the numeric dot separated components of the version
+	Components []int
+	// PreRelease is the pre-release of the version, empty for releases
+	PreRelease string
+	// Original is the string the version was parsed from
+	Original string
+}
+
+// Parse parses a version made of numeric components optionally followed by a pre-release and build metadata.
+func Parse(version string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid version %q", version)
+	}
+
+	parts := strings.Split(match[1], ".")
+	components := make([]int, len(parts))
+	for i, part := range parts {
+		component, err := strconv.Atoi(part)
+		if err != nil || component < 0 {
+			return Version{}, fmt.Errorf("invalid version %q: component %q is not numeric", version, part)
+		}
+		components[i] = component
+	}
+	return Version{Components: components, PreRelease: match[2], Original: version}, nil
+}
+
+// Major returns the first component of the version
+func (v Version) Major() int {
+	return v.component(0)
+}
+
+// Minor returns the second component of the version, 0 if it has none
+func (v Version) Minor() int {
+	return v.component(1)
+}
+
+// Compare returns -1 if v < other, 0 if they are equal and 1 if v > other.
+// Missing components are 0 (1.0.0.0 == 1) and pre-releases are lower than their release.
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v.Components) || i < len(other.Components); i++ {
+		if thisComponent, otherComponent := v.component(i), other.component(i); thisComponent < otherComponent {
+			return -1
+		} else if thisComponent > otherComponent {
+			return 1
+		}
+	}
+
+	if result := comparePreRelease(v.PreRelease, other.PreRelease); result < 0 {
+		return -1
+	} else if result > 0 {
+		return 1
+	}
+	return 0
+}
+
+// LessThan returns true if v is lower than other
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// IncrementPatch returns the release following v, with its last component incremented
+// and without pre-release, as in 3.0.655.1 for 3.0.655.0-beta1.
+func (v Version) IncrementPatch() Version {
+	components := append([]int{}, v.Components...)
+	components[len(components)-1]++
+
+	parts := make([]string, len(components))
+	for i, component := range components {
+		parts[i] = strconv.Itoa(component)
+	}
+	return Version{Components: components, Original: strings.Join(parts, ".")}
+}
+
+// String returns the string the version was parsed from
+func (v Version) String() string {
+	return v.Original
+}
+
+// component returns the component at index, 0 if the version has fewer components
+func (v Version) component(index int) int {
+	if index < len(v.Components) {
+		return v.Components[index]
+	}
+	return 0
+}
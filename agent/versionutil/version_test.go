@@ -0,0 +1,69 @@
+package versionutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	version, err := Parse("3.0.655.0-beta1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 0, 655, 0}, version.Components)
+	assert.Equal(t, "beta1", version.PreRelease)
+	assert.Equal(t, "3.0.655.0-beta1+build.5", version.String())
+	assert.Equal(t, 3, version.Major())
+	assert.Equal(t, 0, version.Minor())
+
+	version, err = Parse("2")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, version.Components)
+	assert.Equal(t, 0, version.Minor())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, version := range []string{"", "v1.0", "1..0", "1.a.0", "1.0.", "-1.0"} {
+		_, err := Parse(version)
+		assert.Error(t, err, version)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		lesser  string
+		greater string
+	}{
+		{"1.0.0.0", "2.0.0.0"},
+		{"2.1.3.0", "2.1.12.0"},
+		{"2.1.10.100", "2.1.10.1000"},
+		{"3.0.655.0-beta1", "3.0.655.0"},
+		{"3.0.655.0-beta.2", "3.0.655.0-beta.10"},
+		{"3.0.655", "3.0.655.1"},
+	}
+	for _, test := range tests {
+		lesser, _ := Parse(test.lesser)
+		greater, _ := Parse(test.greater)
+		assert.Equal(t, -1, lesser.Compare(greater), "%s < %s", test.lesser, test.greater)
+		assert.Equal(t, 1, greater.Compare(lesser), "%s > %s", test.greater, test.lesser)
+		assert.True(t, lesser.LessThan(greater))
+		assert.False(t, greater.LessThan(lesser))
+	}
+
+	one, _ := Parse("1.0.0.0+build.1")
+	two, _ := Parse("1")
+	assert.Equal(t, 0, one.Compare(two))
+	assert.False(t, one.LessThan(two))
+}
+
+func TestIncrementPatch(t *testing.T) {
+	version, _ := Parse("3.0.655.0")
+	next := version.IncrementPatch()
+	assert.Equal(t, []int{3, 0, 655, 1}, next.Components)
+	assert.Equal(t, "3.0.655.1", next.String())
+	assert.Equal(t, []int{3, 0, 655, 0}, version.Components)
+
+	version, _ = Parse("1.2.3-rc.1+build")
+	next = version.IncrementPatch()
+	assert.Equal(t, "1.2.4", next.String())
+	assert.Equal(t, "", next.PreRelease)
+}
@@ -1,6 +1,7 @@
 package versionutil
 
 import (
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -23,9 +24,15 @@ func (s ByVersion) Less(i, j int) bool {
 	return Compare(s[i], s[j], true) < 0
 }
 
+// versionPattern splits versions starting with a digit into their components, pre-release and build metadata,
+// as in 3.0.655.0-beta1+build.5
+var versionPattern = regexp.MustCompile(`^([0-9](?:[^+]*?[0-9A-Za-z])?)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
 // Compare returns 0 if two versions are equal a negative number if this < other and a positive number if this > other
 // If this and other are both compliant with semver, then semver sorting rules are used
 // Otherwise the versions are compared component-by-component, numerically if both are numeric
+// Pre-release suffixes and build metadata follow the semver precedence rules in both cases:
+// 3.0.655.0-beta1 < 3.0.655.0-beta2 < 3.0.655.0 and build metadata is ignored.
 // If !strictSort insignificant trailing components are ignored (1.0.0.0 == 1) and the alpha comparison is case-insensitive
 func Compare(this string, other string, strictSort bool) int {
 	// If both versions are compliant with SemVer, use the SemVer comparison rules
@@ -35,9 +42,25 @@ func Compare(this string, other string, strictSort bool) int {
 		return thisSemVer.Compare(*otherSemVer)
 	}
 
-	thisVersion := this
-	otherVersion := other
+	thisVersion, thisPreRelease := splitVersion(this)
+	otherVersion, otherPreRelease := splitVersion(other)
+	if result := compareComponents(thisVersion, otherVersion, strictSort); result != 0 {
+		return result
+	}
+	return comparePreRelease(thisPreRelease, otherPreRelease)
+}
+
+// splitVersion returns the components and the pre-release of version, without its build metadata.
+// Versions not made of components followed by a pre-release and build metadata are returned as they are.
+func splitVersion(version string) (components string, preRelease string) {
+	if match := versionPattern.FindStringSubmatch(version); match != nil {
+		return match[1], match[2]
+	}
+	return version, ""
+}
 
+// compareComponents compares the dot separated components of two versions
+func compareComponents(thisVersion string, otherVersion string, strictSort bool) int {
 	if !strictSort {
 		// Unless we need a strict ordering, trailing 0 components of version should be ignored
 		thisVersion = normalizeForCompare(thisVersion)
@@ -91,3 +114,56 @@ func normalizeForCompare(version string) string {
 	}
 	return version[0:lenSignificant]
 }
+
+// comparePreRelease compares pre-releases by semver precedence: a version without pre-release is greater than with one,
+// numeric identifiers are compared numerically and are lower than alphanumeric identifiers, compared as text,
+// and a larger set of identifiers is greater when the ones before are equal.
+func comparePreRelease(this string, other string) int {
+	if this == other {
+		return 0
+	} else if this == "" {
+		return 1
+	} else if other == "" {
+		return -1
+	}
+
+	thisIdentifiers := strings.Split(this, ".")
+	otherIdentifiers := strings.Split(other, ".")
+	for i := 0; i < len(thisIdentifiers) && i < len(otherIdentifiers); i++ {
+		if result := compareIdentifiers(thisIdentifiers[i], otherIdentifiers[i]); result != 0 {
+			return result
+		}
+	}
+	return len(thisIdentifiers) - len(otherIdentifiers)
+}
+
+// compareIdentifiers compares two pre-release identifiers
+func compareIdentifiers(this string, other string) int {
+	thisNumeric := isNumericIdentifier(this)
+	otherNumeric := isNumericIdentifier(other)
+	switch {
+	case thisNumeric && otherNumeric:
+		// compare numbers of any size by their significant digits
+		this = strings.TrimLeft(this, "0")
+		other = strings.TrimLeft(other, "0")
+		if len(this) != len(other) {
+			return len(this) - len(other)
+		}
+		return strings.Compare(this, other)
+	case thisNumeric:
+		return -1
+	case otherNumeric:
+		return 1
+	}
+	return strings.Compare(this, other)
+}
+
+// isNumericIdentifier returns true if the identifier is only made of digits
+func isNumericIdentifier(identifier string) bool {
+	for _, c := range identifier {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return identifier != ""
+}
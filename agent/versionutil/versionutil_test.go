@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"sort"
+	"strings"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,9 +21,103 @@ func TestCompareSemVer(t *testing.T) {
 	assert.Equal(t, 0, Compare("3.0.0+foo", "3.0.0+bar", false))
 	assert.Equal(t, 0, Compare("3.0.0+foo-bar", "3.0.0+bar-foo", false))
 
-	// SemVer and non-SemVer compliant versions
-	assert.True(t, Compare("3.0.0+foo", "3.0", false) > 0)
-	assert.True(t, Compare("3.0.0+foo", "3.0.0.1", false) > 0)
+	// SemVer and non-SemVer compliant versions, build metadata is ignored
+	assert.Equal(t, 0, Compare("3.0.0+foo", "3.0", false))
+	assert.True(t, Compare("3.0.0+foo", "3.0.0.1", false) < 0)
+}
+
+func TestComparePreRelease(t *testing.T) {
+	tests := []struct {
+		lesser  string
+		greater string
+	}{
+		// pre-releases are lower than the release
+		{"3.0.655.0-beta1", "3.0.655.0"},
+		{"3.0.655.0-beta1", "3.0.655"},
+		{"3.0.655-rc.1", "3.0.655.0"},
+		{"1.0-alpha", "1.0"},
+		// components take precedence over pre-releases
+		{"3.0.654.0", "3.0.655.0-beta1"},
+		{"3.0.655.0-rc.1", "3.0.655.1-alpha"},
+		{"1.0-beta", "1.0.0.1-alpha"},
+		// identifiers are compared in order
+		{"3.0.655.0-beta1", "3.0.655.0-beta2"},
+		{"3.0.655.0-beta10", "3.0.655.0-beta2"},
+		{"3.0.655.0-alpha", "3.0.655.0-alpha.1"},
+		{"3.0.655.0-alpha.1", "3.0.655.0-alpha.beta"},
+		{"3.0.655.0-alpha.beta", "3.0.655.0-beta"},
+		{"3.0.655.0-beta", "3.0.655.0-beta.2"},
+		{"3.0.655.0-beta.2", "3.0.655.0-beta.11"},
+		{"3.0.655.0-beta.11", "3.0.655.0-rc.1"},
+		{"1.0.0.0-2", "1.0.0.0-10"},
+		{"1.0.0.0-99999999999999999999", "1.0.0.0-100000000000000000000"},
+		{"1.0.0.0-rc-1", "1.0.0.0-rc-2"},
+		// numeric identifiers are lower than alphanumeric ones
+		{"1.0.0.0-1", "1.0.0.0-a"},
+		{"1.0.0.0-rc.9", "1.0.0.0-rc.a"},
+		// the build metadata of the greater version doesn't matter
+		{"3.0.655.0-beta1", "3.0.655.0+build.7"},
+		{"3.0.655.0-beta1+zzz", "3.0.655.0-beta2+aaa"},
+	}
+
+	for _, test := range tests {
+		strictSorts := []bool{false}
+		// versions with fewer components are lower in a strict sort
+		lesserComponents, _ := splitVersion(test.lesser)
+		greaterComponents, _ := splitVersion(test.greater)
+		if strings.Count(lesserComponents, ".") == strings.Count(greaterComponents, ".") {
+			strictSorts = append(strictSorts, true)
+		}
+		for _, strictSort := range strictSorts {
+			assert.True(t, Compare(test.lesser, test.greater, strictSort) < 0, "%s < %s, strict %v", test.lesser, test.greater, strictSort)
+			assert.True(t, Compare(test.greater, test.lesser, strictSort) > 0, "%s > %s, strict %v", test.greater, test.lesser, strictSort)
+		}
+	}
+}
+
+func TestCompareIgnoresBuildMetadata(t *testing.T) {
+	tests := []struct {
+		this  string
+		other string
+	}{
+		{"3.0.655.0+build.1", "3.0.655.0"},
+		{"3.0.655.0+build.1", "3.0.655.0+build.2"},
+		{"3.0.655.0-beta1+build.1", "3.0.655.0-beta1"},
+		{"3.0.655.0-beta.01", "3.0.655.0-beta.1"},
+		{"3.0.655-beta1", "3.0.655.0-beta1"},
+		{"1.0+exp.sha.5114f85", "1.0.0.0"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, 0, Compare(test.this, test.other, false), "%s == %s", test.this, test.other)
+		assert.Equal(t, 0, Compare(test.other, test.this, false), "%s == %s", test.other, test.this)
+	}
+}
+
+func TestSplitVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		components string
+		preRelease string
+	}{
+		{"3.0.655.0", "3.0.655.0", ""},
+		{"3.0.655.0-beta1", "3.0.655.0", "beta1"},
+		{"3.0.655.0-beta.1+build.5", "3.0.655.0", "beta.1"},
+		{"3.0.655.0+build-5", "3.0.655.0", ""},
+		{"1.0.0-rc-1", "1.0.0", "rc-1"},
+		// not versions with pre-releases
+		{"1.-1", "1.-1", ""},
+		{"a-b", "a-b", ""},
+		{"1.0-", "1.0-", ""},
+		{"1.0+", "1.0+", ""},
+		{"", "", ""},
+	}
+
+	for _, test := range tests {
+		components, preRelease := splitVersion(test.version)
+		assert.Equal(t, test.components, components, test.version)
+		assert.Equal(t, test.preRelease, preRelease, test.version)
+	}
 }
 
 func TestCompareVersion(t *testing.T) {
@@ -64,8 +159,8 @@ func TestNormalizeForCompare(t *testing.T) {
 }
 
 func TestSort(t *testing.T) {
-	actual := []string{"4.0", "4.0.1", "3.7", "4.0", "3.8", "2.0.1+asdf.qwerty"}
-	expected := []string{"2.0.1+asdf.qwerty", "3.7", "3.8", "4.0", "4.0", "4.0.1"}
+	actual := []string{"4.0", "4.0.1", "3.7", "4.0", "3.8", "2.0.1+asdf.qwerty", "4.0.1.0", "4.0.1.0-beta.2", "4.0.1.0-beta.10", "4.0.1.0-alpha"}
+	expected := []string{"2.0.1+asdf.qwerty", "3.7", "3.8", "4.0", "4.0", "4.0.1", "4.0.1.0-alpha", "4.0.1.0-beta.2", "4.0.1.0-beta.10", "4.0.1.0"}
 	sort.Sort(ByVersion(actual))
 	assert.Equal(t, actual, expected)
 }